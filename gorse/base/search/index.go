@@ -74,31 +74,49 @@ type DictionaryVector struct {
 	isHidden bool
 	terms    []string
 	indices  []int32
+	decays   []float32
 	values   []float32
 	norm     float32
 }
 
 func NewDictionaryVector(indices []int32, values []float32, terms []string, isHidden bool) *DictionaryVector {
-	sort.Sort(sortutil.Int32Slice(indices))
-	var norm float32
-	for _, i := range indices {
-		norm += values[i]
+	return NewDecayedDictionaryVector(indices, nil, values, terms, isHidden)
+}
+
+// NewDecayedDictionaryVector creates a dictionary vector whose k-th index is decayed by decays[k]. Indices aren't
+// decayed if decays is nil.
+func NewDecayedDictionaryVector(indices []int32, decays, values []float32, terms []string, isHidden bool) *DictionaryVector {
+	if decays == nil {
+		sort.Sort(sortutil.Int32Slice(indices))
+	} else {
+		sort.Sort(&decayedIndices{indices: indices, decays: decays})
 	}
-	norm = math32.Sqrt(norm)
-	return &DictionaryVector{
+	v := &DictionaryVector{
 		isHidden: isHidden,
 		terms:    terms,
 		indices:  indices,
+		decays:   decays,
 		values:   values,
-		norm:     norm,
 	}
+	for k, i := range indices {
+		v.norm += values[i] * v.decay(k) * v.decay(k)
+	}
+	v.norm = math32.Sqrt(v.norm)
+	return v
+}
+
+func (v *DictionaryVector) decay(k int) float32 {
+	if v.decays == nil {
+		return 1
+	}
+	return v.decays[k]
 }
 
 func (v *DictionaryVector) Dot(vector *DictionaryVector) (float32, float32) {
 	i, j, sum, common := 0, 0, float32(0), float32(0)
 	for i < len(v.indices) && j < len(vector.indices) {
 		if v.indices[i] == vector.indices[j] {
-			sum += v.values[v.indices[i]]
+			sum += v.values[v.indices[i]] * v.decay(i) * vector.decay(j)
 			common++
 			i++
 			j++
@@ -134,6 +152,24 @@ func (v *DictionaryVector) IsHidden() bool {
 	return v.isHidden
 }
 
+type decayedIndices struct {
+	indices []int32
+	decays  []float32
+}
+
+func (d *decayedIndices) Len() int {
+	return len(d.indices)
+}
+
+func (d *decayedIndices) Less(i, j int) bool {
+	return d.indices[i] < d.indices[j]
+}
+
+func (d *decayedIndices) Swap(i, j int) {
+	d.indices[i], d.indices[j] = d.indices[j], d.indices[i]
+	d.decays[i], d.decays[j] = d.decays[j], d.decays[i]
+}
+
 type CentroidVector interface {
 	Distance(vector Vector) float32
 }
@@ -150,8 +186,8 @@ func (v DictionaryVector) Centroid(vectors []Vector, indices []int32) CentroidVe
 		if !isDictVector {
 			panic(fmt.Sprintf("unexpected vector type: %v", reflect.TypeOf(vector)))
 		}
-		for _, i := range vector.indices {
-			data[i] += math32.Sqrt(vector.values[i])
+		for k, i := range vector.indices {
+			data[i] += math32.Sqrt(vector.values[i]) * vector.decay(k)
 		}
 	}
 	var norm float32
@@ -173,9 +209,9 @@ func (v *DictionaryCentroidVector) Distance(vector Vector) float32 {
 	if dictVector, isDictVec := vector.(*DictionaryVector); !isDictVec {
 		panic(fmt.Sprintf("unexpected vector type: %v", reflect.TypeOf(vector)))
 	} else {
-		for _, i := range dictVector.indices {
+		for k, i := range dictVector.indices {
 			if val, exist := v.data[i]; exist {
-				sum += val * math32.Sqrt(v.data[i])
+				sum += val * math32.Sqrt(v.data[i]) * dictVector.decay(k)
 				common++
 			}
 		}
@@ -26,6 +26,20 @@ import (
 	"github.com/zhenghaoz/gorse/model/ranking"
 )
 
+func TestDecayedDictionaryVector(t *testing.T) {
+	values := []float32{1, 1, 1}
+	a := NewDecayedDictionaryVector([]int32{2, 0, 1}, []float32{0.5, 1, 1}, values, nil, false)
+	assert.Equal(t, []int32{0, 1, 2}, a.indices)
+	assert.Equal(t, []float32{1, 1, 0.5}, a.decays)
+	// recent common indices contribute more than stale ones
+	b := NewDecayedDictionaryVector([]int32{0}, []float32{1}, values, nil, false)
+	c := NewDecayedDictionaryVector([]int32{2}, []float32{1}, values, nil, false)
+	assert.Less(t, a.Distance(b), a.Distance(c))
+	// vectors aren't decayed without decays
+	assert.Equal(t, NewDictionaryVector([]int32{0, 1}, values, nil, false).Distance(b),
+		NewDecayedDictionaryVector([]int32{0, 1}, []float32{1, 1}, values, nil, false).Distance(b))
+}
+
 func TestHNSW_InnerProduct(t *testing.T) {
 	// load dataset
 	trainSet, testSet, err := ranking.LoadDataFromBuiltIn("ml-100k")
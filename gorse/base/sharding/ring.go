@@ -0,0 +1,73 @@
+// Copyright 2024 gorse Project Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sharding
+
+import (
+	"sort"
+	"strconv"
+
+	"github.com/cespare/xxhash/v2"
+)
+
+// DefaultVirtualNodes is the default number of virtual nodes for each node.
+const DefaultVirtualNodes = 256
+
+// Ring assigns keys to nodes by consistent hashing. Each node is placed on the ring as a number of virtual nodes, so
+// that keys are balanced between nodes and only keys of the joining or leaving node are moved.
+type Ring struct {
+	hashes []uint64
+	owners map[uint64]string
+}
+
+// NewRing creates a consistent hash ring of nodes with given number of virtual nodes for each node.
+func NewRing(virtualNodes int, nodes ...string) *Ring {
+	ring := &Ring{
+		hashes: make([]uint64, 0, len(nodes)*virtualNodes),
+		owners: make(map[uint64]string, len(nodes)*virtualNodes),
+	}
+	for _, node := range nodes {
+		for i := 0; i < virtualNodes; i++ {
+			hash := xxhash.Sum64String(node + "#" + strconv.Itoa(i))
+			if owner, exist := ring.owners[hash]; exist {
+				// resolve collision deterministically
+				if owner < node {
+					continue
+				}
+			} else {
+				ring.hashes = append(ring.hashes, hash)
+			}
+			ring.owners[hash] = node
+		}
+	}
+	sort.Slice(ring.hashes, func(i, j int) bool {
+		return ring.hashes[i] < ring.hashes[j]
+	})
+	return ring
+}
+
+// Get returns the node owning the key. An empty string is returned if there is no node in the ring.
+func (ring *Ring) Get(key string) string {
+	if len(ring.hashes) == 0 {
+		return ""
+	}
+	hash := xxhash.Sum64String(key)
+	i := sort.Search(len(ring.hashes), func(i int) bool {
+		return ring.hashes[i] >= hash
+	})
+	if i == len(ring.hashes) {
+		i = 0
+	}
+	return ring.owners[ring.hashes[i]]
+}
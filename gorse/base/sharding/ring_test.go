@@ -0,0 +1,56 @@
+// Copyright 2024 gorse Project Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sharding
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRing(t *testing.T) {
+	// empty ring
+	assert.Empty(t, NewRing(DefaultVirtualNodes).Get("1"))
+
+	// keys are balanced
+	ring := NewRing(DefaultVirtualNodes, "a", "b", "c", "d")
+	counts := make(map[string]int)
+	for i := 0; i < 10000; i++ {
+		counts[ring.Get(strconv.Itoa(i))]++
+	}
+	assert.Len(t, counts, 4)
+	for _, count := range counts {
+		assert.InDelta(t, 2500, count, 500)
+	}
+
+	// order of nodes doesn't matter
+	shuffled := NewRing(DefaultVirtualNodes, "d", "c", "b", "a")
+	for i := 0; i < 1000; i++ {
+		assert.Equal(t, ring.Get(strconv.Itoa(i)), shuffled.Get(strconv.Itoa(i)))
+	}
+
+	// only keys of the joining node are moved
+	joined := NewRing(DefaultVirtualNodes, "a", "b", "c", "d", "e")
+	moved := 0
+	for i := 0; i < 10000; i++ {
+		before, after := ring.Get(strconv.Itoa(i)), joined.Get(strconv.Itoa(i))
+		if before != after {
+			assert.Equal(t, "e", after)
+			moved++
+		}
+	}
+	assert.InDelta(t, 2000, moved, 500)
+}
@@ -0,0 +1,176 @@
+// Copyright 2024 gorse Project Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/juju/errors"
+	"github.com/samber/lo"
+	"github.com/spf13/cobra"
+	"github.com/zhenghaoz/gorse/base/log"
+	"github.com/zhenghaoz/gorse/cmd/version"
+	"github.com/zhenghaoz/gorse/storage"
+	"github.com/zhenghaoz/gorse/storage/cache"
+	"github.com/zhenghaoz/gorse/storage/data"
+	"github.com/zhenghaoz/gorse/storage/embeddings"
+	"go.uber.org/zap"
+)
+
+var migrateCommand = &cobra.Command{
+	Use:   "gorse-migrate",
+	Short: "Migrate data, cache and embeddings of gorse between databases.",
+	Run: func(cmd *cobra.Command, args []string) {
+		// Show version
+		if showVersion, _ := cmd.PersistentFlags().GetBool("version"); showVersion {
+			fmt.Println(version.BuildInfo())
+			return
+		}
+		// setup logger
+		debug, _ := cmd.PersistentFlags().GetBool("debug")
+		log.SetLogger(cmd.PersistentFlags(), debug)
+
+		migrator, err := newMigrator(cmd)
+		if err != nil {
+			log.Logger().Fatal("failed to create migrator", zap.Error(err))
+		}
+		ctx := context.Background()
+		if verifyOnly, _ := cmd.PersistentFlags().GetBool("verify-only"); !verifyOnly {
+			if err = migrator.Migrate(ctx); err != nil {
+				log.Logger().Fatal("failed to migrate", zap.Error(err))
+			}
+			log.Logger().Info("migrate successfully")
+		}
+		if verify, _ := cmd.PersistentFlags().GetBool("verify"); verify {
+			sampleSize, _ := cmd.PersistentFlags().GetInt("sample-size")
+			results, err := migrator.Verify(ctx, sampleSize)
+			if err != nil {
+				log.Logger().Fatal("failed to verify", zap.Error(err))
+			}
+			if !lo.EveryBy(results, VerifyResult.OK) {
+				log.Logger().Error("verification failed")
+				os.Exit(1)
+			}
+			log.Logger().Info("verify successfully")
+		}
+	},
+}
+
+func newMigrator(cmd *cobra.Command) (*Migrator, error) {
+	flags := cmd.PersistentFlags()
+	fromPrefix, _ := flags.GetString("from-prefix")
+	toPrefix, _ := flags.GetString("to-prefix")
+	migrator := &Migrator{}
+	migrator.BatchSize, _ = flags.GetInt("batch-size")
+
+	// connect to data stores
+	fromData, _ := flags.GetString("from-data")
+	toData, _ := flags.GetString("to-data")
+	if fromData != "" && toData != "" {
+		var err error
+		if migrator.FromData, err = data.Open(fromData, fromPrefix); err != nil {
+			return nil, errors.Trace(err)
+		}
+		if migrator.ToData, err = data.Open(toData, toPrefix); err != nil {
+			return nil, errors.Trace(err)
+		}
+		if err = migrator.ToData.Init(); err != nil {
+			return nil, errors.Trace(err)
+		}
+	}
+
+	// connect to cache stores
+	fromCache, _ := flags.GetString("from-cache")
+	toCache, _ := flags.GetString("to-cache")
+	if fromCache != "" && toCache != "" {
+		var err error
+		if migrator.FromCache, err = cache.Open(fromCache, fromPrefix); err != nil {
+			return nil, errors.Trace(err)
+		}
+		if migrator.ToCache, err = cache.Open(toCache, toPrefix); err != nil {
+			return nil, errors.Trace(err)
+		}
+		if err = migrator.ToCache.Init(); err != nil {
+			return nil, errors.Trace(err)
+		}
+	}
+
+	// connect to embedding stores
+	fromEmbeddings, _ := flags.GetString("from-embeddings")
+	toEmbeddings, _ := flags.GetString("to-embeddings")
+	if fromEmbeddings != "" && toEmbeddings != "" {
+		var err error
+		if migrator.FromEmbeddings, err = openEmbeddings(fromEmbeddings); err != nil {
+			return nil, errors.Trace(err)
+		}
+		if migrator.ToEmbeddings, err = openEmbeddings(toEmbeddings); err != nil {
+			return nil, errors.Trace(err)
+		}
+	}
+
+	// load checkpoint of these stores
+	checkpointPath, _ := flags.GetString("checkpoint")
+	checkpointKey := CheckpointKey(fromData, toData, fromCache, toCache, fromEmbeddings, toEmbeddings, fromPrefix, toPrefix)
+	var err error
+	if migrator.Checkpoint, err = LoadCheckpoint(checkpointPath, checkpointKey); err != nil {
+		return nil, errors.Trace(err)
+	}
+	return migrator, nil
+}
+
+// openEmbeddings connects to an embedding store. The embedding store uses MySQL syntax so only MySQL is supported.
+func openEmbeddings(path string) (*embeddings.SQLEmbeddingStore, error) {
+	if !strings.HasPrefix(path, storage.MySQLPrefix) {
+		return nil, errors.NotSupportedf("embedding store %s", log.RedactDBURL(path))
+	}
+	name, err := storage.AppendMySQLParams(path[len(storage.MySQLPrefix):], map[string]string{"parseTime": "true"})
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	db, err := sql.Open("mysql", name)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return embeddings.NewSQLEmbeddingStore(db), nil
+}
+
+func init() {
+	log.AddFlags(migrateCommand.PersistentFlags())
+	migrateCommand.PersistentFlags().Bool("debug", false, "use debug log mode")
+	migrateCommand.PersistentFlags().BoolP("version", "v", false, "gorse version")
+	migrateCommand.PersistentFlags().String("from-data", "", "source data store")
+	migrateCommand.PersistentFlags().String("to-data", "", "target data store")
+	migrateCommand.PersistentFlags().String("from-cache", "", "source cache store")
+	migrateCommand.PersistentFlags().String("to-cache", "", "target cache store")
+	migrateCommand.PersistentFlags().String("from-embeddings", "", "source embedding store (MySQL only)")
+	migrateCommand.PersistentFlags().String("to-embeddings", "", "target embedding store (MySQL only)")
+	migrateCommand.PersistentFlags().String("from-prefix", "", "table prefix of source stores")
+	migrateCommand.PersistentFlags().String("to-prefix", "", "table prefix of target stores")
+	migrateCommand.PersistentFlags().Int("batch-size", 1000, "number of records in a batch")
+	migrateCommand.PersistentFlags().String("checkpoint", "gorse-migrate.checkpoint", "path of checkpoint file to resume migration")
+	migrateCommand.PersistentFlags().Bool("verify", false, "verify counts and samples after migration")
+	migrateCommand.PersistentFlags().Bool("verify-only", false, "verify without migration")
+	migrateCommand.PersistentFlags().Int("sample-size", 100, "number of sampled records to verify")
+}
+
+func main() {
+	if err := migrateCommand.Execute(); err != nil {
+		log.Logger().Fatal("failed to execute", zap.Error(err))
+	}
+}
@@ -0,0 +1,781 @@
+// Copyright 2024 gorse Project Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"math/rand"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/juju/errors"
+	"github.com/samber/lo"
+	"github.com/zhenghaoz/gorse/base/log"
+	"github.com/zhenghaoz/gorse/storage/cache"
+	"github.com/zhenghaoz/gorse/storage/data"
+	"github.com/zhenghaoz/gorse/storage/embeddings"
+	"go.uber.org/zap"
+)
+
+const (
+	StageUsers       = "users"
+	StageItems       = "items"
+	StageFeedback    = "feedback"
+	StageImpressions = "impressions"
+	StageCacheValues = "cache_values"
+	StageCacheScores = "cache_scores"
+	StageEmbeddings  = "embeddings"
+)
+
+// StageProgress is the progress of a stage. Count is the number of records copied to the target store.
+type StageProgress struct {
+	Count int  `json:"count"`
+	Done  bool `json:"done"`
+}
+
+// Checkpoint records progress of stages. A resumed migration skips finished stages and records copied by unfinished
+// stages, which assumes the source store returns records in the same order. Records are upserted so records copied
+// twice are harmless except impressions. The checkpoint belongs to a pair of source and target stores identified by
+// its key.
+type Checkpoint struct {
+	path   string
+	Key    string                    `json:"key"`
+	Stages map[string]*StageProgress `json:"stages"`
+}
+
+// CheckpointKey identifies source and target stores by a digest of their addresses and prefixes, so that passwords in
+// addresses aren't written to the checkpoint file.
+func CheckpointKey(stores ...string) string {
+	digest := sha256.New()
+	for _, store := range stores {
+		digest.Write([]byte(store))
+		digest.Write([]byte{0})
+	}
+	return hex.EncodeToString(digest.Sum(nil))
+}
+
+// LoadCheckpoint loads the checkpoint of stores identified by the key from a file. An empty checkpoint is returned if
+// the file doesn't exist or the checkpoint in the file belongs to other stores. The checkpoint is kept in memory only
+// if the path is empty.
+func LoadCheckpoint(path, key string) (*Checkpoint, error) {
+	checkpoint := &Checkpoint{path: path, Key: key, Stages: make(map[string]*StageProgress)}
+	if path == "" {
+		return checkpoint, nil
+	}
+	bytes, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return checkpoint, nil
+	} else if err != nil {
+		return nil, errors.Trace(err)
+	}
+	var saved Checkpoint
+	if err = json.Unmarshal(bytes, &saved); err != nil {
+		return nil, errors.Trace(err)
+	}
+	if saved.Key != key {
+		log.Logger().Warn("ignore checkpoint of other stores", zap.String("path", path))
+		return checkpoint, nil
+	}
+	if saved.Stages != nil {
+		checkpoint.Stages = saved.Stages
+	}
+	return checkpoint, nil
+}
+
+// Stage returns the progress of a stage.
+func (c *Checkpoint) Stage(name string) *StageProgress {
+	if _, exist := c.Stages[name]; !exist {
+		c.Stages[name] = &StageProgress{}
+	}
+	return c.Stages[name]
+}
+
+// Save the checkpoint to its file.
+func (c *Checkpoint) Save() error {
+	if c.path == "" {
+		return nil
+	}
+	bytes, err := json.Marshal(c)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	// write to a temporary file then rename it to avoid corrupted checkpoints
+	if err = os.WriteFile(c.path+".tmp", bytes, 0644); err != nil {
+		return errors.Trace(err)
+	}
+	return errors.Trace(os.Rename(c.path+".tmp", c.path))
+}
+
+// Migrator copies data, cache and embeddings from source stores to target stores. Data are copied if both data stores
+// are set, cache is copied if both cache stores are set and embeddings are copied if both embedding stores are set.
+type Migrator struct {
+	FromData       data.Database
+	ToData         data.Database
+	FromCache      cache.Database
+	ToCache        cache.Database
+	FromEmbeddings *embeddings.SQLEmbeddingStore
+	ToEmbeddings   *embeddings.SQLEmbeddingStore
+
+	BatchSize  int
+	Checkpoint *Checkpoint
+}
+
+// stage is a running stage.
+type stage struct {
+	name      string
+	progress  *StageProgress
+	skip      int
+	count     int
+	startTime time.Time
+	m         *Migrator
+}
+
+// advance records n copied records and reports throughput.
+func (s *stage) advance(n int) error {
+	s.progress.Count += n
+	s.count += n
+	if err := s.m.Checkpoint.Save(); err != nil {
+		return errors.Trace(err)
+	}
+	elapsed := time.Since(s.startTime)
+	log.Logger().Info("migrate "+s.name,
+		zap.Int("total", s.progress.Count),
+		zap.Float64("records_per_second", float64(s.count)/elapsed.Seconds()))
+	return nil
+}
+
+// skipBatch removes records copied before the checkpoint from a batch.
+func skipBatch[T any](s *stage, batch []T) []T {
+	if s.skip >= len(batch) {
+		s.skip -= len(batch)
+		return nil
+	}
+	batch = batch[s.skip:]
+	s.skip = 0
+	return batch
+}
+
+func (m *Migrator) runStage(name string, run func(s *stage) error) error {
+	progress := m.Checkpoint.Stage(name)
+	if progress.Done {
+		log.Logger().Info("skip finished stage", zap.String("stage", name), zap.Int("total", progress.Count))
+		return nil
+	}
+	s := &stage{name: name, progress: progress, skip: progress.Count, startTime: time.Now(), m: m}
+	if err := run(s); err != nil {
+		return errors.Annotatef(err, "failed to migrate %s", name)
+	}
+	progress.Done = true
+	if err := m.Checkpoint.Save(); err != nil {
+		return errors.Trace(err)
+	}
+	log.Logger().Info("stage finished", zap.String("stage", name),
+		zap.Int("total", progress.Count), zap.Duration("elapsed", time.Since(s.startTime)))
+	return nil
+}
+
+// Migrate copies all records from source stores to target stores.
+func (m *Migrator) Migrate(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	if m.FromData != nil && m.ToData != nil {
+		if err := m.runStage(StageUsers, func(s *stage) error { return m.migrateUsers(ctx, s) }); err != nil {
+			return errors.Trace(err)
+		}
+		if err := m.runStage(StageItems, func(s *stage) error { return m.migrateItems(ctx, s) }); err != nil {
+			return errors.Trace(err)
+		}
+		if err := m.runStage(StageFeedback, func(s *stage) error { return m.migrateFeedback(ctx, s) }); err != nil {
+			return errors.Trace(err)
+		}
+		if err := m.runStage(StageImpressions, func(s *stage) error { return m.migrateImpressions(ctx, s) }); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	if m.FromCache != nil && m.ToCache != nil {
+		if err := m.runStage(StageCacheValues, func(s *stage) error { return m.migrateCacheValues(ctx, s) }); err != nil {
+			return errors.Trace(err)
+		}
+		if err := m.runStage(StageCacheScores, func(s *stage) error { return m.migrateCacheScores(ctx, s) }); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	if m.FromEmbeddings != nil && m.ToEmbeddings != nil {
+		if err := m.runStage(StageEmbeddings, func(s *stage) error { return m.migrateEmbeddings(ctx, s) }); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	return nil
+}
+
+func (m *Migrator) migrateUsers(ctx context.Context, s *stage) error {
+	users, errChan := m.FromData.GetUserStream(ctx, m.BatchSize)
+	for batch := range users {
+		if batch = skipBatch(s, batch); len(batch) == 0 {
+			continue
+		}
+		if err := m.ToData.BatchInsertUsers(ctx, batch); err != nil {
+			return errors.Trace(err)
+		}
+		if err := s.advance(len(batch)); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	return errors.Trace(<-errChan)
+}
+
+func (m *Migrator) migrateItems(ctx context.Context, s *stage) error {
+	items, errChan := m.FromData.GetItemStream(ctx, m.BatchSize, nil)
+	for batch := range items {
+		if batch = skipBatch(s, batch); len(batch) == 0 {
+			continue
+		}
+		if err := m.ToData.BatchInsertItems(ctx, batch); err != nil {
+			return errors.Trace(err)
+		}
+		if err := s.advance(len(batch)); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	return errors.Trace(<-errChan)
+}
+
+func (m *Migrator) migrateFeedback(ctx context.Context, s *stage) error {
+	feedback, errChan := m.FromData.GetFeedbackStream(ctx, m.BatchSize)
+	for batch := range feedback {
+		if batch = skipBatch(s, batch); len(batch) == 0 {
+			continue
+		}
+		if err := m.ToData.BatchInsertFeedback(ctx, batch, false, false, true); err != nil {
+			return errors.Trace(err)
+		}
+		if err := s.advance(len(batch)); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	return errors.Trace(<-errChan)
+}
+
+func (m *Migrator) migrateImpressions(ctx context.Context, s *stage) error {
+	impressions, errChan := m.FromData.GetImpressionStream(ctx, m.BatchSize, nil, nil)
+	for batch := range impressions {
+		if batch = skipBatch(s, batch); len(batch) == 0 {
+			continue
+		}
+		if err := m.ToData.BatchInsertImpressions(ctx, batch); err != nil {
+			return errors.Trace(err)
+		}
+		if err := s.advance(len(batch)); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	return errors.Trace(<-errChan)
+}
+
+// scanCacheKeys returns keys in the source cache store in order.
+func (m *Migrator) scanCacheKeys() ([]string, error) {
+	var keys []string
+	if err := m.FromCache.Scan(func(key string) error {
+		keys = append(keys, key)
+		return nil
+	}); err != nil {
+		return nil, errors.Trace(err)
+	}
+	sort.Strings(keys)
+	return lo.Uniq(keys), nil
+}
+
+// isOtherType returns true if the error is caused by reading a key of another type, e.g. reading a set as a value.
+func isOtherType(err error) bool {
+	return errors.Is(err, errors.NotFound) || strings.HasPrefix(err.Error(), "WRONGTYPE")
+}
+
+// migrateCacheValues copies values and sets. Keys of other types returned by Scan are skipped since they are copied
+// as scores or rebuilt by the master. Other errors abort the stage so that the checkpoint never passes failed keys.
+func (m *Migrator) migrateCacheValues(ctx context.Context, s *stage) error {
+	keys, err := m.scanCacheKeys()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	for _, batch := range lo.Chunk(keys, m.BatchSize) {
+		if batch = skipBatch(s, batch); len(batch) == 0 {
+			continue
+		}
+		var values []cache.Value
+		for _, key := range batch {
+			value, err := m.FromCache.Get(ctx, key).String()
+			if err == nil {
+				values = append(values, cache.String(key, value))
+				continue
+			} else if !isOtherType(err) {
+				return errors.Trace(err)
+			}
+			members, err := m.FromCache.GetSet(ctx, key)
+			if err != nil && !isOtherType(err) {
+				return errors.Trace(err)
+			}
+			if len(members) > 0 {
+				if err = m.ToCache.AddSet(ctx, key, members...); err != nil {
+					return errors.Trace(err)
+				}
+			}
+		}
+		if len(values) > 0 {
+			if err = m.ToCache.Set(ctx, values...); err != nil {
+				return errors.Trace(err)
+			}
+		}
+		if err = s.advance(len(batch)); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	return nil
+}
+
+// migrateCacheScores copies documents of scores including hidden ones. Collections and subsets are enumerated from
+// the source cache store, and documents of a subset are written in batches. The checkpoint is the number of copied
+// documents since documents are scanned in the order of collection, subset and id.
+func (m *Migrator) migrateCacheScores(ctx context.Context, s *stage) error {
+	var (
+		collection string
+		subset     string
+		batch      []cache.Score
+	)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		if err := m.ToCache.AddScores(ctx, collection, subset, batch); err != nil {
+			return errors.Trace(err)
+		}
+		if err := s.advance(len(batch)); err != nil {
+			return errors.Trace(err)
+		}
+		batch = nil
+		return nil
+	}
+	if err := m.FromCache.ScanScores(ctx, func(c, ss string, document cache.Score) error {
+		if s.skip > 0 {
+			s.skip--
+			return nil
+		}
+		if c != collection || ss != subset || len(batch) >= m.BatchSize {
+			if err := flush(); err != nil {
+				return errors.Trace(err)
+			}
+			collection, subset = c, ss
+		}
+		batch = append(batch, document)
+		return nil
+	}); err != nil {
+		return errors.Trace(err)
+	}
+	return errors.Trace(flush())
+}
+
+// migrateEmbeddings copies embeddings page by page so that the checkpoint is the offset of the next page.
+func (m *Migrator) migrateEmbeddings(ctx context.Context, s *stage) error {
+	for {
+		batch, err := m.FromEmbeddings.Scan(ctx, s.progress.Count, m.BatchSize)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if len(batch) == 0 {
+			return nil
+		}
+		if err = m.ToEmbeddings.BatchStoreEmbeddings(ctx, batch); err != nil {
+			return errors.Trace(err)
+		}
+		if err = s.advance(len(batch)); err != nil {
+			return errors.Trace(err)
+		}
+	}
+}
+
+// VerifyResult compares a kind of records in source and target stores. Sampled records are compared by values.
+type VerifyResult struct {
+	Name       string
+	Source     int
+	Target     int
+	Sampled    int
+	Mismatched []string
+}
+
+// OK returns true if counts are equal and sampled records match.
+func (r VerifyResult) OK() bool {
+	return r.Source == r.Target && len(r.Mismatched) == 0
+}
+
+// sample selects n values from a stream by reservoir sampling.
+type sample[T any] struct {
+	n      int
+	count  int
+	values []T
+	rng    *rand.Rand
+}
+
+func newSample[T any](n int) *sample[T] {
+	return &sample[T]{n: n, rng: rand.New(rand.NewSource(time.Now().UnixNano()))}
+}
+
+func (s *sample[T]) add(value T) {
+	s.count++
+	if len(s.values) < s.n {
+		s.values = append(s.values, value)
+	} else if i := s.rng.Intn(s.count); i < s.n {
+		s.values[i] = value
+	}
+}
+
+func countStream[T any](c chan []T, errChan chan error, sampled *sample[T]) (int, error) {
+	count := 0
+	for batch := range c {
+		count += len(batch)
+		if sampled != nil {
+			for _, value := range batch {
+				sampled.add(value)
+			}
+		}
+	}
+	return count, errors.Trace(<-errChan)
+}
+
+func equalJSON(a, b any) bool {
+	x, err := json.Marshal(a)
+	if err != nil {
+		return false
+	}
+	y, err := json.Marshal(b)
+	if err != nil {
+		return false
+	}
+	return string(x) == string(y)
+}
+
+// Verify compares counts of records in source and target stores and compares sampled records.
+func (m *Migrator) Verify(ctx context.Context, sampleSize int) ([]VerifyResult, error) {
+	var verifies []func(context.Context, int) (VerifyResult, error)
+	if m.FromData != nil && m.ToData != nil {
+		verifies = append(verifies, m.verifyUsers, m.verifyItems, m.verifyFeedback, m.verifyImpressions)
+	}
+	if m.FromCache != nil && m.ToCache != nil {
+		verifies = append(verifies, m.verifyCacheValues, m.verifyCacheScores)
+	}
+	if m.FromEmbeddings != nil && m.ToEmbeddings != nil {
+		verifies = append(verifies, m.verifyEmbeddings)
+	}
+	results := make([]VerifyResult, 0, len(verifies))
+	for _, verify := range verifies {
+		result, err := verify(ctx, sampleSize)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		log.Logger().Info("verify "+result.Name,
+			zap.Bool("ok", result.OK()),
+			zap.Int("source", result.Source),
+			zap.Int("target", result.Target),
+			zap.Int("sampled", result.Sampled),
+			zap.Strings("mismatched", result.Mismatched))
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// normalizeTime removes time zones and precision differences between databases.
+func normalizeTime(t time.Time) time.Time {
+	return t.UTC().Truncate(time.Second)
+}
+
+func (m *Migrator) verifyUsers(ctx context.Context, sampleSize int) (VerifyResult, error) {
+	var (
+		result  = VerifyResult{Name: StageUsers}
+		sampled = newSample[data.User](sampleSize)
+		err     error
+	)
+	users, errChan := m.FromData.GetUserStream(ctx, m.BatchSize)
+	if result.Source, err = countStream(users, errChan, sampled); err != nil {
+		return result, errors.Trace(err)
+	}
+	users, errChan = m.ToData.GetUserStream(ctx, m.BatchSize)
+	if result.Target, err = countStream(users, errChan, nil); err != nil {
+		return result, errors.Trace(err)
+	}
+	for _, user := range sampled.values {
+		target, err := m.ToData.GetUser(ctx, user.UserId)
+		if err != nil && !errors.Is(err, errors.NotFound) {
+			return result, errors.Trace(err)
+		}
+		if err != nil || !equalJSON(user, target) {
+			result.Mismatched = append(result.Mismatched, user.UserId)
+		}
+	}
+	result.Sampled = len(sampled.values)
+	return result, nil
+}
+
+func (m *Migrator) verifyItems(ctx context.Context, sampleSize int) (VerifyResult, error) {
+	var (
+		result  = VerifyResult{Name: StageItems}
+		sampled = newSample[data.Item](sampleSize)
+		err     error
+	)
+	items, errChan := m.FromData.GetItemStream(ctx, m.BatchSize, nil)
+	if result.Source, err = countStream(items, errChan, sampled); err != nil {
+		return result, errors.Trace(err)
+	}
+	items, errChan = m.ToData.GetItemStream(ctx, m.BatchSize, nil)
+	if result.Target, err = countStream(items, errChan, nil); err != nil {
+		return result, errors.Trace(err)
+	}
+	for _, item := range sampled.values {
+		target, err := m.ToData.GetItem(ctx, item.ItemId)
+		if err != nil && !errors.Is(err, errors.NotFound) {
+			return result, errors.Trace(err)
+		}
+		item.Timestamp = normalizeTime(item.Timestamp)
+		target.Timestamp = normalizeTime(target.Timestamp)
+		if err != nil || !equalJSON(item, target) {
+			result.Mismatched = append(result.Mismatched, item.ItemId)
+		}
+	}
+	result.Sampled = len(sampled.values)
+	return result, nil
+}
+
+func (m *Migrator) verifyFeedback(ctx context.Context, sampleSize int) (VerifyResult, error) {
+	var (
+		result  = VerifyResult{Name: StageFeedback}
+		sampled = newSample[data.Feedback](sampleSize)
+		err     error
+	)
+	feedback, errChan := m.FromData.GetFeedbackStream(ctx, m.BatchSize)
+	if result.Source, err = countStream(feedback, errChan, sampled); err != nil {
+		return result, errors.Trace(err)
+	}
+	feedback, errChan = m.ToData.GetFeedbackStream(ctx, m.BatchSize)
+	if result.Target, err = countStream(feedback, errChan, nil); err != nil {
+		return result, errors.Trace(err)
+	}
+	for _, f := range sampled.values {
+		target, err := m.ToData.GetUserItemFeedback(ctx, f.UserId, f.ItemId, f.FeedbackType)
+		if err != nil {
+			return result, errors.Trace(err)
+		}
+		f.Timestamp = normalizeTime(f.Timestamp)
+		if len(target) > 0 {
+			target[0].Timestamp = normalizeTime(target[0].Timestamp)
+		}
+		if len(target) != 1 || !equalJSON(f, target[0]) {
+			result.Mismatched = append(result.Mismatched, cache.Key(f.FeedbackType, f.UserId, f.ItemId))
+		}
+	}
+	result.Sampled = len(sampled.values)
+	return result, nil
+}
+
+func impressionKey(impression data.Impression) string {
+	return cache.Key(impression.RequestId, impression.UserId, impression.ItemId)
+}
+
+// verifyImpressions compares impressions by streams since there is no lookup of an impression.
+func (m *Migrator) verifyImpressions(ctx context.Context, sampleSize int) (VerifyResult, error) {
+	var (
+		result  = VerifyResult{Name: StageImpressions}
+		sampled = newSample[data.Impression](sampleSize)
+		err     error
+	)
+	impressions, errChan := m.FromData.GetImpressionStream(ctx, m.BatchSize, nil, nil)
+	if result.Source, err = countStream(impressions, errChan, sampled); err != nil {
+		return result, errors.Trace(err)
+	}
+	targets := make(map[string]*data.Impression, len(sampled.values))
+	for _, impression := range sampled.values {
+		targets[impressionKey(impression)] = nil
+	}
+	impressions, errChan = m.ToData.GetImpressionStream(ctx, m.BatchSize, nil, nil)
+	for batch := range impressions {
+		result.Target += len(batch)
+		for i := range batch {
+			if _, exist := targets[impressionKey(batch[i])]; exist {
+				targets[impressionKey(batch[i])] = &batch[i]
+			}
+		}
+	}
+	if err = <-errChan; err != nil {
+		return result, errors.Trace(err)
+	}
+	for _, impression := range sampled.values {
+		target := targets[impressionKey(impression)]
+		impression.Timestamp = normalizeTime(impression.Timestamp)
+		if target != nil {
+			target.Timestamp = normalizeTime(target.Timestamp)
+		}
+		if target == nil || !equalJSON(impression, *target) {
+			result.Mismatched = append(result.Mismatched, impressionKey(impression))
+		}
+	}
+	result.Sampled = len(sampled.values)
+	return result, nil
+}
+
+// cacheValue returns the value or sorted members of a key.
+func cacheValue(ctx context.Context, database cache.Database, key string) (any, bool) {
+	if value, err := database.Get(ctx, key).String(); err == nil {
+		return value, true
+	}
+	if members, err := database.GetSet(ctx, key); err == nil && len(members) > 0 {
+		sort.Strings(members)
+		return members, true
+	}
+	return nil, false
+}
+
+func (m *Migrator) verifyCacheValues(ctx context.Context, sampleSize int) (VerifyResult, error) {
+	result := VerifyResult{Name: StageCacheValues}
+	sampled := newSample[string](sampleSize)
+	keys, err := m.scanCacheKeys()
+	if err != nil {
+		return result, errors.Trace(err)
+	}
+	for _, key := range keys {
+		if _, exist := cacheValue(ctx, m.FromCache, key); exist {
+			result.Source++
+			sampled.add(key)
+		}
+	}
+	if err = m.ToCache.Scan(func(key string) error {
+		if _, exist := cacheValue(ctx, m.ToCache, key); exist {
+			result.Target++
+		}
+		return nil
+	}); err != nil {
+		return result, errors.Trace(err)
+	}
+	for _, key := range sampled.values {
+		source, _ := cacheValue(ctx, m.FromCache, key)
+		target, exist := cacheValue(ctx, m.ToCache, key)
+		if !exist || !equalJSON(source, target) {
+			result.Mismatched = append(result.Mismatched, key)
+		}
+	}
+	result.Sampled = len(sampled.values)
+	return result, nil
+}
+
+// scoreDocument is a document of scores with its collection and subset.
+type scoreDocument struct {
+	Collection string
+	Subset     string
+	cache.Score
+}
+
+func (d scoreDocument) key() string {
+	return cache.Key(d.Collection, d.Subset, d.Id)
+}
+
+// equal compares all fields of documents including hidden flags, categories and timestamps.
+func (d scoreDocument) equal(other scoreDocument) bool {
+	x, y := d.Score, other.Score
+	x.Categories = lo.Ternary(len(x.Categories) == 0, nil, x.Categories)
+	y.Categories = lo.Ternary(len(y.Categories) == 0, nil, y.Categories)
+	return d.Collection == other.Collection && d.Subset == other.Subset &&
+		x.Id == y.Id && x.Score == y.Score && x.IsHidden == y.IsHidden &&
+		normalizeTime(x.Timestamp).Equal(normalizeTime(y.Timestamp)) &&
+		equalJSON(x.Categories, y.Categories)
+}
+
+func (m *Migrator) verifyCacheScores(ctx context.Context, sampleSize int) (VerifyResult, error) {
+	result := VerifyResult{Name: StageCacheScores}
+	sampled := newSample[scoreDocument](sampleSize)
+	if err := m.FromCache.ScanScores(ctx, func(collection, subset string, document cache.Score) error {
+		result.Source++
+		sampled.add(scoreDocument{Collection: collection, Subset: subset, Score: document})
+		return nil
+	}); err != nil {
+		return result, errors.Trace(err)
+	}
+	targets := make(map[string]*scoreDocument, len(sampled.values))
+	for _, document := range sampled.values {
+		targets[document.key()] = nil
+	}
+	if err := m.ToCache.ScanScores(ctx, func(collection, subset string, document cache.Score) error {
+		result.Target++
+		target := scoreDocument{Collection: collection, Subset: subset, Score: document}
+		if _, exist := targets[target.key()]; exist {
+			targets[target.key()] = &target
+		}
+		return nil
+	}); err != nil {
+		return result, errors.Trace(err)
+	}
+	for _, document := range sampled.values {
+		if target := targets[document.key()]; target == nil || !document.equal(*target) {
+			result.Mismatched = append(result.Mismatched, document.key())
+		}
+	}
+	result.Sampled = len(sampled.values)
+	return result, nil
+}
+
+// countEmbeddings counts embeddings page by page.
+func (m *Migrator) countEmbeddings(ctx context.Context, store *embeddings.SQLEmbeddingStore, sampled *sample[*embeddings.ItemEmbedding]) (int, error) {
+	count := 0
+	for {
+		batch, err := store.Scan(ctx, count, m.BatchSize)
+		if err != nil {
+			return count, errors.Trace(err)
+		}
+		if len(batch) == 0 {
+			return count, nil
+		}
+		count += len(batch)
+		if sampled != nil {
+			for _, embedding := range batch {
+				sampled.add(embedding)
+			}
+		}
+	}
+}
+
+func (m *Migrator) verifyEmbeddings(ctx context.Context, sampleSize int) (VerifyResult, error) {
+	var (
+		result  = VerifyResult{Name: StageEmbeddings}
+		sampled = newSample[*embeddings.ItemEmbedding](sampleSize)
+		err     error
+	)
+	if result.Source, err = m.countEmbeddings(ctx, m.FromEmbeddings, sampled); err != nil {
+		return result, errors.Trace(err)
+	}
+	if result.Target, err = m.countEmbeddings(ctx, m.ToEmbeddings, nil); err != nil {
+		return result, errors.Trace(err)
+	}
+	for _, embedding := range sampled.values {
+		target, err := m.ToEmbeddings.GetEmbedding(ctx, embedding.ItemId)
+		if err != nil && !errors.Is(err, errors.NotFound) {
+			return result, errors.Trace(err)
+		}
+		if err != nil || !equalJSON(embedding.Vector, target.Vector) ||
+			!normalizeTime(embedding.Timestamp).Equal(normalizeTime(target.Timestamp)) {
+			result.Mismatched = append(result.Mismatched, embedding.ItemId)
+		}
+	}
+	result.Sampled = len(sampled.values)
+	return result, nil
+}
@@ -0,0 +1,262 @@
+// Copyright 2024 gorse Project Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/suite"
+	"github.com/zhenghaoz/gorse/storage/cache"
+	"github.com/zhenghaoz/gorse/storage/data"
+	"google.golang.org/protobuf/proto"
+)
+
+type MigratorTestSuite struct {
+	suite.Suite
+	Migrator
+	checkpointPath string
+}
+
+func (suite *MigratorTestSuite) SetupTest() {
+	var err error
+	suite.FromData, err = data.Open(fmt.Sprintf("sqlite://%s/data.db", suite.T().TempDir()), "")
+	suite.NoError(err)
+	suite.NoError(suite.FromData.Init())
+	suite.ToData, err = data.Open(fmt.Sprintf("sqlite://%s/data.db", suite.T().TempDir()), "gorse_")
+	suite.NoError(err)
+	suite.NoError(suite.ToData.Init())
+	suite.FromCache, err = cache.Open(fmt.Sprintf("sqlite://%s/cache.db", suite.T().TempDir()), "")
+	suite.NoError(err)
+	suite.NoError(suite.FromCache.Init())
+	suite.ToCache, err = cache.Open(fmt.Sprintf("sqlite://%s/cache.db", suite.T().TempDir()), "gorse_")
+	suite.NoError(err)
+	suite.NoError(suite.ToCache.Init())
+	suite.BatchSize = 3
+	suite.checkpointPath = filepath.Join(suite.T().TempDir(), "checkpoint")
+	suite.Checkpoint, err = LoadCheckpoint(suite.checkpointPath, "key")
+	suite.NoError(err)
+}
+
+func (suite *MigratorTestSuite) TearDownTest() {
+	suite.NoError(suite.FromData.Close())
+	suite.NoError(suite.ToData.Close())
+	suite.NoError(suite.FromCache.Close())
+	suite.NoError(suite.ToCache.Close())
+}
+
+func (suite *MigratorTestSuite) insertData() {
+	ctx := context.Background()
+	var (
+		users       []data.User
+		items       []data.Item
+		feedback    []data.Feedback
+		impressions []data.Impression
+	)
+	for i := 0; i < 10; i++ {
+		id := strconv.Itoa(i)
+		users = append(users, data.User{UserId: id, Labels: []any{"a", float64(i)}, Comment: "user " + id})
+		items = append(items, data.Item{ItemId: id, Categories: []string{"c"}, Timestamp: time.Date(2024, 1, i+1, 0, 0, 0, 0, time.UTC)})
+		feedback = append(feedback, data.Feedback{
+			FeedbackKey: data.FeedbackKey{FeedbackType: "like", UserId: id, ItemId: strconv.Itoa(9 - i)},
+			Timestamp:   time.Date(2024, 2, i+1, 0, 0, 0, 0, time.UTC),
+		})
+		impressions = append(impressions, data.Impression{RequestId: id, UserId: id, ItemId: id, Timestamp: time.Now()})
+	}
+	suite.NoError(suite.FromData.BatchInsertUsers(ctx, users))
+	suite.NoError(suite.FromData.BatchInsertItems(ctx, items))
+	suite.NoError(suite.FromData.BatchInsertFeedback(ctx, feedback, false, false, true))
+	suite.NoError(suite.FromData.BatchInsertImpressions(ctx, impressions))
+
+	suite.NoError(suite.FromCache.Set(ctx,
+		cache.String(cache.Key(cache.GlobalMeta, cache.NumUsers), "10"),
+		cache.String(cache.Key(cache.OfflineRecommendDigest, "0"), "digest")))
+	suite.NoError(suite.FromCache.AddSet(ctx, cache.ItemCategories, "c", "d"))
+	scores := []cache.Score{{Id: "1", Score: 2, Categories: []string{""}}, {Id: "2", Score: 1, Categories: []string{""}}}
+	suite.NoError(suite.FromCache.AddScores(ctx, cache.OfflineRecommend, "0", scores))
+	suite.NoError(suite.FromCache.AddScores(ctx, cache.ItemNeighbors, "9", scores))
+	suite.NoError(suite.FromCache.AddScores(ctx, cache.NonPersonalized, "trending", scores))
+	suite.NoError(suite.FromCache.AddScores(ctx, cache.NonPersonalized, cache.Latest, scores))
+	suite.NoError(suite.FromCache.AddScores(ctx, cache.CollaborativeRecommend, "0", []cache.Score{
+		{Id: "3", Score: 1, IsHidden: true, Categories: []string{""}}}))
+}
+
+func (suite *MigratorTestSuite) TestMigrate() {
+	ctx := context.Background()
+	suite.insertData()
+	suite.NoError(suite.Migrate(ctx))
+
+	// check data
+	user, err := suite.ToData.GetUser(ctx, "5")
+	suite.NoError(err)
+	suite.Equal("user 5", user.Comment)
+	feedback, err := suite.ToData.GetUserItemFeedback(ctx, "3", "6", "like")
+	suite.NoError(err)
+	suite.Len(feedback, 1)
+	// check cache
+	value, err := suite.ToCache.Get(ctx, cache.Key(cache.GlobalMeta, cache.NumUsers)).String()
+	suite.NoError(err)
+	suite.Equal("10", value)
+	members, err := suite.ToCache.GetSet(ctx, cache.ItemCategories)
+	suite.NoError(err)
+	suite.ElementsMatch([]string{"c", "d"}, members)
+	for _, subset := range [][2]string{{cache.OfflineRecommend, "0"}, {cache.ItemNeighbors, "9"},
+		{cache.NonPersonalized, "trending"}, {cache.NonPersonalized, cache.Latest}} {
+		scores, err := suite.ToCache.SearchScores(ctx, subset[0], subset[1], nil, 0, -1)
+		suite.NoError(err)
+		suite.Equal([]string{"1", "2"}, cache.ConvertDocumentsToValues(scores), subset)
+	}
+	// hidden scores are copied
+	var hidden []string
+	suite.NoError(suite.ToCache.ScanScores(ctx, func(_, _ string, document cache.Score) error {
+		if document.IsHidden {
+			hidden = append(hidden, document.Id)
+		}
+		return nil
+	}))
+	suite.Equal([]string{"3"}, hidden)
+
+	// check checkpoint
+	checkpoint, err := LoadCheckpoint(suite.checkpointPath, "key")
+	suite.NoError(err)
+	for _, stage := range []string{StageUsers, StageItems, StageFeedback, StageImpressions, StageCacheValues, StageCacheScores} {
+		suite.True(checkpoint.Stage(stage).Done, stage)
+	}
+	suite.Equal(10, checkpoint.Stage(StageUsers).Count)
+	suite.Equal(10, checkpoint.Stage(StageImpressions).Count)
+	suite.Equal(4*2+1, checkpoint.Stage(StageCacheScores).Count)
+
+	// verify
+	results, err := suite.Verify(ctx, 5)
+	suite.NoError(err)
+	suite.Len(results, 6)
+	for _, result := range results {
+		suite.True(result.OK(), result)
+	}
+	// verification fails if records are missing
+	suite.NoError(suite.ToData.DeleteUser(ctx, "5"))
+	results, err = suite.Verify(ctx, 10)
+	suite.NoError(err)
+	result, _ := lo.Find(results, func(result VerifyResult) bool { return result.Name == StageUsers })
+	suite.False(result.OK())
+	suite.Equal(10, result.Source)
+	suite.Equal(9, result.Target)
+	suite.Equal([]string{"5"}, result.Mismatched)
+	// verification fails if impressions are missing
+	_, err = suite.ToData.DeleteUserImpressions(ctx, "5")
+	suite.NoError(err)
+	results, err = suite.Verify(ctx, 10)
+	suite.NoError(err)
+	result, _ = lo.Find(results, func(result VerifyResult) bool { return result.Name == StageImpressions })
+	suite.False(result.OK())
+	suite.Equal(10, result.Source)
+	suite.Equal(9, result.Target)
+	suite.Equal([]string{cache.Key("5", "5", "5")}, result.Mismatched)
+	// verification fails if hidden scores are missing
+	suite.NoError(suite.ToCache.DeleteScores(ctx, []string{cache.CollaborativeRecommend}, cache.ScoreCondition{Id: proto.String("3")}))
+	results, err = suite.Verify(ctx, 10)
+	suite.NoError(err)
+	result, _ = lo.Find(results, func(result VerifyResult) bool { return result.Name == StageCacheScores })
+	suite.False(result.OK())
+	suite.Equal(9, result.Source)
+	suite.Equal(8, result.Target)
+	suite.Equal([]string{cache.Key(cache.CollaborativeRecommend, "0", "3")}, result.Mismatched)
+}
+
+func (suite *MigratorTestSuite) TestMigrateCacheOnly() {
+	ctx := context.Background()
+	suite.insertData()
+	// subsets of users and items are found without the data store
+	migrator := Migrator{FromCache: suite.FromCache, ToCache: suite.ToCache, BatchSize: suite.BatchSize, Checkpoint: suite.Checkpoint}
+	suite.NoError(migrator.Migrate(ctx))
+	for _, subset := range [][2]string{{cache.OfflineRecommend, "0"}, {cache.ItemNeighbors, "9"}} {
+		scores, err := suite.ToCache.SearchScores(ctx, subset[0], subset[1], nil, 0, -1)
+		suite.NoError(err)
+		suite.Equal([]string{"1", "2"}, cache.ConvertDocumentsToValues(scores), subset)
+	}
+	suite.Equal(4*2+1, suite.Checkpoint.Stage(StageCacheScores).Count)
+}
+
+func (suite *MigratorTestSuite) TestResumeScores() {
+	ctx := context.Background()
+	suite.insertData()
+	// documents of the first 2 subsets (collaborative/0 and item_neighbors/9) are copied before interruption
+	suite.Checkpoint.Stage(StageCacheScores).Count = 3
+	migrator := Migrator{FromCache: suite.FromCache, ToCache: suite.ToCache, BatchSize: suite.BatchSize, Checkpoint: suite.Checkpoint}
+	suite.NoError(migrator.Migrate(ctx))
+	var copied []string
+	suite.NoError(suite.ToCache.ScanScores(ctx, func(collection, subset string, document cache.Score) error {
+		copied = append(copied, cache.Key(collection, subset))
+		return nil
+	}))
+	suite.Len(copied, 6)
+	suite.NotContains(copied, cache.Key(cache.CollaborativeRecommend, "0"))
+	suite.NotContains(copied, cache.Key(cache.ItemNeighbors, "9"))
+	suite.Equal(4*2+1, suite.Checkpoint.Stage(StageCacheScores).Count)
+}
+
+func (suite *MigratorTestSuite) TestResume() {
+	ctx := context.Background()
+	suite.insertData()
+	// users are migrated and 6 items are migrated before interruption
+	suite.Checkpoint.Stage(StageUsers).Done = true
+	suite.Checkpoint.Stage(StageUsers).Count = 10
+	suite.Checkpoint.Stage(StageItems).Count = 6
+	suite.NoError(suite.Checkpoint.Save())
+
+	checkpoint, err := LoadCheckpoint(suite.checkpointPath, "key")
+	suite.NoError(err)
+	suite.Checkpoint = checkpoint
+	suite.NoError(suite.Migrate(ctx))
+	// finished stages are skipped
+	users, errChan := suite.ToData.GetUserStream(ctx, 10)
+	count, err := countStream(users, errChan, nil)
+	suite.NoError(err)
+	suite.Zero(count)
+	// copied records are skipped
+	items, itemErrChan := suite.ToData.GetItemStream(ctx, 10, nil)
+	count, err = countStream(items, itemErrChan, nil)
+	suite.NoError(err)
+	suite.Equal(4, count)
+	suite.Equal(10, suite.Checkpoint.Stage(StageItems).Count)
+	suite.True(suite.Checkpoint.Stage(StageItems).Done)
+}
+
+func (suite *MigratorTestSuite) TestCheckpointKey() {
+	suite.Checkpoint.Stage(StageUsers).Done = true
+	suite.NoError(suite.Checkpoint.Save())
+	// the checkpoint of the same stores is resumed
+	checkpoint, err := LoadCheckpoint(suite.checkpointPath, "key")
+	suite.NoError(err)
+	suite.True(checkpoint.Stage(StageUsers).Done)
+	// the checkpoint of other stores is ignored
+	checkpoint, err = LoadCheckpoint(suite.checkpointPath, "other")
+	suite.NoError(err)
+	suite.Equal("other", checkpoint.Key)
+	suite.False(checkpoint.Stage(StageUsers).Done)
+
+	suite.Equal(CheckpointKey("a", "b"), CheckpointKey("a", "b"))
+	suite.NotEqual(CheckpointKey("a", "b"), CheckpointKey("a", "c"))
+	suite.NotEqual(CheckpointKey("ab", ""), CheckpointKey("a", "b"))
+}
+
+func TestMigrator(t *testing.T) {
+	suite.Run(t, new(MigratorTestSuite))
+}
@@ -16,10 +16,13 @@ package encoding
 
 import (
 	"github.com/zhenghaoz/gorse/base/log"
+	"github.com/zhenghaoz/gorse/common/nn"
 	"github.com/zhenghaoz/gorse/model/click"
 	"github.com/zhenghaoz/gorse/model/ranking"
+	"github.com/zhenghaoz/gorse/model/session"
 	"github.com/zhenghaoz/gorse/protocol"
 	"go.uber.org/zap"
+	"google.golang.org/grpc"
 	"io"
 )
 
@@ -108,3 +111,86 @@ func UnmarshalRankingModel(receiver protocol.Master_GetRankingModelClient) (rank
 	}
 	return model, nil
 }
+
+// UnmarshalRankingModelDelta unmarshal ranking model delta from gRPC and applies it to the base ranking model.
+func UnmarshalRankingModelDelta(receiver protocol.Master_GetRankingModelDeltaClient, baseModel ranking.MatrixFactorization) (ranking.MatrixFactorization, error) {
+	return unmarshalFragments(receiver, "ranking model delta", func(reader io.Reader) (ranking.MatrixFactorization, error) {
+		return ranking.UnmarshalModelDelta(reader, baseModel)
+	})
+}
+
+// UnmarshalSessionModel unmarshal session model from gRPC.
+func UnmarshalSessionModel(receiver protocol.Master_GetSessionModelClient) (session.Model, error) {
+	return unmarshalFragments(receiver, "session model", session.UnmarshalModel)
+}
+
+// UnmarshalTwoTowerModel unmarshal two-tower model and transformers of numeric labels from gRPC.
+func UnmarshalTwoTowerModel(receiver protocol.Master_GetTwoTowerModelClient) (*nn.TwoTower, *click.LabelTransformer, *click.LabelTransformer, error) {
+	type twoTowerModel struct {
+		model           *nn.TwoTower
+		userTransformer *click.LabelTransformer
+		itemTransformer *click.LabelTransformer
+	}
+	m, err := unmarshalFragments(receiver, "two-tower model", func(reader io.Reader) (m twoTowerModel, err error) {
+		m.model = new(nn.TwoTower)
+		if err = m.model.Unmarshal(reader); err != nil {
+			return
+		}
+		m.userTransformer, m.itemTransformer, err = click.UnmarshalLabelTransformers(reader)
+		return
+	})
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return m.model, m.userTransformer, m.itemTransformer, nil
+}
+
+// unmarshalFragments receives fragments of a model from gRPC and unmarshals the model from them. Errors of receiving
+// take precedence over errors of unmarshalling since the latter are caused by the former.
+func unmarshalFragments[T any](receiver grpc.ServerStreamingClient[protocol.Fragment], name string, unmarshal func(io.Reader) (T, error)) (T, error) {
+	// receive model
+	reader, writer := io.Pipe()
+	var receiverError error
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		defer func(writer *io.PipeWriter) {
+			err := writer.Close()
+			if err != nil {
+				log.Logger().Error("fail to close pipe", zap.Error(err))
+			}
+		}(writer)
+		for {
+			// receive from stream
+			fragment, err := receiver.Recv()
+			if err == io.EOF {
+				log.Logger().Info("complete receiving " + name)
+				break
+			} else if err != nil {
+				receiverError = err
+				log.Logger().Error("fail to receive stream", zap.Error(err))
+				return
+			}
+			// send to pipe
+			_, err = writer.Write(fragment.Data)
+			if err != nil {
+				receiverError = err
+				log.Logger().Error("fail to write pipe", zap.Error(err))
+				return
+			}
+		}
+	}()
+	// unmarshal model
+	model, err := unmarshal(reader)
+	_ = reader.CloseWithError(err)
+	<-done
+	if receiverError != nil {
+		var zero T
+		return zero, receiverError
+	}
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	return model, nil
+}
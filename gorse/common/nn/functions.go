@@ -218,7 +218,16 @@ func SoftmaxCrossEntropy(x, y *Tensor) *Tensor {
 //
 //	(1 + target) * math32.Log(1+math32.Exp(-prediction)) / 2 + (1 - target) * math32.Log(1+math32.Exp(prediction)) / 2
 func BCEWithLogits(target, prediction *Tensor) *Tensor {
-	return Mean(Add(
+	return Mean(bceWithLogits(target, prediction))
+}
+
+// WeightedBCEWithLogits is the mean of BCEWithLogits losses of samples multiplied by their weights.
+func WeightedBCEWithLogits(target, prediction, weight *Tensor) *Tensor {
+	return Mean(Mul(bceWithLogits(target, prediction), weight))
+}
+
+func bceWithLogits(target, prediction *Tensor) *Tensor {
+	return Add(
 		Div(
 			Mul(
 				Add(NewScalar(1), target),
@@ -228,5 +237,5 @@ func BCEWithLogits(target, prediction *Tensor) *Tensor {
 			Mul(
 				Sub(Ones(target.shape...), target),
 				Log(Add(NewScalar(1), Exp(prediction)))),
-			NewScalar(2))))
+			NewScalar(2)))
 }
@@ -726,23 +726,65 @@ func (s *softmax) String() string {
 
 func (s *softmax) forward(inputs ...*Tensor) *Tensor {
 	x := inputs[0]
+	a, b, c := s.squash(x.shape)
 	y := x.clone()
-	y.sub(x.max(s.axis, true))
-	y.exp()
-	y.div(y.sum(s.axis, true))
+	for i := 0; i < a; i++ {
+		for k := 0; k < c; k++ {
+			// y = exp(x - max(x)) / sum(exp(x - max(x)))
+			offset := i*b*c + k
+			maxValue := y.data[offset]
+			for j := 1; j < b; j++ {
+				maxValue = max(maxValue, y.data[offset+j*c])
+			}
+			var sumValue float32
+			for j := 0; j < b; j++ {
+				y.data[offset+j*c] = math32.Exp(y.data[offset+j*c] - maxValue)
+				sumValue += y.data[offset+j*c]
+			}
+			for j := 0; j < b; j++ {
+				y.data[offset+j*c] /= sumValue
+			}
+		}
+	}
 	return y
 }
 
 func (s *softmax) backward(dy *Tensor) []*Tensor {
 	y := s.output
+	a, b, c := s.squash(y.shape)
 	gx := y.clone()
 	gx.mul(dy)
-	sumdx := gx.sum(s.axis, true)
-	y.mul(sumdx)
-	gx.sub(y)
+	for i := 0; i < a; i++ {
+		for k := 0; k < c; k++ {
+			// gx = y * dy - y * sum(y * dy)
+			offset := i*b*c + k
+			var sumValue float32
+			for j := 0; j < b; j++ {
+				sumValue += gx.data[offset+j*c]
+			}
+			for j := 0; j < b; j++ {
+				gx.data[offset+j*c] -= y.data[offset+j*c] * sumValue
+			}
+		}
+	}
 	return []*Tensor{gx}
 }
 
+// squash returns sizes of dimensions before, along and after the axis.
+func (s *softmax) squash(shape []int) (int, int, int) {
+	a, b, c := 1, 1, 1
+	for i := range shape {
+		if i < s.axis {
+			a *= shape[i]
+		} else if i == s.axis {
+			b = shape[i]
+		} else {
+			c *= shape[i]
+		}
+	}
+	return a, b, c
+}
+
 type softmaxCrossEntropy struct {
 	base
 }
@@ -527,6 +527,25 @@ func TestSoftmax(t *testing.T) {
 	y.Backward()
 	dx := numericalDiff(func(x *Tensor) *Tensor { return Softmax(x, 1) }, x)
 	allClose(t, x.grad, dx)
+
+	// (2,3) -> (2,3)
+	x = NewTensor([]float32{3.0, 1.0, 0.2, 0.2, 1.0, 3.0}, 2, 3)
+	y = Softmax(x, 1)
+	assert.InDeltaSlice(t, []float32{
+		0.8360188027814407, 0.11314284146556013, 0.05083835575299916,
+		0.05083835575299916, 0.11314284146556013, 0.8360188027814407}, y.data, 1e-6)
+	y = Softmax(x, 0)
+	assert.InDeltaSlice(t, []float32{
+		0.9426758241011313, 0.5, 0.05732417589886872,
+		0.05732417589886872, 0.5, 0.9426758241011313}, y.data, 1e-6)
+
+	// Test gradient
+	y = Mul(Softmax(x, 1), NewTensor([]float32{1, 2, 3, 4, 5, 6}, 2, 3))
+	y.Backward()
+	dx = numericalDiff(func(x *Tensor) *Tensor {
+		return Mul(Softmax(x, 1), NewTensor([]float32{1, 2, 3, 4, 5, 6}, 2, 3))
+	}, x)
+	allClose(t, x.grad, dx)
 }
 
 func TestFlatten(t *testing.T) {
@@ -565,6 +584,22 @@ func TestSoftmaxCrossEntropy(t *testing.T) {
 	allClose(t, x.grad, dx)
 }
 
+func TestWeightedBCEWithLogits(t *testing.T) {
+	x := NewTensor([]float32{0.5, -1.0, 2.0}, 3)
+	y := NewTensor([]float32{1, -1, -1}, 3)
+	w := NewTensor([]float32{1, 0, 2}, 3)
+	z := WeightedBCEWithLogits(y, x, w)
+	assert.Empty(t, z.shape)
+	expected := (math32.Log(1+math32.Exp(-0.5)) + 2*math32.Log(1+math32.Exp(2))) / 3
+	assert.InDelta(t, expected, z.data[0], 1e-4)
+
+	// Test gradient
+	z.Backward()
+	dx := numericalDiff(func(x *Tensor) *Tensor { return WeightedBCEWithLogits(y, x, w) }, x)
+	allClose(t, x.grad, dx)
+	assert.Zero(t, x.grad.data[1])
+}
+
 func TestReuseLeaf(t *testing.T) {
 	// x + x
 	x := NewTensor([]float32{1, 2, 3, 4, 5, 6}, 2, 3)
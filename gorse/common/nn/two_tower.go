@@ -0,0 +1,418 @@
+// Copyright 2024 gorse Project Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nn
+
+import (
+	"context"
+	"io"
+
+	"github.com/chewxy/math32"
+	"github.com/juju/errors"
+	"github.com/samber/lo"
+	base2 "github.com/zhenghaoz/gorse/base"
+	"github.com/zhenghaoz/gorse/base/encoding"
+	log2 "github.com/zhenghaoz/gorse/base/log"
+	"github.com/zhenghaoz/gorse/base/progress"
+	"github.com/zhenghaoz/gorse/model"
+	"go.uber.org/zap"
+)
+
+const (
+	idPrefix      = "id:"
+	historyPrefix = "history:"
+	featurePrefix = "feature:"
+
+	// idDropout is the probability of dropping ID features in training, which forces towers to learn from other
+	// features so that users and items unseen in training could be encoded as well.
+	idDropout = 0.5
+)
+
+// TowerInput is the input of a tower in the two-tower model.
+type TowerInput struct {
+	Id        string                       // ID of the user or item
+	Features  []lo.Tuple2[string, float32] // sparse features such as labels and categories
+	History   []string                     // items the user interacted with, only used by the user tower
+	Embedding []float32                    // dense embedding such as image embedding, only used by the item tower
+}
+
+// TwoTower encodes users and items into the same vector space by two towers [1]. The user tower encodes the ID,
+// labels and historical items of a user. The item tower encodes the ID, labels, categories and the dense embedding
+// of an item. Sparse features are mean pooled and fed into a feed-forward network with a residual connection. The
+// model is trained by softmax over items in the mini-batch. Since popular items are sampled as negatives more often
+// in mini-batches, logits are corrected by subtracting logarithms of sampling probabilities of items (logQ
+// correction). Sampling probabilities are estimated by frequencies of items in feedback.
+//
+// Since ID features are dropped randomly in training, items without feedback could still be encoded by their other
+// features, and item vectors could be indexed for approximate nearest neighbor search.
+//
+// [1] Yi, Xinyang, et al. "Sampling-bias-corrected neural modeling for large corpus item recommendations."
+// Proceedings of the 13th ACM Conference on Recommender Systems. 2019.
+//
+// Hyper-parameters:
+//
+//	NFactors     - The dimension of user and item vectors. Default is 32.
+//	NEpochs      - The number of training epochs. Default is 10.
+//	BatchSize    - The number of positive pairs in a mini-batch. Default is 256.
+//	MaxLength    - The maximum number of historical items of a user. Default is 50.
+//	EmbeddingDim - The dimension of dense embeddings of items. Default is 0.
+//	Lr           - The learning rate of Adam. Default is 0.005.
+//	InitStdDev   - The standard deviation of initial embeddings. Default is 0.01.
+type TwoTower struct {
+	model.BaseModel
+	UserFeatures base2.Index
+	ItemFeatures base2.Index
+	// weights
+	userEmbedding *Tensor // (|user features|+1, d), the first row is padding
+	itemEmbedding *Tensor // (|item features|+1, d), the first row is padding
+	denseWeight   *Tensor // (e, d)
+	userW1        *Tensor // (d, d)
+	userW2        *Tensor // (d, d)
+	itemW1        *Tensor // (d, d)
+	itemW2        *Tensor // (d, d)
+	// hyper parameters
+	nFactors     int
+	nEpochs      int
+	batchSize    int
+	maxLength    int
+	embeddingDim int
+	lr           float32
+	initStdDev   float32
+}
+
+// NewTwoTower creates a two-tower model.
+func NewTwoTower(params model.Params) *TwoTower {
+	m := new(TwoTower)
+	m.SetParams(params)
+	return m
+}
+
+// SetParams sets hyper-parameters of the two-tower model.
+func (m *TwoTower) SetParams(params model.Params) {
+	m.BaseModel.SetParams(params)
+	m.nFactors = m.Params.GetInt(model.NFactors, 32)
+	m.nEpochs = m.Params.GetInt(model.NEpochs, 10)
+	m.batchSize = m.Params.GetInt(model.BatchSize, 256)
+	m.maxLength = m.Params.GetInt(model.MaxLength, 50)
+	m.embeddingDim = m.Params.GetInt(model.EmbeddingDim, 0)
+	m.lr = m.Params.GetFloat32(model.Lr, 0.005)
+	m.initStdDev = m.Params.GetFloat32(model.InitStdDev, 0.01)
+}
+
+func (m *TwoTower) GetParamsGrid(withSize bool) model.ParamsGrid {
+	return model.ParamsGrid{
+		model.NFactors:   lo.If(withSize, []interface{}{16, 32, 64}).Else([]interface{}{32}),
+		model.Lr:         []interface{}{0.001, 0.005, 0.01},
+		model.InitStdDev: []interface{}{0.001, 0.005, 0.01, 0.05, 0.1},
+	}
+}
+
+func (m *TwoTower) Clear() {
+	m.UserFeatures = nil
+	m.ItemFeatures = nil
+	m.userEmbedding = nil
+	m.itemEmbedding = nil
+}
+
+func (m *TwoTower) Invalid() bool {
+	return m == nil ||
+		m.UserFeatures == nil ||
+		m.ItemFeatures == nil ||
+		m.userEmbedding == nil ||
+		m.itemEmbedding == nil
+}
+
+// GetNFactors returns the dimension of user and item vectors.
+func (m *TwoTower) GetNFactors() int {
+	return m.nFactors
+}
+
+// init builds vocabularies of features and initializes weights.
+func (m *TwoTower) init(users, items []TowerInput) {
+	m.UserFeatures = base2.NewMapIndex()
+	for _, user := range users {
+		m.UserFeatures.Add(idPrefix + user.Id)
+		for _, feature := range user.Features {
+			m.UserFeatures.Add(featurePrefix + feature.A)
+		}
+	}
+	m.ItemFeatures = base2.NewMapIndex()
+	for _, item := range items {
+		m.UserFeatures.Add(historyPrefix + item.Id)
+		m.ItemFeatures.Add(idPrefix + item.Id)
+		for _, feature := range item.Features {
+			m.ItemFeatures.Add(featurePrefix + feature.A)
+		}
+	}
+	m.userEmbedding = Normal(0, m.initStdDev, int(m.UserFeatures.Len())+1, m.nFactors)
+	m.itemEmbedding = Normal(0, m.initStdDev, int(m.ItemFeatures.Len())+1, m.nFactors)
+	stdDev := 1 / math32.Sqrt(float32(m.nFactors))
+	m.userW1 = Normal(0, stdDev, m.nFactors, m.nFactors)
+	m.userW2 = Normal(0, stdDev, m.nFactors, m.nFactors)
+	m.itemW1 = Normal(0, stdDev, m.nFactors, m.nFactors)
+	m.itemW2 = Normal(0, stdDev, m.nFactors, m.nFactors)
+	if m.embeddingDim > 0 {
+		m.denseWeight = Normal(0, 1/math32.Sqrt(float32(m.embeddingDim)), m.embeddingDim, m.nFactors)
+	} else {
+		m.denseWeight = Zeros(0, m.nFactors)
+	}
+}
+
+func (m *TwoTower) parameters() []*Tensor {
+	params := []*Tensor{m.userEmbedding, m.itemEmbedding, m.userW1, m.userW2, m.itemW1, m.itemW2}
+	if m.embeddingDim > 0 {
+		params = append(params, m.denseWeight)
+	}
+	return params
+}
+
+// userFeatures converts a user into indices of features. The item excluded is removed from history.
+func (m *TwoTower) userFeatures(user TowerInput, excluded string, withId bool) []lo.Tuple2[int32, float32] {
+	var features []lo.Tuple2[int32, float32]
+	appendFeature := func(name string, value float32) {
+		if index := m.UserFeatures.ToNumber(name); index != base2.NotId {
+			features = append(features, lo.Tuple2[int32, float32]{A: index + 1, B: value})
+		}
+	}
+	if withId {
+		appendFeature(idPrefix+user.Id, 1)
+	}
+	for _, feature := range user.Features {
+		appendFeature(featurePrefix+feature.A, feature.B)
+	}
+	history := lo.Filter(user.History, func(itemId string, _ int) bool {
+		return itemId != excluded
+	})
+	if len(history) > m.maxLength {
+		history = history[len(history)-m.maxLength:]
+	}
+	for _, itemId := range history {
+		appendFeature(historyPrefix+itemId, 1)
+	}
+	return features
+}
+
+// itemFeatures converts an item into indices of features.
+func (m *TwoTower) itemFeatures(item TowerInput, withId bool) []lo.Tuple2[int32, float32] {
+	var features []lo.Tuple2[int32, float32]
+	appendFeature := func(name string, value float32) {
+		if index := m.ItemFeatures.ToNumber(name); index != base2.NotId {
+			features = append(features, lo.Tuple2[int32, float32]{A: index + 1, B: value})
+		}
+	}
+	if withId {
+		appendFeature(idPrefix+item.Id, 1)
+	}
+	for _, feature := range item.Features {
+		appendFeature(featurePrefix+feature.A, feature.B)
+	}
+	return features
+}
+
+// forward encodes a batch of sparse features (and dense embeddings if not nil) by a tower. Sparse features are mean
+// pooled and fed into a feed-forward network with a residual connection.
+func (m *TwoTower) forward(table, w1, w2 *Tensor, features [][]lo.Tuple2[int32, float32], embeddings [][]float32) *Tensor {
+	batchSize := len(features)
+	numFeatures := 1
+	for _, f := range features {
+		numFeatures = max(numFeatures, len(f))
+	}
+	indices := make([]float32, batchSize*numFeatures)
+	weights := make([]float32, batchSize*numFeatures*m.nFactors)
+	for i, f := range features {
+		for j, feature := range f {
+			indices[i*numFeatures+j] = float32(feature.A)
+			for k := 0; k < m.nFactors; k++ {
+				weights[(i*numFeatures+j)*m.nFactors+k] = feature.B / float32(len(f))
+			}
+		}
+	}
+	x := Sum(Mul(Embedding(table, NewTensor(indices, batchSize, numFeatures)), NewTensor(weights, batchSize, numFeatures, m.nFactors)), 1)
+	if embeddings != nil && m.embeddingDim > 0 {
+		dense := make([]float32, batchSize*m.embeddingDim)
+		for i, embedding := range embeddings {
+			if len(embedding) == m.embeddingDim {
+				copy(dense[i*m.embeddingDim:], embedding)
+			}
+		}
+		x = Add(x, MatMul(NewTensor(dense, batchSize, m.embeddingDim), m.denseWeight))
+	}
+	return Add(x, MatMul(ReLu(MatMul(x, w1)), w2))
+}
+
+// Fit the two-tower model on positive pairs of users and items. Each pair is the index of a user in users and the
+// index of an item in items. It returns the loss of the last epoch.
+func (m *TwoTower) Fit(ctx context.Context, users, items []TowerInput, feedbackUsers, feedbackItems []int32) float32 {
+	log2.Logger().Info("fit two-tower model",
+		zap.Int("n_users", len(users)),
+		zap.Int("n_items", len(items)),
+		zap.Int("n_feedback", len(feedbackUsers)),
+		zap.Any("params", m.GetParams()))
+	m.init(users, items)
+	logQ := logSamplingProbabilities(feedbackItems, len(items))
+	rng := m.GetRandomGenerator()
+	optimizer := NewAdam(m.parameters(), m.lr)
+	var cost float32
+	_, span := progress.Start(ctx, "TwoTower.Fit", m.nEpochs)
+	for epoch := 1; epoch <= m.nEpochs; epoch++ {
+		cost = 0
+		perm := rng.Perm(len(feedbackUsers))
+		for i := 0; i < len(perm); i += m.batchSize {
+			j := min(i+m.batchSize, len(perm))
+			userFeatures := make([][]lo.Tuple2[int32, float32], 0, j-i)
+			itemFeatures := make([][]lo.Tuple2[int32, float32], 0, j-i)
+			embeddings := make([][]float32, 0, j-i)
+			targets := make([]float32, 0, j-i)
+			corrections := make([]float32, 0, j-i)
+			for k, sample := range perm[i:j] {
+				user, item := users[feedbackUsers[sample]], items[feedbackItems[sample]]
+				userFeatures = append(userFeatures, m.userFeatures(user, item.Id, rng.Float32() >= idDropout))
+				itemFeatures = append(itemFeatures, m.itemFeatures(item, rng.Float32() >= idDropout))
+				embeddings = append(embeddings, item.Embedding)
+				targets = append(targets, float32(k))
+				corrections = append(corrections, logQ[feedbackItems[sample]])
+			}
+			// softmax over items in the mini-batch with logQ correction
+			u := m.forward(m.userEmbedding, m.userW1, m.userW2, userFeatures, nil)
+			v := m.forward(m.itemEmbedding, m.itemW1, m.itemW2, itemFeatures, embeddings)
+			logits := Sub(MatMul(u, v, false, true), NewTensor(corrections, len(corrections)))
+			loss := SoftmaxCrossEntropy(logits, NewTensor(targets, len(targets)))
+			cost += loss.Data()[0] * float32(len(targets))
+			optimizer.ZeroGrad()
+			loss.Backward()
+			optimizer.Step()
+		}
+		cost /= float32(max(len(perm), 1))
+		log2.Logger().Debug("fit two-tower model",
+			zap.Int("epoch", epoch),
+			zap.Int("n_epochs", m.nEpochs),
+			zap.Float32("loss", cost))
+		if math32.IsNaN(cost) {
+			log2.Logger().Warn("model diverged", zap.Float32("lr", m.lr))
+			break
+		}
+		span.Add(1)
+	}
+	span.End()
+	log2.Logger().Info("fit two-tower model complete", zap.Float32("loss", cost))
+	return cost
+}
+
+// logSamplingProbabilities returns logarithms of probabilities of items to be sampled in mini-batches, which are
+// frequencies of items in feedback. Items without feedback are never sampled and their values are left as 0.
+func logSamplingProbabilities(feedbackItems []int32, numItems int) []float32 {
+	counts := make([]int, numItems)
+	for _, item := range feedbackItems {
+		counts[item]++
+	}
+	logQ := make([]float32, numItems)
+	for i, count := range counts {
+		if count > 0 {
+			logQ[i] = math32.Log(float32(count) / float32(len(feedbackItems)))
+		}
+	}
+	return logQ
+}
+
+// EncodeUsers encodes users into vectors by the user tower.
+func (m *TwoTower) EncodeUsers(users []TowerInput) [][]float32 {
+	features := make([][]lo.Tuple2[int32, float32], len(users))
+	for i, user := range users {
+		features[i] = m.userFeatures(user, "", true)
+	}
+	return m.encode(m.userEmbedding, m.userW1, m.userW2, features, nil)
+}
+
+// EncodeItems encodes items into vectors by the item tower.
+func (m *TwoTower) EncodeItems(items []TowerInput) [][]float32 {
+	features := make([][]lo.Tuple2[int32, float32], len(items))
+	embeddings := make([][]float32, len(items))
+	for i, item := range items {
+		features[i] = m.itemFeatures(item, true)
+		embeddings[i] = item.Embedding
+	}
+	return m.encode(m.itemEmbedding, m.itemW1, m.itemW2, features, embeddings)
+}
+
+func (m *TwoTower) encode(table, w1, w2 *Tensor, features [][]lo.Tuple2[int32, float32], embeddings [][]float32) [][]float32 {
+	vectors := make([][]float32, 0, len(features))
+	for i := 0; i < len(features); i += m.batchSize {
+		j := min(i+m.batchSize, len(features))
+		var batchEmbeddings [][]float32
+		if embeddings != nil {
+			batchEmbeddings = embeddings[i:j]
+		}
+		data := m.forward(table, w1, w2, features[i:j], batchEmbeddings).Data()
+		for k := 0; k < j-i; k++ {
+			vectors = append(vectors, data[k*m.nFactors:(k+1)*m.nFactors])
+		}
+	}
+	return vectors
+}
+
+// Marshal model into byte stream.
+func (m *TwoTower) Marshal(w io.Writer) error {
+	// write params
+	if err := encoding.WriteGob(w, m.Params); err != nil {
+		return errors.Trace(err)
+	}
+	// write vocabularies
+	if err := base2.MarshalIndex(w, m.UserFeatures); err != nil {
+		return errors.Trace(err)
+	}
+	if err := base2.MarshalIndex(w, m.ItemFeatures); err != nil {
+		return errors.Trace(err)
+	}
+	// write weights
+	for _, t := range []*Tensor{m.userEmbedding, m.itemEmbedding, m.denseWeight, m.userW1, m.userW2, m.itemW1, m.itemW2} {
+		if err := encoding.WriteGob(w, t.Shape()); err != nil {
+			return errors.Trace(err)
+		}
+		if err := encoding.WriteGob(w, t.Data()); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	return nil
+}
+
+// Unmarshal model from byte stream.
+func (m *TwoTower) Unmarshal(r io.Reader) error {
+	// read params
+	var params model.Params
+	if err := encoding.ReadGob(r, &params); err != nil {
+		return errors.Trace(err)
+	}
+	m.SetParams(params)
+	// read vocabularies
+	var err error
+	if m.UserFeatures, err = base2.UnmarshalIndex(r); err != nil {
+		return errors.Trace(err)
+	}
+	if m.ItemFeatures, err = base2.UnmarshalIndex(r); err != nil {
+		return errors.Trace(err)
+	}
+	// read weights
+	for _, t := range []**Tensor{&m.userEmbedding, &m.itemEmbedding, &m.denseWeight, &m.userW1, &m.userW2, &m.itemW1, &m.itemW2} {
+		var shape []int
+		if err = encoding.ReadGob(r, &shape); err != nil {
+			return errors.Trace(err)
+		}
+		var data []float32
+		if err = encoding.ReadGob(r, &data); err != nil {
+			return errors.Trace(err)
+		}
+		*t = NewTensor(data, shape...)
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+// Copyright 2024 gorse Project Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nn
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"github.com/chewxy/math32"
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/assert"
+	"github.com/zhenghaoz/gorse/base/floats"
+	"github.com/zhenghaoz/gorse/model"
+)
+
+func TestTwoTower(t *testing.T) {
+	// items are either red or blue, and users like items in one color
+	colors := []string{"red", "blue"}
+	var items []TowerInput
+	for i := 0; i < 40; i++ {
+		items = append(items, TowerInput{
+			Id:        fmt.Sprintf("%s%d", colors[i%2], i),
+			Features:  []lo.Tuple2[string, float32]{{A: colors[i%2], B: 1}},
+			Embedding: []float32{float32(1 - i%2), float32(i % 2)},
+		})
+	}
+	var users []TowerInput
+	var feedbackUsers, feedbackItems []int32
+	rng := rand.New(rand.NewSource(0))
+	for i := 0; i < 40; i++ {
+		user := TowerInput{Id: fmt.Sprintf("user%d", i)}
+		for j := 0; j < 5; j++ {
+			itemIndex := int32(rng.Intn(20)*2 + i%2)
+			user.History = append(user.History, items[itemIndex].Id)
+			feedbackUsers = append(feedbackUsers, int32(i))
+			feedbackItems = append(feedbackItems, itemIndex)
+		}
+		users = append(users, user)
+	}
+	m := NewTwoTower(model.Params{
+		model.NFactors:     16,
+		model.NEpochs:      30,
+		model.BatchSize:    32,
+		model.EmbeddingDim: 2,
+		model.Lr:           0.01,
+	})
+	loss := m.Fit(context.Background(), users, items, feedbackUsers, feedbackItems)
+	assert.False(t, m.Invalid())
+	assert.Less(t, loss, float32(3))
+
+	// fresh items are encoded by labels or embeddings
+	freshItems := m.EncodeItems([]TowerInput{
+		{Id: "new_red", Features: []lo.Tuple2[string, float32]{{A: "red", B: 1}}},
+		{Id: "new_blue", Features: []lo.Tuple2[string, float32]{{A: "blue", B: 1}}},
+		{Id: "new_red_image", Embedding: []float32{1, 0}},
+		{Id: "new_blue_image", Embedding: []float32{0, 1}},
+	})
+	assert.Len(t, freshItems, 4)
+	assert.Len(t, freshItems[0], 16)
+	userVectors := m.EncodeUsers([]TowerInput{
+		users[0],
+		users[1],
+		// new user is encoded by history
+		{Id: "new_user", History: []string{"blue1", "blue3", "blue5"}},
+	})
+	assert.Greater(t, floats.Dot(userVectors[0], freshItems[0]), floats.Dot(userVectors[0], freshItems[1]))
+	assert.Greater(t, floats.Dot(userVectors[0], freshItems[2]), floats.Dot(userVectors[0], freshItems[3]))
+	assert.Greater(t, floats.Dot(userVectors[1], freshItems[1]), floats.Dot(userVectors[1], freshItems[0]))
+	assert.Greater(t, floats.Dot(userVectors[1], freshItems[3]), floats.Dot(userVectors[1], freshItems[2]))
+	assert.Greater(t, floats.Dot(userVectors[2], freshItems[1]), floats.Dot(userVectors[2], freshItems[0]))
+
+	// marshal model
+	buf := bytes.NewBuffer(nil)
+	err := m.Marshal(buf)
+	assert.NoError(t, err)
+	copied := new(TwoTower)
+	err = copied.Unmarshal(buf)
+	assert.NoError(t, err)
+	assert.Equal(t, m.GetParams(), copied.GetParams())
+	assert.Equal(t, userVectors, copied.EncodeUsers([]TowerInput{users[0], users[1], {Id: "new_user", History: []string{"blue1", "blue3", "blue5"}}}))
+
+	// clear model
+	m.Clear()
+	assert.True(t, m.Invalid())
+}
+
+func TestLogSamplingProbabilities(t *testing.T) {
+	logQ := logSamplingProbabilities([]int32{0, 0, 0, 1}, 3)
+	assert.InDeltaSlice(t, []float32{math32.Log(0.75), math32.Log(0.25), 0}, logQ, 1e-6)
+}
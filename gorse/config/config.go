@@ -19,6 +19,7 @@ import (
 	"crypto/md5"
 	"encoding/hex"
 	"fmt"
+	"math"
 	"os"
 	"reflect"
 	"strings"
@@ -47,6 +48,12 @@ import (
 	"go.uber.org/zap"
 )
 
+const (
+	DecayNone        = "none"
+	DecayExponential = "exponential"
+	DecayStep        = "step"
+)
+
 const (
 	NeighborTypeAuto    = "auto"
 	NeighborTypeSimilar = "similar"
@@ -62,6 +69,12 @@ type Config struct {
 	Tracing      TracingConfig      `mapstructure:"tracing"`
 	Experimental ExperimentalConfig `mapstructure:"experimental"`
 	OIDC         OIDCConfig         `mapstructure:"oidc"`
+	Events       EventsConfig       `mapstructure:"events"`
+	Tenants      []TenantConfig     `mapstructure:"tenants" validate:"dive"`
+	Experiments  []ExperimentConfig `mapstructure:"experiments" validate:"dive"`
+
+	// configurations of experiment arms, which are shared by users assigned to the same variants
+	experimentConfigs experimentConfigCache
 }
 
 // DatabaseConfig is the configuration for the database.
@@ -75,7 +88,8 @@ type DatabaseConfig struct {
 }
 
 type MySQLConfig struct {
-	IsolationLevel string `mapstructure:"isolation_level" validate:"oneof=READ-UNCOMMITTED READ-COMMITTED REPEATABLE-READ SERIALIZABLE"`
+	IsolationLevel string   `mapstructure:"isolation_level" validate:"oneof=READ-UNCOMMITTED READ-COMMITTED REPEATABLE-READ SERIALIZABLE"`
+	IndexedLabels  []string `mapstructure:"indexed_labels"` // paths of labels indexed by generated columns
 }
 
 // MasterConfig is the configuration for the master.
@@ -106,6 +120,8 @@ type ServerConfig struct {
 	AutoInsertUser bool          `mapstructure:"auto_insert_user"`             // insert new users while inserting feedback
 	AutoInsertItem bool          `mapstructure:"auto_insert_item"`             // insert new items while inserting feedback
 	CacheExpire    time.Duration `mapstructure:"cache_expire" validate:"gt=0"` // server-side cache expire time
+	LogImpressions bool          `mapstructure:"log_impressions"`              // log served recommendations as impressions
+	DeckTTL        time.Duration `mapstructure:"deck_ttl" validate:"gt=0"`     // time to live of idle swipe decks
 }
 
 // RecommendConfig is the configuration of recommendation setup.
@@ -123,13 +139,83 @@ type RecommendConfig struct {
 	Offline         OfflineConfig           `mapstructure:"offline"`
 	Online          OnlineConfig            `mapstructure:"online"`
 	ImageEmbeddings ImageEmbeddingConfig    `mapstructure:"image_embeddings"`
+	Onboarding      OnboardingConfig        `mapstructure:"onboarding"`
+	Session         SessionConfig           `mapstructure:"session"`
 }
 
 type DataSourceConfig struct {
-	PositiveFeedbackTypes []string `mapstructure:"positive_feedback_types"`                // positive feedback type
-	ReadFeedbackTypes     []string `mapstructure:"read_feedback_types"`                    // feedback type for read event
-	PositiveFeedbackTTL   uint     `mapstructure:"positive_feedback_ttl" validate:"gte=0"` // time-to-live of positive feedbacks
-	ItemTTL               uint     `mapstructure:"item_ttl" validate:"gte=0"`              // item-to-live of items
+	PositiveFeedbackTypes []string             `mapstructure:"positive_feedback_types"`                // positive feedback type
+	ReadFeedbackTypes     []string             `mapstructure:"read_feedback_types"`                    // feedback type for read event
+	PositiveFeedbackTTL   uint                 `mapstructure:"positive_feedback_ttl" validate:"gte=0"` // time-to-live of positive feedbacks
+	ItemTTL               uint                 `mapstructure:"item_ttl" validate:"gte=0"`              // item-to-live of items
+	FeedbackWeights       map[string]float64   `mapstructure:"feedback_weights" validate:"dive,gte=0"` // default confidence of feedback types
+	Decay                 DecayConfig          `mapstructure:"decay"`                                  // time decay of feedback
+	NumericLabels         []NumericLabelConfig `mapstructure:"numeric_labels" validate:"dive"`         // transformations of numeric labels
+}
+
+// NumericLabelConfig is the transformation of a numeric label of users and items.
+type NumericLabelConfig struct {
+	Name       string `mapstructure:"name" validate:"required"`                            // name of the label, nested labels are joined by dots
+	Transform  string `mapstructure:"transform" validate:"oneof=none log zscore quantile"` // transformation of values
+	NumBuckets int    `mapstructure:"num_buckets" validate:"gte=0"`                        // number of quantile buckets
+}
+
+// Confidence returns the confidence of a feedback. The value of the feedback is used if it is positive, otherwise the
+// weight of the feedback type is used. The confidence is 1 if neither is set.
+func (config *DataSourceConfig) Confidence(feedbackType string, value float64) float64 {
+	if value > 0 {
+		return value
+	}
+	weight, exist := config.FeedbackWeights[feedbackType]
+	if !exist {
+		// keys of maps are lowercased by viper
+		weight, exist = config.FeedbackWeights[strings.ToLower(feedbackType)]
+	}
+	if exist {
+		return weight
+	}
+	return 1
+}
+
+type DecayConfig struct {
+	Function   string        `mapstructure:"function" validate:"oneof=none exponential step ''"` // decay function
+	HalfLife   time.Duration `mapstructure:"half_life" validate:"gt=0"`                          // half-life of exponential decay
+	StepAge    time.Duration `mapstructure:"step_age" validate:"gt=0"`                           // age of feedback to be decayed by step decay
+	StepWeight float64       `mapstructure:"step_weight" validate:"gte=0,lte=1"`                 // weight of feedback older than step age
+}
+
+// Enabled returns true if feedback is decayed by time.
+func (config *DecayConfig) Enabled() bool {
+	return config.Function == DecayExponential || config.Function == DecayStep
+}
+
+// Weight returns the decayed weight of a feedback inserted at timestamp. Feedback without timestamp or from the future
+// is not decayed.
+func (config *DecayConfig) Weight(timestamp, now time.Time) float64 {
+	if timestamp.IsZero() || !timestamp.Before(now) {
+		return 1
+	}
+	age := now.Sub(timestamp)
+	switch config.Function {
+	case DecayExponential:
+		return math.Exp2(-float64(age) / float64(config.HalfLife))
+	case DecayStep:
+		if age > config.StepAge {
+			return config.StepWeight
+		}
+	}
+	return 1
+}
+
+// Digest returns the digest of decay options. It is empty if decay is disabled.
+func (config *DecayConfig) Digest() string {
+	switch config.Function {
+	case DecayExponential:
+		return fmt.Sprintf("%s-%v", config.Function, config.HalfLife)
+	case DecayStep:
+		return fmt.Sprintf("%s-%v-%v", config.Function, config.StepAge, config.StepWeight)
+	}
+	return ""
 }
 
 type NonPersonalizedConfig struct {
@@ -150,14 +236,18 @@ type NeighborsConfig struct {
 }
 
 type CollaborativeConfig struct {
-	ModelFitPeriod        time.Duration `mapstructure:"model_fit_period" validate:"gt=0"`
-	ModelSearchPeriod     time.Duration `mapstructure:"model_search_period" validate:"gt=0"`
-	ModelSearchEpoch      int           `mapstructure:"model_search_epoch" validate:"gt=0"`
-	ModelSearchTrials     int           `mapstructure:"model_search_trials" validate:"gt=0"`
-	EnableModelSizeSearch bool          `mapstructure:"enable_model_size_search"`
-	EnableIndex           bool          `mapstructure:"enable_index"`
-	IndexRecall           float32       `mapstructure:"index_recall" validate:"gt=0"`
-	IndexFitEpoch         int           `mapstructure:"index_fit_epoch" validate:"gt=0"`
+	ModelFitPeriod                 time.Duration `mapstructure:"model_fit_period" validate:"gt=0"`
+	ModelSearchPeriod              time.Duration `mapstructure:"model_search_period" validate:"gt=0"`
+	ModelSearchEpoch               int           `mapstructure:"model_search_epoch" validate:"gt=0"`
+	ModelSearchTrials              int           `mapstructure:"model_search_trials" validate:"gt=0"`
+	ModelSearchStrategy            string        `mapstructure:"model_search_strategy" validate:"oneof=random tpe ''"`
+	EnableModelSearchEarlyStopping bool          `mapstructure:"enable_model_search_early_stopping"`
+	EnableModelSizeSearch          bool          `mapstructure:"enable_model_size_search"`
+	EnableIndex                    bool          `mapstructure:"enable_index"`
+	IndexRecall                    float32       `mapstructure:"index_recall" validate:"gt=0"`
+	IndexFitEpoch                  int           `mapstructure:"index_fit_epoch" validate:"gt=0"`
+	ModelRegistrySize              int           `mapstructure:"model_registry_size" validate:"gte=0"`
+	EnableShadowMode               bool          `mapstructure:"enable_shadow_mode"`
 }
 
 type ReplacementConfig struct {
@@ -175,10 +265,35 @@ type OfflineConfig struct {
 	EnableUserBasedRecommend     bool               `mapstructure:"enable_user_based_recommend"`
 	EnableItemBasedRecommend     bool               `mapstructure:"enable_item_based_recommend"`
 	EnableColRecommend           bool               `mapstructure:"enable_collaborative_recommend"`
+	EnableTwoTowerRecommend      bool               `mapstructure:"enable_two_tower_recommend"`
 	EnableClickThroughPrediction bool               `mapstructure:"enable_click_through_prediction"`
+	ClickThroughCalibration      string             `mapstructure:"click_through_calibration" validate:"oneof=none platt isotonic ''"`
+	ClickThroughMargin           int                `mapstructure:"click_through_margin" validate:"gte=0"`
+	EnableClickThroughShadowMode bool               `mapstructure:"enable_click_through_shadow_mode"`
+	EnableRealtimeRefresh        bool               `mapstructure:"enable_realtime_refresh"`
+	RealtimeRefreshPeriod        time.Duration      `mapstructure:"realtime_refresh_period" validate:"gt=0"`
+	RealtimeRefreshDebounce      time.Duration      `mapstructure:"realtime_refresh_debounce" validate:"gte=0"`
+	RealtimeRefreshInterval      time.Duration      `mapstructure:"realtime_refresh_interval" validate:"gte=0"`
 	exploreRecommendLock         sync.RWMutex
 }
 
+// OnboardingConfig is the configuration of cold-start recommendation from onboarding quizzes.
+type OnboardingConfig struct {
+	FeedbackType string  `mapstructure:"feedback_type" validate:"required"` // feedback type of seed items
+	Weight       float64 `mapstructure:"weight" validate:"gte=0,lte=1"`     // fraction of recommendation from onboarding
+	NumFeedback  int     `mapstructure:"num_feedback" validate:"gt=0"`      // number of organic positive feedback to fade out
+}
+
+// SessionConfig is the configuration of the session model trained on sessions of positive feedback.
+type SessionConfig struct {
+	SessionTimeout    time.Duration `mapstructure:"session_timeout" validate:"gte=0"` // inactivity splitting sessions, 0 disables splitting
+	NFactors          int           `mapstructure:"n_factors" validate:"gt=0"`        // number of factors without model search
+	NEpochs           int           `mapstructure:"n_epochs" validate:"gt=0"`         // number of training epochs
+	MaxLength         int           `mapstructure:"max_length" validate:"gt=0"`       // max number of recent items in a session
+	Lr                float64       `mapstructure:"lr" validate:"gt=0"`               // learning rate without model search
+	EnableModelSearch bool          `mapstructure:"enable_model_search"`              // search factors and learning rates
+}
+
 type OnlineConfig struct {
 	FallbackRecommend            []string `mapstructure:"fallback_recommend"`
 	NumFeedbackFallbackItemBased int      `mapstructure:"num_feedback_fallback_item_based" validate:"gt=0"`
@@ -205,6 +320,33 @@ type OIDCConfig struct {
 	RedirectURL  string `mapstructure:"redirect_url" validate:"omitempty,endswith=/callback/oauth2"`
 }
 
+// EventsConfig is the configuration of change events. If enabled, nodes write change events to the outbox in the data
+// store and the master delivers them to webhooks.
+type EventsConfig struct {
+	Enable        bool            `mapstructure:"enable"`
+	BatchSize     int             `mapstructure:"batch_size" validate:"gt=0"`
+	PollInterval  time.Duration   `mapstructure:"poll_interval" validate:"gt=0"`
+	MaxRetries    int             `mapstructure:"max_retries" validate:"gte=0"`
+	MaxFailures   int             `mapstructure:"max_failures" validate:"gt=0"`
+	RetryInterval time.Duration   `mapstructure:"retry_interval" validate:"gt=0"`
+	Timeout       time.Duration   `mapstructure:"timeout" validate:"gt=0"`
+	Webhooks      []WebhookConfig `mapstructure:"webhooks" validate:"dive"`
+}
+
+// WebhookConfig is a consumer of change events. Requests are signed by HMAC-SHA256 with the secret if it is set. All
+// event types are delivered if types are empty.
+type WebhookConfig struct {
+	Name   string   `mapstructure:"name" validate:"required"`
+	URL    string   `mapstructure:"url" validate:"required,url"`
+	Secret string   `mapstructure:"secret"`
+	Types  []string `mapstructure:"types"`
+}
+
+// Accept returns true if events of the type are delivered to the webhook.
+func (config *WebhookConfig) Accept(eventType string) bool {
+	return len(config.Types) == 0 || lo.Contains(config.Types, eventType)
+}
+
 type ImageEmbeddingConfig struct {
 	// Whether to enable image-based recommendations
 	EnableImageRecommend bool `mapstructure:"enable_image_recommend"`
@@ -214,6 +356,8 @@ type ImageEmbeddingConfig struct {
 	ImageWeight float64 `mapstructure:"image_weight" validate:"gte=0,lte=1"`
 	// Number of similar items to consider for recommendations
 	NumSimilar int `mapstructure:"num_similar" validate:"gt=0"`
+	// Label of items storing image embeddings
+	EmbeddingLabel string `mapstructure:"embedding_label"`
 }
 
 func GetDefaultConfig() *Config {
@@ -239,10 +383,19 @@ func GetDefaultConfig() *Config {
 			AutoInsertUser: true,
 			AutoInsertItem: true,
 			CacheExpire:    10 * time.Second,
+			DeckTTL:        time.Hour,
 		},
 		Recommend: RecommendConfig{
 			CacheSize:   100,
 			CacheExpire: 72 * time.Hour,
+			DataSource: DataSourceConfig{
+				Decay: DecayConfig{
+					Function:   DecayNone,
+					HalfLife:   30 * 24 * time.Hour,
+					StepAge:    90 * 24 * time.Hour,
+					StepWeight: 0.5,
+				},
+			},
 			Popular: PopularConfig{
 				PopularWindow: 180 * 24 * time.Hour,
 			},
@@ -259,13 +412,15 @@ func GetDefaultConfig() *Config {
 				IndexFitEpoch: 3,
 			},
 			Collaborative: CollaborativeConfig{
-				ModelFitPeriod:    60 * time.Minute,
-				ModelSearchPeriod: 180 * time.Minute,
-				ModelSearchEpoch:  100,
-				ModelSearchTrials: 10,
-				EnableIndex:       true,
-				IndexRecall:       0.9,
-				IndexFitEpoch:     3,
+				ModelFitPeriod:      60 * time.Minute,
+				ModelSearchPeriod:   180 * time.Minute,
+				ModelSearchEpoch:    100,
+				ModelSearchTrials:   10,
+				ModelSearchStrategy: "random",
+				EnableIndex:         true,
+				IndexRecall:         0.9,
+				IndexFitEpoch:       3,
+				ModelRegistrySize:   10,
 			},
 			Replacement: ReplacementConfig{
 				EnableReplacement:        false,
@@ -280,7 +435,14 @@ func GetDefaultConfig() *Config {
 				EnableUserBasedRecommend:     false,
 				EnableItemBasedRecommend:     false,
 				EnableColRecommend:           true,
+				EnableTwoTowerRecommend:      false,
 				EnableClickThroughPrediction: false,
+				ClickThroughCalibration:      "platt",
+				ClickThroughMargin:           100,
+				EnableRealtimeRefresh:        false,
+				RealtimeRefreshPeriod:        time.Second,
+				RealtimeRefreshDebounce:      5 * time.Second,
+				RealtimeRefreshInterval:      time.Minute,
 			},
 			Online: OnlineConfig{
 				FallbackRecommend:            []string{"latest"},
@@ -291,6 +453,19 @@ func GetDefaultConfig() *Config {
 				EmbeddingDim: 512,
 				ImageWeight: 0.5,
 				NumSimilar: 100,
+				EmbeddingLabel: "embedding",
+			},
+			Onboarding: OnboardingConfig{
+				FeedbackType: "onboarding",
+				Weight:       0.5,
+				NumFeedback:  10,
+			},
+			Session: SessionConfig{
+				SessionTimeout: 30 * time.Minute,
+				NFactors:       16,
+				NEpochs:        20,
+				MaxLength:      20,
+				Lr:             0.005,
 			},
 		},
 		Tracing: TracingConfig{
@@ -300,6 +475,14 @@ func GetDefaultConfig() *Config {
 		Experimental: ExperimentalConfig{
 			DeepLearningBatchSize: 128,
 		},
+		Events: EventsConfig{
+			BatchSize:     100,
+			PollInterval:  time.Second,
+			MaxRetries:    3,
+			MaxFailures:   10,
+			RetryInterval: time.Second,
+			Timeout:       10 * time.Second,
+		},
 	}
 }
 
@@ -313,6 +496,9 @@ func (config *Config) UserNeighborDigest() string {
 	// feedback option
 	if lo.Contains([]string{"auto", "related"}, config.Recommend.UserNeighbors.NeighborType) {
 		builder.WriteString(fmt.Sprintf("-%s", strings.Join(config.Recommend.DataSource.PositiveFeedbackTypes, "-")))
+		if config.Recommend.DataSource.Decay.Enabled() {
+			builder.WriteString(fmt.Sprintf("-%s", config.Recommend.DataSource.Decay.Digest()))
+		}
 	} else {
 		builder.WriteString("-")
 	}
@@ -333,6 +519,9 @@ func (config *Config) ItemNeighborDigest() string {
 	// feedback option
 	if lo.Contains([]string{"auto", "related"}, config.Recommend.ItemNeighbors.NeighborType) {
 		builder.WriteString(fmt.Sprintf("-%s", strings.Join(config.Recommend.DataSource.PositiveFeedbackTypes, "-")))
+		if config.Recommend.DataSource.Decay.Enabled() {
+			builder.WriteString(fmt.Sprintf("-%s", config.Recommend.DataSource.Decay.Digest()))
+		}
 	} else {
 		builder.WriteString("-")
 	}
@@ -420,6 +609,9 @@ func (config *Config) OfflineRecommendDigest(option ...DigestOption) string {
 				config.Recommend.Collaborative.IndexRecall, config.Recommend.Collaborative.IndexFitEpoch))
 		}
 	}
+	if config.Recommend.Offline.EnableTwoTowerRecommend {
+		builder.WriteString("-two_tower")
+	}
 	if config.Recommend.Replacement.EnableReplacement {
 		builder.WriteString(fmt.Sprintf("-%v-%v",
 			config.Recommend.Replacement.PositiveReplacementDecay, config.Recommend.Replacement.ReadReplacementDecay))
@@ -534,9 +726,16 @@ func setDefault() {
 	viper.SetDefault("server.auto_insert_user", defaultConfig.Server.AutoInsertUser)
 	viper.SetDefault("server.auto_insert_item", defaultConfig.Server.AutoInsertItem)
 	viper.SetDefault("server.cache_expire", defaultConfig.Server.CacheExpire)
+	viper.SetDefault("server.log_impressions", defaultConfig.Server.LogImpressions)
+	viper.SetDefault("server.deck_ttl", defaultConfig.Server.DeckTTL)
 	// [recommend]
 	viper.SetDefault("recommend.cache_size", defaultConfig.Recommend.CacheSize)
 	viper.SetDefault("recommend.cache_expire", defaultConfig.Recommend.CacheExpire)
+	// [recommend.data_source.decay]
+	viper.SetDefault("recommend.data_source.decay.function", defaultConfig.Recommend.DataSource.Decay.Function)
+	viper.SetDefault("recommend.data_source.decay.half_life", defaultConfig.Recommend.DataSource.Decay.HalfLife)
+	viper.SetDefault("recommend.data_source.decay.step_age", defaultConfig.Recommend.DataSource.Decay.StepAge)
+	viper.SetDefault("recommend.data_source.decay.step_weight", defaultConfig.Recommend.DataSource.Decay.StepWeight)
 	// [recommend.popular]
 	viper.SetDefault("recommend.popular.popular_window", defaultConfig.Recommend.Popular.PopularWindow)
 	// [recommend.user_neighbors]
@@ -554,9 +753,11 @@ func setDefault() {
 	viper.SetDefault("recommend.collaborative.model_search_period", defaultConfig.Recommend.Collaborative.ModelSearchPeriod)
 	viper.SetDefault("recommend.collaborative.model_search_epoch", defaultConfig.Recommend.Collaborative.ModelSearchEpoch)
 	viper.SetDefault("recommend.collaborative.model_search_trials", defaultConfig.Recommend.Collaborative.ModelSearchTrials)
+	viper.SetDefault("recommend.collaborative.model_search_strategy", defaultConfig.Recommend.Collaborative.ModelSearchStrategy)
 	viper.SetDefault("recommend.collaborative.enable_index", defaultConfig.Recommend.Collaborative.EnableIndex)
 	viper.SetDefault("recommend.collaborative.index_recall", defaultConfig.Recommend.Collaborative.IndexRecall)
 	viper.SetDefault("recommend.collaborative.index_fit_epoch", defaultConfig.Recommend.Collaborative.IndexFitEpoch)
+	viper.SetDefault("recommend.collaborative.model_registry_size", defaultConfig.Recommend.Collaborative.ModelRegistrySize)
 	// [recommend.replacement]
 	viper.SetDefault("recommend.replacement.enable_replacement", defaultConfig.Recommend.Replacement.EnableReplacement)
 	viper.SetDefault("recommend.replacement.positive_replacement_decay", defaultConfig.Recommend.Replacement.PositiveReplacementDecay)
@@ -569,7 +770,14 @@ func setDefault() {
 	viper.SetDefault("recommend.offline.enable_user_based_recommend", defaultConfig.Recommend.Offline.EnableUserBasedRecommend)
 	viper.SetDefault("recommend.offline.enable_item_based_recommend", defaultConfig.Recommend.Offline.EnableItemBasedRecommend)
 	viper.SetDefault("recommend.offline.enable_collaborative_recommend", defaultConfig.Recommend.Offline.EnableColRecommend)
+	viper.SetDefault("recommend.offline.enable_two_tower_recommend", defaultConfig.Recommend.Offline.EnableTwoTowerRecommend)
 	viper.SetDefault("recommend.offline.enable_click_through_prediction", defaultConfig.Recommend.Offline.EnableClickThroughPrediction)
+	viper.SetDefault("recommend.offline.click_through_calibration", defaultConfig.Recommend.Offline.ClickThroughCalibration)
+	viper.SetDefault("recommend.offline.click_through_margin", defaultConfig.Recommend.Offline.ClickThroughMargin)
+	viper.SetDefault("recommend.offline.enable_realtime_refresh", defaultConfig.Recommend.Offline.EnableRealtimeRefresh)
+	viper.SetDefault("recommend.offline.realtime_refresh_period", defaultConfig.Recommend.Offline.RealtimeRefreshPeriod)
+	viper.SetDefault("recommend.offline.realtime_refresh_debounce", defaultConfig.Recommend.Offline.RealtimeRefreshDebounce)
+	viper.SetDefault("recommend.offline.realtime_refresh_interval", defaultConfig.Recommend.Offline.RealtimeRefreshInterval)
 	// [recommend.online]
 	viper.SetDefault("recommend.online.fallback_recommend", defaultConfig.Recommend.Online.FallbackRecommend)
 	viper.SetDefault("recommend.online.num_feedback_fallback_item_based", defaultConfig.Recommend.Online.NumFeedbackFallbackItemBased)
@@ -583,6 +791,23 @@ func setDefault() {
 	viper.SetDefault("recommend.image_embeddings.embedding_dim", defaultConfig.Recommend.ImageEmbeddings.EmbeddingDim)
 	viper.SetDefault("recommend.image_embeddings.image_weight", defaultConfig.Recommend.ImageEmbeddings.ImageWeight)
 	viper.SetDefault("recommend.image_embeddings.num_similar", defaultConfig.Recommend.ImageEmbeddings.NumSimilar)
+	viper.SetDefault("recommend.image_embeddings.embedding_label", defaultConfig.Recommend.ImageEmbeddings.EmbeddingLabel)
+	viper.SetDefault("recommend.onboarding.feedback_type", defaultConfig.Recommend.Onboarding.FeedbackType)
+	viper.SetDefault("recommend.onboarding.weight", defaultConfig.Recommend.Onboarding.Weight)
+	viper.SetDefault("recommend.onboarding.num_feedback", defaultConfig.Recommend.Onboarding.NumFeedback)
+	viper.SetDefault("recommend.session.session_timeout", defaultConfig.Recommend.Session.SessionTimeout)
+	viper.SetDefault("recommend.session.n_factors", defaultConfig.Recommend.Session.NFactors)
+	viper.SetDefault("recommend.session.n_epochs", defaultConfig.Recommend.Session.NEpochs)
+	viper.SetDefault("recommend.session.max_length", defaultConfig.Recommend.Session.MaxLength)
+	viper.SetDefault("recommend.session.lr", defaultConfig.Recommend.Session.Lr)
+	viper.SetDefault("recommend.session.enable_model_search", defaultConfig.Recommend.Session.EnableModelSearch)
+	// [events]
+	viper.SetDefault("events.batch_size", defaultConfig.Events.BatchSize)
+	viper.SetDefault("events.poll_interval", defaultConfig.Events.PollInterval)
+	viper.SetDefault("events.max_retries", defaultConfig.Events.MaxRetries)
+	viper.SetDefault("events.max_failures", defaultConfig.Events.MaxFailures)
+	viper.SetDefault("events.retry_interval", defaultConfig.Events.RetryInterval)
+	viper.SetDefault("events.timeout", defaultConfig.Events.Timeout)
 }
 
 type configBinding struct {
@@ -663,6 +888,21 @@ func LoadConfig(path string, oneModel bool) (*Config, error) {
 }
 
 func (config *Config) Validate(oneModel bool) error {
+	if err := validateStruct(config); err != nil {
+		return err
+	}
+	names := lo.Map(config.Events.Webhooks, func(webhook WebhookConfig, _ int) string { return webhook.Name })
+	if duplicates := lo.FindDuplicates(names); len(duplicates) > 0 {
+		return errors.Errorf("duplicate webhooks: %v", duplicates)
+	}
+	if err := config.ValidateTenants(); err != nil {
+		return errors.Trace(err)
+	}
+	return config.ValidateExperiments(config.Experiments)
+}
+
+// validateStruct checks fields of a configuration struct by validation tags.
+func validateStruct(s any) error {
 	validate := validator.New()
 	if err := validate.RegisterValidation("data_store", func(fl validator.FieldLevel) bool {
 		prefixes := []string{
@@ -714,7 +954,7 @@ func (config *Config) Validate(oneModel bool) error {
 	validate.RegisterTagNameFunc(func(fld reflect.StructField) string {
 		return strings.SplitN(fld.Tag.Get("mapstructure"), ",", 2)[0]
 	})
-	err := validate.Struct(config)
+	err := validate.Struct(s)
 	if err != nil {
 		// translate errors
 		trans := ut.New(en.New()).GetFallback()
@@ -0,0 +1,288 @@
+// Copyright 2024 gorse Project Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"crypto/md5"
+	"encoding/binary"
+	"encoding/json"
+	"math"
+	"strings"
+	"sync"
+
+	"github.com/go-viper/mapstructure/v2"
+	"github.com/juju/errors"
+	"github.com/zhenghaoz/gorse/base/log"
+	"go.uber.org/zap"
+)
+
+// ExperimentConfig is the configuration of an A/B experiment. A fraction of users (traffic) is enrolled into the
+// experiment and each enrolled user is assigned to one of the variants by a salted hash of the user ID.
+type ExperimentConfig struct {
+	Name     string          `mapstructure:"name" json:"name" validate:"required"`
+	Salt     string          `mapstructure:"salt" json:"salt"`
+	Traffic  float64         `mapstructure:"traffic" json:"traffic" validate:"gt=0,lte=1"`
+	Variants []VariantConfig `mapstructure:"variants" json:"variants" validate:"required,dive"`
+}
+
+// VariantConfig is a variant of an experiment. Override is a partial recommend configuration using the same keys as
+// the [recommend] section, which is applied on top of the global configuration for users assigned to the variant.
+type VariantConfig struct {
+	Name     string         `mapstructure:"name" json:"name" validate:"required"`
+	Weight   float64        `mapstructure:"weight" json:"weight" validate:"gte=0"`
+	Override map[string]any `mapstructure:"override" json:"override"`
+}
+
+// ExperimentAssignment is the variant of an experiment assigned to a user.
+type ExperimentAssignment struct {
+	Experiment string
+	Variant    string
+}
+
+// hash returns a number in [0, 1) by hashing the salt and the user ID.
+func (experiment *ExperimentConfig) hash(userId string) float64 {
+	salt := experiment.Salt
+	if salt == "" {
+		salt = experiment.Name
+	}
+	digest := md5.Sum([]byte(salt + "/" + userId))
+	// use the top 53 bits to keep the precision of float64
+	return float64(binary.BigEndian.Uint64(digest[:8])>>11) / float64(1<<53)
+}
+
+// Assign returns the variant assigned to a user. The assignment is sticky: the same user is always assigned to the
+// same variant as long as the salt, traffic and variant weights are unchanged.
+func (experiment *ExperimentConfig) Assign(userId string) (*VariantConfig, bool) {
+	if len(experiment.Variants) == 0 {
+		return nil, false
+	}
+	u := experiment.hash(userId)
+	if u >= experiment.Traffic {
+		return nil, false
+	}
+	// rescale the hash value to pick a variant
+	u /= experiment.Traffic
+	var totalWeight float64
+	for _, variant := range experiment.Variants {
+		totalWeight += variantWeight(variant)
+	}
+	threshold := u * totalWeight
+	var cumulative float64
+	for i := range experiment.Variants {
+		cumulative += variantWeight(experiment.Variants[i])
+		if threshold < cumulative {
+			return &experiment.Variants[i], true
+		}
+	}
+	return &experiment.Variants[len(experiment.Variants)-1], true
+}
+
+// variantWeight returns the weight of a variant. Variants without weight are weighted equally.
+func variantWeight(variant VariantConfig) float64 {
+	if variant.Weight == 0 {
+		return 1
+	}
+	return variant.Weight
+}
+
+// AssignExperiments returns variants of all experiments assigned to a user.
+func (config *Config) AssignExperiments(userId string) []ExperimentAssignment {
+	var assignments []ExperimentAssignment
+	for i := range config.Experiments {
+		if variant, ok := config.Experiments[i].Assign(userId); ok {
+			assignments = append(assignments, ExperimentAssignment{
+				Experiment: config.Experiments[i].Name,
+				Variant:    variant.Name,
+			})
+		}
+	}
+	return assignments
+}
+
+// ArmShare returns the share of traffic routed to the experiment arm of a user, which is the product of shares of
+// assigned variants (or of the control group) over all experiments. It is 1 if there is no experiment.
+func (config *Config) ArmShare(userId string) float64 {
+	share := 1.0
+	for i := range config.Experiments {
+		experiment := &config.Experiments[i]
+		if len(experiment.Variants) == 0 {
+			continue
+		}
+		variant, ok := experiment.Assign(userId)
+		if !ok {
+			share *= 1 - experiment.Traffic
+			continue
+		}
+		var totalWeight float64
+		for _, v := range experiment.Variants {
+			totalWeight += variantWeight(v)
+		}
+		share *= experiment.Traffic * variantWeight(*variant) / totalWeight
+	}
+	return share
+}
+
+// ForUser returns the configuration with overrides of assigned variants applied. The original configuration is
+// returned if the user isn't enrolled in any experiment or there is no override. Configurations of experiment arms
+// are cached and shared by users, so the returned configuration must not be modified.
+func (config *Config) ForUser(userId string) *Config {
+	var overrides []map[string]any
+	var arm strings.Builder
+	for i := range config.Experiments {
+		if variant, ok := config.Experiments[i].Assign(userId); ok && len(variant.Override) > 0 {
+			overrides = append(overrides, variant.Override)
+			arm.WriteString(config.Experiments[i].Name + "/" + variant.Name + "\n")
+		}
+	}
+	if len(overrides) == 0 {
+		return config
+	}
+	armConfig, version, ok := config.experimentConfigs.get(arm.String())
+	if ok {
+		return armConfig
+	}
+	armConfig, err := config.WithOverrides(overrides...)
+	if err != nil {
+		log.Logger().Error("failed to apply experiment overrides", zap.String("user_id", userId), zap.Error(err))
+		return config
+	}
+	config.experimentConfigs.put(arm.String(), armConfig, version)
+	return armConfig
+}
+
+// ResetExperimentConfigs drops cached configurations of experiment arms. It must be called after the configuration
+// is modified in place, e.g. reloaded from the master.
+func (config *Config) ResetExperimentConfigs() {
+	config.experimentConfigs.reset()
+}
+
+// experimentConfigCache caches configurations of experiment arms. The version is increased on reset so that
+// configurations derived from the configuration before reset are never cached.
+type experimentConfigCache struct {
+	mutex   sync.Mutex
+	version int
+	configs map[string]*Config
+}
+
+// get returns the cached configuration of an arm and the version of the cache.
+func (c *experimentConfigCache) get(arm string) (*Config, int, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	armConfig, ok := c.configs[arm]
+	return armConfig, c.version, ok
+}
+
+// put caches the configuration of an arm if the cache hasn't been reset since the version.
+func (c *experimentConfigCache) put(arm string, armConfig *Config, version int) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.version != version {
+		return
+	}
+	if c.configs == nil {
+		c.configs = make(map[string]*Config)
+	}
+	c.configs[arm] = armConfig
+}
+
+func (c *experimentConfigCache) reset() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.version++
+	c.configs = nil
+}
+
+// WithOverrides returns a copy of the configuration with partial recommend configurations applied in order.
+func (config *Config) WithOverrides(overrides ...map[string]any) (*Config, error) {
+	config.Recommend.Offline.exploreRecommendLock.RLock()
+	bytes, err := json.Marshal(config)
+	config.Recommend.Offline.exploreRecommendLock.RUnlock()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	var clone Config
+	if err = json.Unmarshal(bytes, &clone); err != nil {
+		return nil, errors.Trace(err)
+	}
+	for _, override := range overrides {
+		decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+			DecodeHook:       mapstructure.StringToTimeDurationHookFunc(),
+			WeaklyTypedInput: true,
+			ErrorUnused:      true,
+			Result:           &clone.Recommend,
+		})
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		if err = decoder.Decode(override); err != nil {
+			return nil, errors.Trace(err)
+		}
+	}
+	return &clone, nil
+}
+
+// ValidateExperiments checks names of experiments and variants are unique and overrides are applicable to the
+// configuration.
+func (config *Config) ValidateExperiments(experiments []ExperimentConfig) error {
+	experimentNames := make(map[string]struct{})
+	for _, experiment := range experiments {
+		if _, exist := experimentNames[experiment.Name]; exist {
+			return errors.Errorf("duplicate experiment `%s`", experiment.Name)
+		}
+		experimentNames[experiment.Name] = struct{}{}
+		if err := experiment.Validate(); err != nil {
+			return errors.Trace(err)
+		}
+		for _, variant := range experiment.Variants {
+			if len(variant.Override) > 0 {
+				variantConfig, err := config.WithOverrides(variant.Override)
+				if err == nil {
+					err = validateStruct(&variantConfig.Recommend)
+				}
+				if err != nil {
+					return errors.Annotatef(err, "invalid override of variant `%s` in experiment `%s`", variant.Name, experiment.Name)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// Validate checks the experiment without looking at overrides.
+func (experiment *ExperimentConfig) Validate() error {
+	if experiment.Name == "" {
+		return errors.New("experiment name is required")
+	}
+	if math.IsNaN(experiment.Traffic) || experiment.Traffic <= 0 || experiment.Traffic > 1 {
+		return errors.Errorf("traffic of experiment `%s` must be in (0, 1]", experiment.Name)
+	}
+	if len(experiment.Variants) == 0 {
+		return errors.Errorf("experiment `%s` has no variant", experiment.Name)
+	}
+	variantNames := make(map[string]struct{})
+	for _, variant := range experiment.Variants {
+		if variant.Name == "" {
+			return errors.Errorf("variant name is required in experiment `%s`", experiment.Name)
+		}
+		if _, exist := variantNames[variant.Name]; exist {
+			return errors.Errorf("duplicate variant `%s` in experiment `%s`", variant.Name, experiment.Name)
+		}
+		if variant.Weight < 0 {
+			return errors.Errorf("weight of variant `%s` in experiment `%s` must be non-negative", variant.Name, experiment.Name)
+		}
+		variantNames[variant.Name] = struct{}{}
+	}
+	return nil
+}
@@ -0,0 +1,154 @@
+// Copyright 2024 gorse Project Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExperimentConfig_Assign(t *testing.T) {
+	experiment := ExperimentConfig{
+		Name:    "image_weight",
+		Traffic: 0.5,
+		Variants: []VariantConfig{
+			{Name: "control", Weight: 1},
+			{Name: "treatment", Weight: 3},
+		},
+	}
+	counts := make(map[string]int)
+	for i := 0; i < 10000; i++ {
+		userId := strconv.Itoa(i)
+		variant, ok := experiment.Assign(userId)
+		// assignment is sticky
+		variant2, ok2 := experiment.Assign(userId)
+		assert.Equal(t, ok, ok2)
+		if ok {
+			assert.Equal(t, variant.Name, variant2.Name)
+			counts[variant.Name]++
+		} else {
+			counts[""]++
+		}
+	}
+	assert.InDelta(t, 5000, counts[""], 300)
+	assert.InDelta(t, 1250, counts["control"], 200)
+	assert.InDelta(t, 3750, counts["treatment"], 200)
+
+	// different salts lead to different assignments
+	salted := experiment
+	salted.Salt = "salt"
+	different := 0
+	for i := 0; i < 1000; i++ {
+		_, ok := experiment.Assign(strconv.Itoa(i))
+		_, ok2 := salted.Assign(strconv.Itoa(i))
+		if ok != ok2 {
+			different++
+		}
+	}
+	assert.Greater(t, different, 0)
+}
+
+func TestConfig_ArmShare(t *testing.T) {
+	cfg := GetDefaultConfig()
+	assert.Equal(t, 1.0, cfg.ArmShare("1"))
+	cfg.Experiments = []ExperimentConfig{{
+		Name:    "image_weight",
+		Traffic: 0.5,
+		Variants: []VariantConfig{
+			{Name: "control", Weight: 1},
+			{Name: "treatment", Weight: 3},
+		},
+	}}
+	for i := 0; i < 100; i++ {
+		userId := strconv.Itoa(i)
+		variant, ok := cfg.Experiments[0].Assign(userId)
+		if !ok {
+			assert.InDelta(t, 0.5, cfg.ArmShare(userId), 1e-9)
+		} else if variant.Name == "control" {
+			assert.InDelta(t, 0.125, cfg.ArmShare(userId), 1e-9)
+		} else {
+			assert.InDelta(t, 0.375, cfg.ArmShare(userId), 1e-9)
+		}
+	}
+}
+
+func TestConfig_ForUser(t *testing.T) {
+	cfg := GetDefaultConfig()
+	cfg.Experiments = []ExperimentConfig{{
+		Name:    "image_weight",
+		Traffic: 1,
+		Variants: []VariantConfig{{
+			Name: "treatment",
+			Override: map[string]any{
+				"image_embeddings": map[string]any{"image_weight": 0.8},
+				"online":           map[string]any{"fallback_recommend": []any{"image_based", "latest"}},
+				"offline":          map[string]any{"refresh_recommend_period": "1h"},
+			},
+		}},
+	}}
+	assert.NoError(t, cfg.ValidateExperiments(cfg.Experiments))
+	userConfig := cfg.ForUser("1")
+	assert.NotSame(t, cfg, userConfig)
+	assert.Equal(t, 0.8, userConfig.Recommend.ImageEmbeddings.ImageWeight)
+	assert.Equal(t, []string{"image_based", "latest"}, userConfig.Recommend.Online.FallbackRecommend)
+	assert.Equal(t, "1h0m0s", userConfig.Recommend.Offline.RefreshRecommendPeriod.String())
+	assert.Equal(t, cfg.Recommend.CacheSize, userConfig.Recommend.CacheSize)
+	// the global configuration is unchanged
+	assert.Equal(t, 0.5, cfg.Recommend.ImageEmbeddings.ImageWeight)
+	assert.Equal(t, []ExperimentAssignment{{Experiment: "image_weight", Variant: "treatment"}}, cfg.AssignExperiments("1"))
+
+	// users in the same arm share the configuration
+	assert.Same(t, userConfig, cfg.ForUser("2"))
+	// changes are seen after reset
+	cfg.Experiments[0].Variants[0].Override["image_embeddings"] = map[string]any{"image_weight": 0.9}
+	cfg.ResetExperimentConfigs()
+	userConfig = cfg.ForUser("1")
+	assert.Equal(t, 0.9, userConfig.Recommend.ImageEmbeddings.ImageWeight)
+	// derived configurations don't share the cache
+	tenantConfig, err := cfg.WithOverrides()
+	assert.NoError(t, err)
+	assert.NotSame(t, userConfig, tenantConfig.ForUser("1"))
+
+	// users out of experiments share the global configuration
+	cfg.Experiments[0].Traffic = 1e-9
+	assert.Same(t, cfg, cfg.ForUser("1"))
+}
+
+func TestConfig_ValidateExperiments(t *testing.T) {
+	cfg := GetDefaultConfig()
+	cfg.Experiments = []ExperimentConfig{{Name: "a", Traffic: 1, Variants: []VariantConfig{{Name: "a"}}}}
+	assert.NoError(t, cfg.ValidateExperiments(cfg.Experiments))
+	// duplicate experiments
+	cfg.Experiments = append(cfg.Experiments, cfg.Experiments[0])
+	assert.Error(t, cfg.ValidateExperiments(cfg.Experiments))
+	// duplicate variants
+	cfg.Experiments = []ExperimentConfig{{Name: "a", Traffic: 1, Variants: []VariantConfig{{Name: "a"}, {Name: "a"}}}}
+	assert.Error(t, cfg.ValidateExperiments(cfg.Experiments))
+	// invalid traffic
+	cfg.Experiments = []ExperimentConfig{{Name: "a", Traffic: 2, Variants: []VariantConfig{{Name: "a"}}}}
+	assert.Error(t, cfg.ValidateExperiments(cfg.Experiments))
+	// unknown key
+	cfg.Experiments = []ExperimentConfig{{Name: "a", Traffic: 1, Variants: []VariantConfig{{
+		Name: "a", Override: map[string]any{"unknown": 1},
+	}}}}
+	assert.Error(t, cfg.ValidateExperiments(cfg.Experiments))
+	// invalid value
+	cfg.Experiments = []ExperimentConfig{{Name: "a", Traffic: 1, Variants: []VariantConfig{{
+		Name: "a", Override: map[string]any{"image_embeddings": map[string]any{"image_weight": 2}},
+	}}}}
+	assert.Error(t, cfg.ValidateExperiments(cfg.Experiments))
+}
@@ -15,8 +15,12 @@
 package config
 
 import (
+	"sync"
+
+	"github.com/zhenghaoz/gorse/common/nn"
 	"github.com/zhenghaoz/gorse/model/click"
 	"github.com/zhenghaoz/gorse/model/ranking"
+	"github.com/zhenghaoz/gorse/model/session"
 	"github.com/zhenghaoz/gorse/storage/cache"
 	"github.com/zhenghaoz/gorse/storage/data"
 )
@@ -33,6 +37,22 @@ type Settings struct {
 	RankingModelVersion int64
 	ClickModel          click.FactorizationMachine
 	ClickModelVersion   int64
+
+	// click model is served by servers while being replaced, so servers access it by LoadClickModel and StoreClickModel
+	clickModelMutex sync.RWMutex
+
+	// session model is served while being replaced, so it is guarded by a mutex
+	sessionModel        session.Model
+	sessionModelVersion int64
+	sessionModelMutex   sync.RWMutex
+
+	// two-tower model is served while being replaced, so it is guarded by a mutex. Numeric labels are transformed by
+	// transformers of its training data.
+	twoTowerModel           *nn.TwoTower
+	twoTowerModelVersion    int64
+	twoTowerUserTransformer *click.LabelTransformer
+	twoTowerItemTransformer *click.LabelTransformer
+	twoTowerModelMutex      sync.RWMutex
 }
 
 func NewSettings() *Settings {
@@ -42,3 +62,57 @@ func NewSettings() *Settings {
 		DataClient:  data.NoDatabase{},
 	}
 }
+
+// LoadSessionModel returns the session model and its version.
+func (s *Settings) LoadSessionModel() (session.Model, int64) {
+	s.sessionModelMutex.RLock()
+	defer s.sessionModelMutex.RUnlock()
+	return s.sessionModel, s.sessionModelVersion
+}
+
+// StoreSessionModel replaces the session model and its version.
+func (s *Settings) StoreSessionModel(m session.Model, version int64) {
+	s.sessionModelMutex.Lock()
+	defer s.sessionModelMutex.Unlock()
+	s.sessionModel = m
+	s.sessionModelVersion = version
+}
+
+// LoadClickModel returns the click model and its version.
+func (s *Settings) LoadClickModel() (click.FactorizationMachine, int64) {
+	s.clickModelMutex.RLock()
+	defer s.clickModelMutex.RUnlock()
+	return s.ClickModel, s.ClickModelVersion
+}
+
+// StoreClickModel replaces the click model and its version.
+func (s *Settings) StoreClickModel(m click.FactorizationMachine, version int64) {
+	s.clickModelMutex.Lock()
+	defer s.clickModelMutex.Unlock()
+	s.ClickModel = m
+	s.ClickModelVersion = version
+}
+
+// LoadTwoTowerModel returns the two-tower model and its version.
+func (s *Settings) LoadTwoTowerModel() (*nn.TwoTower, int64) {
+	s.twoTowerModelMutex.RLock()
+	defer s.twoTowerModelMutex.RUnlock()
+	return s.twoTowerModel, s.twoTowerModelVersion
+}
+
+// LoadTwoTowerTransformers returns transformers of numeric user labels and item labels for the two-tower model.
+func (s *Settings) LoadTwoTowerTransformers() (userTransformer, itemTransformer *click.LabelTransformer) {
+	s.twoTowerModelMutex.RLock()
+	defer s.twoTowerModelMutex.RUnlock()
+	return s.twoTowerUserTransformer, s.twoTowerItemTransformer
+}
+
+// StoreTwoTowerModel replaces the two-tower model, its version and transformers of numeric labels.
+func (s *Settings) StoreTwoTowerModel(m *nn.TwoTower, version int64, userTransformer, itemTransformer *click.LabelTransformer) {
+	s.twoTowerModelMutex.Lock()
+	defer s.twoTowerModelMutex.Unlock()
+	s.twoTowerModel = m
+	s.twoTowerModelVersion = version
+	s.twoTowerUserTransformer = userTransformer
+	s.twoTowerItemTransformer = itemTransformer
+}
@@ -0,0 +1,94 @@
+// Copyright 2024 gorse Project Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"github.com/juju/errors"
+)
+
+// TenantConfig is a tenant sharing the cluster. Tables and keys of a tenant are prefixed by its table prefix in the
+// data store and the cache store. A request is served for a tenant if the API key of the tenant is used or the tenant
+// is selected by name. Override is a partial recommend configuration using the same keys as the [recommend] section.
+// Workers generate offline recommendations of tenants with models pulled from masters of tenants.
+type TenantConfig struct {
+	Name        string         `mapstructure:"name" json:"name" validate:"required"`
+	APIKey      string         `mapstructure:"api_key" json:"api_key"`
+	TablePrefix string         `mapstructure:"table_prefix" json:"table_prefix" validate:"required"`
+	Override    map[string]any `mapstructure:"override" json:"override"`
+}
+
+// GetTenant returns the tenant with the name.
+func (config *Config) GetTenant(name string) (TenantConfig, bool) {
+	for _, tenant := range config.Tenants {
+		if tenant.Name == name {
+			return tenant, true
+		}
+	}
+	return TenantConfig{}, false
+}
+
+// ForTenant returns the configuration of a tenant, which uses table prefixes of the tenant and has the override of
+// the tenant applied. Tenants aren't nested so the returned configuration has no tenant.
+func (config *Config) ForTenant(tenant TenantConfig) (*Config, error) {
+	var overrides []map[string]any
+	if len(tenant.Override) > 0 {
+		overrides = append(overrides, tenant.Override)
+	}
+	tenantConfig, err := config.WithOverrides(overrides...)
+	if err != nil {
+		return nil, errors.Annotatef(err, "invalid override of tenant `%s`", tenant.Name)
+	}
+	tenantConfig.Database.TablePrefix = tenant.TablePrefix
+	tenantConfig.Database.DataTablePrefix = tenant.TablePrefix
+	tenantConfig.Database.CacheTablePrefix = tenant.TablePrefix
+	tenantConfig.Tenants = nil
+	return tenantConfig, nil
+}
+
+// ValidateTenants checks names, API keys and table prefixes of tenants are unique and overrides are applicable to the
+// configuration.
+func (config *Config) ValidateTenants() error {
+	names := make(map[string]struct{})
+	apiKeys := make(map[string]struct{})
+	prefixes := map[string]struct{}{
+		config.Database.DataTablePrefix:  {},
+		config.Database.CacheTablePrefix: {},
+		config.Database.TablePrefix:      {},
+	}
+	for _, tenant := range config.Tenants {
+		if _, exist := names[tenant.Name]; exist {
+			return errors.Errorf("duplicate tenant `%s`", tenant.Name)
+		}
+		names[tenant.Name] = struct{}{}
+		if _, exist := prefixes[tenant.TablePrefix]; exist {
+			return errors.Errorf("table prefix `%s` of tenant `%s` is in use", tenant.TablePrefix, tenant.Name)
+		}
+		prefixes[tenant.TablePrefix] = struct{}{}
+		if tenant.APIKey != "" {
+			if _, exist := apiKeys[tenant.APIKey]; exist || tenant.APIKey == config.Server.APIKey {
+				return errors.Errorf("API key of tenant `%s` is in use", tenant.Name)
+			}
+			apiKeys[tenant.APIKey] = struct{}{}
+		}
+		tenantConfig, err := config.ForTenant(tenant)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if err = validateStruct(&tenantConfig.Recommend); err != nil {
+			return errors.Annotatef(err, "invalid override of tenant `%s`", tenant.Name)
+		}
+	}
+	return nil
+}
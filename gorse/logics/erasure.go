@@ -0,0 +1,134 @@
+// Copyright 2024 gorse Project Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logics
+
+import (
+	"context"
+	"time"
+
+	"github.com/juju/errors"
+	"github.com/zhenghaoz/gorse/storage/cache"
+	"github.com/zhenghaoz/gorse/storage/data"
+)
+
+// userCacheValues are cache values keyed by user.
+var userCacheValues = []string{
+	cache.UserNeighborsDigest,
+	cache.OfflineRecommendDigest,
+	cache.OfflineRecommendPropensity,
+	cache.LastModifyUserTime,
+	cache.LastUpdateUserNeighborsTime,
+	cache.LastUpdateUserRecommendTime,
+	cache.Onboarding,
+}
+
+// userCacheScores are cache scores whose subsets are keyed by user.
+var userCacheScores = []string{
+	cache.UserNeighbors,
+	cache.OfflineRecommend,
+	cache.CollaborativeRecommend,
+}
+
+// ErasureReport is the completion report of erasing a user.
+type ErasureReport struct {
+	UserId        string
+	Impressions   int       // number of deleted impressions
+	Events        int       // number of deleted pending change events
+	CacheKeys     []string  // deleted cache entries of the user
+	Timestamp     time.Time // time of erasure
+	ModelsRetain  []string  // models still holding factors of the user
+	RetainedUntil time.Time // time of the next model fit, after which models no longer hold the user
+}
+
+// retainingModels are models holding factors of users, which are only dropped by the next fit.
+var retainingModels = []string{"ranking", "two-tower"}
+
+// EraseUser removes a user from the data store and the cache synchronously. The user, feedback, impressions and
+// pending change events of the user are deleted from the data store, while the deletion event carried by the context
+// is kept. Cache entries, swipe decks and refresh queue entries of the user are deleted and the user is stripped from
+// neighbors of other users. An audit entry is recorded in the cache after erasure, by which workers drop factors of the
+// user folded into the ranking model. The image embedding store only contains items and is left untouched.
+//
+// Factors of the user in the ranking model and the two-tower model are retained until the next model fit, that is at
+// most one model fit period (plus the duration of the fit) after erasure. The retention window is stated in the report.
+func EraseUser(ctx context.Context, dataClient data.Database, cacheClient cache.Database, userId string, modelFitPeriod time.Duration) (*ErasureReport, error) {
+	report := &ErasureReport{UserId: userId}
+	// erase from data store
+	events, err := dataClient.DeleteUserEvents(ctx, userId)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	report.Events = events
+	if err = dataClient.DeleteUser(ctx, userId); err != nil {
+		return nil, errors.Trace(err)
+	}
+	impressions, err := dataClient.DeleteUserImpressions(ctx, userId)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	report.Impressions = impressions
+	// erase from cache
+	for _, prefix := range userCacheValues {
+		key := cache.Key(prefix, userId)
+		if err = cacheClient.Delete(ctx, key); err != nil {
+			return nil, errors.Trace(err)
+		}
+		report.CacheKeys = append(report.CacheKeys, key)
+	}
+	if err = cacheClient.DeleteScores(ctx, userCacheScores, cache.ScoreCondition{Subset: &userId}); err != nil {
+		return nil, errors.Trace(err)
+	}
+	for _, collection := range userCacheScores {
+		report.CacheKeys = append(report.CacheKeys, cache.Key(collection, userId))
+	}
+	// erase swipe decks and refresh queue entries
+	cursors, err := cacheClient.GetSet(ctx, cache.Key(cache.UserDecks, userId))
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	for _, cursor := range cursors {
+		key := cache.Key(cache.Decks, cursor)
+		if err = cacheClient.Delete(ctx, key); err != nil {
+			return nil, errors.Trace(err)
+		}
+		report.CacheKeys = append(report.CacheKeys, key)
+	}
+	if len(cursors) > 0 {
+		if err = cacheClient.RemSet(ctx, cache.Key(cache.UserDecks, userId), cursors...); err != nil {
+			return nil, errors.Trace(err)
+		}
+	}
+	report.CacheKeys = append(report.CacheKeys, cache.Key(cache.UserDecks, userId))
+	if err = cacheClient.Remove(ctx, cache.RefreshUsers, userId); err != nil {
+		return nil, errors.Trace(err)
+	}
+	// strip from neighbors of other users
+	if err = cacheClient.DeleteScores(ctx, []string{cache.UserNeighbors}, cache.ScoreCondition{Id: &userId}); err != nil {
+		return nil, errors.Trace(err)
+	}
+	// record audit entry
+	report.Timestamp = time.Now()
+	report.ModelsRetain = retainingModels
+	report.RetainedUntil = report.Timestamp.Add(modelFitPeriod)
+	if err = cacheClient.AddScores(ctx, cache.Erasures, "", []cache.Score{{
+		Id:         userId,
+		Score:      float64(report.Timestamp.Unix()),
+		Categories: []string{""},
+		Timestamp:  report.Timestamp,
+	}}); err != nil {
+		return nil, errors.Trace(err)
+	}
+	return report, nil
+}
@@ -0,0 +1,137 @@
+// Copyright 2024 gorse Project Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logics
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/juju/errors"
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/assert"
+	"github.com/zhenghaoz/gorse/storage/cache"
+	"github.com/zhenghaoz/gorse/storage/data"
+)
+
+func TestEraseUser(t *testing.T) {
+	ctx := context.Background()
+	dataClient, err := data.Open(fmt.Sprintf("sqlite://%s/data.db", t.TempDir()), "")
+	assert.NoError(t, err)
+	assert.NoError(t, dataClient.Init())
+	defer dataClient.Close()
+	cacheClient, err := cache.Open(fmt.Sprintf("sqlite://%s/cache.db", t.TempDir()), "")
+	assert.NoError(t, err)
+	assert.NoError(t, cacheClient.Init())
+	defer cacheClient.Close()
+
+	// insert data
+	err = dataClient.BatchInsertFeedback(ctx, []data.Feedback{
+		{FeedbackKey: data.FeedbackKey{FeedbackType: "like", UserId: "0", ItemId: "0"}},
+		{FeedbackKey: data.FeedbackKey{FeedbackType: "like", UserId: "1", ItemId: "0"}},
+	}, true, true, true)
+	assert.NoError(t, err)
+	err = dataClient.BatchInsertImpressions(ctx, []data.Impression{
+		{RequestId: "0", UserId: "0", ItemId: "0", Timestamp: time.Now()},
+		{RequestId: "1", UserId: "1", ItemId: "0", Timestamp: time.Now()},
+	})
+	assert.NoError(t, err)
+	// insert cache
+	err = cacheClient.Set(ctx,
+		cache.String(cache.Key(cache.OfflineRecommendDigest, "0"), "digest"),
+		cache.String(cache.Key(cache.Onboarding, "0"), "{}"))
+	assert.NoError(t, err)
+	err = cacheClient.AddScores(ctx, cache.OfflineRecommend, "0", []cache.Score{{Id: "0", Score: 1, Categories: []string{""}}})
+	assert.NoError(t, err)
+	err = cacheClient.AddScores(ctx, cache.UserNeighbors, "0", []cache.Score{{Id: "1", Score: 1, Categories: []string{""}}})
+	assert.NoError(t, err)
+	err = cacheClient.AddScores(ctx, cache.UserNeighbors, "1", []cache.Score{
+		{Id: "0", Score: 1, Categories: []string{""}},
+		{Id: "2", Score: 1, Categories: []string{""}},
+	})
+	assert.NoError(t, err)
+	err = cacheClient.Set(ctx, cache.String(cache.Key(cache.Decks, "cursor"), "{}"))
+	assert.NoError(t, err)
+	err = cacheClient.AddSet(ctx, cache.Key(cache.UserDecks, "0"), "cursor")
+	assert.NoError(t, err)
+	err = cacheClient.Push(ctx, cache.RefreshUsers, "0")
+	assert.NoError(t, err)
+	err = cacheClient.Push(ctx, cache.RefreshUsers, "1")
+	assert.NoError(t, err)
+	// insert events
+	var events []data.Event
+	for _, eventType := range []string{data.EventUserUpserted, data.EventFeedbackInserted, data.EventItemUpserted} {
+		event, err := data.NewEvent(eventType, "0", nil)
+		assert.NoError(t, err)
+		events = append(events, event)
+	}
+	err = dataClient.InsertEvents(ctx, events)
+	assert.NoError(t, err)
+
+	// erase user
+	event, err := data.NewEvent(data.EventUserDeleted, "0", nil)
+	assert.NoError(t, err)
+	report, err := EraseUser(data.WithEvents(ctx, []data.Event{event}), dataClient, cacheClient, "0", time.Hour)
+	assert.NoError(t, err)
+	assert.Equal(t, "0", report.UserId)
+	assert.Equal(t, 1, report.Impressions)
+	assert.Equal(t, 2, report.Events)
+	assert.Contains(t, report.CacheKeys, cache.Key(cache.Onboarding, "0"))
+	assert.Contains(t, report.CacheKeys, cache.Key(cache.OfflineRecommend, "0"))
+	assert.Equal(t, []string{"ranking", "two-tower"}, report.ModelsRetain)
+	assert.Equal(t, report.Timestamp.Add(time.Hour), report.RetainedUntil)
+
+	// check data store
+	_, err = dataClient.GetUser(ctx, "0")
+	assert.True(t, errors.Is(err, errors.NotFound))
+	feedback, err := dataClient.GetUserFeedback(ctx, "0", nil)
+	assert.NoError(t, err)
+	assert.Empty(t, feedback)
+	_, err = dataClient.GetUser(ctx, "1")
+	assert.NoError(t, err)
+	// check cache
+	_, err = cacheClient.Get(ctx, cache.Key(cache.Onboarding, "0")).String()
+	assert.True(t, errors.Is(err, errors.NotFound))
+	scores, err := cacheClient.SearchScores(ctx, cache.OfflineRecommend, "0", nil, 0, -1)
+	assert.NoError(t, err)
+	assert.Empty(t, scores)
+	scores, err = cacheClient.SearchScores(ctx, cache.UserNeighbors, "0", nil, 0, -1)
+	assert.NoError(t, err)
+	assert.Empty(t, scores)
+	scores, err = cacheClient.SearchScores(ctx, cache.UserNeighbors, "1", nil, 0, -1)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"2"}, cache.ConvertDocumentsToValues(scores))
+	_, err = cacheClient.Get(ctx, cache.Key(cache.Decks, "cursor")).String()
+	assert.True(t, errors.Is(err, errors.NotFound))
+	cursors, err := cacheClient.GetSet(ctx, cache.Key(cache.UserDecks, "0"))
+	assert.NoError(t, err)
+	assert.Empty(t, cursors)
+	refreshUser, err := cacheClient.Pop(ctx, cache.RefreshUsers)
+	assert.NoError(t, err)
+	assert.Equal(t, "1", refreshUser)
+	_, err = cacheClient.Pop(ctx, cache.RefreshUsers)
+	assert.ErrorIs(t, err, io.EOF)
+	// events of the user are deleted except the deletion event, events of items with the same ID are kept
+	events, err = dataClient.GetEvents(ctx, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{data.EventItemUpserted, data.EventUserDeleted},
+		lo.Map(events, func(event data.Event, _ int) string { return event.Type }))
+	// check audit
+	scores, err = cacheClient.SearchScores(ctx, cache.Erasures, "", nil, 0, -1)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"0"}, cache.ConvertDocumentsToValues(scores))
+}
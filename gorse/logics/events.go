@@ -0,0 +1,318 @@
+// Copyright 2024 gorse Project Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logics
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	mapset "github.com/deckarep/golang-set/v2"
+	"github.com/juju/errors"
+	"github.com/samber/lo"
+	"github.com/zhenghaoz/gorse/base/log"
+	"github.com/zhenghaoz/gorse/config"
+	"github.com/zhenghaoz/gorse/storage/cache"
+	"github.com/zhenghaoz/gorse/storage/data"
+	"go.uber.org/zap"
+)
+
+const (
+	HeaderEvent     = "X-Gorse-Event"
+	HeaderEventId   = "X-Gorse-Event-Id"
+	HeaderSignature = "X-Gorse-Signature"
+)
+
+// WithEvents attaches change events of a type to a context if change events are enabled. Each payload is encoded as
+// JSON and keyed by the ID of the changed entity. The events are committed with the next write of users, items or
+// feedback using the returned context, so the context should only be passed to the write recording the change.
+func WithEvents[T any](ctx context.Context, cfg *config.Config, eventType string, payloads []T, key func(T) string) (context.Context, error) {
+	events, err := newEvents(cfg, eventType, payloads, key)
+	if err != nil || len(events) == 0 {
+		return ctx, errors.Trace(err)
+	}
+	return data.WithEvents(ctx, events), nil
+}
+
+// PublishEvents writes change events of a type to the outbox if change events are enabled. It is used for changes
+// outside the data store, such as refreshed recommendation in the cache.
+func PublishEvents[T any](ctx context.Context, cfg *config.Config, dataClient data.Database, eventType string,
+	payloads []T, key func(T) string) error {
+	events, err := newEvents(cfg, eventType, payloads, key)
+	if err != nil || len(events) == 0 {
+		return errors.Trace(err)
+	}
+	return errors.Trace(dataClient.InsertEvents(ctx, events))
+}
+
+func newEvents[T any](cfg *config.Config, eventType string, payloads []T, key func(T) string) ([]data.Event, error) {
+	if !cfg.Events.Enable || len(payloads) == 0 {
+		return nil, nil
+	}
+	events := make([]data.Event, 0, len(payloads))
+	for _, payload := range payloads {
+		event, err := data.NewEvent(eventType, key(payload), payload)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		events = append(events, event)
+	}
+	return events, nil
+}
+
+// RecommendRefresh is the payload of a recommend.refreshed event. Items are sorted by scores in descending order.
+type RecommendRefresh struct {
+	Recommender string   `json:"recommender"`
+	UserId      string   `json:"user_id,omitempty"`
+	Items       []string `json:"items"`
+}
+
+// NewRecommendRefresh creates the payload of a recommend.refreshed event from refreshed scores.
+func NewRecommendRefresh(recommender, userId string, scores []cache.Score) RecommendRefresh {
+	sorted := make([]cache.Score, len(scores))
+	copy(sorted, scores)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Score > sorted[j].Score })
+	return RecommendRefresh{
+		Recommender: recommender,
+		UserId:      userId,
+		Items:       lo.Uniq(lo.Map(sorted, func(score cache.Score, _ int) string { return score.Id })),
+	}
+}
+
+// EventMessage is the body of a webhook request.
+type EventMessage struct {
+	Id        int64           `json:"id"`
+	Type      string          `json:"type"`
+	Key       string          `json:"key"`
+	Payload   json.RawMessage `json:"payload"`
+	Timestamp time.Time       `json:"timestamp"`
+}
+
+// Sign returns the hex encoded HMAC-SHA256 of a webhook request body.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// EventDispatcher delivers change events in the outbox to webhooks. Events stay in the outbox until all webhooks accept
+// them, and each webhook has a set of delivered events in the cache. Each webhook reads pending events past those
+// delivered to it, so a failing webhook holds back neither other webhooks nor deletion of events it has accepted. An
+// event committed late with a lower ID is still pending in the outbox, so it is delivered in next dispatch rather than
+// skipped. Events are delivered at least once in the order of IDs, except that an event failing in MaxFailures
+// dispatches is moved to the dead letter queue of the webhook so that later events are delivered.
+type EventDispatcher struct {
+	Config      *config.EventsConfig
+	DataClient  data.Database
+	CacheClient cache.Database
+	client      *http.Client
+}
+
+// NewEventDispatcher creates a dispatcher of change events.
+func NewEventDispatcher(cfg *config.EventsConfig, dataClient data.Database, cacheClient cache.Database) *EventDispatcher {
+	return &EventDispatcher{
+		Config:      cfg,
+		DataClient:  dataClient,
+		CacheClient: cacheClient,
+		client:      &http.Client{Timeout: cfg.Timeout},
+	}
+}
+
+// Dispatch delivers a batch of pending events to webhooks concurrently. Webhooks failing after retries are skipped
+// until next dispatch. Events delivered to (or dead lettered for) all webhooks are deleted from the outbox.
+func (d *EventDispatcher) Dispatch(ctx context.Context) error {
+	if len(d.Config.Webhooks) == 0 {
+		return nil
+	}
+	var wg sync.WaitGroup
+	results := make([]mapset.Set[int64], len(d.Config.Webhooks))
+	errs := make([]error, len(d.Config.Webhooks))
+	for i, webhook := range d.Config.Webhooks {
+		wg.Add(1)
+		go func(i int, webhook config.WebhookConfig) {
+			defer wg.Done()
+			results[i], errs[i] = d.dispatchWebhook(ctx, webhook)
+		}(i, webhook)
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return errors.Trace(err)
+		}
+	}
+	// An event is done if every webhook has read and completed it.
+	done := make(map[int64]int)
+	for _, result := range results {
+		for id := range result.Iter() {
+			done[id]++
+		}
+	}
+	var ids []int64
+	var members []string
+	for id, count := range done {
+		if count == len(results) {
+			ids = append(ids, id)
+			members = append(members, strconv.FormatInt(id, 10))
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	// Delivered sets are cleared before events are deleted. Otherwise, a reused ID of a deleted event would be
+	// mistaken for a delivered event.
+	for _, webhook := range d.Config.Webhooks {
+		if err := d.CacheClient.RemSet(ctx, cache.Key(cache.EventDelivered, webhook.Name), members...); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	return errors.Trace(d.DataClient.DeleteEvents(ctx, ids))
+}
+
+// dispatchWebhook delivers a batch of pending events that haven't been delivered to a webhook and returns IDs of read
+// events delivered to, dead lettered for or not accepted by the webhook. Events delivered before are read past, since
+// the outbox holds them until other webhooks accept them. Delivery stops at the first failure to keep events in order,
+// unless the event has failed in MaxFailures dispatches and is moved to the dead letter queue.
+func (d *EventDispatcher) dispatchWebhook(ctx context.Context, webhook config.WebhookConfig) (mapset.Set[int64], error) {
+	done := mapset.NewThreadUnsafeSet[int64]()
+	key := cache.Key(cache.EventDelivered, webhook.Name)
+	members, err := d.CacheClient.GetSet(ctx, key)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	sent := mapset.NewThreadUnsafeSet(members...)
+	events, err := d.DataClient.GetEvents(ctx, d.Config.BatchSize+sent.Cardinality())
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	numDispatched := 0
+	for _, event := range events {
+		id := strconv.FormatInt(event.Id, 10)
+		if !webhook.Accept(event.Type) || sent.Contains(id) {
+			done.Add(event.Id)
+			continue
+		}
+		if numDispatched >= d.Config.BatchSize {
+			break
+		}
+		numDispatched++
+		failures := cache.Key(cache.EventFailures, webhook.Name, id)
+		if err = d.deliver(ctx, webhook, event); err != nil {
+			log.Logger().Warn("failed to deliver event", zap.String("webhook", webhook.Name),
+				zap.Int64("event_id", event.Id), zap.Error(err))
+			deadLettered, err := d.countFailure(ctx, webhook, event, failures)
+			if err != nil {
+				return nil, errors.Trace(err)
+			}
+			if !deadLettered {
+				break
+			}
+		} else if numDispatched == 1 {
+			// only the first event of a dispatch might have failed before
+			if err = d.CacheClient.Delete(ctx, failures); err != nil {
+				return nil, errors.Trace(err)
+			}
+		}
+		if err = d.CacheClient.AddSet(ctx, key, id); err != nil {
+			return nil, errors.Trace(err)
+		}
+		done.Add(event.Id)
+	}
+	return done, nil
+}
+
+// countFailure counts a failed dispatch of an event to a webhook. The event is moved to the dead letter queue of the
+// webhook if it has failed in MaxFailures dispatches.
+func (d *EventDispatcher) countFailure(ctx context.Context, webhook config.WebhookConfig, event data.Event, key string) (bool, error) {
+	failures, err := d.CacheClient.Get(ctx, key).Integer()
+	if err != nil && !errors.Is(err, errors.NotFound) {
+		return false, errors.Trace(err)
+	}
+	failures++
+	if failures < d.Config.MaxFailures {
+		return false, errors.Trace(d.CacheClient.Set(ctx, cache.Integer(key, failures)))
+	}
+	body, err := json.Marshal(newEventMessage(event))
+	if err != nil {
+		return false, errors.Trace(err)
+	}
+	if err = d.CacheClient.Push(ctx, cache.Key(cache.EventDeadLetters, webhook.Name), string(body)); err != nil {
+		return false, errors.Trace(err)
+	}
+	log.Logger().Error("moved event to dead letter queue", zap.String("webhook", webhook.Name),
+		zap.Int64("event_id", event.Id), zap.Int("failures", failures))
+	return true, errors.Trace(d.CacheClient.Delete(ctx, key))
+}
+
+func newEventMessage(event data.Event) EventMessage {
+	return EventMessage{
+		Id:        event.Id,
+		Type:      event.Type,
+		Key:       event.Key,
+		Payload:   json.RawMessage(event.Payload),
+		Timestamp: event.Timestamp,
+	}
+}
+
+// deliver posts an event to a webhook with retries. The interval between retries doubles after each retry.
+func (d *EventDispatcher) deliver(ctx context.Context, webhook config.WebhookConfig, event data.Event) error {
+	body, err := json.Marshal(newEventMessage(event))
+	if err != nil {
+		return errors.Trace(err)
+	}
+	interval := d.Config.RetryInterval
+	for i := 0; ; i++ {
+		if err = d.post(ctx, webhook, event, body); err == nil {
+			return nil
+		} else if i >= d.Config.MaxRetries {
+			return errors.Trace(err)
+		}
+		select {
+		case <-ctx.Done():
+			return errors.Trace(ctx.Err())
+		case <-time.After(interval):
+			interval *= 2
+		}
+	}
+}
+
+func (d *EventDispatcher) post(ctx context.Context, webhook config.WebhookConfig, event data.Event, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		return errors.Trace(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderEvent, event.Type)
+	req.Header.Set(HeaderEventId, strconv.FormatInt(event.Id, 10))
+	if webhook.Secret != "" {
+		req.Header.Set(HeaderSignature, Sign(webhook.Secret, body))
+	}
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return errors.Errorf("webhook %s responded %s", webhook.Name, resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,277 @@
+// Copyright 2024 gorse Project Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logics
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/juju/errors"
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/assert"
+	"github.com/zhenghaoz/gorse/config"
+	"github.com/zhenghaoz/gorse/storage/cache"
+	"github.com/zhenghaoz/gorse/storage/data"
+)
+
+// mockConsumer is a webhook consumer failing the first n requests.
+type mockConsumer struct {
+	sync.Mutex
+	failures int
+	messages []EventMessage
+	headers  []http.Header
+	bodies   [][]byte
+}
+
+func (c *mockConsumer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	c.Lock()
+	defer c.Unlock()
+	if c.failures > 0 {
+		c.failures--
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	body, _ := io.ReadAll(r.Body)
+	var message EventMessage
+	if err := json.Unmarshal(body, &message); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	c.messages = append(c.messages, message)
+	c.headers = append(c.headers, r.Header)
+	c.bodies = append(c.bodies, body)
+}
+
+func (c *mockConsumer) keys() []string {
+	c.Lock()
+	defer c.Unlock()
+	return lo.Map(c.messages, func(message EventMessage, _ int) string { return message.Key })
+}
+
+func TestPublishEvents(t *testing.T) {
+	ctx := context.Background()
+	dataClient, err := data.Open(fmt.Sprintf("sqlite://%s/data.db", t.TempDir()), "")
+	assert.NoError(t, err)
+	assert.NoError(t, dataClient.Init())
+	defer dataClient.Close()
+
+	cfg := config.GetDefaultConfig()
+	items := []data.Item{{ItemId: "0", Comment: "comment"}}
+	itemId := func(item data.Item) string { return item.ItemId }
+	// events are dropped if disabled
+	err = PublishEvents(ctx, cfg, dataClient, data.EventItemUpserted, items, itemId)
+	assert.NoError(t, err)
+	events, err := dataClient.GetEvents(ctx, 10)
+	assert.NoError(t, err)
+	assert.Empty(t, events)
+	// events are written to the outbox if enabled
+	cfg.Events.Enable = true
+	err = PublishEvents(ctx, cfg, dataClient, data.EventItemUpserted, items, itemId)
+	assert.NoError(t, err)
+	events, err = dataClient.GetEvents(ctx, 10)
+	assert.NoError(t, err)
+	if assert.Len(t, events, 1) {
+		assert.Equal(t, data.EventItemUpserted, events[0].Type)
+		assert.Equal(t, "0", events[0].Key)
+		var item data.Item
+		assert.NoError(t, json.Unmarshal([]byte(events[0].Payload), &item))
+		assert.Equal(t, items[0].Comment, item.Comment)
+	}
+}
+
+func TestEventDispatcher(t *testing.T) {
+	ctx := context.Background()
+	dataClient, err := data.Open(fmt.Sprintf("sqlite://%s/data.db", t.TempDir()), "")
+	assert.NoError(t, err)
+	assert.NoError(t, dataClient.Init())
+	defer dataClient.Close()
+	cacheClient, err := cache.Open(fmt.Sprintf("sqlite://%s/cache.db", t.TempDir()), "")
+	assert.NoError(t, err)
+	assert.NoError(t, cacheClient.Init())
+	defer cacheClient.Close()
+
+	// start consumers
+	all := &mockConsumer{failures: 2}
+	allServer := httptest.NewServer(all)
+	defer allServer.Close()
+	deleted := &mockConsumer{}
+	deletedServer := httptest.NewServer(deleted)
+	defer deletedServer.Close()
+	down := &mockConsumer{failures: 100}
+	downServer := httptest.NewServer(down)
+	defer downServer.Close()
+
+	// insert events
+	var events []data.Event
+	for i := 0; i < 5; i++ {
+		eventType := data.EventItemUpserted
+		if i%2 == 1 {
+			eventType = data.EventItemDeleted
+		}
+		event, err := data.NewEvent(eventType, strconv.Itoa(i), data.Item{ItemId: strconv.Itoa(i)})
+		assert.NoError(t, err)
+		events = append(events, event)
+	}
+	err = dataClient.InsertEvents(ctx, events)
+	assert.NoError(t, err)
+
+	cfg := config.GetDefaultConfig().Events
+	cfg.Enable = true
+	cfg.BatchSize = 3
+	cfg.RetryInterval = time.Millisecond
+	cfg.MaxRetries = 2
+	cfg.Webhooks = []config.WebhookConfig{
+		{Name: "all", URL: allServer.URL, Secret: "secret"},
+		{Name: "deleted", URL: deletedServer.URL, Types: []string{data.EventItemDeleted}},
+	}
+	dispatcher := NewEventDispatcher(&cfg, dataClient, cacheClient)
+
+	// deliver the first batch after retries
+	err = dispatcher.Dispatch(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"0", "1", "2"}, all.keys())
+	assert.Equal(t, []string{"1"}, deleted.keys())
+	// deliver the second batch
+	err = dispatcher.Dispatch(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"0", "1", "2", "3", "4"}, all.keys())
+	assert.Equal(t, []string{"1", "3"}, deleted.keys())
+
+	// check messages
+	for i, message := range all.messages {
+		assert.Equal(t, events[i].Type, message.Type)
+		assert.Equal(t, events[i].Type, all.headers[i].Get(HeaderEvent))
+		assert.Equal(t, strconv.FormatInt(message.Id, 10), all.headers[i].Get(HeaderEventId))
+		assert.Equal(t, Sign("secret", all.bodies[i]), all.headers[i].Get(HeaderSignature))
+		var item data.Item
+		assert.NoError(t, json.Unmarshal(message.Payload, &item))
+		assert.Equal(t, strconv.Itoa(i), item.ItemId)
+	}
+	assert.Empty(t, deleted.headers[0].Get(HeaderSignature))
+
+	// events are deleted once delivered to all webhooks
+	remain, err := dataClient.GetEvents(ctx, 10)
+	assert.NoError(t, err)
+	assert.Empty(t, remain)
+
+	// events are kept until all webhooks accept them
+	event, err := data.NewEvent(data.EventItemHidden, "5", nil)
+	assert.NoError(t, err)
+	err = dataClient.InsertEvents(ctx, []data.Event{event})
+	assert.NoError(t, err)
+	cfg.Webhooks = append(cfg.Webhooks, config.WebhookConfig{Name: "down", URL: downServer.URL})
+	err = dispatcher.Dispatch(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"0", "1", "2", "3", "4", "5"}, all.keys())
+	assert.Empty(t, down.keys())
+	remain, err = dataClient.GetEvents(ctx, 10)
+	assert.NoError(t, err)
+	if assert.Len(t, remain, 1) {
+		assert.Equal(t, "5", remain[0].Key)
+	}
+	// redeliver after the webhook recovers, without resending to webhooks accepted them
+	down.Lock()
+	down.failures = 0
+	down.Unlock()
+	err = dispatcher.Dispatch(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"5"}, down.keys())
+	assert.Equal(t, []string{"0", "1", "2", "3", "4", "5"}, all.keys())
+	remain, err = dataClient.GetEvents(ctx, 10)
+	assert.NoError(t, err)
+	assert.Empty(t, remain)
+
+	// a failing webhook doesn't hold back other webhooks
+	down.Lock()
+	down.failures = 100
+	down.Unlock()
+	cfg.MaxFailures = 2
+	events = nil
+	for i := 6; i < 10; i++ {
+		event, err := data.NewEvent(data.EventItemHidden, strconv.Itoa(i), nil)
+		assert.NoError(t, err)
+		events = append(events, event)
+	}
+	err = dataClient.InsertEvents(ctx, events)
+	assert.NoError(t, err)
+	err = dispatcher.Dispatch(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"0", "1", "2", "3", "4", "5", "6", "7", "8"}, all.keys())
+	err = dispatcher.Dispatch(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"0", "1", "2", "3", "4", "5", "6", "7", "8", "9"}, all.keys())
+	// the event failed in MaxFailures dispatches is moved to the dead letter queue
+	assert.Equal(t, []string{"5"}, down.keys())
+	deadLetter, err := cacheClient.Pop(ctx, cache.Key(cache.EventDeadLetters, "down"))
+	assert.NoError(t, err)
+	var message EventMessage
+	assert.NoError(t, json.Unmarshal([]byte(deadLetter), &message))
+	assert.Equal(t, "6", message.Key)
+	remain, err = dataClient.GetEvents(ctx, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"7", "8", "9"}, lo.Map(remain, func(event data.Event, _ int) string { return event.Key }))
+	// later events are delivered after the webhook recovers
+	down.Lock()
+	down.failures = 0
+	down.Unlock()
+	err = dispatcher.Dispatch(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"5", "7", "8", "9"}, down.keys())
+	_, err = cacheClient.Get(ctx, cache.Key(cache.EventFailures, "down", strconv.FormatInt(remain[0].Id, 10))).Integer()
+	assert.True(t, errors.Is(err, errors.NotFound))
+	remain, err = dataClient.GetEvents(ctx, 10)
+	assert.NoError(t, err)
+	assert.Empty(t, remain)
+}
+
+func TestWithEvents(t *testing.T) {
+	ctx := context.Background()
+	dataClient, err := data.Open(fmt.Sprintf("sqlite://%s/data.db", t.TempDir()), "")
+	assert.NoError(t, err)
+	assert.NoError(t, dataClient.Init())
+	defer dataClient.Close()
+
+	cfg := config.GetDefaultConfig()
+	cfg.Events.Enable = true
+	items := []data.Item{{ItemId: "0", Comment: "comment"}}
+	itemId := func(item data.Item) string { return item.ItemId }
+	// events are written with the change
+	eventCtx, err := WithEvents(ctx, cfg, data.EventItemUpserted, items, itemId)
+	assert.NoError(t, err)
+	err = dataClient.BatchInsertItems(eventCtx, items)
+	assert.NoError(t, err)
+	events, err := dataClient.GetEvents(ctx, 10)
+	assert.NoError(t, err)
+	if assert.Len(t, events, 1) {
+		assert.Equal(t, data.EventItemUpserted, events[0].Type)
+		assert.Equal(t, "0", events[0].Key)
+	}
+	// events are dropped with the failed change
+	eventCtx, err = WithEvents(ctx, cfg, data.EventItemUpserted, items, itemId)
+	assert.NoError(t, err)
+	err = dataClient.ModifyItem(eventCtx, "0", data.ItemPatch{Status: lo.ToPtr(data.StatusRelisted)})
+	assert.Error(t, err)
+	events, err = dataClient.GetEvents(ctx, 10)
+	assert.NoError(t, err)
+	assert.Len(t, events, 1)
+}
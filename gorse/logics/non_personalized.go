@@ -101,8 +101,8 @@ func NewPopular(window time.Duration, n int, timestamp time.Time) *NonPersonaliz
 }
 
 func (l *NonPersonalized) Push(item data.Item, feedback []data.Feedback) {
-	// Skip hidden items
-	if item.IsHidden {
+	// Skip hidden, reserved and sold items
+	if !item.IsVisible() {
 		return
 	}
 	// Evaluate filter function
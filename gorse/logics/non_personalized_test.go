@@ -111,13 +111,21 @@ func TestHidden(t *testing.T) {
 	for i := 0; i < 100; i++ {
 		item := data.Item{ItemId: strconv.Itoa(i), Timestamp: timestamp.Add(time.Duration(-i) * time.Second)}
 		item.IsHidden = i < 10
+		// reserved and sold items are hidden as well
+		if i >= 10 && i < 15 {
+			item.Status = data.StatusReserved
+		} else if i >= 15 && i < 20 {
+			item.Status = data.StatusSold
+		} else if i >= 20 && i < 25 {
+			item.Status = data.StatusRelisted
+		}
 		latest.Push(item, nil)
 	}
 	scores := latest.PopAll()
 	assert.Len(t, scores, 10)
 	for i := 0; i < 10; i++ {
-		assert.Equal(t, strconv.Itoa(i+10), scores[i].Id)
-		assert.Equal(t, float64(timestamp.Add(time.Duration(-i-10)*time.Second).Unix()), scores[i].Score)
+		assert.Equal(t, strconv.Itoa(i+20), scores[i].Id)
+		assert.Equal(t, float64(timestamp.Add(time.Duration(-i-20)*time.Second).Unix()), scores[i].Score)
 		assert.Equal(t, timestamp, scores[i].Timestamp)
 	}
 }
@@ -0,0 +1,141 @@
+// Copyright 2024 gorse Project Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logics
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"time"
+
+	mapset "github.com/deckarep/golang-set/v2"
+	"github.com/juju/errors"
+	"github.com/samber/lo"
+	"github.com/zhenghaoz/gorse/base/floats"
+	"github.com/zhenghaoz/gorse/config"
+	"github.com/zhenghaoz/gorse/model/click"
+	"github.com/zhenghaoz/gorse/storage/cache"
+	"github.com/zhenghaoz/gorse/storage/data"
+)
+
+// Onboarding is the synthetic profile of a user collected from an onboarding quiz. Seed items are also inserted as
+// feedback with a dedicated type, while categories and labels are preferences of the user. The taste vector is built
+// from image embeddings of seed items once the quiz is submitted.
+type Onboarding struct {
+	Items       []string
+	Categories  []string
+	Labels      []string
+	TasteVector []float32
+	Timestamp   time.Time
+}
+
+// LoadOnboarding loads the onboarding profile of a user. It returns nil if the user hasn't completed onboarding.
+func LoadOnboarding(ctx context.Context, cacheClient cache.Database, userId string) (*Onboarding, error) {
+	value, err := cacheClient.Get(ctx, cache.Key(cache.Onboarding, userId)).String()
+	if err != nil {
+		if errors.Is(err, errors.NotFound) {
+			return nil, nil
+		}
+		return nil, errors.Trace(err)
+	}
+	var onboarding Onboarding
+	if err = json.Unmarshal([]byte(value), &onboarding); err != nil {
+		return nil, errors.Trace(err)
+	}
+	return &onboarding, nil
+}
+
+// SaveOnboarding saves the onboarding profile of a user.
+func SaveOnboarding(ctx context.Context, cacheClient cache.Database, userId string, onboarding *Onboarding) error {
+	bytes, err := json.Marshal(onboarding)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	return cacheClient.Set(ctx, cache.String(cache.Key(cache.Onboarding, userId), string(bytes)))
+}
+
+// Weight returns the fraction of recommendation from onboarding. The weight decreases linearly with the number of
+// organic positive feedback, which excludes feedback of seed items.
+func (onboarding *Onboarding) Weight(cfg config.OnboardingConfig, positiveFeedbackTypes []string, feedback []data.Feedback) float64 {
+	numFeedback := lo.CountBy(feedback, func(f data.Feedback) bool {
+		return f.FeedbackType != cfg.FeedbackType && lo.Contains(positiveFeedbackTypes, f.FeedbackType)
+	})
+	return cfg.Weight * math.Max(0, 1-float64(numFeedback)/float64(cfg.NumFeedback))
+}
+
+// Size returns the number of recommended items from onboarding.
+func (onboarding *Onboarding) Size(weight float64, n int) int {
+	return int(math.Ceil(weight * float64(n)))
+}
+
+// NewTasteVector returns the mean of image embeddings of seed items. It returns nil if no seed item has an image
+// embedding.
+func NewTasteVector(items []data.Item, cfg config.ImageEmbeddingConfig) []float32 {
+	var (
+		vector []float32
+		count  int
+	)
+	for _, item := range items {
+		embedding := ImageEmbedding(item.Labels, cfg.EmbeddingLabel, cfg.EmbeddingDim)
+		if embedding == nil {
+			continue
+		}
+		if vector == nil {
+			vector = make([]float32, len(embedding))
+		}
+		floats.Add(vector, embedding)
+		count++
+	}
+	if count > 0 {
+		floats.MulConst(vector, 1/float32(count))
+	}
+	return vector
+}
+
+// FallbackCandidates scores latest and popular items by reciprocal ranks, which are candidates of onboarding if no seed
+// item is picked in the quiz. Excluded items are skipped and candidates should be filtered by Match.
+func FallbackCandidates(ctx context.Context, cacheClient cache.Database, categories []string, n int, excludeSet mapset.Set[string]) (map[string]float64, error) {
+	candidates := make(map[string]float64)
+	for _, name := range []string{cache.Latest, cache.Popular} {
+		items, err := cacheClient.SearchScores(ctx, cache.NonPersonalized, name, categories, 0, n)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		for rank, item := range items {
+			if !excludeSet.Contains(item.Id) {
+				candidates[item.Id] += 1 / float64(rank+1)
+			}
+		}
+	}
+	return candidates, nil
+}
+
+// Match checks whether an item has any of preferred categories and any of preferred labels. Preferences that are not
+// specified are always satisfied.
+func (onboarding *Onboarding) Match(item *data.Item) bool {
+	if len(onboarding.Categories) > 0 && !lo.Some(item.Categories, onboarding.Categories) {
+		return false
+	}
+	if len(onboarding.Labels) > 0 {
+		labels := mapset.NewSet[string]()
+		for _, feature := range click.ConvertLabelsToFeatures(item.Labels) {
+			labels.Add(feature.Name)
+		}
+		if !lo.SomeBy(onboarding.Labels, func(label string) bool { return labels.Contains(label) }) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,91 @@
+// Copyright 2024 gorse Project Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logics
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zhenghaoz/gorse/config"
+	"github.com/zhenghaoz/gorse/storage/cache"
+	"github.com/zhenghaoz/gorse/storage/data"
+)
+
+func TestOnboarding(t *testing.T) {
+	ctx := context.Background()
+	cacheClient, err := cache.Open(fmt.Sprintf("sqlite://%s/cache.db", t.TempDir()), "")
+	assert.NoError(t, err)
+	assert.NoError(t, cacheClient.Init())
+	defer cacheClient.Close()
+
+	// load not existed onboarding
+	onboarding, err := LoadOnboarding(ctx, cacheClient, "0")
+	assert.NoError(t, err)
+	assert.Nil(t, onboarding)
+	// save and load onboarding
+	err = SaveOnboarding(ctx, cacheClient, "0", &Onboarding{Items: []string{"1", "2"}, Categories: []string{"a"}})
+	assert.NoError(t, err)
+	onboarding, err = LoadOnboarding(ctx, cacheClient, "0")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"1", "2"}, onboarding.Items)
+	assert.Equal(t, []string{"a"}, onboarding.Categories)
+}
+
+func TestOnboarding_Weight(t *testing.T) {
+	cfg := config.OnboardingConfig{FeedbackType: "onboarding", Weight: 0.5, NumFeedback: 4}
+	onboarding := &Onboarding{Items: []string{"1"}}
+	// seed items and negative feedback are not organic
+	feedback := []data.Feedback{
+		{FeedbackKey: data.FeedbackKey{FeedbackType: "onboarding", ItemId: "1"}},
+		{FeedbackKey: data.FeedbackKey{FeedbackType: "read", ItemId: "2"}},
+	}
+	assert.Equal(t, 0.5, onboarding.Weight(cfg, []string{"like"}, feedback))
+	assert.Equal(t, 5, onboarding.Size(0.5, 10))
+	// fade out by organic feedback
+	for i := 0; i < 2; i++ {
+		feedback = append(feedback, data.Feedback{FeedbackKey: data.FeedbackKey{FeedbackType: "like", ItemId: strconv.Itoa(i + 10)}})
+	}
+	assert.Equal(t, 0.25, onboarding.Weight(cfg, []string{"like"}, feedback))
+	assert.Equal(t, 3, onboarding.Size(0.25, 10))
+	for i := 0; i < 4; i++ {
+		feedback = append(feedback, data.Feedback{FeedbackKey: data.FeedbackKey{FeedbackType: "like", ItemId: strconv.Itoa(i + 20)}})
+	}
+	assert.Zero(t, onboarding.Weight(cfg, []string{"like"}, feedback))
+	assert.Zero(t, onboarding.Size(0, 10))
+}
+
+func TestOnboarding_Match(t *testing.T) {
+	item := &data.Item{ItemId: "1", Categories: []string{"a", "b"}, Labels: []any{"x", "y"}}
+	assert.True(t, (&Onboarding{}).Match(item))
+	assert.True(t, (&Onboarding{Categories: []string{"b", "c"}}).Match(item))
+	assert.False(t, (&Onboarding{Categories: []string{"c"}}).Match(item))
+	assert.True(t, (&Onboarding{Labels: []string{"y", "z"}}).Match(item))
+	assert.False(t, (&Onboarding{Labels: []string{"z"}}).Match(item))
+	assert.False(t, (&Onboarding{Categories: []string{"a"}, Labels: []string{"z"}}).Match(item))
+}
+
+func TestNewTasteVector(t *testing.T) {
+	cfg := config.ImageEmbeddingConfig{EmbeddingLabel: "embedding", EmbeddingDim: 2}
+	assert.Nil(t, NewTasteVector([]data.Item{{ItemId: "1"}}, cfg))
+	// the mean of image embeddings of seed items
+	assert.Equal(t, []float32{2, 3}, NewTasteVector([]data.Item{
+		{ItemId: "1", Labels: map[string]any{"embedding": []any{1.0, 2.0}}},
+		{ItemId: "2", Labels: map[string]any{"embedding": []any{3.0, 4.0}}},
+		{ItemId: "3", Labels: map[string]any{"embedding": []any{5.0}}},
+	}, cfg))
+}
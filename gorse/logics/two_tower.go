@@ -0,0 +1,80 @@
+// Copyright 2024 gorse Project Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logics
+
+import (
+	"encoding/json"
+
+	"github.com/samber/lo"
+	"github.com/zhenghaoz/gorse/common/nn"
+	"github.com/zhenghaoz/gorse/model/click"
+)
+
+// NewUserTowerInput creates the input of the user tower from labels of a user and items the user interacted with.
+// Items in history should be sorted from the earliest to the latest.
+func NewUserTowerInput(userId string, labels []click.Feature, history []string) nn.TowerInput {
+	return nn.TowerInput{
+		Id: userId,
+		Features: lo.Map(labels, func(label click.Feature, _ int) lo.Tuple2[string, float32] {
+			return lo.Tuple2[string, float32]{A: "label:" + label.Name, B: label.Value}
+		}),
+		History: history,
+	}
+}
+
+// NewItemTowerInput creates the input of the item tower from labels, categories and the image embedding of an item.
+func NewItemTowerInput(itemId string, labels []click.Feature, categories []string, embedding []float32) nn.TowerInput {
+	features := make([]lo.Tuple2[string, float32], 0, len(labels)+len(categories))
+	for _, label := range labels {
+		features = append(features, lo.Tuple2[string, float32]{A: "label:" + label.Name, B: label.Value})
+	}
+	for _, category := range categories {
+		features = append(features, lo.Tuple2[string, float32]{A: "category:" + category, B: 1})
+	}
+	return nn.TowerInput{
+		Id:        itemId,
+		Features:  features,
+		Embedding: embedding,
+	}
+}
+
+// ImageEmbedding returns the image embedding stored in a label of an item. The label should be an array of numbers.
+// It returns nil if the label doesn't exist or the dimension of the embedding mismatches.
+func ImageEmbedding(labels any, name string, dim int) []float32 {
+	m, ok := labels.(map[string]any)
+	if !ok {
+		return nil
+	}
+	values, ok := m[name].([]any)
+	if !ok || len(values) != dim {
+		return nil
+	}
+	embedding := make([]float32, dim)
+	for i, value := range values {
+		switch value := value.(type) {
+		case json.Number:
+			f, err := value.Float64()
+			if err != nil {
+				return nil
+			}
+			embedding[i] = float32(f)
+		case float64:
+			embedding[i] = float32(value)
+		default:
+			return nil
+		}
+	}
+	return embedding
+}
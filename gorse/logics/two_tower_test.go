@@ -0,0 +1,51 @@
+// Copyright 2024 gorse Project Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logics
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/assert"
+	"github.com/zhenghaoz/gorse/model/click"
+)
+
+func TestTowerInput(t *testing.T) {
+	labels := []click.Feature{{Name: "red", Value: 1}, {Name: "size.", Value: 2}}
+	user := NewUserTowerInput("1", labels, []string{"2", "3"})
+	assert.Equal(t, "1", user.Id)
+	assert.Equal(t, []lo.Tuple2[string, float32]{{A: "label:red", B: 1}, {A: "label:size.", B: 2}}, user.Features)
+	assert.Equal(t, []string{"2", "3"}, user.History)
+
+	item := NewItemTowerInput("2", labels, []string{"a"}, []float32{1, 2})
+	assert.Equal(t, "2", item.Id)
+	assert.Equal(t, []lo.Tuple2[string, float32]{{A: "label:red", B: 1}, {A: "label:size.", B: 2}, {A: "category:a", B: 1}}, item.Features)
+	assert.Equal(t, []float32{1, 2}, item.Embedding)
+}
+
+func TestImageEmbedding(t *testing.T) {
+	labels := map[string]any{
+		"embedding": []any{json.Number("0.5"), json.Number("1")},
+		"floats":    []any{0.5, 1.0},
+		"tags":      []any{"a", "b"},
+	}
+	assert.Equal(t, []float32{0.5, 1}, ImageEmbedding(labels, "embedding", 2))
+	assert.Equal(t, []float32{0.5, 1}, ImageEmbedding(labels, "floats", 2))
+	assert.Nil(t, ImageEmbedding(labels, "embedding", 3))
+	assert.Nil(t, ImageEmbedding(labels, "tags", 2))
+	assert.Nil(t, ImageEmbedding(labels, "unknown", 2))
+	assert.Nil(t, ImageEmbedding([]any{"a"}, "embedding", 2))
+}
@@ -22,6 +22,7 @@ import (
 	"net"
 	"net/http"
 	"os"
+	"path/filepath"
 	"sync"
 	"time"
 
@@ -38,12 +39,15 @@ import (
 	"github.com/zhenghaoz/gorse/base/task"
 	"github.com/zhenghaoz/gorse/common/util"
 	"github.com/zhenghaoz/gorse/config"
+	"github.com/zhenghaoz/gorse/logics"
 	"github.com/zhenghaoz/gorse/model"
 	"github.com/zhenghaoz/gorse/model/click"
 	"github.com/zhenghaoz/gorse/model/ranking"
+	"github.com/zhenghaoz/gorse/model/session"
 	"github.com/zhenghaoz/gorse/protocol"
 	"github.com/zhenghaoz/gorse/server"
 	"github.com/zhenghaoz/gorse/storage"
+	"github.com/zhenghaoz/gorse/storage/blob"
 	"github.com/zhenghaoz/gorse/storage/cache"
 	"github.com/zhenghaoz/gorse/storage/data"
 	"github.com/zhenghaoz/gorse/storage/meta"
@@ -91,11 +95,24 @@ type Master struct {
 	rankingModelMutex    sync.RWMutex
 	rankingModelSearcher *ranking.ModelSearcher
 
+	// previous ranking model, kept to send deltas to workers
+	prevRankingModel        ranking.MatrixFactorization
+	prevRankingModelVersion int64
+
 	// click model
 	clickScore         click.Score
+	clickDiagnostics   *click.Diagnostics
 	clickModelMutex    sync.RWMutex
 	clickModelSearcher *click.ModelSearcher
 
+	// session dataset
+	sessionTrainSet  *session.DataSet
+	sessionTestSet   *session.DataSet
+	sessionDataMutex sync.RWMutex
+
+	// experiments
+	experimentsMutex sync.RWMutex
+
 	// oauth2
 	oauth2Config oauth2.Config
 	verifier     *oidc.IDTokenVerifier
@@ -103,14 +120,30 @@ type Master struct {
 
 	localCache *LocalCache
 
+	// model registry
+	blobStore     *blob.MasterStoreServer
+	modelRegistry *ModelRegistry
+
+	// candidate models evaluated by workers in shadow mode
+	shadowRankingModelVersion int64
+	shadowClickModelVersion   int64
+
 	// events
 	fitTicker    *time.Ticker
 	importedChan *parallel.ConditionChannel // feedback inserted events
 	loadDataChan *parallel.ConditionChannel // dataset loaded events
 	triggerChan  *parallel.ConditionChannel // manually trigger events
+	modelMutex   sync.Mutex
+	modelChan    chan struct{} // closed when models are updated
 
 	scheduleState         ScheduleState
 	workerScheduleHandler http.HandlerFunc
+
+	// tenant served by this master, empty for the master of the cluster
+	tenant string
+	// gRPC services of tenants for workers, keyed by tenant names and then service names
+	tenantServices      map[string]map[string]any
+	tenantServicesMutex sync.RWMutex
 }
 
 // NewMaster creates a master node.
@@ -124,6 +157,10 @@ func NewMaster(cfg *config.Config, cacheFile string, managedMode bool) *Master {
 	otel.SetTracerProvider(tp)
 	otel.SetErrorHandler(log.GetErrorHandler())
 	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	return newMaster(cfg, cacheFile, managedMode)
+}
+
+func newMaster(cfg *config.Config, cacheFile string, managedMode bool) *Master {
 	m := &Master{
 		// create task monitor
 		cacheFile:     cacheFile,
@@ -136,12 +173,18 @@ func NewMaster(cfg *config.Config, cacheFile string, managedMode bool) *Master {
 			cfg.Recommend.Collaborative.ModelSearchEpoch,
 			cfg.Recommend.Collaborative.ModelSearchTrials,
 			cfg.Recommend.Collaborative.EnableModelSizeSearch,
+		).SetSearchStrategy(
+			cfg.Recommend.Collaborative.ModelSearchStrategy,
+			cfg.Recommend.Collaborative.EnableModelSearchEarlyStopping,
 		),
 		// default click model
 		clickModelSearcher: click.NewModelSearcher(
 			cfg.Recommend.Collaborative.ModelSearchEpoch,
 			cfg.Recommend.Collaborative.ModelSearchTrials,
 			cfg.Recommend.Collaborative.EnableModelSizeSearch,
+		).SetSearchStrategy(
+			cfg.Recommend.Collaborative.ModelSearchStrategy,
+			cfg.Recommend.Collaborative.EnableModelSearchEarlyStopping,
 		),
 		RestServer: server.RestServer{
 			Settings: &config.Settings{
@@ -157,12 +200,15 @@ func NewMaster(cfg *config.Config, cacheFile string, managedMode bool) *Master {
 			HttpHost:   cfg.Master.HttpHost,
 			HttpPort:   cfg.Master.HttpPort,
 			WebService: new(restful.WebService),
+			Tenants:    server.NewTenantRouter(),
 		},
 		fitTicker:    time.NewTicker(cfg.Recommend.Collaborative.ModelFitPeriod),
 		importedChan: parallel.NewConditionChannel(),
 		loadDataChan: parallel.NewConditionChannel(),
 		triggerChan:  parallel.NewConditionChannel(),
 	}
+	m.StoreSessionModel(nil, rand.Int63())
+	m.StoreTwoTowerModel(nil, rand.Int63(), nil, nil)
 
 	// enable deep learning
 	if cfg.Experimental.EnableDeepLearning {
@@ -179,45 +225,10 @@ func NewMaster(cfg *config.Config, cacheFile string, managedMode bool) *Master {
 func (m *Master) Serve() {
 
 	// load local cached model
-	var err error
-	m.localCache, err = LoadLocalCache(m.cacheFile)
-	if err != nil {
-		if errors.Is(err, errors.NotFound) {
-			log.Logger().Info("no local cache found, create a new one", zap.String("path", m.cacheFile))
-		} else {
-			log.Logger().Error("failed to load local cache", zap.String("path", m.cacheFile), zap.Error(err))
-		}
-	}
-	if m.localCache.RankingModel != nil {
-		log.Logger().Info("load cached ranking model",
-			zap.String("model_name", m.localCache.RankingModelName),
-			zap.String("model_version", encoding.Hex(m.localCache.RankingModelVersion)),
-			zap.Float32("model_score", m.localCache.RankingModelScore.NDCG),
-			zap.Any("params", m.localCache.RankingModel.GetParams()))
-		m.RankingModel = m.localCache.RankingModel
-		m.rankingModelName = m.localCache.RankingModelName
-		m.RankingModelVersion = m.localCache.RankingModelVersion
-		m.rankingScore = m.localCache.RankingModelScore
-		CollaborativeFilteringPrecision10.Set(float64(m.rankingScore.Precision))
-		CollaborativeFilteringRecall10.Set(float64(m.rankingScore.Recall))
-		CollaborativeFilteringNDCG10.Set(float64(m.rankingScore.NDCG))
-		MemoryInUseBytesVec.WithLabelValues("collaborative_filtering_model").Set(float64(m.RankingModel.Bytes()))
-	}
-	if m.localCache.ClickModel != nil {
-		log.Logger().Info("load cached click model",
-			zap.String("model_version", encoding.Hex(m.localCache.ClickModelVersion)),
-			zap.Float32("model_score", m.localCache.ClickModelScore.Precision),
-			zap.Any("params", m.localCache.ClickModel.GetParams()))
-		m.ClickModel = m.localCache.ClickModel
-		m.clickScore = m.localCache.ClickModelScore
-		m.ClickModelVersion = m.localCache.ClickModelVersion
-		RankingPrecision.Set(float64(m.clickScore.Precision))
-		RankingRecall.Set(float64(m.clickScore.Recall))
-		RankingAUC.Set(float64(m.clickScore.AUC))
-		MemoryInUseBytesVec.WithLabelValues("ranking_model").Set(float64(sizeof.DeepSize(m.ClickModel)))
-	}
+	m.loadLocalCache()
 
 	// connect meta database
+	var err error
 	m.metaStore, err = meta.Open(fmt.Sprintf("sqlite://%s/gorse_meta.db", os.TempDir()), m.Config.Master.MetaTimeout)
 	if err != nil {
 		log.Logger().Fatal("failed to connect meta database", zap.Error(err))
@@ -226,9 +237,19 @@ func (m *Master) Serve() {
 		log.Logger().Fatal("failed to init meta database", zap.Error(err))
 	}
 
+	// open model registry
+	m.blobStore = blob.NewMasterStoreServer(filepath.Join(m.cacheFile, "blob"))
+	m.modelRegistry, err = NewModelRegistry(m.blobStore, m.Config.Recommend.Collaborative.ModelRegistrySize)
+	if err != nil {
+		log.Logger().Fatal("failed to open model registry", zap.Error(err))
+	}
+	m.loadPinnedModels()
+	m.loadSearchHistory()
+
 	// connect data database
 	m.DataClient, err = data.Open(m.Config.Database.DataStore, m.Config.Database.DataTablePrefix,
-		storage.WithIsolationLevel(m.Config.Database.MySQL.IsolationLevel))
+		storage.WithIsolationLevel(m.Config.Database.MySQL.IsolationLevel),
+		storage.WithIndexedLabels(m.Config.Database.MySQL.IndexedLabels))
 	if err != nil {
 		log.Logger().Fatal("failed to connect data database", zap.Error(err),
 			zap.String("database", log.RedactDBURL(m.Config.Database.DataStore)))
@@ -248,6 +269,16 @@ func (m *Master) Serve() {
 		log.Logger().Fatal("failed to init database", zap.Error(err))
 	}
 
+	// load experiments managed by the master API
+	if err = m.loadExperiments(context.Background()); err != nil {
+		log.Logger().Error("failed to load experiments", zap.Error(err))
+	}
+
+	if m.Config.Events.Enable {
+		go m.RunDispatchEventsLoop()
+		log.Logger().Info("start event dispatcher", zap.Int("n_webhooks", len(m.Config.Events.Webhooks)))
+	}
+
 	if m.managedMode {
 		go m.RunManagedTasksLoop()
 	} else {
@@ -266,7 +297,11 @@ func (m *Master) Serve() {
 			zap.String("ssl_ca", m.Config.Master.SSLCA),
 			zap.String("ssl_cert", m.Config.Master.SSLCert),
 			zap.String("ssl_key", m.Config.Master.SSLKey))
-		opts := []grpc.ServerOption{grpc.MaxSendMsgSize(math.MaxInt)}
+		opts := []grpc.ServerOption{
+			grpc.MaxSendMsgSize(math.MaxInt),
+			grpc.UnaryInterceptor(m.unaryTenantInterceptor),
+			grpc.StreamInterceptor(m.streamTenantInterceptor),
+		}
 		if m.Config.Master.SSLMode {
 			c, err := util.NewServerCreds(&util.TLSConfig{
 				SSLCA:   m.Config.Master.SSLCA,
@@ -286,6 +321,7 @@ func (m *Master) Serve() {
 		protocol.RegisterMasterServer(m.grpcServer, m)
 		protocol.RegisterCacheStoreServer(m.grpcServer, cache.NewProxyServer(m.CacheClient))
 		protocol.RegisterDataStoreServer(m.grpcServer, data.NewProxyServer(m.DataClient))
+		protocol.RegisterBlobStoreServer(m.grpcServer, m.blobStore)
 		if err = m.grpcServer.Serve(lis); err != nil {
 			log.Logger().Fatal("failed to start rpc server", zap.Error(err))
 		}
@@ -309,10 +345,84 @@ func (m *Master) Serve() {
 		}
 	}
 
+	// start tenants
+	for _, tenant := range m.Config.Tenants {
+		if err = m.serveTenant(tenant); err != nil {
+			log.Logger().Fatal("failed to start tenant", zap.String("tenant", tenant.Name), zap.Error(err))
+		}
+	}
+
 	// start http server
 	m.StartHttpServer()
 }
 
+// loadLocalCache loads models cached in the local cache file.
+func (m *Master) loadLocalCache() {
+	var err error
+	m.localCache, err = LoadLocalCache(m.cacheFile)
+	if err != nil {
+		if errors.Is(err, errors.NotFound) {
+			log.Logger().Info("no local cache found, create a new one", zap.String("path", m.cacheFile))
+		} else {
+			log.Logger().Error("failed to load local cache", zap.String("path", m.cacheFile), zap.Error(err))
+		}
+	}
+	if m.localCache.RankingModel != nil {
+		log.Logger().Info("load cached ranking model",
+			zap.String("model_name", m.localCache.RankingModelName),
+			zap.String("model_version", encoding.Hex(m.localCache.RankingModelVersion)),
+			zap.Float32("model_score", m.localCache.RankingModelScore.NDCG),
+			zap.Any("params", m.localCache.RankingModel.GetParams()))
+		m.RankingModel = m.localCache.RankingModel
+		m.rankingModelName = m.localCache.RankingModelName
+		m.RankingModelVersion = m.localCache.RankingModelVersion
+		m.rankingScore = m.localCache.RankingModelScore
+		m.gauge(CollaborativeFilteringPrecision10).Set(float64(m.rankingScore.Precision))
+		m.gauge(CollaborativeFilteringRecall10).Set(float64(m.rankingScore.Recall))
+		m.gauge(CollaborativeFilteringNDCG10).Set(float64(m.rankingScore.NDCG))
+		m.gaugeVec(MemoryInUseBytesVec, "collaborative_filtering_model").Set(float64(m.RankingModel.Bytes()))
+	}
+	if m.localCache.ClickModel != nil {
+		log.Logger().Info("load cached click model",
+			zap.String("model_version", encoding.Hex(m.localCache.ClickModelVersion)),
+			zap.Float32("model_score", m.localCache.ClickModelScore.Precision),
+			zap.Any("params", m.localCache.ClickModel.GetParams()))
+		m.ClickModel = m.localCache.ClickModel
+		m.clickScore = m.localCache.ClickModelScore
+		m.ClickModelVersion = m.localCache.ClickModelVersion
+		m.gauge(RankingPrecision).Set(float64(m.clickScore.Precision))
+		m.gauge(RankingRecall).Set(float64(m.clickScore.Recall))
+		m.gauge(RankingAUC).Set(float64(m.clickScore.AUC))
+		m.gauge(RankingLogLoss).Set(float64(m.clickScore.LogLoss))
+		m.gauge(RankingBrierScore).Set(float64(m.clickScore.BrierScore))
+		m.gaugeVec(MemoryInUseBytesVec, "ranking_model").Set(float64(sizeof.DeepSize(m.ClickModel)))
+	}
+}
+
+// loadPinnedModels serves models pinned in the model registry instead of models in the local cache.
+func (m *Master) loadPinnedModels() {
+	for _, modelType := range []string{blob.RankingModelType, blob.ClickModelType} {
+		if pinned, exist := m.modelRegistry.Pinned(modelType); exist {
+			if _, err := m.pinModel(modelType, pinned.Version); err != nil {
+				log.Logger().Error("failed to load pinned model", zap.String("type", modelType),
+					zap.String("version", pinned.Version), zap.Error(err))
+			}
+		}
+	}
+}
+
+// loadSearchHistory loads trials of previous model searches, so that model searches warm-start from them.
+func (m *Master) loadSearchHistory() {
+	for name, history := range map[string]*model.SearchHistory{
+		RankingSearchHistory: m.rankingModelSearcher.GetHistory(),
+		ClickSearchHistory:   m.clickModelSearcher.GetHistory(),
+	} {
+		if err := m.blobStore.ReadBlob(name, history.Unmarshal); err != nil && !os.IsNotExist(err) {
+			log.Logger().Error("failed to load search history", zap.String("name", name), zap.Error(err))
+		}
+	}
+}
+
 func (m *Master) Shutdown() {
 	// stop http server
 	err := m.HttpServer.Shutdown(context.TODO())
@@ -330,6 +440,8 @@ func (m *Master) RunPrivilegedTasksLoop() {
 		tasks = []Task{
 			NewFitClickModelTask(m),
 			NewFitRankingModelTask(m),
+			NewFitSessionModelTask(m),
+			NewFitTwoTowerModelTask(m),
 			NewFindUserNeighborsTask(m),
 			NewFindItemNeighborsTask(m),
 		}
@@ -369,17 +481,17 @@ func (m *Master) RunPrivilegedTasksLoop() {
 
 		var registeredTask []Task
 		for _, t := range tasks {
-			if m.jobsScheduler.Register(t.name(), t.priority(), true) {
+			if m.jobsScheduler.Register(m.taskName(t), t.priority(), true) {
 				registeredTask = append(registeredTask, t)
 			}
 		}
 		for _, t := range registeredTask {
 			go func(task Task) {
-				j := m.jobsScheduler.GetJobsAllocator(task.name())
-				defer m.jobsScheduler.Unregister(task.name())
+				j := m.jobsScheduler.GetJobsAllocator(m.taskName(task))
+				defer m.jobsScheduler.Unregister(m.taskName(task))
 				j.Init()
 				if err := task.run(context.Background(), j); err != nil {
-					log.Logger().Error("failed to run task", zap.String("task", task.name()), zap.Error(err))
+					log.Logger().Error("failed to run task", zap.String("task", m.taskName(task)), zap.Error(err))
 					return
 				}
 			}(t)
@@ -387,6 +499,18 @@ func (m *Master) RunPrivilegedTasksLoop() {
 	}
 }
 
+// RunDispatchEventsLoop delivers change events in the outbox to webhooks periodically.
+func (m *Master) RunDispatchEventsLoop() {
+	defer base.CheckPanic()
+	dispatcher := logics.NewEventDispatcher(&m.Config.Events, m.DataClient, m.CacheClient)
+	for {
+		if err := dispatcher.Dispatch(context.Background()); err != nil {
+			log.Logger().Error("failed to dispatch events", zap.Error(err))
+		}
+		time.Sleep(m.Config.Events.PollInterval)
+	}
+}
+
 // RunRagtagTasksLoop searches optimal recommendation model in background. It never modifies variables other than
 // rankingModelSearcher, clickSearchedModel and clickSearchedScore.
 func (m *Master) RunRagtagTasksLoop() {
@@ -407,17 +531,17 @@ func (m *Master) RunRagtagTasksLoop() {
 		}
 		var registeredTask []Task
 		for _, t := range tasks {
-			if m.jobsScheduler.Register(t.name(), t.priority(), false) {
+			if m.jobsScheduler.Register(m.taskName(t), t.priority(), false) {
 				registeredTask = append(registeredTask, t)
 			}
 		}
 		for _, t := range registeredTask {
 			go func(task Task) {
-				defer m.jobsScheduler.Unregister(task.name())
-				j := m.jobsScheduler.GetJobsAllocator(task.name())
+				defer m.jobsScheduler.Unregister(m.taskName(task))
+				j := m.jobsScheduler.GetJobsAllocator(m.taskName(task))
 				j.Init()
 				if err = task.run(context.Background(), j); err != nil {
-					log.Logger().Error("failed to run task", zap.String("task", task.name()), zap.Error(err))
+					log.Logger().Error("failed to run task", zap.String("task", m.taskName(task)), zap.Error(err))
 				}
 			}(t)
 		}
@@ -430,6 +554,8 @@ func (m *Master) RunManagedTasksLoop() {
 		privilegedTasks = []Task{
 			NewFitClickModelTask(m),
 			NewFitRankingModelTask(m),
+			NewFitSessionModelTask(m),
+			NewFitTwoTowerModelTask(m),
 			NewFindUserNeighborsTask(m),
 			NewFindItemNeighborsTask(m),
 		}
@@ -467,13 +593,13 @@ func (m *Master) RunManagedTasksLoop() {
 
 			var registeredTask []Task
 			for _, t := range privilegedTasks {
-				if m.jobsScheduler.Register(t.name(), t.priority(), true) {
+				if m.jobsScheduler.Register(m.taskName(t), t.priority(), true) {
 					registeredTask = append(registeredTask, t)
 				}
 			}
 			if searchModel {
 				for _, t := range ragtagTasks {
-					if m.jobsScheduler.Register(t.name(), t.priority(), false) {
+					if m.jobsScheduler.Register(m.taskName(t), t.priority(), false) {
 						registeredTask = append(registeredTask, t)
 					}
 				}
@@ -483,12 +609,12 @@ func (m *Master) RunManagedTasksLoop() {
 			wg.Add(len(registeredTask))
 			for _, t := range registeredTask {
 				go func(task Task) {
-					j := m.jobsScheduler.GetJobsAllocator(task.name())
-					defer m.jobsScheduler.Unregister(task.name())
+					j := m.jobsScheduler.GetJobsAllocator(m.taskName(task))
+					defer m.jobsScheduler.Unregister(m.taskName(task))
 					defer wg.Done()
 					j.Init()
 					if err := task.run(context.Background(), j); err != nil {
-						log.Logger().Error("failed to run task", zap.String("task", task.name()), zap.Error(err))
+						log.Logger().Error("failed to run task", zap.String("task", m.taskName(task)), zap.Error(err))
 						return
 					}
 				}(t)
@@ -15,6 +15,7 @@
 package master
 
 import (
+	"math"
 	"time"
 
 	mapset "github.com/deckarep/golang-set/v2"
@@ -28,197 +29,249 @@ const (
 	LabelFeedbackType = "feedback_type"
 	LabelStep         = "step"
 	LabelData         = "data"
+	LabelTenant       = "tenant"
+	LabelMetric       = "metric"
+	LabelLabel        = "label"
 )
 
+// metricNames maps metrics of the master to their names, which are used to label metrics of tenants.
+var metricNames = make(map[prometheus.Collector]string)
+
+func newGauge(opts prometheus.GaugeOpts) prometheus.Gauge {
+	gauge := promauto.NewGauge(opts)
+	metricNames[gauge] = opts.Name
+	return gauge
+}
+
+func newGaugeVec(opts prometheus.GaugeOpts, labelNames []string) *prometheus.GaugeVec {
+	vec := promauto.NewGaugeVec(opts, labelNames)
+	metricNames[vec] = opts.Name
+	return vec
+}
+
+// TenantMetricsVec collects metrics of tenants. A metric of a tenant is labeled by the name of the corresponding
+// metric of the master and its label value (empty for metrics without labels).
+var TenantMetricsVec = promauto.NewGaugeVec(prometheus.GaugeOpts{
+	Namespace: "gorse",
+	Subsystem: "master",
+	Name:      "tenant_metrics",
+}, []string{LabelTenant, LabelMetric, LabelLabel})
+
+// gauge returns the gauge of a metric for the tenant of the master.
+func (m *Master) gauge(gauge prometheus.Gauge) prometheus.Gauge {
+	if m.tenant == "" {
+		return gauge
+	}
+	return TenantMetricsVec.WithLabelValues(m.tenant, metricNames[gauge], "")
+}
+
+// gaugeVec returns the gauge of a metric with a label value for the tenant of the master.
+func (m *Master) gaugeVec(vec *prometheus.GaugeVec, label string) prometheus.Gauge {
+	if m.tenant == "" {
+		return vec.WithLabelValues(label)
+	}
+	return TenantMetricsVec.WithLabelValues(m.tenant, metricNames[vec], label)
+}
+
 var (
-	LoadDatasetStepSecondsVec = promauto.NewGaugeVec(prometheus.GaugeOpts{
+	LoadDatasetStepSecondsVec = newGaugeVec(prometheus.GaugeOpts{
 		Namespace: "gorse",
 		Subsystem: "master",
 		Name:      "load_dataset_step_seconds",
 	}, []string{LabelStep})
-	LoadDatasetTotalSeconds = promauto.NewGauge(prometheus.GaugeOpts{
+	LoadDatasetTotalSeconds = newGauge(prometheus.GaugeOpts{
 		Namespace: "gorse",
 		Subsystem: "master",
 		Name:      "load_dataset_total_seconds",
 	})
-	FindUserNeighborsSecondsVec = promauto.NewGaugeVec(prometheus.GaugeOpts{
+	FindUserNeighborsSecondsVec = newGaugeVec(prometheus.GaugeOpts{
 		Namespace: "gorse",
 		Subsystem: "master",
 		Name:      "find_user_neighbors_seconds",
 	}, []string{LabelStep})
-	FindUserNeighborsTotalSeconds = promauto.NewGauge(prometheus.GaugeOpts{
+	FindUserNeighborsTotalSeconds = newGauge(prometheus.GaugeOpts{
 		Namespace: "gorse",
 		Subsystem: "master",
 		Name:      "find_user_neighbors_total_seconds",
 	})
-	FindItemNeighborsSecondsVec = promauto.NewGaugeVec(prometheus.GaugeOpts{
+	FindItemNeighborsSecondsVec = newGaugeVec(prometheus.GaugeOpts{
 		Namespace: "gorse",
 		Subsystem: "master",
 		Name:      "find_item_neighbors_seconds",
 	}, []string{"step"})
-	FindItemNeighborsTotalSeconds = promauto.NewGauge(prometheus.GaugeOpts{
+	FindItemNeighborsTotalSeconds = newGauge(prometheus.GaugeOpts{
 		Namespace: "gorse",
 		Subsystem: "master",
 		Name:      "find_item_neighbors_total_seconds",
 	})
-	UpdateUserNeighborsTotal = promauto.NewGauge(prometheus.GaugeOpts{
+	UpdateUserNeighborsTotal = newGauge(prometheus.GaugeOpts{
 		Namespace: "gorse",
 		Subsystem: "master",
 		Name:      "update_user_neighbors_total",
 	})
-	UpdateItemNeighborsTotal = promauto.NewGauge(prometheus.GaugeOpts{
+	UpdateItemNeighborsTotal = newGauge(prometheus.GaugeOpts{
 		Namespace: "gorse",
 		Subsystem: "master",
 		Name:      "update_item_neighbors_total",
 	})
-	CacheScannedTotal = promauto.NewGauge(prometheus.GaugeOpts{
+	CacheScannedTotal = newGauge(prometheus.GaugeOpts{
 		Namespace: "gorse",
 		Subsystem: "master",
 		Name:      "cache_scanned_total",
 	})
-	CacheReclaimedTotal = promauto.NewGauge(prometheus.GaugeOpts{
+	CacheReclaimedTotal = newGauge(prometheus.GaugeOpts{
 		Namespace: "gorse",
 		Subsystem: "master",
 		Name:      "cache_reclaimed_total",
 	})
-	CacheScannedSeconds = promauto.NewGauge(prometheus.GaugeOpts{
+	CacheScannedSeconds = newGauge(prometheus.GaugeOpts{
 		Namespace: "gorse",
 		Subsystem: "master",
 		Name:      "cache_scanned_seconds",
 	})
 
-	CollaborativeFilteringFitSeconds = promauto.NewGauge(prometheus.GaugeOpts{
+	CollaborativeFilteringFitSeconds = newGauge(prometheus.GaugeOpts{
 		Namespace: "gorse",
 		Subsystem: "master",
 		Name:      "collaborative_filtering_fit_seconds",
 	})
-	CollaborativeFilteringSearchSeconds = promauto.NewGauge(prometheus.GaugeOpts{
+	CollaborativeFilteringSearchSeconds = newGauge(prometheus.GaugeOpts{
 		Namespace: "gorse",
 		Subsystem: "master",
 		Name:      "collaborative_filtering_search_seconds",
 	})
-	CollaborativeFilteringNDCG10 = promauto.NewGauge(prometheus.GaugeOpts{
+	CollaborativeFilteringNDCG10 = newGauge(prometheus.GaugeOpts{
 		Namespace: "gorse",
 		Subsystem: "master",
 		Name:      "collaborative_filtering_ndcg_10",
 	})
-	CollaborativeFilteringPrecision10 = promauto.NewGauge(prometheus.GaugeOpts{
+	CollaborativeFilteringPrecision10 = newGauge(prometheus.GaugeOpts{
 		Namespace: "gorse",
 		Subsystem: "master",
 		Name:      "collaborative_filtering_precision_10",
 	})
-	CollaborativeFilteringRecall10 = promauto.NewGauge(prometheus.GaugeOpts{
+	CollaborativeFilteringRecall10 = newGauge(prometheus.GaugeOpts{
 		Namespace: "gorse",
 		Subsystem: "master",
 		Name:      "collaborative_filtering_recall_10",
 	})
-	CollaborativeFilteringSearchPrecision10 = promauto.NewGauge(prometheus.GaugeOpts{
+	CollaborativeFilteringSearchPrecision10 = newGauge(prometheus.GaugeOpts{
 		Namespace: "gorse",
 		Subsystem: "master",
 		Name:      "collaborative_filtering_search_precision_10",
 	})
-	RankingFitSeconds = promauto.NewGauge(prometheus.GaugeOpts{
+	RankingFitSeconds = newGauge(prometheus.GaugeOpts{
 		Namespace: "gorse",
 		Subsystem: "master",
 		Name:      "ranking_fit_seconds",
 	})
-	RankingSearchSeconds = promauto.NewGauge(prometheus.GaugeOpts{
+	RankingSearchSeconds = newGauge(prometheus.GaugeOpts{
 		Namespace: "gorse",
 		Subsystem: "master",
 		Name:      "ranking_search_seconds",
 	})
-	RankingPrecision = promauto.NewGauge(prometheus.GaugeOpts{
+	RankingPrecision = newGauge(prometheus.GaugeOpts{
 		Namespace: "gorse",
 		Subsystem: "master",
 		Name:      "ranking_model_precision",
 	})
-	RankingRecall = promauto.NewGauge(prometheus.GaugeOpts{
+	RankingRecall = newGauge(prometheus.GaugeOpts{
 		Namespace: "gorse",
 		Subsystem: "master",
 		Name:      "ranking_model_recall",
 	})
-	RankingAUC = promauto.NewGauge(prometheus.GaugeOpts{
+	RankingAUC = newGauge(prometheus.GaugeOpts{
 		Namespace: "gorse",
 		Subsystem: "master",
 		Name:      "ranking_model_auc",
 	})
-	RankingSearchPrecision = promauto.NewGauge(prometheus.GaugeOpts{
+	RankingLogLoss = newGauge(prometheus.GaugeOpts{
+		Namespace: "gorse",
+		Subsystem: "master",
+		Name:      "ranking_model_log_loss",
+	})
+	RankingBrierScore = newGauge(prometheus.GaugeOpts{
+		Namespace: "gorse",
+		Subsystem: "master",
+		Name:      "ranking_model_brier_score",
+	})
+	RankingSearchPrecision = newGauge(prometheus.GaugeOpts{
 		Namespace: "gorse",
 		Subsystem: "master",
 		Name:      "ranking_search_precision",
 	})
-	UserNeighborIndexRecall = promauto.NewGauge(prometheus.GaugeOpts{
+	UserNeighborIndexRecall = newGauge(prometheus.GaugeOpts{
 		Namespace: "gorse",
 		Subsystem: "master",
 		Name:      "user_neighbor_index_recall",
 	})
-	ItemNeighborIndexRecall = promauto.NewGauge(prometheus.GaugeOpts{
+	ItemNeighborIndexRecall = newGauge(prometheus.GaugeOpts{
 		Namespace: "gorse",
 		Subsystem: "master",
 		Name:      "item_neighbor_index_recall",
 	})
 
-	UsersTotal = promauto.NewGauge(prometheus.GaugeOpts{
+	UsersTotal = newGauge(prometheus.GaugeOpts{
 		Namespace: "gorse",
 		Subsystem: "master",
 		Name:      "users_total",
 	})
-	ActiveUsersTotal = promauto.NewGauge(prometheus.GaugeOpts{
+	ActiveUsersTotal = newGauge(prometheus.GaugeOpts{
 		Namespace: "gorse",
 		Subsystem: "master",
 		Name:      "active_users_total",
 	})
-	InactiveUsersTotal = promauto.NewGauge(prometheus.GaugeOpts{
+	InactiveUsersTotal = newGauge(prometheus.GaugeOpts{
 		Namespace: "gorse",
 		Subsystem: "master",
 		Name:      "inactive_users_total",
 	})
-	ItemsTotal = promauto.NewGauge(prometheus.GaugeOpts{
+	ItemsTotal = newGauge(prometheus.GaugeOpts{
 		Namespace: "gorse",
 		Subsystem: "master",
 		Name:      "items_total",
 	})
-	ActiveItemsTotal = promauto.NewGauge(prometheus.GaugeOpts{
+	ActiveItemsTotal = newGauge(prometheus.GaugeOpts{
 		Namespace: "gorse",
 		Subsystem: "master",
 		Name:      "active_items_total",
 	})
-	InactiveItemsTotal = promauto.NewGauge(prometheus.GaugeOpts{
+	InactiveItemsTotal = newGauge(prometheus.GaugeOpts{
 		Namespace: "gorse",
 		Subsystem: "master",
 		Name:      "inactive_items_total",
 	})
-	UserLabelsTotal = promauto.NewGauge(prometheus.GaugeOpts{
+	UserLabelsTotal = newGauge(prometheus.GaugeOpts{
 		Namespace: "gorse",
 		Subsystem: "master",
 		Name:      "user_labels_total",
 	})
-	ItemLabelsTotal = promauto.NewGauge(prometheus.GaugeOpts{
+	ItemLabelsTotal = newGauge(prometheus.GaugeOpts{
 		Namespace: "gorse",
 		Subsystem: "master",
 		Name:      "item_labels_total",
 	})
-	FeedbacksTotal = promauto.NewGauge(prometheus.GaugeOpts{
+	FeedbacksTotal = newGauge(prometheus.GaugeOpts{
 		Namespace: "gorse",
 		Subsystem: "master",
 		Name:      "feedbacks_total",
 	})
-	ImplicitFeedbacksTotal = promauto.NewGauge(prometheus.GaugeOpts{
+	ImplicitFeedbacksTotal = newGauge(prometheus.GaugeOpts{
 		Namespace: "gorse",
 		Subsystem: "master",
 		Name:      "implicit_feedbacks_total",
 	})
-	PositiveFeedbacksTotal = promauto.NewGauge(prometheus.GaugeOpts{
+	PositiveFeedbacksTotal = newGauge(prometheus.GaugeOpts{
 		Namespace: "gorse",
 		Subsystem: "master",
 		Name:      "positive_feedbacks_total",
 	})
-	NegativeFeedbackTotal = promauto.NewGauge(prometheus.GaugeOpts{
+	NegativeFeedbackTotal = newGauge(prometheus.GaugeOpts{
 		Namespace: "gorse",
 		Subsystem: "master",
 		Name:      "negative_feedbacks_total",
 	})
-	MemoryInUseBytesVec = promauto.NewGaugeVec(prometheus.GaugeOpts{
+	MemoryInUseBytesVec = newGaugeVec(prometheus.GaugeOpts{
 		Namespace: "gorse",
 		Subsystem: "master",
 		Name:      "memory_inuse_bytes",
@@ -229,6 +282,8 @@ type OnlineEvaluator struct {
 	ReadFeedbacks      []map[int32]mapset.Set[int32]
 	PositiveFeedbacks  map[string][]lo.Tuple3[int32, int32, time.Time]
 	ReverseIndex       map[lo.Tuple2[int32, int32]]time.Time
+	Impressions        map[string][]lo.Tuple3[int32, int32, time.Time]
+	Sales              []lo.Tuple2[time.Time, time.Time]
 	EvaluateDays       int
 	TruncatedDateToday time.Time
 }
@@ -239,6 +294,7 @@ func NewOnlineEvaluator() *OnlineEvaluator {
 	evaluator.TruncatedDateToday = time.Now().Truncate(time.Hour * 24)
 	evaluator.ReverseIndex = make(map[lo.Tuple2[int32, int32]]time.Time)
 	evaluator.PositiveFeedbacks = make(map[string][]lo.Tuple3[int32, int32, time.Time])
+	evaluator.Impressions = make(map[string][]lo.Tuple3[int32, int32, time.Time])
 	evaluator.ReadFeedbacks = make([]map[int32]mapset.Set[int32], evaluator.EvaluateDays)
 	for i := 0; i < evaluator.EvaluateDays; i++ {
 		evaluator.ReadFeedbacks[i] = make(map[int32]mapset.Set[int32])
@@ -264,8 +320,166 @@ func (evaluator *OnlineEvaluator) Positive(feedbackType string, userIndex, itemI
 	evaluator.PositiveFeedbacks[feedbackType] = append(evaluator.PositiveFeedbacks[feedbackType], lo.Tuple3[int32, int32, time.Time]{userIndex, itemIndex, timestamp})
 }
 
+// Impress records an item shown to a user by a recommendation source.
+func (evaluator *OnlineEvaluator) Impress(source string, userIndex, itemIndex int32, timestamp time.Time) {
+	evaluator.Impressions[source] = append(evaluator.Impressions[source], lo.Tuple3[int32, int32, time.Time]{userIndex, itemIndex, timestamp})
+}
+
+// Sell records an item listed and sold at given times.
+func (evaluator *OnlineEvaluator) Sell(listed, sold time.Time) {
+	evaluator.Sales = append(evaluator.Sales, lo.Tuple2[time.Time, time.Time]{A: listed, B: sold})
+}
+
+// EvaluateTimeToSell computes the mean number of days between listing and sale of items sold in each day. Days
+// without sales are skipped.
+func (evaluator *OnlineEvaluator) EvaluateTimeToSell() []cache.TimeSeriesPoint {
+	sums := make([]float64, evaluator.EvaluateDays)
+	counts := make([]int, evaluator.EvaluateDays)
+	for _, sale := range evaluator.Sales {
+		truncatedTime := sale.B.Truncate(time.Hour * 24)
+		index := int(evaluator.TruncatedDateToday.Sub(truncatedTime) / time.Hour / 24)
+		if index < 0 || index >= evaluator.EvaluateDays {
+			continue
+		}
+		sums[index] += sale.B.Sub(sale.A).Hours() / 24
+		counts[index]++
+	}
+	var measurements []cache.TimeSeriesPoint
+	for i := 0; i < evaluator.EvaluateDays; i++ {
+		if counts[i] > 0 {
+			measurements = append(measurements, cache.TimeSeriesPoint{
+				Name:      TimeToSell,
+				Timestamp: evaluator.TruncatedDateToday.Add(-time.Hour * 24 * time.Duration(i)),
+				Value:     sums[i] / float64(counts[i]),
+			})
+		}
+	}
+	return measurements
+}
+
+// EvaluateImpressions computes click-through rates of each recommendation source. An impression is clicked if the
+// user gives positive feedback to the item after the impression. The click-through rate of a day is the ratio of
+// clicked impressions in impressions of the day.
+func (evaluator *OnlineEvaluator) EvaluateImpressions() []cache.TimeSeriesPoint {
+	var measurements []cache.TimeSeriesPoint
+	for feedbackType, positiveFeedbacks := range evaluator.PositiveFeedbacks {
+		// index the latest positive feedback of each pair
+		latest := make(map[lo.Tuple2[int32, int32]]time.Time)
+		for _, f := range positiveFeedbacks {
+			key := lo.Tuple2[int32, int32]{f.A, f.B}
+			if timestamp, exist := latest[key]; !exist || f.C.After(timestamp) {
+				latest[key] = f.C
+			}
+		}
+		for source, impressions := range evaluator.Impressions {
+			clicks := make([]int, evaluator.EvaluateDays)
+			counts := make([]int, evaluator.EvaluateDays)
+			for _, impression := range impressions {
+				truncatedTime := impression.C.Truncate(time.Hour * 24)
+				index := int(evaluator.TruncatedDateToday.Sub(truncatedTime) / time.Hour / 24)
+				if index < 0 || index >= evaluator.EvaluateDays {
+					continue
+				}
+				counts[index]++
+				if timestamp, exist := latest[lo.Tuple2[int32, int32]{impression.A, impression.B}]; exist && !timestamp.Before(impression.C) {
+					clicks[index]++
+				}
+			}
+			for i := 0; i < evaluator.EvaluateDays; i++ {
+				var rate float64
+				if counts[i] > 0 {
+					rate = float64(clicks[i]) / float64(counts[i])
+				}
+				measurements = append(measurements, cache.TimeSeriesPoint{
+					Name:      cache.Key(ImpressionClickThroughRate, source, feedbackType),
+					Timestamp: evaluator.TruncatedDateToday.Add(-time.Hour * 24 * time.Duration(i)),
+					Value:     rate,
+				})
+			}
+		}
+	}
+	return measurements
+}
+
 func (evaluator *OnlineEvaluator) Evaluate() []cache.TimeSeriesPoint {
 	var measurements []cache.TimeSeriesPoint
+	rates := evaluator.evaluate(func(int32) (string, bool) { return "", true })
+	for feedbackType := range evaluator.PositiveFeedbacks {
+		for i := 0; i < evaluator.EvaluateDays; i++ {
+			var rate float64
+			if days, exist := rates[""][feedbackType]; exist {
+				rate = days[i].Mean
+			}
+			measurements = append(measurements, cache.TimeSeriesPoint{
+				Name:      cache.Key(PositiveFeedbackRate, feedbackType),
+				Timestamp: evaluator.TruncatedDateToday.Add(-time.Hour * 24 * time.Duration(i)),
+				Value:     rate,
+			})
+		}
+	}
+	return measurements
+}
+
+// EvaluateExperiment computes positive feedback rates of each variant of an experiment. The assign function returns
+// the variant of a user, and users out of the experiment are skipped. Besides rates, lower and upper bounds of 95%
+// confidence intervals are stored in separated time series.
+func (evaluator *OnlineEvaluator) EvaluateExperiment(experiment string, assign func(userIndex int32) (string, bool)) []cache.TimeSeriesPoint {
+	var measurements []cache.TimeSeriesPoint
+	for variant, rates := range evaluator.evaluate(assign) {
+		for feedbackType, days := range rates {
+			for i, rate := range days {
+				timestamp := evaluator.TruncatedDateToday.Add(-time.Hour * 24 * time.Duration(i))
+				measurements = append(measurements, cache.TimeSeriesPoint{
+					Name:      cache.Key(ExperimentPositiveFeedbackRate, experiment, variant, feedbackType),
+					Timestamp: timestamp,
+					Value:     rate.Mean,
+				}, cache.TimeSeriesPoint{
+					Name:      cache.Key(ExperimentPositiveFeedbackRateLower, experiment, variant, feedbackType),
+					Timestamp: timestamp,
+					Value:     math.Max(0, rate.Mean-confidenceZ*rate.StdErr),
+				}, cache.TimeSeriesPoint{
+					Name:      cache.Key(ExperimentPositiveFeedbackRateUpper, experiment, variant, feedbackType),
+					Timestamp: timestamp,
+					Value:     math.Min(1, rate.Mean+confidenceZ*rate.StdErr),
+				})
+			}
+		}
+	}
+	return measurements
+}
+
+// confidenceZ is the z-score of the 95% confidence interval.
+const confidenceZ = 1.96
+
+// positiveFeedbackRate is the positive feedback rate of a day and its standard error.
+type positiveFeedbackRate struct {
+	Mean   float64
+	StdErr float64
+}
+
+// evaluate computes positive feedback rates of groups. The positive feedback rate of a user is the ratio of read items
+// that receive positive feedback. The rate of a group is the mean of rates of users in the group. Results are indexed
+// by group, feedback type and day.
+func (evaluator *OnlineEvaluator) evaluate(group func(userIndex int32) (string, bool)) map[string]map[string][]positiveFeedbackRate {
+	// assign users to groups
+	groups := make(map[int32]string)
+	groupNames := mapset.NewSet[string]()
+	for i := 0; i < evaluator.EvaluateDays; i++ {
+		for userIndex := range evaluator.ReadFeedbacks[i] {
+			if _, exist := groups[userIndex]; exist {
+				continue
+			}
+			if name, ok := group(userIndex); ok {
+				groups[userIndex] = name
+				groupNames.Add(name)
+			}
+		}
+	}
+
+	results := make(map[string]map[string][]positiveFeedbackRate)
+	for _, name := range groupNames.ToSlice() {
+		results[name] = make(map[string][]positiveFeedbackRate)
+	}
 	for feedbackType, positiveFeedbacks := range evaluator.PositiveFeedbacks {
 		positiveFeedbackSets := make([]map[int32]mapset.Set[int32], evaluator.EvaluateDays)
 		for i := 0; i < evaluator.EvaluateDays; i++ {
@@ -284,23 +498,39 @@ func (evaluator *OnlineEvaluator) Evaluate() []cache.TimeSeriesPoint {
 			}
 		}
 
+		for _, name := range groupNames.ToSlice() {
+			results[name][feedbackType] = make([]positiveFeedbackRate, evaluator.EvaluateDays)
+		}
 		for i := 0; i < evaluator.EvaluateDays; i++ {
-			var rate float64
-			if len(evaluator.ReadFeedbacks[i]) > 0 {
+			userRates := make(map[string][]float64)
+			for userIndex, readSet := range evaluator.ReadFeedbacks[i] {
+				name, ok := groups[userIndex]
+				if !ok {
+					continue
+				}
+				var rate float64
+				if positiveSet, exist := positiveFeedbackSets[i][userIndex]; exist {
+					rate = float64(positiveSet.Cardinality()) / float64(readSet.Cardinality())
+				}
+				userRates[name] = append(userRates[name], rate)
+			}
+			for name, rates := range userRates {
 				var sum float64
-				for userIndex, readSet := range evaluator.ReadFeedbacks[i] {
-					if positiveSet, exist := positiveFeedbackSets[i][userIndex]; exist {
-						sum += float64(positiveSet.Cardinality()) / float64(readSet.Cardinality())
+				for _, rate := range rates {
+					sum += rate
+				}
+				mean := sum / float64(len(rates))
+				var stdErr float64
+				if len(rates) > 1 {
+					var squareSum float64
+					for _, rate := range rates {
+						squareSum += (rate - mean) * (rate - mean)
 					}
+					stdErr = math.Sqrt(squareSum / float64(len(rates)-1) / float64(len(rates)))
 				}
-				rate = sum / float64(len(evaluator.ReadFeedbacks[i]))
+				results[name][feedbackType][i] = positiveFeedbackRate{Mean: mean, StdErr: stdErr}
 			}
-			measurements = append(measurements, cache.TimeSeriesPoint{
-				Name:      cache.Key(PositiveFeedbackRate, feedbackType),
-				Timestamp: evaluator.TruncatedDateToday.Add(-time.Hour * 24 * time.Duration(i)),
-				Value:     rate,
-			})
 		}
 	}
-	return measurements
+	return results
 }
@@ -18,6 +18,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/samber/lo"
 	"github.com/stretchr/testify/assert"
 	"github.com/zhenghaoz/gorse/storage/cache"
 )
@@ -55,3 +56,96 @@ func TestOnlineEvaluator(t *testing.T) {
 		{"PositiveFeedbackRate/fork", time.Date(2005, 6, 15, 0, 0, 0, 0, time.UTC), 0},
 	}, result)
 }
+
+func TestOnlineEvaluator_EvaluateTimeToSell(t *testing.T) {
+	evaluator := NewOnlineEvaluator()
+	evaluator.TruncatedDateToday = time.Date(2005, 6, 16, 0, 0, 0, 0, time.UTC)
+	evaluator.EvaluateDays = 2
+	evaluator.Sell(time.Date(2005, 6, 10, 0, 0, 0, 0, time.UTC), time.Date(2005, 6, 15, 12, 0, 0, 0, time.UTC))
+	evaluator.Sell(time.Date(2005, 6, 12, 0, 0, 0, 0, time.UTC), time.Date(2005, 6, 15, 0, 0, 0, 0, time.UTC))
+	evaluator.Sell(time.Date(2005, 6, 16, 0, 0, 0, 0, time.UTC), time.Date(2005, 6, 16, 6, 0, 0, 0, time.UTC))
+	// sales out of the evaluation window are skipped
+	evaluator.Sell(time.Date(2005, 6, 1, 0, 0, 0, 0, time.UTC), time.Date(2005, 6, 2, 0, 0, 0, 0, time.UTC))
+	assert.ElementsMatch(t, []cache.TimeSeriesPoint{
+		{Name: TimeToSell, Timestamp: time.Date(2005, 6, 16, 0, 0, 0, 0, time.UTC), Value: 0.25},
+		{Name: TimeToSell, Timestamp: time.Date(2005, 6, 15, 0, 0, 0, 0, time.UTC), Value: 4.25},
+	}, evaluator.EvaluateTimeToSell())
+	assert.Empty(t, NewOnlineEvaluator().EvaluateTimeToSell())
+}
+
+func TestOnlineEvaluator_EvaluateExperiment(t *testing.T) {
+	evaluator := NewOnlineEvaluator()
+	evaluator.TruncatedDateToday = time.Date(2005, 6, 16, 0, 0, 0, 0, time.UTC)
+	evaluator.EvaluateDays = 2
+	for i := int32(1); i <= 5; i++ {
+		evaluator.Read(1, i, time.Date(2005, 6, 15, 0, 0, 0, 0, time.UTC))
+	}
+	for i := int32(1); i <= 4; i++ {
+		evaluator.Read(2, i, time.Date(2005, 6, 15, 0, 0, 0, 0, time.UTC))
+	}
+	evaluator.Read(3, 1, time.Date(2005, 6, 15, 0, 0, 0, 0, time.UTC))
+	evaluator.Positive("star", 1, 1, time.Date(2005, 6, 15, 0, 0, 0, 0, time.UTC))
+	evaluator.Positive("star", 2, 1, time.Date(2005, 6, 15, 0, 0, 0, 0, time.UTC))
+	evaluator.Positive("star", 2, 3, time.Date(2005, 6, 16, 0, 0, 0, 0, time.UTC))
+	evaluator.Positive("star", 3, 1, time.Date(2005, 6, 16, 0, 0, 0, 0, time.UTC))
+
+	// user 3 is out of the experiment
+	result := evaluator.EvaluateExperiment("exp", func(userIndex int32) (string, bool) {
+		return "a", userIndex != 3
+	})
+	points := make(map[string]float64)
+	for _, point := range result {
+		if point.Timestamp.Equal(time.Date(2005, 6, 15, 0, 0, 0, 0, time.UTC)) {
+			points[point.Name] = point.Value
+		} else {
+			assert.Zero(t, point.Value)
+		}
+	}
+	assert.Len(t, result, 6)
+	assert.InDelta(t, 0.35, points["ExperimentPositiveFeedbackRate/exp/a/star"], 1e-6)
+	assert.InDelta(t, 0.35-1.96*0.15, points["ExperimentPositiveFeedbackRateLower/exp/a/star"], 1e-6)
+	assert.InDelta(t, 0.35+1.96*0.15, points["ExperimentPositiveFeedbackRateUpper/exp/a/star"], 1e-6)
+
+	// each variant is evaluated separately
+	result = evaluator.EvaluateExperiment("exp", func(userIndex int32) (string, bool) {
+		if userIndex == 1 {
+			return "a", true
+		}
+		return "b", true
+	})
+	points = make(map[string]float64)
+	for _, point := range result {
+		if point.Timestamp.Equal(time.Date(2005, 6, 15, 0, 0, 0, 0, time.UTC)) {
+			points[point.Name] = point.Value
+		}
+	}
+	assert.InDelta(t, 0.2, points["ExperimentPositiveFeedbackRate/exp/a/star"], 1e-6)
+	assert.InDelta(t, 0.2, points["ExperimentPositiveFeedbackRateLower/exp/a/star"], 1e-6)
+	assert.InDelta(t, 0.75, points["ExperimentPositiveFeedbackRate/exp/b/star"], 1e-6)
+}
+
+func TestOnlineEvaluator_EvaluateImpressions(t *testing.T) {
+	evaluator := NewOnlineEvaluator()
+	evaluator.TruncatedDateToday = time.Date(2005, 6, 16, 0, 0, 0, 0, time.UTC)
+	evaluator.EvaluateDays = 2
+	for i := int32(1); i <= 4; i++ {
+		evaluator.Impress("offline", 1, i, time.Date(2005, 6, 15, 1, 0, 0, 0, time.UTC))
+	}
+	evaluator.Impress("latest", 1, 5, time.Date(2005, 6, 16, 1, 0, 0, 0, time.UTC))
+	evaluator.Impress("latest", 2, 5, time.Date(2005, 6, 16, 1, 0, 0, 0, time.UTC))
+	evaluator.Positive("star", 1, 1, time.Date(2005, 6, 15, 2, 0, 0, 0, time.UTC))
+	evaluator.Positive("star", 1, 2, time.Date(2005, 6, 16, 2, 0, 0, 0, time.UTC))
+	// feedback before impressions isn't a click
+	evaluator.Positive("star", 1, 3, time.Date(2005, 6, 15, 0, 0, 0, 0, time.UTC))
+	evaluator.Positive("star", 2, 5, time.Date(2005, 6, 16, 2, 0, 0, 0, time.UTC))
+
+	result := evaluator.EvaluateImpressions()
+	assert.Len(t, result, 4)
+	points := make(map[lo.Tuple2[string, time.Time]]float64)
+	for _, point := range result {
+		points[lo.Tuple2[string, time.Time]{A: point.Name, B: point.Timestamp}] = point.Value
+	}
+	assert.Equal(t, 0.5, points[lo.Tuple2[string, time.Time]{A: "ImpressionClickThroughRate/offline/star", B: time.Date(2005, 6, 15, 0, 0, 0, 0, time.UTC)}])
+	assert.Zero(t, points[lo.Tuple2[string, time.Time]{A: "ImpressionClickThroughRate/offline/star", B: time.Date(2005, 6, 16, 0, 0, 0, 0, time.UTC)}])
+	assert.Equal(t, 0.5, points[lo.Tuple2[string, time.Time]{A: "ImpressionClickThroughRate/latest/star", B: time.Date(2005, 6, 16, 0, 0, 0, 0, time.UTC)}])
+}
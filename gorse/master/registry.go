@@ -0,0 +1,422 @@
+// Copyright 2024 gorse Project Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package master
+
+import (
+	"encoding/json"
+	std_errors "errors"
+	"io"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/juju/errors"
+	"github.com/samber/lo"
+	"github.com/zhenghaoz/gorse/base/encoding"
+	"github.com/zhenghaoz/gorse/base/log"
+	"github.com/zhenghaoz/gorse/model"
+	"github.com/zhenghaoz/gorse/model/click"
+	"github.com/zhenghaoz/gorse/model/ranking"
+	"github.com/zhenghaoz/gorse/storage/blob"
+	"go.uber.org/zap"
+)
+
+const registryBlob = "registry.json"
+
+// ModelVersion is the metadata of a model version in the registry.
+type ModelVersion struct {
+	Type        string             `json:"type"`
+	Version     string             `json:"version"`
+	Name        string             `json:"name"`
+	Params      model.Params       `json:"params"`
+	Scores      map[string]float32 `json:"scores"`
+	NumUsers    int                `json:"num_users"`
+	NumItems    int                `json:"num_items"`
+	NumFeedback int                `json:"num_feedback"`
+	Timestamp   time.Time          `json:"timestamp"`
+	Pinned      bool               `json:"pinned"`
+}
+
+// ModelRegistry keeps recent versions of ranking models and click models in the blob store of the master. Models
+// are stored as blobs named by blob.ModelBlobName and metadata of versions is stored in a JSON blob. At most one
+// version of each type of model could be pinned, which is served instead of newly fitted models. Pinned versions are
+// never evicted.
+type ModelRegistry struct {
+	mutex    sync.Mutex
+	store    *blob.MasterStoreServer
+	size     int
+	versions []ModelVersion // versions from the newest to the oldest
+}
+
+// NewModelRegistry opens a model registry keeping at most size versions for each type of model.
+func NewModelRegistry(store *blob.MasterStoreServer, size int) (*ModelRegistry, error) {
+	r := &ModelRegistry{store: store, size: size}
+	err := store.ReadBlob(registryBlob, func(reader io.Reader) error {
+		return json.NewDecoder(reader).Decode(&r.versions)
+	})
+	if err != nil && !std_errors.Is(err, os.ErrNotExist) {
+		return nil, errors.Trace(err)
+	}
+	return r, nil
+}
+
+// AddRankingModel adds a version of ranking model to the registry.
+func (r *ModelRegistry) AddRankingModel(version ModelVersion, m ranking.MatrixFactorization) error {
+	version.Type = blob.RankingModelType
+	return r.add(version, func(w io.Writer) error {
+		return ranking.MarshalModel(w, m)
+	})
+}
+
+// AddClickModel adds a version of click model to the registry.
+func (r *ModelRegistry) AddClickModel(version ModelVersion, m click.FactorizationMachine) error {
+	version.Type = blob.ClickModelType
+	return r.add(version, func(w io.Writer) error {
+		return click.MarshalModel(w, m)
+	})
+}
+
+func (r *ModelRegistry) add(version ModelVersion, marshal func(w io.Writer) error) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if r.size == 0 {
+		return nil
+	}
+	versionNumber, err := parseVersion(version.Version)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if err = r.store.WriteBlob(blob.ModelBlobName(version.Type, versionNumber), marshal); err != nil {
+		return errors.Trace(err)
+	}
+	versions := []ModelVersion{version}
+	count := 1
+	for _, v := range r.versions {
+		if v.Type == version.Type && v.Version == version.Version {
+			continue
+		} else if v.Type == version.Type && !v.Pinned {
+			if count >= r.size {
+				// evict the oldest unpinned version
+				if err = r.removeBlob(v); err != nil {
+					return errors.Trace(err)
+				}
+				continue
+			}
+			count++
+		}
+		versions = append(versions, v)
+	}
+	r.versions = versions
+	return r.writeIndex()
+}
+
+// List returns versions of a type of model from the newest to the oldest.
+func (r *ModelRegistry) List(modelType string) []ModelVersion {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return lo.Filter(r.versions, func(v ModelVersion, _ int) bool {
+		return v.Type == modelType
+	})
+}
+
+// Get returns a version of a type of model.
+func (r *ModelRegistry) Get(modelType, version string) (ModelVersion, bool) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return lo.Find(r.versions, func(v ModelVersion) bool {
+		return v.Type == modelType && v.Version == version
+	})
+}
+
+// Pinned returns the pinned version of a type of model.
+func (r *ModelRegistry) Pinned(modelType string) (ModelVersion, bool) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return lo.Find(r.versions, func(v ModelVersion) bool {
+		return v.Type == modelType && v.Pinned
+	})
+}
+
+// Pin a version of a type of model. The previously pinned version is unpinned.
+func (r *ModelRegistry) Pin(modelType, version string) (ModelVersion, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	_, index, found := lo.FindIndexOf(r.versions, func(v ModelVersion) bool {
+		return v.Type == modelType && v.Version == version
+	})
+	if !found {
+		return ModelVersion{}, errors.NotFoundf("%s model version %s", modelType, version)
+	}
+	for i := range r.versions {
+		if r.versions[i].Type == modelType {
+			r.versions[i].Pinned = i == index
+		}
+	}
+	return r.versions[index], r.writeIndex()
+}
+
+// Unpin the pinned version of a type of model.
+func (r *ModelRegistry) Unpin(modelType string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	for i := range r.versions {
+		if r.versions[i].Type == modelType {
+			r.versions[i].Pinned = false
+		}
+	}
+	return r.writeIndex()
+}
+
+// Previous returns the version of a type of model registered before the given version.
+func (r *ModelRegistry) Previous(modelType, version string) (ModelVersion, error) {
+	versions := r.List(modelType)
+	_, index, found := lo.FindIndexOf(versions, func(v ModelVersion) bool {
+		return v.Version == version
+	})
+	if !found {
+		return ModelVersion{}, errors.NotFoundf("%s model version %s", modelType, version)
+	} else if index+1 >= len(versions) {
+		return ModelVersion{}, errors.NotFoundf("%s model version before %s", modelType, version)
+	}
+	return versions[index+1], nil
+}
+
+// NextVersion returns a version greater than the given version and all versions of a type of model in the registry.
+func (r *ModelRegistry) NextVersion(modelType string, version int64) int64 {
+	for _, v := range r.List(modelType) {
+		if number, err := parseVersion(v.Version); err == nil && number > version {
+			version = number
+		}
+	}
+	return version + 1
+}
+
+// LoadRankingModel loads a version of ranking model from the registry.
+func (r *ModelRegistry) LoadRankingModel(version string) (m ranking.MatrixFactorization, err error) {
+	versionNumber, err := parseVersion(version)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	err = r.store.ReadBlob(blob.ModelBlobName(blob.RankingModelType, versionNumber), func(reader io.Reader) error {
+		m, err = ranking.UnmarshalModel(reader)
+		return err
+	})
+	return m, errors.Trace(err)
+}
+
+// LoadClickModel loads a version of click model from the registry.
+func (r *ModelRegistry) LoadClickModel(version string) (m click.FactorizationMachine, err error) {
+	versionNumber, err := parseVersion(version)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	err = r.store.ReadBlob(blob.ModelBlobName(blob.ClickModelType, versionNumber), func(reader io.Reader) error {
+		m, err = click.UnmarshalModel(reader)
+		return err
+	})
+	return m, errors.Trace(err)
+}
+
+func (r *ModelRegistry) removeBlob(v ModelVersion) error {
+	versionNumber, err := parseVersion(v.Version)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	err = r.store.RemoveBlob(blob.ModelBlobName(v.Type, versionNumber))
+	if err != nil && !std_errors.Is(err, os.ErrNotExist) {
+		return errors.Trace(err)
+	}
+	return nil
+}
+
+func (r *ModelRegistry) writeIndex() error {
+	return r.store.WriteBlob(registryBlob, func(w io.Writer) error {
+		return json.NewEncoder(w).Encode(r.versions)
+	})
+}
+
+// parseVersion parses a version in hex, which is the format of versions in logs and APIs.
+func parseVersion(version string) (int64, error) {
+	return strconv.ParseInt(version, 16, 64)
+}
+
+// isModelPinned checks whether a version of a type of model is pinned.
+func (m *Master) isModelPinned(modelType string) bool {
+	if m.modelRegistry == nil {
+		return false
+	}
+	_, pinned := m.modelRegistry.Pinned(modelType)
+	return pinned
+}
+
+// nextModelVersion returns the version of a newly fitted model, which never collides with versions in the registry.
+func (m *Master) nextModelVersion(modelType string, version int64) int64 {
+	if m.modelRegistry == nil {
+		return version + 1
+	}
+	return m.modelRegistry.NextVersion(modelType, version)
+}
+
+// pinModel pins a version of model in the registry. The pinned version is served until it is unpinned, and newly
+// fitted models become candidates evaluated in shadow mode.
+func (m *Master) pinModel(modelType, version string) (ModelVersion, error) {
+	if modelType != blob.RankingModelType && modelType != blob.ClickModelType {
+		return ModelVersion{}, errors.NotValidf("model type %s", modelType)
+	}
+	if _, exist := m.modelRegistry.Get(modelType, version); !exist {
+		return ModelVersion{}, errors.NotFoundf("%s model version %s", modelType, version)
+	}
+	versionNumber, err := parseVersion(version)
+	if err != nil {
+		return ModelVersion{}, errors.Trace(err)
+	}
+	var pinned ModelVersion
+	switch modelType {
+	case blob.RankingModelType:
+		rankingModel, err := m.modelRegistry.LoadRankingModel(version)
+		if err != nil {
+			return ModelVersion{}, errors.Trace(err)
+		}
+		if pinned, err = m.modelRegistry.Pin(modelType, version); err != nil {
+			return ModelVersion{}, errors.Trace(err)
+		}
+		m.rankingModelMutex.Lock()
+		if m.RankingModelVersion != versionNumber {
+			m.prevRankingModel = m.RankingModel
+			m.prevRankingModelVersion = m.RankingModelVersion
+			m.RankingModel = rankingModel
+			m.RankingModelVersion = versionNumber
+			m.rankingModelName = pinned.Name
+			m.rankingScore = ranking.Score{
+				NDCG:      pinned.Scores["ndcg"],
+				Precision: pinned.Scores["precision"],
+				Recall:    pinned.Scores["recall"],
+			}
+		}
+		m.shadowRankingModelVersion = 0
+		m.rankingModelMutex.Unlock()
+	case blob.ClickModelType:
+		clickModel, err := m.modelRegistry.LoadClickModel(version)
+		if err != nil {
+			return ModelVersion{}, errors.Trace(err)
+		}
+		if pinned, err = m.modelRegistry.Pin(modelType, version); err != nil {
+			return ModelVersion{}, errors.Trace(err)
+		}
+		m.clickModelMutex.Lock()
+		if m.ClickModelVersion != versionNumber {
+			m.ClickModel = clickModel
+			m.ClickModelVersion = versionNumber
+			m.clickScore = click.Score{
+				Task:      m.clickScore.Task,
+				Precision: pinned.Scores["precision"],
+				Recall:    pinned.Scores["recall"],
+				AUC:       pinned.Scores["auc"],
+			}
+		}
+		m.shadowClickModelVersion = 0
+		m.clickModelMutex.Unlock()
+	}
+	m.notifyModelUpdated()
+	log.Logger().Info("pin model", zap.String("type", modelType), zap.String("version", version))
+	return pinned, nil
+}
+
+// unpinModel unpins a type of model. The pinned version is served until the next model is fitted.
+func (m *Master) unpinModel(modelType string) error {
+	switch modelType {
+	case blob.RankingModelType:
+		m.rankingModelMutex.Lock()
+		m.shadowRankingModelVersion = 0
+		m.rankingModelMutex.Unlock()
+	case blob.ClickModelType:
+		m.clickModelMutex.Lock()
+		m.shadowClickModelVersion = 0
+		m.clickModelMutex.Unlock()
+	default:
+		return errors.NotValidf("model type %s", modelType)
+	}
+	if err := m.modelRegistry.Unpin(modelType); err != nil {
+		return errors.Trace(err)
+	}
+	m.notifyModelUpdated()
+	log.Logger().Info("unpin model", zap.String("type", modelType))
+	return nil
+}
+
+// rollbackModel pins the version of model registered before the served version.
+func (m *Master) rollbackModel(modelType string) (ModelVersion, error) {
+	var version int64
+	switch modelType {
+	case blob.RankingModelType:
+		m.rankingModelMutex.RLock()
+		version = m.RankingModelVersion
+		m.rankingModelMutex.RUnlock()
+	case blob.ClickModelType:
+		m.clickModelMutex.RLock()
+		version = m.ClickModelVersion
+		m.clickModelMutex.RUnlock()
+	default:
+		return ModelVersion{}, errors.NotValidf("model type %s", modelType)
+	}
+	previous, err := m.modelRegistry.Previous(modelType, encoding.Hex(version))
+	if err != nil {
+		return ModelVersion{}, errors.Trace(err)
+	}
+	return m.pinModel(modelType, previous.Version)
+}
+
+// newRankingModelVersion creates the metadata of a fitted ranking model.
+func newRankingModelVersion(version int64, name string, m ranking.MatrixFactorization, score ranking.Score, dataset *ranking.DataSet) ModelVersion {
+	return ModelVersion{
+		Version: encoding.Hex(version),
+		Name:    name,
+		Params:  m.GetParams(),
+		Scores: map[string]float32{
+			"ndcg":      score.NDCG,
+			"precision": score.Precision,
+			"recall":    score.Recall,
+		},
+		NumUsers:    dataset.UserCount(),
+		NumItems:    dataset.ItemCount(),
+		NumFeedback: dataset.Count(),
+		Timestamp:   time.Now(),
+	}
+}
+
+// newClickModelVersion creates the metadata of a fitted click model.
+func newClickModelVersion(version int64, m click.FactorizationMachine, score click.Score, dataset *click.Dataset) ModelVersion {
+	name := "fm"
+	if _, isDeepFM := m.(*click.DeepFM); isDeepFM {
+		name = "deepfm"
+	}
+	return ModelVersion{
+		Version: encoding.Hex(version),
+		Name:    name,
+		Params:  m.GetParams(),
+		Scores: map[string]float32{
+			"precision":   score.Precision,
+			"recall":      score.Recall,
+			"auc":         score.AUC,
+			"log_loss":    score.LogLoss,
+			"brier_score": score.BrierScore,
+		},
+		NumUsers:    dataset.UserCount(),
+		NumItems:    dataset.ItemCount(),
+		NumFeedback: dataset.Count(),
+		Timestamp:   time.Now(),
+	}
+}
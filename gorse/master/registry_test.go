@@ -0,0 +1,145 @@
+// Copyright 2024 gorse Project Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package master
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/juju/errors"
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/assert"
+	"github.com/zhenghaoz/gorse/base/encoding"
+	"github.com/zhenghaoz/gorse/config"
+	"github.com/zhenghaoz/gorse/model"
+	"github.com/zhenghaoz/gorse/model/ranking"
+	"github.com/zhenghaoz/gorse/storage/blob"
+)
+
+func newRegistryRankingModel() (ranking.MatrixFactorization, *ranking.DataSet) {
+	dataset := ranking.NewMapIndexDataset()
+	for i := 0; i < 10; i++ {
+		for j := 0; j < 3; j++ {
+			dataset.AddFeedback(strconv.Itoa(i), strconv.Itoa((i+j)%10), true)
+		}
+	}
+	m := ranking.NewBPR(model.Params{model.NFactors: 16, model.NEpochs: 1})
+	m.Fit(context.Background(), dataset, dataset, ranking.NewFitConfig())
+	return m, dataset
+}
+
+func TestModelRegistry(t *testing.T) {
+	store := blob.NewMasterStoreServer(t.TempDir())
+	registry, err := NewModelRegistry(store, 2)
+	assert.NoError(t, err)
+	m, dataset := newRegistryRankingModel()
+
+	// add versions
+	for version := int64(1); version <= 3; version++ {
+		err = registry.AddRankingModel(newRankingModelVersion(version, "bpr", m, ranking.Score{NDCG: 0.1}, dataset), m)
+		assert.NoError(t, err)
+	}
+	versions := registry.List(blob.RankingModelType)
+	assert.Equal(t, []string{"3", "2"}, lo.Map(versions, func(v ModelVersion, _ int) string { return v.Version }))
+	assert.Equal(t, "bpr", versions[0].Name)
+	assert.Equal(t, float32(0.1), versions[0].Scores["ndcg"])
+	assert.Equal(t, 10, versions[0].NumUsers)
+	assert.Equal(t, 30, versions[0].NumFeedback)
+	assert.Empty(t, registry.List(blob.ClickModelType))
+	_, err = registry.LoadRankingModel("1")
+	assert.Error(t, err)
+	loaded, err := registry.LoadRankingModel("2")
+	assert.NoError(t, err)
+	assert.Equal(t, m.GetUserFactor(0), loaded.GetUserFactor(0))
+
+	// pinned versions are never evicted
+	_, err = registry.Pin(blob.RankingModelType, "1")
+	assert.True(t, errors.Is(err, errors.NotFound))
+	pinned, err := registry.Pin(blob.RankingModelType, "2")
+	assert.NoError(t, err)
+	assert.True(t, pinned.Pinned)
+	err = registry.AddRankingModel(newRankingModelVersion(4, "bpr", m, ranking.Score{}, dataset), m)
+	assert.NoError(t, err)
+	err = registry.AddRankingModel(newRankingModelVersion(5, "bpr", m, ranking.Score{}, dataset), m)
+	assert.NoError(t, err)
+	versions = registry.List(blob.RankingModelType)
+	assert.Equal(t, []string{"5", "4", "2"}, lo.Map(versions, func(v ModelVersion, _ int) string { return v.Version }))
+	pinned, exist := registry.Pinned(blob.RankingModelType)
+	assert.True(t, exist)
+	assert.Equal(t, "2", pinned.Version)
+
+	// previous and next versions
+	previous, err := registry.Previous(blob.RankingModelType, "4")
+	assert.NoError(t, err)
+	assert.Equal(t, "2", previous.Version)
+	_, err = registry.Previous(blob.RankingModelType, "2")
+	assert.True(t, errors.Is(err, errors.NotFound))
+	assert.Equal(t, int64(6), registry.NextVersion(blob.RankingModelType, 2))
+	assert.Equal(t, int64(0x101), registry.NextVersion(blob.RankingModelType, 0x100))
+
+	// reopen registry
+	registry, err = NewModelRegistry(store, 2)
+	assert.NoError(t, err)
+	versions = registry.List(blob.RankingModelType)
+	assert.Equal(t, []string{"5", "4", "2"}, lo.Map(versions, func(v ModelVersion, _ int) string { return v.Version }))
+	assert.True(t, versions[2].Pinned)
+	err = registry.Unpin(blob.RankingModelType)
+	assert.NoError(t, err)
+	_, exist = registry.Pinned(blob.RankingModelType)
+	assert.False(t, exist)
+}
+
+func (s *MasterTestSuite) TestFitRankingModelWithPinnedModel() {
+	s.Config = config.GetDefaultConfig()
+	s.Config.Recommend.Collaborative.EnableShadowMode = true
+	s.rankingModelSearcher = ranking.NewModelSearcher(1, 1, false)
+	s.localCache = &LocalCache{path: s.T().TempDir()}
+	var err error
+	s.modelRegistry, err = NewModelRegistry(blob.NewMasterStoreServer(s.T().TempDir()), 10)
+	s.NoError(err)
+	m, dataset := newRegistryRankingModel()
+	s.rankingTrainSet, s.rankingTestSet = dataset, dataset
+	s.RankingModel = m
+	s.RankingModelVersion = 1
+	err = s.modelRegistry.AddRankingModel(newRankingModelVersion(1, "bpr", m, ranking.Score{}, dataset), m)
+	s.NoError(err)
+	_, err = s.pinModel(blob.RankingModelType, "1")
+	s.NoError(err)
+
+	// fitted model is a candidate while a model is pinned
+	err = NewFitRankingModelTask(&s.Master).run(context.Background(), nil)
+	s.NoError(err)
+	s.Equal(int64(1), s.RankingModelVersion)
+	s.Equal(m, s.RankingModel)
+	s.Equal(int64(2), s.shadowRankingModelVersion)
+	s.Len(s.modelRegistry.List(blob.RankingModelType), 2)
+
+	// fitted model is served once unpinned
+	err = s.unpinModel(blob.RankingModelType)
+	s.NoError(err)
+	s.Zero(s.shadowRankingModelVersion)
+	err = NewFitRankingModelTask(&s.Master).run(context.Background(), nil)
+	s.NoError(err)
+	s.Equal(int64(3), s.RankingModelVersion)
+	s.Len(s.modelRegistry.List(blob.RankingModelType), 3)
+
+	// roll back to the candidate model
+	rollback, err := s.rollbackModel(blob.RankingModelType)
+	s.NoError(err)
+	s.Equal(encoding.Hex(2), rollback.Version)
+	s.Equal(int64(2), s.RankingModelVersion)
+	s.Equal(int64(3), s.prevRankingModelVersion)
+}
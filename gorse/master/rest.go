@@ -43,10 +43,12 @@ import (
 	"github.com/zhenghaoz/gorse/base/progress"
 	"github.com/zhenghaoz/gorse/cmd/version"
 	"github.com/zhenghaoz/gorse/config"
+	"github.com/zhenghaoz/gorse/logics"
 	"github.com/zhenghaoz/gorse/model/click"
 	"github.com/zhenghaoz/gorse/model/ranking"
 	"github.com/zhenghaoz/gorse/protocol"
 	"github.com/zhenghaoz/gorse/server"
+	"github.com/zhenghaoz/gorse/storage/blob"
 	"github.com/zhenghaoz/gorse/storage/cache"
 	"github.com/zhenghaoz/gorse/storage/data"
 	"github.com/zhenghaoz/gorse/storage/meta"
@@ -82,8 +84,13 @@ func (m *Master) CreateWebService() {
 	ws.Route(ws.GET("/dashboard/cluster").To(m.getCluster).
 		Doc("Get nodes in the cluster.").
 		Metadata(restfulspec.KeyOpenAPITags, []string{"dashboard"}).
-		Returns(http.StatusOK, "OK", []meta.Node{}).
-		Writes([]meta.Node{}))
+		Returns(http.StatusOK, "OK", []ClusterNode{}).
+		Writes([]ClusterNode{}))
+	ws.Route(ws.GET("/dashboard/tenants").To(m.getTenants).
+		Doc("Get tenants in the cluster.").
+		Metadata(restfulspec.KeyOpenAPITags, []string{"dashboard"}).
+		Returns(http.StatusOK, "OK", []string{}).
+		Writes([]string{}))
 	ws.Route(ws.GET("/dashboard/categories").To(m.getCategories).
 		Doc("Get categories of items.").
 		Metadata(restfulspec.KeyOpenAPITags, []string{"dashboard"}).
@@ -112,6 +119,84 @@ func (m *Master) CreateWebService() {
 		Param(ws.HeaderParameter("X-API-Key", "secret key for RESTful API")).
 		Returns(http.StatusOK, "OK", map[string][]cache.TimeSeriesPoint{}).
 		Writes(map[string][]cache.TimeSeriesPoint{}))
+	ws.Route(ws.GET("/dashboard/time_to_sell").To(m.getTimeToSell).
+		Doc("Get mean days between listing and sale of sold items.").
+		Metadata(restfulspec.KeyOpenAPITags, []string{"dashboard"}).
+		Param(ws.HeaderParameter("X-API-Key", "secret key for RESTful API")).
+		Param(ws.QueryParameter("n", "number of days").DataType("integer")).
+		Returns(http.StatusOK, "OK", []cache.TimeSeriesPoint{}).
+		Writes([]cache.TimeSeriesPoint{}))
+	// Experiments
+	ws.Route(ws.GET("/dashboard/experiments").To(m.getExperiments).
+		Doc("Get experiments.").
+		Metadata(restfulspec.KeyOpenAPITags, []string{"dashboard"}).
+		Returns(http.StatusOK, "OK", []config.ExperimentConfig{}).
+		Writes([]config.ExperimentConfig{}))
+	ws.Route(ws.POST("/dashboard/experiments").To(m.upsertExperiment).
+		Doc("Insert or update an experiment.").
+		Metadata(restfulspec.KeyOpenAPITags, []string{"dashboard"}).
+		Reads(config.ExperimentConfig{}).
+		Returns(http.StatusOK, "OK", config.ExperimentConfig{}).
+		Writes(config.ExperimentConfig{}))
+	ws.Route(ws.DELETE("/dashboard/experiment/{name}").To(m.deleteExperiment).
+		Doc("Delete an experiment.").
+		Metadata(restfulspec.KeyOpenAPITags, []string{"dashboard"}).
+		Param(ws.PathParameter("name", "name of the experiment").DataType("string")).
+		Returns(http.StatusOK, "OK", config.ExperimentConfig{}).
+		Writes(config.ExperimentConfig{}))
+	ws.Route(ws.GET("/dashboard/experiment/{name}/rates").To(m.getExperimentRates).
+		Doc("Get positive feedback rates of variants in an experiment.").
+		Metadata(restfulspec.KeyOpenAPITags, []string{"dashboard"}).
+		Param(ws.PathParameter("name", "name of the experiment").DataType("string")).
+		Param(ws.QueryParameter("n", "number of days").DataType("integer")).
+		Returns(http.StatusOK, "OK", map[string]map[string][]ExperimentRate{}).
+		Writes(map[string]map[string][]ExperimentRate{}))
+	ws.Route(ws.GET("/dashboard/impressions/rates").To(m.getImpressionRates).
+		Doc("Get click-through rates of recommendation sources.").
+		Metadata(restfulspec.KeyOpenAPITags, []string{"dashboard"}).
+		Param(ws.QueryParameter("n", "number of days").DataType("integer")).
+		Returns(http.StatusOK, "OK", map[string]map[string][]cache.TimeSeriesPoint{}).
+		Writes(map[string]map[string][]cache.TimeSeriesPoint{}))
+	// Model registry
+	ws.Route(ws.GET("/dashboard/models/{model-type}").To(m.getModelVersions).
+		Doc("Get versions of a type of model in the model registry.").
+		Metadata(restfulspec.KeyOpenAPITags, []string{"dashboard"}).
+		Param(ws.PathParameter("model-type", "type of model (ranking or click)").DataType("string")).
+		Returns(http.StatusOK, "OK", []ModelVersion{}).
+		Writes([]ModelVersion{}))
+	ws.Route(ws.PUT("/dashboard/models/{model-type}/pin/{version}").To(m.pinModelVersion).
+		Doc("Pin a version of model. The pinned version is served until unpinned.").
+		Metadata(restfulspec.KeyOpenAPITags, []string{"dashboard"}).
+		Param(ws.PathParameter("model-type", "type of model (ranking or click)").DataType("string")).
+		Param(ws.PathParameter("version", "version of model").DataType("string")).
+		Returns(http.StatusOK, "OK", ModelVersion{}).
+		Writes(ModelVersion{}))
+	ws.Route(ws.DELETE("/dashboard/models/{model-type}/pin").To(m.unpinModelVersion).
+		Doc("Unpin a type of model. Newly fitted models are served again.").
+		Metadata(restfulspec.KeyOpenAPITags, []string{"dashboard"}).
+		Param(ws.PathParameter("model-type", "type of model (ranking or click)").DataType("string")).
+		Returns(http.StatusOK, "OK", server.Success{}).
+		Writes(server.Success{}))
+	ws.Route(ws.POST("/dashboard/models/{model-type}/rollback").To(m.rollbackModelVersion).
+		Doc("Roll back to the version of model before the served version. The version is pinned.").
+		Metadata(restfulspec.KeyOpenAPITags, []string{"dashboard"}).
+		Param(ws.PathParameter("model-type", "type of model (ranking or click)").DataType("string")).
+		Returns(http.StatusOK, "OK", ModelVersion{}).
+		Writes(ModelVersion{}))
+	ws.Route(ws.GET("/dashboard/models/click/diagnostics").To(m.getClickModelDiagnostics).
+		Doc("Get calibration curves, log loss, Brier score and per-segment AUC of the served click model.").
+		Metadata(restfulspec.KeyOpenAPITags, []string{"dashboard"}).
+		Returns(http.StatusOK, "OK", click.Diagnostics{}).
+		Writes(click.Diagnostics{}))
+	ws.Route(ws.GET("/dashboard/explain/{user-id}/{item-id}").To(m.explainClickThroughRate).
+		Doc("Explain the score of an item for a user predicted by the click model.").
+		Metadata(restfulspec.KeyOpenAPITags, []string{"dashboard"}).
+		Param(ws.PathParameter("user-id", "identifier of the user").DataType("string")).
+		Param(ws.PathParameter("item-id", "identifier of the item").DataType("string")).
+		Param(ws.QueryParameter("context", "context of the request in JSON such as {\"device\":\"mobile\"}").DataType("string")).
+		Param(ws.QueryParameter("n", "number of top contributing features of users, items and contexts").DataType("integer")).
+		Returns(http.StatusOK, "OK", click.Explanation{}).
+		Writes(click.Explanation{}))
 	// Get a user
 	ws.Route(ws.GET("/dashboard/user/{user-id}").To(m.getUser).
 		Doc("Get a user.").
@@ -136,6 +221,13 @@ func (m *Master) CreateWebService() {
 		Param(ws.QueryParameter("cursor", "cursor for next page").DataType("string")).
 		Returns(http.StatusOK, "OK", UserIterator{}).
 		Writes(UserIterator{}))
+	// Erase users
+	ws.Route(ws.POST("/dashboard/users/erase").To(m.eraseUsers).
+		Doc("Erase users and all their data from the data store and the cache. Users failed to be erased are reported.").
+		Metadata(restfulspec.KeyOpenAPITags, []string{"dashboard"}).
+		Reads([]string{}).
+		Returns(http.StatusOK, "OK", ErasureResult{}).
+		Writes(ErasureResult{}))
 	// Get non-personalized recommendation
 	ws.Route(ws.GET("/dashboard/non-personalized/{name}").To(m.getNonPersonalized).
 		Doc("Get non-personalized recommendations.").
@@ -219,6 +311,11 @@ func (m *Master) SetOneMode(workerScheduleHandler http.HandlerFunc) {
 }
 
 func (m *Master) StartHttpServer() {
+	m.RestServer.StartHttpServer(m.newContainer())
+}
+
+// newContainer creates a container serving the dashboard and the master APIs.
+func (m *Master) newContainer() *restful.Container {
 	m.CreateWebService()
 	container := restful.NewContainer()
 	container.Handle("/", http.HandlerFunc(m.dashboard))
@@ -237,7 +334,8 @@ func (m *Master) StartHttpServer() {
 		container.Handle("/api/admin/schedule/master", http.HandlerFunc(m.scheduleAPIHandler))
 		container.Handle("/api/admin/schedule/worker", m.workerScheduleHandler)
 	}
-	m.RestServer.StartHttpServer(container)
+	container.Handle("/tenant", http.HandlerFunc(m.switchTenant))
+	return container
 }
 
 var (
@@ -378,6 +476,25 @@ func (m *Master) logout(response http.ResponseWriter, request *http.Request) {
 	log.Logger().Info(fmt.Sprintf("%s %s", request.Method, request.RequestURI), zap.Int("status_code", http.StatusFound))
 }
 
+// switchTenant selects the tenant shown in the dashboard. The tenant of the cluster is selected if the name is empty.
+func (m *Master) switchTenant(response http.ResponseWriter, request *http.Request) {
+	name := request.URL.Query().Get("name")
+	cookie := &http.Cookie{
+		Name:  server.CookieTenant,
+		Value: name,
+		Path:  "/",
+	}
+	if name == "" {
+		cookie.MaxAge = -1
+	} else if !lo.Contains(m.Tenants.Tenants(), name) {
+		http.Error(response, fmt.Sprintf("tenant %s not found", name), http.StatusNotFound)
+		return
+	}
+	http.SetCookie(response, cookie)
+	http.Redirect(response, request, "/", http.StatusFound)
+	log.Logger().Info(fmt.Sprintf("%s %s", request.Method, request.RequestURI), zap.Int("status_code", http.StatusFound))
+}
+
 func (m *Master) LoginFilter(req *restful.Request, resp *restful.Response, chain *restful.FilterChain) {
 	if m.checkLogin(req.Request) {
 		req.Request.Header.Set("X-API-Key", m.Config.Server.APIKey)
@@ -454,16 +571,55 @@ func (m *Master) getCategories(request *restful.Request, response *restful.Respo
 	server.Ok(response, categories)
 }
 
+// ClusterNode is a node in the cluster. The share of users owned by a worker and the number of users whose
+// recommendations have been generated in the current round are attached to the worker.
+type ClusterNode struct {
+	*meta.Node
+	NumUsers          int
+	NumHandoffUsers   int
+	NumCompletedUsers int
+	Ready             bool
+}
+
 func (m *Master) getCluster(_ *restful.Request, response *restful.Response) {
 	nodes, err := m.metaStore.ListNodes()
 	if err != nil {
 		server.InternalServerError(response, err)
 		return
 	}
+	shards, err := m.metaStore.ListShards()
+	if err != nil {
+		server.InternalServerError(response, err)
+		return
+	}
+	shardMap := lo.SliceToMap(shards, func(shard *meta.Shard) (string, *meta.Shard) {
+		return shard.UUID, shard
+	})
 	sort.Slice(nodes, func(i, j int) bool {
 		return nodes[i].Type < nodes[j].Type
 	})
-	server.Ok(response, nodes)
+	clusterNodes := make([]ClusterNode, 0, len(nodes))
+	for _, node := range nodes {
+		clusterNode := ClusterNode{Node: node}
+		if shard, ok := shardMap[node.UUID]; ok {
+			clusterNode.NumUsers = shard.NumUsers
+			clusterNode.NumHandoffUsers = shard.NumHandoffUsers
+			clusterNode.Ready = shard.Ready
+		}
+		if value, ok := m.remoteProgress.Load(node.UUID); ok {
+			for _, p := range value.([]progress.Progress) {
+				if p.Name == "Recommend" {
+					clusterNode.NumCompletedUsers = p.Count
+				}
+			}
+		}
+		clusterNodes = append(clusterNodes, clusterNode)
+	}
+	server.Ok(response, clusterNodes)
+}
+
+func (m *Master) getTenants(_ *restful.Request, response *restful.Response) {
+	server.Ok(response, m.Tenants.Tenants())
 }
 
 func formatConfig(configMap map[string]interface{}) map[string]interface{} {
@@ -488,7 +644,9 @@ func formatConfig(configMap map[string]interface{}) map[string]interface{} {
 
 func (m *Master) getConfig(_ *restful.Request, response *restful.Response) {
 	var configMap map[string]interface{}
+	m.experimentsMutex.RLock()
 	err := mapstructure.Decode(m.Config, &configMap)
+	m.experimentsMutex.RUnlock()
 	if err != nil {
 		server.InternalServerError(response, err)
 		return
@@ -664,6 +822,368 @@ func (m *Master) getRates(request *restful.Request, response *restful.Response)
 	server.Ok(response, measurements)
 }
 
+func (m *Master) getTimeToSell(request *restful.Request, response *restful.Response) {
+	ctx := context.Background()
+	if request != nil && request.Request != nil {
+		ctx = request.Request.Context()
+	}
+	n, err := server.ParseInt(request, "n", 100)
+	if err != nil {
+		server.BadRequest(response, err)
+		return
+	}
+	points, err := m.CacheClient.GetTimeSeriesPoints(ctx, TimeToSell, time.Now().Add(-24*time.Hour*time.Duration(n)), time.Now())
+	if err != nil {
+		server.InternalServerError(response, err)
+		return
+	}
+	server.Ok(response, points)
+}
+
+// ExperimentRate is the positive feedback rate of a variant and its 95% confidence interval.
+type ExperimentRate struct {
+	Timestamp time.Time
+	Value     float64
+	Lower     float64
+	Upper     float64
+}
+
+// experiments returns experiments in effect. The returned slice should not be modified.
+func (m *Master) experiments() []config.ExperimentConfig {
+	m.experimentsMutex.RLock()
+	defer m.experimentsMutex.RUnlock()
+	return m.Config.Experiments
+}
+
+// loadExperiments merges experiments managed by the master API into experiments in the config file. Experiments
+// saved by the API take precedence over experiments with the same name in the config file.
+func (m *Master) loadExperiments(ctx context.Context) error {
+	value, err := m.CacheClient.Get(ctx, cache.Key(cache.GlobalMeta, cache.Experiments)).String()
+	if err != nil {
+		if errors.Is(err, errors.NotFound) {
+			return nil
+		}
+		return errors.Trace(err)
+	}
+	var saved []config.ExperimentConfig
+	if err = json.Unmarshal([]byte(value), &saved); err != nil {
+		return errors.Trace(err)
+	}
+	m.experimentsMutex.Lock()
+	defer m.experimentsMutex.Unlock()
+	experiments := append([]config.ExperimentConfig{}, m.Config.Experiments...)
+	for _, experiment := range saved {
+		experiments = upsertExperiment(experiments, experiment)
+	}
+	if err = m.Config.ValidateExperiments(experiments); err != nil {
+		return errors.Trace(err)
+	}
+	m.Config.Experiments = experiments
+	m.Config.ResetExperimentConfigs()
+	return nil
+}
+
+// updateExperiments validates, saves and applies experiments. The caller must hold experimentsMutex.
+func (m *Master) updateExperiments(ctx context.Context, experiments []config.ExperimentConfig) error {
+	bytes, err := json.Marshal(experiments)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if err = m.CacheClient.Set(ctx, cache.String(cache.Key(cache.GlobalMeta, cache.Experiments), string(bytes))); err != nil {
+		return errors.Trace(err)
+	}
+	m.Config.Experiments = experiments
+	m.Config.ResetExperimentConfigs()
+	return nil
+}
+
+// upsertExperiment replaces the experiment with the same name or appends the experiment.
+func upsertExperiment(experiments []config.ExperimentConfig, experiment config.ExperimentConfig) []config.ExperimentConfig {
+	for i := range experiments {
+		if experiments[i].Name == experiment.Name {
+			experiments[i] = experiment
+			return experiments
+		}
+	}
+	return append(experiments, experiment)
+}
+
+func (m *Master) getExperiments(_ *restful.Request, response *restful.Response) {
+	experiments := m.experiments()
+	if experiments == nil {
+		experiments = []config.ExperimentConfig{}
+	}
+	server.Ok(response, experiments)
+}
+
+func (m *Master) upsertExperiment(request *restful.Request, response *restful.Response) {
+	ctx := context.Background()
+	if request != nil && request.Request != nil {
+		ctx = request.Request.Context()
+	}
+	var experiment config.ExperimentConfig
+	if err := request.ReadEntity(&experiment); err != nil {
+		server.BadRequest(response, err)
+		return
+	}
+	m.experimentsMutex.Lock()
+	defer m.experimentsMutex.Unlock()
+	experiments := upsertExperiment(append([]config.ExperimentConfig{}, m.Config.Experiments...), experiment)
+	if err := m.Config.ValidateExperiments(experiments); err != nil {
+		server.BadRequest(response, err)
+		return
+	}
+	if err := m.updateExperiments(ctx, experiments); err != nil {
+		server.InternalServerError(response, err)
+		return
+	}
+	server.Ok(response, experiment)
+}
+
+func (m *Master) deleteExperiment(request *restful.Request, response *restful.Response) {
+	ctx := context.Background()
+	if request != nil && request.Request != nil {
+		ctx = request.Request.Context()
+	}
+	name := request.PathParameter("name")
+	m.experimentsMutex.Lock()
+	defer m.experimentsMutex.Unlock()
+	experiment, index, found := lo.FindIndexOf(m.Config.Experiments, func(experiment config.ExperimentConfig) bool {
+		return experiment.Name == name
+	})
+	if !found {
+		server.PageNotFound(response, errors.NotFoundf("experiment %s", name))
+		return
+	}
+	experiments := append(append([]config.ExperimentConfig{}, m.Config.Experiments[:index]...), m.Config.Experiments[index+1:]...)
+	if err := m.updateExperiments(ctx, experiments); err != nil {
+		server.InternalServerError(response, err)
+		return
+	}
+	server.Ok(response, experiment)
+}
+
+func (m *Master) getExperimentRates(request *restful.Request, response *restful.Response) {
+	ctx := context.Background()
+	if request != nil && request.Request != nil {
+		ctx = request.Request.Context()
+	}
+	name := request.PathParameter("name")
+	n, err := server.ParseInt(request, "n", 100)
+	if err != nil {
+		server.BadRequest(response, err)
+		return
+	}
+	experiment, found := lo.Find(m.experiments(), func(experiment config.ExperimentConfig) bool {
+		return experiment.Name == name
+	})
+	if !found {
+		server.PageNotFound(response, errors.NotFoundf("experiment %s", name))
+		return
+	}
+	begin, end := time.Now().Add(-24*time.Hour*time.Duration(n)), time.Now()
+	rates := make(map[string]map[string][]ExperimentRate, len(experiment.Variants))
+	for _, variant := range experiment.Variants {
+		rates[variant.Name] = make(map[string][]ExperimentRate, len(m.Config.Recommend.DataSource.PositiveFeedbackTypes))
+		for _, feedbackType := range m.Config.Recommend.DataSource.PositiveFeedbackTypes {
+			values, err := m.CacheClient.GetTimeSeriesPoints(ctx, cache.Key(ExperimentPositiveFeedbackRate, name, variant.Name, feedbackType), begin, end)
+			if err != nil {
+				server.InternalServerError(response, err)
+				return
+			}
+			lowers, err := m.CacheClient.GetTimeSeriesPoints(ctx, cache.Key(ExperimentPositiveFeedbackRateLower, name, variant.Name, feedbackType), begin, end)
+			if err != nil {
+				server.InternalServerError(response, err)
+				return
+			}
+			uppers, err := m.CacheClient.GetTimeSeriesPoints(ctx, cache.Key(ExperimentPositiveFeedbackRateUpper, name, variant.Name, feedbackType), begin, end)
+			if err != nil {
+				server.InternalServerError(response, err)
+				return
+			}
+			lowerIndex := lo.SliceToMap(lowers, func(point cache.TimeSeriesPoint) (int64, float64) {
+				return point.Timestamp.Unix(), point.Value
+			})
+			upperIndex := lo.SliceToMap(uppers, func(point cache.TimeSeriesPoint) (int64, float64) {
+				return point.Timestamp.Unix(), point.Value
+			})
+			rates[variant.Name][feedbackType] = make([]ExperimentRate, 0, len(values))
+			for _, value := range values {
+				rates[variant.Name][feedbackType] = append(rates[variant.Name][feedbackType], ExperimentRate{
+					Timestamp: value.Timestamp,
+					Value:     value.Value,
+					Lower:     lowerIndex[value.Timestamp.Unix()],
+					Upper:     upperIndex[value.Timestamp.Unix()],
+				})
+			}
+		}
+	}
+	server.Ok(response, rates)
+}
+
+func (m *Master) getModelVersions(request *restful.Request, response *restful.Response) {
+	if m.modelRegistry == nil {
+		server.InternalServerError(response, errors.New("model registry isn't opened"))
+		return
+	}
+	modelType := request.PathParameter("model-type")
+	if modelType != blob.RankingModelType && modelType != blob.ClickModelType {
+		server.BadRequest(response, errors.NotValidf("model type %s", modelType))
+		return
+	}
+	server.Ok(response, m.modelRegistry.List(modelType))
+}
+
+func (m *Master) pinModelVersion(request *restful.Request, response *restful.Response) {
+	if m.modelRegistry == nil {
+		server.InternalServerError(response, errors.New("model registry isn't opened"))
+		return
+	}
+	version, err := m.pinModel(request.PathParameter("model-type"), request.PathParameter("version"))
+	if err != nil {
+		writeModelRegistryError(response, err)
+		return
+	}
+	server.Ok(response, version)
+}
+
+func (m *Master) unpinModelVersion(request *restful.Request, response *restful.Response) {
+	if m.modelRegistry == nil {
+		server.InternalServerError(response, errors.New("model registry isn't opened"))
+		return
+	}
+	if err := m.unpinModel(request.PathParameter("model-type")); err != nil {
+		writeModelRegistryError(response, err)
+		return
+	}
+	server.Ok(response, server.Success{RowAffected: 1})
+}
+
+func (m *Master) rollbackModelVersion(request *restful.Request, response *restful.Response) {
+	if m.modelRegistry == nil {
+		server.InternalServerError(response, errors.New("model registry isn't opened"))
+		return
+	}
+	version, err := m.rollbackModel(request.PathParameter("model-type"))
+	if err != nil {
+		writeModelRegistryError(response, err)
+		return
+	}
+	server.Ok(response, version)
+}
+
+// getClickModelDiagnostics returns diagnostics of the served click model. Diagnostics are computed on the validation
+// set once the click model is fitted.
+func (m *Master) getClickModelDiagnostics(_ *restful.Request, response *restful.Response) {
+	m.clickModelMutex.RLock()
+	diagnostics := m.clickDiagnostics
+	m.clickModelMutex.RUnlock()
+	if diagnostics == nil {
+		server.PageNotFound(response, errors.NotFoundf("diagnostics of click model"))
+		return
+	}
+	server.Ok(response, diagnostics)
+}
+
+// explainClickThroughRate decomposes the score of an item for a user predicted by the served click model into
+// contributions of user, item and context features.
+func (m *Master) explainClickThroughRate(request *restful.Request, response *restful.Response) {
+	ctx := context.Background()
+	if request != nil && request.Request != nil {
+		ctx = request.Request.Context()
+	}
+	// parse parameters
+	n, err := server.ParseInt(request, "n", 10)
+	if err != nil {
+		server.BadRequest(response, err)
+		return
+	}
+	requestContext, err := server.ParseContext(request, "context")
+	if err != nil {
+		server.BadRequest(response, err)
+		return
+	}
+	contextFeatures := click.ConvertContextToFeatures(requestContext)
+	// get user and item
+	user, err := m.DataClient.GetUser(ctx, request.PathParameter("user-id"))
+	if err != nil {
+		if errors.Is(err, errors.NotFound) {
+			server.PageNotFound(response, err)
+		} else {
+			server.InternalServerError(response, err)
+		}
+		return
+	}
+	item, err := m.DataClient.GetItem(ctx, request.PathParameter("item-id"))
+	if err != nil {
+		if errors.Is(err, errors.NotFound) {
+			server.PageNotFound(response, err)
+		} else {
+			server.InternalServerError(response, err)
+		}
+		return
+	}
+	// explain prediction
+	m.clickModelMutex.RLock()
+	clickModel := m.ClickModel
+	m.clickModelMutex.RUnlock()
+	if clickModel == nil || clickModel.Invalid() {
+		server.PageNotFound(response, errors.NotFoundf("click model"))
+		return
+	}
+	explainer, ok := clickModel.(click.Explainer)
+	if !ok {
+		server.BadRequest(response, errors.NotSupportedf("explaining %T", clickModel))
+		return
+	}
+	explanation := explainer.Explain(user.UserId, item.ItemId, click.ConvertLabelsToFeatures(user.Labels),
+		click.ConvertLabelsToFeatures(item.Labels), contextFeatures)
+	explanation.Truncate(n)
+	server.Ok(response, explanation)
+}
+
+func writeModelRegistryError(response *restful.Response, err error) {
+	if errors.Is(err, errors.NotFound) {
+		server.PageNotFound(response, err)
+	} else if errors.Is(err, errors.NotValid) {
+		server.BadRequest(response, err)
+	} else {
+		server.InternalServerError(response, err)
+	}
+}
+
+// getImpressionRates returns click-through rates of impressions indexed by source and positive feedback type. Sources
+// without impressions are omitted.
+func (m *Master) getImpressionRates(request *restful.Request, response *restful.Response) {
+	ctx := context.Background()
+	if request != nil && request.Request != nil {
+		ctx = request.Request.Context()
+	}
+	n, err := server.ParseInt(request, "n", 100)
+	if err != nil {
+		server.BadRequest(response, err)
+		return
+	}
+	begin, end := time.Now().Add(-24*time.Hour*time.Duration(n)), time.Now()
+	rates := make(map[string]map[string][]cache.TimeSeriesPoint)
+	for _, source := range server.ImpressionSources {
+		for _, feedbackType := range m.Config.Recommend.DataSource.PositiveFeedbackTypes {
+			points, err := m.CacheClient.GetTimeSeriesPoints(ctx, cache.Key(ImpressionClickThroughRate, source, feedbackType), begin, end)
+			if err != nil {
+				server.InternalServerError(response, err)
+				return
+			}
+			if len(points) > 0 {
+				if rates[source] == nil {
+					rates[source] = make(map[string][]cache.TimeSeriesPoint)
+				}
+				rates[source][feedbackType] = points
+			}
+		}
+	}
+	server.Ok(response, rates)
+}
+
 type UserIterator struct {
 	Cursor string
 	Users  []User
@@ -737,6 +1257,58 @@ func (m *Master) getUsers(request *restful.Request, response *restful.Response)
 	server.Ok(response, UserIterator{Cursor: cursor, Users: details})
 }
 
+// ErasureResult is the result of erasing users. Erasing a user doesn't stop at failures of other users, and users
+// failed to be erased could be erased again.
+type ErasureResult struct {
+	Erased []logics.ErasureReport
+	Failed []ErasureFailure
+}
+
+// ErasureFailure is the error of erasing a user.
+type ErasureFailure struct {
+	UserId string
+	Error  string
+}
+
+func (m *Master) eraseUsers(request *restful.Request, response *restful.Response) {
+	ctx := context.Background()
+	if request != nil && request.Request != nil {
+		ctx = request.Request.Context()
+	}
+	var userIds []string
+	if err := request.ReadEntity(&userIds); err != nil {
+		server.BadRequest(response, err)
+		return
+	}
+	if len(userIds) == 0 {
+		server.BadRequest(response, errors.New("no users to erase"))
+		return
+	}
+	result := ErasureResult{
+		Erased: make([]logics.ErasureReport, 0, len(userIds)),
+		Failed: make([]ErasureFailure, 0),
+	}
+	for _, userId := range userIds {
+		eventCtx, err := logics.WithEvents(ctx, m.Config, data.EventUserDeleted, []string{userId},
+			func(id string) string { return id })
+		if err != nil {
+			server.InternalServerError(response, err)
+			return
+		}
+		report, err := logics.EraseUser(eventCtx, m.DataClient, m.CacheClient, userId,
+			m.Config.Recommend.Collaborative.ModelFitPeriod)
+		if err != nil {
+			log.Logger().Error("failed to erase user", zap.String("user_id", userId), zap.Error(err))
+			result.Failed = append(result.Failed, ErasureFailure{UserId: userId, Error: err.Error()})
+			continue
+		}
+		log.Logger().Info("erase user", zap.String("user_id", userId), zap.Int("impressions", report.Impressions),
+			zap.Time("models_retained_until", report.RetainedUntil))
+		result.Erased = append(result.Erased, *report)
+	}
+	server.Ok(response, result)
+}
+
 func (m *Master) getRecommend(request *restful.Request, response *restful.Response) {
 	ctx := context.Background()
 	if request != nil && request.Request != nil {
@@ -967,7 +1539,12 @@ func (m *Master) importExportUsers(response http.ResponseWriter, request *http.R
 			users = append(users, user)
 			// batch insert
 			if len(users) == batchSize {
-				err = m.DataClient.BatchInsertUsers(ctx, users)
+				eventCtx, err := logics.WithEvents(ctx, m.Config, data.EventUserUpserted, users, func(user data.User) string { return user.UserId })
+				if err != nil {
+					server.InternalServerError(restful.NewResponse(response), err)
+					return
+				}
+				err = m.DataClient.BatchInsertUsers(eventCtx, users)
 				if err != nil {
 					server.InternalServerError(restful.NewResponse(response), err)
 					return
@@ -977,7 +1554,12 @@ func (m *Master) importExportUsers(response http.ResponseWriter, request *http.R
 			lineCount++
 		}
 		if len(users) > 0 {
-			err = m.DataClient.BatchInsertUsers(ctx, users)
+			eventCtx, err := logics.WithEvents(ctx, m.Config, data.EventUserUpserted, users, func(user data.User) string { return user.UserId })
+			if err != nil {
+				server.InternalServerError(restful.NewResponse(response), err)
+				return
+			}
+			err = m.DataClient.BatchInsertUsers(eventCtx, users)
 			if err != nil {
 				server.InternalServerError(restful.NewResponse(response), err)
 				return
@@ -1084,7 +1666,12 @@ func (m *Master) importExportItems(response http.ResponseWriter, request *http.R
 			})
 			// batch insert
 			if len(items) == batchSize {
-				err = m.DataClient.BatchInsertItems(ctx, items)
+				eventCtx, err := logics.WithEvents(ctx, m.Config, data.EventItemUpserted, items, func(item data.Item) string { return item.ItemId })
+				if err != nil {
+					server.InternalServerError(restful.NewResponse(response), err)
+					return
+				}
+				err = m.DataClient.BatchInsertItems(eventCtx, items)
 				if err != nil {
 					server.InternalServerError(restful.NewResponse(response), err)
 					return
@@ -1094,7 +1681,12 @@ func (m *Master) importExportItems(response http.ResponseWriter, request *http.R
 			lineCount++
 		}
 		if len(items) > 0 {
-			err = m.DataClient.BatchInsertItems(ctx, items)
+			eventCtx, err := logics.WithEvents(ctx, m.Config, data.EventItemUpserted, items, func(item data.Item) string { return item.ItemId })
+			if err != nil {
+				server.InternalServerError(restful.NewResponse(response), err)
+				return
+			}
+			err = m.DataClient.BatchInsertItems(eventCtx, items)
 			if err != nil {
 				server.InternalServerError(restful.NewResponse(response), err)
 				return
@@ -1194,11 +1786,18 @@ func (m *Master) importExportFeedback(response http.ResponseWriter, request *htt
 				FeedbackKey: feedback.FeedbackKey,
 				Timestamp:   timestamp,
 				Comment:     feedback.Comment,
+				Value:       feedback.Value,
 			})
 			// batch insert
 			if len(feedbacks) == batchSize {
 				// batch insert to data store
-				err = m.DataClient.BatchInsertFeedback(ctx, feedbacks,
+				eventCtx, err := logics.WithEvents(ctx, m.Config, data.EventFeedbackInserted, feedbacks,
+					func(feedback data.Feedback) string { return feedback.UserId })
+				if err != nil {
+					server.InternalServerError(restful.NewResponse(response), err)
+					return
+				}
+				err = m.DataClient.BatchInsertFeedback(eventCtx, feedbacks,
 					m.Config.Server.AutoInsertUser,
 					m.Config.Server.AutoInsertItem, true)
 				if err != nil {
@@ -1212,7 +1811,13 @@ func (m *Master) importExportFeedback(response http.ResponseWriter, request *htt
 		// insert to cache store
 		if len(feedbacks) > 0 {
 			// insert to data store
-			err = m.DataClient.BatchInsertFeedback(ctx, feedbacks,
+			eventCtx, err := logics.WithEvents(ctx, m.Config, data.EventFeedbackInserted, feedbacks,
+				func(feedback data.Feedback) string { return feedback.UserId })
+			if err != nil {
+				server.InternalServerError(restful.NewResponse(response), err)
+				return
+			}
+			err = m.DataClient.BatchInsertFeedback(eventCtx, feedbacks,
 				m.Config.Server.AutoInsertUser,
 				m.Config.Server.AutoInsertItem, true)
 			if err != nil {
@@ -1452,6 +2057,7 @@ func (m *Master) dump(response http.ResponseWriter, request *http.Request) {
 				ItemId:       feedback.ItemId,
 				Timestamp:    timestamppb.New(feedback.Timestamp),
 				Comment:      feedback.Comment,
+				Value:        feedback.Value,
 			}); err != nil {
 				writeError(response, http.StatusInternalServerError, err.Error())
 				return
@@ -1597,6 +2203,7 @@ func (m *Master) restore(response http.ResponseWriter, request *http.Request) {
 					},
 					Timestamp: feedback.Timestamp.AsTime(),
 					Comment:   feedback.Comment,
+					Value:     feedback.Value,
 				})
 				stats.Feedback++
 				if len(feedbacks) == batchSize {
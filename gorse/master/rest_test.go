@@ -33,11 +33,15 @@ import (
 	"github.com/samber/lo"
 	"github.com/steinfletcher/apitest"
 	"github.com/stretchr/testify/assert"
+	"github.com/zhenghaoz/gorse/base/progress"
 	"github.com/zhenghaoz/gorse/config"
+	"github.com/zhenghaoz/gorse/logics"
+	"github.com/zhenghaoz/gorse/model"
 	"github.com/zhenghaoz/gorse/model/click"
 	"github.com/zhenghaoz/gorse/model/ranking"
 	"github.com/zhenghaoz/gorse/protocol"
 	"github.com/zhenghaoz/gorse/server"
+	"github.com/zhenghaoz/gorse/storage/blob"
 	"github.com/zhenghaoz/gorse/storage/cache"
 	"github.com/zhenghaoz/gorse/storage/data"
 	"github.com/zhenghaoz/gorse/storage/meta"
@@ -354,6 +358,16 @@ func TestMaster_GetCluster(t *testing.T) {
 	assert.NoError(t, err)
 	err = s.metaStore.UpdateNode(workerNode)
 	assert.NoError(t, err)
+	// add shard and progress of the worker
+	err = s.metaStore.UpdateShard(&meta.Shard{
+		UUID:            workerNode.UUID,
+		NumUsers:        100,
+		NumHandoffUsers: 10,
+		Ready:           true,
+		UpdateTime:      time.Now().UTC(),
+	})
+	assert.NoError(t, err)
+	s.remoteProgress.Store(workerNode.UUID, []progress.Progress{{Tracer: workerNode.UUID, Name: "Recommend", Count: 60, Total: 110}})
 	// get nodes
 	apitest.New().
 		Handler(s.handler).
@@ -361,7 +375,10 @@ func TestMaster_GetCluster(t *testing.T) {
 		Header("Cookie", cookie).
 		Expect(t).
 		Status(http.StatusOK).
-		Body(marshal(t, []*meta.Node{serverNode, workerNode})).
+		Body(marshal(t, []ClusterNode{
+			{Node: serverNode},
+			{Node: workerNode, NumUsers: 100, NumHandoffUsers: 10, NumCompletedUsers: 60, Ready: true},
+		})).
 		End()
 }
 
@@ -442,6 +459,332 @@ func TestMaster_GetRates(t *testing.T) {
 		End()
 }
 
+func TestMaster_GetTimeToSell(t *testing.T) {
+	s, cookie := newMockServer(t)
+	defer s.Close(t)
+	ctx := context.Background()
+	baseTimestamp := time.Now()
+	err := s.CacheClient.AddTimeSeriesPoints(ctx, []cache.TimeSeriesPoint{
+		{Name: TimeToSell, Value: 4.0, Timestamp: baseTimestamp.Add(-200 * 24 * time.Hour)},
+		{Name: TimeToSell, Value: 2.0, Timestamp: baseTimestamp.Add(-1 * 24 * time.Hour)},
+		{Name: TimeToSell, Value: 3.0, Timestamp: baseTimestamp.Add(-0 * 24 * time.Hour)},
+	})
+	assert.NoError(t, err)
+	apitest.New().
+		Handler(s.handler).
+		Get("/api/dashboard/time_to_sell").
+		Header("Cookie", cookie).
+		Expect(t).
+		Status(http.StatusOK).
+		Body(marshal(t, []cache.TimeSeriesPoint{
+			{Name: TimeToSell, Value: 2.0, Timestamp: baseTimestamp.Add(-1 * 24 * time.Hour)},
+			{Name: TimeToSell, Value: 3.0, Timestamp: baseTimestamp.Add(-0 * 24 * time.Hour)},
+		})).
+		End()
+}
+
+func TestMaster_Experiments(t *testing.T) {
+	s, cookie := newMockServer(t)
+	defer s.Close(t)
+	ctx := context.Background()
+	experiment := config.ExperimentConfig{
+		Name:    "image_weight",
+		Traffic: 0.5,
+		Variants: []config.VariantConfig{
+			{Name: "control", Weight: 1},
+			{Name: "treatment", Weight: 1, Override: map[string]any{
+				"image_embeddings": map[string]any{"image_weight": 0.8},
+			}},
+		},
+	}
+	// insert experiment
+	apitest.New().
+		Handler(s.handler).
+		Post("/api/dashboard/experiments").
+		Header("Cookie", cookie).
+		JSON(experiment).
+		Expect(t).
+		Status(http.StatusOK).
+		Body(marshal(t, experiment)).
+		End()
+	// insert invalid experiment
+	apitest.New().
+		Handler(s.handler).
+		Post("/api/dashboard/experiments").
+		Header("Cookie", cookie).
+		JSON(config.ExperimentConfig{Name: "invalid", Traffic: 2}).
+		Expect(t).
+		Status(http.StatusBadRequest).
+		End()
+	// get experiments
+	apitest.New().
+		Handler(s.handler).
+		Get("/api/dashboard/experiments").
+		Header("Cookie", cookie).
+		Expect(t).
+		Status(http.StatusOK).
+		Body(marshal(t, []config.ExperimentConfig{experiment})).
+		End()
+	// experiments are persisted
+	s.Config.Experiments = nil
+	assert.NoError(t, s.loadExperiments(ctx))
+	assert.Equal(t, []string{"image_weight"}, lo.Map(s.Config.Experiments, func(e config.ExperimentConfig, _ int) string {
+		return e.Name
+	}))
+
+	// get rates
+	s.Config.Recommend.DataSource.PositiveFeedbackTypes = []string{"a"}
+	timestamp := time.Now().Add(-24 * time.Hour).Truncate(time.Second)
+	err := s.CacheClient.AddTimeSeriesPoints(ctx, []cache.TimeSeriesPoint{
+		{Name: cache.Key(ExperimentPositiveFeedbackRate, "image_weight", "control", "a"), Value: 0.2, Timestamp: timestamp},
+		{Name: cache.Key(ExperimentPositiveFeedbackRateLower, "image_weight", "control", "a"), Value: 0.1, Timestamp: timestamp},
+		{Name: cache.Key(ExperimentPositiveFeedbackRateUpper, "image_weight", "control", "a"), Value: 0.3, Timestamp: timestamp},
+	})
+	assert.NoError(t, err)
+	apitest.New().
+		Handler(s.handler).
+		Get("/api/dashboard/experiment/image_weight/rates").
+		Header("Cookie", cookie).
+		Expect(t).
+		Status(http.StatusOK).
+		Body(marshal(t, map[string]map[string][]ExperimentRate{
+			"control":   {"a": {{Timestamp: timestamp, Value: 0.2, Lower: 0.1, Upper: 0.3}}},
+			"treatment": {"a": {}},
+		})).
+		End()
+
+	// delete experiment
+	apitest.New().
+		Handler(s.handler).
+		Delete("/api/dashboard/experiment/image_weight").
+		Header("Cookie", cookie).
+		Expect(t).
+		Status(http.StatusOK).
+		End()
+	apitest.New().
+		Handler(s.handler).
+		Delete("/api/dashboard/experiment/image_weight").
+		Header("Cookie", cookie).
+		Expect(t).
+		Status(http.StatusNotFound).
+		End()
+	apitest.New().
+		Handler(s.handler).
+		Get("/api/dashboard/experiments").
+		Header("Cookie", cookie).
+		Expect(t).
+		Status(http.StatusOK).
+		Body(marshal(t, []config.ExperimentConfig{})).
+		End()
+}
+
+func TestMaster_ModelRegistry(t *testing.T) {
+	s, cookie := newMockServer(t)
+	defer s.Close(t)
+	var err error
+	s.modelRegistry, err = NewModelRegistry(blob.NewMasterStoreServer(t.TempDir()), 10)
+	assert.NoError(t, err)
+	m, dataset := newRegistryRankingModel()
+	for version := int64(1); version <= 2; version++ {
+		err = s.modelRegistry.AddRankingModel(newRankingModelVersion(version, "bpr", m, ranking.Score{}, dataset), m)
+		assert.NoError(t, err)
+	}
+	s.RankingModel = m
+	s.RankingModelVersion = 2
+
+	// list versions
+	apitest.New().
+		Handler(s.handler).
+		Get("/api/dashboard/models/ranking").
+		Header("Cookie", cookie).
+		Expect(t).
+		Status(http.StatusOK).
+		Body(marshal(t, s.modelRegistry.List(blob.RankingModelType))).
+		End()
+	apitest.New().
+		Handler(s.handler).
+		Get("/api/dashboard/models/unknown").
+		Header("Cookie", cookie).
+		Expect(t).
+		Status(http.StatusBadRequest).
+		End()
+	// roll back
+	previous, exist := s.modelRegistry.Get(blob.RankingModelType, "1")
+	assert.True(t, exist)
+	previous.Pinned = true
+	apitest.New().
+		Handler(s.handler).
+		Post("/api/dashboard/models/ranking/rollback").
+		Header("Cookie", cookie).
+		ContentType(restful.MIME_JSON).
+		Expect(t).
+		Status(http.StatusOK).
+		Body(marshal(t, previous)).
+		End()
+	assert.Equal(t, int64(1), s.RankingModelVersion)
+	apitest.New().
+		Handler(s.handler).
+		Post("/api/dashboard/models/ranking/rollback").
+		Header("Cookie", cookie).
+		ContentType(restful.MIME_JSON).
+		Expect(t).
+		Status(http.StatusNotFound).
+		End()
+	// pin
+	latest, exist := s.modelRegistry.Get(blob.RankingModelType, "2")
+	assert.True(t, exist)
+	latest.Pinned = true
+	apitest.New().
+		Handler(s.handler).
+		Put("/api/dashboard/models/ranking/pin/2").
+		Header("Cookie", cookie).
+		ContentType(restful.MIME_JSON).
+		Expect(t).
+		Status(http.StatusOK).
+		Body(marshal(t, latest)).
+		End()
+	assert.Equal(t, int64(2), s.RankingModelVersion)
+	apitest.New().
+		Handler(s.handler).
+		Put("/api/dashboard/models/ranking/pin/3").
+		Header("Cookie", cookie).
+		ContentType(restful.MIME_JSON).
+		Expect(t).
+		Status(http.StatusNotFound).
+		End()
+	// unpin
+	apitest.New().
+		Handler(s.handler).
+		Delete("/api/dashboard/models/ranking/pin").
+		Header("Cookie", cookie).
+		Expect(t).
+		Status(http.StatusOK).
+		End()
+	_, pinned := s.modelRegistry.Pinned(blob.RankingModelType)
+	assert.False(t, pinned)
+}
+
+func TestMaster_GetClickModelDiagnostics(t *testing.T) {
+	s, cookie := newMockServer(t)
+	defer s.Close(t)
+	apitest.New().
+		Handler(s.handler).
+		Get("/api/dashboard/models/click/diagnostics").
+		Header("Cookie", cookie).
+		Expect(t).
+		Status(http.StatusNotFound).
+		End()
+	s.clickDiagnostics = &click.Diagnostics{
+		Method:               click.PlattCalibration,
+		NumSamples:           100,
+		AUC:                  0.8,
+		LogLoss:              0.6,
+		BrierScore:           0.2,
+		CalibratedLogLoss:    0.5,
+		CalibratedBrierScore: 0.15,
+		CalibrationCurve:     []click.CalibrationBin{{MeanPrediction: 0.3, FractionPositive: 0.25, Count: 100}},
+		CalibratedCurve:      []click.CalibrationBin{{MeanPrediction: 0.25, FractionPositive: 0.25, Count: 100}},
+		SegmentAUC:           []click.SegmentAUC{{Segment: "a", AUC: 0.8, Count: 100}},
+	}
+	apitest.New().
+		Handler(s.handler).
+		Get("/api/dashboard/models/click/diagnostics").
+		Header("Cookie", cookie).
+		Expect(t).
+		Status(http.StatusOK).
+		Body(marshal(t, s.clickDiagnostics)).
+		End()
+}
+
+func TestMaster_ExplainClickThroughRate(t *testing.T) {
+	s, cookie := newMockServer(t)
+	defer s.Close(t)
+	ctx := context.Background()
+	err := s.DataClient.BatchInsertUsers(ctx, []data.User{{UserId: "0", Labels: map[string]any{"gender": "female"}}})
+	assert.NoError(t, err)
+	err = s.DataClient.BatchInsertItems(ctx, []data.Item{{ItemId: "0", Labels: []any{"a", "b"}}})
+	assert.NoError(t, err)
+
+	// click model isn't fitted
+	s.ClickModel = nil
+	apitest.New().
+		Handler(s.handler).
+		Get("/api/dashboard/explain/0/0").
+		Header("Cookie", cookie).
+		Expect(t).
+		Status(http.StatusNotFound).
+		End()
+
+	// create factorization machine
+	builder := click.NewUnifiedMapIndexBuilder()
+	builder.AddUser("0")
+	builder.AddItem("0")
+	builder.AddUserLabel("gender.female")
+	builder.AddItemLabel("a")
+	builder.AddItemLabel("b")
+	builder.AddCtxLabel("device.mobile")
+	fm := click.NewFM(click.FMClassification, nil)
+	fm.Index = builder.Build()
+	fm.B = 0.1
+	fm.W = []float32{0.1, 0.2, 0.3, 0.4, 0.5, 0.6}
+	fm.V = [][]float32{{1, 0}, {0, 1}, {1, 1}, {1, -1}, {0, 2}, {2, 0}}
+	s.ClickModel = fm
+	expected := fm.Explain("0", "0", []click.Feature{{Name: "gender.female", Value: 1}},
+		[]click.Feature{{Name: "a", Value: 1}, {Name: "b", Value: 1}}, []click.Feature{{Name: "device.mobile", Value: 1}})
+	expected.Truncate(2)
+	apitest.New().
+		Handler(s.handler).
+		Get("/api/dashboard/explain/0/0").
+		Header("Cookie", cookie).
+		QueryParams(map[string]string{"n": "2", "context": `{"device":"mobile"}`}).
+		Expect(t).
+		Status(http.StatusOK).
+		Body(marshal(t, expected)).
+		End()
+
+	// explain DeepFM
+	dataset := &click.Dataset{Index: builder.Build()}
+	dataset.UserFeatures = [][]lo.Tuple2[int32, float32]{{{A: 0, B: 1}}}
+	dataset.ItemFeatures = [][]lo.Tuple2[int32, float32]{{{A: 0, B: 1}, {A: 1, B: 1}}}
+	for _, target := range []float32{1, -1} {
+		dataset.Users.Append(0)
+		dataset.Items.Append(0)
+		dataset.Target.Append(target)
+	}
+	dataset.PositiveCount, dataset.NegativeCount = 1, 1
+	deepFM := click.NewDeepFM(model.Params{model.NFactors: 4, model.NEpochs: 1, model.BatchSize: 4, model.HiddenLayers: []int{4, 4}})
+	deepFM.Fit(ctx, dataset, dataset, click.NewFitConfig())
+	s.ClickModel = deepFM
+	expected = deepFM.Explain("0", "0", []click.Feature{{Name: "gender.female", Value: 1}},
+		[]click.Feature{{Name: "a", Value: 1}, {Name: "b", Value: 1}}, nil)
+	expected.Truncate(2)
+	assert.Equal(t, click.IntegratedGradientAttribution, expected.Method)
+	apitest.New().
+		Handler(s.handler).
+		Get("/api/dashboard/explain/0/0").
+		Header("Cookie", cookie).
+		QueryParams(map[string]string{"n": "2"}).
+		Expect(t).
+		Status(http.StatusOK).
+		Body(marshal(t, expected)).
+		End()
+	apitest.New().
+		Handler(s.handler).
+		Get("/api/dashboard/explain/0/1").
+		Header("Cookie", cookie).
+		Expect(t).
+		Status(http.StatusNotFound).
+		End()
+	apitest.New().
+		Handler(s.handler).
+		Get("/api/dashboard/explain/0/0").
+		Header("Cookie", cookie).
+		QueryParams(map[string]string{"context": "{"}).
+		Expect(t).
+		Status(http.StatusBadRequest).
+		End()
+}
+
 func TestMaster_GetCategories(t *testing.T) {
 	s, cookie := newMockServer(t)
 	defer s.Close(t)
@@ -501,6 +844,72 @@ func TestMaster_GetUsers(t *testing.T) {
 		End()
 }
 
+// failedEraseDataClient fails to delete a user.
+type failedEraseDataClient struct {
+	data.Database
+	userId string
+}
+
+func (d failedEraseDataClient) DeleteUser(ctx context.Context, userId string) error {
+	if userId == d.userId {
+		return errors.New("failed to delete user")
+	}
+	return d.Database.DeleteUser(ctx, userId)
+}
+
+func TestMaster_EraseUsers(t *testing.T) {
+	s, cookie := newMockServer(t)
+	defer s.Close(t)
+	ctx := context.Background()
+	// add users
+	err := s.DataClient.BatchInsertUsers(ctx, []data.User{{UserId: "0"}, {UserId: "1"}, {UserId: "2"}})
+	assert.NoError(t, err)
+	err = s.DataClient.BatchInsertFeedback(ctx, []data.Feedback{
+		{FeedbackKey: data.FeedbackKey{FeedbackType: "click", UserId: "0", ItemId: "0"}},
+		{FeedbackKey: data.FeedbackKey{FeedbackType: "click", UserId: "1", ItemId: "0"}},
+	}, true, true, true)
+	assert.NoError(t, err)
+	err = s.CacheClient.Set(ctx, cache.Time(cache.Key(cache.LastModifyUserTime, "0"), time.Now()))
+	assert.NoError(t, err)
+	// erase users and continue past failures
+	s.DataClient = failedEraseDataClient{Database: s.DataClient, userId: "1"}
+	var result ErasureResult
+	apitest.New().
+		Handler(s.handler).
+		Post("/api/dashboard/users/erase").
+		Header("Cookie", cookie).
+		JSON([]string{"1", "0"}).
+		Expect(t).
+		Status(http.StatusOK).
+		End().
+		JSON(&result)
+	assert.Equal(t, []string{"0"}, lo.Map(result.Erased, func(report logics.ErasureReport, _ int) string { return report.UserId }))
+	if assert.Len(t, result.Failed, 1) {
+		assert.Equal(t, "1", result.Failed[0].UserId)
+		assert.Contains(t, result.Failed[0].Error, "failed to delete user")
+	}
+	_, err = s.DataClient.GetUser(ctx, "0")
+	assert.ErrorIs(t, err, errors.NotFound)
+	_, err = s.DataClient.GetUser(ctx, "1")
+	assert.NoError(t, err)
+	_, err = s.DataClient.GetUser(ctx, "2")
+	assert.NoError(t, err)
+	feedback, err := s.DataClient.GetUserFeedback(ctx, "0", nil)
+	assert.NoError(t, err)
+	assert.Empty(t, feedback)
+	_, err = s.CacheClient.Get(ctx, cache.Key(cache.LastModifyUserTime, "0")).Time()
+	assert.ErrorIs(t, err, errors.NotFound)
+	// erase nothing
+	apitest.New().
+		Handler(s.handler).
+		Post("/api/dashboard/users/erase").
+		Header("Cookie", cookie).
+		JSON([]string{}).
+		Expect(t).
+		Status(http.StatusBadRequest).
+		End()
+}
+
 func TestServer_SearchDocumentsOfItems(t *testing.T) {
 	s, cookie := newMockServer(t)
 	defer s.Close(t)
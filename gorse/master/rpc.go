@@ -18,12 +18,15 @@ import (
 	"context"
 	"encoding/json"
 	"github.com/juju/errors"
+	"github.com/samber/lo"
 	"github.com/zhenghaoz/gorse/base/log"
 	"github.com/zhenghaoz/gorse/model/click"
 	"github.com/zhenghaoz/gorse/model/ranking"
+	"github.com/zhenghaoz/gorse/model/session"
 	"github.com/zhenghaoz/gorse/protocol"
 	"github.com/zhenghaoz/gorse/storage/meta"
 	"go.uber.org/zap"
+	"google.golang.org/grpc"
 	"io"
 	"time"
 )
@@ -42,7 +45,9 @@ func (m *Master) GetMeta(ctx context.Context, nodeInfo *protocol.NodeInfo) (*pro
 		return nil, err
 	}
 	// marshall config
+	m.experimentsMutex.RLock()
 	s, err := json.Marshal(m.Config)
+	m.experimentsMutex.RUnlock()
 	if err != nil {
 		return nil, err
 	}
@@ -52,6 +57,7 @@ func (m *Master) GetMeta(ctx context.Context, nodeInfo *protocol.NodeInfo) (*pro
 	if m.RankingModel != nil && !m.RankingModel.Invalid() {
 		rankingModelVersion = m.RankingModelVersion
 	}
+	shadowRankingModelVersion := m.shadowRankingModelVersion
 	m.rankingModelMutex.RUnlock()
 	// save click model version
 	m.clickModelMutex.RLock()
@@ -59,7 +65,18 @@ func (m *Master) GetMeta(ctx context.Context, nodeInfo *protocol.NodeInfo) (*pro
 	if m.ClickModel != nil && !m.ClickModel.Invalid() {
 		clickModelVersion = m.ClickModelVersion
 	}
+	shadowClickModelVersion := m.shadowClickModelVersion
 	m.clickModelMutex.RUnlock()
+	// save session model version
+	var sessionModelVersion int64
+	if sessionModel, version := m.LoadSessionModel(); sessionModel != nil && !sessionModel.Invalid() {
+		sessionModelVersion = version
+	}
+	// save two-tower model version
+	var twoTowerModelVersion int64
+	if twoTowerModel, version := m.LoadTwoTowerModel(); !twoTowerModel.Invalid() {
+		twoTowerModelVersion = version
+	}
 	// collect nodes
 	workers := make([]string, 0)
 	servers := make([]string, 0)
@@ -75,16 +92,75 @@ func (m *Master) GetMeta(ctx context.Context, nodeInfo *protocol.NodeInfo) (*pro
 			servers = append(servers, n.UUID)
 		}
 	}
+	// collect ready workers
+	readyWorkers := make([]string, 0)
+	shards, err := m.metaStore.ListShards()
+	if err != nil {
+		return nil, err
+	}
+	for _, shard := range shards {
+		if shard.Ready && lo.Contains(workers, shard.UUID) {
+			readyWorkers = append(readyWorkers, shard.UUID)
+		}
+	}
 	return &protocol.Meta{
-		Config:              string(s),
-		RankingModelVersion: rankingModelVersion,
-		ClickModelVersion:   clickModelVersion,
-		Me:                  nodeInfo.Uuid,
-		Workers:             workers,
-		Servers:             servers,
+		Config:                    string(s),
+		RankingModelVersion:       rankingModelVersion,
+		ClickModelVersion:         clickModelVersion,
+		SessionModelVersion:       sessionModelVersion,
+		TwoTowerModelVersion:      twoTowerModelVersion,
+		ShadowRankingModelVersion: shadowRankingModelVersion,
+		ShadowClickModelVersion:   shadowClickModelVersion,
+		Me:                        nodeInfo.Uuid,
+		Workers:                   workers,
+		Servers:                   servers,
+		ReadyWorkers:              readyWorkers,
 	}, nil
 }
 
+// WatchMeta sends latest configuration to a worker once models are updated. Latest configuration is also sent every
+// meta timeout to keep the worker alive.
+func (m *Master) WatchMeta(nodeInfo *protocol.NodeInfo, sender protocol.Master_WatchMetaServer) error {
+	ticker := time.NewTicker(m.Config.Master.MetaTimeout)
+	defer ticker.Stop()
+	for {
+		updated := m.modelUpdated()
+		meta, err := m.GetMeta(sender.Context(), nodeInfo)
+		if err != nil {
+			return err
+		}
+		if err = sender.Send(meta); err != nil {
+			return err
+		}
+		select {
+		case <-sender.Context().Done():
+			return nil
+		case <-updated:
+		case <-ticker.C:
+		}
+	}
+}
+
+// modelUpdated returns a channel closed once models are updated.
+func (m *Master) modelUpdated() <-chan struct{} {
+	m.modelMutex.Lock()
+	defer m.modelMutex.Unlock()
+	if m.modelChan == nil {
+		m.modelChan = make(chan struct{})
+	}
+	return m.modelChan
+}
+
+// notifyModelUpdated wakes up all workers watching meta.
+func (m *Master) notifyModelUpdated() {
+	m.modelMutex.Lock()
+	defer m.modelMutex.Unlock()
+	if m.modelChan != nil {
+		close(m.modelChan)
+		m.modelChan = nil
+	}
+}
+
 // GetRankingModel returns latest ranking model.
 func (m *Master) GetRankingModel(version *protocol.VersionInfo, sender protocol.Master_GetRankingModelServer) error {
 	m.rankingModelMutex.RLock()
@@ -97,39 +173,33 @@ func (m *Master) GetRankingModel(version *protocol.VersionInfo, sender protocol.
 	if m.RankingModelVersion != version.Version {
 		return errors.New("model version mismatch")
 	}
-	// encode model
-	reader, writer := io.Pipe()
-	var encoderError error
-	go func() {
-		defer func(writer *io.PipeWriter) {
-			err := writer.Close()
-			if err != nil {
-				log.Logger().Error("fail to close pipe", zap.Error(err))
-			}
-		}(writer)
-		err := ranking.MarshalModel(writer, m.RankingModel)
-		if err != nil {
-			log.Logger().Error("fail to marshal ranking model", zap.Error(err))
-			encoderError = err
-			return
-		}
-	}()
-	// send model
-	for {
-		buf := make([]byte, batchSize)
-		n, err := reader.Read(buf)
-		if err == io.EOF {
-			log.Logger().Debug("complete sending ranking model")
-			break
-		} else if err != nil {
-			return err
-		}
-		err = sender.Send(&protocol.Fragment{Data: buf[:n]})
-		if err != nil {
-			return err
-		}
+	return sendModel(sender, "ranking model", func(w io.Writer) error {
+		return ranking.MarshalModel(w, m.RankingModel)
+	})
+}
+
+// GetRankingModelDelta returns the difference between the ranking model of the base version and latest ranking model.
+// Only the previous ranking model is kept, so workers falling behind more than one version have to pull the whole model.
+// Workers also pull the whole model if the delta isn't smaller than the model or their base mismatches the checksum in
+// the delta.
+func (m *Master) GetRankingModelDelta(delta *protocol.DeltaInfo, sender protocol.Master_GetRankingModelDeltaServer) error {
+	m.rankingModelMutex.RLock()
+	defer m.rankingModelMutex.RUnlock()
+	// skip empty model
+	if m.RankingModel == nil || m.RankingModel.Invalid() {
+		return errors.New("no valid model found")
 	}
-	return encoderError
+	// check model version
+	if m.RankingModelVersion != delta.Version {
+		return errors.New("model version mismatch")
+	}
+	// check base model version
+	if m.prevRankingModel == nil || m.prevRankingModelVersion != delta.BaseVersion {
+		return errors.NotFoundf("ranking model %x", delta.BaseVersion)
+	}
+	return sendModel(sender, "ranking model delta", func(w io.Writer) error {
+		return ranking.MarshalModelDelta(w, m.prevRankingModel, m.RankingModel)
+	})
 }
 
 // GetClickModel returns latest click model.
@@ -144,8 +214,52 @@ func (m *Master) GetClickModel(version *protocol.VersionInfo, sender protocol.Ma
 	if m.ClickModelVersion != version.Version {
 		return errors.New("model version mismatch")
 	}
+	return sendModel(sender, "click model", func(w io.Writer) error {
+		return click.MarshalModel(w, m.ClickModel)
+	})
+}
+
+// GetSessionModel returns latest session model.
+func (m *Master) GetSessionModel(version *protocol.VersionInfo, sender protocol.Master_GetSessionModelServer) error {
+	sessionModel, sessionModelVersion := m.LoadSessionModel()
+	// skip empty model
+	if sessionModel == nil || sessionModel.Invalid() {
+		return errors.New("no valid model found")
+	}
+	// check model version
+	if sessionModelVersion != version.Version {
+		return errors.New("model version mismatch")
+	}
+	return sendModel(sender, "session model", func(w io.Writer) error {
+		return session.MarshalModel(w, sessionModel)
+	})
+}
+
+// GetTwoTowerModel returns latest two-tower model followed by transformers of numeric labels.
+func (m *Master) GetTwoTowerModel(version *protocol.VersionInfo, sender protocol.Master_GetTwoTowerModelServer) error {
+	twoTowerModel, twoTowerModelVersion := m.LoadTwoTowerModel()
+	// skip empty model
+	if twoTowerModel.Invalid() {
+		return errors.New("no valid model found")
+	}
+	// check model version
+	if twoTowerModelVersion != version.Version {
+		return errors.New("model version mismatch")
+	}
+	userTransformer, itemTransformer := m.LoadTwoTowerTransformers()
+	return sendModel(sender, "two-tower model", func(w io.Writer) error {
+		if err := twoTowerModel.Marshal(w); err != nil {
+			return errors.Trace(err)
+		}
+		return click.MarshalLabelTransformers(w, userTransformer, itemTransformer)
+	})
+}
+
+// sendModel encodes a model and sends it in fragments.
+func sendModel(sender grpc.ServerStreamingServer[protocol.Fragment], name string, marshal func(w io.Writer) error) error {
 	// encode model
 	reader, writer := io.Pipe()
+	defer reader.Close()
 	var encoderError error
 	go func() {
 		defer func(writer *io.PipeWriter) {
@@ -154,9 +268,9 @@ func (m *Master) GetClickModel(version *protocol.VersionInfo, sender protocol.Ma
 				log.Logger().Error("fail to close pipe", zap.Error(err))
 			}
 		}(writer)
-		err := click.MarshalModel(writer, m.ClickModel)
+		err := marshal(writer)
 		if err != nil {
-			log.Logger().Error("fail to marshal click model", zap.Error(err))
+			log.Logger().Error("fail to marshal "+name, zap.Error(err))
 			encoderError = err
 			return
 		}
@@ -166,10 +280,12 @@ func (m *Master) GetClickModel(version *protocol.VersionInfo, sender protocol.Ma
 		buf := make([]byte, batchSize)
 		n, err := reader.Read(buf)
 		if err == io.EOF {
-			log.Logger().Debug("complete sending click model")
+			log.Logger().Debug("complete sending " + name)
 			break
 		} else if err != nil {
 			return err
+		} else if n == 0 {
+			continue
 		}
 		err = sender.Send(&protocol.Fragment{Data: buf[:n]})
 		if err != nil {
@@ -197,3 +313,17 @@ func (m *Master) PushProgress(
 	m.remoteProgress.Store(tracer, protocol.DecodeProgress(in))
 	return &protocol.PushProgressResponse{}, nil
 }
+
+// PushShard saves the share of users owned by a worker.
+func (m *Master) PushShard(_ context.Context, in *protocol.Shard) (*protocol.PushShardResponse, error) {
+	if err := m.metaStore.UpdateShard(&meta.Shard{
+		UUID:            in.Uuid,
+		NumUsers:        int(in.NumUsers),
+		NumHandoffUsers: int(in.NumHandoffUsers),
+		Ready:           in.Ready,
+		UpdateTime:      time.Now().UTC(),
+	}); err != nil {
+		return nil, err
+	}
+	return &protocol.PushShardResponse{}, nil
+}
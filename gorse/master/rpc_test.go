@@ -21,6 +21,7 @@ import (
 	"net"
 	"os"
 	"path/filepath"
+	"strconv"
 	"testing"
 	"time"
 
@@ -28,18 +29,22 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/zhenghaoz/gorse/base/progress"
 	"github.com/zhenghaoz/gorse/common/encoding"
+	"github.com/zhenghaoz/gorse/common/nn"
 	"github.com/zhenghaoz/gorse/common/util"
 	"github.com/zhenghaoz/gorse/config"
 	"github.com/zhenghaoz/gorse/model"
 	"github.com/zhenghaoz/gorse/model/click"
 	"github.com/zhenghaoz/gorse/model/ranking"
+	"github.com/zhenghaoz/gorse/model/session"
 	"github.com/zhenghaoz/gorse/protocol"
 	"github.com/zhenghaoz/gorse/server"
 	"github.com/zhenghaoz/gorse/storage/cache"
 	"github.com/zhenghaoz/gorse/storage/data"
 	"github.com/zhenghaoz/gorse/storage/meta"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
 )
 
 type mockMasterRPC struct {
@@ -62,7 +67,19 @@ func newMockMasterRPC(t *testing.T) *mockMasterRPC {
 	trainSet, testSet := newRankingDataset()
 	bpr := ranking.NewBPR(model.Params{model.NEpochs: 0})
 	bpr.Fit(context.Background(), trainSet, testSet, nil)
-	return &mockMasterRPC{
+	// create session model
+	sessionSet := session.NewDataSet()
+	sessionSet.AddSequence("1", "2", "3")
+	sessionSet.AddSequence("2", "3", "4")
+	sasrec := session.NewSASRec(model.Params{model.NEpochs: 0})
+	sasrec.Fit(context.Background(), sessionSet, sessionSet, nil)
+	// create two-tower model
+	twoTower := nn.NewTwoTower(model.Params{model.NEpochs: 0, model.NFactors: 16})
+	twoTower.Fit(context.Background(),
+		[]nn.TowerInput{{Id: "1", History: []string{"2"}}},
+		[]nn.TowerInput{{Id: "1"}, {Id: "2"}},
+		[]int32{0}, []int32{0})
+	m := &mockMasterRPC{
 		Master: Master{
 			rankingModelName: "bpr",
 			RestServer: server.RestServer{
@@ -80,13 +97,21 @@ func newMockMasterRPC(t *testing.T) *mockMasterRPC {
 		},
 		addr: make(chan string),
 	}
+	m.StoreSessionModel(sasrec, 789)
+	m.StoreTwoTowerModel(twoTower, 1011, nil, &click.LabelTransformer{Statistics: map[string]*click.NumericStatistics{
+		"price": {Method: click.LogTransform},
+	}})
+	return m
 }
 
 func (m *mockMasterRPC) Start(t *testing.T) {
 	listen, err := net.Listen("tcp", ":0")
 	assert.NoError(t, err)
 	m.addr <- listen.Addr().String()
-	var opts []grpc.ServerOption
+	opts := []grpc.ServerOption{
+		grpc.UnaryInterceptor(m.unaryTenantInterceptor),
+		grpc.StreamInterceptor(m.streamTenantInterceptor),
+	}
 	m.grpcServer = grpc.NewServer(opts...)
 	protocol.RegisterMasterServer(m.grpcServer, m)
 	err = m.grpcServer.Serve(listen)
@@ -155,6 +180,35 @@ func TestRPC(t *testing.T) {
 	rpcServer.RankingModel.SetParams(rpcServer.RankingModel.GetParams())
 	assert.Equal(t, rpcServer.RankingModel, rankingModel)
 
+	// test get session model
+	sessionModelReceiver, err := client.GetSessionModel(ctx, &protocol.VersionInfo{Version: 789})
+	assert.NoError(t, err)
+	sessionModel, err := encoding.UnmarshalSessionModel(sessionModelReceiver)
+	assert.NoError(t, err)
+	expectedSessionModel, _ := rpcServer.LoadSessionModel()
+	assert.Equal(t, expectedSessionModel, sessionModel)
+	sessionModelReceiver, err = client.GetSessionModel(ctx, &protocol.VersionInfo{Version: 790})
+	assert.NoError(t, err)
+	_, err = encoding.UnmarshalSessionModel(sessionModelReceiver)
+	assert.Error(t, err)
+
+	// test get two-tower model
+	twoTowerModelReceiver, err := client.GetTwoTowerModel(ctx, &protocol.VersionInfo{Version: 1011})
+	assert.NoError(t, err)
+	twoTowerModel, userTransformer, itemTransformer, err := encoding.UnmarshalTwoTowerModel(twoTowerModelReceiver)
+	assert.NoError(t, err)
+	assert.Nil(t, userTransformer)
+	expectedUserTransformer, expectedItemTransformer := rpcServer.LoadTwoTowerTransformers()
+	assert.Nil(t, expectedUserTransformer)
+	assert.Equal(t, expectedItemTransformer, itemTransformer)
+	expectedTwoTowerModel, _ := rpcServer.LoadTwoTowerModel()
+	assert.Equal(t, expectedTwoTowerModel.GetParams(), twoTowerModel.GetParams())
+	assert.Equal(t, expectedTwoTowerModel.EncodeItems([]nn.TowerInput{{Id: "1"}}), twoTowerModel.EncodeItems([]nn.TowerInput{{Id: "1"}}))
+	twoTowerModelReceiver, err = client.GetTwoTowerModel(ctx, &protocol.VersionInfo{Version: 1012})
+	assert.NoError(t, err)
+	_, _, _, err = encoding.UnmarshalTwoTowerModel(twoTowerModelReceiver)
+	assert.Error(t, err)
+
 	// test get meta
 	_, err = client.GetMeta(ctx,
 		&protocol.NodeInfo{NodeType: protocol.NodeType_Server, Uuid: "server1", Hostname: "yoga"})
@@ -164,9 +218,20 @@ func TestRPC(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, int64(123), metaResp.RankingModelVersion)
 	assert.Equal(t, int64(456), metaResp.ClickModelVersion)
+	assert.Equal(t, int64(789), metaResp.SessionModelVersion)
+	assert.Equal(t, int64(1011), metaResp.TwoTowerModelVersion)
 	assert.Equal(t, "worker1", metaResp.Me)
 	assert.Equal(t, []string{"server1"}, metaResp.Servers)
 	assert.Equal(t, []string{"worker1"}, metaResp.Workers)
+	assert.Empty(t, metaResp.ReadyWorkers)
+
+	// test push shard
+	_, err = client.PushShard(ctx, &protocol.Shard{Uuid: "worker1", NumUsers: 10, Ready: true})
+	assert.NoError(t, err)
+	metaResp, err = client.GetMeta(ctx,
+		&protocol.NodeInfo{NodeType: protocol.NodeType_Worker, Uuid: "worker1", Hostname: "yoga"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"worker1"}, metaResp.ReadyWorkers)
 	var cfg config.Config
 	err = json.Unmarshal([]byte(metaResp.Config), &cfg)
 	assert.NoError(t, err)
@@ -181,6 +246,133 @@ func TestRPC(t *testing.T) {
 	rpcServer.Stop()
 }
 
+func TestRPC_WatchMeta(t *testing.T) {
+	rpcServer := newMockMasterRPC(t)
+	go rpcServer.Start(t)
+	defer rpcServer.Stop()
+	address := <-rpcServer.addr
+	conn, err := grpc.Dial(address, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	assert.NoError(t, err)
+	client := protocol.NewMasterClient(conn)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// receive meta once watched
+	stream, err := client.WatchMeta(ctx, &protocol.NodeInfo{NodeType: protocol.NodeType_Worker, Uuid: "worker1"})
+	assert.NoError(t, err)
+	metaResp, err := stream.Recv()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(123), metaResp.RankingModelVersion)
+	assert.Equal(t, []string{"worker1"}, metaResp.Workers)
+
+	// receive meta once models are updated
+	rpcServer.rankingModelMutex.Lock()
+	rpcServer.RankingModelVersion++
+	rpcServer.rankingModelMutex.Unlock()
+	rpcServer.notifyModelUpdated()
+	metaResp, err = stream.Recv()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(124), metaResp.RankingModelVersion)
+}
+
+func TestRPC_GetRankingModelDelta(t *testing.T) {
+	rpcServer := newMockMasterRPC(t)
+	dataset := ranking.NewMapIndexDataset()
+	for i := 0; i < 10; i++ {
+		dataset.AddFeedback(strconv.Itoa(i), strconv.Itoa(i), true)
+		dataset.AddFeedback(strconv.Itoa(i), strconv.Itoa(i+1), true)
+	}
+	prevModel := ranking.NewBPR(model.Params{model.NEpochs: 1})
+	prevModel.Fit(context.Background(), dataset, dataset, nil)
+	rankingModel := ranking.Clone(prevModel).(*ranking.BPR)
+	rankingModel.UserFactor[0][0]++
+	rpcServer.prevRankingModel = prevModel
+	rpcServer.prevRankingModelVersion = 122
+	rpcServer.RankingModel = rankingModel
+	go rpcServer.Start(t)
+	defer rpcServer.Stop()
+	address := <-rpcServer.addr
+	conn, err := grpc.Dial(address, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	assert.NoError(t, err)
+	client := protocol.NewMasterClient(conn)
+	ctx := context.Background()
+
+	// apply delta to previous model
+	receiver, err := client.GetRankingModelDelta(ctx, &protocol.DeltaInfo{BaseVersion: 122, Version: 123})
+	assert.NoError(t, err)
+	deltaModel, err := encoding.UnmarshalRankingModelDelta(receiver, ranking.Clone(prevModel))
+	assert.NoError(t, err)
+	assert.Equal(t, rankingModel.UserFactor, deltaModel.(*ranking.BPR).UserFactor)
+	assert.Equal(t, rankingModel.ItemFactor, deltaModel.(*ranking.BPR).ItemFactor)
+
+	// unknown base version
+	receiver, err = client.GetRankingModelDelta(ctx, &protocol.DeltaInfo{BaseVersion: 121, Version: 123})
+	assert.NoError(t, err)
+	_, err = encoding.UnmarshalRankingModelDelta(receiver, ranking.Clone(prevModel))
+	assert.Error(t, err)
+
+	// delta isn't smaller than the model
+	rpcServer.rankingModelMutex.Lock()
+	for i := range rankingModel.UserFactor {
+		rankingModel.UserFactor[i][0]++
+	}
+	for i := range rankingModel.ItemFactor {
+		rankingModel.ItemFactor[i][0]++
+	}
+	rpcServer.rankingModelMutex.Unlock()
+	receiver, err = client.GetRankingModelDelta(ctx, &protocol.DeltaInfo{BaseVersion: 122, Version: 123})
+	assert.NoError(t, err)
+	_, err = encoding.UnmarshalRankingModelDelta(receiver, ranking.Clone(prevModel))
+	assert.Error(t, err)
+}
+
+func TestRPC_Tenant(t *testing.T) {
+	rpcServer := newMockMasterRPC(t)
+	tenantServer := newMockMasterRPC(t)
+	tenantServer.RankingModelVersion = 321
+	tenantServer.Config.Database.DataTablePrefix = "eu_"
+	rpcServer.tenantServices = map[string]map[string]any{
+		"eu": {protocol.Master_ServiceDesc.ServiceName: &tenantServer.Master},
+	}
+	go rpcServer.Start(t)
+	defer rpcServer.Stop()
+	address := <-rpcServer.addr
+	ctx := context.Background()
+	nodeInfo := &protocol.NodeInfo{NodeType: protocol.NodeType_Worker, Uuid: "worker1"}
+
+	// requests without tenant are served for the cluster
+	conn, err := grpc.Dial(address, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	assert.NoError(t, err)
+	metaResp, err := protocol.NewMasterClient(conn).GetMeta(ctx, nodeInfo)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(123), metaResp.RankingModelVersion)
+
+	// requests with tenant are served for the tenant
+	conn, err = grpc.Dial(address, append(protocol.WithTenant("eu"),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))...)
+	assert.NoError(t, err)
+	client := protocol.NewMasterClient(conn)
+	metaResp, err = client.GetMeta(ctx, nodeInfo)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(321), metaResp.RankingModelVersion)
+	var cfg config.Config
+	assert.NoError(t, json.Unmarshal([]byte(metaResp.Config), &cfg))
+	assert.Equal(t, "eu_", cfg.Database.DataTablePrefix)
+	rankingModelReceiver, err := client.GetRankingModel(ctx, &protocol.VersionInfo{Version: 321})
+	assert.NoError(t, err)
+	rankingModel, err := encoding.UnmarshalRankingModel(rankingModelReceiver)
+	assert.NoError(t, err)
+	tenantServer.RankingModel.SetParams(tenantServer.RankingModel.GetParams())
+	assert.Equal(t, tenantServer.RankingModel, rankingModel)
+
+	// unknown tenant
+	conn, err = grpc.Dial(address, append(protocol.WithTenant("us"),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))...)
+	assert.NoError(t, err)
+	_, err = protocol.NewMasterClient(conn).GetMeta(ctx, nodeInfo)
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
 func generateToTempFile(t *testing.T) (string, string, string) {
 	// Generate Certificate Authority
 	ca := testcerts.NewCA()
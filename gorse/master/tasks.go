@@ -16,8 +16,10 @@ package master
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"github.com/zhenghaoz/gorse/logics"
+	"slices"
 	"sort"
 	"strings"
 	"sync"
@@ -36,30 +38,44 @@ import (
 	"github.com/zhenghaoz/gorse/base/search"
 	"github.com/zhenghaoz/gorse/base/sizeof"
 	"github.com/zhenghaoz/gorse/base/task"
+	"github.com/zhenghaoz/gorse/common/nn"
 	"github.com/zhenghaoz/gorse/config"
+	"github.com/zhenghaoz/gorse/model"
 	"github.com/zhenghaoz/gorse/model/click"
 	"github.com/zhenghaoz/gorse/model/ranking"
+	"github.com/zhenghaoz/gorse/model/session"
+	"github.com/zhenghaoz/gorse/server"
+	"github.com/zhenghaoz/gorse/storage/blob"
 	"github.com/zhenghaoz/gorse/storage/cache"
 	"github.com/zhenghaoz/gorse/storage/data"
 	"go.uber.org/atomic"
 	"go.uber.org/zap"
 	"google.golang.org/protobuf/proto"
-	"modernc.org/sortutil"
 )
 
 const (
-	PositiveFeedbackRate = "PositiveFeedbackRate"
+	PositiveFeedbackRate                = "PositiveFeedbackRate"
+	ExperimentPositiveFeedbackRate      = "ExperimentPositiveFeedbackRate"
+	ExperimentPositiveFeedbackRateLower = "ExperimentPositiveFeedbackRateLower"
+	ExperimentPositiveFeedbackRateUpper = "ExperimentPositiveFeedbackRateUpper"
+	ImpressionClickThroughRate          = "ImpressionClickThroughRate"
+	TimeToSell                          = "TimeToSell"
 
 	TaskFindItemNeighbors      = "Find neighbors of items"
 	TaskFindUserNeighbors      = "Find neighbors of users"
 	TaskFitRankingModel        = "Fit collaborative filtering model"
 	TaskFitClickModel          = "Fit click-through rate prediction model"
+	TaskFitSessionModel        = "Fit session recommendation model"
+	TaskFitTwoTowerModel       = "Fit two-tower retrieval model"
 	TaskSearchRankingModel     = "Search collaborative filtering  model"
 	TaskSearchClickModel       = "Search click-through rate prediction model"
 	TaskCacheGarbageCollection = "Collect garbage in cache"
 
 	batchSize        = 10000
 	similarityShrink = 100
+
+	// numSessionTestSequences caps sequences, one per user, left out to evaluate the session model.
+	numSessionTestSequences = 10000
 )
 
 type Task interface {
@@ -93,7 +109,7 @@ func (m *Master) runLoadDatasetTask() error {
 		zap.Uint("item_ttl", m.Config.Recommend.DataSource.ItemTTL),
 		zap.Uint("feedback_ttl", m.Config.Recommend.DataSource.PositiveFeedbackTTL))
 	evaluator := NewOnlineEvaluator()
-	rankingDataset, clickDataset, err := m.LoadDataFromDatabase(ctx, m.DataClient,
+	rankingDataset, clickDataset, sessionDataset, err := m.LoadDataFromDatabase(ctx, m.DataClient,
 		m.Config.Recommend.DataSource.PositiveFeedbackTypes,
 		m.Config.Recommend.DataSource.ReadFeedbackTypes,
 		m.Config.Recommend.DataSource.ItemTTL,
@@ -104,10 +120,13 @@ func (m *Master) runLoadDatasetTask() error {
 	}
 
 	// save non-personalized recommenders to cache
+	refreshes := make([]logics.RecommendRefresh, 0, len(nonPersonalizedRecommenders))
 	for _, recommender := range nonPersonalizedRecommenders {
 		scores := recommender.PopAll()
 		if err = m.CacheClient.AddScores(ctx, cache.NonPersonalized, recommender.Name(), scores); err != nil {
 			log.Logger().Error("failed to cache non-personalized recommenders", zap.Error(err))
+		} else {
+			refreshes = append(refreshes, logics.NewRecommendRefresh(recommender.Name(), "", scores))
 		}
 		if err = m.CacheClient.DeleteScores(ctx, []string{cache.NonPersonalized},
 			cache.ScoreCondition{
@@ -118,39 +137,61 @@ func (m *Master) runLoadDatasetTask() error {
 		}
 	}
 
+	if err = logics.PublishEvents(ctx, m.Config, m.DataClient, data.EventRecommendRefreshed, refreshes,
+		func(refresh logics.RecommendRefresh) string { return refresh.Recommender }); err != nil {
+		log.Logger().Error("failed to publish refreshed recommendation", zap.Error(err))
+	}
+
 	// write statistics to database
-	UsersTotal.Set(float64(rankingDataset.UserCount()))
+	m.gauge(UsersTotal).Set(float64(rankingDataset.UserCount()))
 	if err = m.CacheClient.Set(ctx, cache.Integer(cache.Key(cache.GlobalMeta, cache.NumUsers), rankingDataset.UserCount())); err != nil {
 		log.Logger().Error("failed to write number of users", zap.Error(err))
 	}
-	ItemsTotal.Set(float64(rankingDataset.ItemCount()))
+	m.gauge(ItemsTotal).Set(float64(rankingDataset.ItemCount()))
 	if err = m.CacheClient.Set(ctx, cache.Integer(cache.Key(cache.GlobalMeta, cache.NumItems), rankingDataset.ItemCount())); err != nil {
 		log.Logger().Error("failed to write number of items", zap.Error(err))
 	}
-	ImplicitFeedbacksTotal.Set(float64(rankingDataset.Count()))
+	m.gauge(ImplicitFeedbacksTotal).Set(float64(rankingDataset.Count()))
 	if err = m.CacheClient.Set(ctx, cache.Integer(cache.Key(cache.GlobalMeta, cache.NumTotalPosFeedbacks), rankingDataset.Count())); err != nil {
 		log.Logger().Error("failed to write number of positive feedbacks", zap.Error(err))
 	}
-	UserLabelsTotal.Set(float64(clickDataset.Index.CountUserLabels()))
+	m.gauge(UserLabelsTotal).Set(float64(clickDataset.Index.CountUserLabels()))
 	if err = m.CacheClient.Set(ctx, cache.Integer(cache.Key(cache.GlobalMeta, cache.NumUserLabels), int(clickDataset.Index.CountUserLabels()))); err != nil {
 		log.Logger().Error("failed to write number of user labels", zap.Error(err))
 	}
-	ItemLabelsTotal.Set(float64(clickDataset.Index.CountItemLabels()))
+	m.gauge(ItemLabelsTotal).Set(float64(clickDataset.Index.CountItemLabels()))
 	if err = m.CacheClient.Set(ctx, cache.Integer(cache.Key(cache.GlobalMeta, cache.NumItemLabels), int(clickDataset.Index.CountItemLabels()))); err != nil {
 		log.Logger().Error("failed to write number of item labels", zap.Error(err))
 	}
-	ImplicitFeedbacksTotal.Set(float64(rankingDataset.Count()))
-	PositiveFeedbacksTotal.Set(float64(clickDataset.PositiveCount))
+	m.gauge(ImplicitFeedbacksTotal).Set(float64(rankingDataset.Count()))
+	m.gauge(PositiveFeedbacksTotal).Set(float64(clickDataset.PositiveCount))
 	if err = m.CacheClient.Set(ctx, cache.Integer(cache.Key(cache.GlobalMeta, cache.NumValidPosFeedbacks), clickDataset.PositiveCount)); err != nil {
 		log.Logger().Error("failed to write number of positive feedbacks", zap.Error(err))
 	}
-	NegativeFeedbackTotal.Set(float64(clickDataset.NegativeCount))
+	m.gauge(NegativeFeedbackTotal).Set(float64(clickDataset.NegativeCount))
 	if err = m.CacheClient.Set(ctx, cache.Integer(cache.Key(cache.GlobalMeta, cache.NumValidNegFeedbacks), clickDataset.NegativeCount)); err != nil {
 		log.Logger().Error("failed to write number of negative feedbacks", zap.Error(err))
 	}
 
 	// evaluate positive feedback rate
 	points := evaluator.Evaluate()
+	points = append(points, evaluator.EvaluateTimeToSell()...)
+	for _, experiment := range m.experiments() {
+		points = append(points, evaluator.EvaluateExperiment(experiment.Name, func(userIndex int32) (string, bool) {
+			variant, ok := experiment.Assign(rankingDataset.UserIndex.ToName(userIndex))
+			if !ok {
+				return "", false
+			}
+			return variant.Name, true
+		})...)
+	}
+	if m.Config.Server.LogImpressions {
+		if err = m.loadImpressions(ctx, evaluator, rankingDataset); err != nil {
+			log.Logger().Error("failed to load impressions", zap.Error(err))
+		} else {
+			points = append(points, evaluator.EvaluateImpressions()...)
+		}
+	}
 	if err = m.CacheClient.AddTimeSeriesPoints(ctx, points); err != nil {
 		log.Logger().Error("failed to insert measurement", zap.Error(err))
 	}
@@ -171,10 +212,10 @@ func (m *Master) runLoadDatasetTask() error {
 			inactiveItems++
 		}
 	}
-	ActiveUsersTotal.Set(float64(activeUsers))
-	ActiveItemsTotal.Set(float64(activeItems))
-	InactiveUsersTotal.Set(float64(inactiveUsers))
-	InactiveItemsTotal.Set(float64(inactiveItems))
+	m.gauge(ActiveUsersTotal).Set(float64(activeUsers))
+	m.gauge(ActiveItemsTotal).Set(float64(activeItems))
+	m.gauge(InactiveUsersTotal).Set(float64(inactiveUsers))
+	m.gauge(InactiveItemsTotal).Set(float64(inactiveItems))
 
 	// write categories to cache
 	if err = m.CacheClient.SetSet(ctx, cache.ItemCategories, rankingDataset.CategorySet.ToSlice()...); err != nil {
@@ -187,9 +228,9 @@ func (m *Master) runLoadDatasetTask() error {
 	m.rankingTrainSet, m.rankingTestSet = rankingDataset.Split(0, 0)
 	rankingDataset = nil
 	m.rankingDataMutex.Unlock()
-	LoadDatasetStepSecondsVec.WithLabelValues("split_ranking_dataset").Set(time.Since(startTime).Seconds())
-	MemoryInUseBytesVec.WithLabelValues("collaborative_filtering_train_set").Set(float64(m.rankingTrainSet.Bytes()))
-	MemoryInUseBytesVec.WithLabelValues("collaborative_filtering_test_set").Set(float64(m.rankingTestSet.Bytes()))
+	m.gaugeVec(LoadDatasetStepSecondsVec, "split_ranking_dataset").Set(time.Since(startTime).Seconds())
+	m.gaugeVec(MemoryInUseBytesVec, "collaborative_filtering_train_set").Set(float64(m.rankingTrainSet.Bytes()))
+	m.gaugeVec(MemoryInUseBytesVec, "collaborative_filtering_test_set").Set(float64(m.rankingTestSet.Bytes()))
 
 	// split click dataset
 	startTime = time.Now()
@@ -197,11 +238,19 @@ func (m *Master) runLoadDatasetTask() error {
 	m.clickTrainSet, m.clickTestSet = clickDataset.Split(0.2, 0)
 	clickDataset = nil
 	m.clickDataMutex.Unlock()
-	LoadDatasetStepSecondsVec.WithLabelValues("split_click_dataset").Set(time.Since(startTime).Seconds())
-	MemoryInUseBytesVec.WithLabelValues("ranking_train_set").Set(float64(sizeof.DeepSize(m.clickTrainSet)))
-	MemoryInUseBytesVec.WithLabelValues("ranking_test_set").Set(float64(sizeof.DeepSize(m.clickTestSet)))
+	m.gaugeVec(LoadDatasetStepSecondsVec, "split_click_dataset").Set(time.Since(startTime).Seconds())
+	m.gaugeVec(MemoryInUseBytesVec, "ranking_train_set").Set(float64(sizeof.DeepSize(m.clickTrainSet)))
+	m.gaugeVec(MemoryInUseBytesVec, "ranking_test_set").Set(float64(sizeof.DeepSize(m.clickTestSet)))
+
+	// split session dataset
+	startTime = time.Now()
+	m.sessionDataMutex.Lock()
+	m.sessionTrainSet, m.sessionTestSet = sessionDataset.Split(numSessionTestSequences, 0)
+	sessionDataset = nil
+	m.sessionDataMutex.Unlock()
+	m.gaugeVec(LoadDatasetStepSecondsVec, "split_session_dataset").Set(time.Since(startTime).Seconds())
 
-	LoadDatasetTotalSeconds.Set(time.Since(initialStartTime).Seconds())
+	m.gauge(LoadDatasetTotalSeconds).Set(time.Since(initialStartTime).Seconds())
 	return nil
 }
 
@@ -273,9 +322,7 @@ func (t *FindItemNeighborsTask) run(ctx context.Context, j *task.JobsAllocator)
 	userIDF := make([]float32, dataset.UserCount())
 	if t.Config.Recommend.ItemNeighbors.NeighborType == config.NeighborTypeRelated ||
 		t.Config.Recommend.ItemNeighbors.NeighborType == config.NeighborTypeAuto {
-		for _, feedbacks := range dataset.ItemFeedback {
-			sort.Sort(sortutil.Int32Slice(feedbacks))
-		}
+		dataset.SortItemFeedback()
 		// inverse document frequency of users
 		for i := range dataset.UserFeedback {
 			if dataset.ItemCount() == len(dataset.UserFeedback[i]) {
@@ -285,21 +332,19 @@ func (t *FindItemNeighborsTask) run(ctx context.Context, j *task.JobsAllocator)
 			}
 		}
 	}
-	labeledItems := make([][]int32, dataset.NumItemLabels)
-	labelIDF := make([]float32, dataset.NumItemLabels)
+	_, itemTransformer := t.labelTransformers()
+	itemLabels, numLabels := discretizeLabels(dataset.ItemFeatures, dataset.ItemLabelIndex, itemTransformer)
+	labeledItems := make([][]int32, numLabels)
+	labelIDF := make([]float32, numLabels)
 	if t.Config.Recommend.ItemNeighbors.NeighborType == config.NeighborTypeSimilar ||
 		t.Config.Recommend.ItemNeighbors.NeighborType == config.NeighborTypeAuto {
-		for i, itemLabels := range dataset.ItemFeatures {
-			sort.Slice(itemLabels, func(i, j int) bool {
-				return itemLabels[i].A < itemLabels[j].A
-			})
-			for _, label := range itemLabels {
-				labeledItems[label.A] = append(labeledItems[label.A], int32(i))
+		for i, labels := range itemLabels {
+			for _, label := range labels {
+				labeledItems[label] = append(labeledItems[label], int32(i))
 			}
 		}
 		// inverse document frequency of labels
 		for i := range labeledItems {
-			labeledItems[i] = lo.Uniq(labeledItems[i])
 			if dataset.ItemCount() == len(labeledItems[i]) {
 				labelIDF[i] = 1
 			} else {
@@ -311,9 +356,9 @@ func (t *FindItemNeighborsTask) run(ctx context.Context, j *task.JobsAllocator)
 	start := time.Now()
 	var err error
 	if t.Config.Recommend.ItemNeighbors.EnableIndex {
-		err = t.findItemNeighborsIVF(dataset, labelIDF, userIDF, completed, j)
+		err = t.findItemNeighborsIVF(dataset, itemLabels, labelIDF, userIDF, completed, j)
 	} else {
-		err = t.findItemNeighborsBruteForce(dataset, labeledItems, labelIDF, userIDF, completed, j)
+		err = t.findItemNeighborsBruteForce(dataset, itemLabels, labeledItems, labelIDF, userIDF, completed, j)
 	}
 	searchTime := time.Since(start)
 
@@ -321,14 +366,14 @@ func (t *FindItemNeighborsTask) run(ctx context.Context, j *task.JobsAllocator)
 	if err != nil {
 		log.Logger().Error("failed to searching neighbors of items", zap.Error(err))
 		progress.Fail(newCtx, err)
-		FindItemNeighborsTotalSeconds.Set(0)
+		t.gauge(FindItemNeighborsTotalSeconds).Set(0)
 	} else {
 		if err := t.CacheClient.Set(ctx, cache.Time(cache.Key(cache.GlobalMeta, cache.LastUpdateItemNeighborsTime), time.Now())); err != nil {
 			log.Logger().Error("failed to set neighbors of items update time", zap.Error(err))
 		}
 		log.Logger().Info("complete searching neighbors of items",
 			zap.String("search_time", searchTime.String()))
-		FindItemNeighborsTotalSeconds.Set(time.Since(startTaskTime).Seconds())
+		t.gauge(FindItemNeighborsTotalSeconds).Set(time.Since(startTaskTime).Seconds())
 	}
 
 	t.lastNumItems = numItems
@@ -336,7 +381,46 @@ func (t *FindItemNeighborsTask) run(ctx context.Context, j *task.JobsAllocator)
 	return nil
 }
 
-func (m *Master) findItemNeighborsBruteForce(dataset *ranking.DataSet, labeledItems [][]int32,
+// labelBucketsPerUnit is the number of buckets per unit of label values in neighbor search. Log-scaled labels are
+// bucketed by ratios of about 1.6 and z-scored labels are bucketed by half standard deviations.
+const labelBucketsPerUnit = 2
+
+// discretizeLabels converts labels of users or items into sorted label tokens for neighbor search, since neighbors
+// are found by shared tokens. A token is a label with its value. Values of labels scaled by the transformer are
+// bucketed, so that scaled labels with close values share tokens. Values of other labels, such as labels without
+// values (whose values are 1) and numeric labels passed through, are kept unchanged. The number of tokens is returned
+// as well.
+func discretizeLabels(features [][]lo.Tuple2[int32, float32], labelIndex base.Index,
+	transformer *click.LabelTransformer) ([][]int32, int) {
+	scaled := make(map[int32]bool)
+	tokenIndex := make(map[lo.Tuple2[int32, float32]]int32)
+	tokens := make([][]int32, len(features))
+	for i, labels := range features {
+		tokens[i] = make([]int32, 0, len(labels))
+		for _, label := range labels {
+			isScaled, exist := scaled[label.A]
+			if !exist {
+				isScaled = transformer != nil && transformer.IsScaled(labelIndex.ToName(label.A))
+				scaled[label.A] = isScaled
+			}
+			key := lo.Tuple2[int32, float32]{A: label.A, B: label.B}
+			if isScaled {
+				key.B = math32.Round(label.B * labelBucketsPerUnit)
+			}
+			token, exist := tokenIndex[key]
+			if !exist {
+				token = int32(len(tokenIndex))
+				tokenIndex[key] = token
+			}
+			tokens[i] = append(tokens[i], token)
+		}
+		slices.Sort(tokens[i])
+		tokens[i] = slices.Compact(tokens[i])
+	}
+	return tokens, len(tokenIndex)
+}
+
+func (m *Master) findItemNeighborsBruteForce(dataset *ranking.DataSet, itemLabels, labeledItems [][]int32,
 	labelIDF, userIDF []float32, completed chan struct{}, j *task.JobsAllocator) error {
 	ctx := context.Background()
 	var (
@@ -347,19 +431,13 @@ func (m *Master) findItemNeighborsBruteForce(dataset *ranking.DataSet, labeledIt
 	var vector VectorsInterface
 	switch m.Config.Recommend.ItemNeighbors.NeighborType {
 	case config.NeighborTypeSimilar:
-		vector = NewVectors(lo.Map(dataset.ItemFeatures, func(features []lo.Tuple2[int32, float32], _ int) []int32 {
-			indices, _ := lo.Unzip2(features)
-			return indices
-		}), labeledItems, labelIDF)
+		vector = NewVectors(itemLabels, labeledItems, labelIDF)
 	case config.NeighborTypeRelated:
-		vector = NewVectors(dataset.ItemFeedback, dataset.UserFeedback, userIDF)
+		vector = NewDecayedVectors(dataset.ItemFeedback, dataset.ItemDecay, dataset.UserFeedback, userIDF)
 	case config.NeighborTypeAuto:
 		vector = NewDualVectors(
-			NewVectors(lo.Map(dataset.ItemFeatures, func(features []lo.Tuple2[int32, float32], _ int) []int32 {
-				indices, _ := lo.Unzip2(features)
-				return indices
-			}), labeledItems, labelIDF),
-			NewVectors(dataset.ItemFeedback, dataset.UserFeedback, userIDF))
+			NewVectors(itemLabels, labeledItems, labelIDF),
+			NewDecayedVectors(dataset.ItemFeedback, dataset.ItemDecay, dataset.UserFeedback, userIDF))
 	default:
 		return errors.NotImplementedf("item neighbor type `%v`", m.Config.Recommend.ItemNeighbors.NeighborType)
 	}
@@ -424,14 +502,14 @@ func (m *Master) findItemNeighborsBruteForce(dataset *ranking.DataSet, labeledIt
 	if err != nil {
 		return errors.Trace(err)
 	}
-	UpdateItemNeighborsTotal.Set(updateItemCount.Load())
-	FindItemNeighborsSecondsVec.WithLabelValues("find_item_neighbors").Set(findNeighborSeconds.Load())
-	FindItemNeighborsSecondsVec.WithLabelValues("build_index").Set(0)
-	ItemNeighborIndexRecall.Set(1)
+	m.gauge(UpdateItemNeighborsTotal).Set(updateItemCount.Load())
+	m.gaugeVec(FindItemNeighborsSecondsVec, "find_item_neighbors").Set(findNeighborSeconds.Load())
+	m.gaugeVec(FindItemNeighborsSecondsVec, "build_index").Set(0)
+	m.gauge(ItemNeighborIndexRecall).Set(1)
 	return nil
 }
 
-func (m *Master) findItemNeighborsIVF(dataset *ranking.DataSet, labelIDF, userIDF []float32, completed chan struct{}, j *task.JobsAllocator) error {
+func (m *Master) findItemNeighborsIVF(dataset *ranking.DataSet, itemLabels [][]int32, labelIDF, userIDF []float32, completed chan struct{}, j *task.JobsAllocator) error {
 	var (
 		updateItemCount     atomic.Float64
 		findNeighborSeconds atomic.Float64
@@ -445,18 +523,18 @@ func (m *Master) findItemNeighborsIVF(dataset *ranking.DataSet, labelIDF, userID
 	var vectors []search.Vector
 	switch m.Config.Recommend.ItemNeighbors.NeighborType {
 	case config.NeighborTypeSimilar:
-		vectors = lo.Map(dataset.ItemFeatures, func(_ []lo.Tuple2[int32, float32], i int) search.Vector {
-			indices, _ := lo.Unzip2(dataset.ItemFeatures[i])
+		vectors = lo.Map(itemLabels, func(indices []int32, i int) search.Vector {
 			return search.NewDictionaryVector(indices, labelIDF, dataset.ItemCategories[i], dataset.HiddenItems[i])
 		})
 	case config.NeighborTypeRelated:
 		vectors = lo.Map(dataset.ItemFeatures, func(_ []lo.Tuple2[int32, float32], i int) search.Vector {
-			return search.NewDictionaryVector(dataset.ItemFeedback[i], userIDF, dataset.ItemCategories[i], dataset.HiddenItems[i])
+			return search.NewDecayedDictionaryVector(dataset.ItemFeedback[i], decaysAt(dataset.ItemDecay, i), userIDF,
+				dataset.ItemCategories[i], dataset.HiddenItems[i])
 		})
 	case config.NeighborTypeAuto:
-		vectors = lo.Map(dataset.ItemFeatures, func(_ []lo.Tuple2[int32, float32], i int) search.Vector {
-			indices, _ := lo.Unzip2(dataset.ItemFeatures[i])
-			return NewDualDictionaryVector(indices, labelIDF, dataset.ItemFeedback[i], userIDF, dataset.ItemCategories[i], dataset.HiddenItems[i])
+		vectors = lo.Map(itemLabels, func(indices []int32, i int) search.Vector {
+			return NewDualDictionaryVector(indices, labelIDF, dataset.ItemFeedback[i], decaysAt(dataset.ItemDecay, i), userIDF,
+				dataset.ItemCategories[i], dataset.HiddenItems[i])
 		})
 	default:
 		return errors.NotImplementedf("item neighbor type `%v`", m.Config.Recommend.ItemNeighbors.NeighborType)
@@ -468,7 +546,7 @@ func (m *Master) findItemNeighborsIVF(dataset *ranking.DataSet, labelIDF, userID
 	index, recall = builder.Build(m.Config.Recommend.ItemNeighbors.IndexRecall,
 		m.Config.Recommend.ItemNeighbors.IndexFitEpoch,
 		true)
-	ItemNeighborIndexRecall.Set(float64(recall))
+	m.gauge(ItemNeighborIndexRecall).Set(float64(recall))
 	if err := m.CacheClient.Set(ctx, cache.String(cache.Key(cache.GlobalMeta, cache.ItemNeighborIndexRecall), encoding.FormatFloat32(recall))); err != nil {
 		return errors.Trace(err)
 	}
@@ -529,9 +607,9 @@ func (m *Master) findItemNeighborsIVF(dataset *ranking.DataSet, labelIDF, userID
 	if err != nil {
 		return errors.Trace(err)
 	}
-	UpdateItemNeighborsTotal.Set(updateItemCount.Load())
-	FindItemNeighborsSecondsVec.WithLabelValues("find_item_neighbors").Set(findNeighborSeconds.Load())
-	FindItemNeighborsSecondsVec.WithLabelValues("build_index").Set(buildIndexSeconds.Load())
+	m.gauge(UpdateItemNeighborsTotal).Set(updateItemCount.Load())
+	m.gaugeVec(FindItemNeighborsSecondsVec, "find_item_neighbors").Set(findNeighborSeconds.Load())
+	m.gaugeVec(FindItemNeighborsSecondsVec, "build_index").Set(buildIndexSeconds.Load())
 	return nil
 }
 
@@ -603,9 +681,7 @@ func (t *FindUserNeighborsTask) run(ctx context.Context, j *task.JobsAllocator)
 	itemIDF := make([]float32, dataset.ItemCount())
 	if t.Config.Recommend.UserNeighbors.NeighborType == config.NeighborTypeRelated ||
 		t.Config.Recommend.UserNeighbors.NeighborType == config.NeighborTypeAuto {
-		for _, feedbacks := range dataset.UserFeedback {
-			sort.Sort(sortutil.Int32Slice(feedbacks))
-		}
+		dataset.SortUserFeedback()
 		// inverse document frequency of items
 		for i := range dataset.ItemFeedback {
 			if dataset.UserCount() == len(dataset.ItemFeedback[i]) {
@@ -615,21 +691,19 @@ func (t *FindUserNeighborsTask) run(ctx context.Context, j *task.JobsAllocator)
 			}
 		}
 	}
-	labeledUsers := make([][]int32, dataset.NumUserLabels)
-	labelIDF := make([]float32, dataset.NumUserLabels)
+	userTransformer, _ := t.labelTransformers()
+	userLabels, numLabels := discretizeLabels(dataset.UserFeatures, dataset.UserLabelIndex, userTransformer)
+	labeledUsers := make([][]int32, numLabels)
+	labelIDF := make([]float32, numLabels)
 	if t.Config.Recommend.UserNeighbors.NeighborType == config.NeighborTypeSimilar ||
 		t.Config.Recommend.UserNeighbors.NeighborType == config.NeighborTypeAuto {
-		for i, userLabels := range dataset.UserFeatures {
-			sort.Slice(userLabels, func(i, j int) bool {
-				return userLabels[i].A < userLabels[j].A
-			})
-			for _, label := range userLabels {
-				labeledUsers[label.A] = append(labeledUsers[label.A], int32(i))
+		for i, labels := range userLabels {
+			for _, label := range labels {
+				labeledUsers[label] = append(labeledUsers[label], int32(i))
 			}
 		}
 		// inverse document frequency of labels
 		for i := range labeledUsers {
-			labeledUsers[i] = lo.Uniq(labeledUsers[i])
 			if dataset.UserCount() == len(labeledUsers[i]) {
 				labelIDF[i] = 1
 			} else {
@@ -641,9 +715,9 @@ func (t *FindUserNeighborsTask) run(ctx context.Context, j *task.JobsAllocator)
 	start := time.Now()
 	var err error
 	if t.Config.Recommend.UserNeighbors.EnableIndex {
-		err = t.findUserNeighborsIVF(newCtx, dataset, labelIDF, itemIDF, completed, j)
+		err = t.findUserNeighborsIVF(newCtx, dataset, userLabels, labelIDF, itemIDF, completed, j)
 	} else {
-		err = t.findUserNeighborsBruteForce(newCtx, dataset, labeledUsers, labelIDF, itemIDF, completed, j)
+		err = t.findUserNeighborsBruteForce(newCtx, dataset, userLabels, labeledUsers, labelIDF, itemIDF, completed, j)
 	}
 	searchTime := time.Since(start)
 
@@ -651,14 +725,14 @@ func (t *FindUserNeighborsTask) run(ctx context.Context, j *task.JobsAllocator)
 	if err != nil {
 		log.Logger().Error("failed to searching neighbors of users", zap.Error(err))
 		progress.Fail(newCtx, err)
-		FindUserNeighborsTotalSeconds.Set(0)
+		t.gauge(FindUserNeighborsTotalSeconds).Set(0)
 	} else {
 		if err := t.CacheClient.Set(ctx, cache.Time(cache.Key(cache.GlobalMeta, cache.LastUpdateUserNeighborsTime), time.Now())); err != nil {
 			log.Logger().Error("failed to set neighbors of users update time", zap.Error(err))
 		}
 		log.Logger().Info("complete searching neighbors of users",
 			zap.String("search_time", searchTime.String()))
-		FindUserNeighborsTotalSeconds.Set(time.Since(startTaskTime).Seconds())
+		t.gauge(FindUserNeighborsTotalSeconds).Set(time.Since(startTaskTime).Seconds())
 	}
 
 	t.lastNumUsers = numUsers
@@ -666,7 +740,7 @@ func (t *FindUserNeighborsTask) run(ctx context.Context, j *task.JobsAllocator)
 	return nil
 }
 
-func (m *Master) findUserNeighborsBruteForce(ctx context.Context, dataset *ranking.DataSet, labeledUsers [][]int32, labelIDF, itemIDF []float32, completed chan struct{}, j *task.JobsAllocator) error {
+func (m *Master) findUserNeighborsBruteForce(ctx context.Context, dataset *ranking.DataSet, userLabels, labeledUsers [][]int32, labelIDF, itemIDF []float32, completed chan struct{}, j *task.JobsAllocator) error {
 	var (
 		updateUserCount     atomic.Float64
 		findNeighborSeconds atomic.Float64
@@ -675,19 +749,13 @@ func (m *Master) findUserNeighborsBruteForce(ctx context.Context, dataset *ranki
 	var vectors VectorsInterface
 	switch m.Config.Recommend.UserNeighbors.NeighborType {
 	case config.NeighborTypeSimilar:
-		vectors = NewVectors(lo.Map(dataset.UserFeatures, func(features []lo.Tuple2[int32, float32], _ int) []int32 {
-			indices, _ := lo.Unzip2(features)
-			return indices
-		}), labeledUsers, labelIDF)
+		vectors = NewVectors(userLabels, labeledUsers, labelIDF)
 	case config.NeighborTypeRelated:
-		vectors = NewVectors(dataset.UserFeedback, dataset.ItemFeedback, itemIDF)
+		vectors = NewDecayedVectors(dataset.UserFeedback, dataset.UserDecay, dataset.ItemFeedback, itemIDF)
 	case config.NeighborTypeAuto:
 		vectors = NewDualVectors(
-			NewVectors(lo.Map(dataset.UserFeatures, func(features []lo.Tuple2[int32, float32], _ int) []int32 {
-				indices, _ := lo.Unzip2(features)
-				return indices
-			}), labeledUsers, labelIDF),
-			NewVectors(dataset.UserFeedback, dataset.ItemFeedback, itemIDF))
+			NewVectors(userLabels, labeledUsers, labelIDF),
+			NewDecayedVectors(dataset.UserFeedback, dataset.UserDecay, dataset.ItemFeedback, itemIDF))
 	default:
 		return errors.NotImplementedf("user neighbor type `%v`", m.Config.Recommend.UserNeighbors.NeighborType)
 	}
@@ -743,14 +811,14 @@ func (m *Master) findUserNeighborsBruteForce(ctx context.Context, dataset *ranki
 	if err != nil {
 		return errors.Trace(err)
 	}
-	UpdateUserNeighborsTotal.Set(updateUserCount.Load())
-	FindUserNeighborsSecondsVec.WithLabelValues("find_item_neighbors").Set(findNeighborSeconds.Load())
-	FindUserNeighborsSecondsVec.WithLabelValues("build_index").Set(0)
-	UserNeighborIndexRecall.Set(1)
+	m.gauge(UpdateUserNeighborsTotal).Set(updateUserCount.Load())
+	m.gaugeVec(FindUserNeighborsSecondsVec, "find_item_neighbors").Set(findNeighborSeconds.Load())
+	m.gaugeVec(FindUserNeighborsSecondsVec, "build_index").Set(0)
+	m.gauge(UserNeighborIndexRecall).Set(1)
 	return nil
 }
 
-func (m *Master) findUserNeighborsIVF(ctx context.Context, dataset *ranking.DataSet, labelIDF, itemIDF []float32, completed chan struct{}, j *task.JobsAllocator) error {
+func (m *Master) findUserNeighborsIVF(ctx context.Context, dataset *ranking.DataSet, userLabels [][]int32, labelIDF, itemIDF []float32, completed chan struct{}, j *task.JobsAllocator) error {
 	var (
 		updateUserCount     atomic.Float64
 		buildIndexSeconds   atomic.Float64
@@ -762,19 +830,17 @@ func (m *Master) findUserNeighborsIVF(ctx context.Context, dataset *ranking.Data
 	var vectors []search.Vector
 	switch m.Config.Recommend.UserNeighbors.NeighborType {
 	case config.NeighborTypeSimilar:
-		vectors = lo.Map(dataset.UserFeatures, func(features []lo.Tuple2[int32, float32], _ int) search.Vector {
-			indices, _ := lo.Unzip2(features)
+		vectors = lo.Map(userLabels, func(indices []int32, _ int) search.Vector {
 			return search.NewDictionaryVector(indices, labelIDF, nil, false)
 		})
 	case config.NeighborTypeRelated:
-		vectors = lo.Map(dataset.UserFeedback, func(indices []int32, _ int) search.Vector {
-			return search.NewDictionaryVector(indices, itemIDF, nil, false)
+		vectors = lo.Map(dataset.UserFeedback, func(indices []int32, i int) search.Vector {
+			return search.NewDecayedDictionaryVector(indices, decaysAt(dataset.UserDecay, i), itemIDF, nil, false)
 		})
 	case config.NeighborTypeAuto:
 		vectors = make([]search.Vector, dataset.UserCount())
 		for i := range vectors {
-			indices, _ := lo.Unzip2(dataset.UserFeatures[i])
-			vectors[i] = NewDualDictionaryVector(indices, labelIDF, dataset.UserFeedback[i], itemIDF, nil, false)
+			vectors[i] = NewDualDictionaryVector(userLabels[i], labelIDF, dataset.UserFeedback[i], decaysAt(dataset.UserDecay, i), itemIDF, nil, false)
 		}
 	default:
 		return errors.NotImplementedf("user neighbor type `%v`", m.Config.Recommend.UserNeighbors.NeighborType)
@@ -787,7 +853,7 @@ func (m *Master) findUserNeighborsIVF(ctx context.Context, dataset *ranking.Data
 		m.Config.Recommend.UserNeighbors.IndexRecall,
 		m.Config.Recommend.UserNeighbors.IndexFitEpoch,
 		true)
-	UserNeighborIndexRecall.Set(float64(recall))
+	m.gauge(UserNeighborIndexRecall).Set(float64(recall))
 	if err := m.CacheClient.Set(ctx, cache.String(cache.Key(cache.GlobalMeta, cache.UserNeighborIndexRecall), encoding.FormatFloat32(recall))); err != nil {
 		return errors.Trace(err)
 	}
@@ -835,17 +901,30 @@ func (m *Master) findUserNeighborsIVF(ctx context.Context, dataset *ranking.Data
 	if err != nil {
 		return errors.Trace(err)
 	}
-	UpdateUserNeighborsTotal.Set(updateUserCount.Load())
-	FindUserNeighborsSecondsVec.WithLabelValues("find_item_neighbors").Set(findNeighborSeconds.Load())
-	FindUserNeighborsSecondsVec.WithLabelValues("build_index").Set(buildIndexSeconds.Load())
+	m.gauge(UpdateUserNeighborsTotal).Set(updateUserCount.Load())
+	m.gaugeVec(FindUserNeighborsSecondsVec, "find_item_neighbors").Set(findNeighborSeconds.Load())
+	m.gaugeVec(FindUserNeighborsSecondsVec, "build_index").Set(buildIndexSeconds.Load())
 	return nil
 }
 
-func commonElements(a, b []int32, weights []float32) (float32, float32) {
+// labelTransformers returns transformers of numeric user labels and item labels, which have transformed labels of the
+// ranking dataset since they are built together with the click dataset.
+func (m *Master) labelTransformers() (userTransformer, itemTransformer *click.LabelTransformer) {
+	m.clickDataMutex.RLock()
+	defer m.clickDataMutex.RUnlock()
+	if m.clickTrainSet != nil {
+		userTransformer, itemTransformer = m.clickTrainSet.UserTransformer, m.clickTrainSet.ItemTransformer
+	}
+	return
+}
+
+// commonElements sums weights of common elements of a and b. Elements are decayed by decayA and decayB, which are
+// aligned with a and b. Elements aren't decayed if decays are nil.
+func commonElements(a, b []int32, decayA, decayB, weights []float32) (float32, float32) {
 	i, j, sum, count := 0, 0, float32(0), float32(0)
 	for i < len(a) && j < len(b) {
 		if a[i] == b[j] {
-			sum += weights[a[i]]
+			sum += weights[a[i]] * decayAt(decayA, i) * decayAt(decayB, j)
 			count++
 			i++
 			j++
@@ -858,14 +937,29 @@ func commonElements(a, b []int32, weights []float32) (float32, float32) {
 	return sum, count
 }
 
-func weightedSum(a []int32, weights []float32) float32 {
+func weightedSum(a []int32, decays, weights []float32) float32 {
 	var sum float32
-	for _, i := range a {
-		sum += weights[i]
+	for k, i := range a {
+		sum += weights[i] * decayAt(decays, k) * decayAt(decays, k)
 	}
 	return sum
 }
 
+func decayAt(decays []float32, k int) float32 {
+	if decays == nil {
+		return 1
+	}
+	return decays[k]
+}
+
+// decaysAt returns decays of the i-th feedback list, or nil if feedback isn't decayed.
+func decaysAt(decays [][]float32, i int) []float32 {
+	if i < len(decays) {
+		return decays[i]
+	}
+	return nil
+}
+
 // checkUserNeighborCacheTimeout checks if user neighbor cache stale.
 // 1. if cache is empty, stale.
 // 2. if modified time > update time, stale.
@@ -1002,10 +1096,12 @@ func (t *FitRankingModelTask) run(ctx context.Context, j *task.JobsAllocator) er
 	dataset := t.rankingTrainSet
 	numFeedback := dataset.Count()
 
+	// the pinned model is served until unpinned, while newly fitted models become candidates
 	var modelChanged bool
+	pinned := t.isModelPinned(blob.RankingModelType)
 	bestRankingName, bestRankingModel, bestRankingScore := t.rankingModelSearcher.GetBestModel()
 	t.rankingModelMutex.Lock()
-	if bestRankingModel != nil && !bestRankingModel.Invalid() &&
+	if !pinned && bestRankingModel != nil && !bestRankingModel.Invalid() &&
 		(bestRankingName != t.rankingModelName || bestRankingModel.GetParams().ToString() != t.RankingModel.GetParams().ToString()) &&
 		(bestRankingScore.NDCG > t.rankingScore.NDCG) {
 		// 1. best ranking model must have been found.
@@ -1033,20 +1129,54 @@ func (t *FitRankingModelTask) run(ctx context.Context, j *task.JobsAllocator) er
 
 	startFitTime := time.Now()
 	score := rankingModel.Fit(newCtx, t.rankingTrainSet, t.rankingTestSet, ranking.NewFitConfig().SetJobsAllocator(j))
-	CollaborativeFilteringFitSeconds.Set(time.Since(startFitTime).Seconds())
+	t.gauge(CollaborativeFilteringFitSeconds).Set(time.Since(startFitTime).Seconds())
+	if !pinned {
+		// keep factors barely changed by retraining, so that workers pull small and exact deltas
+		t.rankingModelMutex.RLock()
+		ranking.AlignModel(t.RankingModel, rankingModel)
+		t.rankingModelMutex.RUnlock()
+	}
+
+	// register ranking model
+	t.rankingModelMutex.RLock()
+	version := t.nextModelVersion(blob.RankingModelType, t.RankingModelVersion)
+	rankingModelName := t.rankingModelName
+	t.rankingModelMutex.RUnlock()
+	if t.modelRegistry != nil {
+		if err := t.modelRegistry.AddRankingModel(newRankingModelVersion(version, rankingModelName, rankingModel, score, dataset), rankingModel); err != nil {
+			log.Logger().Error("failed to register ranking model", zap.Error(err))
+		}
+	}
+	if pinned {
+		// evaluate the candidate model in shadow mode
+		if t.Config.Recommend.Collaborative.EnableShadowMode {
+			t.rankingModelMutex.Lock()
+			t.shadowRankingModelVersion = version
+			t.rankingModelMutex.Unlock()
+			t.notifyModelUpdated()
+		}
+		log.Logger().Info("fit candidate ranking model complete",
+			zap.String("version", encoding.Hex(version)),
+			zap.Bool("shadow_mode", t.Config.Recommend.Collaborative.EnableShadowMode))
+		t.lastNumFeedback = numFeedback
+		return nil
+	}
 
 	// update ranking model
 	t.rankingModelMutex.Lock()
+	t.prevRankingModel = t.RankingModel
+	t.prevRankingModelVersion = t.RankingModelVersion
 	t.RankingModel = rankingModel
-	t.RankingModelVersion++
+	t.RankingModelVersion = version
 	t.rankingScore = score
 	t.rankingModelMutex.Unlock()
+	t.notifyModelUpdated()
 	log.Logger().Info("fit ranking model complete",
 		zap.String("version", fmt.Sprintf("%x", t.RankingModelVersion)))
-	CollaborativeFilteringNDCG10.Set(float64(score.NDCG))
-	CollaborativeFilteringRecall10.Set(float64(score.Recall))
-	CollaborativeFilteringPrecision10.Set(float64(score.Precision))
-	MemoryInUseBytesVec.WithLabelValues("collaborative_filtering_model").Set(float64(t.RankingModel.Bytes()))
+	t.gauge(CollaborativeFilteringNDCG10).Set(float64(score.NDCG))
+	t.gauge(CollaborativeFilteringRecall10).Set(float64(score.Recall))
+	t.gauge(CollaborativeFilteringPrecision10).Set(float64(score.Precision))
+	t.gaugeVec(MemoryInUseBytesVec, "collaborative_filtering_model").Set(float64(t.RankingModel.Bytes()))
 	if err := t.CacheClient.Set(ctx, cache.Time(cache.Key(cache.GlobalMeta, cache.LastFitMatchingModelTime), time.Now())); err != nil {
 		log.Logger().Error("failed to write meta", zap.Error(err))
 	}
@@ -1120,9 +1250,11 @@ func (t *FitClickModelTask) run(ctx context.Context, j *task.JobsAllocator) erro
 		shouldFit = true
 	}
 
+	// the pinned model is served until unpinned, while newly fitted models become candidates
+	pinned := t.isModelPinned(blob.ClickModelType)
 	bestClickModel, bestClickScore := t.clickModelSearcher.GetBestModel()
 	t.clickModelMutex.Lock()
-	if bestClickModel != nil && !bestClickModel.Invalid() &&
+	if !pinned && bestClickModel != nil && !bestClickModel.Invalid() &&
 		bestClickModel.GetParams().ToString() != t.ClickModel.GetParams().ToString() &&
 		bestClickScore.Precision > t.clickScore.Precision {
 		// 1. best click model must have been found.
@@ -1147,20 +1279,64 @@ func (t *FitClickModelTask) run(ctx context.Context, j *task.JobsAllocator) erro
 	startFitTime := time.Now()
 	score := clickModel.Fit(newCtx, t.clickTrainSet, t.clickTestSet, click.NewFitConfig().
 		SetJobsAllocator(j))
-	RankingFitSeconds.Set(time.Since(startFitTime).Seconds())
+	t.gauge(RankingFitSeconds).Set(time.Since(startFitTime).Seconds())
+
+	// calibrate click-through rates on the validation set, the calibrator is shipped with the model
+	calibrator, diagnostics := click.Calibrate(clickModel, t.clickTestSet, t.Config.Recommend.Offline.ClickThroughCalibration)
+	click.SetCalibrator(clickModel, calibrator)
+	if calibrator != nil {
+		score.LogLoss = diagnostics.CalibratedLogLoss
+		score.BrierScore = diagnostics.CalibratedBrierScore
+	}
+	log.Logger().Info("calibrate click model",
+		zap.String("method", diagnostics.Method),
+		zap.Float32("log_loss", diagnostics.LogLoss),
+		zap.Float32("brier_score", diagnostics.BrierScore),
+		zap.Float32("calibrated_log_loss", diagnostics.CalibratedLogLoss),
+		zap.Float32("calibrated_brier_score", diagnostics.CalibratedBrierScore))
+
+	// register click model
+	t.clickModelMutex.RLock()
+	version := t.nextModelVersion(blob.ClickModelType, t.ClickModelVersion)
+	t.clickModelMutex.RUnlock()
+	if t.modelRegistry != nil {
+		if err := t.modelRegistry.AddClickModel(newClickModelVersion(version, clickModel, score, t.clickTrainSet), clickModel); err != nil {
+			log.Logger().Error("failed to register click model", zap.Error(err))
+		}
+	}
+	if pinned {
+		// evaluate the candidate model in shadow mode
+		if t.Config.Recommend.Offline.EnableClickThroughShadowMode {
+			t.clickModelMutex.Lock()
+			t.shadowClickModelVersion = version
+			t.clickModelMutex.Unlock()
+			t.notifyModelUpdated()
+		}
+		log.Logger().Info("fit candidate click model complete",
+			zap.String("version", encoding.Hex(version)),
+			zap.Bool("shadow_mode", t.Config.Recommend.Offline.EnableClickThroughShadowMode))
+		t.lastNumItems = numItems
+		t.lastNumUsers = numUsers
+		t.lastNumFeedback = numFeedback
+		return nil
+	}
 
 	// update match model
 	t.clickModelMutex.Lock()
 	t.ClickModel = clickModel
 	t.clickScore = score
-	t.ClickModelVersion++
+	t.clickDiagnostics = &diagnostics
+	t.ClickModelVersion = version
 	t.clickModelMutex.Unlock()
+	t.notifyModelUpdated()
 	log.Logger().Info("fit click model complete",
 		zap.String("version", fmt.Sprintf("%x", t.ClickModelVersion)))
-	RankingPrecision.Set(float64(score.Precision))
-	RankingRecall.Set(float64(score.Recall))
-	RankingAUC.Set(float64(score.AUC))
-	MemoryInUseBytesVec.WithLabelValues("ranking_model").Set(float64(sizeof.DeepSize(t.ClickModel)))
+	t.gauge(RankingPrecision).Set(float64(score.Precision))
+	t.gauge(RankingRecall).Set(float64(score.Recall))
+	t.gauge(RankingAUC).Set(float64(score.AUC))
+	t.gauge(RankingLogLoss).Set(float64(score.LogLoss))
+	t.gauge(RankingBrierScore).Set(float64(score.BrierScore))
+	t.gaugeVec(MemoryInUseBytesVec, "ranking_model").Set(float64(sizeof.DeepSize(t.ClickModel)))
 	if err := t.CacheClient.Set(ctx, cache.Time(cache.Key(cache.GlobalMeta, cache.LastFitRankingModelTime), time.Now())); err != nil {
 		log.Logger().Error("failed to write meta", zap.Error(err))
 	}
@@ -1188,6 +1364,209 @@ func (t *FitClickModelTask) run(ctx context.Context, j *task.JobsAllocator) erro
 	return nil
 }
 
+// FitSessionModelTask fits session model using time-ordered positive feedback. After model fitted, session model
+// version is increased.
+type FitSessionModelTask struct {
+	*Master
+	lastNumItems     int
+	lastNumSequences int
+}
+
+func NewFitSessionModelTask(m *Master) *FitSessionModelTask {
+	return &FitSessionModelTask{Master: m}
+}
+
+func (t *FitSessionModelTask) name() string {
+	return TaskFitSessionModel
+}
+
+func (t *FitSessionModelTask) priority() int {
+	return -t.sessionTrainSet.Count()
+}
+
+func (t *FitSessionModelTask) run(ctx context.Context, j *task.JobsAllocator) error {
+	newCtx, span := t.tracer.Start(ctx, "Fit Session Model", 1)
+	defer span.End()
+
+	log.Logger().Info("prepare to fit session model", zap.Int("n_jobs", t.Config.Master.NumJobs))
+	t.sessionDataMutex.RLock()
+	defer t.sessionDataMutex.RUnlock()
+	if t.sessionTrainSet == nil || t.sessionTrainSet.ItemCount() == 0 || t.sessionTrainSet.Count() == 0 {
+		log.Logger().Warn("empty session dataset",
+			zap.Strings("positive_feedback_type", t.Config.Recommend.DataSource.PositiveFeedbackTypes))
+		return nil
+	}
+	numItems := t.sessionTrainSet.ItemCount()
+	numSequences := t.sessionTrainSet.Count()
+	if numItems == t.lastNumItems && numSequences == t.lastNumSequences {
+		log.Logger().Info("nothing changed")
+		return nil
+	}
+
+	// training model
+	params := model.Params{
+		model.NFactors:  t.Config.Recommend.Session.NFactors,
+		model.NEpochs:   t.Config.Recommend.Session.NEpochs,
+		model.MaxLength: t.Config.Recommend.Session.MaxLength,
+		model.Lr:        t.Config.Recommend.Session.Lr,
+	}
+	fitConfig := ranking.NewFitConfig().SetJobsAllocator(j)
+	var (
+		sessionModel *session.SASRec
+		score        ranking.Score
+	)
+	if t.Config.Recommend.Session.EnableModelSearch {
+		sessionModel, score = session.SearchCV(newCtx, params, t.sessionTrainSet, t.sessionTestSet,
+			t.Config.Recommend.Collaborative.EnableModelSizeSearch, model.SearchOptions{
+				Strategy:      t.Config.Recommend.Collaborative.ModelSearchStrategy,
+				NumTrials:     t.Config.Recommend.Collaborative.ModelSearchTrials,
+				NumEpochs:     t.Config.Recommend.Session.NEpochs,
+				EarlyStopping: t.Config.Recommend.Collaborative.EnableModelSearchEarlyStopping,
+			}, fitConfig)
+	}
+	if sessionModel == nil {
+		sessionModel = session.NewSASRec(params)
+		score = sessionModel.Fit(newCtx, t.sessionTrainSet, t.sessionTestSet, fitConfig)
+	}
+
+	// update session model
+	_, version := t.LoadSessionModel()
+	t.StoreSessionModel(sessionModel, version+1)
+	t.notifyModelUpdated()
+	log.Logger().Info("fit session model complete",
+		zap.String("version", fmt.Sprintf("%x", version+1)),
+		zap.Float32("NDCG", score.NDCG))
+
+	t.lastNumItems = numItems
+	t.lastNumSequences = numSequences
+	return nil
+}
+
+// FitTwoTowerModelTask fits two-tower model using labels, categories, image embeddings and positive feedback.
+// After model fitted, two-tower model version is increased.
+type FitTwoTowerModelTask struct {
+	*Master
+	lastNumItems    int
+	lastNumUsers    int
+	lastNumFeedback int
+}
+
+func NewFitTwoTowerModelTask(m *Master) *FitTwoTowerModelTask {
+	return &FitTwoTowerModelTask{Master: m}
+}
+
+func (t *FitTwoTowerModelTask) name() string {
+	return TaskFitTwoTowerModel
+}
+
+func (t *FitTwoTowerModelTask) priority() int {
+	return -t.rankingTrainSet.ItemCount() * t.rankingTrainSet.UserCount()
+}
+
+func (t *FitTwoTowerModelTask) run(ctx context.Context, j *task.JobsAllocator) error {
+	if !t.Config.Recommend.Offline.EnableTwoTowerRecommend {
+		return nil
+	}
+	newCtx, span := t.tracer.Start(ctx, "Fit Two-Tower Model", 1)
+	defer span.End()
+
+	log.Logger().Info("prepare to fit two-tower model", zap.Int("n_jobs", t.Config.Master.NumJobs))
+	t.rankingDataMutex.RLock()
+	defer t.rankingDataMutex.RUnlock()
+	dataset := t.rankingTrainSet
+	if dataset == nil || dataset.UserCount() == 0 || dataset.ItemCount() == 0 || dataset.Count() == 0 {
+		log.Logger().Warn("empty ranking dataset",
+			zap.Strings("positive_feedback_type", t.Config.Recommend.DataSource.PositiveFeedbackTypes))
+		return nil
+	}
+	numItems := dataset.ItemCount()
+	numUsers := dataset.UserCount()
+	numFeedback := dataset.Count()
+	if numItems == t.lastNumItems && numUsers == t.lastNumUsers && numFeedback == t.lastNumFeedback {
+		log.Logger().Info("nothing changed")
+		return nil
+	}
+
+	// build inputs of towers
+	users := make([]nn.TowerInput, numUsers)
+	for userIndex := range users {
+		var labels []click.Feature
+		if dataset.UserLabelIndex != nil && userIndex < len(dataset.UserFeatures) {
+			for _, feature := range dataset.UserFeatures[userIndex] {
+				labels = append(labels, click.Feature{Name: dataset.UserLabelIndex.ToName(feature.A), Value: feature.B})
+			}
+		}
+		history := make([]string, len(dataset.UserFeedback[userIndex]))
+		for i, itemIndex := range dataset.UserFeedback[userIndex] {
+			history[i] = dataset.ItemIndex.ToName(itemIndex)
+		}
+		users[userIndex] = logics.NewUserTowerInput(dataset.UserIndex.ToName(int32(userIndex)), labels, history)
+	}
+	embeddingDim := 0
+	items := make([]nn.TowerInput, numItems)
+	for itemIndex := range items {
+		var labels []click.Feature
+		if dataset.ItemLabelIndex != nil && itemIndex < len(dataset.ItemFeatures) {
+			for _, feature := range dataset.ItemFeatures[itemIndex] {
+				labels = append(labels, click.Feature{Name: dataset.ItemLabelIndex.ToName(feature.A), Value: feature.B})
+			}
+		}
+		var categories []string
+		if itemIndex < len(dataset.ItemCategories) {
+			categories = dataset.ItemCategories[itemIndex]
+		}
+		var embedding []float32
+		if itemIndex < len(dataset.ItemEmbeddings) {
+			embedding = dataset.ItemEmbeddings[itemIndex]
+			embeddingDim = max(embeddingDim, len(embedding))
+		}
+		items[itemIndex] = logics.NewItemTowerInput(dataset.ItemIndex.ToName(int32(itemIndex)), labels, categories, embedding)
+	}
+
+	feedbackUsers := make([]int32, dataset.FeedbackUsers.Len())
+	feedbackItems := make([]int32, dataset.FeedbackItems.Len())
+	for i := range feedbackUsers {
+		feedbackUsers[i] = dataset.FeedbackUsers.Get(i)
+		feedbackItems[i] = dataset.FeedbackItems.Get(i)
+	}
+
+	// labels of the ranking dataset are transformed by transformers of the click dataset loaded together
+	userTransformer, itemTransformer := t.labelTransformers()
+
+	// training model
+	twoTowerModel := nn.NewTwoTower(model.Params{model.EmbeddingDim: embeddingDim})
+	loss := twoTowerModel.Fit(newCtx, users, items, feedbackUsers, feedbackItems)
+
+	// update two-tower model
+	_, version := t.LoadTwoTowerModel()
+	t.StoreTwoTowerModel(twoTowerModel, version+1, userTransformer, itemTransformer)
+	t.notifyModelUpdated()
+	log.Logger().Info("fit two-tower model complete",
+		zap.String("version", fmt.Sprintf("%x", version+1)),
+		zap.Float32("loss", loss))
+
+	t.lastNumItems = numItems
+	t.lastNumUsers = numUsers
+	t.lastNumFeedback = numFeedback
+	return nil
+}
+
+// Names of blobs storing trials of model searches.
+const (
+	RankingSearchHistory = "ranking-search-history"
+	ClickSearchHistory   = "click-search-history"
+)
+
+// saveSearchHistory saves trials of model searches to the blob store.
+func (m *Master) saveSearchHistory(name string, history *model.SearchHistory) {
+	if m.blobStore == nil {
+		return
+	}
+	if err := m.blobStore.WriteBlob(name, history.Marshal); err != nil {
+		log.Logger().Error("failed to save search history", zap.String("name", name), zap.Error(err))
+	}
+}
+
 // SearchRankingModelTask searches best hyper-parameters for ranking models.
 // It requires read lock on the ranking dataset.
 type SearchRankingModelTask struct {
@@ -1239,9 +1618,10 @@ func (t *SearchRankingModelTask) run(ctx context.Context, j *task.JobsAllocator)
 		log.Logger().Error("failed to search collaborative filtering model", zap.Error(err))
 		return nil
 	}
-	CollaborativeFilteringSearchSeconds.Set(time.Since(startTime).Seconds())
+	t.gauge(CollaborativeFilteringSearchSeconds).Set(time.Since(startTime).Seconds())
+	t.saveSearchHistory(RankingSearchHistory, t.rankingModelSearcher.GetHistory())
 	_, _, bestScore := t.rankingModelSearcher.GetBestModel()
-	CollaborativeFilteringSearchPrecision10.Set(float64(bestScore.Precision))
+	t.gauge(CollaborativeFilteringSearchPrecision10).Set(float64(bestScore.Precision))
 
 	t.lastNumItems = numItems
 	t.lastNumUsers = numUsers
@@ -1299,9 +1679,10 @@ func (t *SearchClickModelTask) run(ctx context.Context, j *task.JobsAllocator) e
 		log.Logger().Error("failed to search ranking model", zap.Error(err))
 		return nil
 	}
-	RankingSearchSeconds.Set(time.Since(startTime).Seconds())
+	t.gauge(RankingSearchSeconds).Set(time.Since(startTime).Seconds())
+	t.saveSearchHistory(ClickSearchHistory, t.clickModelSearcher.GetHistory())
 	_, bestScore := t.clickModelSearcher.GetBestModel()
-	RankingSearchPrecision.Set(float64(bestScore.Precision))
+	t.gauge(RankingSearchPrecision).Set(float64(bestScore.Precision))
 
 	t.lastNumItems = numItems
 	t.lastNumUsers = numUsers
@@ -1342,8 +1723,9 @@ func (t *CacheGarbageCollectionTask) run(ctx context.Context, j *task.JobsAlloca
 		scanCount++
 		switch splits[0] {
 		case cache.UserNeighbors, cache.UserNeighborsDigest,
-			cache.OfflineRecommend, cache.OfflineRecommendDigest, cache.CollaborativeRecommend,
-			cache.LastModifyUserTime, cache.LastUpdateUserNeighborsTime, cache.LastUpdateUserRecommendTime:
+			cache.OfflineRecommend, cache.OfflineRecommendDigest, cache.OfflineRecommendPropensity,
+			cache.CollaborativeRecommend, cache.LastModifyUserTime, cache.LastUpdateUserNeighborsTime, cache.LastUpdateUserRecommendTime,
+			cache.Onboarding:
 			userId := splits[1]
 			// check user in dataset
 			if t.rankingTrainSet != nil && t.rankingTrainSet.UserIndex.ToNumber(userId) != base.NotId {
@@ -1359,8 +1741,9 @@ func (t *CacheGarbageCollectionTask) run(ctx context.Context, j *task.JobsAlloca
 			}
 			// delete user cache
 			switch splits[0] {
-			case cache.UserNeighborsDigest, cache.OfflineRecommendDigest,
-				cache.LastModifyUserTime, cache.LastUpdateUserNeighborsTime, cache.LastUpdateUserRecommendTime:
+			case cache.UserNeighborsDigest, cache.OfflineRecommendDigest, cache.OfflineRecommendPropensity,
+				cache.LastModifyUserTime, cache.LastUpdateUserNeighborsTime, cache.LastUpdateUserRecommendTime,
+				cache.Onboarding:
 				err = t.CacheClient.Delete(ctx, s)
 			}
 			if err != nil {
@@ -1390,15 +1773,53 @@ func (t *CacheGarbageCollectionTask) run(ctx context.Context, j *task.JobsAlloca
 				return errors.Trace(err)
 			}
 			reclaimCount++
+		case cache.Decks:
+			// delete expired decks
+			value, err := t.CacheClient.Get(ctx, s).String()
+			if err != nil {
+				return errors.Trace(err)
+			}
+			var deck server.Deck
+			if err = json.Unmarshal([]byte(value), &deck); err == nil && !deck.Expired() {
+				return nil
+			}
+			if err = t.CacheClient.Delete(ctx, s); err != nil {
+				return errors.Trace(err)
+			}
+			reclaimCount++
 		}
 		return nil
 	})
-	CacheScannedTotal.Set(float64(scanCount))
-	CacheReclaimedTotal.Set(float64(reclaimCount))
-	CacheScannedSeconds.Set(time.Since(start).Seconds())
+	t.gauge(CacheScannedTotal).Set(float64(scanCount))
+	t.gauge(CacheReclaimedTotal).Set(float64(reclaimCount))
+	t.gauge(CacheScannedSeconds).Set(time.Since(start).Seconds())
 	return errors.Trace(err)
 }
 
+// loadImpressions pulls impressions in the evaluation window and inserts them to the evaluator. Impressions of unknown
+// users or items are ignored.
+func (m *Master) loadImpressions(ctx context.Context, evaluator *OnlineEvaluator, rankingDataset *ranking.DataSet) error {
+	beginTime := evaluator.TruncatedDateToday.Add(-time.Hour * 24 * time.Duration(evaluator.EvaluateDays-1))
+	impressionChan, errChan := m.DataClient.GetImpressionStream(ctx, batchSize, &beginTime, nil)
+	for impressions := range impressionChan {
+		for _, impression := range impressions {
+			userIndex := rankingDataset.UserIndex.ToNumber(impression.UserId)
+			if userIndex == base.NotId {
+				continue
+			}
+			itemIndex := rankingDataset.ItemIndex.ToNumber(impression.ItemId)
+			if itemIndex == base.NotId {
+				continue
+			}
+			evaluator.Impress(impression.Source, userIndex, itemIndex, impression.Timestamp)
+		}
+	}
+	if err := <-errChan; err != nil {
+		return errors.Trace(err)
+	}
+	return nil
+}
+
 // LoadDataFromDatabase loads dataset from data store.
 func (m *Master) LoadDataFromDatabase(
 	ctx context.Context,
@@ -1407,19 +1828,19 @@ func (m *Master) LoadDataFromDatabase(
 	itemTTL, positiveFeedbackTTL uint,
 	evaluator *OnlineEvaluator,
 	nonPersonalizedRecommenders []*logics.NonPersonalized,
-) (rankingDataset *ranking.DataSet, clickDataset *click.Dataset, err error) {
+) (rankingDataset *ranking.DataSet, clickDataset *click.Dataset, sessionDataset *session.DataSet, err error) {
 	// Estimate the number of users, items, and feedbacks
 	estimatedNumUsers, err := m.DataClient.CountUsers(context.Background())
 	if err != nil {
-		return nil, nil, errors.Trace(err)
+		return nil, nil, nil, errors.Trace(err)
 	}
 	estimatedNumItems, err := m.DataClient.CountItems(context.Background())
 	if err != nil {
-		return nil, nil, errors.Trace(err)
+		return nil, nil, nil, errors.Trace(err)
 	}
 	estimatedNumFeedbacks, err := m.DataClient.CountFeedback(context.Background())
 	if err != nil {
-		return nil, nil, errors.Trace(err)
+		return nil, nil, nil, errors.Trace(err)
 	}
 
 	newCtx, span := progress.Start(ctx, "LoadDataFromDatabase",
@@ -1441,12 +1862,45 @@ func (m *Master) LoadDataFromDatabase(
 	if m.Config.Recommend.Popular.PopularWindow > 0 {
 		timeWindowLimit = time.Now().Add(-m.Config.Recommend.Popular.PopularWindow)
 	}
+	// setup time decay
+	decay := m.Config.Recommend.DataSource.Decay
+	decayTime := *m.Config.Now()
 	rankingDataset = ranking.NewMapIndexDataset()
 
+	// numeric labels are indexed once their statistics are computed
+	numericTransforms := lo.Map(m.Config.Recommend.DataSource.NumericLabels, func(c config.NumericLabelConfig, _ int) click.NumericTransform {
+		return click.NumericTransform{Label: c.Name, Method: c.Transform, NumBuckets: c.NumBuckets}
+	})
+
 	// STEP 1: pull users
 	userLabelCount := make(map[string]int)
-	userLabelFirst := make(map[string]int32)
+	userLabelFirst := make(map[string]lo.Tuple2[int32, float32])
 	userLabelIndex := base.NewMapIndex()
+	userTransformerBuilder := click.NewLabelTransformerBuilder(numericTransforms)
+	var userNumericFeatures []lo.Tuple2[int32, click.Feature]
+	addUserFeature := func(userIndex int32, feature click.Feature) {
+		userLabelCount[feature.Name]++
+		// Memorize the first occurrence.
+		if userLabelCount[feature.Name] == 1 {
+			userLabelFirst[feature.Name] = lo.Tuple2[int32, float32]{A: userIndex, B: feature.Value}
+		}
+		// Add the label to the index in second occurrence.
+		if userLabelCount[feature.Name] == 2 {
+			userLabelIndex.Add(feature.Name)
+			first := userLabelFirst[feature.Name]
+			rankingDataset.UserFeatures[first.A] = append(rankingDataset.UserFeatures[first.A], lo.Tuple2[int32, float32]{
+				A: userLabelIndex.ToNumber(feature.Name),
+				B: first.B,
+			})
+		}
+		// Add the label to the user.
+		if userLabelCount[feature.Name] > 1 {
+			rankingDataset.UserFeatures[userIndex] = append(rankingDataset.UserFeatures[userIndex], lo.Tuple2[int32, float32]{
+				A: userLabelIndex.ToNumber(feature.Name),
+				B: feature.Value,
+			})
+		}
+	}
 	start := time.Now()
 	userChan, errChan := database.GetUserStream(newCtx, batchSize)
 	for users := range userChan {
@@ -1460,46 +1914,61 @@ func (m *Master) LoadDataFromDatabase(
 			rankingDataset.NumUserLabelUsed += len(features)
 			rankingDataset.UserFeatures[userIndex] = make([]lo.Tuple2[int32, float32], 0, len(features))
 			for _, feature := range features {
-				userLabelCount[feature.Name]++
-				// Memorize the first occurrence.
-				if userLabelCount[feature.Name] == 1 {
-					userLabelFirst[feature.Name] = userIndex
-				}
-				// Add the label to the index in second occurrence.
-				if userLabelCount[feature.Name] == 2 {
-					userLabelIndex.Add(feature.Name)
-					firstUserIndex := userLabelFirst[feature.Name]
-					rankingDataset.UserFeatures[firstUserIndex] = append(rankingDataset.UserFeatures[firstUserIndex], lo.Tuple2[int32, float32]{
-						A: userLabelIndex.ToNumber(feature.Name),
-						B: feature.Value,
-					})
-				}
-				// Add the label to the user.
-				if userLabelCount[feature.Name] > 1 {
-					rankingDataset.UserFeatures[userIndex] = append(rankingDataset.UserFeatures[userIndex], lo.Tuple2[int32, float32]{
-						A: userLabelIndex.ToNumber(feature.Name),
-						B: feature.Value,
-					})
+				if userTransformerBuilder.IsNumeric(feature) {
+					userTransformerBuilder.Add(feature)
+					userNumericFeatures = append(userNumericFeatures, lo.Tuple2[int32, click.Feature]{A: userIndex, B: feature})
+				} else {
+					addUserFeature(userIndex, feature)
 				}
 			}
 		}
 		span.Add(len(users))
 	}
 	if err = <-errChan; err != nil {
-		return nil, nil, errors.Trace(err)
+		return nil, nil, nil, errors.Trace(err)
+	}
+	userTransformer := userTransformerBuilder.Build()
+	for _, feature := range userNumericFeatures {
+		addUserFeature(feature.A, userTransformer.TransformFeature(feature.B))
 	}
 	rankingDataset.NumUserLabels = userLabelIndex.Len()
+	rankingDataset.UserLabelIndex = userLabelIndex
 	log.Logger().Debug("pulled users from database",
 		zap.Int("n_users", rankingDataset.UserCount()),
 		zap.Int32("n_user_labels", userLabelIndex.Len()),
 		zap.Duration("used_time", time.Since(start)))
-	LoadDatasetStepSecondsVec.WithLabelValues("load_users").Set(time.Since(start).Seconds())
+	m.gaugeVec(LoadDatasetStepSecondsVec, "load_users").Set(time.Since(start).Seconds())
 
 	// STEP 2: pull items
 	var items []data.Item
 	itemLabelCount := make(map[string]int)
-	itemLabelFirst := make(map[string]int32)
+	itemLabelFirst := make(map[string]lo.Tuple2[int32, float32])
 	itemLabelIndex := base.NewMapIndex()
+	itemTransformerBuilder := click.NewLabelTransformerBuilder(numericTransforms)
+	var itemNumericFeatures []lo.Tuple2[int32, click.Feature]
+	addItemFeature := func(itemIndex int32, feature click.Feature) {
+		itemLabelCount[feature.Name]++
+		// Memorize the first occurrence.
+		if itemLabelCount[feature.Name] == 1 {
+			itemLabelFirst[feature.Name] = lo.Tuple2[int32, float32]{A: itemIndex, B: feature.Value}
+		}
+		// Add the label to the index in second occurrence.
+		if itemLabelCount[feature.Name] == 2 {
+			itemLabelIndex.Add(feature.Name)
+			first := itemLabelFirst[feature.Name]
+			rankingDataset.ItemFeatures[first.A] = append(rankingDataset.ItemFeatures[first.A], lo.Tuple2[int32, float32]{
+				A: itemLabelIndex.ToNumber(feature.Name),
+				B: first.B,
+			})
+		}
+		// Add the label to the item.
+		if itemLabelCount[feature.Name] > 1 {
+			rankingDataset.ItemFeatures[itemIndex] = append(rankingDataset.ItemFeatures[itemIndex], lo.Tuple2[int32, float32]{
+				A: itemLabelIndex.ToNumber(feature.Name),
+				B: feature.Value,
+			})
+		}
+	}
 	start = time.Now()
 	itemChan, errChan := database.GetItemStream(newCtx, batchSize, itemTimeLimit)
 	for batchItems := range itemChan {
@@ -1511,49 +1980,45 @@ func (m *Master) LoadDataFromDatabase(
 				rankingDataset.ItemFeatures = append(rankingDataset.ItemFeatures, nil)
 				rankingDataset.HiddenItems = append(rankingDataset.HiddenItems, false)
 				rankingDataset.ItemCategories = append(rankingDataset.ItemCategories, item.Categories)
+				rankingDataset.ItemEmbeddings = append(rankingDataset.ItemEmbeddings, nil)
 				rankingDataset.CategorySet.Append(item.Categories...)
 			}
+			rankingDataset.ItemEmbeddings[itemIndex] = logics.ImageEmbedding(item.Labels,
+				m.Config.Recommend.ImageEmbeddings.EmbeddingLabel, m.Config.Recommend.ImageEmbeddings.EmbeddingDim)
 			features := click.ConvertLabelsToFeatures(item.Labels)
 			rankingDataset.NumItemLabelUsed += len(features)
 			rankingDataset.ItemFeatures[itemIndex] = make([]lo.Tuple2[int32, float32], 0, len(features))
 			for _, feature := range features {
-				itemLabelCount[feature.Name]++
-				// Memorize the first occurrence.
-				if itemLabelCount[feature.Name] == 1 {
-					itemLabelFirst[feature.Name] = itemIndex
-				}
-				// Add the label to the index in second occurrence.
-				if itemLabelCount[feature.Name] == 2 {
-					itemLabelIndex.Add(feature.Name)
-					firstItemIndex := itemLabelFirst[feature.Name]
-					rankingDataset.ItemFeatures[firstItemIndex] = append(rankingDataset.ItemFeatures[firstItemIndex], lo.Tuple2[int32, float32]{
-						A: itemLabelIndex.ToNumber(feature.Name),
-						B: feature.Value,
-					})
-				}
-				// Add the label to the item.
-				if itemLabelCount[feature.Name] > 1 {
-					rankingDataset.ItemFeatures[itemIndex] = append(rankingDataset.ItemFeatures[itemIndex], lo.Tuple2[int32, float32]{
-						A: itemLabelIndex.ToNumber(feature.Name),
-						B: feature.Value,
-					})
+				if itemTransformerBuilder.IsNumeric(feature) {
+					itemTransformerBuilder.Add(feature)
+					itemNumericFeatures = append(itemNumericFeatures, lo.Tuple2[int32, click.Feature]{A: itemIndex, B: feature})
+				} else {
+					addItemFeature(itemIndex, feature)
 				}
 			}
-			if item.IsHidden { // set hidden flag
+			if !item.IsVisible() { // set hidden flag
 				rankingDataset.HiddenItems[itemIndex] = true
 			}
+			if listed, sold, ok := item.TimeToSell(); ok {
+				evaluator.Sell(listed, sold)
+			}
 		}
 		span.Add(len(batchItems))
 	}
 	if err = <-errChan; err != nil {
-		return nil, nil, errors.Trace(err)
+		return nil, nil, nil, errors.Trace(err)
+	}
+	itemTransformer := itemTransformerBuilder.Build()
+	for _, feature := range itemNumericFeatures {
+		addItemFeature(feature.A, itemTransformer.TransformFeature(feature.B))
 	}
 	rankingDataset.NumItemLabels = itemLabelIndex.Len()
+	rankingDataset.ItemLabelIndex = itemLabelIndex
 	log.Logger().Debug("pulled items from database",
 		zap.Int("n_items", rankingDataset.ItemCount()),
 		zap.Int32("n_item_labels", itemLabelIndex.Len()),
 		zap.Duration("used_time", time.Since(start)))
-	LoadDatasetStepSecondsVec.WithLabelValues("load_items").Set(time.Since(start).Seconds())
+	m.gaugeVec(LoadDatasetStepSecondsVec, "load_items").Set(time.Since(start).Seconds())
 
 	// create positive set
 	popularCount := make([]int32, rankingDataset.ItemCount())
@@ -1561,6 +2026,40 @@ func (m *Master) LoadDataFromDatabase(
 	for i := range positiveSet {
 		positiveSet[i] = mapset.NewSet[int32]()
 	}
+	// create time-ordered positive feedback of users
+	userSequences := make([][]lo.Tuple2[int32, time.Time], rankingDataset.UserCount())
+	// create decayed weights of feedback
+	var (
+		positiveWeight []map[int32]float32
+		negativeWeight []map[int32]float32
+	)
+	if decay.Enabled() {
+		positiveWeight = make([]map[int32]float32, rankingDataset.UserCount())
+		negativeWeight = make([]map[int32]float32, rankingDataset.UserCount())
+		for i := range positiveWeight {
+			positiveWeight[i] = make(map[int32]float32)
+			negativeWeight[i] = make(map[int32]float32)
+		}
+	}
+
+	// create context features of feedback, positive feedback takes precedence over read feedback
+	ctxLabelIndex := base.NewMapIndex()
+	sampleContext := make([]map[int32][]lo.Tuple2[int32, float32], rankingDataset.UserCount())
+	encodeContext := func(userIndex, itemIndex int32, context map[string]string) {
+		features := click.ConvertContextToFeatures(context)
+		if len(features) == 0 {
+			return
+		}
+		encoded := make([]lo.Tuple2[int32, float32], 0, len(features))
+		for _, feature := range features {
+			ctxLabelIndex.Add(feature.Name)
+			encoded = append(encoded, lo.Tuple2[int32, float32]{A: ctxLabelIndex.ToNumber(feature.Name), B: feature.Value})
+		}
+		if sampleContext[userIndex] == nil {
+			sampleContext[userIndex] = make(map[int32][]lo.Tuple2[int32, float32])
+		}
+		sampleContext[userIndex][itemIndex] = encoded
+	}
 
 	// split item groups
 	sort.Slice(items, func(i, j int) bool {
@@ -1600,13 +2099,24 @@ func (m *Master) LoadDataFromDatabase(
 				mu.Lock()
 				posFeedbackCount++
 				// insert feedback to ranking dataset
-				rankingDataset.AddFeedback(f.UserId, f.ItemId, false)
+				weight := float32(decay.Weight(f.Timestamp, decayTime))
+				confidence := float32(m.Config.Recommend.DataSource.Confidence(f.FeedbackType, f.Value))
+				if decay.Enabled() {
+					rankingDataset.AddDecayedFeedback(f.UserId, f.ItemId, confidence*weight, weight, false)
+					positiveWeight[userIndex][itemIndex] = max(positiveWeight[userIndex][itemIndex], weight)
+				} else {
+					rankingDataset.AddWeightedFeedback(f.UserId, f.ItemId, confidence, false)
+				}
 				// insert feedback to popularity counter
 				if f.Timestamp.After(timeWindowLimit) && !rankingDataset.HiddenItems[itemIndex] {
 					popularCount[itemIndex]++
 				}
 				// insert feedback to evaluator
 				evaluator.Positive(f.FeedbackType, userIndex, itemIndex, f.Timestamp)
+				// insert feedback to session dataset
+				userSequences[userIndex] = append(userSequences[userIndex], lo.Tuple2[int32, time.Time]{A: itemIndex, B: f.Timestamp})
+				// insert context of feedback
+				encodeContext(userIndex, itemIndex, f.Context)
 				mu.Unlock()
 
 				// append item feedback
@@ -1651,12 +2161,12 @@ func (m *Master) LoadDataFromDatabase(
 		return nil
 	})
 	if err != nil {
-		return nil, nil, errors.Trace(err)
+		return nil, nil, nil, errors.Trace(err)
 	}
 	log.Logger().Debug("pulled positive feedback from database",
 		zap.Int("n_positive_feedback", posFeedbackCount),
 		zap.Duration("used_time", time.Since(start)))
-	LoadDatasetStepSecondsVec.WithLabelValues("load_positive_feedback").Set(time.Since(start).Seconds())
+	m.gaugeVec(LoadDatasetStepSecondsVec, "load_positive_feedback").Set(time.Since(start).Seconds())
 
 	// create negative set
 	negativeSet := make([]mapset.Set[int32], rankingDataset.UserCount())
@@ -1684,13 +2194,20 @@ func (m *Master) LoadDataFromDatabase(
 				if itemIndex == base.NotId {
 					continue
 				}
-				if !positiveSet[userIndex].Contains(itemIndex) {
+				isPositive := positiveSet[userIndex].Contains(itemIndex)
+				if !isPositive {
 					negativeSet[userIndex].Add(itemIndex)
 				}
 
 				mu.Lock()
 				negativeFeedbackCount++
+				if !isPositive {
+					encodeContext(userIndex, itemIndex, f.Context)
+				}
 				evaluator.Read(userIndex, itemIndex, f.Timestamp)
+				if decay.Enabled() {
+					negativeWeight[userIndex][itemIndex] = max(negativeWeight[userIndex][itemIndex], float32(decay.Weight(f.Timestamp, decayTime)))
+				}
 				mu.Unlock()
 			}
 			span.Add(len(feedback))
@@ -1701,12 +2218,12 @@ func (m *Master) LoadDataFromDatabase(
 		return nil
 	})
 	if err != nil {
-		return nil, nil, errors.Trace(err)
+		return nil, nil, nil, errors.Trace(err)
 	}
 	log.Logger().Debug("pulled negative feedback from database",
 		zap.Int("n_negative_feedback", int(negativeFeedbackCount)),
 		zap.Duration("used_time", time.Since(start)))
-	LoadDatasetStepSecondsVec.WithLabelValues("load_negative_feedback").Set(time.Since(start).Seconds())
+	m.gaugeVec(LoadDatasetStepSecondsVec, "load_negative_feedback").Set(time.Since(start).Seconds())
 
 	// STEP 5: create click dataset
 	start = time.Now()
@@ -1715,10 +2232,14 @@ func (m *Master) LoadDataFromDatabase(
 	unifiedIndex.UserIndex = rankingDataset.UserIndex
 	unifiedIndex.ItemLabelIndex = itemLabelIndex
 	unifiedIndex.UserLabelIndex = userLabelIndex
+	unifiedIndex.CtxLabelIndex = ctxLabelIndex
+	hasContext := ctxLabelIndex.Len() > 0
 	clickDataset = &click.Dataset{
-		Index:        unifiedIndex.Build(),
-		UserFeatures: rankingDataset.UserFeatures,
-		ItemFeatures: rankingDataset.ItemFeatures,
+		Index:           unifiedIndex.Build(),
+		UserFeatures:    rankingDataset.UserFeatures,
+		ItemFeatures:    rankingDataset.ItemFeatures,
+		UserTransformer: userTransformer,
+		ItemTransformer: itemTransformer,
 	}
 	for userIndex := range positiveSet {
 		if positiveSet[userIndex].Cardinality() == 0 || negativeSet[userIndex].Cardinality() == 0 {
@@ -1733,6 +2254,12 @@ func (m *Master) LoadDataFromDatabase(
 			clickDataset.Items.Append(itemIndex)
 			clickDataset.Target.Append(1)
 			clickDataset.PositiveCount++
+			if hasContext {
+				clickDataset.ContextFeatures = append(clickDataset.ContextFeatures, sampleContext[userIndex][itemIndex])
+			}
+			if decay.Enabled() {
+				clickDataset.Weight.Append(positiveWeight[userIndex][itemIndex])
+			}
 		}
 		// insert negative feedback
 		for _, itemIndex := range negativeSet[userIndex].ToSlice() {
@@ -1740,15 +2267,58 @@ func (m *Master) LoadDataFromDatabase(
 			clickDataset.Items.Append(itemIndex)
 			clickDataset.Target.Append(-1)
 			clickDataset.NegativeCount++
+			if hasContext {
+				clickDataset.ContextFeatures = append(clickDataset.ContextFeatures, sampleContext[userIndex][itemIndex])
+			}
+			if decay.Enabled() {
+				clickDataset.Weight.Append(negativeWeight[userIndex][itemIndex])
+			}
 		}
 		// release positive set and negative set
 		positiveSet[userIndex] = nil
 		negativeSet[userIndex] = nil
+		sampleContext[userIndex] = nil
+		if decay.Enabled() {
+			positiveWeight[userIndex] = nil
+			negativeWeight[userIndex] = nil
+		}
 	}
 	log.Logger().Debug("created ranking dataset",
 		zap.Int("n_valid_positive", clickDataset.PositiveCount),
 		zap.Int("n_valid_negative", clickDataset.NegativeCount),
 		zap.Duration("used_time", time.Since(start)))
-	LoadDatasetStepSecondsVec.WithLabelValues("create_ranking_dataset").Set(time.Since(start).Seconds())
-	return rankingDataset, clickDataset, nil
+	m.gaugeVec(LoadDatasetStepSecondsVec, "create_ranking_dataset").Set(time.Since(start).Seconds())
+
+	// STEP 6: create session dataset
+	start = time.Now()
+	sessionDataset = &session.DataSet{ItemIndex: rankingDataset.ItemIndex}
+	sessionTimeout := m.Config.Recommend.Session.SessionTimeout
+	for userIndex, feedback := range userSequences {
+		sort.SliceStable(feedback, func(i, j int) bool {
+			return feedback[i].B.Before(feedback[j].B)
+		})
+		var sequence []int32
+		for i, f := range feedback {
+			// start a new session after a period of inactivity
+			if i > 0 && sessionTimeout > 0 && f.B.Sub(feedback[i-1].B) > sessionTimeout {
+				if len(sequence) > 1 {
+					sessionDataset.Sequences = append(sessionDataset.Sequences, sequence)
+				}
+				sequence = nil
+			}
+			// skip repeated feedback on the same item
+			if len(sequence) == 0 || sequence[len(sequence)-1] != f.A {
+				sequence = append(sequence, f.A)
+			}
+		}
+		if len(sequence) > 1 {
+			sessionDataset.Sequences = append(sessionDataset.Sequences, sequence)
+		}
+		userSequences[userIndex] = nil
+	}
+	log.Logger().Debug("created session dataset",
+		zap.Int("n_sequences", sessionDataset.Count()),
+		zap.Duration("used_time", time.Since(start)))
+	m.gaugeVec(LoadDatasetStepSecondsVec, "create_session_dataset").Set(time.Since(start).Seconds())
+	return rankingDataset, clickDataset, sessionDataset, nil
 }
@@ -16,12 +16,21 @@ package master
 
 import (
 	"context"
+	"fmt"
 	"runtime"
+	"slices"
 	"strconv"
+	"testing"
 	"time"
 
 	"github.com/samber/lo"
+	"github.com/stretchr/testify/assert"
+	"github.com/zhenghaoz/gorse/base"
 	"github.com/zhenghaoz/gorse/config"
+	"github.com/zhenghaoz/gorse/model"
+	"github.com/zhenghaoz/gorse/model/click"
+	"github.com/zhenghaoz/gorse/model/ranking"
+	"github.com/zhenghaoz/gorse/storage/blob"
 	"github.com/zhenghaoz/gorse/storage/cache"
 	"github.com/zhenghaoz/gorse/storage/data"
 )
@@ -81,7 +90,7 @@ func (s *MasterTestSuite) TestFindItemNeighborsBruteForce() {
 	}
 
 	// load mock dataset
-	dataset, _, err := s.LoadDataFromDatabase(context.Background(), s.DataClient, []string{"FeedbackType"},
+	dataset, _, _, err := s.LoadDataFromDatabase(context.Background(), s.DataClient, []string{"FeedbackType"},
 		nil, 0, 0, NewOnlineEvaluator(), nil)
 	s.NoError(err)
 	s.rankingTrainSet = dataset
@@ -187,7 +196,7 @@ func (s *MasterTestSuite) TestFindItemNeighborsIVF() {
 	}
 
 	// load mock dataset
-	dataset, _, err := s.LoadDataFromDatabase(context.Background(), s.DataClient, []string{"FeedbackType"},
+	dataset, _, _, err := s.LoadDataFromDatabase(context.Background(), s.DataClient, []string{"FeedbackType"},
 		nil, 0, 0, NewOnlineEvaluator(), nil)
 	s.NoError(err)
 	s.rankingTrainSet = dataset
@@ -255,7 +264,7 @@ func (s *MasterTestSuite) TestFindItemNeighborsIVF_ZeroIDF() {
 		{FeedbackKey: data.FeedbackKey{FeedbackType: "FeedbackType", UserId: "0", ItemId: "1"}},
 	}, true, true, true)
 	s.NoError(err)
-	dataset, _, err := s.LoadDataFromDatabase(context.Background(), s.DataClient, []string{"FeedbackType"},
+	dataset, _, _, err := s.LoadDataFromDatabase(context.Background(), s.DataClient, []string{"FeedbackType"},
 		nil, 0, 0, NewOnlineEvaluator(), nil)
 	s.NoError(err)
 	s.rankingTrainSet = dataset
@@ -316,7 +325,7 @@ func (s *MasterTestSuite) TestFindUserNeighborsBruteForce() {
 	s.NoError(err)
 	err = s.DataClient.BatchInsertFeedback(ctx, feedbacks, true, true, true)
 	s.NoError(err)
-	dataset, _, err := s.LoadDataFromDatabase(context.Background(), s.DataClient, []string{"FeedbackType"},
+	dataset, _, _, err := s.LoadDataFromDatabase(context.Background(), s.DataClient, []string{"FeedbackType"},
 		nil, 0, 0, NewOnlineEvaluator(), nil)
 	s.NoError(err)
 	s.rankingTrainSet = dataset
@@ -397,7 +406,7 @@ func (s *MasterTestSuite) TestFindUserNeighborsIVF() {
 	s.NoError(err)
 	err = s.DataClient.BatchInsertFeedback(ctx, feedbacks, true, true, true)
 	s.NoError(err)
-	dataset, _, err := s.LoadDataFromDatabase(context.Background(), s.DataClient, []string{"FeedbackType"},
+	dataset, _, _, err := s.LoadDataFromDatabase(context.Background(), s.DataClient, []string{"FeedbackType"},
 		nil, 0, 0, NewOnlineEvaluator(), nil)
 	s.NoError(err)
 	s.rankingTrainSet = dataset
@@ -457,7 +466,7 @@ func (s *MasterTestSuite) TestFindUserNeighborsIVF_ZeroIDF() {
 		{FeedbackKey: data.FeedbackKey{FeedbackType: "FeedbackType", UserId: "1", ItemId: "0"}},
 	}, true, true, true)
 	s.NoError(err)
-	dataset, _, err := s.LoadDataFromDatabase(context.Background(), s.DataClient, []string{"FeedbackType"},
+	dataset, _, _, err := s.LoadDataFromDatabase(context.Background(), s.DataClient, []string{"FeedbackType"},
 		nil, 0, 0, NewOnlineEvaluator(), nil)
 	s.NoError(err)
 	s.rankingTrainSet = dataset
@@ -572,6 +581,9 @@ func (s *MasterTestSuite) TestLoadDataFromDatabase() {
 	s.Equal(90, s.clickTrainSet.Count()+s.clickTestSet.Count())
 	s.Equal(45, s.clickTrainSet.PositiveCount+s.clickTestSet.PositiveCount)
 	s.Equal(45, s.clickTrainSet.NegativeCount+s.clickTestSet.NegativeCount)
+	s.Equal(9, s.sessionTrainSet.Count())
+	s.Equal(10, s.sessionTrainSet.ItemCount())
+	s.Equal(8, s.sessionTestSet.Count())
 
 	// check latest items
 	latest, err := s.CacheClient.SearchScores(ctx, cache.NonPersonalized, cache.Latest, []string{""}, 0, 100)
@@ -619,6 +631,393 @@ func (s *MasterTestSuite) TestLoadDataFromDatabase() {
 	s.Equal([]string{"0", "1", "2"}, categories)
 }
 
+func (s *MasterTestSuite) TestLoadDataFromDatabase_Decay() {
+	ctx := context.Background()
+	// create config
+	s.Config = &config.Config{}
+	s.Config.Recommend.CacheSize = 3
+	s.Config.Recommend.DataSource.PositiveFeedbackTypes = []string{"positive"}
+	s.Config.Recommend.DataSource.ReadFeedbackTypes = []string{"negative"}
+	s.Config.Recommend.DataSource.Decay = config.DecayConfig{Function: config.DecayStep, StepAge: 24 * time.Hour, StepWeight: 0.5}
+	s.Config.Master.NumJobs = runtime.NumCPU()
+
+	// insert items and users
+	err := s.DataClient.BatchInsertItems(ctx, []data.Item{{ItemId: "0"}, {ItemId: "1"}, {ItemId: "2"}})
+	s.NoError(err)
+	err = s.DataClient.BatchInsertUsers(ctx, []data.User{{UserId: "0"}, {UserId: "1"}})
+	s.NoError(err)
+
+	// insert feedback
+	recent, old := time.Now().Add(-time.Hour), time.Now().Add(-48*time.Hour)
+	err = s.DataClient.BatchInsertFeedback(ctx, []data.Feedback{
+		{FeedbackKey: data.FeedbackKey{FeedbackType: "positive", UserId: "0", ItemId: "0"}, Timestamp: old},
+		{FeedbackKey: data.FeedbackKey{FeedbackType: "positive", UserId: "0", ItemId: "1"}, Timestamp: recent},
+		{FeedbackKey: data.FeedbackKey{FeedbackType: "negative", UserId: "0", ItemId: "2"}, Timestamp: old},
+		{FeedbackKey: data.FeedbackKey{FeedbackType: "positive", UserId: "1", ItemId: "0"}, Timestamp: recent},
+	}, false, false, true)
+	s.NoError(err)
+
+	// load dataset
+	err = s.runLoadDatasetTask()
+	s.NoError(err)
+	dataset := s.rankingTrainSet
+	user0 := dataset.UserIndex.ToNumber("0")
+	for _, set := range []*ranking.DataSet{s.rankingTrainSet, s.rankingTestSet} {
+		for j, itemIndex := range set.UserFeedback[user0] {
+			if set.ItemIndex.ToName(itemIndex) == "0" {
+				s.Equal(float32(0.5), set.UserConfidence[user0][j])
+				s.Equal(float32(0.5), set.UserDecay[user0][j])
+			} else {
+				s.Equal(float32(1), set.UserConfidence[user0][j])
+				s.Equal(float32(1), set.UserDecay[user0][j])
+			}
+		}
+		// decay of each interaction is aligned with feedback of items
+		for itemIndex, users := range set.ItemFeedback {
+			for j, userIndex := range users {
+				s.Equal(lo.Ternary(userIndex == user0 && set.ItemIndex.ToName(int32(itemIndex)) == "0", float32(0.5), float32(1)),
+					set.ItemDecay[itemIndex][j])
+			}
+		}
+	}
+
+	// check weights of click samples
+	weights := make(map[string]float32)
+	for _, set := range []*click.Dataset{s.clickTrainSet, s.clickTestSet} {
+		s.Equal(set.Count(), set.Weight.Len())
+		for i := 0; i < set.Count(); i++ {
+			weights[dataset.ItemIndex.ToName(set.Items.Get(i))] = set.GetWeight(i)
+		}
+	}
+	s.Equal(map[string]float32{"0": 0.5, "1": 1, "2": 0.5}, weights)
+}
+
+func (s *MasterTestSuite) TestLoadDataFromDatabase_NumericLabels() {
+	ctx := context.Background()
+	// create config
+	s.Config = &config.Config{}
+	s.Config.Recommend.DataSource.PositiveFeedbackTypes = []string{"positive"}
+	s.Config.Recommend.DataSource.ReadFeedbackTypes = []string{"negative"}
+	s.Config.Recommend.DataSource.NumericLabels = []config.NumericLabelConfig{
+		{Name: "price", Transform: click.QuantileTransform, NumBuckets: 5},
+		{Name: "age", Transform: click.ZScoreTransform},
+	}
+	s.Config.Master.NumJobs = runtime.NumCPU()
+
+	// insert items, users and feedback
+	var (
+		items     []data.Item
+		users     []data.User
+		feedbacks []data.Feedback
+	)
+	for i := 0; i < 10; i++ {
+		items = append(items, data.Item{ItemId: strconv.Itoa(i), Labels: map[string]any{"price": i * 100, "brand": "gorse"}})
+		users = append(users, data.User{UserId: strconv.Itoa(i), Labels: map[string]any{"age": 20 + i}})
+		for j := 0; j < 10; j++ {
+			feedbacks = append(feedbacks, data.Feedback{FeedbackKey: data.FeedbackKey{
+				FeedbackType: lo.Ternary(i <= j, "positive", "negative"),
+				UserId:       strconv.Itoa(i),
+				ItemId:       strconv.Itoa(j),
+			}})
+		}
+	}
+	s.NoError(s.DataClient.BatchInsertItems(ctx, items))
+	s.NoError(s.DataClient.BatchInsertUsers(ctx, users))
+	s.NoError(s.DataClient.BatchInsertFeedback(ctx, feedbacks, false, false, true))
+
+	// load dataset
+	err := s.runLoadDatasetTask()
+	s.NoError(err)
+	dataset := s.rankingTrainSet
+	// prices are bucketed by quantiles
+	s.Equal(base.NotId, dataset.ItemLabelIndex.ToNumber("price."))
+	for i := 0; i < 10; i++ {
+		itemIndex := dataset.ItemIndex.ToNumber(strconv.Itoa(i))
+		names := lo.Map(dataset.ItemFeatures[itemIndex], func(feature lo.Tuple2[int32, float32], _ int) string {
+			return dataset.ItemLabelIndex.ToName(feature.A)
+		})
+		s.ElementsMatch([]string{"brand.gorse", fmt.Sprintf("price[%d]", i/2)}, names)
+	}
+	// ages are standardized
+	var sum float32
+	for i := 0; i < 10; i++ {
+		features := dataset.UserFeatures[dataset.UserIndex.ToNumber(strconv.Itoa(i))]
+		s.Len(features, 1)
+		s.Equal("age.", dataset.UserLabelIndex.ToName(features[0].A))
+		sum += features[0].B
+	}
+	s.InDelta(0, sum, 1e-4)
+	// transformers are shipped with click datasets
+	for _, set := range []*click.Dataset{s.clickTrainSet, s.clickTestSet} {
+		s.Len(set.ItemTransformer.Statistics["price."].Boundaries, 4)
+		s.InDelta(24.5, set.UserTransformer.Statistics["age."].Mean, 1e-4)
+	}
+}
+
+func TestDecayedVectors(t *testing.T) {
+	// item 0 is read by user 0 recently and by user 1 long ago, user 0 reads item 1 and user 1 reads item 2
+	itemFeedback := [][]int32{{0, 1}, {0}, {1}}
+	itemDecay := [][]float32{{1, 0.1}, {1}, {0.1}}
+	userFeedback := [][]int32{{0, 1}, {0, 2}}
+	vectors := NewDecayedVectors(itemFeedback, itemDecay, userFeedback, []float32{1, 1})
+	assert.Greater(t, vectors.Distance(0, 1), vectors.Distance(0, 2))
+	// interactions contribute equally without decay
+	vectors = NewVectors(itemFeedback, userFeedback, []float32{1, 1})
+	assert.Equal(t, vectors.Distance(0, 1), vectors.Distance(0, 2))
+}
+
+func TestDiscretizeLabels(t *testing.T) {
+	labelIndex := base.NewMapIndex()
+	labelIndex.Add("tag.")
+	labelIndex.Add("age.")
+	labelIndex.Add("score.")
+	transformer := &click.LabelTransformer{Statistics: map[string]*click.NumericStatistics{
+		"age.":   {Method: click.ZScoreTransform},
+		"score.": {Method: click.PassThroughTransform},
+	}}
+	features := [][]lo.Tuple2[int32, float32]{
+		{{A: 0, B: 1}, {A: 1, B: 0.1}},
+		{{A: 1, B: 0.2}, {A: 0, B: 1}},
+		{{A: 1, B: 1.5}},
+		{{A: 1, B: -1.5}},
+		{{A: 2, B: 0.1}},
+		{{A: 2, B: 0.2}},
+	}
+	labels, numLabels := discretizeLabels(features, labelIndex, transformer)
+	// close values of scaled labels share a token while distant values don't
+	assert.Equal(t, 6, numLabels)
+	assert.Equal(t, labels[0], labels[1])
+	assert.Len(t, labels[2], 1)
+	assert.NotContains(t, labels[0], labels[2][0])
+	assert.NotEqual(t, labels[2], labels[3])
+	// values of labels passed through are kept unchanged
+	assert.NotEqual(t, labels[4], labels[5])
+	for _, tokens := range labels {
+		assert.True(t, slices.IsSorted(tokens))
+	}
+	// values are kept unchanged without transformer
+	labels, numLabels = discretizeLabels(features, labelIndex, nil)
+	assert.Equal(t, 7, numLabels)
+	assert.NotEqual(t, labels[0], labels[1])
+}
+
+func (s *MasterTestSuite) TestLoadDataFromDatabase_Context() {
+	ctx := context.Background()
+	// create config
+	s.Config = &config.Config{}
+	s.Config.Recommend.DataSource.PositiveFeedbackTypes = []string{"positive"}
+	s.Config.Recommend.DataSource.ReadFeedbackTypes = []string{"negative"}
+	s.Config.Master.NumJobs = runtime.NumCPU()
+
+	// insert items, users and feedback: positive feedback occurs on mobile and read feedback occurs on desktop
+	var (
+		items     []data.Item
+		users     []data.User
+		feedbacks []data.Feedback
+	)
+	for i := 0; i < 10; i++ {
+		items = append(items, data.Item{ItemId: strconv.Itoa(i)})
+		users = append(users, data.User{UserId: strconv.Itoa(i)})
+		for j := 0; j < 10; j++ {
+			feedbacks = append(feedbacks, data.Feedback{
+				FeedbackKey: data.FeedbackKey{
+					FeedbackType: lo.Ternary(i <= j, "positive", "negative"),
+					UserId:       strconv.Itoa(i),
+					ItemId:       strconv.Itoa(j),
+				},
+				Context: map[string]string{"device": lo.Ternary(i <= j, "mobile", "desktop")},
+			})
+		}
+	}
+	s.NoError(s.DataClient.BatchInsertItems(ctx, items))
+	s.NoError(s.DataClient.BatchInsertUsers(ctx, users))
+	s.NoError(s.DataClient.BatchInsertFeedback(ctx, feedbacks, false, false, true))
+
+	// load dataset
+	err := s.runLoadDatasetTask()
+	s.NoError(err)
+	s.ElementsMatch([]string{"device.mobile", "device.desktop"}, s.clickTrainSet.Index.GetContextLabels())
+	for _, set := range []*click.Dataset{s.clickTrainSet, s.clickTestSet} {
+		s.Len(set.ContextFeatures, set.Count())
+		for i := 0; i < set.Count(); i++ {
+			features := set.ContextFeatures[i]
+			s.Len(features, 1)
+			s.Equal(lo.Ternary(set.Target.Get(i) > 0, "device.mobile", "device.desktop"),
+				set.Index.GetContextLabels()[features[0].A])
+		}
+	}
+}
+
+func (s *MasterTestSuite) TestFitSessionModel() {
+	ctx := context.Background()
+	s.Config = &config.Config{}
+	s.Config.Recommend.DataSource.PositiveFeedbackTypes = []string{"positive"}
+	s.Config.Recommend.Session = config.GetDefaultConfig().Recommend.Session
+	s.Config.Recommend.Session.SessionTimeout = 2 * time.Hour
+	s.Config.Master.NumJobs = runtime.NumCPU()
+
+	// insert items
+	var items []data.Item
+	for i := 0; i < 10; i++ {
+		items = append(items, data.Item{ItemId: strconv.Itoa(i), Timestamp: time.Now()})
+	}
+	err := s.DataClient.BatchInsertItems(ctx, items)
+	s.NoError(err)
+
+	// insert feedback: user i reads item i, i+1, ..., i+4 in order
+	var feedback []data.Feedback
+	timestamp := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 10; i++ {
+		for j := 4; j >= 0; j-- {
+			feedback = append(feedback, data.Feedback{
+				FeedbackKey: data.FeedbackKey{
+					FeedbackType: "positive",
+					UserId:       strconv.Itoa(i),
+					ItemId:       strconv.Itoa((i + j) % 10),
+				},
+				Timestamp: timestamp.Add(time.Duration(j) * time.Hour),
+			})
+		}
+	}
+	err = s.DataClient.BatchInsertFeedback(ctx, feedback, true, false, true)
+	s.NoError(err)
+
+	// load dataset
+	err = s.runLoadDatasetTask()
+	s.NoError(err)
+	s.Equal(10, s.sessionTrainSet.Count())
+	s.Equal(10, s.sessionTestSet.Count())
+	for _, sequence := range s.sessionTestSet.Sequences {
+		if s.Len(sequence, 5) {
+			first := s.sessionTestSet.ItemIndex.ToName(sequence[0])
+			for j := range sequence {
+				itemId, err := strconv.Atoi(first)
+				s.NoError(err)
+				s.Equal(strconv.Itoa((itemId+j)%10), s.sessionTestSet.ItemIndex.ToName(sequence[j]))
+			}
+		}
+	}
+
+	// fit session model
+	fitTask := NewFitSessionModelTask(&s.Master)
+	_, version := s.LoadSessionModel()
+	s.NoError(fitTask.run(ctx, nil))
+	sessionModel, newVersion := s.LoadSessionModel()
+	s.False(sessionModel.Invalid())
+	s.Equal(version+1, newVersion)
+
+	// skip fitting if nothing changed
+	s.NoError(fitTask.run(ctx, nil))
+	_, newVersion = s.LoadSessionModel()
+	s.Equal(version+1, newVersion)
+}
+
+func (s *MasterTestSuite) TestLoadSessionDataset() {
+	ctx := context.Background()
+	s.Config = &config.Config{}
+	s.Config.Recommend.DataSource.PositiveFeedbackTypes = []string{"positive"}
+	s.Config.Recommend.Session.SessionTimeout = 30 * time.Minute
+	s.Config.Master.NumJobs = runtime.NumCPU()
+
+	// insert items
+	var items []data.Item
+	for i := 0; i < 10; i++ {
+		items = append(items, data.Item{ItemId: strconv.Itoa(i), Timestamp: time.Now()})
+	}
+	s.NoError(s.DataClient.BatchInsertItems(ctx, items))
+
+	// insert feedback: user 0 reads items 0..3 in a session, item 4 alone and items 5..7 in another session
+	var feedback []data.Feedback
+	timestamp := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i, offset := range []time.Duration{0, time.Minute, 2 * time.Minute, 3 * time.Minute,
+		time.Hour, 2 * time.Hour, 2*time.Hour + 20*time.Minute, 2*time.Hour + 40*time.Minute} {
+		feedback = append(feedback, data.Feedback{
+			FeedbackKey: data.FeedbackKey{FeedbackType: "positive", UserId: "0", ItemId: strconv.Itoa(i)},
+			Timestamp:   timestamp.Add(offset),
+		})
+	}
+	s.NoError(s.DataClient.BatchInsertFeedback(ctx, feedback, true, false, true))
+
+	// load dataset
+	s.NoError(s.runLoadDatasetTask())
+	var sessions [][]string
+	for _, sequence := range s.sessionTestSet.Sequences {
+		sessions = append(sessions, lo.Map(sequence, func(itemIndex int32, _ int) string {
+			return s.sessionTestSet.ItemIndex.ToName(itemIndex)
+		}))
+	}
+	s.ElementsMatch([][]string{{"0", "1", "2", "3"}, {"5", "6", "7"}}, sessions)
+}
+
+func (s *MasterTestSuite) TestFitTwoTowerModel() {
+	ctx := context.Background()
+	s.Config = &config.Config{}
+	s.Config.Recommend.DataSource.PositiveFeedbackTypes = []string{"positive"}
+	s.Config.Recommend.ImageEmbeddings.EmbeddingLabel = "embedding"
+	s.Config.Recommend.ImageEmbeddings.EmbeddingDim = 2
+	s.Config.Recommend.DataSource.NumericLabels = []config.NumericLabelConfig{{Name: "weight", Transform: click.ZScoreTransform}}
+	s.Config.Master.NumJobs = runtime.NumCPU()
+
+	// insert items with labels, categories and embeddings
+	var items []data.Item
+	for i := 0; i < 10; i++ {
+		items = append(items, data.Item{
+			ItemId:     strconv.Itoa(i),
+			Categories: []string{strconv.Itoa(i % 2)},
+			Labels: map[string]any{
+				"color":     strconv.Itoa(i % 2),
+				"weight":    i,
+				"embedding": []any{float64(i % 2), float64(1 - i%2)},
+			},
+			Timestamp: time.Now(),
+		})
+	}
+	err := s.DataClient.BatchInsertItems(ctx, items)
+	s.NoError(err)
+
+	// insert feedback
+	var feedback []data.Feedback
+	for i := 0; i < 10; i++ {
+		for j := 0; j < 5; j++ {
+			feedback = append(feedback, data.Feedback{
+				FeedbackKey: data.FeedbackKey{
+					FeedbackType: "positive",
+					UserId:       strconv.Itoa(i),
+					ItemId:       strconv.Itoa((i + 2*j) % 10),
+				},
+				Timestamp: time.Now(),
+			})
+		}
+	}
+	err = s.DataClient.BatchInsertFeedback(ctx, feedback, true, false, true)
+	s.NoError(err)
+	err = s.runLoadDatasetTask()
+	s.NoError(err)
+	s.Equal([]float32{1, 0}, s.rankingTrainSet.ItemEmbeddings[s.rankingTrainSet.ItemIndex.ToNumber("1")])
+
+	// skip fitting if two-tower recommendation is disabled
+	fitTask := NewFitTwoTowerModelTask(&s.Master)
+	_, version := s.LoadTwoTowerModel()
+	s.NoError(fitTask.run(ctx, nil))
+	_, newVersion := s.LoadTwoTowerModel()
+	s.Equal(version, newVersion)
+
+	// fit two-tower model
+	s.Config.Recommend.Offline.EnableTwoTowerRecommend = true
+	s.NoError(fitTask.run(ctx, nil))
+	twoTowerModel, newVersion := s.LoadTwoTowerModel()
+	s.False(twoTowerModel.Invalid())
+	s.Equal(version+1, newVersion)
+	// transformers of the training data are kept with the model
+	_, itemTransformer := s.LoadTwoTowerTransformers()
+	s.InDelta(4.5, itemTransformer.Statistics["weight."].Mean, 1e-4)
+
+	// skip fitting if nothing changed
+	s.NoError(fitTask.run(ctx, nil))
+	_, newVersion = s.LoadTwoTowerModel()
+	s.Equal(version+1, newVersion)
+}
+
 func (s *MasterTestSuite) TestNonPersonalizedRecommend() {
 	ctx := context.Background()
 	// create config
@@ -768,3 +1167,21 @@ func (s *MasterTestSuite) TestCheckUserNeighborCacheTimeout() {
 	s.NoError(err)
 	s.False(s.checkUserNeighborCacheTimeout("1"))
 }
+
+func (s *MasterTestSuite) TestSearchHistory() {
+	s.blobStore = blob.NewMasterStoreServer(s.T().TempDir())
+	s.rankingModelSearcher = ranking.NewModelSearcher(10, 10, false)
+	s.clickModelSearcher = click.NewModelSearcher(10, 10, false)
+	s.rankingModelSearcher.GetHistory().Add("bpr", model.Trial{Params: model.Params{model.NFactors: 16}, Epochs: 10, Score: 0.1})
+	s.saveSearchHistory(RankingSearchHistory, s.rankingModelSearcher.GetHistory())
+
+	// searches warm-start from the saved history
+	s.rankingModelSearcher = ranking.NewModelSearcher(10, 10, false)
+	s.loadSearchHistory()
+	trials := s.rankingModelSearcher.GetHistory().Get("bpr")
+	if s.Len(trials, 1) {
+		s.EqualValues(16, trials[0].Params[model.NFactors])
+		s.Equal(float32(0.1), trials[0].Score)
+	}
+	s.Empty(s.clickModelSearcher.GetHistory().Get("fm"))
+}
@@ -0,0 +1,193 @@
+// Copyright 2024 gorse Project Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package master
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/juju/errors"
+	"github.com/zhenghaoz/gorse/base/log"
+	"github.com/zhenghaoz/gorse/base/progress"
+	"github.com/zhenghaoz/gorse/config"
+	"github.com/zhenghaoz/gorse/protocol"
+	"github.com/zhenghaoz/gorse/storage"
+	"github.com/zhenghaoz/gorse/storage/cache"
+	"github.com/zhenghaoz/gorse/storage/data"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// taskName returns the name of a task in the job scheduler, which is prefixed by the tenant of the master.
+func (m *Master) taskName(t Task) string {
+	if m.tenant == "" {
+		return t.name()
+	}
+	return fmt.Sprintf("%s/%s", m.tenant, t.name())
+}
+
+// newTenantMaster creates the master of a tenant. The master of a tenant has its own stores, datasets, models and
+// progress tracer, but it shares the job scheduler, the cluster meta and login sessions with the master.
+func (m *Master) newTenantMaster(tenant config.TenantConfig) (*Master, error) {
+	cfg, err := m.Config.ForTenant(tenant)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	tm := newMaster(cfg, fmt.Sprintf("%s.%s", m.cacheFile, tenant.Name), m.managedMode)
+	tm.tenant = tenant.Name
+	tm.jobsScheduler = m.jobsScheduler
+	tm.tracer = progress.NewTracer(tenant.Name)
+	tm.metaStore = m.metaStore
+	tm.oauth2Config = m.oauth2Config
+	tm.verifier = m.verifier
+	tm.tokenCache = m.tokenCache
+	tm.Tenants = m.Tenants
+	tm.DisableLog = m.DisableLog
+
+	// connect stores with table prefixes of the tenant
+	tm.DataClient, err = data.Open(cfg.Database.DataStore, cfg.Database.DataTablePrefix,
+		storage.WithIsolationLevel(cfg.Database.MySQL.IsolationLevel),
+		storage.WithIndexedLabels(cfg.Database.MySQL.IndexedLabels))
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if err = tm.DataClient.Init(); err != nil {
+		return nil, errors.Trace(err)
+	}
+	tm.CacheClient, err = cache.Open(cfg.Database.CacheStore, cfg.Database.CacheTablePrefix,
+		storage.WithIsolationLevel(cfg.Database.MySQL.IsolationLevel))
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if err = tm.CacheClient.Init(); err != nil {
+		return nil, errors.Trace(err)
+	}
+	return tm, nil
+}
+
+// serveTenant starts model fitting, neighbor searching and event dispatching for a tenant and serves the dashboard
+// and APIs of the tenant. Workers sync meta, pull models and access proxied stores of the tenant by gRPC requests with
+// the tenant in metadata.
+func (m *Master) serveTenant(tenant config.TenantConfig) error {
+	tm, err := m.newTenantMaster(tenant)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	tm.loadLocalCache()
+	if err = tm.loadExperiments(context.Background()); err != nil {
+		log.Logger().Error("failed to load experiments", zap.String("tenant", tenant.Name), zap.Error(err))
+	}
+	if tm.Config.Events.Enable {
+		go tm.RunDispatchEventsLoop()
+	}
+	if tm.managedMode {
+		go tm.RunManagedTasksLoop()
+	} else {
+		go tm.RunPrivilegedTasksLoop()
+		go tm.RunRagtagTasksLoop()
+	}
+	m.Tenants.Set(tenant, tm.newTenantContainer())
+	m.tenantServicesMutex.Lock()
+	if m.tenantServices == nil {
+		m.tenantServices = make(map[string]map[string]any)
+	}
+	m.tenantServices[tenant.Name] = map[string]any{
+		protocol.Master_ServiceDesc.ServiceName:     tm,
+		protocol.CacheStore_ServiceDesc.ServiceName: cache.NewProxyServer(tm.CacheClient),
+		protocol.DataStore_ServiceDesc.ServiceName:  data.NewProxyServer(tm.DataClient),
+	}
+	m.tenantServicesMutex.Unlock()
+	log.Logger().Info("start tenant", zap.String("tenant", tenant.Name), zap.String("table_prefix", tenant.TablePrefix))
+	return nil
+}
+
+// newTenantContainer creates a container serving the dashboard, the master APIs and the REST APIs of a tenant.
+func (m *Master) newTenantContainer() http.Handler {
+	container := m.newContainer()
+	m.RestServer.CreateWebService()
+	container.Add(m.WebService)
+	return container
+}
+
+// tenantServiceDescs are descriptions of gRPC services served for tenants. Other services are served for the cluster.
+var tenantServiceDescs = map[string]*grpc.ServiceDesc{
+	protocol.Master_ServiceDesc.ServiceName:     &protocol.Master_ServiceDesc,
+	protocol.CacheStore_ServiceDesc.ServiceName: &protocol.CacheStore_ServiceDesc,
+	protocol.DataStore_ServiceDesc.ServiceName:  &protocol.DataStore_ServiceDesc,
+}
+
+// tenantService returns the service of the tenant in metadata of a request, the description of the service and the
+// name of the method. A nil service is returned if the request is served for the cluster.
+func (m *Master) tenantService(ctx context.Context, fullMethod string) (any, *grpc.ServiceDesc, string, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	tenants := md.Get(protocol.MetadataTenant)
+	if len(tenants) == 0 {
+		return nil, nil, "", nil
+	}
+	serviceName, methodName, _ := strings.Cut(strings.TrimPrefix(fullMethod, "/"), "/")
+	desc, ok := tenantServiceDescs[serviceName]
+	if !ok {
+		return nil, nil, "", nil
+	}
+	m.tenantServicesMutex.RLock()
+	services, exist := m.tenantServices[tenants[0]]
+	m.tenantServicesMutex.RUnlock()
+	if !exist {
+		return nil, nil, "", status.Errorf(codes.NotFound, "tenant %s not found", tenants[0])
+	}
+	return services[serviceName], desc, methodName, nil
+}
+
+// unaryTenantInterceptor dispatches unary requests of tenants to services of tenants.
+func (m *Master) unaryTenantInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	srv, desc, methodName, err := m.tenantService(ctx, info.FullMethod)
+	if err != nil {
+		return nil, err
+	} else if srv == nil {
+		return handler(ctx, req)
+	}
+	for _, method := range desc.Methods {
+		if method.MethodName == methodName {
+			// the request has been decoded, so it is copied to the request of the service
+			return method.Handler(srv, ctx, func(in any) error {
+				proto.Merge(in.(proto.Message), req.(proto.Message))
+				return nil
+			}, nil)
+		}
+	}
+	return nil, status.Errorf(codes.Unimplemented, "method %s not implemented", info.FullMethod)
+}
+
+// streamTenantInterceptor dispatches streaming requests of tenants to services of tenants.
+func (m *Master) streamTenantInterceptor(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	tenantSrv, desc, streamName, err := m.tenantService(stream.Context(), info.FullMethod)
+	if err != nil {
+		return err
+	} else if tenantSrv == nil {
+		return handler(srv, stream)
+	}
+	for _, streamDesc := range desc.Streams {
+		if streamDesc.StreamName == streamName {
+			return streamDesc.Handler(tenantSrv, stream)
+		}
+	}
+	return status.Errorf(codes.Unimplemented, "method %s not implemented", info.FullMethod)
+}
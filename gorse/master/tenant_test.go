@@ -0,0 +1,70 @@
+// Copyright 2024 gorse Project Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package master
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/zhenghaoz/gorse/config"
+	"github.com/zhenghaoz/gorse/server"
+)
+
+func TestMaster_Tenant(t *testing.T) {
+	m := &Master{}
+	tm := &Master{tenant: "eu"}
+	task := &CacheGarbageCollectionTask{}
+	assert.Equal(t, TaskCacheGarbageCollection, m.taskName(task))
+	assert.Equal(t, "eu/"+TaskCacheGarbageCollection, tm.taskName(task))
+
+	// metrics of tenants are isolated
+	m.gauge(UsersTotal).Set(1)
+	tm.gauge(UsersTotal).Set(2)
+	tm.gaugeVec(LoadDatasetStepSecondsVec, "load_users").Set(3)
+	assert.Equal(t, float64(1), testutil.ToFloat64(UsersTotal))
+	assert.Equal(t, float64(2), testutil.ToFloat64(TenantMetricsVec.WithLabelValues("eu", "users_total", "")))
+	assert.Equal(t, float64(3), testutil.ToFloat64(TenantMetricsVec.WithLabelValues("eu", "load_dataset_step_seconds", "load_users")))
+}
+
+func TestMaster_SwitchTenant(t *testing.T) {
+	m := &Master{}
+	m.Tenants = server.NewTenantRouter()
+	m.Tenants.Set(config.TenantConfig{Name: "eu"}, http.NotFoundHandler())
+
+	// select a tenant
+	recorder := httptest.NewRecorder()
+	m.switchTenant(recorder, httptest.NewRequest(http.MethodGet, "/tenant?name=eu", nil))
+	assert.Equal(t, http.StatusFound, recorder.Code)
+	cookies := recorder.Result().Cookies()
+	if assert.Len(t, cookies, 1) {
+		assert.Equal(t, server.CookieTenant, cookies[0].Name)
+		assert.Equal(t, "eu", cookies[0].Value)
+	}
+	// select the cluster
+	recorder = httptest.NewRecorder()
+	m.switchTenant(recorder, httptest.NewRequest(http.MethodGet, "/tenant", nil))
+	assert.Equal(t, http.StatusFound, recorder.Code)
+	cookies = recorder.Result().Cookies()
+	if assert.Len(t, cookies, 1) {
+		assert.Equal(t, -1, cookies[0].MaxAge)
+	}
+	// unknown tenant
+	recorder = httptest.NewRecorder()
+	m.switchTenant(recorder, httptest.NewRequest(http.MethodGet, "/tenant?name=us", nil))
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+}
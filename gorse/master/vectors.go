@@ -30,27 +30,36 @@ type VectorsInterface interface {
 
 type Vectors struct {
 	connections [][]int32
+	decays      [][]float32
 	connected   [][]int32
 	weights     []float32
 }
 
 func NewVectors(connections, connected [][]int32, weights []float32) *Vectors {
+	return NewDecayedVectors(connections, nil, connected, weights)
+}
+
+// NewDecayedVectors creates vectors whose connections are decayed by decays, which are aligned with connections.
+// Connections aren't decayed if decays is nil.
+func NewDecayedVectors(connections [][]int32, decays [][]float32, connected [][]int32, weights []float32) *Vectors {
 	if len(connected) != len(weights) {
 		panic("the length of connected and weights doesn't match")
 	}
 	return &Vectors{
 		connections: connections,
+		decays:      decays,
 		connected:   connected,
 		weights:     weights,
 	}
 }
 
 func (v *Vectors) Distance(i, j int) float32 {
-	commonSum, commonCount := commonElements(v.connections[i], v.connections[j], v.weights)
+	decayI, decayJ := decaysAt(v.decays, i), decaysAt(v.decays, j)
+	commonSum, commonCount := commonElements(v.connections[i], v.connections[j], decayI, decayJ, v.weights)
 	if commonCount > 0 {
 		return commonSum * commonCount /
-			math32.Sqrt(weightedSum(v.connections[i], v.weights)) /
-			math32.Sqrt(weightedSum(v.connections[j], v.weights)) /
+			math32.Sqrt(weightedSum(v.connections[i], decayI, v.weights)) /
+			math32.Sqrt(weightedSum(v.connections[j], decayJ, v.weights)) /
 			(commonCount + similarityShrink)
 	} else {
 		return 0
@@ -123,11 +132,11 @@ type DualDictionaryVector struct {
 
 func NewDualDictionaryVector(
 	indices1 []int32, values1 []float32,
-	indices2 []int32, values2 []float32,
+	indices2 []int32, decays2, values2 []float32,
 	terms []string, isHidden bool) *DualDictionaryVector {
 	return &DualDictionaryVector{
 		first:  search.NewDictionaryVector(indices1, values1, terms, isHidden),
-		second: search.NewDictionaryVector(indices2, values2, terms, isHidden),
+		second: search.NewDecayedDictionaryVector(indices2, decays2, values2, terms, isHidden),
 	}
 }
 
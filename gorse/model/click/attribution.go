@@ -0,0 +1,247 @@
+// Copyright 2024 gorse Project Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package click
+
+import (
+	"sort"
+
+	"github.com/chewxy/math32"
+	"github.com/samber/lo"
+	"github.com/zhenghaoz/gorse/base"
+	"github.com/zhenghaoz/gorse/common/nn"
+)
+
+const (
+	ExactAttribution              = "exact"
+	IntegratedGradientAttribution = "integrated_gradients"
+
+	UserFeature         = "user"
+	ItemFeature         = "item"
+	UserLabelFeature    = "user_label"
+	ItemLabelFeature    = "item_label"
+	ContextLabelFeature = "context_label"
+
+	// numIntegrationSteps is the number of steps to approximate integrated gradients.
+	numIntegrationSteps = 32
+)
+
+// Attribution is the contribution of a feature to a predicted score.
+type Attribution struct {
+	Name        string  `json:"name"`
+	Type        string  `json:"type"`
+	Value       float32 `json:"value"`
+	Linear      float32 `json:"linear"`      // contribution of the linear term
+	Interaction float32 `json:"interaction"` // half of contributions of pairwise interactions with other features
+	Score       float32 `json:"score"`       // total contribution
+}
+
+// Explanation decomposes a raw predicted score into the baseline score and contributions of features. Contributions
+// of features are sorted by absolute values in descending order.
+type Explanation struct {
+	Method  string        `json:"method"`
+	Score   float32       `json:"score"`
+	Bias    float32       `json:"bias"` // score without any feature
+	User    []Attribution `json:"user"`
+	Item    []Attribution `json:"item"`
+	Context []Attribution `json:"context"`
+}
+
+// Explainer explains predictions of a model.
+type Explainer interface {
+	Explain(userId, itemId string, userFeatures, itemFeatures, contextFeatures []Feature) Explanation
+}
+
+// Truncate keeps top n contributing user, item and context features.
+func (e *Explanation) Truncate(n int) {
+	if len(e.User) > n {
+		e.User = e.User[:n]
+	}
+	if len(e.Item) > n {
+		e.Item = e.Item[:n]
+	}
+	if len(e.Context) > n {
+		e.Context = e.Context[:n]
+	}
+}
+
+// group collects contributions of features into the explanation.
+func (e *Explanation) group(attributions []Attribution) {
+	for _, attribution := range attributions {
+		switch attribution.Type {
+		case UserFeature, UserLabelFeature:
+			e.User = append(e.User, attribution)
+		case ItemFeature, ItemLabelFeature:
+			e.Item = append(e.Item, attribution)
+		case ContextLabelFeature:
+			e.Context = append(e.Context, attribution)
+		}
+	}
+	for _, attributions := range [][]Attribution{e.User, e.Item, e.Context} {
+		sort.SliceStable(attributions, func(i, j int) bool {
+			return math32.Abs(attributions[i].Score) > math32.Abs(attributions[j].Score)
+		})
+	}
+}
+
+// encode converts a sample into feature indices and values in the same way as prediction. Features unknown to the
+// model are dropped.
+func (b *BaseFactorizationMachine) encode(userId, itemId string, userFeatures, itemFeatures, contextFeatures []Feature) (
+	indices []int32, values []float32, attributions []Attribution) {
+	add := func(index int32, name, featureType string, value float32) {
+		if index != base.NotId {
+			indices = append(indices, index)
+			values = append(values, value)
+			attributions = append(attributions, Attribution{Name: name, Type: featureType, Value: value})
+		}
+	}
+	add(b.Index.EncodeUser(userId), userId, UserFeature, 1)
+	add(b.Index.EncodeItem(itemId), itemId, ItemFeature, 1)
+	for _, feature := range b.UserTransformer.Transform(userFeatures) {
+		add(b.Index.EncodeUserLabel(feature.Name), feature.Name, UserLabelFeature, feature.Value)
+	}
+	for _, feature := range b.ItemTransformer.Transform(itemFeatures) {
+		add(b.Index.EncodeItemLabel(feature.Name), feature.Name, ItemLabelFeature, feature.Value)
+	}
+	for _, feature := range contextFeatures {
+		add(b.Index.EncodeContextLabel(feature.Name), feature.Name, ContextLabelFeature, feature.Value)
+	}
+	return
+}
+
+// Explain decomposes the raw score of factorization machines exactly. The linear term of a feature is w_i x_i and
+// the pairwise interaction <v_i, v_j> x_i x_j is shared equally by both features.
+func (fm *FM) Explain(userId, itemId string, userFeatures, itemFeatures, contextFeatures []Feature) Explanation {
+	indices, values, attributions := fm.encode(userId, itemId, userFeatures, itemFeatures, contextFeatures)
+	explanation := Explanation{Method: ExactAttribution, Score: fm.B, Bias: fm.B}
+	for i, index := range indices {
+		attributions[i].Linear = fm.W[index] * values[i]
+		for j := i + 1; j < len(indices); j++ {
+			var interaction float32
+			for k := range fm.V[index] {
+				interaction += fm.V[index][k] * fm.V[indices[j]][k]
+			}
+			interaction *= values[i] * values[j]
+			attributions[i].Interaction += interaction / 2
+			attributions[j].Interaction += interaction / 2
+		}
+	}
+	for i := range attributions {
+		attributions[i].Score = attributions[i].Linear + attributions[i].Interaction
+		explanation.Score += attributions[i].Score
+	}
+	explanation.group(attributions)
+	return explanation
+}
+
+// Explain approximates contributions of features to the raw score of DeepFM by integrated gradients. Along the path
+// from the baseline without features to the sample, both values and embeddings of features are scaled by alpha, and
+// gradients of the score with respect to alpha of each feature are estimated by central differences at midpoints.
+func (fm *DeepFM) Explain(userId, itemId string, userFeatures, itemFeatures, contextFeatures []Feature) Explanation {
+	indices, values, attributions := fm.encode(userId, itemId, userFeatures, itemFeatures, contextFeatures)
+	if len(indices) > fm.numDimension {
+		indices, values, attributions = indices[:fm.numDimension], values[:fm.numDimension], attributions[:fm.numDimension]
+	}
+	// the first two rows are the baseline and the sample, followed by pairs of rows moving each feature by half a step
+	// around each midpoint
+	halfStep := 0.5 / float32(numIntegrationSteps)
+	scales := [][]float32{make([]float32, len(indices)), lo.RepeatBy(len(indices), func(int) float32 { return 1 })}
+	for step := 0; step < numIntegrationSteps; step++ {
+		alpha := (float32(step) + 0.5) / float32(numIntegrationSteps)
+		for j := range indices {
+			for _, delta := range []float32{halfStep, -halfStep} {
+				row := lo.RepeatBy(len(indices), func(int) float32 { return alpha })
+				row[j] += delta
+				scales = append(scales, row)
+			}
+		}
+	}
+	outputs := fm.scaledPredict(indices, values, scales)
+	for step := 0; step < numIntegrationSteps; step++ {
+		for j := range indices {
+			offset := 2 + 2*(step*len(indices)+j)
+			attributions[j].Score += outputs[offset] - outputs[offset+1]
+		}
+	}
+	explanation := Explanation{
+		Method: IntegratedGradientAttribution,
+		Score:  outputs[1],
+		Bias:   outputs[0],
+	}
+	explanation.group(attributions)
+	return explanation
+}
+
+// Explain approximates contributions of features to the raw score of DeepFM by integrated gradients. Along the path
+// from the baseline without features to the sample, both values and embeddings of features are scaled by alpha, and
+// gradients of the score with respect to alpha of each feature are integrated by the midpoint rule.
+func (fm *DeepFMV2) Explain(userId, itemId string, userFeatures, itemFeatures, contextFeatures []Feature) Explanation {
+	indices, values, attributions := fm.encode(userId, itemId, userFeatures, itemFeatures, contextFeatures)
+	if len(indices) > fm.numDimension {
+		indices, values, attributions = indices[:fm.numDimension], values[:fm.numDimension], attributions[:fm.numDimension]
+	}
+	fm.mu.Lock()
+	defer fm.mu.Unlock()
+	// the first steps rows are interpolated samples, the next two rows are the baseline and the sample
+	steps := min(numIntegrationSteps, fm.batchSize-2)
+	alignedIndices := make([]float32, fm.batchSize*fm.numDimension)
+	alignedValues := make([]float32, fm.batchSize*fm.numDimension)
+	alignedScales := make([]float32, fm.batchSize*fm.numDimension*fm.nFactors)
+	for row := 0; row < fm.batchSize; row++ {
+		alpha := float32(1)
+		if row < steps {
+			alpha = (float32(row) + 0.5) / float32(steps)
+		} else if row == steps {
+			alpha = 0
+		}
+		for j := 0; j < fm.numDimension; j++ {
+			offset := row*fm.numDimension + j
+			scale := float32(1) // padded features are left as is
+			if j < len(indices) {
+				alignedIndices[offset] = float32(indices[j])
+				alignedValues[offset] = alpha * values[j]
+				scale = alpha
+			}
+			for k := 0; k < fm.nFactors; k++ {
+				alignedScales[offset*fm.nFactors+k] = scale
+			}
+		}
+	}
+	indicesTensor := nn.NewTensor(alignedIndices, fm.batchSize, fm.numDimension)
+	valuesTensor := nn.NewTensor(alignedValues, fm.batchSize, fm.numDimension)
+	scalesTensor := nn.NewTensor(alignedScales, fm.batchSize, fm.numDimension, fm.nFactors)
+	e := nn.Mul(fm.embeddingV.Forward(indicesTensor), scalesTensor)
+	output := fm.forward(e, indicesTensor, valuesTensor)
+	nn.Sum(output).Backward()
+
+	// d score / d alpha_j = d score / d x_j * x_j + sum_k d score / d scale_jk
+	valuesGrad, scalesGrad := valuesTensor.Grad().Data(), scalesTensor.Grad().Data()
+	for row := 0; row < steps; row++ {
+		for j := range indices {
+			offset := row*fm.numDimension + j
+			grad := valuesGrad[offset] * values[j]
+			for k := 0; k < fm.nFactors; k++ {
+				grad += scalesGrad[offset*fm.nFactors+k]
+			}
+			attributions[j].Score += grad / float32(steps)
+		}
+	}
+	explanation := Explanation{
+		Method: IntegratedGradientAttribution,
+		Score:  output.Data()[steps+1],
+		Bias:   output.Data()[steps],
+	}
+	explanation.group(attributions)
+	return explanation
+}
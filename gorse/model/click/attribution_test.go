@@ -0,0 +1,131 @@
+// Copyright 2024 gorse Project Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package click
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/chewxy/math32"
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/assert"
+	"github.com/zhenghaoz/gorse/model"
+)
+
+// newAttributionDataset creates a dataset where users click items labeled by their favorite colors.
+func newAttributionDataset() *Dataset {
+	colors := []string{"red", "green", "blue"}
+	builder := NewUnifiedMapIndexBuilder()
+	dataset := NewMapIndexDataset()
+	for _, color := range colors {
+		builder.AddUserLabel("favorite." + color)
+		builder.AddItemLabel("color." + color)
+	}
+	for i := 0; i < 12; i++ {
+		builder.AddItem(fmt.Sprintf("item%d", i))
+		dataset.ItemFeatures = append(dataset.ItemFeatures, []lo.Tuple2[int32, float32]{{A: int32(i % 3), B: 1}})
+	}
+	for i := 0; i < 30; i++ {
+		builder.AddUser(fmt.Sprintf("user%d", i))
+		dataset.UserFeatures = append(dataset.UserFeatures, []lo.Tuple2[int32, float32]{{A: int32(i % 3), B: 1}})
+		for j := 0; j < 12; j++ {
+			dataset.Users.Append(int32(i))
+			dataset.Items.Append(int32(j))
+			if i%3 == j%3 {
+				dataset.Target.Append(1)
+				dataset.PositiveCount++
+			} else {
+				dataset.Target.Append(-1)
+				dataset.NegativeCount++
+			}
+		}
+	}
+	dataset.Index = builder.Build()
+	return dataset
+}
+
+func sumAttributions(explanation Explanation) float32 {
+	var sum float32
+	for _, attributions := range [][]Attribution{explanation.User, explanation.Item, explanation.Context} {
+		for _, attribution := range attributions {
+			sum += attribution.Score
+		}
+	}
+	return sum
+}
+
+func TestFM_Explain(t *testing.T) {
+	dataset := newAttributionDataset()
+	fm := NewFM(FMClassification, model.Params{model.NFactors: 16, model.NEpochs: 20})
+	fm.Fit(context.Background(), dataset, dataset, nil)
+
+	userFeatures := []Feature{{Name: "favorite.red", Value: 1}}
+	itemFeatures := []Feature{{Name: "color.red", Value: 1}, {Name: "unknown", Value: 1}}
+	explanation := fm.Explain("user0", "item0", userFeatures, itemFeatures, nil)
+	assert.Equal(t, ExactAttribution, explanation.Method)
+	assert.InDelta(t, fm.Predict("user0", "item0", userFeatures, itemFeatures, nil), explanation.Score, 1e-4)
+	assert.InDelta(t, explanation.Score-explanation.Bias, sumAttributions(explanation), 1e-4)
+	assert.ElementsMatch(t, []string{"user0", "favorite.red"}, lo.Map(explanation.User, func(a Attribution, _ int) string { return a.Name }))
+	assert.ElementsMatch(t, []string{"item0", "color.red"}, lo.Map(explanation.Item, func(a Attribution, _ int) string { return a.Name }))
+	assert.Empty(t, explanation.Context)
+	for _, attribution := range append(explanation.User, explanation.Item...) {
+		assert.InDelta(t, attribution.Linear+attribution.Interaction, attribution.Score, 1e-6)
+	}
+	assert.GreaterOrEqual(t, math32.Abs(explanation.User[0].Score), math32.Abs(explanation.User[1].Score))
+	// matched colors contribute more than mismatched colors
+	mismatched := fm.Explain("user0", "item0", userFeatures, []Feature{{Name: "color.blue", Value: 1}}, nil)
+	matchedColor, _ := lo.Find(explanation.Item, func(a Attribution) bool { return a.Name == "color.red" })
+	mismatchedColor, _ := lo.Find(mismatched.Item, func(a Attribution) bool { return a.Name == "color.blue" })
+	assert.Greater(t, matchedColor.Score, mismatchedColor.Score)
+
+	explanation.Truncate(1)
+	assert.Len(t, explanation.User, 1)
+	assert.Len(t, explanation.Item, 1)
+}
+
+func TestDeepFM_Explain(t *testing.T) {
+	dataset := newAttributionDataset()
+	fm := NewDeepFM(model.Params{model.NFactors: 16, model.NEpochs: 5, model.BatchSize: 64, model.HiddenLayers: []int{16, 16}})
+	fm.Fit(context.Background(), dataset, dataset, NewFitConfig())
+
+	userFeatures := []Feature{{Name: "favorite.red", Value: 1}}
+	itemFeatures := []Feature{{Name: "color.red", Value: 1}}
+	explanation := fm.Explain("user0", "item0", userFeatures, itemFeatures, nil)
+	assert.Equal(t, IntegratedGradientAttribution, explanation.Method)
+	assert.InDelta(t, fm.BatchPredict([]lo.Tuple5[string, string, []Feature, []Feature, []Feature]{{A: "user0", B: "item0", C: userFeatures, D: itemFeatures}})[0],
+		explanation.Score, 1e-4)
+	// integrated gradients are complete up to the error of numerical integration
+	assert.InDelta(t, explanation.Score-explanation.Bias, sumAttributions(explanation), 1e-2)
+	assert.Len(t, explanation.User, 2)
+	assert.Len(t, explanation.Item, 2)
+}
+
+func TestDeepFMV2_Explain(t *testing.T) {
+	dataset := newAttributionDataset()
+	fm := NewDeepFMV2(model.Params{model.NFactors: 16, model.NEpochs: 5, model.BatchSize: 64, model.HiddenLayers: []int{16}})
+	fm.Fit(context.Background(), dataset, dataset, NewFitConfig())
+
+	userFeatures := []Feature{{Name: "favorite.red", Value: 1}}
+	itemFeatures := []Feature{{Name: "color.red", Value: 1}}
+	explanation := fm.Explain("user0", "item0", userFeatures, itemFeatures, nil)
+	assert.Equal(t, IntegratedGradientAttribution, explanation.Method)
+	assert.InDelta(t, fm.BatchPredict([]lo.Tuple5[string, string, []Feature, []Feature, []Feature]{{A: "user0", B: "item0", C: userFeatures, D: itemFeatures}})[0],
+		explanation.Score, 1e-4)
+	// integrated gradients are complete up to the error of numerical integration
+	assert.InDelta(t, explanation.Score-explanation.Bias, sumAttributions(explanation), 1e-2)
+	assert.Len(t, explanation.User, 2)
+	assert.Len(t, explanation.Item, 2)
+}
@@ -0,0 +1,361 @@
+// Copyright 2024 gorse Project Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package click
+
+import (
+	"math"
+	"sort"
+
+	"github.com/chewxy/math32"
+	"github.com/samber/lo"
+)
+
+// Calibration methods of click-through rates.
+const (
+	NoCalibration       = "none"
+	PlattCalibration    = "platt"
+	IsotonicCalibration = "isotonic"
+)
+
+const (
+	// numCalibrationBins is the number of bins of calibration curves.
+	numCalibrationBins = 10
+	// numSegments is the max number of segments in diagnostics.
+	numSegments = 10
+)
+
+// Calibrator maps raw scores of factorization machines to click-through rates. A nil calibrator maps raw scores by
+// the sigmoid function.
+type Calibrator struct {
+	Method string
+	// Platt scaling: p = 1 / (1 + exp(A * score + B))
+	A float64
+	B float64
+	// Isotonic regression: p is interpolated linearly between points (Scores[i], Values[i]).
+	Scores []float32
+	Values []float32
+}
+
+// Calibrate converts a raw score to a click-through rate.
+func (c *Calibrator) Calibrate(score float32) float32 {
+	if c == nil {
+		return sigmoid(score)
+	}
+	switch c.Method {
+	case PlattCalibration:
+		return float32(1 / (1 + math.Exp(c.A*float64(score)+c.B)))
+	case IsotonicCalibration:
+		if len(c.Scores) == 0 {
+			return sigmoid(score)
+		}
+		i := sort.Search(len(c.Scores), func(i int) bool { return c.Scores[i] >= score })
+		if i == 0 {
+			return c.Values[0]
+		} else if i == len(c.Scores) {
+			return c.Values[len(c.Values)-1]
+		}
+		t := (score - c.Scores[i-1]) / (c.Scores[i] - c.Scores[i-1])
+		return c.Values[i-1] + t*(c.Values[i]-c.Values[i-1])
+	default:
+		return sigmoid(score)
+	}
+}
+
+// FitCalibrator fits a calibrator on raw scores and targets (positive if target > 0). It returns nil if the method is
+// none or there are no samples.
+func FitCalibrator(method string, scores, targets []float32) *Calibrator {
+	if len(scores) == 0 {
+		return nil
+	}
+	switch method {
+	case PlattCalibration:
+		return fitPlatt(scores, targets)
+	case IsotonicCalibration:
+		return fitIsotonic(scores, targets)
+	default:
+		return nil
+	}
+}
+
+// fitPlatt fits Platt scaling by Newton's method with backtracking line search, following "A note on Platt's
+// probabilistic outputs for support vector machines" by Lin et al.
+func fitPlatt(scores, targets []float32) *Calibrator {
+	var numPos, numNeg float64
+	for _, target := range targets {
+		if target > 0 {
+			numPos++
+		} else {
+			numNeg++
+		}
+	}
+	// regularized targets
+	hiTarget, loTarget := (numPos+1)/(numPos+2), 1/(numNeg+2)
+	t := make([]float64, len(targets))
+	for i, target := range targets {
+		t[i] = lo.Ternary(target > 0, hiTarget, loTarget)
+	}
+	objective := func(a, b float64) float64 {
+		var f float64
+		for i, score := range scores {
+			fApB := float64(score)*a + b
+			if fApB >= 0 {
+				f += t[i]*fApB + math.Log1p(math.Exp(-fApB))
+			} else {
+				f += (t[i]-1)*fApB + math.Log1p(math.Exp(fApB))
+			}
+		}
+		return f
+	}
+	a, b := 0.0, math.Log((numNeg+1)/(numPos+1))
+	f := objective(a, b)
+	for iter := 0; iter < 100; iter++ {
+		h11, h22, h21, g1, g2 := 1e-12, 1e-12, 0.0, 0.0, 0.0
+		for i, score := range scores {
+			s := float64(score)
+			fApB := s*a + b
+			var p, q float64
+			if fApB >= 0 {
+				p, q = math.Exp(-fApB)/(1+math.Exp(-fApB)), 1/(1+math.Exp(-fApB))
+			} else {
+				p, q = 1/(1+math.Exp(fApB)), math.Exp(fApB)/(1+math.Exp(fApB))
+			}
+			d2 := p * q
+			h11 += s * s * d2
+			h22 += d2
+			h21 += s * d2
+			d1 := t[i] - p
+			g1 += s * d1
+			g2 += d1
+		}
+		if math.Abs(g1) < 1e-5 && math.Abs(g2) < 1e-5 {
+			break
+		}
+		det := h11*h22 - h21*h21
+		dA := -(h22*g1 - h21*g2) / det
+		dB := -(-h21*g1 + h11*g2) / det
+		gd := g1*dA + g2*dB
+		step := 1.0
+		for ; step >= 1e-10; step /= 2 {
+			newA, newB := a+step*dA, b+step*dB
+			if newF := objective(newA, newB); newF < f+1e-4*step*gd {
+				a, b, f = newA, newB, newF
+				break
+			}
+		}
+		if step < 1e-10 {
+			break
+		}
+	}
+	return &Calibrator{Method: PlattCalibration, A: a, B: b}
+}
+
+// fitIsotonic fits isotonic regression by the pool adjacent violators algorithm.
+func fitIsotonic(scores, targets []float32) *Calibrator {
+	type block struct {
+		score  float64
+		value  float64
+		weight float64
+	}
+	indices := lo.Range(len(scores))
+	sort.Slice(indices, func(i, j int) bool { return scores[indices[i]] < scores[indices[j]] })
+	var blocks []block
+	for _, i := range indices {
+		target := lo.Ternary[float64](targets[i] > 0, 1, 0)
+		if len(blocks) > 0 && blocks[len(blocks)-1].score == float64(scores[i]) {
+			// merge samples with the same score
+			last := &blocks[len(blocks)-1]
+			last.value = (last.value*last.weight + target) / (last.weight + 1)
+			last.weight++
+		} else {
+			blocks = append(blocks, block{score: float64(scores[i]), value: target, weight: 1})
+		}
+		// pool adjacent violators
+		for len(blocks) > 1 && blocks[len(blocks)-2].value >= blocks[len(blocks)-1].value {
+			prev, last := blocks[len(blocks)-2], blocks[len(blocks)-1]
+			weight := prev.weight + last.weight
+			blocks = blocks[:len(blocks)-1]
+			blocks[len(blocks)-1] = block{
+				score:  (prev.score*prev.weight + last.score*last.weight) / weight,
+				value:  (prev.value*prev.weight + last.value*last.weight) / weight,
+				weight: weight,
+			}
+		}
+	}
+	calibrator := &Calibrator{Method: IsotonicCalibration}
+	for _, b := range blocks {
+		calibrator.Scores = append(calibrator.Scores, float32(b.score))
+		calibrator.Values = append(calibrator.Values, float32(b.value))
+	}
+	return calibrator
+}
+
+// GetCalibrator returns the calibrator of a model. It returns nil if the model isn't calibrated.
+func GetCalibrator(m FactorizationMachine) *Calibrator {
+	if calibrated, ok := m.(interface{ GetCalibrator() *Calibrator }); ok {
+		return calibrated.GetCalibrator()
+	}
+	return nil
+}
+
+// SetCalibrator sets the calibrator of a model if the model supports calibration.
+func SetCalibrator(m FactorizationMachine, calibrator *Calibrator) {
+	if calibrated, ok := m.(interface{ SetCalibrator(*Calibrator) }); ok {
+		calibrated.SetCalibrator(calibrator)
+	}
+}
+
+// CalibrationBin is a bin of calibration curves.
+type CalibrationBin struct {
+	MeanPrediction   float32 `json:"mean_prediction"`
+	FractionPositive float32 `json:"fraction_positive"`
+	Count            int     `json:"count"`
+}
+
+// SegmentAUC is the AUC of samples with an item label.
+type SegmentAUC struct {
+	Segment string  `json:"segment"`
+	AUC     float32 `json:"auc"`
+	Count   int     `json:"count"`
+}
+
+// Diagnostics of a click model on the validation set.
+type Diagnostics struct {
+	Method               string           `json:"method"`
+	NumSamples           int              `json:"num_samples"`
+	AUC                  float32          `json:"auc"`
+	LogLoss              float32          `json:"log_loss"`
+	BrierScore           float32          `json:"brier_score"`
+	CalibratedLogLoss    float32          `json:"calibrated_log_loss"`
+	CalibratedBrierScore float32          `json:"calibrated_brier_score"`
+	CalibrationCurve     []CalibrationBin `json:"calibration_curve"`
+	CalibratedCurve      []CalibrationBin `json:"calibrated_curve"`
+	SegmentAUC           []SegmentAUC     `json:"segment_auc"`
+}
+
+// Calibrate fits a calibrator for a click model on the validation set and diagnoses the model. Calibrated metrics
+// are estimated by 2-fold cross-fitting, since the calibrator is fitted on the same validation set. Segments are the
+// most frequent item labels.
+func Calibrate(estimator FactorizationMachine, testSet *Dataset, method string) (*Calibrator, Diagnostics) {
+	scores := predictDataset(estimator, testSet)
+	targets := make([]float32, testSet.Count())
+	for i := range targets {
+		targets[i] = testSet.Target.Get(i)
+	}
+	calibrator := FitCalibrator(method, scores, targets)
+	diagnostics := Diagnostics{
+		Method:     lo.Ternary(calibrator == nil, NoCalibration, method),
+		NumSamples: len(scores),
+	}
+	if len(scores) == 0 {
+		return calibrator, diagnostics
+	}
+
+	// uncalibrated metrics
+	posScores, negScores := splitByTargets(scores, targets)
+	diagnostics.AUC = AUC(posScores, negScores)
+	probabilities := lo.Map(scores, func(score float32, _ int) float32 { return sigmoid(score) })
+	posProbabilities, negProbabilities := splitByTargets(probabilities, targets)
+	diagnostics.LogLoss = LogLoss(posProbabilities, negProbabilities)
+	diagnostics.BrierScore = BrierScore(posProbabilities, negProbabilities)
+	diagnostics.CalibrationCurve = CalibrationCurve(posProbabilities, negProbabilities, numCalibrationBins)
+
+	// calibrated metrics by cross-fitting
+	calibrated := make([]float32, len(scores))
+	for fold := 0; fold < 2; fold++ {
+		var foldScores, foldTargets []float32
+		for i := range scores {
+			if i%2 != fold {
+				foldScores = append(foldScores, scores[i])
+				foldTargets = append(foldTargets, targets[i])
+			}
+		}
+		foldCalibrator := FitCalibrator(method, foldScores, foldTargets)
+		for i := fold; i < len(scores); i += 2 {
+			calibrated[i] = foldCalibrator.Calibrate(scores[i])
+		}
+	}
+	posCalibrated, negCalibrated := splitByTargets(calibrated, targets)
+	diagnostics.CalibratedLogLoss = LogLoss(posCalibrated, negCalibrated)
+	diagnostics.CalibratedBrierScore = BrierScore(posCalibrated, negCalibrated)
+	diagnostics.CalibratedCurve = CalibrationCurve(posCalibrated, negCalibrated, numCalibrationBins)
+
+	// AUC of segments
+	if testSet.Items.Len() > 0 && testSet.ItemFeatures != nil {
+		itemLabels := testSet.Index.GetItemLabels()
+		segments := make(map[int32][]int)
+		for i := 0; i < testSet.Count(); i++ {
+			itemIndex := testSet.Items.Get(i)
+			if int(itemIndex) < len(testSet.ItemFeatures) {
+				for _, feature := range testSet.ItemFeatures[itemIndex] {
+					segments[feature.A] = append(segments[feature.A], i)
+				}
+			}
+		}
+		for label, samples := range segments {
+			if int(label) >= len(itemLabels) {
+				continue
+			}
+			segmentScores := lo.Map(samples, func(i int, _ int) float32 { return scores[i] })
+			segmentTargets := lo.Map(samples, func(i int, _ int) float32 { return targets[i] })
+			posSegment, negSegment := splitByTargets(segmentScores, segmentTargets)
+			if len(posSegment) > 0 && len(negSegment) > 0 {
+				diagnostics.SegmentAUC = append(diagnostics.SegmentAUC, SegmentAUC{
+					Segment: itemLabels[label],
+					AUC:     AUC(posSegment, negSegment),
+					Count:   len(samples),
+				})
+			}
+		}
+		sort.Slice(diagnostics.SegmentAUC, func(i, j int) bool {
+			if diagnostics.SegmentAUC[i].Count != diagnostics.SegmentAUC[j].Count {
+				return diagnostics.SegmentAUC[i].Count > diagnostics.SegmentAUC[j].Count
+			}
+			return diagnostics.SegmentAUC[i].Segment < diagnostics.SegmentAUC[j].Segment
+		})
+		if len(diagnostics.SegmentAUC) > numSegments {
+			diagnostics.SegmentAUC = diagnostics.SegmentAUC[:numSegments]
+		}
+	}
+	return calibrator, diagnostics
+}
+
+// predictDataset predicts raw scores of all samples in a dataset.
+func predictDataset(estimator FactorizationMachine, dataset *Dataset) []float32 {
+	features := make([]lo.Tuple2[[]int32, []float32], dataset.Count())
+	for i := range features {
+		features[i].A, features[i].B, _ = dataset.Get(i)
+	}
+	if batchInference, ok := estimator.(BatchInference); ok {
+		return batchInference.BatchInternalPredict(features)
+	}
+	return lo.Map(features, func(x lo.Tuple2[[]int32, []float32], _ int) float32 {
+		return estimator.InternalPredict(x.A, x.B)
+	})
+}
+
+func splitByTargets(predictions, targets []float32) (posPrediction, negPrediction []float32) {
+	for i, prediction := range predictions {
+		if targets[i] > 0 {
+			posPrediction = append(posPrediction, prediction)
+		} else {
+			negPrediction = append(negPrediction, prediction)
+		}
+	}
+	return
+}
+
+func sigmoid(x float32) float32 {
+	return 1 / (1 + math32.Exp(-x))
+}
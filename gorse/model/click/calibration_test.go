@@ -0,0 +1,126 @@
+// Copyright 2024 gorse Project Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package click
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/assert"
+	"github.com/zhenghaoz/gorse/base"
+	"github.com/zhenghaoz/gorse/model"
+)
+
+// newCalibrationSamples generates raw scores whose click-through rates are sigmoid(2 * score - 1).
+func newCalibrationSamples() (scores, targets []float32) {
+	rng := base.NewRandomGenerator(0)
+	for i := 0; i < 10000; i++ {
+		score := rng.Float32()*6 - 3
+		scores = append(scores, score)
+		targets = append(targets, lo.Ternary[float32](rng.Float32() < sigmoid(2*score-1), 1, -1))
+	}
+	return
+}
+
+func TestFitCalibrator(t *testing.T) {
+	scores, targets := newCalibrationSamples()
+	// Platt scaling
+	platt := FitCalibrator(PlattCalibration, scores, targets)
+	assert.InDelta(t, -2, platt.A, 0.2)
+	assert.InDelta(t, 1, platt.B, 0.2)
+	assert.InDelta(t, sigmoid(-1), platt.Calibrate(0), 0.03)
+	// isotonic regression
+	isotonic := FitCalibrator(IsotonicCalibration, scores, targets)
+	for score := float32(-3); score < 3; score += 0.1 {
+		assert.LessOrEqual(t, isotonic.Calibrate(score), isotonic.Calibrate(score+0.1))
+	}
+	assert.InDelta(t, sigmoid(-1), isotonic.Calibrate(0), 0.1)
+	// no calibration
+	assert.Nil(t, FitCalibrator(NoCalibration, scores, targets))
+	assert.Nil(t, FitCalibrator(PlattCalibration, nil, nil))
+	var calibrator *Calibrator
+	assert.Equal(t, sigmoid(1), calibrator.Calibrate(1))
+}
+
+func TestCalibrate(t *testing.T) {
+	// items 0 ~ 4 are labeled "even" or "odd", items 5 ~ 9 are labeled "unknown"
+	builder := NewUnifiedMapIndexBuilder()
+	dataset := NewMapIndexDataset()
+	for i := 0; i < 10; i++ {
+		builder.AddItem(fmt.Sprintf("item%v", i))
+	}
+	for _, label := range []string{"even", "odd", "unknown"} {
+		builder.AddItemLabel(label)
+	}
+	for i := 0; i < 10; i++ {
+		dataset.ItemFeatures = append(dataset.ItemFeatures, []lo.Tuple2[int32, float32]{{A: lo.Ternary[int32](i >= 5, 2, int32(i%2)), B: 1}})
+	}
+	for i := 0; i < 50; i++ {
+		builder.AddUser(fmt.Sprintf("user%v", i))
+		dataset.UserFeatures = append(dataset.UserFeatures, nil)
+		for j := 0; j < 10; j++ {
+			dataset.Users.Append(int32(i))
+			dataset.Items.Append(int32(j))
+			if (i+j)%2 == 0 {
+				dataset.Target.Append(1)
+				dataset.PositiveCount++
+			} else {
+				dataset.Target.Append(-1)
+				dataset.NegativeCount++
+			}
+		}
+	}
+	dataset.Index = builder.Build()
+	fm := NewFM(FMClassification, model.Params{model.NFactors: 16, model.NEpochs: 10})
+	fm.Fit(context.Background(), dataset, dataset, nil)
+
+	calibrator, diagnostics := Calibrate(fm, dataset, PlattCalibration)
+	assert.NotNil(t, calibrator)
+	assert.Equal(t, PlattCalibration, diagnostics.Method)
+	assert.Equal(t, 500, diagnostics.NumSamples)
+	assert.Greater(t, diagnostics.AUC, float32(0.5))
+	assert.False(t, math.IsNaN(float64(diagnostics.LogLoss)))
+	assert.LessOrEqual(t, diagnostics.CalibratedBrierScore, diagnostics.BrierScore+0.01)
+	assert.NotEmpty(t, diagnostics.CalibrationCurve)
+	assert.NotEmpty(t, diagnostics.CalibratedCurve)
+	assert.Equal(t, 500, lo.SumBy(diagnostics.CalibratedCurve, func(bin CalibrationBin) int { return bin.Count }))
+	assert.Equal(t, []string{"unknown", "even", "odd"}, lo.Map(diagnostics.SegmentAUC, func(s SegmentAUC, _ int) string { return s.Segment }))
+
+	// calibrator is shipped with the model
+	SetCalibrator(fm, calibrator)
+	score := EvaluateClassification(fm, dataset)
+	assert.Equal(t, diagnostics.AUC, score.AUC)
+	assert.NotZero(t, score.LogLoss)
+	buf := bytes.NewBuffer(nil)
+	assert.NoError(t, MarshalModel(buf, fm))
+	m, err := UnmarshalModel(buf)
+	assert.NoError(t, err)
+	assert.Equal(t, calibrator, GetCalibrator(m))
+	// models without calibrator
+	fm.SetCalibrator(nil)
+	buf.Reset()
+	assert.NoError(t, MarshalModel(buf, fm))
+	m, err = UnmarshalModel(buf)
+	assert.NoError(t, err)
+	assert.Nil(t, GetCalibrator(m))
+	// calibrator is dropped once the model is fitted again
+	SetCalibrator(fm, calibrator)
+	fm.Fit(context.Background(), dataset, dataset, nil)
+	assert.Nil(t, fm.GetCalibrator())
+}
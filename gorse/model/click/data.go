@@ -18,6 +18,7 @@ import (
 	"bufio"
 	"encoding/json"
 	"os"
+	"sort"
 	"strconv"
 	"strings"
 
@@ -78,21 +79,47 @@ func convertLabelsToFeatures(result []Feature, prefix string, o any) []Feature {
 			Name:  prefix,
 			Value: float32(value),
 		})
+	case float64:
+		// numbers are decoded as float64 from labels stored in databases
+		result = append(result, Feature{
+			Name:  prefix,
+			Value: float32(labels),
+		})
 	}
 	return result
 }
 
+// ConvertContextToFeatures converts a request context such as {"device": "mobile"} to features such as
+// "device.mobile". Features are sorted by names.
+func ConvertContextToFeatures(context map[string]string) []Feature {
+	if len(context) == 0 {
+		return nil
+	}
+	features := make([]Feature, 0, len(context))
+	for key, value := range context {
+		features = append(features, Feature{Name: key + "." + value, Value: 1})
+	}
+	sort.Slice(features, func(i, j int) bool {
+		return features[i].Name < features[j].Name
+	})
+	return features
+}
+
 // Dataset for click-through-rate models.
 type Dataset struct {
 	Index UnifiedIndex
 
 	UserFeatures    [][]lo.Tuple2[int32, float32] // features of users
 	ItemFeatures    [][]lo.Tuple2[int32, float32] // features of items
-	ContextFeatures [][]lo.Tuple2[int32, float32] // features of context
+	ContextFeatures [][]lo.Tuple2[int32, float32] // features of context of samples
+
+	UserTransformer *LabelTransformer // transformer of numeric user labels
+	ItemTransformer *LabelTransformer // transformer of numeric item labels
 
 	Users  base.Array[int32]
 	Items  base.Array[int32]
 	Target base.Array[float32]
+	Weight base.Array[float32] // weights of samples, all samples are weighted 1 if empty
 
 	PositiveCount int
 	NegativeCount int
@@ -122,6 +149,14 @@ func (dataset *Dataset) Count() int {
 	return dataset.Target.Len()
 }
 
+// GetWeight returns the weight of the i-th sample.
+func (dataset *Dataset) GetWeight(i int) float32 {
+	if dataset.Weight.Len() == 0 {
+		return 1
+	}
+	return dataset.Weight.Get(i)
+}
+
 // Get returns the i-th sample.
 func (dataset *Dataset) Get(i int) ([]int32, []float32, float32) {
 	var (
@@ -157,12 +192,14 @@ func (dataset *Dataset) Get(i int) ([]int32, []float32, float32) {
 			indices = append(indices, position+feature.A)
 			values = append(values, feature.B)
 		}
+		position += dataset.Index.CountItemLabels()
 	}
 	// append context indices
 	if dataset.ContextFeatures != nil {
-		contextIndices, contextValues := lo.Unzip2(dataset.ContextFeatures[i])
-		indices = append(indices, contextIndices...)
-		values = append(values, contextValues...)
+		for _, feature := range dataset.ContextFeatures[i] {
+			indices = append(indices, position+feature.A)
+			values = append(values, feature.B)
+		}
 	}
 	return indices, values, dataset.Target.Get(i)
 }
@@ -241,14 +278,18 @@ func LoadDataFromBuiltIn(name string) (train, test *Dataset, err error) {
 func (dataset *Dataset) Split(ratio float32, seed int64) (*Dataset, *Dataset) {
 	// create train/test dataset
 	trainSet := &Dataset{
-		Index:        dataset.Index,
-		UserFeatures: dataset.UserFeatures,
-		ItemFeatures: dataset.ItemFeatures,
+		Index:           dataset.Index,
+		UserFeatures:    dataset.UserFeatures,
+		ItemFeatures:    dataset.ItemFeatures,
+		UserTransformer: dataset.UserTransformer,
+		ItemTransformer: dataset.ItemTransformer,
 	}
 	testSet := &Dataset{
-		Index:        dataset.Index,
-		UserFeatures: dataset.UserFeatures,
-		ItemFeatures: dataset.ItemFeatures,
+		Index:           dataset.Index,
+		UserFeatures:    dataset.UserFeatures,
+		ItemFeatures:    dataset.ItemFeatures,
+		UserTransformer: dataset.UserTransformer,
+		ItemTransformer: dataset.ItemTransformer,
 	}
 	// split by random
 	numTestSize := int(float32(dataset.Count()) * ratio)
@@ -263,6 +304,9 @@ func (dataset *Dataset) Split(ratio float32, seed int64) (*Dataset, *Dataset) {
 				testSet.ContextFeatures = append(testSet.ContextFeatures, dataset.ContextFeatures[i])
 			}
 			testSet.Target.Append(dataset.Target.Get(i))
+			if dataset.Weight.Len() > 0 {
+				testSet.Weight.Append(dataset.Weight.Get(i))
+			}
 			if dataset.Target.Get(i) > 0 {
 				testSet.PositiveCount++
 			} else {
@@ -276,6 +320,9 @@ func (dataset *Dataset) Split(ratio float32, seed int64) (*Dataset, *Dataset) {
 				trainSet.ContextFeatures = append(trainSet.ContextFeatures, dataset.ContextFeatures[i])
 			}
 			trainSet.Target.Append(dataset.Target.Get(i))
+			if dataset.Weight.Len() > 0 {
+				trainSet.Weight.Append(dataset.Weight.Get(i))
+			}
 			if dataset.Target.Get(i) > 0 {
 				trainSet.PositiveCount++
 			} else {
@@ -55,6 +55,8 @@ func TestConvertLabelsToFeatures(t *testing.T) {
 		{Name: "city.wenzhou", Value: 1},
 		{Name: "tags.", Value: 0.5},
 	}, features)
+	features = ConvertLabelsToFeatures(map[string]any{"price": float64(99)})
+	assert.Equal(t, []Feature{{Name: "price.", Value: 99}}, features)
 
 	// not supported
 	features = ConvertLabelsToFeatures([]any{float64(1), float64(2), float64(3)})
@@ -63,6 +65,39 @@ func TestConvertLabelsToFeatures(t *testing.T) {
 	assert.ElementsMatch(t, []Feature{{Name: "city.wenzhou", Value: 1}}, features)
 }
 
+func TestConvertContextToFeatures(t *testing.T) {
+	assert.Nil(t, ConvertContextToFeatures(nil))
+	assert.Equal(t, []Feature{
+		{Name: "device.mobile", Value: 1},
+		{Name: "surface.home", Value: 1},
+	}, ConvertContextToFeatures(map[string]string{"surface": "home", "device": "mobile"}))
+}
+
+func TestDataset_Context(t *testing.T) {
+	unifiedIndex := NewUnifiedMapIndexBuilder()
+	unifiedIndex.AddUser("user")
+	unifiedIndex.AddItem("item")
+	unifiedIndex.AddUserLabel("gender.female")
+	unifiedIndex.AddItemLabel("a")
+	unifiedIndex.AddItemLabel("b")
+	unifiedIndex.AddCtxLabel("device.mobile")
+	dataset := &Dataset{
+		Index:           unifiedIndex.Build(),
+		UserFeatures:    [][]lo.Tuple2[int32, float32]{{{A: 0, B: 1}}},
+		ItemFeatures:    [][]lo.Tuple2[int32, float32]{{{A: 1, B: 1}}},
+		ContextFeatures: [][]lo.Tuple2[int32, float32]{{{A: 0, B: 1}}},
+	}
+	dataset.Users.Append(0)
+	dataset.Items.Append(0)
+	dataset.Target.Append(1)
+	// context features are placed after item labels
+	indices, values, target := dataset.Get(0)
+	assert.Equal(t, []int32{0, 1, 2, 4, 5}, indices)
+	assert.Equal(t, []float32{1, 1, 1, 1, 1}, values)
+	assert.Equal(t, float32(1), target)
+	assert.Equal(t, dataset.Index.EncodeContextLabel("device.mobile"), indices[4])
+}
+
 func TestLoadDataFromBuiltIn(t *testing.T) {
 	train, test, err := LoadDataFromBuiltIn("frappe")
 	assert.NoError(t, err)
@@ -129,7 +164,7 @@ func TestDataset_Split(t *testing.T) {
 		dataset.Index.CountUsers() + dataset.Index.CountItems() + dataset.Index.CountUserLabels() + 6,
 		dataset.Index.CountUsers() + dataset.Index.CountItems() + dataset.Index.CountUserLabels() + 7,
 		dataset.Index.CountUsers() + dataset.Index.CountItems() + dataset.Index.CountUserLabels() + 8,
-		0,
+		dataset.Index.CountUsers() + dataset.Index.CountItems() + dataset.Index.CountUserLabels() + dataset.Index.CountItemLabels() + 0,
 	}, features)
 	assert.Equal(t, []float32{1, 1, 1, 1, 1, 1, 1, 0.5}, values)
 	assert.Equal(t, float32(-1), target)
@@ -147,3 +182,33 @@ func TestDataset_Split(t *testing.T) {
 	assert.Equal(t, 3, test.PositiveCount)
 	assert.Equal(t, 3, test.NegativeCount)
 }
+
+func TestDataset_Weight(t *testing.T) {
+	unifiedIndex := NewUnifiedMapIndexBuilder()
+	dataset := NewMapIndexDataset()
+	for i := 0; i < 10; i++ {
+		unifiedIndex.AddUser(fmt.Sprintf("user%v", i))
+		unifiedIndex.AddItem(fmt.Sprintf("item%v", i))
+		dataset.UserFeatures = append(dataset.UserFeatures, nil)
+		dataset.ItemFeatures = append(dataset.ItemFeatures, nil)
+		dataset.Users.Append(int32(i))
+		dataset.Items.Append(int32(i))
+		dataset.Target.Append(1)
+		dataset.PositiveCount++
+	}
+	dataset.Index = unifiedIndex.Build()
+	// samples are weighted 1 by default
+	assert.Equal(t, float32(1), dataset.GetWeight(0))
+	// weights follow samples after split
+	for i := 0; i < 10; i++ {
+		dataset.Weight.Append(float32(i) / 10)
+	}
+	train, test := dataset.Split(0.2, 0)
+	assert.Equal(t, train.Count(), train.Weight.Len())
+	assert.Equal(t, test.Count(), test.Weight.Len())
+	for _, set := range []*Dataset{train, test} {
+		for i := 0; i < set.Count(); i++ {
+			assert.Equal(t, float32(set.Users.Get(i))/10, set.GetWeight(i))
+		}
+	}
+}
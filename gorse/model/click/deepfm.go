@@ -78,6 +78,7 @@ type DeepFM struct {
 	values      *gorgonia.Node
 	output      *gorgonia.Node
 	target      *gorgonia.Node
+	weight      *gorgonia.Node
 	cost        *gorgonia.Node
 	b           *gorgonia.Node
 	b0          *gorgonia.Node
@@ -150,7 +151,7 @@ func (fm *DeepFM) GetParamsGrid(withSize bool) model.ParamsGrid {
 	}
 }
 
-func (fm *DeepFM) Predict(userId, itemId string, userFeatures, itemFeatures []Feature) float32 {
+func (fm *DeepFM) Predict(userId, itemId string, userFeatures, itemFeatures, contextFeatures []Feature) float32 {
 	panic("Predict is unsupported for deep learning models")
 }
 
@@ -176,7 +177,41 @@ func (fm *DeepFM) BatchInternalPredict(x []lo.Tuple2[[]int32, []float32]) []floa
 	return predictions[:len(x)]
 }
 
-func (fm *DeepFM) BatchPredict(inputs []lo.Tuple4[string, string, []Feature, []Feature]) []float32 {
+// scaledPredict predicts raw scores of a sample, whose values and embeddings of features are scaled by each row of
+// scales. Padded features are left as is.
+func (fm *DeepFM) scaledPredict(indices []int32, values []float32, scales [][]float32) []float32 {
+	fm.predictMutex.Lock()
+	defer fm.predictMutex.Unlock()
+	alignedIndices := make([]float32, fm.batchSize*fm.numDimension)
+	for row := 0; row < fm.batchSize; row++ {
+		for j, index := range indices {
+			alignedIndices[row*fm.numDimension+j] = float32(index)
+		}
+	}
+	indicesTensor := tensor.New(tensor.WithShape(fm.batchSize, fm.numDimension), tensor.WithBacking(alignedIndices))
+	predictions := make([]float32, 0, len(scales))
+	for i := 0; i < len(scales); i += fm.batchSize {
+		v, w, w0 := fm.embedding(indicesTensor)
+		alignedValues := make([]float32, fm.batchSize*fm.numDimension)
+		for row := 0; row < fm.batchSize && i+row < len(scales); row++ {
+			for j := range indices {
+				offset := row*fm.numDimension + j
+				alignedValues[offset] = values[j] * scales[i+row][j]
+				floats.MulConst(fm.dataV[offset*fm.nFactors:(offset+1)*fm.nFactors], scales[i+row][j])
+			}
+		}
+		lo.Must0(gorgonia.Let(fm.embeddingV, v))
+		lo.Must0(gorgonia.Let(fm.embeddingW, w))
+		lo.Must0(gorgonia.Let(fm.embeddingW0, w0))
+		lo.Must0(gorgonia.Let(fm.values, tensor.New(tensor.WithShape(fm.batchSize, fm.numDimension), tensor.WithBacking(alignedValues))))
+		lo.Must0(fm.vm.RunAll())
+		predictions = append(predictions, fm.output.Value().Data().([]float32)...)
+		fm.vm.Reset()
+	}
+	return predictions[:len(scales)]
+}
+
+func (fm *DeepFM) BatchPredict(inputs []lo.Tuple5[string, string, []Feature, []Feature, []Feature]) []float32 {
 	x := make([]lo.Tuple2[[]int32, []float32], len(inputs))
 	for i, input := range inputs {
 		// encode user
@@ -190,19 +225,26 @@ func (fm *DeepFM) BatchPredict(inputs []lo.Tuple4[string, string, []Feature, []F
 			x[i].B = append(x[i].B, 1)
 		}
 		// encode user labels
-		for _, userFeature := range input.C {
+		for _, userFeature := range fm.UserTransformer.Transform(input.C) {
 			if userFeatureIndex := fm.Index.EncodeUserLabel(userFeature.Name); userFeatureIndex != base.NotId {
 				x[i].A = append(x[i].A, userFeatureIndex)
 				x[i].B = append(x[i].B, userFeature.Value)
 			}
 		}
 		// encode item labels
-		for _, itemFeature := range input.D {
+		for _, itemFeature := range fm.ItemTransformer.Transform(input.D) {
 			if itemFeatureIndex := fm.Index.EncodeItemLabel(itemFeature.Name); itemFeatureIndex != base.NotId {
 				x[i].A = append(x[i].A, itemFeatureIndex)
 				x[i].B = append(x[i].B, itemFeature.Value)
 			}
 		}
+		// encode context labels
+		for _, contextFeature := range input.E {
+			if contextFeatureIndex := fm.Index.EncodeContextLabel(contextFeature.Name); contextFeatureIndex != base.NotId {
+				x[i].A = append(x[i].A, contextFeatureIndex)
+				x[i].B = append(x[i].B, contextFeature.Value)
+			}
+		}
 	}
 	return fm.BatchInternalPredict(x)
 }
@@ -216,15 +258,17 @@ func (fm *DeepFM) Fit(ctx context.Context, trainSet *Dataset, testSet *Dataset,
 	log.Logger().Info(fmt.Sprintf("fit DeepFM %v/%v", 0, fm.nEpochs), fields...)
 
 	var x []lo.Tuple2[[]int32, []float32]
-	var y []float32
+	var y, w []float32
 	for i := 0; i < trainSet.Target.Len(); i++ {
 		fm.minTarget = math32.Min(fm.minTarget, trainSet.Target.Get(i))
 		fm.maxTarget = math32.Max(fm.maxTarget, trainSet.Target.Get(i))
 		indices, values, target := trainSet.Get(i)
 		x = append(x, lo.Tuple2[[]int32, []float32]{A: indices, B: values})
 		y = append(y, target)
+		w = append(w, trainSet.GetWeight(i))
 	}
 	indicesTensor, valuesTensor, targetTensor := fm.convertToTensors(x, y)
+	weightTensor := tensor.New(tensor.WithShape(targetTensor.Shape()...), tensor.WithBacking(alignWeights(w, fm.batchSize)))
 
 	solver := gorgonia.NewAdamSolver(gorgonia.WithBatchSize(float64(fm.batchSize)),
 		gorgonia.WithL2Reg(float64(fm.reg)),
@@ -241,6 +285,7 @@ func (fm *DeepFM) Fit(ctx context.Context, trainSet *Dataset, testSet *Dataset,
 			lo.Must0(gorgonia.Let(fm.embeddingW0, w0))
 			lo.Must0(gorgonia.Let(fm.values, lo.Must1(valuesTensor.Slice(gorgonia.S(i, i+fm.batchSize)))))
 			lo.Must0(gorgonia.Let(fm.target, lo.Must1(targetTensor.Slice(gorgonia.S(i, i+fm.batchSize)))))
+			lo.Must0(gorgonia.Let(fm.weight, lo.Must1(weightTensor.Slice(gorgonia.S(i, i+fm.batchSize)))))
 			lo.Must0(fm.vm.RunAll())
 
 			fm.backward(lo.Must1(indicesTensor.Slice(gorgonia.S(i, i+fm.batchSize))))
@@ -275,7 +320,7 @@ func (fm *DeepFM) Fit(ctx context.Context, trainSet *Dataset, testSet *Dataset,
 
 // Init parameters for DeepFM.
 func (fm *DeepFM) Init(trainSet *Dataset) {
-	fm.numFeatures = trainSet.ItemCount() + trainSet.UserCount() + len(trainSet.UserFeatures) + len(trainSet.ItemFeatures) + len(trainSet.ContextFeatures)
+	fm.numFeatures = int(trainSet.Index.Len())
 	fm.numDimension = 0
 	for i := 0; i < trainSet.Count(); i++ {
 		_, x, _ := trainSet.Get(i)
@@ -445,6 +490,9 @@ func (fm *DeepFM) forward(batchSize int) {
 	fm.target = gorgonia.NodeFromAny(fm.g,
 		tensor.New(tensor.WithShape(batchSize), tensor.WithBacking(make([]float32, batchSize))),
 		gorgonia.WithName("target"))
+	fm.weight = gorgonia.NodeFromAny(fm.g,
+		tensor.New(tensor.WithShape(batchSize), tensor.WithBacking(make([]float32, batchSize))),
+		gorgonia.WithName("weight"))
 
 	// factorization machine
 	x := gorgonia.Must(gorgonia.Reshape(fm.values, []int{batchSize, fm.numDimension, 1}))
@@ -486,7 +534,7 @@ func (fm *DeepFM) forward(batchSize int) {
 	fm.output = gorgonia.Must(gorgonia.Add(fmOutput, dnnOutput))
 
 	// loss function
-	fm.cost = fm.bceWithLogits(fm.target, fm.output)
+	fm.cost = fm.bceWithLogits(fm.target, fm.output, fm.weight)
 }
 
 func (fm *DeepFM) embedding(indices tensor.View) (v, w, w0 *tensor.Dense) {
@@ -651,12 +699,21 @@ func (fm *DeepFM) convertToTensors(x []lo.Tuple2[[]int32, []float32], y []float3
 	return
 }
 
-// bceWithLogits is equivalent to:
+// alignWeights pads sample weights to a multiple of the batch size. Padded samples are weighted 0.
+func alignWeights(w []float32, batchSize int) []float32 {
+	numBatch := (len(w) + batchSize - 1) / batchSize
+	alignedWeight := make([]float32, numBatch*batchSize)
+	copy(alignedWeight, w)
+	return alignedWeight
+}
+
+// bceWithLogits is equivalent to the sum of weighted losses:
 //
-//	(1 + target) * math32.Log(1+math32.Exp(-prediction)) / 2 + (1 - target) * math32.Log(1+math32.Exp(prediction)) / 2
-func (fm *DeepFM) bceWithLogits(target, prediction *gorgonia.Node) *gorgonia.Node {
-	// 1 + target
+//	weight * ((1 + target) * math32.Log(1+math32.Exp(-prediction)) / 2 + (1 - target) * math32.Log(1+math32.Exp(prediction)) / 2)
+func (fm *DeepFM) bceWithLogits(target, prediction, weight *gorgonia.Node) *gorgonia.Node {
+	// (1 + target) * weight
 	onePlusTarget := gorgonia.Must(gorgonia.Add(fm.nodeFromFloat64(1), target))
+	onePlusTarget = gorgonia.Must(gorgonia.HadamardProd(onePlusTarget, weight))
 	// math32.Exp(-prediction)
 	expNegPrediction := gorgonia.Must(gorgonia.Exp(gorgonia.Must(gorgonia.Neg(prediction))))
 	// 1+math32.Exp(-prediction)
@@ -667,8 +724,9 @@ func (fm *DeepFM) bceWithLogits(target, prediction *gorgonia.Node) *gorgonia.Nod
 	positiveLoss := gorgonia.Must(gorgonia.Mul(onePlusTarget, logExpNegPredictionPlusOne))
 	positiveLoss = gorgonia.Must(gorgonia.Div(positiveLoss, fm.nodeFromFloat64(2)))
 
-	// 1 - target
+	// (1 - target) * weight
 	oneMinusTarget := gorgonia.Must(gorgonia.Sub(fm.nodeFromFloat64(1), target))
+	oneMinusTarget = gorgonia.Must(gorgonia.HadamardProd(oneMinusTarget, weight))
 	// math32.Exp(prediction)
 	expPrediction := gorgonia.Must(gorgonia.Exp(prediction))
 	// 1+math32.Exp(prediction)
@@ -56,8 +56,8 @@ func TestDeepFM_Classification_Criteo(t *testing.T) {
 	assert.InDelta(t, 0.77, score.Accuracy, classificationDelta)
 
 	// test prediction
-	assert.Equal(t, m.BatchInternalPredict([]lo.Tuple2[[]int32, []float32]{{A: []int32{1, 2, 3, 4, 5, 6}, B: []float32{1, 1, 0.3, 0.4, 0.5, 0.6}}}),
-		m.BatchPredict([]lo.Tuple4[string, string, []Feature, []Feature]{{
+	assert.Equal(t, m.BatchInternalPredict([]lo.Tuple2[[]int32, []float32]{{A: []int32{1, 2, 3, 4, 5, 6, 7}, B: []float32{1, 1, 0.3, 0.4, 0.5, 0.6, 0.7}}}),
+		m.BatchPredict([]lo.Tuple5[string, string, []Feature, []Feature, []Feature]{{
 			A: "1",
 			B: "2",
 			C: []Feature{
@@ -67,6 +67,9 @@ func TestDeepFM_Classification_Criteo(t *testing.T) {
 			D: []Feature{
 				{Name: "5", Value: 0.5},
 				{Name: "6", Value: 0.6},
+			},
+			E: []Feature{
+				{Name: "7", Value: 0.7},
 			}}}))
 
 	// test marshal and unmarshal
@@ -83,3 +86,42 @@ func TestDeepFM_Classification_Criteo(t *testing.T) {
 	m.Clear()
 	assert.True(t, m.Invalid())
 }
+
+// newWeightedDataset creates a dataset of a single user-item pair, where positive samples are weighted 1 and more
+// negative samples are weighted 0.
+func newWeightedDataset() *Dataset {
+	unifiedIndex := NewUnifiedMapIndexBuilder()
+	unifiedIndex.AddUser("user")
+	unifiedIndex.AddItem("item")
+	dataset := NewMapIndexDataset()
+	dataset.Index = unifiedIndex.Build()
+	for i := 0; i < 40; i++ {
+		dataset.UserFeatures = append(dataset.UserFeatures, nil)
+		dataset.ItemFeatures = append(dataset.ItemFeatures, nil)
+		dataset.Users.Append(0)
+		dataset.Items.Append(0)
+		if i < 10 {
+			dataset.Target.Append(1)
+			dataset.Weight.Append(1)
+			dataset.PositiveCount++
+		} else {
+			dataset.Target.Append(-1)
+			dataset.Weight.Append(0)
+			dataset.NegativeCount++
+		}
+	}
+	return dataset
+}
+
+func TestDeepFM_Weight(t *testing.T) {
+	dataset := newWeightedDataset()
+	m := NewDeepFM(model.Params{
+		model.NEpochs:   20,
+		model.Lr:        0.01,
+		model.BatchSize: 16,
+	})
+	m.Fit(context.Background(), dataset, dataset, newFitConfigWithTestTracker(20))
+	indices, values, _ := dataset.Get(0)
+	prediction := m.BatchInternalPredict([]lo.Tuple2[[]int32, []float32]{{A: indices, B: values}})
+	assert.Greater(t, prediction[0], float32(0))
+}
@@ -113,7 +113,7 @@ func (fm *DeepFMV2) GetParamsGrid(withSize bool) model.ParamsGrid {
 	}
 }
 
-func (fm *DeepFMV2) Predict(userId, itemId string, userFeatures, itemFeatures []Feature) float32 {
+func (fm *DeepFMV2) Predict(userId, itemId string, userFeatures, itemFeatures, contextFeatures []Feature) float32 {
 	panic("Predict is unsupported for deep learning models")
 }
 
@@ -135,7 +135,7 @@ func (fm *DeepFMV2) BatchInternalPredict(x []lo.Tuple2[[]int32, []float32]) []fl
 	return predictions[:len(x)]
 }
 
-func (fm *DeepFMV2) BatchPredict(inputs []lo.Tuple4[string, string, []Feature, []Feature]) []float32 {
+func (fm *DeepFMV2) BatchPredict(inputs []lo.Tuple5[string, string, []Feature, []Feature, []Feature]) []float32 {
 	x := make([]lo.Tuple2[[]int32, []float32], len(inputs))
 	for i, input := range inputs {
 		// encode user
@@ -149,19 +149,26 @@ func (fm *DeepFMV2) BatchPredict(inputs []lo.Tuple4[string, string, []Feature, [
 			x[i].B = append(x[i].B, 1)
 		}
 		// encode user labels
-		for _, userFeature := range input.C {
+		for _, userFeature := range fm.UserTransformer.Transform(input.C) {
 			if userFeatureIndex := fm.Index.EncodeUserLabel(userFeature.Name); userFeatureIndex != base.NotId {
 				x[i].A = append(x[i].A, userFeatureIndex)
 				x[i].B = append(x[i].B, userFeature.Value)
 			}
 		}
 		// encode item labels
-		for _, itemFeature := range input.D {
+		for _, itemFeature := range fm.ItemTransformer.Transform(input.D) {
 			if itemFeatureIndex := fm.Index.EncodeItemLabel(itemFeature.Name); itemFeatureIndex != base.NotId {
 				x[i].A = append(x[i].A, itemFeatureIndex)
 				x[i].B = append(x[i].B, itemFeature.Value)
 			}
 		}
+		// encode context labels
+		for _, contextFeature := range input.E {
+			if contextFeatureIndex := fm.Index.EncodeContextLabel(contextFeature.Name); contextFeatureIndex != base.NotId {
+				x[i].A = append(x[i].A, contextFeatureIndex)
+				x[i].B = append(x[i].B, contextFeature.Value)
+			}
+		}
 	}
 	return fm.BatchInternalPredict(x)
 }
@@ -175,15 +182,17 @@ func (fm *DeepFMV2) Fit(ctx context.Context, trainSet *Dataset, testSet *Dataset
 	log.Logger().Info(fmt.Sprintf("fit DeepFM %v/%v", 0, fm.nEpochs), fields...)
 
 	var x []lo.Tuple2[[]int32, []float32]
-	var y []float32
+	var y, w []float32
 	for i := 0; i < trainSet.Target.Len(); i++ {
 		fm.minTarget = math32.Min(fm.minTarget, trainSet.Target.Get(i))
 		fm.maxTarget = math32.Max(fm.maxTarget, trainSet.Target.Get(i))
 		indices, values, target := trainSet.Get(i)
 		x = append(x, lo.Tuple2[[]int32, []float32]{A: indices, B: values})
 		y = append(y, target)
+		w = append(w, trainSet.GetWeight(i))
 	}
 	indices, values, target := fm.convertToTensors(x, y)
+	weight := nn.NewTensor(alignWeights(w, fm.batchSize), target.Shape()...)
 
 	optimizer := nn.NewAdam(fm.Parameters(), fm.lr)
 	for epoch := 1; epoch <= fm.nEpochs; epoch++ {
@@ -193,8 +202,9 @@ func (fm *DeepFMV2) Fit(ctx context.Context, trainSet *Dataset, testSet *Dataset
 			batchIndices := indices.Slice(i, i+fm.batchSize)
 			batchValues := values.Slice(i, i+fm.batchSize)
 			batchTarget := target.Slice(i, i+fm.batchSize)
+			batchWeight := weight.Slice(i, i+fm.batchSize)
 			batchOutput := fm.Forward(batchIndices, batchValues)
-			batchLoss := nn.BCEWithLogits(batchTarget, batchOutput)
+			batchLoss := nn.WeightedBCEWithLogits(batchTarget, batchOutput, batchWeight)
 			cost += batchLoss.Data()[0]
 			optimizer.ZeroGrad()
 			batchLoss.Backward()
@@ -225,7 +235,7 @@ func (fm *DeepFMV2) Fit(ctx context.Context, trainSet *Dataset, testSet *Dataset
 
 // Init parameters for DeepFM.
 func (fm *DeepFMV2) Init(trainSet *Dataset) {
-	fm.numFeatures = trainSet.ItemCount() + trainSet.UserCount() + len(trainSet.UserFeatures) + len(trainSet.ItemFeatures) + len(trainSet.ContextFeatures)
+	fm.numFeatures = int(trainSet.Index.Len())
 	fm.numDimension = 0
 	for i := 0; i < trainSet.Count(); i++ {
 		_, x, _ := trainSet.Get(i)
@@ -281,9 +291,11 @@ func (fm *DeepFMV2) Unmarshal(r io.Reader) error {
 }
 
 func (fm *DeepFMV2) Forward(indices, values *nn.Tensor) *nn.Tensor {
-	// embedding
-	e := fm.embeddingV.Forward(indices)
+	return fm.forward(fm.embeddingV.Forward(indices), indices, values)
+}
 
+// forward computes outputs from embeddings e of features.
+func (fm *DeepFMV2) forward(e, indices, values *nn.Tensor) *nn.Tensor {
 	// factorization machine
 	x := nn.Reshape(values, fm.batchSize, fm.numDimension, 1)
 	vx := nn.BMM(e, x, true)
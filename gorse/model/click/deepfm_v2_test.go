@@ -59,8 +59,8 @@ func TestDeepFMV2_Classification_Criteo(t *testing.T) {
 	assert.InDelta(t, 0.77, score.Accuracy, classificationDelta)
 
 	// test prediction
-	assert.Equal(t, m.BatchInternalPredict([]lo.Tuple2[[]int32, []float32]{{A: []int32{1, 2, 3, 4, 5, 6}, B: []float32{1, 1, 0.3, 0.4, 0.5, 0.6}}}),
-		m.BatchPredict([]lo.Tuple4[string, string, []Feature, []Feature]{{
+	assert.Equal(t, m.BatchInternalPredict([]lo.Tuple2[[]int32, []float32]{{A: []int32{1, 2, 3, 4, 5, 6, 7}, B: []float32{1, 1, 0.3, 0.4, 0.5, 0.6, 0.7}}}),
+		m.BatchPredict([]lo.Tuple5[string, string, []Feature, []Feature, []Feature]{{
 			A: "1",
 			B: "2",
 			C: []Feature{
@@ -70,6 +70,9 @@ func TestDeepFMV2_Classification_Criteo(t *testing.T) {
 			D: []Feature{
 				{Name: "5", Value: 0.5},
 				{Name: "6", Value: 0.6},
+			},
+			E: []Feature{
+				{Name: "7", Value: 0.7},
 			}}}))
 
 	// test marshal and unmarshal
@@ -15,6 +15,7 @@
 package click
 
 import (
+	"math"
 	"sort"
 
 	"github.com/chewxy/math32"
@@ -74,12 +75,17 @@ func EvaluateClassification(estimator FactorizationMachine, testSet *Dataset) Sc
 			Precision: 0,
 		}
 	}
+	calibrator := GetCalibrator(estimator)
+	posProbability := lo.Map(posPrediction, func(p float32, _ int) float32 { return calibrator.Calibrate(p) })
+	negProbability := lo.Map(negPrediction, func(p float32, _ int) float32 { return calibrator.Calibrate(p) })
 	return Score{
-		Task:      FMClassification,
-		Precision: Precision(posPrediction, negPrediction),
-		Recall:    Recall(posPrediction, negPrediction),
-		Accuracy:  Accuracy(posPrediction, negPrediction),
-		AUC:       AUC(posPrediction, negPrediction),
+		Task:       FMClassification,
+		Precision:  Precision(posPrediction, negPrediction),
+		Recall:     Recall(posPrediction, negPrediction),
+		Accuracy:   Accuracy(posPrediction, negPrediction),
+		AUC:        AUC(posPrediction, negPrediction),
+		LogLoss:    LogLoss(posProbability, negProbability),
+		BrierScore: BrierScore(posProbability, negProbability),
 	}
 }
 
@@ -153,6 +159,70 @@ func AUC(posPrediction, negPrediction []float32) float32 {
 	return sum / float32(len(posPrediction)*len(negPrediction))
 }
 
+// LogLoss computes the mean cross entropy of predicted probabilities. Probabilities are clipped to avoid infinity.
+func LogLoss(posProbability, negProbability []float32) float32 {
+	const eps = 1e-7
+	var sum float64
+	for _, p := range posProbability {
+		sum -= math.Log(math.Max(float64(p), eps))
+	}
+	for _, p := range negProbability {
+		sum -= math.Log(math.Max(1-float64(p), eps))
+	}
+	if len(posProbability)+len(negProbability) == 0 {
+		return 0
+	}
+	return float32(sum / float64(len(posProbability)+len(negProbability)))
+}
+
+// BrierScore computes the mean squared error of predicted probabilities.
+func BrierScore(posProbability, negProbability []float32) float32 {
+	var sum float32
+	for _, p := range posProbability {
+		sum += (1 - p) * (1 - p)
+	}
+	for _, p := range negProbability {
+		sum += p * p
+	}
+	if len(posProbability)+len(negProbability) == 0 {
+		return 0
+	}
+	return sum / float32(len(posProbability)+len(negProbability))
+}
+
+// CalibrationCurve divides predicted probabilities into bins of equal width, and computes the mean probability and
+// the fraction of positive samples in each bin. Empty bins are skipped.
+func CalibrationCurve(posProbability, negProbability []float32, numBins int) []CalibrationBin {
+	sums := make([]float32, numBins)
+	positives := make([]int, numBins)
+	counts := make([]int, numBins)
+	bin := func(p float32) int {
+		return min(max(int(p*float32(numBins)), 0), numBins-1)
+	}
+	for _, p := range posProbability {
+		i := bin(p)
+		sums[i] += p
+		positives[i]++
+		counts[i]++
+	}
+	for _, p := range negProbability {
+		i := bin(p)
+		sums[i] += p
+		counts[i]++
+	}
+	curve := make([]CalibrationBin, 0, numBins)
+	for i := range counts {
+		if counts[i] > 0 {
+			curve = append(curve, CalibrationBin{
+				MeanPrediction:   sums[i] / float32(counts[i]),
+				FractionPositive: float32(positives[i]) / float32(counts[i]),
+				Count:            counts[i],
+			})
+		}
+	}
+	return curve
+}
+
 // SnapshotManger manages the best snapshot.
 type SnapshotManger struct {
 	BestWeights []interface{}
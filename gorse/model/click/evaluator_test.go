@@ -15,8 +15,10 @@
 package click
 
 import (
-	"github.com/stretchr/testify/assert"
+	"math"
 	"testing"
+
+	"github.com/stretchr/testify/assert"
 )
 
 func TestPrecision(t *testing.T) {
@@ -44,3 +46,24 @@ func TestAccuracy(t *testing.T) {
 	accuracy = Accuracy(nil, nil)
 	assert.Zero(t, accuracy)
 }
+
+func TestLogLoss(t *testing.T) {
+	assert.InDelta(t, -math.Log(0.5), LogLoss([]float32{0.5}, []float32{0.5}), 1e-6)
+	assert.InDelta(t, (-math.Log(0.9)-math.Log(0.8))/2, LogLoss([]float32{0.9}, []float32{0.2}), 1e-6)
+	assert.Less(t, LogLoss([]float32{1}, []float32{1}), float32(20))
+	assert.Zero(t, LogLoss(nil, nil))
+}
+
+func TestBrierScore(t *testing.T) {
+	assert.InDelta(t, (0.01+0.04)/2, BrierScore([]float32{0.9}, []float32{0.2}), 1e-6)
+	assert.Zero(t, BrierScore(nil, nil))
+}
+
+func TestCalibrationCurve(t *testing.T) {
+	curve := CalibrationCurve([]float32{0.15, 0.95, 1}, []float32{0.05, 0.15, 0.9}, 10)
+	assert.Equal(t, []CalibrationBin{
+		{MeanPrediction: 0.05, FractionPositive: 0, Count: 1},
+		{MeanPrediction: 0.15, FractionPositive: 0.5, Count: 2},
+		{MeanPrediction: (0.95 + 1 + 0.9) / 3, FractionPositive: float32(2) / 3, Count: 3},
+	}, curve)
+}
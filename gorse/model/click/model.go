@@ -39,12 +39,14 @@ import (
 )
 
 type Score struct {
-	Task      FMTask
-	RMSE      float32
-	Precision float32
-	Recall    float32
-	Accuracy  float32
-	AUC       float32
+	Task       FMTask
+	RMSE       float32
+	Precision  float32
+	Recall     float32
+	Accuracy   float32
+	AUC        float32
+	LogLoss    float32
+	BrierScore float32
 }
 
 func (score Score) ZapFields() []zap.Field {
@@ -57,6 +59,8 @@ func (score Score) ZapFields() []zap.Field {
 			zap.Float32("Precision", score.Precision),
 			zap.Float32("Recall", score.Recall),
 			zap.Float32("AUC", score.AUC),
+			zap.Float32("LogLoss", score.LogLoss),
+			zap.Float32("BrierScore", score.BrierScore),
 		}
 	default:
 		return nil
@@ -123,14 +127,14 @@ func (config *FitConfig) LoadDefaultIfNil() *FitConfig {
 
 type FactorizationMachine interface {
 	model.Model
-	Predict(userId, itemId string, userFeatures, itemFeatures []Feature) float32
+	Predict(userId, itemId string, userFeatures, itemFeatures, contextFeatures []Feature) float32
 	InternalPredict(x []int32, values []float32) float32
 	Fit(ctx context.Context, trainSet *Dataset, testSet *Dataset, config *FitConfig) Score
 	Marshal(w io.Writer) error
 }
 
 type BatchInference interface {
-	BatchPredict(inputs []lo.Tuple4[string, string, []Feature, []Feature]) []float32
+	BatchPredict(inputs []lo.Tuple5[string, string, []Feature, []Feature, []Feature]) []float32
 	BatchInternalPredict(x []lo.Tuple2[[]int32, []float32]) []float32
 }
 
@@ -144,11 +148,39 @@ type FactorizationMachineSpawner interface {
 
 type BaseFactorizationMachine struct {
 	model.BaseModel
-	Index UnifiedIndex
+	Index           UnifiedIndex
+	Calibrator      *Calibrator
+	UserTransformer *LabelTransformer
+	ItemTransformer *LabelTransformer
 }
 
+// Init the model with the train set. The calibrator is dropped since it doesn't fit the model to be trained.
 func (b *BaseFactorizationMachine) Init(trainSet *Dataset) {
 	b.Index = trainSet.Index
+	b.Calibrator = nil
+	b.UserTransformer = trainSet.UserTransformer
+	b.ItemTransformer = trainSet.ItemTransformer
+}
+
+// GetLabelTransformers returns transformers of numeric user labels and item labels.
+func (b *BaseFactorizationMachine) GetLabelTransformers() (userTransformer, itemTransformer *LabelTransformer) {
+	return b.UserTransformer, b.ItemTransformer
+}
+
+// SetLabelTransformers sets transformers of numeric user labels and item labels.
+func (b *BaseFactorizationMachine) SetLabelTransformers(userTransformer, itemTransformer *LabelTransformer) {
+	b.UserTransformer = userTransformer
+	b.ItemTransformer = itemTransformer
+}
+
+// GetCalibrator returns the calibrator of predictions. It returns nil if the model isn't calibrated.
+func (b *BaseFactorizationMachine) GetCalibrator() *Calibrator {
+	return b.Calibrator
+}
+
+// SetCalibrator sets the calibrator of predictions.
+func (b *BaseFactorizationMachine) SetCalibrator(calibrator *Calibrator) {
+	b.Calibrator = calibrator
 }
 
 type FMTask uint8
@@ -206,7 +238,7 @@ func (fm *FM) SetParams(params model.Params) {
 	fm.optimizer = fm.Params.GetString(model.Optimizer, model.Adam)
 }
 
-func (fm *FM) Predict(userId, itemId string, userFeatures, itemFeatures []Feature) float32 {
+func (fm *FM) Predict(userId, itemId string, userFeatures, itemFeatures, contextFeatures []Feature) float32 {
 	var features []int32
 	var values []float32
 	// encode user
@@ -220,19 +252,26 @@ func (fm *FM) Predict(userId, itemId string, userFeatures, itemFeatures []Featur
 		values = append(values, 1)
 	}
 	// encode user labels
-	for _, userFeature := range userFeatures {
+	for _, userFeature := range fm.UserTransformer.Transform(userFeatures) {
 		if userFeatureIndex := fm.Index.EncodeUserLabel(userFeature.Name); userFeatureIndex != base.NotId {
 			features = append(features, userFeatureIndex)
 			values = append(values, userFeature.Value)
 		}
 	}
 	// encode item labels
-	for _, itemFeature := range itemFeatures {
+	for _, itemFeature := range fm.ItemTransformer.Transform(itemFeatures) {
 		if itemFeatureIndex := fm.Index.EncodeItemLabel(itemFeature.Name); itemFeatureIndex != base.NotId {
 			features = append(features, itemFeatureIndex)
 			values = append(values, itemFeature.Value)
 		}
 	}
+	// encode context labels
+	for _, contextFeature := range contextFeatures {
+		if contextFeatureIndex := fm.Index.EncodeContextLabel(contextFeature.Name); contextFeatureIndex != base.NotId {
+			features = append(features, contextFeatureIndex)
+			values = append(values, contextFeature.Value)
+		}
+	}
 	return fm.InternalPredict(features, values)
 }
 
@@ -340,6 +379,8 @@ func (fm *FM) Fit(ctx context.Context, trainSet, testSet *Dataset, config *FitCo
 				default:
 					log.Logger().Fatal("unknown task", zap.String("task", string(fm.Task)))
 				}
+				// weight gradient by sample weight
+				grad *= trainSet.GetWeight(i)
 				// \sum^n_{j=1}v_j,fx_j
 				floats.Zero(temp[workerId])
 				for it, j := range features {
@@ -544,7 +585,20 @@ func MarshalModel(w io.Writer, m FactorizationMachine) error {
 	if err != nil {
 		return err
 	}
-	return m.Marshal(w)
+	if err = m.Marshal(w); err != nil {
+		return err
+	}
+	// write calibrator
+	calibrator := GetCalibrator(m)
+	if calibrator == nil {
+		calibrator = &Calibrator{Method: NoCalibration}
+	}
+	if err = encoding.WriteGob(w, calibrator); err != nil {
+		return err
+	}
+	// write transformers of numeric labels
+	userTransformer, itemTransformer := GetLabelTransformers(m)
+	return MarshalLabelTransformers(w, userTransformer, itemTransformer)
 }
 
 const (
@@ -558,21 +612,41 @@ func UnmarshalModel(r io.Reader) (FactorizationMachine, error) {
 	if err != nil {
 		return nil, err
 	}
+	var m FactorizationMachine
 	switch header {
 	case headerFM:
 		var fm FM
 		if err := fm.Unmarshal(r); err != nil {
 			return nil, errors.Trace(err)
 		}
-		return &fm, nil
+		m = &fm
 	case headerDeepFM:
 		fm := NewDeepFM(nil)
 		if err := fm.Unmarshal(r); err != nil {
 			return nil, errors.Trace(err)
 		}
-		return fm, nil
+		m = fm
+	default:
+		return nil, fmt.Errorf("unknown model: %v", header)
+	}
+	// read calibrator, which is absent in models written by previous versions
+	var calibrator Calibrator
+	if err = encoding.ReadGob(r, &calibrator); err != nil {
+		if err == io.EOF {
+			return m, nil
+		}
+		return nil, errors.Trace(err)
+	}
+	if calibrator.Method != NoCalibration {
+		SetCalibrator(m, &calibrator)
+	}
+	// read transformers of numeric labels, which are absent in models written by previous versions
+	userTransformer, itemTransformer, err := UnmarshalLabelTransformers(r)
+	if err != nil {
+		return nil, errors.Trace(err)
 	}
-	return nil, fmt.Errorf("unknown model: %v", header)
+	SetLabelTransformers(m, userTransformer, itemTransformer)
+	return m, nil
 }
 
 // Clone a model with deep copy.
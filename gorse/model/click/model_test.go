@@ -96,7 +96,7 @@ func TestFM_Regression_Criteo(t *testing.T) {
 	assert.InDelta(t, 0.839194, score.RMSE, regressionDelta)
 
 	// test prediction
-	assert.Equal(t, m.InternalPredict([]int32{1, 2, 3, 4, 5, 6}, []float32{1, 1, 0.3, 0.4, 0.5, 0.6}),
+	assert.Equal(t, m.InternalPredict([]int32{1, 2, 3, 4, 5, 6, 7}, []float32{1, 1, 0.3, 0.4, 0.5, 0.6, 0.7}),
 		m.Predict("1", "2",
 			[]Feature{
 				{Name: "3", Value: 0.3},
@@ -105,6 +105,9 @@ func TestFM_Regression_Criteo(t *testing.T) {
 			[]Feature{
 				{Name: "5", Value: 0.5},
 				{Name: "6", Value: 0.6},
+			},
+			[]Feature{
+				{Name: "7", Value: 0.7},
 			}))
 
 	// test increment test
@@ -36,6 +36,7 @@ type ParamsSearchResult struct {
 	BestIndex  int
 	Scores     []Score
 	Params     []model.Params
+	Trials     []model.Trial
 }
 
 // GridSearchCV finds the best parameters for a model.
@@ -128,13 +129,77 @@ func RandomSearchCV(ctx context.Context, estimator FactorizationMachine, trainSe
 	return results
 }
 
+// SearchCV searches hyper-parameters by the strategy in options. Trials are trained for fewer epochs if early stopping
+// is enabled, and only models trained for all epochs are candidates of the best model. It falls back to RandomSearchCV
+// if neither the tree-structured Parzen estimator nor early stopping is used.
+func SearchCV(ctx context.Context, estimator FactorizationMachine, trainSet *Dataset, testSet *Dataset, paramGrid model.ParamsGrid,
+	options model.SearchOptions, fitConfig *FitConfig) ParamsSearchResult {
+	if paramGrid.NumCombinations() <= options.NumTrials || (options.Strategy != model.TPESearch && !options.EarlyStopping) {
+		if options.NumEpochs > 0 {
+			estimator.SetParams(estimator.GetParams().Overwrite(model.Params{model.NEpochs: options.NumEpochs}))
+		}
+		results := RandomSearchCV(ctx, estimator, trainSet, testSet, paramGrid, options.NumTrials, options.Seed, fitConfig)
+		for i := range results.Params {
+			results.Trials = append(results.Trials, model.Trial{Params: results.Params[i], Epochs: options.NumEpochs, Score: results.Scores[i].objective()})
+		}
+		return results
+	}
+	rungs := options.Rungs()
+	results := ParamsSearchResult{
+		Scores: make([]Score, 0, options.NumTrials),
+		Params: make([]model.Params, 0, options.NumTrials),
+	}
+	newCtx, span := progress.Start(ctx, "SearchCV", options.NumTrials)
+	results.Trials = model.Search(paramGrid, options, func(params model.Params, epochs int) float32 {
+		log.Logger().Info(fmt.Sprintf("%v search %v/%v", options.Strategy, span.Count(), options.NumTrials),
+			zap.Any("params", params), zap.Int("n_epochs", epochs))
+		estimator.Clear()
+		fitParams := estimator.GetParams().Overwrite(params)
+		if epochs > 0 {
+			fitParams[model.NEpochs] = epochs
+		}
+		estimator.SetParams(fitParams)
+		score := estimator.Fit(newCtx, trainSet, testSet, fitConfig)
+		if epochs == rungs[0] {
+			span.Add(1)
+		}
+		if epochs == rungs[len(rungs)-1] {
+			results.Scores = append(results.Scores, score)
+			results.Params = append(results.Params, params.Copy())
+			if results.BestModel == nil || score.BetterThan(results.BestScore) {
+				results.BestScore = score
+				results.BestParams = params.Copy()
+				results.BestIndex = len(results.Params) - 1
+				results.BestModel = Clone(estimator)
+			}
+		}
+		return score.objective()
+	})
+	span.End()
+	return results
+}
+
+// objective converts a score to the objective of hyper-parameter searches, larger is better.
+func (score Score) objective() float32 {
+	if score.Task == FMRegression {
+		return -score.RMSE
+	}
+	return score.AUC
+}
+
+// searchHistorySize is the max number of trials kept in the search history.
+const searchHistorySize = 100
+
 // ModelSearcher is a thread-safe click model searcher.
 type ModelSearcher struct {
 	model FactorizationMachine
 	// arguments
-	numEpochs  int
-	numTrials  int
-	searchSize bool
+	numEpochs     int
+	numTrials     int
+	searchSize    bool
+	strategy      string
+	earlyStopping bool
+	history       *model.SearchHistory
 	// results
 	bestMutex sync.Mutex
 	bestModel FactorizationMachine
@@ -148,9 +213,23 @@ func NewModelSearcher(nEpoch, nTrials int, searchSize bool) *ModelSearcher {
 		numTrials:  nTrials,
 		numEpochs:  nEpoch,
 		searchSize: searchSize,
+		strategy:   model.RandomSearch,
+		history:    model.NewSearchHistory(searchHistorySize),
 	}
 }
 
+// SetSearchStrategy sets the strategy to sample hyper-parameters and whether to stop unpromising trials early.
+func (searcher *ModelSearcher) SetSearchStrategy(strategy string, earlyStopping bool) *ModelSearcher {
+	searcher.strategy = strategy
+	searcher.earlyStopping = earlyStopping
+	return searcher
+}
+
+// GetHistory returns trials of previous searches. Searches warm-start from the history.
+func (searcher *ModelSearcher) GetHistory() *model.SearchHistory {
+	return searcher.history
+}
+
 // GetBestModel returns the best click model with its score.
 func (searcher *ModelSearcher) GetBestModel() (FactorizationMachine, Score) {
 	searcher.bestMutex.Lock()
@@ -166,9 +245,17 @@ func (searcher *ModelSearcher) Fit(ctx context.Context, trainSet, valSet *Datase
 		zap.Int32("n_item_labels", trainSet.Index.CountItemLabels()))
 	startTime := time.Now()
 
-	// Random search
+	// Random search or Bayesian optimization
+	const name = "fm"
 	grid := searcher.model.GetParamsGrid(searcher.searchSize)
-	r := RandomSearchCV(ctx, searcher.model, trainSet, valSet, grid, searcher.numTrials, 0, NewFitConfig().SetJobsAllocator(j))
+	r := SearchCV(ctx, searcher.model, trainSet, valSet, grid, model.SearchOptions{
+		Strategy:      searcher.strategy,
+		NumTrials:     searcher.numTrials,
+		NumEpochs:     searcher.numEpochs,
+		EarlyStopping: searcher.earlyStopping,
+		History:       searcher.history.Get(name),
+	}, NewFitConfig().SetJobsAllocator(j))
+	searcher.history.Add(name, r.Trials...)
 	searcher.bestMutex.Lock()
 	defer searcher.bestMutex.Unlock()
 	searcher.bestModel = r.BestModel
@@ -18,6 +18,7 @@ import (
 	"io"
 	"testing"
 
+	"github.com/samber/lo"
 	"github.com/stretchr/testify/assert"
 	"github.com/zhenghaoz/gorse/base"
 	"github.com/zhenghaoz/gorse/base/task"
@@ -59,7 +60,7 @@ func (m *mockFactorizationMachineForSearch) Fit(_ context.Context, _, _ *Dataset
 	return Score{Task: FMClassification, AUC: score}
 }
 
-func (m *mockFactorizationMachineForSearch) Predict(_, _ string, _, _ []Feature) float32 {
+func (m *mockFactorizationMachineForSearch) Predict(_, _ string, _, _, _ []Feature) float32 {
 	panic("don't call me")
 }
 
@@ -138,3 +139,21 @@ func TestModelSearcher_GridSearch(t *testing.T) {
 		model.InitStdDev: 4,
 	}, m.GetParams())
 }
+
+func TestModelSearcher_TPESearch(t *testing.T) {
+	searcher := NewModelSearcher(9, 20, false).SetSearchStrategy(model.TPESearch, true)
+	searcher.model = &mockFactorizationMachineForSearch{model.BaseModel{Params: model.Params{model.NEpochs: 9}}}
+	err := searcher.Fit(context.Background(), NewMapIndexDataset(), NewMapIndexDataset(), task.NewConstantJobsAllocator(1))
+	assert.NoError(t, err)
+	m, score := searcher.GetBestModel()
+	assert.Equal(t, float32(12), score.AUC)
+	assert.Equal(t, model.Params{
+		model.NEpochs:    9,
+		model.NFactors:   4,
+		model.InitMean:   4,
+		model.InitStdDev: 4,
+	}, m.GetParams())
+	// trials are trained for 1, 3 and 9 epochs
+	history := searcher.GetHistory().Get("fm")
+	assert.Equal(t, []int{1, 3, 9}, lo.Uniq(lo.Map(history, func(trial model.Trial, _ int) int { return trial.Epochs })))
+}
@@ -0,0 +1,267 @@
+// Copyright 2024 gorse Project Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package click
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/chewxy/math32"
+	"github.com/juju/errors"
+	"github.com/samber/lo"
+	"github.com/zhenghaoz/gorse/base/encoding"
+)
+
+const (
+	PassThroughTransform = "none"
+	LogTransform         = "log"
+	ZScoreTransform      = "zscore"
+	QuantileTransform    = "quantile"
+
+	defaultNumBuckets = 10
+)
+
+// NumericTransform declares the transformation of a numeric label.
+type NumericTransform struct {
+	Label      string // name of the label, nested labels are joined by dots
+	Method     string // none, log, zscore or quantile
+	NumBuckets int    // number of quantile buckets
+}
+
+// NumericStatistics are statistics of a numeric label used to transform its values.
+type NumericStatistics struct {
+	Method     string
+	Mean       float32
+	StdDev     float32
+	Boundaries []float32 // upper boundaries of quantile buckets except the last bucket
+}
+
+// LabelTransformer transforms numeric labels of users or items by statistics computed on the training data.
+type LabelTransformer struct {
+	Statistics map[string]*NumericStatistics // statistics indexed by feature names
+}
+
+// Transform returns transformed features. Values of log-scaled and z-scored labels are replaced, while values of
+// bucketed labels are replaced by indicator features named by bucket index such as "price[3]". Features are returned
+// as is if the transformer is nil.
+func (t *LabelTransformer) Transform(features []Feature) []Feature {
+	if t == nil || len(t.Statistics) == 0 {
+		return features
+	}
+	var result []Feature
+	for i, feature := range features {
+		if stats, ok := t.Statistics[feature.Name]; ok {
+			if result == nil {
+				result = make([]Feature, i, len(features))
+				copy(result, features[:i])
+			}
+			result = append(result, stats.transform(feature))
+		} else if result != nil {
+			result = append(result, feature)
+		}
+	}
+	if result == nil {
+		return features
+	}
+	return result
+}
+
+// TransformFeature returns a transformed feature. The feature is returned as is if it isn't a numeric label.
+func (t *LabelTransformer) TransformFeature(feature Feature) Feature {
+	if t == nil {
+		return feature
+	}
+	if stats, ok := t.Statistics[feature.Name]; ok {
+		return stats.transform(feature)
+	}
+	return feature
+}
+
+// IsScaled returns true if values of the feature are log-scaled or z-scored. Values of other features are passed
+// through or replaced by indicators.
+func (t *LabelTransformer) IsScaled(name string) bool {
+	if t == nil {
+		return false
+	}
+	if stats, ok := t.Statistics[name]; ok {
+		return stats.Method == LogTransform || stats.Method == ZScoreTransform
+	}
+	return false
+}
+
+func (s *NumericStatistics) transform(feature Feature) Feature {
+	switch s.Method {
+	case LogTransform:
+		feature.Value = logScale(feature.Value)
+	case ZScoreTransform:
+		if s.StdDev > 0 {
+			feature.Value = (feature.Value - s.Mean) / s.StdDev
+		} else {
+			feature.Value = 0
+		}
+	case QuantileTransform:
+		bucket := sort.Search(len(s.Boundaries), func(i int) bool { return feature.Value < s.Boundaries[i] })
+		feature.Name = fmt.Sprintf("%s[%d]", strings.TrimSuffix(feature.Name, "."), bucket)
+		feature.Value = 1
+	}
+	return feature
+}
+
+// logScale returns sign(x) * log(1 + |x|), which keeps the sign of negative values.
+func logScale(x float32) float32 {
+	if x < 0 {
+		return -math32.Log1p(-x)
+	}
+	return math32.Log1p(x)
+}
+
+// GetLabelTransformers returns transformers of numeric user labels and item labels of a model. Transformers are nil
+// if the model doesn't transform numeric labels.
+func GetLabelTransformers(m FactorizationMachine) (userTransformer, itemTransformer *LabelTransformer) {
+	if transformed, ok := m.(interface {
+		GetLabelTransformers() (*LabelTransformer, *LabelTransformer)
+	}); ok {
+		return transformed.GetLabelTransformers()
+	}
+	return nil, nil
+}
+
+// SetLabelTransformers sets transformers of numeric user labels and item labels if the model supports them.
+func SetLabelTransformers(m FactorizationMachine, userTransformer, itemTransformer *LabelTransformer) {
+	if transformed, ok := m.(interface {
+		SetLabelTransformers(*LabelTransformer, *LabelTransformer)
+	}); ok {
+		transformed.SetLabelTransformers(userTransformer, itemTransformer)
+	}
+}
+
+// LabelTransformerBuilder collects values of numeric labels to compute statistics.
+type LabelTransformerBuilder struct {
+	transforms map[string]NumericTransform
+	values     map[string][]float32
+}
+
+// NewLabelTransformerBuilder creates a builder for declared transformations.
+func NewLabelTransformerBuilder(transforms []NumericTransform) *LabelTransformerBuilder {
+	builder := &LabelTransformerBuilder{
+		transforms: make(map[string]NumericTransform),
+		values:     make(map[string][]float32),
+	}
+	for _, transform := range transforms {
+		// numeric labels are converted to features named by labels with a trailing dot
+		builder.transforms[transform.Label+"."] = transform
+	}
+	return builder
+}
+
+// IsNumeric returns true if the feature is a declared numeric label.
+func (b *LabelTransformerBuilder) IsNumeric(feature Feature) bool {
+	_, ok := b.transforms[feature.Name]
+	return ok
+}
+
+// Add collects the value of a feature if it is a declared numeric label.
+func (b *LabelTransformerBuilder) Add(feature Feature) {
+	if _, ok := b.transforms[feature.Name]; ok {
+		b.values[feature.Name] = append(b.values[feature.Name], feature.Value)
+	}
+}
+
+// Build computes statistics of numeric labels. It returns nil if no transformation is declared.
+func (b *LabelTransformerBuilder) Build() *LabelTransformer {
+	if len(b.transforms) == 0 {
+		return nil
+	}
+	transformer := &LabelTransformer{Statistics: make(map[string]*NumericStatistics)}
+	for name, transform := range b.transforms {
+		stats := &NumericStatistics{Method: transform.Method}
+		values := b.values[name]
+		switch transform.Method {
+		case ZScoreTransform:
+			stats.Mean, stats.StdDev = meanAndStdDev(values)
+		case QuantileTransform:
+			stats.Boundaries = quantileBoundaries(values, lo.Ternary(transform.NumBuckets > 0, transform.NumBuckets, defaultNumBuckets))
+		}
+		transformer.Statistics[name] = stats
+	}
+	return transformer
+}
+
+func meanAndStdDev(values []float32) (mean, stdDev float32) {
+	if len(values) == 0 {
+		return 0, 0
+	}
+	for _, value := range values {
+		mean += value
+	}
+	mean /= float32(len(values))
+	for _, value := range values {
+		stdDev += (value - mean) * (value - mean)
+	}
+	return mean, math32.Sqrt(stdDev / float32(len(values)))
+}
+
+// quantileBoundaries returns distinct boundaries splitting values into buckets of nearly equal sizes. There are fewer
+// buckets if there are not enough distinct values.
+func quantileBoundaries(values []float32, numBuckets int) []float32 {
+	if len(values) == 0 {
+		return nil
+	}
+	sorted := make([]float32, len(values))
+	copy(sorted, values)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	var boundaries []float32
+	for i := 1; i < numBuckets; i++ {
+		boundary := sorted[i*len(sorted)/numBuckets]
+		if boundary > sorted[0] && (len(boundaries) == 0 || boundary > boundaries[len(boundaries)-1]) {
+			boundaries = append(boundaries, boundary)
+		}
+	}
+	return boundaries
+}
+
+// MarshalLabelTransformers writes transformers of numeric user labels and item labels, which are shipped with models
+// trained on transformed labels.
+func MarshalLabelTransformers(w io.Writer, userTransformer, itemTransformer *LabelTransformer) error {
+	if err := encoding.WriteGob(w, lo.FromPtr(userTransformer)); err != nil {
+		return errors.Trace(err)
+	}
+	return errors.Trace(encoding.WriteGob(w, lo.FromPtr(itemTransformer)))
+}
+
+// UnmarshalLabelTransformers reads transformers written by MarshalLabelTransformers. Nil transformers are returned if
+// the stream ends before transformers, which happens to models written by previous versions.
+func UnmarshalLabelTransformers(r io.Reader) (userTransformer, itemTransformer *LabelTransformer, err error) {
+	var user, item LabelTransformer
+	if err = encoding.ReadGob(r, &user); err != nil {
+		if err == io.EOF {
+			return nil, nil, nil
+		}
+		return nil, nil, errors.Trace(err)
+	}
+	if err = encoding.ReadGob(r, &item); err != nil {
+		return nil, nil, errors.Trace(err)
+	}
+	return nonEmptyTransformer(user), nonEmptyTransformer(item), nil
+}
+
+func nonEmptyTransformer(transformer LabelTransformer) *LabelTransformer {
+	if len(transformer.Statistics) == 0 {
+		return nil
+	}
+	return &transformer
+}
@@ -0,0 +1,128 @@
+// Copyright 2024 gorse Project Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package click
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/chewxy/math32"
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/assert"
+	"github.com/zhenghaoz/gorse/model"
+)
+
+func TestLabelTransformer(t *testing.T) {
+	builder := NewLabelTransformerBuilder([]NumericTransform{
+		{Label: "price", Method: LogTransform},
+		{Label: "size.width", Method: ZScoreTransform},
+		{Label: "rating", Method: QuantileTransform, NumBuckets: 4},
+		{Label: "stock", Method: PassThroughTransform},
+	})
+	for i := 0; i < 8; i++ {
+		for _, feature := range ConvertLabelsToFeatures(map[string]any{
+			"price":  json.Number("99"),
+			"size":   map[string]any{"width": float64(i)},
+			"rating": float64(i),
+			"stock":  float64(i),
+			"brand":  "gorse",
+		}) {
+			assert.Equal(t, feature.Name != "brand.gorse", builder.IsNumeric(feature))
+			builder.Add(feature)
+		}
+	}
+	transformer := builder.Build()
+	assert.Equal(t, []float32{2, 4, 6}, transformer.Statistics["rating."].Boundaries)
+	assert.InDelta(t, 3.5, transformer.Statistics["size.width."].Mean, 1e-6)
+	assert.InDelta(t, math32.Sqrt(5.25), transformer.Statistics["size.width."].StdDev, 1e-6)
+
+	features := transformer.Transform([]Feature{
+		{Name: "brand.gorse", Value: 1},
+		{Name: "price.", Value: 99},
+		{Name: "size.width.", Value: 3.5},
+		{Name: "rating.", Value: 5},
+		{Name: "stock.", Value: 3},
+	})
+	assert.Equal(t, []Feature{
+		{Name: "brand.gorse", Value: 1},
+		{Name: "price.", Value: math32.Log(100)},
+		{Name: "size.width.", Value: 0},
+		{Name: "rating[2]", Value: 1},
+		{Name: "stock.", Value: 3},
+	}, features)
+	assert.Equal(t, Feature{Name: "rating[0]", Value: 1}, transformer.TransformFeature(Feature{Name: "rating.", Value: -1}))
+	assert.Equal(t, Feature{Name: "rating[3]", Value: 1}, transformer.TransformFeature(Feature{Name: "rating.", Value: 100}))
+	assert.Equal(t, Feature{Name: "price.", Value: -math32.Log(100)}, transformer.TransformFeature(Feature{Name: "price.", Value: -99}))
+
+	// nil transformer
+	var nilTransformer *LabelTransformer
+	assert.Equal(t, features, nilTransformer.Transform(features))
+	assert.Nil(t, NewLabelTransformerBuilder(nil).Build())
+}
+
+func TestQuantileBoundaries(t *testing.T) {
+	assert.Nil(t, quantileBoundaries(nil, 10))
+	assert.Nil(t, quantileBoundaries([]float32{1, 1, 1}, 10))
+	assert.Equal(t, []float32{3}, quantileBoundaries([]float32{1, 1, 1, 3, 3, 3}, 3))
+}
+
+func TestFM_LabelTransformer(t *testing.T) {
+	// item features are bucketed prices
+	itemTransformer := &LabelTransformer{Statistics: map[string]*NumericStatistics{
+		"price.": {Method: QuantileTransform, Boundaries: []float32{100}},
+	}}
+	builder := NewUnifiedMapIndexBuilder()
+	builder.AddItem("cheap")
+	builder.AddItem("expensive")
+	builder.AddItemLabel("price[0]")
+	builder.AddItemLabel("price[1]")
+	dataset := &Dataset{
+		ItemFeatures:    [][]lo.Tuple2[int32, float32]{{{A: 0, B: 1}}, {{A: 1, B: 1}}},
+		ItemTransformer: itemTransformer,
+	}
+	for i := 0; i < 10; i++ {
+		builder.AddUser(string(rune('a' + i)))
+		dataset.UserFeatures = append(dataset.UserFeatures, nil)
+		dataset.Users.Append(int32(i))
+		dataset.Items.Append(0)
+		dataset.Target.Append(1)
+		dataset.Users.Append(int32(i))
+		dataset.Items.Append(1)
+		dataset.Target.Append(-1)
+	}
+	dataset.PositiveCount, dataset.NegativeCount = 10, 10
+	dataset.Index = builder.Build()
+	fm := NewFM(FMClassification, model.Params{model.NFactors: 16, model.NEpochs: 10})
+	fm.Fit(context.Background(), dataset, dataset, nil)
+	_, transformer := GetLabelTransformers(fm)
+	assert.Equal(t, itemTransformer, transformer)
+
+	// raw prices are transformed in prediction
+	cheap := fm.Predict("new", "new", nil, []Feature{{Name: "price.", Value: 10}}, nil)
+	expensive := fm.Predict("new", "new", nil, []Feature{{Name: "price.", Value: 1000}}, nil)
+	assert.Greater(t, cheap, expensive)
+
+	// transformers are shipped with the model
+	buf := bytes.NewBuffer(nil)
+	assert.NoError(t, MarshalModel(buf, fm))
+	m, err := UnmarshalModel(buf)
+	assert.NoError(t, err)
+	userTransformer, transformer := GetLabelTransformers(m)
+	assert.Nil(t, userTransformer)
+	assert.Equal(t, itemTransformer, transformer)
+	assert.Equal(t, cheap, m.Predict("new", "new", nil, []Feature{{Name: "price.", Value: 10}}, nil))
+}
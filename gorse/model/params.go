@@ -42,6 +42,9 @@ const (
 	BatchSize    ParamName = "BatchSize"
 	HiddenLayers ParamName = "HiddenLayers"
 	Optimizer    ParamName = "Optimizer"
+	MaxItems     ParamName = "MaxItems"     // maximum number of items in item-item models
+	MaxLength    ParamName = "MaxLength"    // maximum length of item sequences
+	EmbeddingDim ParamName = "EmbeddingDim" // dimension of dense embeddings
 
 	SGD  = "sgd"
 	Adam = "adam"
@@ -19,6 +19,7 @@ import (
 	"fmt"
 	"os"
 	"reflect"
+	"sort"
 	"strings"
 
 	mapset "github.com/deckarep/golang-set/v2"
@@ -39,9 +40,16 @@ type DataSet struct {
 	FeedbackItems  base.Array[int32]
 	UserFeedback   [][]int32
 	ItemFeedback   [][]int32
+	UserConfidence [][]float32 // confidence of UserFeedback
+	ItemConfidence [][]float32 // confidence of ItemFeedback
+	UserDecay      [][]float32 // time decay of UserFeedback, nil if feedback isn't decayed
+	ItemDecay      [][]float32 // time decay of ItemFeedback, nil if feedback isn't decayed
 	Negatives      [][]int32
 	ItemFeatures   [][]lo.Tuple2[int32, float32]
 	UserFeatures   [][]lo.Tuple2[int32, float32]
+	ItemLabelIndex base.Index  // names of item labels in ItemFeatures
+	UserLabelIndex base.Index  // names of user labels in UserFeatures
+	ItemEmbeddings [][]float32 // image embeddings of items, nil if absent
 	HiddenItems    []bool
 	ItemCategories [][]string
 	CategorySet    mapset.Set[string]
@@ -62,6 +70,8 @@ func NewMapIndexDataset() *DataSet {
 	// Initialize slices
 	s.UserFeedback = make([][]int32, 0)
 	s.ItemFeedback = make([][]int32, 0)
+	s.UserConfidence = make([][]float32, 0)
+	s.ItemConfidence = make([][]float32, 0)
 	return s
 }
 
@@ -73,6 +83,8 @@ func NewDirectIndexDataset() *DataSet {
 	// Initialize slices
 	dataset.UserFeedback = make([][]int32, 0)
 	dataset.ItemFeedback = make([][]int32, 0)
+	dataset.UserConfidence = make([][]float32, 0)
+	dataset.ItemConfidence = make([][]float32, 0)
 	dataset.Negatives = make([][]int32, 0)
 	return dataset
 }
@@ -88,6 +100,8 @@ func (dataset *DataSet) Bytes() int {
 	bytes += reflect.TypeOf(dataset.UserFeedback).Elem().Size() * uintptr(len(dataset.UserFeedback)+len(dataset.ItemFeedback))
 	bytes += reflect.TypeOf(dataset.UserFeedback).Elem().Elem().Size() * uintptr(dataset.Count()*2)
 	bytes += encoding.MatrixBytes(dataset.Negatives)
+	bytes += encoding.MatrixBytes(dataset.UserConfidence) + encoding.MatrixBytes(dataset.ItemConfidence)
+	bytes += encoding.MatrixBytes(dataset.UserDecay) + encoding.MatrixBytes(dataset.ItemDecay)
 
 	// ItemLabels + UserLabels
 	bytes += reflect.TypeOf(dataset.ItemFeatures).Elem().Size() * uintptr(len(dataset.ItemFeatures)+len(dataset.UserFeatures))
@@ -104,6 +118,9 @@ func (dataset *DataSet) AddUser(userId string) {
 	for int(userIndex) >= len(dataset.UserFeedback) {
 		dataset.UserFeedback = append(dataset.UserFeedback, make([]int32, 0))
 	}
+	for int(userIndex) >= len(dataset.UserConfidence) {
+		dataset.UserConfidence = append(dataset.UserConfidence, make([]float32, 0))
+	}
 }
 
 func (dataset *DataSet) AddItem(itemId string) {
@@ -112,9 +129,17 @@ func (dataset *DataSet) AddItem(itemId string) {
 	for int(itemIndex) >= len(dataset.ItemFeedback) {
 		dataset.ItemFeedback = append(dataset.ItemFeedback, make([]int32, 0))
 	}
+	for int(itemIndex) >= len(dataset.ItemConfidence) {
+		dataset.ItemConfidence = append(dataset.ItemConfidence, make([]float32, 0))
+	}
 }
 
 func (dataset *DataSet) AddFeedback(userId, itemId string, insertUserItem bool) {
+	dataset.AddWeightedFeedback(userId, itemId, 1, insertUserItem)
+}
+
+// AddWeightedFeedback adds a feedback with confidence. The confidence of feedback added by AddFeedback is 1.
+func (dataset *DataSet) AddWeightedFeedback(userId, itemId string, confidence float32, insertUserItem bool) {
 	if insertUserItem {
 		dataset.UserIndex.Add(userId)
 	}
@@ -124,11 +149,16 @@ func (dataset *DataSet) AddFeedback(userId, itemId string, insertUserItem bool)
 	userIndex := dataset.UserIndex.ToNumber(userId)
 	itemIndex := dataset.ItemIndex.ToNumber(itemId)
 	if userIndex != base.NotId && itemIndex != base.NotId {
-		dataset.AddRawFeedback(userIndex, itemIndex)
+		dataset.AddRawWeightedFeedback(userIndex, itemIndex, confidence)
 	}
 }
 
 func (dataset *DataSet) AddRawFeedback(userIndex, itemIndex int32) {
+	dataset.AddRawWeightedFeedback(userIndex, itemIndex, 1)
+}
+
+// AddRawWeightedFeedback adds a feedback with confidence by user index and item index.
+func (dataset *DataSet) AddRawWeightedFeedback(userIndex, itemIndex int32, confidence float32) {
 	dataset.FeedbackUsers.Append(userIndex)
 	dataset.FeedbackItems.Append(itemIndex)
 	for int(itemIndex) >= len(dataset.ItemFeedback) {
@@ -139,6 +169,104 @@ func (dataset *DataSet) AddRawFeedback(userIndex, itemIndex int32) {
 		dataset.UserFeedback = append(dataset.UserFeedback, make([]int32, 0))
 	}
 	dataset.UserFeedback[userIndex] = append(dataset.UserFeedback[userIndex], itemIndex)
+	for int(itemIndex) >= len(dataset.ItemConfidence) {
+		dataset.ItemConfidence = append(dataset.ItemConfidence, make([]float32, 0))
+	}
+	dataset.ItemConfidence[itemIndex] = append(dataset.ItemConfidence[itemIndex], confidence)
+	for int(userIndex) >= len(dataset.UserConfidence) {
+		dataset.UserConfidence = append(dataset.UserConfidence, make([]float32, 0))
+	}
+	dataset.UserConfidence[userIndex] = append(dataset.UserConfidence[userIndex], confidence)
+}
+
+// AddDecayedFeedback adds a feedback with confidence and time decay. The confidence should have been decayed.
+func (dataset *DataSet) AddDecayedFeedback(userId, itemId string, confidence, decay float32, insertUserItem bool) {
+	if insertUserItem {
+		dataset.UserIndex.Add(userId)
+		dataset.ItemIndex.Add(itemId)
+	}
+	userIndex := dataset.UserIndex.ToNumber(userId)
+	itemIndex := dataset.ItemIndex.ToNumber(itemId)
+	if userIndex != base.NotId && itemIndex != base.NotId {
+		dataset.AddRawDecayedFeedback(userIndex, itemIndex, confidence, decay)
+	}
+}
+
+// AddRawDecayedFeedback adds a feedback with confidence and time decay by user index and item index.
+func (dataset *DataSet) AddRawDecayedFeedback(userIndex, itemIndex int32, confidence, decay float32) {
+	dataset.AddRawWeightedFeedback(userIndex, itemIndex, confidence)
+	for int(itemIndex) >= len(dataset.ItemDecay) {
+		dataset.ItemDecay = append(dataset.ItemDecay, make([]float32, 0))
+	}
+	dataset.ItemDecay[itemIndex] = append(dataset.ItemDecay[itemIndex], decay)
+	for int(userIndex) >= len(dataset.UserDecay) {
+		dataset.UserDecay = append(dataset.UserDecay, make([]float32, 0))
+	}
+	dataset.UserDecay[userIndex] = append(dataset.UserDecay[userIndex], decay)
+}
+
+// IsDecayed returns true if feedback is decayed by time.
+func (dataset *DataSet) IsDecayed() bool {
+	return dataset.UserDecay != nil
+}
+
+// IsWeighted returns true if confidences of feedback are not all 1.
+func (dataset *DataSet) IsWeighted() bool {
+	if len(dataset.UserConfidence) != len(dataset.UserFeedback) || len(dataset.ItemConfidence) != len(dataset.ItemFeedback) {
+		return false
+	}
+	for _, confidences := range dataset.UserConfidence {
+		for _, confidence := range confidences {
+			if confidence != 1 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// SortUserFeedback sorts feedback of each user by item index. Confidence and decay are kept aligned with feedback.
+func (dataset *DataSet) SortUserFeedback() {
+	for i := range dataset.UserFeedback {
+		sortFeedback(dataset.UserFeedback[i], i, dataset.UserConfidence, dataset.UserDecay)
+	}
+}
+
+// SortItemFeedback sorts feedback of each item by user index. Confidence and decay are kept aligned with feedback.
+func (dataset *DataSet) SortItemFeedback() {
+	for i := range dataset.ItemFeedback {
+		sortFeedback(dataset.ItemFeedback[i], i, dataset.ItemConfidence, dataset.ItemDecay)
+	}
+}
+
+func sortFeedback(indices []int32, i int, aligned ...[][]float32) {
+	sorter := &feedbackSorter{indices: indices}
+	for _, values := range aligned {
+		if i < len(values) && len(values[i]) == len(indices) {
+			sorter.aligned = append(sorter.aligned, values[i])
+		}
+	}
+	sort.Sort(sorter)
+}
+
+type feedbackSorter struct {
+	indices []int32
+	aligned [][]float32
+}
+
+func (s *feedbackSorter) Len() int {
+	return len(s.indices)
+}
+
+func (s *feedbackSorter) Less(i, j int) bool {
+	return s.indices[i] < s.indices[j]
+}
+
+func (s *feedbackSorter) Swap(i, j int) {
+	s.indices[i], s.indices[j] = s.indices[j], s.indices[i]
+	for _, values := range s.aligned {
+		values[i], values[j] = values[j], values[i]
+	}
 }
 
 func (dataset *DataSet) SetNegatives(userId string, negatives []string) {
@@ -207,27 +335,49 @@ func (dataset *DataSet) Split(numTestUsers int, seed int64) (*DataSet, *DataSet)
 	trainSet.CategorySet, testSet.CategorySet = dataset.CategorySet, dataset.CategorySet
 	trainSet.ItemFeatures, testSet.ItemFeatures = dataset.ItemFeatures, dataset.ItemFeatures
 	trainSet.UserFeatures, testSet.UserFeatures = dataset.UserFeatures, dataset.UserFeatures
+	trainSet.ItemLabelIndex, testSet.ItemLabelIndex = dataset.ItemLabelIndex, dataset.ItemLabelIndex
+	trainSet.UserLabelIndex, testSet.UserLabelIndex = dataset.UserLabelIndex, dataset.UserLabelIndex
+	trainSet.ItemEmbeddings, testSet.ItemEmbeddings = dataset.ItemEmbeddings, dataset.ItemEmbeddings
 	trainSet.NumItemLabelUsed, testSet.NumItemLabelUsed = dataset.NumItemLabelUsed, dataset.NumItemLabelUsed
 	trainSet.NumUserLabelUsed, testSet.NumUserLabelUsed = dataset.NumUserLabelUsed, dataset.NumUserLabelUsed
 	trainSet.UserIndex, testSet.UserIndex = dataset.UserIndex, dataset.UserIndex
 	trainSet.ItemIndex, testSet.ItemIndex = dataset.ItemIndex, dataset.ItemIndex
 	trainSet.UserFeedback, testSet.UserFeedback = createSliceOfSlice(dataset.UserCount()), createSliceOfSlice(dataset.UserCount())
 	trainSet.ItemFeedback, testSet.ItemFeedback = createSliceOfSlice(dataset.ItemCount()), createSliceOfSlice(dataset.ItemCount())
+	trainSet.UserConfidence, testSet.UserConfidence = make([][]float32, dataset.UserCount()), make([][]float32, dataset.UserCount())
+	trainSet.ItemConfidence, testSet.ItemConfidence = make([][]float32, dataset.ItemCount()), make([][]float32, dataset.ItemCount())
+	weighted, decayed := dataset.IsWeighted(), dataset.IsDecayed()
+	if decayed {
+		trainSet.UserDecay, testSet.UserDecay = make([][]float32, dataset.UserCount()), make([][]float32, dataset.UserCount())
+		trainSet.ItemDecay, testSet.ItemDecay = make([][]float32, dataset.ItemCount()), make([][]float32, dataset.ItemCount())
+	}
+	add := func(set *DataSet, userIndex int32, k int) {
+		itemIndex := dataset.UserFeedback[userIndex][k]
+		confidence := float32(1)
+		if weighted {
+			confidence = dataset.UserConfidence[userIndex][k]
+		}
+		set.FeedbackUsers.Append(userIndex)
+		set.FeedbackItems.Append(itemIndex)
+		set.UserFeedback[userIndex] = append(set.UserFeedback[userIndex], itemIndex)
+		set.ItemFeedback[itemIndex] = append(set.ItemFeedback[itemIndex], userIndex)
+		set.UserConfidence[userIndex] = append(set.UserConfidence[userIndex], confidence)
+		set.ItemConfidence[itemIndex] = append(set.ItemConfidence[itemIndex], confidence)
+		if decayed {
+			decay := dataset.UserDecay[userIndex][k]
+			set.UserDecay[userIndex] = append(set.UserDecay[userIndex], decay)
+			set.ItemDecay[itemIndex] = append(set.ItemDecay[itemIndex], decay)
+		}
+	}
 	rng := base.NewRandomGenerator(seed)
 	if numTestUsers >= dataset.UserCount() || numTestUsers <= 0 {
 		for userIndex := int32(0); userIndex < int32(dataset.UserCount()); userIndex++ {
 			if len(dataset.UserFeedback[userIndex]) > 0 {
 				k := rng.Intn(len(dataset.UserFeedback[userIndex]))
-				testSet.FeedbackUsers.Append(userIndex)
-				testSet.FeedbackItems.Append(dataset.UserFeedback[userIndex][k])
-				testSet.UserFeedback[userIndex] = append(testSet.UserFeedback[userIndex], dataset.UserFeedback[userIndex][k])
-				testSet.ItemFeedback[dataset.UserFeedback[userIndex][k]] = append(testSet.ItemFeedback[dataset.UserFeedback[userIndex][k]], userIndex)
-				for i, itemIndex := range dataset.UserFeedback[userIndex] {
+				add(testSet, userIndex, k)
+				for i := range dataset.UserFeedback[userIndex] {
 					if i != k {
-						trainSet.FeedbackUsers.Append(userIndex)
-						trainSet.FeedbackItems.Append(itemIndex)
-						trainSet.UserFeedback[userIndex] = append(trainSet.UserFeedback[userIndex], itemIndex)
-						trainSet.ItemFeedback[itemIndex] = append(trainSet.ItemFeedback[itemIndex], userIndex)
+						add(trainSet, userIndex, i)
 					}
 				}
 			}
@@ -237,16 +387,10 @@ func (dataset *DataSet) Split(numTestUsers int, seed int64) (*DataSet, *DataSet)
 		for _, userIndex := range testUsers {
 			if len(dataset.UserFeedback[userIndex]) > 0 {
 				k := rng.Intn(len(dataset.UserFeedback[userIndex]))
-				testSet.FeedbackUsers.Append(userIndex)
-				testSet.FeedbackItems.Append(dataset.UserFeedback[userIndex][k])
-				testSet.UserFeedback[userIndex] = append(testSet.UserFeedback[userIndex], dataset.UserFeedback[userIndex][k])
-				testSet.ItemFeedback[dataset.UserFeedback[userIndex][k]] = append(testSet.ItemFeedback[dataset.UserFeedback[userIndex][k]], userIndex)
-				for i, itemIndex := range dataset.UserFeedback[userIndex] {
+				add(testSet, userIndex, k)
+				for i := range dataset.UserFeedback[userIndex] {
 					if i != k {
-						trainSet.FeedbackUsers.Append(userIndex)
-						trainSet.FeedbackItems.Append(itemIndex)
-						trainSet.UserFeedback[userIndex] = append(trainSet.UserFeedback[userIndex], itemIndex)
-						trainSet.ItemFeedback[itemIndex] = append(trainSet.ItemFeedback[itemIndex], userIndex)
+						add(trainSet, userIndex, i)
 					}
 				}
 			}
@@ -254,11 +398,8 @@ func (dataset *DataSet) Split(numTestUsers int, seed int64) (*DataSet, *DataSet)
 		testUserSet := mapset.NewSet(testUsers...)
 		for userIndex := int32(0); userIndex < int32(dataset.UserCount()); userIndex++ {
 			if !testUserSet.Contains(userIndex) {
-				for _, itemIndex := range dataset.UserFeedback[userIndex] {
-					trainSet.FeedbackUsers.Append(userIndex)
-					trainSet.FeedbackItems.Append(itemIndex)
-					trainSet.UserFeedback[userIndex] = append(trainSet.UserFeedback[userIndex], itemIndex)
-					trainSet.ItemFeedback[itemIndex] = append(trainSet.ItemFeedback[itemIndex], userIndex)
+				for i := range dataset.UserFeedback[userIndex] {
+					add(trainSet, userIndex, i)
 				}
 			}
 		}
@@ -69,3 +69,74 @@ func TestDataSet_Split(t *testing.T) {
 	assert.Equal(t, numItems, test2.ItemCount())
 	assert.Equal(t, 2, test2.Count())
 }
+
+func TestDataSet_AddWeightedFeedback(t *testing.T) {
+	dataset := NewMapIndexDataset()
+	assert.False(t, dataset.IsWeighted())
+	dataset.AddFeedback("0", "0", true)
+	dataset.AddWeightedFeedback("0", "1", 2, true)
+	dataset.AddWeightedFeedback("1", "1", 3, true)
+	assert.True(t, dataset.IsWeighted())
+	assert.Equal(t, [][]float32{{1, 2}, {3}}, dataset.UserConfidence)
+	assert.Equal(t, [][]float32{{1}, {2, 3}}, dataset.ItemConfidence)
+	// confidences follow feedback after split
+	train, test := dataset.Split(0, 0)
+	for _, set := range []*DataSet{train, test} {
+		for userIndex, items := range set.UserFeedback {
+			for j, itemIndex := range items {
+				assert.Equal(t, float32(userIndex+int(itemIndex)+1), set.UserConfidence[userIndex][j])
+			}
+		}
+		for itemIndex, users := range set.ItemFeedback {
+			for j, userIndex := range users {
+				assert.Equal(t, float32(int(userIndex)+itemIndex+1), set.ItemConfidence[itemIndex][j])
+			}
+		}
+	}
+}
+
+func TestDataSet_SortFeedback(t *testing.T) {
+	dataset := NewDirectIndexDataset()
+	dataset.AddWeightedFeedback("0", "2", 3, true)
+	dataset.AddWeightedFeedback("0", "0", 1, true)
+	dataset.AddWeightedFeedback("1", "0", 2, true)
+	dataset.AddWeightedFeedback("0", "1", 2, true)
+	dataset.AddWeightedFeedback("2", "0", 3, true)
+	dataset.AddWeightedFeedback("1", "1", 4, true)
+	dataset.SortUserFeedback()
+	assert.Equal(t, [][]int32{{0, 1, 2}, {0, 1}, {0}}, dataset.UserFeedback)
+	assert.Equal(t, [][]float32{{1, 2, 3}, {2, 4}, {3}}, dataset.UserConfidence)
+	dataset.SortItemFeedback()
+	assert.Equal(t, [][]int32{{0, 1, 2}, {0, 1}, {0}}, dataset.ItemFeedback)
+	assert.Equal(t, [][]float32{{1, 2, 3}, {2, 4}, {3}}, dataset.ItemConfidence)
+}
+
+func TestDataSet_AddDecayedFeedback(t *testing.T) {
+	dataset := NewDirectIndexDataset()
+	dataset.AddDecayedFeedback("0", "1", 2, 0.5, true)
+	dataset.AddDecayedFeedback("0", "0", 1, 1, true)
+	dataset.AddDecayedFeedback("1", "0", 0.2, 0.1, true)
+	assert.True(t, dataset.IsDecayed())
+	dataset.SortUserFeedback()
+	assert.Equal(t, [][]int32{{0, 1}, {0}}, dataset.UserFeedback)
+	assert.Equal(t, [][]float32{{1, 2}, {0.2}}, dataset.UserConfidence)
+	assert.Equal(t, [][]float32{{1, 0.5}, {0.1}}, dataset.UserDecay)
+	assert.Equal(t, [][]float32{{1, 0.1}, {0.5}}, dataset.ItemDecay)
+
+	// decay is kept aligned with feedback after split
+	trainSet, testSet := dataset.Split(0, 0)
+	for _, set := range []*DataSet{trainSet, testSet} {
+		for userIndex, items := range set.UserFeedback {
+			for j, itemIndex := range items {
+				assert.Equal(t, map[[2]int32]float32{{0, 0}: 1, {0, 1}: 0.5, {1, 0}: 0.1}[[2]int32{int32(userIndex), itemIndex}],
+					set.UserDecay[userIndex][j])
+			}
+		}
+		for itemIndex, users := range set.ItemFeedback {
+			for j, userIndex := range users {
+				assert.Equal(t, map[[2]int32]float32{{0, 0}: 1, {0, 1}: 0.5, {1, 0}: 0.1}[[2]int32{userIndex, int32(itemIndex)}],
+					set.ItemDecay[itemIndex][j])
+			}
+		}
+	}
+}
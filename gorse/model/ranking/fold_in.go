@@ -0,0 +1,142 @@
+// Copyright 2024 gorse Project Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ranking
+
+import (
+	"sync"
+
+	"github.com/zhenghaoz/gorse/base"
+	"github.com/zhenghaoz/gorse/model"
+	"gonum.org/v1/gonum/mat"
+)
+
+// FoldIn solves latent factors of users or items absent from the last fit, while factors of their counterparts are
+// frozen. A user is folded in by solving the objective of implicit ALS against item factors
+//
+//	(Y^T Y + alpha \sum_{i \in R} y_i y_i^T + reg I) x = (1 + alpha) \sum_{i \in R} y_i
+//
+// where Y are factors of predictable items and R are items the user interacted with. Items are folded in likewise.
+// EASE isn't supported since its user factors are feedback rather than latent factors.
+type FoldIn struct {
+	model    MatrixFactorization
+	reg      float64
+	alpha    float64
+	userGram *mat.SymDense
+	itemGram *mat.SymDense
+	mutex    sync.Mutex
+}
+
+// NewFoldIn creates a FoldIn on a fitted model. Regularization and confidence are taken from the model.
+func NewFoldIn(m MatrixFactorization) *FoldIn {
+	return &FoldIn{
+		model: m,
+		reg:   float64(m.GetParams().GetFloat32(model.Reg, 0.1)),
+		alpha: float64(m.GetParams().GetFloat32(model.Alpha, 1)),
+	}
+}
+
+// FoldInUser returns the factor of a user interacted with given items. It returns nil if none of the items is
+// predictable.
+func (f *FoldIn) FoldInUser(itemIds []string) []float32 {
+	if _, isEASE := f.model.(*EASE); isEASE || f.model.Invalid() {
+		return nil
+	}
+	factors := counterpartFactors(itemIds, f.model.GetItemIndex(), f.model.IsItemPredictable, f.model.GetItemFactor)
+	if len(factors) == 0 {
+		return nil
+	}
+	f.mutex.Lock()
+	if f.itemGram == nil {
+		f.itemGram = gram(f.model.GetItemIndex(), f.model.IsItemPredictable, f.model.GetItemFactor, len(factors[0]))
+	}
+	f.mutex.Unlock()
+	return f.solve(f.itemGram, factors)
+}
+
+// FoldInItem returns the factor of an item interacted by given users. It returns nil if none of the users is
+// predictable.
+func (f *FoldIn) FoldInItem(userIds []string) []float32 {
+	if _, isEASE := f.model.(*EASE); isEASE || f.model.Invalid() {
+		return nil
+	}
+	factors := counterpartFactors(userIds, f.model.GetUserIndex(), f.model.IsUserPredictable, f.model.GetUserFactor)
+	if len(factors) == 0 {
+		return nil
+	}
+	f.mutex.Lock()
+	if f.userGram == nil {
+		f.userGram = gram(f.model.GetUserIndex(), f.model.IsUserPredictable, f.model.GetUserFactor, len(factors[0]))
+	}
+	f.mutex.Unlock()
+	return f.solve(f.userGram, factors)
+}
+
+func (f *FoldIn) solve(gram *mat.SymDense, factors [][]float32) []float32 {
+	n := gram.SymmetricDim()
+	a := mat.NewSymDense(n, nil)
+	a.CopySym(gram)
+	b := mat.NewVecDense(n, nil)
+	for _, y := range factors {
+		for i := 0; i < n; i++ {
+			b.SetVec(i, b.AtVec(i)+(1+f.alpha)*float64(y[i]))
+			for j := i; j < n; j++ {
+				a.SetSym(i, j, a.At(i, j)+f.alpha*float64(y[i])*float64(y[j]))
+			}
+		}
+	}
+	for i := 0; i < n; i++ {
+		a.SetSym(i, i, a.At(i, i)+f.reg)
+	}
+	var chol mat.Cholesky
+	if ok := chol.Factorize(a); !ok {
+		return nil
+	}
+	var x mat.VecDense
+	if err := chol.SolveVecTo(&x, b); err != nil {
+		return nil
+	}
+	factor := make([]float32, n)
+	for i := range factor {
+		factor[i] = float32(x.AtVec(i))
+	}
+	return factor
+}
+
+// counterpartFactors collects factors of predictable users or items by their names.
+func counterpartFactors(names []string, index base.Index, predictable func(int32) bool, factor func(int32) []float32) [][]float32 {
+	var factors [][]float32
+	for _, name := range names {
+		if i := index.ToNumber(name); i != base.NotId && predictable(i) {
+			factors = append(factors, factor(i))
+		}
+	}
+	return factors
+}
+
+// gram computes \sum_i y_i y_i^T over factors of predictable users or items.
+func gram(index base.Index, predictable func(int32) bool, factor func(int32) []float32, n int) *mat.SymDense {
+	g := mat.NewSymDense(n, nil)
+	for i := int32(0); i < index.Len(); i++ {
+		if predictable(i) {
+			y := factor(i)
+			for j := 0; j < n; j++ {
+				for k := j; k < n; k++ {
+					g.SetSym(j, k, g.At(j, k)+float64(y[j])*float64(y[k]))
+				}
+			}
+		}
+	}
+	return g
+}
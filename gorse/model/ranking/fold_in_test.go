@@ -0,0 +1,65 @@
+// Copyright 2024 gorse Project Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ranking
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zhenghaoz/gorse/base/floats"
+	"github.com/zhenghaoz/gorse/model"
+)
+
+func TestFoldIn(t *testing.T) {
+	trainSet, testSet := newWeightedDataset()
+	m := NewALS(model.Params{
+		model.NFactors: 16,
+		model.NEpochs:  30,
+	})
+	m.Fit(context.Background(), trainSet, testSet, newFitConfig(30))
+	foldIn := NewFoldIn(m)
+
+	// new user interacted with item 0, 1, 2, 3 and 4
+	userFactor := foldIn.FoldInUser([]string{"0", "1", "2", "3", "4", "unknown"})
+	assert.Len(t, userFactor, 16)
+	var positive, negative float32
+	for i := 0; i < 5; i++ {
+		positive += floats.Dot(userFactor, m.GetItemFactor(trainSet.ItemIndex.ToNumber(strconv.Itoa(i))))
+		negative += floats.Dot(userFactor, m.GetItemFactor(trainSet.ItemIndex.ToNumber(strconv.Itoa(i+10))))
+	}
+	assert.Greater(t, positive, negative)
+	assert.Nil(t, foldIn.FoldInUser([]string{"unknown"}))
+
+	// new item interacted by users interacted with item 0
+	var users []string
+	for _, userIndex := range trainSet.ItemFeedback[trainSet.ItemIndex.ToNumber("0")] {
+		users = append(users, trainSet.UserIndex.ToName(userIndex))
+	}
+	itemFactor := foldIn.FoldInItem(users)
+	assert.Len(t, itemFactor, 16)
+	var score float32
+	for _, userId := range users {
+		score += floats.Dot(itemFactor, m.GetUserFactor(trainSet.UserIndex.ToNumber(userId)))
+	}
+	assert.Greater(t, score/float32(len(users)), meanScore(m, trainSet, "10"))
+	assert.Nil(t, foldIn.FoldInItem(nil))
+
+	// EASE isn't supported
+	ease := NewEASE(model.Params{model.Reg: 10})
+	ease.Fit(context.Background(), trainSet, testSet, newFitConfig(1))
+	assert.Nil(t, NewFoldIn(ease).FoldInUser([]string{"0"}))
+}
@@ -16,9 +16,13 @@ package ranking
 
 import (
 	"context"
+	"encoding/binary"
 	"fmt"
+	"hash/fnv"
 	"io"
 	"reflect"
+	"slices"
+	"sort"
 	"time"
 
 	"github.com/bits-and-blooms/bitset"
@@ -36,6 +40,7 @@ import (
 	"github.com/zhenghaoz/gorse/base/task"
 	"github.com/zhenghaoz/gorse/model"
 	"go.uber.org/zap"
+	"gonum.org/v1/gonum/mat"
 )
 
 type Score struct {
@@ -245,8 +250,10 @@ func Clone(m MatrixFactorization) MatrixFactorization {
 }
 
 const (
-	CollaborativeBPR = "bpr"
-	CollaborativeCCD = "ccd"
+	CollaborativeBPR  = "bpr"
+	CollaborativeCCD  = "ccd"
+	CollaborativeALS  = "als"
+	CollaborativeEASE = "ease"
 )
 
 func GetModelName(m Model) string {
@@ -255,6 +262,10 @@ func GetModelName(m Model) string {
 		return CollaborativeBPR
 	case *CCD:
 		return CollaborativeCCD
+	case *ALS:
+		return CollaborativeALS
+	case *EASE:
+		return CollaborativeEASE
 	default:
 		return reflect.TypeOf(m).String()
 	}
@@ -288,10 +299,263 @@ func UnmarshalModel(r io.Reader) (MatrixFactorization, error) {
 			return nil, errors.Trace(err)
 		}
 		return &ccd, nil
+	case "als":
+		var als ALS
+		if err := als.Unmarshal(r); err != nil {
+			return nil, errors.Trace(err)
+		}
+		return &als, nil
+	case "ease":
+		var ease EASE
+		if err := ease.Unmarshal(r); err != nil {
+			return nil, errors.Trace(err)
+		}
+		return &ease, nil
 	}
 	return nil, fmt.Errorf("unknown model %v", name)
 }
 
+// DeltaTolerance is the largest difference of a factor element ignored by AlignModel. Retraining perturbs factors of
+// all users and items slightly, so factors within the tolerance are kept from the base.
+const DeltaTolerance = 1e-3
+
+// ErrBaseMismatch is returned by UnmarshalModelDelta if the base differs from the base of the delta, in which case the
+// whole model should be transferred instead.
+var ErrBaseMismatch = errors.New("base model mismatches the base of the delta")
+
+// AlignModel keeps factors of a model from its base if no element differs from the base by more than DeltaTolerance.
+// The base is the model served before retraining, so the delta between them is small. Every factor of the aligned
+// model differs from the fitted one by at most DeltaTolerance, so errors don't accumulate across versions. Models of
+// different names, params or numbers of factors are left unchanged.
+func AlignModel(baseModel, m MatrixFactorization) {
+	if baseModel.Invalid() || m.Invalid() || GetModelName(m) != GetModelName(baseModel) ||
+		m.GetParams().ToString() != baseModel.GetParams().ToString() {
+		return
+	}
+	src, srcFactors, err := matrixFactorization(baseModel)
+	if err != nil {
+		return
+	}
+	dst, dstFactors, err := matrixFactorization(m)
+	if err != nil || srcFactors != dstFactors {
+		return
+	}
+	alignFactors(src.UserIndex, src.UserFactor, dst.UserIndex, dst.UserFactor)
+	alignFactors(src.ItemIndex, src.ItemFactor, dst.ItemIndex, dst.ItemFactor)
+}
+
+// ErrDeltaNotSmaller is returned by MarshalModelDelta if the delta isn't smaller than factors of the whole model, in
+// which case the whole model should be transferred instead.
+var ErrDeltaNotSmaller = errors.New("model delta is not smaller than the model")
+
+// MarshalModelDelta writes the difference between a model and its base into byte stream. Hyper-parameters, indices
+// and predictable flags are written in full, while only factors of new users and items and factors differing from
+// the base are written, so the delta is exact. The checksum of the base is written as well, so that the delta is only
+// applied to the same base. Use AlignModel to keep factors barely changed by retraining from the base.
+func MarshalModelDelta(w io.Writer, baseModel, m MatrixFactorization) error {
+	if GetModelName(m) != GetModelName(baseModel) || m.GetParams().ToString() != baseModel.GetParams().ToString() {
+		return errors.NotValidf("delta between different models")
+	}
+	src, srcFactors, err := matrixFactorization(baseModel)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	dst, dstFactors, err := matrixFactorization(m)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if srcFactors != dstFactors {
+		return errors.NotValidf("delta from %v factors to %v factors", srcFactors, dstFactors)
+	}
+	changedUsers := changedFactors(src.UserIndex, src.UserFactor, dst.UserIndex, dst.UserFactor)
+	changedItems := changedFactors(src.ItemIndex, src.ItemFactor, dst.ItemIndex, dst.ItemFactor)
+	// each changed factor is written with its position
+	deltaSize := (len(changedUsers) + len(changedItems)) * (dstFactors + 1)
+	if deltaSize > 0 && deltaSize >= int(dst.UserIndex.Len()+dst.ItemIndex.Len())*dstFactors {
+		return ErrDeltaNotSmaller
+	}
+	if err = encoding.WriteString(w, GetModelName(m)); err != nil {
+		return errors.Trace(err)
+	}
+	if err = binary.Write(w, binary.LittleEndian, factorChecksum(src)); err != nil {
+		return errors.Trace(err)
+	}
+	if err = dst.Marshal(w); err != nil {
+		return errors.Trace(err)
+	}
+	if err = writeFactorDelta(w, changedUsers, dst.UserFactor); err != nil {
+		return errors.Trace(err)
+	}
+	return writeFactorDelta(w, changedItems, dst.ItemFactor)
+}
+
+// UnmarshalModelDelta reads the difference written by MarshalModelDelta and applies it to the base. The base is
+// not modified and unchanged factors are shared between the base and the returned model. ErrBaseMismatch is returned
+// if the base isn't the base of the delta.
+func UnmarshalModelDelta(r io.Reader, baseModel MatrixFactorization) (MatrixFactorization, error) {
+	name, err := encoding.ReadString(r)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if name != GetModelName(baseModel) {
+		return nil, errors.NotValidf("delta of %v for %v", name, GetModelName(baseModel))
+	}
+	var checksum uint64
+	if err = binary.Read(r, binary.LittleEndian, &checksum); err != nil {
+		return nil, errors.Trace(err)
+	}
+	var m MatrixFactorization
+	switch name {
+	case CollaborativeBPR:
+		m = new(BPR)
+	case CollaborativeCCD:
+		m = new(CCD)
+	case CollaborativeALS:
+		m = new(ALS)
+	case CollaborativeEASE:
+		m = new(EASE)
+	default:
+		return nil, fmt.Errorf("unknown model %v", name)
+	}
+	dst, _, err := matrixFactorization(m)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if err = dst.Unmarshal(r); err != nil {
+		return nil, errors.Trace(err)
+	}
+	m.SetParams(dst.Params)
+	if m.GetParams().ToString() != baseModel.GetParams().ToString() {
+		return nil, errors.NotValidf("delta with params %v for params %v", m.GetParams(), baseModel.GetParams())
+	}
+	src, nFactors, err := matrixFactorization(baseModel)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if factorChecksum(src) != checksum {
+		return nil, ErrBaseMismatch
+	}
+	dst.UserFactor, err = readFactorDelta(r, src.UserIndex, src.UserFactor, dst.UserIndex, nFactors)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	dst.ItemFactor, err = readFactorDelta(r, src.ItemIndex, src.ItemFactor, dst.ItemIndex, nFactors)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return m, nil
+}
+
+func matrixFactorization(m MatrixFactorization) (*BaseMatrixFactorization, int, error) {
+	switch m := m.(type) {
+	case *BPR:
+		return &m.BaseMatrixFactorization, m.nFactors, nil
+	case *CCD:
+		return &m.BaseMatrixFactorization, m.nFactors, nil
+	case *ALS:
+		return &m.BaseMatrixFactorization, m.nFactors, nil
+	case *EASE:
+		return &m.BaseMatrixFactorization, m.dimension(), nil
+	default:
+		return nil, 0, errors.NotSupportedf("delta of %v", GetModelName(m))
+	}
+}
+
+// alignFactors copies factors of the base to factors with no element differing by more than DeltaTolerance.
+func alignFactors(baseIndex base.Index, baseFactor [][]float32, index base.Index, factor [][]float32) {
+	for i, name := range index.GetNames() {
+		j := baseIndex.ToNumber(name)
+		if j == base.NotId || len(baseFactor[j]) != len(factor[i]) {
+			continue
+		}
+		aligned := true
+		for k := range factor[i] {
+			if math32.Abs(baseFactor[j][k]-factor[i][k]) > DeltaTolerance {
+				aligned = false
+				break
+			}
+		}
+		if aligned {
+			copy(factor[i], baseFactor[j])
+		}
+	}
+}
+
+// changedFactors returns positions of factors which are new or differ from the base.
+func changedFactors(baseIndex base.Index, baseFactor [][]float32, index base.Index, factor [][]float32) []int32 {
+	changed := make([]int32, 0)
+	for i, name := range index.GetNames() {
+		j := baseIndex.ToNumber(name)
+		if j == base.NotId || !slices.Equal(baseFactor[j], factor[i]) {
+			changed = append(changed, int32(i))
+		}
+	}
+	return changed
+}
+
+// factorChecksum returns the checksum of factors of users and items with their names.
+func factorChecksum(m *BaseMatrixFactorization) uint64 {
+	h := fnv.New64a()
+	for _, factors := range []struct {
+		index  base.Index
+		factor [][]float32
+	}{{m.UserIndex, m.UserFactor}, {m.ItemIndex, m.ItemFactor}} {
+		for i, name := range factors.index.GetNames() {
+			_, _ = h.Write([]byte(name))
+			_ = binary.Write(h, binary.LittleEndian, factors.factor[i])
+		}
+	}
+	return h.Sum64()
+}
+
+// writeFactorDelta writes positions and values of changed factors.
+func writeFactorDelta(w io.Writer, changed []int32, factor [][]float32) error {
+	if err := binary.Write(w, binary.LittleEndian, int32(len(changed))); err != nil {
+		return errors.Trace(err)
+	}
+	if err := binary.Write(w, binary.LittleEndian, changed); err != nil {
+		return errors.Trace(err)
+	}
+	for _, i := range changed {
+		if err := binary.Write(w, binary.LittleEndian, factor[i]); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	return nil
+}
+
+// readFactorDelta reads factors written by writeFactorDelta and fills the rest from the base.
+func readFactorDelta(r io.Reader, baseIndex base.Index, baseFactor [][]float32, index base.Index, nFactors int) ([][]float32, error) {
+	var n int32
+	if err := binary.Read(r, binary.LittleEndian, &n); err != nil {
+		return nil, errors.Trace(err)
+	}
+	changed := make([]int32, n)
+	if err := binary.Read(r, binary.LittleEndian, changed); err != nil {
+		return nil, errors.Trace(err)
+	}
+	factor := make([][]float32, index.Len())
+	for _, i := range changed {
+		if i < 0 || int(i) >= len(factor) {
+			return nil, errors.NotValidf("factor position %v", i)
+		}
+		factor[i] = make([]float32, nFactors)
+		if err := binary.Read(r, binary.LittleEndian, factor[i]); err != nil {
+			return nil, errors.Trace(err)
+		}
+	}
+	for i, name := range index.GetNames() {
+		if factor[i] == nil {
+			j := baseIndex.ToNumber(name)
+			if j == base.NotId {
+				return nil, errors.NotFoundf("factor of %v", name)
+			}
+			factor[i] = baseFactor[j]
+		}
+	}
+	return factor, nil
+}
+
 // BPR means Bayesian Personal Ranking, is a pairwise learning algorithm for matrix factorization
 // model with implicit feedback. The pairwise ranking between item i and j for user u is estimated
 // by:
@@ -409,6 +673,19 @@ func (bpr *BPR) Fit(ctx context.Context, trainSet, valSet *DataSet, config *FitC
 			userFeedback[u].Add(i)
 		}
 	}
+	// Positive items are sampled in proportion to confidence if feedback is weighted
+	var cumConfidence [][]float32
+	if trainSet.IsWeighted() {
+		cumConfidence = make([][]float32, trainSet.UserCount())
+		for u := range cumConfidence {
+			cumConfidence[u] = make([]float32, len(trainSet.UserConfidence[u]))
+			var sum float32
+			for j, confidence := range trainSet.UserConfidence[u] {
+				sum += confidence
+				cumConfidence[u][j] = sum
+			}
+		}
+	}
 	snapshots := SnapshotManger{}
 	evalStart := time.Now()
 	scores := Evaluate(bpr, valSet, trainSet, config.TopK, config.Candidates, config.AvailableJobs(), NDCG, Precision, Recall)
@@ -437,7 +714,15 @@ func (bpr *BPR) Fit(ctx context.Context, trainSet, valSet *DataSet, config *FitC
 					break
 				}
 			}
-			posIndex := trainSet.UserFeedback[userIndex][rng[workerId].Intn(ratingCount)]
+			var posIndex int32
+			if cumConfidence != nil {
+				r := rng[workerId].Float32() * cumConfidence[userIndex][ratingCount-1]
+				posIndex = trainSet.UserFeedback[userIndex][min(sort.Search(ratingCount, func(j int) bool {
+					return cumConfidence[userIndex][j] > r
+				}), ratingCount-1)]
+			} else {
+				posIndex = trainSet.UserFeedback[userIndex][rng[workerId].Intn(ratingCount)]
+			}
 			// Select a negative sample
 			negIndex := int32(-1)
 			for {
@@ -711,6 +996,8 @@ func (ccd *CCD) Fit(ctx context.Context, trainSet, valSet *DataSet, config *FitC
 		zap.Any("params", ccd.GetParams()),
 		zap.Any("config", config))
 	ccd.Init(trainSet)
+	// Observed feedback is weighted by confidence if feedback is weighted
+	weighted := trainSet.IsWeighted()
 	// Create temporary matrix
 	maxJobs := config.MaxJobs()
 	s := base.NewMatrix32(ccd.nFactors, ccd.nFactors)
@@ -763,9 +1050,13 @@ func (ccd *CCD) Fit(ctx context.Context, trainSet, valSet *DataSet, config *FitC
 				}
 				// p_{uf} <-
 				a, b, c := float32(0), float32(0), float32(0)
-				for _, i := range userFeedback {
-					a += (1 - (1-ccd.weight)*userRes[workerId][i]) * ccd.ItemFactor[i][f]
-					c += (1 - ccd.weight) * ccd.ItemFactor[i][f] * ccd.ItemFactor[i][f]
+				for j, i := range userFeedback {
+					confidence := float32(1)
+					if weighted {
+						confidence = trainSet.UserConfidence[userIndex][j]
+					}
+					a += (confidence - (confidence-ccd.weight)*userRes[workerId][i]) * ccd.ItemFactor[i][f]
+					c += (confidence - ccd.weight) * ccd.ItemFactor[i][f] * ccd.ItemFactor[i][f]
 				}
 				for k := 0; k < ccd.nFactors; k++ {
 					if k != f {
@@ -804,9 +1095,13 @@ func (ccd *CCD) Fit(ctx context.Context, trainSet, valSet *DataSet, config *FitC
 				}
 				// q_{if} <-
 				a, b, c := float32(0), float32(0), float32(0)
-				for _, u := range itemFeedback {
-					a += (1 - (1-ccd.weight)*itemRes[workerId][u]) * ccd.UserFactor[u][f]
-					c += (1 - ccd.weight) * ccd.UserFactor[u][f] * ccd.UserFactor[u][f]
+				for j, u := range itemFeedback {
+					confidence := float32(1)
+					if weighted {
+						confidence = trainSet.ItemConfidence[itemIndex][j]
+					}
+					a += (confidence - (confidence-ccd.weight)*itemRes[workerId][u]) * ccd.UserFactor[u][f]
+					c += (confidence - ccd.weight) * ccd.UserFactor[u][f] * ccd.UserFactor[u][f]
 				}
 				for k := 0; k < ccd.nFactors; k++ {
 					if k != f {
@@ -892,3 +1187,616 @@ func (ccd *CCD) Unmarshal(r io.Reader) error {
 	}
 	return nil
 }
+
+// alsSteps is the number of conjugate gradient steps to solve a least squares problem in ALS.
+const alsSteps = 3
+
+// ALS means implicit Alternating Least Squares, a matrix factorization model with implicit feedback. Each observed
+// feedback is weighted by confidence c_{ui} = 1 + alpha w_{ui}, where w_{ui} is the weight of the feedback (1 if
+// feedback is not weighted), and unobserved feedback is weighted by 1. Least squares problems are solved
+// approximately by a few conjugate gradient steps starting from the factors of the previous epoch.
+//
+//	Takács, Gábor, István Pilászy, and Domonkos Tikk. "Applications of the conjugate gradient method for implicit
+//	feedback collaborative filtering." Proceedings of the fifth ACM conference on Recommender systems. 2011.
+//
+// Hyper-parameters:
+//
+//	 Reg 		- The regularization parameter of the cost function that is
+//				  optimized. Default is 0.1.
+//	 Alpha		- The scale of confidence of observed feedback. Default is 1.
+//	 nFactors	- The number of latent factors. Default is 16.
+//	 NEpochs	- The number of alternating epochs. Default is 50.
+//	 InitMean	- The mean of initial random latent factors. Default is 0.
+//	 InitStdDev	- The standard deviation of initial random latent factors. Default is 0.1.
+type ALS struct {
+	BaseMatrixFactorization
+	// Hyper parameters
+	nFactors   int
+	nEpochs    int
+	reg        float32
+	alpha      float32
+	initMean   float32
+	initStdDev float32
+}
+
+// NewALS creates an implicit ALS model.
+func NewALS(params model.Params) *ALS {
+	als := new(ALS)
+	als.SetParams(params)
+	return als
+}
+
+// GetUserFactor returns latent factor of a user.
+func (als *ALS) GetUserFactor(userIndex int32) []float32 {
+	return als.UserFactor[userIndex]
+}
+
+// GetItemFactor returns latent factor of an item.
+func (als *ALS) GetItemFactor(itemIndex int32) []float32 {
+	return als.ItemFactor[itemIndex]
+}
+
+// SetParams sets hyper-parameters for the ALS model.
+func (als *ALS) SetParams(params model.Params) {
+	als.BaseMatrixFactorization.SetParams(params)
+	als.nFactors = als.Params.GetInt(model.NFactors, 16)
+	als.nEpochs = als.Params.GetInt(model.NEpochs, 50)
+	als.initMean = als.Params.GetFloat32(model.InitMean, 0)
+	als.initStdDev = als.Params.GetFloat32(model.InitStdDev, 0.1)
+	als.reg = als.Params.GetFloat32(model.Reg, 0.1)
+	als.alpha = als.Params.GetFloat32(model.Alpha, 1)
+}
+
+func (als *ALS) GetParamsGrid(withSize bool) model.ParamsGrid {
+	return model.ParamsGrid{
+		model.NFactors:   lo.If(withSize, []interface{}{8, 16, 32, 64}).Else([]interface{}{16}),
+		model.InitMean:   []interface{}{0},
+		model.InitStdDev: []interface{}{0.001, 0.005, 0.01, 0.05, 0.1},
+		model.Reg:        []interface{}{0.01, 0.05, 0.1, 0.5, 1},
+		model.Alpha:      []interface{}{1, 5, 10, 20, 40},
+	}
+}
+
+// Predict by the ALS model.
+func (als *ALS) Predict(userId, itemId string) float32 {
+	userIndex := als.UserIndex.ToNumber(userId)
+	itemIndex := als.ItemIndex.ToNumber(itemId)
+	if userIndex == base.NotId {
+		log.Logger().Info("unknown user:", zap.String("user_id", userId))
+		return 0
+	}
+	if itemIndex == base.NotId {
+		log.Logger().Info("unknown item:", zap.String("item_id", itemId))
+		return 0
+	}
+	return als.InternalPredict(userIndex, itemIndex)
+}
+
+func (als *ALS) InternalPredict(userIndex, itemIndex int32) float32 {
+	ret := float32(0.0)
+	if itemIndex != base.NotId && userIndex != base.NotId {
+		ret = floats.Dot(als.UserFactor[userIndex], als.ItemFactor[itemIndex])
+	} else {
+		log.Logger().Warn("unknown user or item")
+	}
+	return ret
+}
+
+func (als *ALS) Clear() {
+	als.UserIndex = nil
+	als.ItemIndex = nil
+	als.ItemFactor = nil
+	als.UserFactor = nil
+}
+
+func (als *ALS) Invalid() bool {
+	return als == nil ||
+		als.UserIndex == nil ||
+		als.ItemIndex == nil ||
+		als.ItemFactor == nil ||
+		als.UserFactor == nil
+}
+
+func (als *ALS) Init(trainSet *DataSet) {
+	// Initialize
+	newUserFactor := als.GetRandomGenerator().NormalMatrix(trainSet.UserCount(), als.nFactors, als.initMean, als.initStdDev)
+	newItemFactor := als.GetRandomGenerator().NormalMatrix(trainSet.ItemCount(), als.nFactors, als.initMean, als.initStdDev)
+	// Relocate parameters
+	if als.UserIndex != nil {
+		for _, userId := range trainSet.UserIndex.GetNames() {
+			oldIndex := als.UserIndex.ToNumber(userId)
+			newIndex := trainSet.UserIndex.ToNumber(userId)
+			if oldIndex != base.NotId {
+				newUserFactor[newIndex] = als.UserFactor[oldIndex]
+			}
+		}
+	}
+	if als.ItemIndex != nil {
+		for _, itemId := range trainSet.ItemIndex.GetNames() {
+			oldIndex := als.ItemIndex.ToNumber(itemId)
+			newIndex := trainSet.ItemIndex.ToNumber(itemId)
+			if oldIndex != base.NotId {
+				newItemFactor[newIndex] = als.ItemFactor[oldIndex]
+			}
+		}
+	}
+	// Initialize base
+	als.UserFactor = newUserFactor
+	als.ItemFactor = newItemFactor
+	als.BaseMatrixFactorization.Init(trainSet)
+}
+
+// Fit the ALS model. Its task complexity is O(als.nEpochs).
+func (als *ALS) Fit(ctx context.Context, trainSet, valSet *DataSet, config *FitConfig) Score {
+	config = config.LoadDefaultIfNil()
+	log.Logger().Info("fit als",
+		zap.Int("train_set_size", trainSet.Count()),
+		zap.Int("test_set_size", valSet.Count()),
+		zap.Any("params", als.GetParams()),
+		zap.Any("config", config))
+	als.Init(trainSet)
+	// Observed feedback is weighted by confidence if feedback is weighted
+	weighted := trainSet.IsWeighted()
+	// Create temporary matrix
+	maxJobs := config.MaxJobs()
+	gram := base.NewMatrix32(als.nFactors, als.nFactors)
+	buffers := make([]*alsBuffer, maxJobs)
+	for i := 0; i < maxJobs; i++ {
+		buffers[i] = newALSBuffer(als.nFactors)
+	}
+	// evaluate initial model
+	snapshots := SnapshotManger{}
+	evalStart := time.Now()
+	scores := Evaluate(als, valSet, trainSet, config.TopK, config.Candidates, config.AvailableJobs(), NDCG, Precision, Recall)
+	evalTime := time.Since(evalStart)
+	log.Logger().Debug(fmt.Sprintf("fit als %v/%v", 0, als.nEpochs),
+		zap.String("eval_time", evalTime.String()),
+		zap.Float32(fmt.Sprintf("NDCG@%v", config.TopK), scores[0]),
+		zap.Float32(fmt.Sprintf("Precision@%v", config.TopK), scores[1]),
+		zap.Float32(fmt.Sprintf("Recall@%v", config.TopK), scores[2]))
+	snapshots.AddSnapshot(Score{NDCG: scores[0], Precision: scores[1], Recall: scores[2]}, als.UserFactor, als.ItemFactor)
+
+	_, span := progress.Start(ctx, "ALS.Fit", als.nEpochs)
+	for ep := 1; ep <= als.nEpochs; ep++ {
+		fitStart := time.Now()
+		// Update user factors
+		als.gram(gram, als.ItemFactor, trainSet.ItemFeedback)
+		_ = parallel.Parallel(trainSet.UserCount(), config.AvailableJobs(), func(workerId, userIndex int) error {
+			var confidence []float32
+			if weighted {
+				confidence = trainSet.UserConfidence[userIndex]
+			}
+			als.solve(als.UserFactor[userIndex], gram, als.ItemFactor, trainSet.UserFeedback[userIndex], confidence, buffers[workerId])
+			return nil
+		})
+		// Update item factors
+		als.gram(gram, als.UserFactor, trainSet.UserFeedback)
+		_ = parallel.Parallel(trainSet.ItemCount(), config.AvailableJobs(), func(workerId, itemIndex int) error {
+			var confidence []float32
+			if weighted {
+				confidence = trainSet.ItemConfidence[itemIndex]
+			}
+			als.solve(als.ItemFactor[itemIndex], gram, als.UserFactor, trainSet.ItemFeedback[itemIndex], confidence, buffers[workerId])
+			return nil
+		})
+		fitTime := time.Since(fitStart)
+		// Cross validation
+		if ep%config.Verbose == 0 || ep == als.nEpochs {
+			evalStart = time.Now()
+			scores = Evaluate(als, valSet, trainSet, config.TopK, config.Candidates, config.AvailableJobs(), NDCG, Precision, Recall)
+			evalTime = time.Since(evalStart)
+			log.Logger().Debug(fmt.Sprintf("fit als %v/%v", ep, als.nEpochs),
+				zap.String("fit_time", fitTime.String()),
+				zap.String("eval_time", evalTime.String()),
+				zap.Float32(fmt.Sprintf("NDCG@%v", config.TopK), scores[0]),
+				zap.Float32(fmt.Sprintf("Precision@%v", config.TopK), scores[1]),
+				zap.Float32(fmt.Sprintf("Recall@%v", config.TopK), scores[2]))
+			snapshots.AddSnapshot(Score{NDCG: scores[0], Precision: scores[1], Recall: scores[2]}, als.UserFactor, als.ItemFactor)
+		}
+		span.Add(1)
+	}
+	span.End()
+
+	// restore best snapshot
+	als.UserFactor = snapshots.BestWeights[0].([][]float32)
+	als.ItemFactor = snapshots.BestWeights[1].([][]float32)
+	log.Logger().Info("fit als complete",
+		zap.Float32(fmt.Sprintf("NDCG@%v", config.TopK), snapshots.BestScore.NDCG),
+		zap.Float32(fmt.Sprintf("Precision@%v", config.TopK), snapshots.BestScore.Precision),
+		zap.Float32(fmt.Sprintf("Recall@%v", config.TopK), snapshots.BestScore.Recall))
+	return snapshots.BestScore
+}
+
+// gram computes \sum_i y_i y_i^T over factors with feedback.
+func (als *ALS) gram(dst, factors [][]float32, feedback [][]int32) {
+	floats.MatZero(dst)
+	for index := range factors {
+		if len(feedback[index]) > 0 {
+			for i := 0; i < als.nFactors; i++ {
+				floats.MulConstAddTo(factors[index], factors[index][i], dst[i])
+			}
+		}
+	}
+}
+
+// alsBuffer holds temporary vectors of conjugate gradient.
+type alsBuffer struct {
+	r  []float32
+	p  []float32
+	ap []float32
+}
+
+func newALSBuffer(nFactors int) *alsBuffer {
+	return &alsBuffer{
+		r:  make([]float32, nFactors),
+		p:  make([]float32, nFactors),
+		ap: make([]float32, nFactors),
+	}
+}
+
+// solve updates x by conjugate gradient steps towards the solution of
+//
+//	(G + \sum_{i \in R} (c_i - 1) y_i y_i^T + reg I) x = \sum_{i \in R} c_i y_i
+//
+// where G is the gram matrix of factors and R is the feedback. Weights of feedback are 1 if weights are nil.
+func (als *ALS) solve(x []float32, gram, factors [][]float32, feedback []int32, weights []float32, buf *alsBuffer) {
+	confidence := func(j int) float32 {
+		if weights != nil {
+			return 1 + als.alpha*weights[j]
+		}
+		return 1 + als.alpha
+	}
+	multiply := func(v, dst []float32) {
+		for i := range dst {
+			dst[i] = floats.Dot(gram[i], v) + als.reg*v[i]
+		}
+		for j, i := range feedback {
+			floats.MulConstAddTo(factors[i], (confidence(j)-1)*floats.Dot(factors[i], v), dst)
+		}
+	}
+	// r <- b - Ax
+	multiply(x, buf.ap)
+	floats.MulConstTo(buf.ap, -1, buf.r)
+	for j, i := range feedback {
+		floats.MulConstAddTo(factors[i], confidence(j), buf.r)
+	}
+	copy(buf.p, buf.r)
+	rs := floats.Dot(buf.r, buf.r)
+	for step := 0; step < alsSteps && rs > 1e-10; step++ {
+		multiply(buf.p, buf.ap)
+		pap := floats.Dot(buf.p, buf.ap)
+		if pap <= 0 {
+			break
+		}
+		a := rs / pap
+		floats.MulConstAddTo(buf.p, a, x)
+		floats.MulConstAddTo(buf.ap, -a, buf.r)
+		rsNew := floats.Dot(buf.r, buf.r)
+		floats.MulConst(buf.p, rsNew/rs)
+		floats.Add(buf.p, buf.r)
+		rs = rsNew
+	}
+}
+
+// Marshal model into byte stream.
+func (als *ALS) Marshal(w io.Writer) error {
+	// write params
+	err := als.BaseMatrixFactorization.Marshal(w)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	// write user factors
+	err = encoding.WriteMatrix(w, als.UserFactor)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	// write item factors
+	err = encoding.WriteMatrix(w, als.ItemFactor)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	return nil
+}
+
+// Unmarshal model from byte stream.
+func (als *ALS) Unmarshal(r io.Reader) error {
+	// read params
+	var err error
+	err = als.BaseMatrixFactorization.Unmarshal(r)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	als.SetParams(als.Params)
+	// read user factors
+	als.UserFactor = base.NewMatrix32(int(als.UserIndex.Len()), als.nFactors)
+	err = encoding.ReadMatrix(r, als.UserFactor)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	// read item factors
+	als.ItemFactor = base.NewMatrix32(int(als.ItemIndex.Len()), als.nFactors)
+	err = encoding.ReadMatrix(r, als.ItemFactor)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	return nil
+}
+
+// EASE means Embarrassingly Shallow AutoEncoder, an item-item model whose weights are given in closed form by
+//
+//	P = (X^T X + reg I)^{-1},  B_{ij} = -P_{ij} / P_{jj},  B_{jj} = 0
+//
+// where X is the feedback matrix weighted by confidence. Only the MaxItems most popular items are modeled since the
+// inversion costs O(MaxItems^3). EASE is served as a matrix factorization: the factor of a user is its feedback on
+// modeled items and the factor of an item is the column of B, so that both factors have MaxItems dimensions and
+// their dot product is the score of EASE. Items out of the limit are not predictable.
+//
+//	Steck, Harald. "Embarrassingly shallow autoencoders for sparse data." The World Wide Web Conference. 2019.
+//
+// Hyper-parameters:
+//
+//	Reg 		- The L2 regularization of item-item weights. Default is 100.
+//	MaxItems	- The maximum number of modeled items. Default is 1024.
+type EASE struct {
+	BaseMatrixFactorization
+	// Hyper parameters
+	reg      float32
+	maxItems int
+}
+
+// NewEASE creates an EASE model.
+func NewEASE(params model.Params) *EASE {
+	ease := new(EASE)
+	ease.SetParams(params)
+	return ease
+}
+
+// GetUserFactor returns feedback of a user on modeled items.
+func (ease *EASE) GetUserFactor(userIndex int32) []float32 {
+	return ease.UserFactor[userIndex]
+}
+
+// GetItemFactor returns item-item weights to an item.
+func (ease *EASE) GetItemFactor(itemIndex int32) []float32 {
+	return ease.ItemFactor[itemIndex]
+}
+
+// SetParams sets hyper-parameters for the EASE model.
+func (ease *EASE) SetParams(params model.Params) {
+	ease.BaseMatrixFactorization.SetParams(params)
+	ease.reg = ease.Params.GetFloat32(model.Reg, 100)
+	ease.maxItems = ease.Params.GetInt(model.MaxItems, 1024)
+}
+
+func (ease *EASE) GetParamsGrid(withSize bool) model.ParamsGrid {
+	return model.ParamsGrid{
+		model.MaxItems: lo.If(withSize, []interface{}{256, 512, 1024, 2048}).Else([]interface{}{1024}),
+		model.Reg:      []interface{}{1, 10, 100, 500, 1000},
+	}
+}
+
+// Predict by the EASE model.
+func (ease *EASE) Predict(userId, itemId string) float32 {
+	userIndex := ease.UserIndex.ToNumber(userId)
+	itemIndex := ease.ItemIndex.ToNumber(itemId)
+	if userIndex == base.NotId {
+		log.Logger().Info("unknown user:", zap.String("user_id", userId))
+		return 0
+	}
+	if itemIndex == base.NotId {
+		log.Logger().Info("unknown item:", zap.String("item_id", itemId))
+		return 0
+	}
+	return ease.InternalPredict(userIndex, itemIndex)
+}
+
+func (ease *EASE) InternalPredict(userIndex, itemIndex int32) float32 {
+	ret := float32(0.0)
+	if itemIndex != base.NotId && userIndex != base.NotId {
+		ret = floats.Dot(ease.UserFactor[userIndex], ease.ItemFactor[itemIndex])
+	} else {
+		log.Logger().Warn("unknown user or item")
+	}
+	return ret
+}
+
+func (ease *EASE) Clear() {
+	ease.UserIndex = nil
+	ease.ItemIndex = nil
+	ease.ItemFactor = nil
+	ease.UserFactor = nil
+}
+
+func (ease *EASE) Invalid() bool {
+	return ease == nil ||
+		ease.UserIndex == nil ||
+		ease.ItemIndex == nil ||
+		ease.ItemFactor == nil ||
+		ease.UserFactor == nil
+}
+
+// dimension returns the number of modeled items.
+func (ease *EASE) dimension() int {
+	if len(ease.ItemFactor) == 0 {
+		return 0
+	}
+	return len(ease.ItemFactor[0])
+}
+
+// Init selects the most popular items to be modeled and fills user factors with feedback on them. It returns
+// positions of modeled items, -1 for items out of the limit.
+func (ease *EASE) Init(trainSet *DataSet) []int {
+	ease.BaseMatrixFactorization.Init(trainSet)
+	// select the most popular items
+	items := make([]int, 0, trainSet.ItemCount())
+	for itemIndex := 0; itemIndex < trainSet.ItemCount(); itemIndex++ {
+		if len(trainSet.ItemFeedback[itemIndex]) > 0 {
+			items = append(items, itemIndex)
+		}
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		return len(trainSet.ItemFeedback[items[i]]) > len(trainSet.ItemFeedback[items[j]])
+	})
+	if len(items) > ease.maxItems {
+		items = items[:ease.maxItems]
+	}
+	positions := make([]int, trainSet.ItemCount())
+	for i := range positions {
+		positions[i] = -1
+	}
+	ease.ItemPredictable = bitset.New(uint(trainSet.ItemCount()))
+	for position, itemIndex := range items {
+		positions[itemIndex] = position
+		ease.ItemPredictable.Set(uint(itemIndex))
+	}
+	// fill user factors with feedback
+	ease.UserFactor = base.NewMatrix32(trainSet.UserCount(), len(items))
+	ease.UserPredictable = bitset.New(uint(trainSet.UserCount()))
+	for userIndex, feedback := range trainSet.UserFeedback {
+		for j, itemIndex := range feedback {
+			if position := positions[itemIndex]; position >= 0 {
+				ease.UserFactor[userIndex][position] = 1
+				if trainSet.IsWeighted() {
+					ease.UserFactor[userIndex][position] = trainSet.UserConfidence[userIndex][j]
+				}
+				ease.UserPredictable.Set(uint(userIndex))
+			}
+		}
+	}
+	ease.ItemFactor = base.NewMatrix32(trainSet.ItemCount(), len(items))
+	return positions
+}
+
+// Fit the EASE model. Its task complexity is 1 since weights are solved at once.
+func (ease *EASE) Fit(ctx context.Context, trainSet, valSet *DataSet, config *FitConfig) Score {
+	config = config.LoadDefaultIfNil()
+	log.Logger().Info("fit ease",
+		zap.Int("train_set_size", trainSet.Count()),
+		zap.Int("test_set_size", valSet.Count()),
+		zap.Any("params", ease.GetParams()),
+		zap.Any("config", config))
+	_, span := progress.Start(ctx, "EASE.Fit", 1)
+	defer span.End()
+	fitStart := time.Now()
+	positions := ease.Init(trainSet)
+	if ease.dimension() > 0 {
+		if err := ease.solve(positions); err != nil {
+			log.Logger().Error("failed to fit ease", zap.Any("params", ease.GetParams()), zap.Error(err))
+			ease.Clear()
+			return Score{}
+		}
+	}
+	fitTime := time.Since(fitStart)
+	span.Add(1)
+
+	evalStart := time.Now()
+	scores := Evaluate(ease, valSet, trainSet, config.TopK, config.Candidates, config.AvailableJobs(), NDCG, Precision, Recall)
+	evalTime := time.Since(evalStart)
+	log.Logger().Info("fit ease complete",
+		zap.Int("n_items", ease.dimension()),
+		zap.String("fit_time", fitTime.String()),
+		zap.String("eval_time", evalTime.String()),
+		zap.Float32(fmt.Sprintf("NDCG@%v", config.TopK), scores[0]),
+		zap.Float32(fmt.Sprintf("Precision@%v", config.TopK), scores[1]),
+		zap.Float32(fmt.Sprintf("Recall@%v", config.TopK), scores[2]))
+	return Score{NDCG: scores[0], Precision: scores[1], Recall: scores[2]}
+}
+
+// solve computes item-item weights of modeled items at given positions.
+func (ease *EASE) solve(positions []int) error {
+	n := ease.dimension()
+	// G = X^T X + reg I
+	gram := make([]float64, n*n)
+	nonZeros := make([]int, 0, n)
+	for _, x := range ease.UserFactor {
+		nonZeros = nonZeros[:0]
+		for i := range x {
+			if x[i] != 0 {
+				nonZeros = append(nonZeros, i)
+			}
+		}
+		for _, i := range nonZeros {
+			for _, j := range nonZeros {
+				gram[i*n+j] += float64(x[i]) * float64(x[j])
+			}
+		}
+	}
+	for i := 0; i < n; i++ {
+		gram[i*n+i] += float64(ease.reg)
+	}
+	// P = G^{-1}
+	var chol mat.Cholesky
+	if ok := chol.Factorize(mat.NewSymDense(n, gram)); !ok {
+		return errors.New("gram matrix is not positive definite")
+	}
+	var p mat.SymDense
+	if err := chol.InverseTo(&p); err != nil {
+		return errors.Trace(err)
+	}
+	// B_{ij} = -P_{ij} / P_{jj}
+	for itemIndex, j := range positions {
+		if j >= 0 {
+			for i := 0; i < n; i++ {
+				if i != j {
+					ease.ItemFactor[itemIndex][i] = float32(-p.At(i, j) / p.At(j, j))
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// Marshal model into byte stream.
+func (ease *EASE) Marshal(w io.Writer) error {
+	// write params
+	err := ease.BaseMatrixFactorization.Marshal(w)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	// write number of modeled items
+	err = binary.Write(w, binary.LittleEndian, int32(ease.dimension()))
+	if err != nil {
+		return errors.Trace(err)
+	}
+	// write user factors
+	err = encoding.WriteMatrix(w, ease.UserFactor)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	// write item factors
+	err = encoding.WriteMatrix(w, ease.ItemFactor)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	return nil
+}
+
+// Unmarshal model from byte stream.
+func (ease *EASE) Unmarshal(r io.Reader) error {
+	// read params
+	var err error
+	err = ease.BaseMatrixFactorization.Unmarshal(r)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	ease.SetParams(ease.Params)
+	// read number of modeled items
+	var n int32
+	err = binary.Read(r, binary.LittleEndian, &n)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	// read user factors
+	ease.UserFactor = base.NewMatrix32(int(ease.UserIndex.Len()), int(n))
+	err = encoding.ReadMatrix(r, ease.UserFactor)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	// read item factors
+	ease.ItemFactor = base.NewMatrix32(int(ease.ItemIndex.Len()), int(n))
+	err = encoding.ReadMatrix(r, ease.ItemFactor)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	return nil
+}
@@ -18,9 +18,12 @@ import (
 	"context"
 	"math"
 	"runtime"
+	"strconv"
 	"testing"
 
+	"github.com/juju/errors"
 	"github.com/stretchr/testify/assert"
+	"github.com/zhenghaoz/gorse/base"
 	"github.com/zhenghaoz/gorse/base/floats"
 	"github.com/zhenghaoz/gorse/base/task"
 	"github.com/zhenghaoz/gorse/model"
@@ -147,3 +150,290 @@ func TestCCD_MovieLens(t *testing.T) {
 //	score := m.Fit(trainSet, testSet, fitConfig)
 //	assertEpsilon(t, 0.52, score.NDCG, benchDelta)
 //}
+
+func newWeightedDataset() (*DataSet, *DataSet) {
+	dataset := NewMapIndexDataset()
+	for i := 0; i < 100; i++ {
+		userId := strconv.Itoa(i)
+		dataset.AddWeightedFeedback(userId, "heavy", 10, true)
+		dataset.AddWeightedFeedback(userId, "light", 0.1, true)
+		for j := 0; j < 5; j++ {
+			dataset.AddWeightedFeedback(userId, strconv.Itoa((i+j)%20), 1, true)
+		}
+	}
+	return dataset.Split(10, 0)
+}
+
+func meanScore(m MatrixFactorization, trainSet *DataSet, itemId string) float32 {
+	var sum float32
+	for userIndex := 0; userIndex < trainSet.UserCount(); userIndex++ {
+		sum += m.Predict(trainSet.UserIndex.ToName(int32(userIndex)), itemId)
+	}
+	return sum / float32(trainSet.UserCount())
+}
+
+func TestBPR_Weighted(t *testing.T) {
+	trainSet, testSet := newWeightedDataset()
+	assert.True(t, trainSet.IsWeighted())
+	m := NewBPR(model.Params{
+		model.NFactors: 16,
+		model.NEpochs:  30,
+	})
+	m.Fit(context.Background(), trainSet, testSet, newFitConfig(30))
+	assert.True(t, meanScore(m, trainSet, "heavy") > meanScore(m, trainSet, "light"))
+}
+
+func TestCCD_Weighted(t *testing.T) {
+	trainSet, testSet := newWeightedDataset()
+	m := NewCCD(model.Params{
+		model.NFactors: 16,
+		model.NEpochs:  30,
+	})
+	m.Fit(context.Background(), trainSet, testSet, newFitConfig(30))
+	assert.True(t, meanScore(m, trainSet, "heavy") > meanScore(m, trainSet, "light"))
+}
+
+func TestALS_MovieLens(t *testing.T) {
+	trainSet, testSet, err := LoadDataFromBuiltIn("ml-1m")
+	assert.NoError(t, err)
+	m := NewALS(model.Params{
+		model.NFactors: 16,
+		model.Reg:      0.1,
+		model.NEpochs:  10,
+		model.Alpha:    1,
+	})
+	score := m.Fit(context.Background(), trainSet, testSet, newFitConfig(10))
+	assert.InDelta(t, 0.36, score.NDCG, benchDelta)
+
+	// test predict
+	assert.Equal(t, m.Predict("1", "1"), m.InternalPredict(1, 1))
+	assert.Equal(t, m.InternalPredict(1, 1), floats.Dot(m.GetUserFactor(1), m.GetItemFactor(1)))
+
+	// test encode/decode model and increment training
+	buf := bytes.NewBuffer(nil)
+	err = MarshalModel(buf, m)
+	assert.NoError(t, err)
+	tmp, err := UnmarshalModel(buf)
+	assert.NoError(t, err)
+	m = tmp.(*ALS)
+	m.nEpochs = 1
+	scoreInc := m.Fit(context.Background(), trainSet, testSet, newFitConfig(1))
+	assert.InDelta(t, score.NDCG, scoreInc.NDCG, incrDelta)
+
+	// test clear
+	m.Clear()
+	assert.True(t, m.Invalid())
+}
+
+func TestALS_Weighted(t *testing.T) {
+	trainSet, testSet := newWeightedDataset()
+	m := NewALS(model.Params{
+		model.NFactors: 16,
+		model.NEpochs:  30,
+	})
+	m.Fit(context.Background(), trainSet, testSet, newFitConfig(30))
+	assert.True(t, meanScore(m, trainSet, "heavy") > meanScore(m, trainSet, "light"))
+
+	// test encode/decode model
+	buf := bytes.NewBuffer(nil)
+	err := MarshalModel(buf, m)
+	assert.NoError(t, err)
+	tmp, err := UnmarshalModel(buf)
+	assert.NoError(t, err)
+	assert.Equal(t, CollaborativeALS, GetModelName(tmp))
+	assert.Equal(t, m.Predict("1", "heavy"), tmp.Predict("1", "heavy"))
+}
+
+func TestEASE_MovieLens(t *testing.T) {
+	trainSet, testSet, err := LoadDataFromBuiltIn("ml-1m")
+	assert.NoError(t, err)
+	m := NewEASE(model.Params{
+		model.Reg:      500,
+		model.MaxItems: 4096,
+	})
+	score := m.Fit(context.Background(), trainSet, testSet, newFitConfig(1))
+	assert.InDelta(t, 0.38, score.NDCG, benchDelta)
+
+	// test predict
+	assert.Equal(t, m.Predict("1", "1"), m.InternalPredict(1, 1))
+	assert.Equal(t, m.InternalPredict(1, 1), floats.Dot(m.GetUserFactor(1), m.GetItemFactor(1)))
+
+	// test clear
+	m.Clear()
+	assert.True(t, m.Invalid())
+}
+
+func TestEASE_Weighted(t *testing.T) {
+	trainSet, testSet := newWeightedDataset()
+	m := NewEASE(model.Params{
+		model.Reg: 10,
+	})
+	m.Fit(context.Background(), trainSet, testSet, newFitConfig(1))
+	assert.True(t, meanScore(m, trainSet, "heavy") > meanScore(m, trainSet, "light"))
+
+	// fit without feedback
+	m.Fit(context.Background(), NewMapIndexDataset(), NewMapIndexDataset(), newFitConfig(1))
+	assert.False(t, m.Invalid())
+	assert.Zero(t, m.dimension())
+}
+
+func TestEASE_MaxItems(t *testing.T) {
+	trainSet, testSet := newWeightedDataset()
+	m := NewEASE(model.Params{
+		model.Reg:      10,
+		model.MaxItems: 5,
+	})
+	m.Fit(context.Background(), trainSet, testSet, newFitConfig(1))
+	assert.Equal(t, 5, m.dimension())
+	assert.True(t, m.IsItemPredictable(trainSet.ItemIndex.ToNumber("heavy")))
+	assert.True(t, m.IsItemPredictable(trainSet.ItemIndex.ToNumber("light")))
+	var numPredictable int
+	for itemIndex := int32(0); itemIndex < trainSet.ItemIndex.Len(); itemIndex++ {
+		if m.IsItemPredictable(itemIndex) {
+			numPredictable++
+		} else {
+			assert.Equal(t, make([]float32, 5), m.GetItemFactor(itemIndex))
+		}
+	}
+	assert.Equal(t, 5, numPredictable)
+
+	// test encode/decode model
+	buf := bytes.NewBuffer(nil)
+	err := MarshalModel(buf, m)
+	assert.NoError(t, err)
+	tmp, err := UnmarshalModel(buf)
+	assert.NoError(t, err)
+	ease := tmp.(*EASE)
+	assert.Equal(t, m.UserFactor, ease.UserFactor)
+	assert.Equal(t, m.ItemFactor, ease.ItemFactor)
+	assert.Equal(t, 5, ease.maxItems)
+
+	// delta requires the same number of modeled items
+	other := NewEASE(model.Params{
+		model.Reg:      10,
+		model.MaxItems: 5,
+	})
+	other.Fit(context.Background(), trainSet, testSet, newFitConfig(1))
+	err = MarshalModelDelta(bytes.NewBuffer(nil), other, m)
+	assert.NoError(t, err)
+	other.UserFactor = base.NewMatrix32(trainSet.UserCount(), 4)
+	other.ItemFactor = base.NewMatrix32(trainSet.ItemCount(), 4)
+	err = MarshalModelDelta(bytes.NewBuffer(nil), other, m)
+	assert.Error(t, err)
+}
+
+func TestModelDelta(t *testing.T) {
+	trainSet, testSet := newWeightedDataset()
+	baseModel := NewBPR(model.Params{
+		model.NFactors: 16,
+		model.NEpochs:  1,
+	})
+	baseModel.Fit(context.Background(), trainSet, testSet, newFitConfig(1))
+
+	// update one user, one item and insert a new user
+	m := Clone(baseModel).(*BPR)
+	m.UserFactor[2][0] += DeltaTolerance / 2
+	AlignModel(baseModel, m)
+	assert.Equal(t, baseModel.UserFactor[2], m.UserFactor[2])
+	m.UserFactor[3][0] += 1
+	m.ItemFactor[5][1] += 1
+	m.UserIndex.(*base.MapIndex).Add("new")
+	m.UserFactor = append(m.UserFactor, make([]float32, 16))
+	m.UserPredictable.Set(uint(m.UserIndex.Len() - 1))
+
+	// delta is smaller than the whole model
+	fullBuf := bytes.NewBuffer(nil)
+	err := MarshalModel(fullBuf, m)
+	assert.NoError(t, err)
+	deltaBuf := bytes.NewBuffer(nil)
+	err = MarshalModelDelta(deltaBuf, baseModel, m)
+	assert.NoError(t, err)
+	assert.Less(t, deltaBuf.Len()*2, fullBuf.Len())
+
+	// apply delta
+	applied, err := UnmarshalModelDelta(deltaBuf, baseModel)
+	assert.NoError(t, err)
+	bpr := applied.(*BPR)
+	assert.Equal(t, m.UserFactor, bpr.UserFactor)
+	assert.Equal(t, m.ItemFactor, bpr.ItemFactor)
+	assert.Equal(t, m.GetUserIndex().GetNames(), bpr.GetUserIndex().GetNames())
+	assert.True(t, bpr.IsUserPredictable(m.UserIndex.ToNumber("new")))
+	assert.Equal(t, m.Predict("3", "5"), bpr.Predict("3", "5"))
+
+	// delta can't be applied to a different model
+	deltaBuf.Reset()
+	err = MarshalModelDelta(deltaBuf, baseModel, m)
+	assert.NoError(t, err)
+	_, err = UnmarshalModelDelta(deltaBuf, NewCCD(model.Params{model.NFactors: 16}))
+	assert.Error(t, err)
+	err = MarshalModelDelta(deltaBuf, NewCCD(model.Params{model.NFactors: 16}), m)
+	assert.Error(t, err)
+
+	// delta can't be applied to a different base
+	deltaBuf.Reset()
+	err = MarshalModelDelta(deltaBuf, baseModel, m)
+	assert.NoError(t, err)
+	otherModel := Clone(baseModel).(*BPR)
+	otherModel.UserFactor[0][0] += DeltaTolerance / 2
+	_, err = UnmarshalModelDelta(deltaBuf, otherModel)
+	assert.ErrorIs(t, err, ErrBaseMismatch)
+
+	// the whole model is required if most factors changed
+	for i := range m.UserFactor {
+		m.UserFactor[i][0] += 1
+	}
+	for i := range m.ItemFactor {
+		m.ItemFactor[i][0] += 1
+	}
+	deltaBuf.Reset()
+	err = MarshalModelDelta(deltaBuf, baseModel, m)
+	assert.ErrorIs(t, err, ErrDeltaNotSmaller)
+	assert.Zero(t, deltaBuf.Len())
+}
+
+func TestModelDelta_Chain(t *testing.T) {
+	trainSet, testSet := newWeightedDataset()
+	fitted := NewBPR(model.Params{
+		model.NFactors: 16,
+		model.NEpochs:  1,
+	})
+	fitted.Fit(context.Background(), trainSet, testSet, newFitConfig(1))
+	masterModel := Clone(fitted).(*BPR)
+	var workerModel MatrixFactorization = Clone(masterModel)
+	numDeltas := 0
+
+	// retraining perturbs all factors slightly and changes a few factors
+	for i := 0; i < 5; i++ {
+		for _, factor := range append(fitted.UserFactor, fitted.ItemFactor...) {
+			for k := range factor {
+				factor[k] += DeltaTolerance * 0.6
+			}
+		}
+		fitted.UserFactor[i][0] += 1
+		m := Clone(fitted).(*BPR)
+		AlignModel(masterModel, m)
+		deltaBuf := bytes.NewBuffer(nil)
+		err := MarshalModelDelta(deltaBuf, masterModel, m)
+		if errors.Is(err, ErrDeltaNotSmaller) {
+			// factors drifting beyond the tolerance are transferred in full
+			workerModel = Clone(m)
+		} else {
+			assert.NoError(t, err)
+			workerModel, err = UnmarshalModelDelta(deltaBuf, workerModel)
+			assert.NoError(t, err)
+			numDeltas++
+		}
+		masterModel = m
+
+		// the worker model equals the master model, which is close to the fitted model
+		assert.Equal(t, masterModel.UserFactor, workerModel.(*BPR).UserFactor)
+		assert.Equal(t, masterModel.ItemFactor, workerModel.(*BPR).ItemFactor)
+		for j := range fitted.UserFactor {
+			assert.InDeltaSlice(t, fitted.UserFactor[j], masterModel.UserFactor[j], DeltaTolerance)
+		}
+		for j := range fitted.ItemFactor {
+			assert.InDeltaSlice(t, fitted.ItemFactor[j], masterModel.ItemFactor[j], DeltaTolerance)
+		}
+	}
+	assert.Equal(t, 3, numDeltas)
+}
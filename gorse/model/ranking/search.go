@@ -36,6 +36,7 @@ type ParamsSearchResult struct {
 	BestIndex  int
 	Scores     []Score
 	Params     []model.Params
+	Trials     []model.Trial
 }
 
 func (r *ParamsSearchResult) AddScore(params model.Params, score Score) {
@@ -102,7 +103,7 @@ func GridSearchCV(ctx context.Context, estimator MatrixFactorization, trainSet *
 func RandomSearchCV(ctx context.Context, estimator MatrixFactorization, trainSet *DataSet, testSet *DataSet, paramGrid model.ParamsGrid,
 	numTrials int, seed int64, fitConfig *FitConfig) ParamsSearchResult {
 	// if the number of combination is less than number of trials, use grid search
-	if paramGrid.NumCombinations() < numTrials {
+	if paramGrid.NumCombinations() <= numTrials {
 		return GridSearchCV(ctx, estimator, trainSet, testSet, paramGrid, seed, fitConfig)
 	}
 	rng := base.NewRandomGenerator(seed)
@@ -138,13 +139,69 @@ func RandomSearchCV(ctx context.Context, estimator MatrixFactorization, trainSet
 	return results
 }
 
+// SearchCV searches hyper-parameters by the strategy in options. Trials are trained for fewer epochs if early stopping
+// is enabled, and only models trained for all epochs are candidates of the best model. It falls back to RandomSearchCV
+// if neither the tree-structured Parzen estimator nor early stopping is used.
+func SearchCV(ctx context.Context, estimator MatrixFactorization, trainSet *DataSet, testSet *DataSet, paramGrid model.ParamsGrid,
+	options model.SearchOptions, fitConfig *FitConfig) ParamsSearchResult {
+	if paramGrid.NumCombinations() <= options.NumTrials || (options.Strategy != model.TPESearch && !options.EarlyStopping) {
+		if options.NumEpochs > 0 {
+			estimator.SetParams(estimator.GetParams().Overwrite(model.Params{model.NEpochs: options.NumEpochs}))
+		}
+		results := RandomSearchCV(ctx, estimator, trainSet, testSet, paramGrid, options.NumTrials, options.Seed, fitConfig)
+		for i := range results.Params {
+			results.Trials = append(results.Trials, model.Trial{Params: results.Params[i], Epochs: options.NumEpochs, Score: results.Scores[i].NDCG})
+		}
+		return results
+	}
+	rungs := options.Rungs()
+	results := ParamsSearchResult{
+		Scores: make([]Score, 0, options.NumTrials),
+		Params: make([]model.Params, 0, options.NumTrials),
+	}
+	newCtx, span := progress.Start(ctx, "SearchCV", options.NumTrials)
+	results.Trials = model.Search(paramGrid, options, func(params model.Params, epochs int) float32 {
+		log.Logger().Info(fmt.Sprintf("%v search (%v/%v)", options.Strategy, span.Count(), options.NumTrials),
+			zap.Any("params", params), zap.Int("n_epochs", epochs))
+		estimator.Clear()
+		fitParams := estimator.GetParams().Overwrite(params)
+		if epochs > 0 {
+			fitParams[model.NEpochs] = epochs
+		}
+		estimator.SetParams(fitParams)
+		score := estimator.Fit(newCtx, trainSet, testSet, fitConfig)
+		if epochs == rungs[0] {
+			span.Add(1)
+		}
+		if epochs == rungs[len(rungs)-1] {
+			results.Scores = append(results.Scores, score)
+			results.Params = append(results.Params, params.Copy())
+			if results.BestModel == nil || score.NDCG > results.BestScore.NDCG {
+				results.BestModel = Clone(estimator)
+				results.BestScore = score
+				results.BestParams = params.Copy()
+				results.BestIndex = len(results.Params) - 1
+			}
+		}
+		return score.NDCG
+	})
+	span.End()
+	return results
+}
+
+// searchHistorySize is the max number of trials kept in the search history for each model.
+const searchHistorySize = 100
+
 // ModelSearcher is a thread-safe personal ranking model searcher.
 type ModelSearcher struct {
 	models []MatrixFactorization
 	// arguments
-	numEpochs  int
-	numTrials  int
-	searchSize bool
+	numEpochs     int
+	numTrials     int
+	searchSize    bool
+	strategy      string
+	earlyStopping bool
+	history       *model.SearchHistory
 	// results
 	bestMutex     sync.Mutex
 	bestModelName string
@@ -158,12 +215,28 @@ func NewModelSearcher(nEpoch, nTrials int, searchSize bool) *ModelSearcher {
 		numTrials:  nTrials,
 		numEpochs:  nEpoch,
 		searchSize: searchSize,
+		strategy:   model.RandomSearch,
+		history:    model.NewSearchHistory(searchHistorySize),
 	}
 	searcher.models = append(searcher.models, NewBPR(model.Params{model.NEpochs: searcher.numEpochs}))
 	searcher.models = append(searcher.models, NewCCD(model.Params{model.NEpochs: searcher.numEpochs}))
+	searcher.models = append(searcher.models, NewALS(model.Params{model.NEpochs: searcher.numEpochs}))
+	searcher.models = append(searcher.models, NewEASE(nil))
 	return searcher
 }
 
+// SetSearchStrategy sets the strategy to sample hyper-parameters and whether to stop unpromising trials early.
+func (searcher *ModelSearcher) SetSearchStrategy(strategy string, earlyStopping bool) *ModelSearcher {
+	searcher.strategy = strategy
+	searcher.earlyStopping = earlyStopping
+	return searcher
+}
+
+// GetHistory returns trials of previous searches. Searches warm-start from the history.
+func (searcher *ModelSearcher) GetHistory() *model.SearchHistory {
+	return searcher.history
+}
+
 // GetBestModel returns the optimal personal ranking model.
 func (searcher *ModelSearcher) GetBestModel() (string, MatrixFactorization, Score) {
 	searcher.bestMutex.Lock()
@@ -177,10 +250,22 @@ func (searcher *ModelSearcher) Fit(ctx context.Context, trainSet, valSet *DataSe
 		zap.Int("n_items", trainSet.ItemCount()))
 	startTime := time.Now()
 	for _, m := range searcher.models {
-		r := RandomSearchCV(ctx, m, trainSet, valSet, m.GetParamsGrid(searcher.searchSize), searcher.numTrials, 0,
-			NewFitConfig().SetJobsAllocator(j))
+		name := GetModelName(m)
+		options := model.SearchOptions{
+			Strategy:      searcher.strategy,
+			NumTrials:     searcher.numTrials,
+			EarlyStopping: searcher.earlyStopping,
+			History:       searcher.history.Get(name),
+		}
+		// EASE isn't trained by epochs
+		if _, exist := m.GetParams()[model.NEpochs]; exist {
+			options.NumEpochs = searcher.numEpochs
+		}
+		r := SearchCV(ctx, m, trainSet, valSet, m.GetParamsGrid(searcher.searchSize), options, NewFitConfig().SetJobsAllocator(j))
+		searcher.history.Add(name, r.Trials...)
 		searcher.bestMutex.Lock()
-		if searcher.bestModel == nil || r.BestScore.NDCG > searcher.bestScore.NDCG {
+		if r.BestModel != nil && (searcher.bestModel == nil || r.BestScore.NDCG > searcher.bestScore.NDCG) {
+			searcher.bestModelName = GetModelName(r.BestModel)
 			searcher.bestModel = r.BestModel
 			searcher.bestScore = r.BestScore
 		}
@@ -18,6 +18,7 @@ import (
 	"io"
 	"testing"
 
+	"github.com/samber/lo"
 	"github.com/stretchr/testify/assert"
 	"github.com/zhenghaoz/gorse/base"
 	"github.com/zhenghaoz/gorse/base/task"
@@ -132,10 +133,13 @@ func TestRandomSearchCV(t *testing.T) {
 
 func TestModelSearcher(t *testing.T) {
 	searcher := NewModelSearcher(2, 63, false)
+	assert.Equal(t, []string{CollaborativeBPR, CollaborativeCCD, CollaborativeALS, CollaborativeEASE},
+		lo.Map(searcher.models, func(m MatrixFactorization, _ int) string { return GetModelName(m) }))
 	searcher.models = []MatrixFactorization{newMockMatrixFactorizationForSearch(2)}
 	err := searcher.Fit(context.Background(), NewMapIndexDataset(), NewMapIndexDataset(), task.NewConstantJobsAllocator(1))
 	assert.NoError(t, err)
-	_, m, score := searcher.GetBestModel()
+	name, m, score := searcher.GetBestModel()
+	assert.Equal(t, GetModelName(m), name)
 	assert.Equal(t, float32(12), score.NDCG)
 	assert.Equal(t, model.Params{
 		model.NEpochs:    2,
@@ -143,4 +147,49 @@ func TestModelSearcher(t *testing.T) {
 		model.InitMean:   4,
 		model.InitStdDev: 4,
 	}, m.GetParams())
+	assert.Len(t, searcher.GetHistory().Get(name), 63)
+
+	// search by the tree-structured Parzen estimator with early stopping
+	searcher.SetSearchStrategy(model.TPESearch, true)
+	err = searcher.Fit(context.Background(), NewMapIndexDataset(), NewMapIndexDataset(), task.NewConstantJobsAllocator(1))
+	assert.NoError(t, err)
+	_, _, score = searcher.GetBestModel()
+	assert.Equal(t, float32(12), score.NDCG)
+	assert.Len(t, searcher.GetHistory().Get(name), searchHistorySize)
+}
+
+func TestModelSearcher_GridSearch(t *testing.T) {
+	// all combinations are searched if the number of trials equals the number of combinations
+	searcher := NewModelSearcher(2, 64, false)
+	searcher.models = []MatrixFactorization{newMockMatrixFactorizationForSearch(2)}
+	err := searcher.Fit(context.Background(), NewMapIndexDataset(), NewMapIndexDataset(), task.NewConstantJobsAllocator(1))
+	assert.NoError(t, err)
+	name, _, score := searcher.GetBestModel()
+	assert.Equal(t, float32(12), score.NDCG)
+	assert.Len(t, searcher.GetHistory().Get(name), 64)
+}
+
+func TestSearchCV(t *testing.T) {
+	m := newMockMatrixFactorizationForSearch(27)
+	fitConfig := newFitConfigForSearch()
+	r := SearchCV(context.Background(), m, nil, nil, m.GetParamsGrid(false), model.SearchOptions{
+		Strategy:      model.TPESearch,
+		NumTrials:     20,
+		NumEpochs:     27,
+		EarlyStopping: true,
+	}, fitConfig)
+	assert.Equal(t, float32(12), r.BestScore.NDCG)
+	assert.Equal(t, 27, r.BestModel.GetParams().GetInt(model.NEpochs, 0))
+	assert.Equal(t, []int{1, 3, 9, 27}, lo.Uniq(lo.Map(r.Trials, func(trial model.Trial, _ int) int { return trial.Epochs })))
+	assert.Len(t, lo.Filter(r.Trials, func(trial model.Trial, _ int) bool { return trial.Epochs == 1 }), 20)
+	assert.Len(t, r.Scores, len(lo.Filter(r.Trials, func(trial model.Trial, _ int) bool { return trial.Epochs == 27 })))
+
+	// random search without early stopping
+	r = SearchCV(context.Background(), m, nil, nil, m.GetParamsGrid(false), model.SearchOptions{
+		Strategy:  model.RandomSearch,
+		NumTrials: 20,
+		NumEpochs: 27,
+	}, fitConfig)
+	assert.Len(t, r.Trials, 20)
+	assert.Equal(t, r.BestScore.NDCG, lo.MaxBy(r.Trials, func(a, b model.Trial) bool { return a.Score > b.Score }).Score)
 }
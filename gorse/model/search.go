@@ -0,0 +1,382 @@
+// Copyright 2024 gorse Project Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"sync"
+
+	"github.com/juju/errors"
+	"github.com/zhenghaoz/gorse/base"
+)
+
+// Search strategies of hyper-parameters.
+const (
+	RandomSearch = "random"
+	TPESearch    = "tpe"
+)
+
+const (
+	// reductionFactor is the fraction of trials promoted to the next rung of successive halving is 1/reductionFactor.
+	reductionFactor = 3
+	// maxRungs is the max number of rungs of successive halving.
+	maxRungs = 4
+	// tpeStartupTrials is the number of trials sampled by random before the Parzen estimators are used.
+	tpeStartupTrials = 5
+	// tpeGamma is the fraction of trials regarded as good trials by the Parzen estimators.
+	tpeGamma = 0.25
+	// tpeCandidates is the number of candidates sampled from good trials, and the candidate with the largest
+	// expected improvement is evaluated.
+	tpeCandidates = 24
+)
+
+// Trial is an evaluation of hyper-parameters trained for a number of epochs. The score is larger if better.
+type Trial struct {
+	Params Params  `json:"params"`
+	Epochs int     `json:"epochs"`
+	Score  float32 `json:"score"`
+}
+
+// SearchHistory keeps recent trials of models by model names. Later searches warm-start from the history.
+type SearchHistory struct {
+	mutex  sync.Mutex
+	size   int
+	trials map[string][]Trial
+}
+
+// NewSearchHistory creates a SearchHistory keeping at most size recent trials for each model.
+func NewSearchHistory(size int) *SearchHistory {
+	return &SearchHistory{
+		size:   size,
+		trials: make(map[string][]Trial),
+	}
+}
+
+// Get returns trials of a model.
+func (h *SearchHistory) Get(name string) []Trial {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	return append([]Trial(nil), h.trials[name]...)
+}
+
+// Add appends trials of a model. The oldest trials are dropped if the history is full.
+func (h *SearchHistory) Add(name string, trials ...Trial) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	h.trials[name] = append(h.trials[name], trials...)
+	if len(h.trials[name]) > h.size {
+		h.trials[name] = h.trials[name][len(h.trials[name])-h.size:]
+	}
+}
+
+// Marshal the history into byte stream.
+func (h *SearchHistory) Marshal(w io.Writer) error {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	return errors.Trace(json.NewEncoder(w).Encode(h.trials))
+}
+
+// Unmarshal the history from byte stream.
+func (h *SearchHistory) Unmarshal(r io.Reader) error {
+	trials := make(map[string][]Trial)
+	if err := json.NewDecoder(r).Decode(&trials); err != nil {
+		return errors.Trace(err)
+	}
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	h.trials = trials
+	return nil
+}
+
+// SearchOptions are options of hyper-parameter searches.
+type SearchOptions struct {
+	// Strategy is the strategy to sample hyper-parameters, random or tpe.
+	Strategy string
+	// NumTrials is the number of hyper-parameters to be sampled.
+	NumTrials int
+	// NumEpochs is the number of epochs to train a model. It's zero if the model isn't trained by epochs.
+	NumEpochs int
+	// EarlyStopping enables asynchronous successive halving. Most hyper-parameters are trained for a few epochs, and
+	// only promising ones are trained for more epochs.
+	EarlyStopping bool
+	// History contains trials of previous searches to warm-start the Parzen estimators.
+	History []Trial
+	// Seed is the seed of the random generator.
+	Seed int64
+}
+
+// Rungs returns numbers of epochs at rungs of successive halving. There is only one rung if early stopping is
+// disabled.
+func (options SearchOptions) Rungs() []int {
+	if !options.EarlyStopping || options.NumEpochs < reductionFactor {
+		return []int{options.NumEpochs}
+	}
+	numRungs := 1
+	for epochs := options.NumEpochs; epochs >= reductionFactor && numRungs < maxRungs; epochs /= reductionFactor {
+		numRungs++
+	}
+	rungs := make([]int, numRungs)
+	for i, epochs := numRungs-1, options.NumEpochs; i >= 0; i, epochs = i-1, epochs/reductionFactor {
+		rungs[i] = epochs
+	}
+	return rungs
+}
+
+// Search searches hyper-parameters in a grid. The evaluate function trains a model with hyper-parameters for a
+// number of epochs and returns the validation score. Hyper-parameters are sampled by random or the tree-structured
+// Parzen estimator (TPE), and scheduled by asynchronous successive halving (ASHA) if early stopping is enabled.
+// It returns all trials in this search.
+func Search(grid ParamsGrid, options SearchOptions, evaluate func(params Params, epochs int) float32) []Trial {
+	rungs := options.Rungs()
+	rng := base.NewRandomGenerator(options.Seed)
+	space := newSearchSpace(grid)
+	var (
+		configs []*searchConfig
+		trials  []Trial
+	)
+	run := func(config *searchConfig, rung int) {
+		score := evaluate(config.params.Copy(), rungs[rung])
+		config.scores = append(config.scores, score)
+		trials = append(trials, Trial{Params: config.params.Copy(), Epochs: rungs[rung], Score: score})
+	}
+	for {
+		// promote a trial to the next rung
+		if config, rung := promote(configs, len(rungs), len(configs) >= options.NumTrials); config != nil {
+			run(config, rung)
+			continue
+		}
+		if len(configs) >= options.NumTrials {
+			break
+		}
+		// sample new hyper-parameters
+		var config *searchConfig
+		if options.Strategy == TPESearch {
+			config = space.sampleTPE(rng, append(append([]Trial(nil), options.History...), trials...), configs)
+		} else {
+			config = space.sampleRandom(rng)
+		}
+		configs = append(configs, config)
+		run(config, 0)
+	}
+	return trials
+}
+
+// searchConfig is a set of hyper-parameters and its scores at rungs reached.
+type searchConfig struct {
+	params Params
+	scores []float32
+}
+
+// promote finds a trial to be trained at the next rung. A trial is promoted if it's in the top 1/reductionFactor of
+// its rung. At least one trial is promoted once sampling is exhausted, so that the top rung is never empty.
+func promote(configs []*searchConfig, numRungs int, exhausted bool) (*searchConfig, int) {
+	for rung := numRungs - 2; rung >= 0; rung-- {
+		var candidates []*searchConfig
+		for _, config := range configs {
+			if len(config.scores) > rung {
+				candidates = append(candidates, config)
+			}
+		}
+		sort.SliceStable(candidates, func(i, j int) bool {
+			return candidates[i].scores[rung] > candidates[j].scores[rung]
+		})
+		numPromoted := len(candidates) / reductionFactor
+		if exhausted {
+			numPromoted = max(numPromoted, min(1, len(candidates)))
+		}
+		for _, config := range candidates[:numPromoted] {
+			if len(config.scores) == rung+1 {
+				return config, rung + 1
+			}
+		}
+	}
+	return nil, 0
+}
+
+// searchSpace is a grid of hyper-parameters with names in stable order.
+type searchSpace struct {
+	names  []ParamName
+	values [][]interface{}
+	// ordinal is true if values of a hyper-parameter are numbers, and neighboring values are similar.
+	ordinal []bool
+}
+
+func newSearchSpace(grid ParamsGrid) *searchSpace {
+	space := &searchSpace{}
+	for name := range grid {
+		space.names = append(space.names, name)
+	}
+	sort.Slice(space.names, func(i, j int) bool {
+		return space.names[i] < space.names[j]
+	})
+	for _, name := range space.names {
+		space.values = append(space.values, grid[name])
+		ordinal := true
+		for _, value := range grid[name] {
+			switch value.(type) {
+			case int, int32, int64, float32, float64:
+			default:
+				ordinal = false
+			}
+		}
+		space.ordinal = append(space.ordinal, ordinal)
+	}
+	return space
+}
+
+func (space *searchSpace) sampleRandom(rng base.RandomGenerator) *searchConfig {
+	params := make(Params)
+	for i, name := range space.names {
+		params[name] = space.values[i][rng.Intn(len(space.values[i]))]
+	}
+	return &searchConfig{params: params}
+}
+
+// sampleTPE samples hyper-parameters by the tree-structured Parzen estimator. Trials of the largest number of epochs
+// with enough observations are split into good trials and bad trials. Candidates are sampled from the density l(x) of
+// good trials, and the candidate maximizing l(x)/g(x) is chosen, where g(x) is the density of bad trials.
+// Hyper-parameters are assumed independent.
+func (space *searchSpace) sampleTPE(rng base.RandomGenerator, trials []Trial, configs []*searchConfig) *searchConfig {
+	observations := space.observations(trials)
+	if len(observations) < tpeStartupTrials {
+		return space.sampleRandom(rng)
+	}
+	sort.SliceStable(observations, func(i, j int) bool {
+		return observations[i].score > observations[j].score
+	})
+	numGood := int(math.Ceil(tpeGamma * float64(len(observations))))
+	good := make([][]float64, len(space.names))
+	bad := make([][]float64, len(space.names))
+	for i := range space.names {
+		good[i] = space.parzen(i, observations[:numGood])
+		bad[i] = space.parzen(i, observations[numGood:])
+	}
+	evaluated := make(map[string]struct{}, len(configs))
+	for _, config := range configs {
+		evaluated[config.params.ToString()] = struct{}{}
+	}
+	var (
+		best      *searchConfig
+		bestScore = math.Inf(-1)
+		bestNew   bool
+	)
+	for c := 0; c < tpeCandidates; c++ {
+		params := make(Params)
+		var score float64
+		for i, name := range space.names {
+			j := sampleDiscrete(rng, good[i])
+			params[name] = space.values[i][j]
+			score += math.Log(good[i][j]) - math.Log(bad[i][j])
+		}
+		// prefer hyper-parameters not evaluated in this search
+		_, isEvaluated := evaluated[params.ToString()]
+		if (!isEvaluated && !bestNew) || (!isEvaluated == bestNew && score > bestScore) {
+			best, bestScore, bestNew = &searchConfig{params: params}, score, !isEvaluated
+		}
+	}
+	return best
+}
+
+// observation is a trial whose hyper-parameters are converted to indices of values in the grid.
+type observation struct {
+	indices []int
+	score   float32
+}
+
+// observations converts trials of the largest number of epochs with enough trials to observations. Trials with
+// hyper-parameters out of the grid are ignored.
+func (space *searchSpace) observations(trials []Trial) []observation {
+	lookup := make([]map[string]int, len(space.names))
+	for i := range space.names {
+		lookup[i] = make(map[string]int)
+		for j, value := range space.values[i] {
+			lookup[i][fmt.Sprint(value)] = j
+		}
+	}
+	byEpochs := make(map[int][]observation)
+	for _, trial := range trials {
+		indices := make([]int, len(space.names))
+		valid := true
+		for i, name := range space.names {
+			j, exist := lookup[i][fmt.Sprint(trial.Params[name])]
+			if !exist {
+				valid = false
+				break
+			}
+			indices[i] = j
+		}
+		if valid {
+			byEpochs[trial.Epochs] = append(byEpochs[trial.Epochs], observation{indices: indices, score: trial.Score})
+		}
+	}
+	var (
+		observations []observation
+		maxEpochs    = -1
+	)
+	for epochs, o := range byEpochs {
+		if len(o) >= tpeStartupTrials && epochs > maxEpochs {
+			observations, maxEpochs = o, epochs
+		}
+	}
+	return observations
+}
+
+// parzen estimates the density of values of the i-th hyper-parameter. A uniform prior is mixed with kernels centered
+// at observed values. The kernel is Gaussian over positions in the grid for ordinal values, otherwise it's a point
+// mass.
+func (space *searchSpace) parzen(i int, observations []observation) []float64 {
+	n := len(space.values[i])
+	density := make([]float64, n)
+	for j := range density {
+		density[j] = 1 / float64(n)
+	}
+	for _, o := range observations {
+		kernel := make([]float64, n)
+		var sum float64
+		for j := range kernel {
+			if space.ordinal[i] {
+				d := float64(j - o.indices[i])
+				kernel[j] = math.Exp(-d * d / 2)
+			} else if j == o.indices[i] {
+				kernel[j] = 1
+			}
+			sum += kernel[j]
+		}
+		for j := range density {
+			density[j] += kernel[j] / sum
+		}
+	}
+	total := float64(len(observations) + 1)
+	for j := range density {
+		density[j] /= total
+	}
+	return density
+}
+
+// sampleDiscrete samples an index from a discrete distribution.
+func sampleDiscrete(rng base.RandomGenerator, probabilities []float64) int {
+	r := rng.Float64()
+	for i, p := range probabilities {
+		if r < p {
+			return i
+		}
+		r -= p
+	}
+	return len(probabilities) - 1
+}
@@ -0,0 +1,100 @@
+// Copyright 2024 gorse Project Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/assert"
+)
+
+func newSearchGrid() ParamsGrid {
+	return ParamsGrid{
+		NFactors:  lo.ToAnySlice(lo.Range(10)),
+		Lr:        lo.ToAnySlice(lo.Range(10)),
+		Reg:       lo.ToAnySlice(lo.Range(10)),
+		Optimizer: []interface{}{"sgd", "adam"},
+	}
+}
+
+// searchObjective is maximized at (7, 2, 5, adam). Scores grow with epochs.
+func searchObjective(params Params, epochs int) float32 {
+	x, y, z := params.GetInt(NFactors, 0), params.GetInt(Lr, 0), params.GetInt(Reg, 0)
+	score := -float32((x-7)*(x-7) + (y-2)*(y-2) + (z-5)*(z-5))
+	if params.GetString(Optimizer, "") != "adam" {
+		score -= 1
+	}
+	return score * 100 / float32(epochs)
+}
+
+func bestTrial(trials []Trial, epochs int) Trial {
+	best := Trial{Score: -1e9}
+	for _, trial := range trials {
+		if trial.Epochs == epochs && trial.Score > best.Score {
+			best = trial
+		}
+	}
+	return best
+}
+
+func TestSearchOptions_Rungs(t *testing.T) {
+	assert.Equal(t, []int{100}, SearchOptions{NumEpochs: 100}.Rungs())
+	assert.Equal(t, []int{3, 11, 33, 100}, SearchOptions{NumEpochs: 100, EarlyStopping: true}.Rungs())
+	assert.Equal(t, []int{1, 3, 10}, SearchOptions{NumEpochs: 10, EarlyStopping: true}.Rungs())
+	assert.Equal(t, []int{0}, SearchOptions{EarlyStopping: true}.Rungs())
+}
+
+func TestSearch(t *testing.T) {
+	grid := newSearchGrid()
+	randomTrials := Search(grid, SearchOptions{Strategy: RandomSearch, NumTrials: 30, NumEpochs: 100}, searchObjective)
+	assert.Len(t, randomTrials, 30)
+	tpeTrials := Search(grid, SearchOptions{Strategy: TPESearch, NumTrials: 30, NumEpochs: 100}, searchObjective)
+	assert.Len(t, tpeTrials, 30)
+	assert.Greater(t, bestTrial(tpeTrials, 100).Score, bestTrial(randomTrials, 100).Score)
+
+	// warm start from history
+	warmTrials := Search(grid, SearchOptions{Strategy: TPESearch, NumTrials: 10, NumEpochs: 100, History: tpeTrials, Seed: 1}, searchObjective)
+	assert.GreaterOrEqual(t, bestTrial(warmTrials, 100).Score, bestTrial(tpeTrials, 100).Score-3)
+
+	// early stopping
+	var numEpochs int
+	earlyStoppingTrials := Search(grid, SearchOptions{Strategy: TPESearch, NumTrials: 30, NumEpochs: 100, EarlyStopping: true},
+		func(params Params, epochs int) float32 {
+			numEpochs += epochs
+			return searchObjective(params, epochs)
+		})
+	assert.Less(t, numEpochs, 30*100/2)
+	assert.Equal(t, 30, len(lo.Filter(earlyStoppingTrials, func(trial Trial, _ int) bool { return trial.Epochs == 3 })))
+	assert.NotEmpty(t, lo.Filter(earlyStoppingTrials, func(trial Trial, _ int) bool { return trial.Epochs == 100 }))
+}
+
+func TestSearchHistory(t *testing.T) {
+	history := NewSearchHistory(3)
+	for i := 0; i < 5; i++ {
+		history.Add("bpr", Trial{Params: Params{NFactors: i}, Epochs: 10, Score: float32(i)})
+	}
+	history.Add("fm", Trial{Params: Params{Optimizer: "adam"}, Epochs: 10, Score: 1})
+	assert.Equal(t, []float32{2, 3, 4}, lo.Map(history.Get("bpr"), func(trial Trial, _ int) float32 { return trial.Score }))
+	assert.Empty(t, history.Get("als"))
+
+	buf := bytes.NewBuffer(nil)
+	assert.NoError(t, history.Marshal(buf))
+	restored := NewSearchHistory(3)
+	assert.NoError(t, restored.Unmarshal(buf))
+	assert.Len(t, restored.Get("bpr"), 3)
+	assert.Equal(t, "adam", restored.Get("fm")[0].Params.GetString(Optimizer, ""))
+}
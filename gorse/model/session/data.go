@@ -0,0 +1,97 @@
+// Copyright 2024 gorse Project Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package session
+
+import (
+	mapset "github.com/deckarep/golang-set/v2"
+	"github.com/zhenghaoz/gorse/base"
+)
+
+// DataSet contains time-ordered sequences of items. Each sequence is the positive
+// feedback of a user sorted by timestamp.
+type DataSet struct {
+	ItemIndex base.Index
+	Sequences [][]int32
+}
+
+// NewDataSet creates an empty data set.
+func NewDataSet() *DataSet {
+	return &DataSet{
+		ItemIndex: base.NewMapIndex(),
+		Sequences: make([][]int32, 0),
+	}
+}
+
+// AddItem adds an item to the data set and returns its index.
+func (dataset *DataSet) AddItem(itemId string) int32 {
+	dataset.ItemIndex.Add(itemId)
+	return dataset.ItemIndex.ToNumber(itemId)
+}
+
+// AddSequence adds a time-ordered sequence of items. Sequences shorter than 2 items
+// are dropped since there is nothing to predict from them.
+func (dataset *DataSet) AddSequence(itemIds ...string) {
+	if len(itemIds) < 2 {
+		return
+	}
+	sequence := make([]int32, len(itemIds))
+	for i, itemId := range itemIds {
+		sequence[i] = dataset.AddItem(itemId)
+	}
+	dataset.Sequences = append(dataset.Sequences, sequence)
+}
+
+// Count returns the number of sequences.
+func (dataset *DataSet) Count() int {
+	return len(dataset.Sequences)
+}
+
+// ItemCount returns the number of items.
+func (dataset *DataSet) ItemCount() int {
+	return int(dataset.ItemIndex.Len())
+}
+
+// Split the data set into a train set and a test set by leaving the last item of
+// sequences out. The test set keeps the whole sequence, whose last item is the target
+// and the rest is the history. If numTestSequences is not positive, all sequences
+// with more than 2 items are used for testing.
+func (dataset *DataSet) Split(numTestSequences int, seed int64) (*DataSet, *DataSet) {
+	trainSet, testSet := new(DataSet), new(DataSet)
+	trainSet.ItemIndex, testSet.ItemIndex = dataset.ItemIndex, dataset.ItemIndex
+	var candidates []int
+	for i, sequence := range dataset.Sequences {
+		if len(sequence) > 2 {
+			candidates = append(candidates, i)
+		}
+	}
+	testIndices := mapset.NewSet[int]()
+	if numTestSequences <= 0 || numTestSequences >= len(candidates) {
+		testIndices.Append(candidates...)
+	} else {
+		rng := base.NewRandomGenerator(seed)
+		for _, k := range rng.Sample(0, len(candidates), numTestSequences) {
+			testIndices.Add(candidates[k])
+		}
+	}
+	for i, sequence := range dataset.Sequences {
+		if testIndices.Contains(i) {
+			trainSet.Sequences = append(trainSet.Sequences, sequence[:len(sequence)-1])
+			testSet.Sequences = append(testSet.Sequences, sequence)
+		} else {
+			trainSet.Sequences = append(trainSet.Sequences, sequence)
+		}
+	}
+	return trainSet, testSet
+}
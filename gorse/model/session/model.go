@@ -0,0 +1,444 @@
+// Copyright 2024 gorse Project Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package session
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"reflect"
+	"time"
+
+	"github.com/chewxy/math32"
+	mapset "github.com/deckarep/golang-set/v2"
+	"github.com/juju/errors"
+	"github.com/samber/lo"
+	"github.com/zhenghaoz/gorse/base"
+	"github.com/zhenghaoz/gorse/base/encoding"
+	"github.com/zhenghaoz/gorse/base/floats"
+	"github.com/zhenghaoz/gorse/base/heap"
+	"github.com/zhenghaoz/gorse/base/log"
+	"github.com/zhenghaoz/gorse/base/progress"
+	"github.com/zhenghaoz/gorse/common/nn"
+	"github.com/zhenghaoz/gorse/model"
+	"github.com/zhenghaoz/gorse/model/ranking"
+	"go.uber.org/zap"
+)
+
+// Model recommends items following a sequence of items.
+type Model interface {
+	model.Model
+	// Fit a model with a train set and a test set.
+	Fit(ctx context.Context, trainSet, testSet *DataSet, config *ranking.FitConfig) ranking.Score
+	// GetItemIndex returns item index.
+	GetItemIndex() base.Index
+	// InternalPredict returns scores of all items following a sequence of item indices.
+	InternalPredict(sequence []int32) []float32
+	// Recommend returns top n items following a sequence of items. Items in the sequence are excluded.
+	Recommend(itemIds []string, n int) ([]string, []float32)
+	// Marshal model into byte stream.
+	Marshal(w io.Writer) error
+	// Unmarshal model from byte stream.
+	Unmarshal(r io.Reader) error
+}
+
+// maskValue is added to attention scores of invisible positions.
+const maskValue = -1e9
+
+// SASRec is a lite version of self-attentive sequential recommendation [1]. Items of a sequence are embedded with
+// learnable positional embeddings and fed into a causal single-head self-attention block followed by a point-wise
+// feed-forward network. The output at the last position is used to score the next item.
+//
+// [1] Kang, Wang-Cheng, and Julian McAuley. "Self-attentive sequential recommendation."
+// 2018 IEEE international conference on data mining (ICDM). IEEE, 2018.
+//
+// Hyper-parameters:
+//
+//	NFactors   - The number of latent factors. Default is 16.
+//	NEpochs    - The number of training epochs. Default is 20.
+//	BatchSize  - The number of sequences in a mini-batch. Default is 64.
+//	MaxLength  - The maximum length of sequences. Default is 20.
+//	Lr         - The learning rate of Adam. Default is 0.005.
+//	InitStdDev - The standard deviation of initial item embeddings. Default is 0.01.
+type SASRec struct {
+	model.BaseModel
+	ItemIndex base.Index
+	// weights
+	itemEmbedding     *nn.Tensor // (n+1, d), the first row is padding
+	positionEmbedding *nn.Tensor // (L, d)
+	wq                *nn.Tensor // (d, d)
+	wk                *nn.Tensor // (d, d)
+	wv                *nn.Tensor // (d, d)
+	w1                *nn.Tensor // (d, d)
+	w2                *nn.Tensor // (d, d)
+	// hyper parameters
+	nFactors   int
+	nEpochs    int
+	batchSize  int
+	maxLength  int
+	lr         float32
+	initStdDev float32
+}
+
+// NewSASRec creates a SASRec model.
+func NewSASRec(params model.Params) *SASRec {
+	m := new(SASRec)
+	m.SetParams(params)
+	return m
+}
+
+// SetParams sets hyper-parameters of SASRec.
+func (m *SASRec) SetParams(params model.Params) {
+	m.BaseModel.SetParams(params)
+	m.nFactors = m.Params.GetInt(model.NFactors, 16)
+	m.nEpochs = m.Params.GetInt(model.NEpochs, 20)
+	m.batchSize = m.Params.GetInt(model.BatchSize, 64)
+	m.maxLength = m.Params.GetInt(model.MaxLength, 20)
+	m.lr = m.Params.GetFloat32(model.Lr, 0.005)
+	m.initStdDev = m.Params.GetFloat32(model.InitStdDev, 0.01)
+}
+
+func (m *SASRec) GetParamsGrid(withSize bool) model.ParamsGrid {
+	return model.ParamsGrid{
+		model.NFactors:   lo.If(withSize, []interface{}{16, 32, 64}).Else([]interface{}{16}),
+		model.Lr:         []interface{}{0.001, 0.005, 0.01},
+		model.InitStdDev: []interface{}{0.001, 0.005, 0.01, 0.05, 0.1},
+	}
+}
+
+func (m *SASRec) Clear() {
+	m.ItemIndex = nil
+	m.itemEmbedding = nil
+}
+
+func (m *SASRec) Invalid() bool {
+	return m == nil ||
+		m.ItemIndex == nil ||
+		m.itemEmbedding == nil
+}
+
+func (m *SASRec) GetItemIndex() base.Index {
+	return m.ItemIndex
+}
+
+// Init parameters of SASRec.
+func (m *SASRec) Init(trainSet *DataSet) {
+	m.ItemIndex = trainSet.ItemIndex
+	m.itemEmbedding = nn.Normal(0, m.initStdDev, trainSet.ItemCount()+1, m.nFactors)
+	m.positionEmbedding = nn.Normal(0, m.initStdDev, m.maxLength, m.nFactors)
+	stdDev := 1 / math32.Sqrt(float32(m.nFactors))
+	m.wq = nn.Normal(0, stdDev, m.nFactors, m.nFactors)
+	m.wk = nn.Normal(0, stdDev, m.nFactors, m.nFactors)
+	m.wv = nn.Normal(0, stdDev, m.nFactors, m.nFactors)
+	m.w1 = nn.Normal(0, stdDev, m.nFactors, m.nFactors)
+	m.w2 = nn.Normal(0, stdDev, m.nFactors, m.nFactors)
+}
+
+func (m *SASRec) parameters() []*nn.Tensor {
+	return []*nn.Tensor{m.itemEmbedding, m.positionEmbedding, m.wq, m.wk, m.wv, m.w1, m.w2}
+}
+
+// forward returns hidden states (B, L, d) of item sequences (B, L). Items are shifted by one and zeros are padding.
+func (m *SASRec) forward(x *nn.Tensor) *nn.Tensor {
+	batchSize := x.Shape()[0]
+	// mask future and padding positions
+	mask := make([]float32, batchSize*m.maxLength*m.maxLength)
+	for b := 0; b < batchSize; b++ {
+		for i := 0; i < m.maxLength; i++ {
+			for j := 0; j < m.maxLength; j++ {
+				if j > i || (j < i && x.Get(b, j) == 0) {
+					mask[(b*m.maxLength+i)*m.maxLength+j] = maskValue
+				}
+			}
+		}
+	}
+	// embedding
+	e := nn.Add(nn.Embedding(m.itemEmbedding, x), m.positionEmbedding)
+	flat := nn.Reshape(e, batchSize*m.maxLength, m.nFactors)
+	// self-attention
+	q := nn.Reshape(nn.MatMul(flat, m.wq), batchSize, m.maxLength, m.nFactors)
+	k := nn.Reshape(nn.MatMul(flat, m.wk), batchSize, m.maxLength, m.nFactors)
+	v := nn.Reshape(nn.MatMul(flat, m.wv), batchSize, m.maxLength, m.nFactors)
+	a := nn.Mul(nn.BMM(q, k, false, true), nn.NewScalar(1/math32.Sqrt(float32(m.nFactors))))
+	a = nn.Softmax(nn.Add(a, nn.NewTensor(mask, batchSize, m.maxLength, m.maxLength)), 2)
+	h := nn.Add(e, nn.BMM(a, v))
+	// point-wise feed-forward
+	f := nn.MatMul(nn.ReLu(nn.MatMul(nn.Reshape(h, batchSize*m.maxLength, m.nFactors), m.w1)), m.w2)
+	return nn.Add(h, nn.Reshape(f, batchSize, m.maxLength, m.nFactors))
+}
+
+// pad converts item indices to a sequence of length L. Items are shifted by one and left padded by zeros.
+func (m *SASRec) pad(sequence []int32) []float32 {
+	if len(sequence) > m.maxLength {
+		sequence = sequence[len(sequence)-m.maxLength:]
+	}
+	padded := make([]float32, m.maxLength)
+	for i, itemIndex := range sequence {
+		padded[m.maxLength-len(sequence)+i] = float32(itemIndex + 1)
+	}
+	return padded
+}
+
+// samples splits train sequences into inputs and targets. Long sequences are cut into chunks of length L.
+func (m *SASRec) samples(trainSet *DataSet) (inputs, targets [][]float32) {
+	for _, sequence := range trainSet.Sequences {
+		for end := len(sequence); end > 1; end -= m.maxLength {
+			start := max(0, end-m.maxLength-1)
+			inputs = append(inputs, m.pad(sequence[start:end-1]))
+			targets = append(targets, m.pad(sequence[start+1:end]))
+		}
+	}
+	return
+}
+
+func (m *SASRec) Fit(ctx context.Context, trainSet, testSet *DataSet, config *ranking.FitConfig) ranking.Score {
+	config = config.LoadDefaultIfNil()
+	log.Logger().Info("fit sasrec",
+		zap.Int("train_set_size", trainSet.Count()),
+		zap.Int("test_set_size", testSet.Count()),
+		zap.Any("params", m.GetParams()),
+		zap.Any("config", config))
+	m.Init(trainSet)
+	inputs, targets := m.samples(trainSet)
+	rng := m.GetRandomGenerator()
+	optimizer := nn.NewAdam(m.parameters(), m.lr)
+	score := ranking.Score{}
+	_, span := progress.Start(ctx, "SASRec.Fit", m.nEpochs)
+	for epoch := 1; epoch <= m.nEpochs; epoch++ {
+		fitStart := time.Now()
+		cost := float32(0)
+		perm := rng.Perm(len(inputs))
+		for i := 0; i < len(perm); i += m.batchSize {
+			// create mini-batch, the last batch is padded by empty sequences
+			x := make([]float32, m.batchSize*m.maxLength)
+			positives := make([]float32, m.batchSize*m.maxLength)
+			negatives := make([]float32, m.batchSize*m.maxLength)
+			weights := make([]float32, m.batchSize*m.maxLength)
+			count := 0
+			for b := 0; b < m.batchSize && i+b < len(perm); b++ {
+				copy(x[b*m.maxLength:], inputs[perm[i+b]])
+				copy(positives[b*m.maxLength:], targets[perm[i+b]])
+				for j := b * m.maxLength; j < (b+1)*m.maxLength; j++ {
+					if positives[j] > 0 {
+						for negatives[j] == 0 || negatives[j] == positives[j] {
+							negatives[j] = float32(rng.Intn(trainSet.ItemCount()) + 1)
+						}
+						weights[j] = 1
+						count++
+					}
+				}
+			}
+			if count == 0 {
+				continue
+			}
+			// loss = log(1 + exp(-h * e_pos)) + log(1 + exp(h * e_neg))
+			h := m.forward(nn.NewTensor(x, m.batchSize, m.maxLength))
+			posLogits := nn.Sum(nn.Mul(h, nn.Embedding(m.itemEmbedding, nn.NewTensor(positives, m.batchSize, m.maxLength))), 2)
+			negLogits := nn.Sum(nn.Mul(h, nn.Embedding(m.itemEmbedding, nn.NewTensor(negatives, m.batchSize, m.maxLength))), 2)
+			loss := nn.Add(
+				nn.Log(nn.Add(nn.NewScalar(1), nn.Exp(nn.Neg(posLogits)))),
+				nn.Log(nn.Add(nn.NewScalar(1), nn.Exp(negLogits))))
+			loss = nn.Mul(nn.Sum(nn.Mul(loss, nn.NewTensor(weights, m.batchSize, m.maxLength))), nn.NewScalar(1/float32(count)))
+			cost += loss.Data()[0]
+			optimizer.ZeroGrad()
+			loss.Backward()
+			optimizer.Step()
+		}
+		fitTime := time.Since(fitStart)
+		// Cross validation
+		if epoch%config.Verbose == 0 || epoch == m.nEpochs {
+			evalStart := time.Now()
+			score = Evaluate(m, testSet, config.TopK, config.Candidates)
+			evalTime := time.Since(evalStart)
+			log.Logger().Debug(fmt.Sprintf("fit sasrec %v/%v", epoch, m.nEpochs),
+				zap.String("fit_time", fitTime.String()),
+				zap.String("eval_time", evalTime.String()),
+				zap.Float32("loss", cost),
+				zap.Float32(fmt.Sprintf("NDCG@%v", config.TopK), score.NDCG),
+				zap.Float32(fmt.Sprintf("Precision@%v", config.TopK), score.Precision),
+				zap.Float32(fmt.Sprintf("Recall@%v", config.TopK), score.Recall))
+			// check NaN
+			if math32.IsNaN(cost) {
+				log.Logger().Warn("model diverged", zap.Float32("lr", m.lr))
+				break
+			}
+		}
+		span.Add(1)
+	}
+	span.End()
+	log.Logger().Info("fit sasrec complete",
+		zap.Float32(fmt.Sprintf("NDCG@%v", config.TopK), score.NDCG),
+		zap.Float32(fmt.Sprintf("Precision@%v", config.TopK), score.Precision),
+		zap.Float32(fmt.Sprintf("Recall@%v", config.TopK), score.Recall))
+	return score
+}
+
+func (m *SASRec) InternalPredict(sequence []int32) []float32 {
+	h := m.forward(nn.NewTensor(m.pad(sequence), 1, m.maxLength)).Data()
+	last := h[(m.maxLength-1)*m.nFactors:]
+	embeddings := m.itemEmbedding.Data()
+	scores := make([]float32, m.ItemIndex.Len())
+	for i := range scores {
+		scores[i] = floats.Dot(last, embeddings[(i+1)*m.nFactors:(i+2)*m.nFactors])
+	}
+	return scores
+}
+
+func (m *SASRec) Recommend(itemIds []string, n int) ([]string, []float32) {
+	var sequence []int32
+	for _, itemId := range itemIds {
+		if itemIndex := m.ItemIndex.ToNumber(itemId); itemIndex != base.NotId {
+			sequence = append(sequence, itemIndex)
+		}
+	}
+	if len(sequence) == 0 {
+		return nil, nil
+	}
+	excludeSet := mapset.NewSet(sequence...)
+	filter := heap.NewTopKFilter[int32, float32](n)
+	for i, score := range m.InternalPredict(sequence) {
+		if !excludeSet.Contains(int32(i)) {
+			filter.Push(int32(i), score)
+		}
+	}
+	indices, scores := filter.PopAll()
+	return lo.Map(indices, func(itemIndex int32, _ int) string {
+		return m.ItemIndex.ToName(itemIndex)
+	}), scores
+}
+
+// Marshal model into byte stream.
+func (m *SASRec) Marshal(w io.Writer) error {
+	// write params
+	if err := encoding.WriteGob(w, m.Params); err != nil {
+		return errors.Trace(err)
+	}
+	// write index
+	if err := base.MarshalIndex(w, m.ItemIndex); err != nil {
+		return errors.Trace(err)
+	}
+	// write weights
+	for _, t := range m.parameters() {
+		if err := encoding.WriteGob(w, t.Shape()); err != nil {
+			return errors.Trace(err)
+		}
+		if err := encoding.WriteGob(w, t.Data()); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	return nil
+}
+
+// Unmarshal model from byte stream.
+func (m *SASRec) Unmarshal(r io.Reader) error {
+	// read params
+	var params model.Params
+	if err := encoding.ReadGob(r, &params); err != nil {
+		return errors.Trace(err)
+	}
+	m.SetParams(params)
+	// read index
+	var err error
+	if m.ItemIndex, err = base.UnmarshalIndex(r); err != nil {
+		return errors.Trace(err)
+	}
+	// read weights
+	for _, t := range []**nn.Tensor{&m.itemEmbedding, &m.positionEmbedding, &m.wq, &m.wk, &m.wv, &m.w1, &m.w2} {
+		var shape []int
+		if err = encoding.ReadGob(r, &shape); err != nil {
+			return errors.Trace(err)
+		}
+		var data []float32
+		if err = encoding.ReadGob(r, &data); err != nil {
+			return errors.Trace(err)
+		}
+		*t = nn.NewTensor(data, shape...)
+	}
+	return nil
+}
+
+// Evaluate a session model by ranking the last item of each test sequence among sampled candidates, given the rest
+// of the sequence.
+func Evaluate(m Model, testSet *DataSet, topK, numCandidates int) ranking.Score {
+	var score ranking.Score
+	var count float32
+	rng := base.NewRandomGenerator(0)
+	for _, sequence := range testSet.Sequences {
+		if len(sequence) < 2 {
+			continue
+		}
+		history, target := sequence[:len(sequence)-1], sequence[len(sequence)-1]
+		// sample negative candidates
+		excludeSet := mapset.NewSet(history...)
+		excludeSet.Add(target)
+		candidates := rng.SampleInt32(0, int32(testSet.ItemCount()), numCandidates, excludeSet)
+		candidates = append(candidates, target)
+		// rank candidates
+		scores := m.InternalPredict(history)
+		filter := heap.NewTopKFilter[int32, float32](topK)
+		for _, itemIndex := range candidates {
+			filter.Push(itemIndex, scores[itemIndex])
+		}
+		rankList, _ := filter.PopAll()
+		targetSet := mapset.NewSet(target)
+		score.NDCG += ranking.NDCG(targetSet, rankList)
+		score.Precision += ranking.Precision(targetSet, rankList)
+		score.Recall += ranking.Recall(targetSet, rankList)
+		count++
+	}
+	if count > 0 {
+		score.NDCG /= count
+		score.Precision /= count
+		score.Recall /= count
+	}
+	return score
+}
+
+// GetModelName returns the name of a session model.
+func GetModelName(m Model) string {
+	switch m.(type) {
+	case *SASRec:
+		return "sasrec"
+	}
+	panic(fmt.Sprintf("unknown model %v", reflect.TypeOf(m)))
+}
+
+// MarshalModel writes a session model with its name into byte stream.
+func MarshalModel(w io.Writer, m Model) error {
+	if err := encoding.WriteString(w, GetModelName(m)); err != nil {
+		return errors.Trace(err)
+	}
+	if err := m.Marshal(w); err != nil {
+		return errors.Trace(err)
+	}
+	return nil
+}
+
+// UnmarshalModel reads a session model written by MarshalModel.
+func UnmarshalModel(r io.Reader) (Model, error) {
+	name, err := encoding.ReadString(r)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	switch name {
+	case "sasrec":
+		var sasrec SASRec
+		if err := sasrec.Unmarshal(r); err != nil {
+			return nil, errors.Trace(err)
+		}
+		return &sasrec, nil
+	}
+	return nil, fmt.Errorf("unknown model %v", name)
+}
@@ -0,0 +1,99 @@
+// Copyright 2024 gorse Project Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package session
+
+import (
+	"bytes"
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zhenghaoz/gorse/base"
+	"github.com/zhenghaoz/gorse/model"
+	"github.com/zhenghaoz/gorse/model/ranking"
+)
+
+// newCyclicDataSet creates sequences walking through items in a cycle.
+func newCyclicDataSet(numSequences, numItems int) *DataSet {
+	rng := base.NewRandomGenerator(0)
+	dataset := NewDataSet()
+	for i := 0; i < numSequences; i++ {
+		start := rng.Intn(numItems)
+		length := 5 + rng.Intn(10)
+		itemIds := make([]string, length)
+		for j := range itemIds {
+			itemIds[j] = strconv.Itoa((start + j) % numItems)
+		}
+		dataset.AddSequence(itemIds...)
+	}
+	return dataset
+}
+
+func TestDataSet(t *testing.T) {
+	dataset := NewDataSet()
+	dataset.AddSequence("1", "2", "3")
+	dataset.AddSequence("1")
+	dataset.AddSequence("2", "4")
+	assert.Equal(t, 2, dataset.Count())
+	assert.Equal(t, 4, dataset.ItemCount())
+	assert.Equal(t, [][]int32{{0, 1, 2}, {1, 3}}, dataset.Sequences)
+
+	// sequences with more than 2 items are split
+	trainSet, testSet := dataset.Split(0, 0)
+	assert.Equal(t, [][]int32{{0, 1}, {1, 3}}, trainSet.Sequences)
+	assert.Equal(t, [][]int32{{0, 1, 2}}, testSet.Sequences)
+
+	// sample test sequences
+	dataset = newCyclicDataSet(100, 50)
+	trainSet, testSet = dataset.Split(10, 0)
+	assert.Equal(t, 100, trainSet.Count())
+	assert.Equal(t, 10, testSet.Count())
+}
+
+func TestSASRec(t *testing.T) {
+	dataset := newCyclicDataSet(500, 50)
+	trainSet, testSet := dataset.Split(100, 0)
+	m := NewSASRec(model.Params{
+		model.NEpochs:   10,
+		model.MaxLength: 10,
+		model.Lr:        0.01,
+	})
+	score := m.Fit(context.Background(), trainSet, testSet, ranking.NewFitConfig())
+	assert.Greater(t, score.NDCG, float32(0.5))
+
+	// the next item in the cycle should be recommended
+	itemIds, scores := m.Recommend([]string{"unknown", "3", "4", "5"}, 3)
+	assert.Len(t, itemIds, 3)
+	assert.Len(t, scores, 3)
+	assert.Equal(t, "6", itemIds[0])
+	assert.NotContains(t, itemIds, "5")
+	itemIds, _ = m.Recommend([]string{"unknown"}, 3)
+	assert.Empty(t, itemIds)
+
+	// marshal model
+	buf := bytes.NewBuffer(nil)
+	err := MarshalModel(buf, m)
+	assert.NoError(t, err)
+	copied, err := UnmarshalModel(buf)
+	assert.NoError(t, err)
+	assert.False(t, copied.Invalid())
+	assert.Equal(t, m.GetParams(), copied.GetParams())
+	assert.Equal(t, score, Evaluate(copied, testSet, 10, 100))
+
+	// clear model
+	m.Clear()
+	assert.True(t, m.Invalid())
+}
@@ -0,0 +1,48 @@
+// Copyright 2024 gorse Project Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package session
+
+import (
+	"context"
+
+	"github.com/zhenghaoz/gorse/model"
+	"github.com/zhenghaoz/gorse/model/ranking"
+)
+
+// SearchCV searches hyper-parameters of SASRec by the strategy in options. Hyper-parameters out of the grid are taken
+// from params, and only models trained for all epochs are candidates of the best model. It returns nil if no model
+// has been trained for all epochs.
+func SearchCV(ctx context.Context, params model.Params, trainSet, testSet *DataSet, withSize bool,
+	options model.SearchOptions, config *ranking.FitConfig) (*SASRec, ranking.Score) {
+	rungs := options.Rungs()
+	var (
+		bestModel *SASRec
+		bestScore ranking.Score
+	)
+	model.Search(NewSASRec(params).GetParamsGrid(withSize), options, func(trialParams model.Params, epochs int) float32 {
+		fitParams := params.Overwrite(trialParams)
+		if epochs > 0 {
+			fitParams[model.NEpochs] = epochs
+		}
+		m := NewSASRec(fitParams)
+		score := m.Fit(ctx, trainSet, testSet, config)
+		if epochs == rungs[len(rungs)-1] && (bestModel == nil || score.NDCG > bestScore.NDCG) {
+			bestModel = m
+			bestScore = score
+		}
+		return score.NDCG
+	})
+	return bestModel, bestScore
+}
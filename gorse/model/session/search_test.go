@@ -0,0 +1,36 @@
+// Copyright 2024 gorse Project Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package session
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zhenghaoz/gorse/model"
+	"github.com/zhenghaoz/gorse/model/ranking"
+)
+
+func TestSearchCV(t *testing.T) {
+	dataset := newCyclicDataSet(200, 30)
+	trainSet, testSet := dataset.Split(50, 0)
+	m, score := SearchCV(context.Background(), model.Params{model.MaxLength: 10}, trainSet, testSet, false,
+		model.SearchOptions{Strategy: model.RandomSearch, NumTrials: 3, NumEpochs: 3}, ranking.NewFitConfig())
+	assert.NotNil(t, m)
+	assert.Equal(t, 3, m.GetParams().GetInt(model.NEpochs, 0))
+	assert.Equal(t, 10, m.GetParams().GetInt(model.MaxLength, 0))
+	assert.Contains(t, []any{0.001, 0.005, 0.01}, m.GetParams()[model.Lr])
+	assert.Positive(t, score.NDCG)
+}
@@ -37,13 +37,16 @@ const (
 	CacheStore_Get_FullMethodName                 = "/protocol.CacheStore/Get"
 	CacheStore_Set_FullMethodName                 = "/protocol.CacheStore/Set"
 	CacheStore_Delete_FullMethodName              = "/protocol.CacheStore/Delete"
+	CacheStore_CompareAndSet_FullMethodName       = "/protocol.CacheStore/CompareAndSet"
 	CacheStore_GetSet_FullMethodName              = "/protocol.CacheStore/GetSet"
 	CacheStore_SetSet_FullMethodName              = "/protocol.CacheStore/SetSet"
 	CacheStore_AddSet_FullMethodName              = "/protocol.CacheStore/AddSet"
 	CacheStore_RemSet_FullMethodName              = "/protocol.CacheStore/RemSet"
 	CacheStore_Push_FullMethodName                = "/protocol.CacheStore/Push"
 	CacheStore_Pop_FullMethodName                 = "/protocol.CacheStore/Pop"
+	CacheStore_PopBefore_FullMethodName           = "/protocol.CacheStore/PopBefore"
 	CacheStore_Remain_FullMethodName              = "/protocol.CacheStore/Remain"
+	CacheStore_Remove_FullMethodName              = "/protocol.CacheStore/Remove"
 	CacheStore_AddScores_FullMethodName           = "/protocol.CacheStore/AddScores"
 	CacheStore_SearchScores_FullMethodName        = "/protocol.CacheStore/SearchScores"
 	CacheStore_DeleteScores_FullMethodName        = "/protocol.CacheStore/DeleteScores"
@@ -60,13 +63,16 @@ type CacheStoreClient interface {
 	Get(ctx context.Context, in *GetRequest, opts ...grpc.CallOption) (*GetResponse, error)
 	Set(ctx context.Context, in *SetRequest, opts ...grpc.CallOption) (*SetResponse, error)
 	Delete(ctx context.Context, in *DeleteRequest, opts ...grpc.CallOption) (*DeleteResponse, error)
+	CompareAndSet(ctx context.Context, in *CompareAndSetRequest, opts ...grpc.CallOption) (*CompareAndSetResponse, error)
 	GetSet(ctx context.Context, in *GetSetRequest, opts ...grpc.CallOption) (*GetSetResponse, error)
 	SetSet(ctx context.Context, in *SetSetRequest, opts ...grpc.CallOption) (*SetSetResponse, error)
 	AddSet(ctx context.Context, in *AddSetRequest, opts ...grpc.CallOption) (*AddSetResponse, error)
 	RemSet(ctx context.Context, in *RemSetRequest, opts ...grpc.CallOption) (*RemSetResponse, error)
 	Push(ctx context.Context, in *PushRequest, opts ...grpc.CallOption) (*PushResponse, error)
 	Pop(ctx context.Context, in *PopRequest, opts ...grpc.CallOption) (*PopResponse, error)
+	PopBefore(ctx context.Context, in *PopBeforeRequest, opts ...grpc.CallOption) (*PopResponse, error)
 	Remain(ctx context.Context, in *RemainRequest, opts ...grpc.CallOption) (*RemainResponse, error)
+	Remove(ctx context.Context, in *RemoveRequest, opts ...grpc.CallOption) (*RemoveResponse, error)
 	AddScores(ctx context.Context, in *AddScoresRequest, opts ...grpc.CallOption) (*AddScoresResponse, error)
 	SearchScores(ctx context.Context, in *SearchScoresRequest, opts ...grpc.CallOption) (*SearchScoresResponse, error)
 	DeleteScores(ctx context.Context, in *DeleteScoresRequest, opts ...grpc.CallOption) (*DeleteScoresResponse, error)
@@ -123,6 +129,16 @@ func (c *cacheStoreClient) Delete(ctx context.Context, in *DeleteRequest, opts .
 	return out, nil
 }
 
+func (c *cacheStoreClient) CompareAndSet(ctx context.Context, in *CompareAndSetRequest, opts ...grpc.CallOption) (*CompareAndSetResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CompareAndSetResponse)
+	err := c.cc.Invoke(ctx, CacheStore_CompareAndSet_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *cacheStoreClient) GetSet(ctx context.Context, in *GetSetRequest, opts ...grpc.CallOption) (*GetSetResponse, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(GetSetResponse)
@@ -183,6 +199,16 @@ func (c *cacheStoreClient) Pop(ctx context.Context, in *PopRequest, opts ...grpc
 	return out, nil
 }
 
+func (c *cacheStoreClient) PopBefore(ctx context.Context, in *PopBeforeRequest, opts ...grpc.CallOption) (*PopResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(PopResponse)
+	err := c.cc.Invoke(ctx, CacheStore_PopBefore_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *cacheStoreClient) Remain(ctx context.Context, in *RemainRequest, opts ...grpc.CallOption) (*RemainResponse, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(RemainResponse)
@@ -193,6 +219,16 @@ func (c *cacheStoreClient) Remain(ctx context.Context, in *RemainRequest, opts .
 	return out, nil
 }
 
+func (c *cacheStoreClient) Remove(ctx context.Context, in *RemoveRequest, opts ...grpc.CallOption) (*RemoveResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RemoveResponse)
+	err := c.cc.Invoke(ctx, CacheStore_Remove_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *cacheStoreClient) AddScores(ctx context.Context, in *AddScoresRequest, opts ...grpc.CallOption) (*AddScoresResponse, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(AddScoresResponse)
@@ -261,13 +297,16 @@ type CacheStoreServer interface {
 	Get(context.Context, *GetRequest) (*GetResponse, error)
 	Set(context.Context, *SetRequest) (*SetResponse, error)
 	Delete(context.Context, *DeleteRequest) (*DeleteResponse, error)
+	CompareAndSet(context.Context, *CompareAndSetRequest) (*CompareAndSetResponse, error)
 	GetSet(context.Context, *GetSetRequest) (*GetSetResponse, error)
 	SetSet(context.Context, *SetSetRequest) (*SetSetResponse, error)
 	AddSet(context.Context, *AddSetRequest) (*AddSetResponse, error)
 	RemSet(context.Context, *RemSetRequest) (*RemSetResponse, error)
 	Push(context.Context, *PushRequest) (*PushResponse, error)
 	Pop(context.Context, *PopRequest) (*PopResponse, error)
+	PopBefore(context.Context, *PopBeforeRequest) (*PopResponse, error)
 	Remain(context.Context, *RemainRequest) (*RemainResponse, error)
+	Remove(context.Context, *RemoveRequest) (*RemoveResponse, error)
 	AddScores(context.Context, *AddScoresRequest) (*AddScoresResponse, error)
 	SearchScores(context.Context, *SearchScoresRequest) (*SearchScoresResponse, error)
 	DeleteScores(context.Context, *DeleteScoresRequest) (*DeleteScoresResponse, error)
@@ -296,6 +335,9 @@ func (UnimplementedCacheStoreServer) Set(context.Context, *SetRequest) (*SetResp
 func (UnimplementedCacheStoreServer) Delete(context.Context, *DeleteRequest) (*DeleteResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Delete not implemented")
 }
+func (UnimplementedCacheStoreServer) CompareAndSet(context.Context, *CompareAndSetRequest) (*CompareAndSetResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CompareAndSet not implemented")
+}
 func (UnimplementedCacheStoreServer) GetSet(context.Context, *GetSetRequest) (*GetSetResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetSet not implemented")
 }
@@ -314,9 +356,15 @@ func (UnimplementedCacheStoreServer) Push(context.Context, *PushRequest) (*PushR
 func (UnimplementedCacheStoreServer) Pop(context.Context, *PopRequest) (*PopResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Pop not implemented")
 }
+func (UnimplementedCacheStoreServer) PopBefore(context.Context, *PopBeforeRequest) (*PopResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PopBefore not implemented")
+}
 func (UnimplementedCacheStoreServer) Remain(context.Context, *RemainRequest) (*RemainResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Remain not implemented")
 }
+func (UnimplementedCacheStoreServer) Remove(context.Context, *RemoveRequest) (*RemoveResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Remove not implemented")
+}
 func (UnimplementedCacheStoreServer) AddScores(context.Context, *AddScoresRequest) (*AddScoresResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method AddScores not implemented")
 }
@@ -428,6 +476,24 @@ func _CacheStore_Delete_Handler(srv interface{}, ctx context.Context, dec func(i
 	return interceptor(ctx, in, info, handler)
 }
 
+func _CacheStore_CompareAndSet_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CompareAndSetRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CacheStoreServer).CompareAndSet(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CacheStore_CompareAndSet_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CacheStoreServer).CompareAndSet(ctx, req.(*CompareAndSetRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _CacheStore_GetSet_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(GetSetRequest)
 	if err := dec(in); err != nil {
@@ -536,6 +602,24 @@ func _CacheStore_Pop_Handler(srv interface{}, ctx context.Context, dec func(inte
 	return interceptor(ctx, in, info, handler)
 }
 
+func _CacheStore_PopBefore_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PopBeforeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CacheStoreServer).PopBefore(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CacheStore_PopBefore_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CacheStoreServer).PopBefore(ctx, req.(*PopBeforeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _CacheStore_Remain_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(RemainRequest)
 	if err := dec(in); err != nil {
@@ -554,6 +638,24 @@ func _CacheStore_Remain_Handler(srv interface{}, ctx context.Context, dec func(i
 	return interceptor(ctx, in, info, handler)
 }
 
+func _CacheStore_Remove_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RemoveRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CacheStoreServer).Remove(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CacheStore_Remove_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CacheStoreServer).Remove(ctx, req.(*RemoveRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _CacheStore_AddScores_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(AddScoresRequest)
 	if err := dec(in); err != nil {
@@ -685,6 +787,10 @@ var CacheStore_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "Delete",
 			Handler:    _CacheStore_Delete_Handler,
 		},
+		{
+			MethodName: "CompareAndSet",
+			Handler:    _CacheStore_CompareAndSet_Handler,
+		},
 		{
 			MethodName: "GetSet",
 			Handler:    _CacheStore_GetSet_Handler,
@@ -709,10 +815,18 @@ var CacheStore_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "Pop",
 			Handler:    _CacheStore_Pop_Handler,
 		},
+		{
+			MethodName: "PopBefore",
+			Handler:    _CacheStore_PopBefore_Handler,
+		},
 		{
 			MethodName: "Remain",
 			Handler:    _CacheStore_Remain_Handler,
 		},
+		{
+			MethodName: "Remove",
+			Handler:    _CacheStore_Remove_Handler,
+		},
 		{
 			MethodName: "AddScores",
 			Handler:    _CacheStore_AddScores_Handler,
@@ -33,10 +33,15 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	Master_GetMeta_FullMethodName         = "/protocol.Master/GetMeta"
-	Master_GetRankingModel_FullMethodName = "/protocol.Master/GetRankingModel"
-	Master_GetClickModel_FullMethodName   = "/protocol.Master/GetClickModel"
-	Master_PushProgress_FullMethodName    = "/protocol.Master/PushProgress"
+	Master_GetMeta_FullMethodName              = "/protocol.Master/GetMeta"
+	Master_WatchMeta_FullMethodName            = "/protocol.Master/WatchMeta"
+	Master_GetRankingModel_FullMethodName      = "/protocol.Master/GetRankingModel"
+	Master_GetRankingModelDelta_FullMethodName = "/protocol.Master/GetRankingModelDelta"
+	Master_GetClickModel_FullMethodName        = "/protocol.Master/GetClickModel"
+	Master_GetSessionModel_FullMethodName      = "/protocol.Master/GetSessionModel"
+	Master_GetTwoTowerModel_FullMethodName     = "/protocol.Master/GetTwoTowerModel"
+	Master_PushProgress_FullMethodName         = "/protocol.Master/PushProgress"
+	Master_PushShard_FullMethodName            = "/protocol.Master/PushShard"
 )
 
 // MasterClient is the client API for Master service.
@@ -45,10 +50,15 @@ const (
 type MasterClient interface {
 	// meta distribute
 	GetMeta(ctx context.Context, in *NodeInfo, opts ...grpc.CallOption) (*Meta, error)
+	WatchMeta(ctx context.Context, in *NodeInfo, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Meta], error)
 	// data distribute
 	GetRankingModel(ctx context.Context, in *VersionInfo, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Fragment], error)
+	GetRankingModelDelta(ctx context.Context, in *DeltaInfo, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Fragment], error)
 	GetClickModel(ctx context.Context, in *VersionInfo, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Fragment], error)
+	GetSessionModel(ctx context.Context, in *VersionInfo, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Fragment], error)
+	GetTwoTowerModel(ctx context.Context, in *VersionInfo, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Fragment], error)
 	PushProgress(ctx context.Context, in *PushProgressRequest, opts ...grpc.CallOption) (*PushProgressResponse, error)
+	PushShard(ctx context.Context, in *Shard, opts ...grpc.CallOption) (*PushShardResponse, error)
 }
 
 type masterClient struct {
@@ -69,9 +79,28 @@ func (c *masterClient) GetMeta(ctx context.Context, in *NodeInfo, opts ...grpc.C
 	return out, nil
 }
 
+func (c *masterClient) WatchMeta(ctx context.Context, in *NodeInfo, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Meta], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Master_ServiceDesc.Streams[0], Master_WatchMeta_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[NodeInfo, Meta]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Master_WatchMetaClient = grpc.ServerStreamingClient[Meta]
+
 func (c *masterClient) GetRankingModel(ctx context.Context, in *VersionInfo, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Fragment], error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
-	stream, err := c.cc.NewStream(ctx, &Master_ServiceDesc.Streams[0], Master_GetRankingModel_FullMethodName, cOpts...)
+	stream, err := c.cc.NewStream(ctx, &Master_ServiceDesc.Streams[1], Master_GetRankingModel_FullMethodName, cOpts...)
 	if err != nil {
 		return nil, err
 	}
@@ -88,9 +117,28 @@ func (c *masterClient) GetRankingModel(ctx context.Context, in *VersionInfo, opt
 // This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
 type Master_GetRankingModelClient = grpc.ServerStreamingClient[Fragment]
 
+func (c *masterClient) GetRankingModelDelta(ctx context.Context, in *DeltaInfo, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Fragment], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Master_ServiceDesc.Streams[2], Master_GetRankingModelDelta_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[DeltaInfo, Fragment]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Master_GetRankingModelDeltaClient = grpc.ServerStreamingClient[Fragment]
+
 func (c *masterClient) GetClickModel(ctx context.Context, in *VersionInfo, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Fragment], error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
-	stream, err := c.cc.NewStream(ctx, &Master_ServiceDesc.Streams[1], Master_GetClickModel_FullMethodName, cOpts...)
+	stream, err := c.cc.NewStream(ctx, &Master_ServiceDesc.Streams[3], Master_GetClickModel_FullMethodName, cOpts...)
 	if err != nil {
 		return nil, err
 	}
@@ -107,6 +155,44 @@ func (c *masterClient) GetClickModel(ctx context.Context, in *VersionInfo, opts
 // This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
 type Master_GetClickModelClient = grpc.ServerStreamingClient[Fragment]
 
+func (c *masterClient) GetSessionModel(ctx context.Context, in *VersionInfo, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Fragment], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Master_ServiceDesc.Streams[4], Master_GetSessionModel_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[VersionInfo, Fragment]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Master_GetSessionModelClient = grpc.ServerStreamingClient[Fragment]
+
+func (c *masterClient) GetTwoTowerModel(ctx context.Context, in *VersionInfo, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Fragment], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Master_ServiceDesc.Streams[5], Master_GetTwoTowerModel_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[VersionInfo, Fragment]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Master_GetTwoTowerModelClient = grpc.ServerStreamingClient[Fragment]
+
 func (c *masterClient) PushProgress(ctx context.Context, in *PushProgressRequest, opts ...grpc.CallOption) (*PushProgressResponse, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(PushProgressResponse)
@@ -117,16 +203,31 @@ func (c *masterClient) PushProgress(ctx context.Context, in *PushProgressRequest
 	return out, nil
 }
 
+func (c *masterClient) PushShard(ctx context.Context, in *Shard, opts ...grpc.CallOption) (*PushShardResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(PushShardResponse)
+	err := c.cc.Invoke(ctx, Master_PushShard_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // MasterServer is the server API for Master service.
 // All implementations must embed UnimplementedMasterServer
 // for forward compatibility.
 type MasterServer interface {
 	// meta distribute
 	GetMeta(context.Context, *NodeInfo) (*Meta, error)
+	WatchMeta(*NodeInfo, grpc.ServerStreamingServer[Meta]) error
 	// data distribute
 	GetRankingModel(*VersionInfo, grpc.ServerStreamingServer[Fragment]) error
+	GetRankingModelDelta(*DeltaInfo, grpc.ServerStreamingServer[Fragment]) error
 	GetClickModel(*VersionInfo, grpc.ServerStreamingServer[Fragment]) error
+	GetSessionModel(*VersionInfo, grpc.ServerStreamingServer[Fragment]) error
+	GetTwoTowerModel(*VersionInfo, grpc.ServerStreamingServer[Fragment]) error
 	PushProgress(context.Context, *PushProgressRequest) (*PushProgressResponse, error)
+	PushShard(context.Context, *Shard) (*PushShardResponse, error)
 	mustEmbedUnimplementedMasterServer()
 }
 
@@ -140,15 +241,30 @@ type UnimplementedMasterServer struct{}
 func (UnimplementedMasterServer) GetMeta(context.Context, *NodeInfo) (*Meta, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetMeta not implemented")
 }
+func (UnimplementedMasterServer) WatchMeta(*NodeInfo, grpc.ServerStreamingServer[Meta]) error {
+	return status.Errorf(codes.Unimplemented, "method WatchMeta not implemented")
+}
 func (UnimplementedMasterServer) GetRankingModel(*VersionInfo, grpc.ServerStreamingServer[Fragment]) error {
 	return status.Errorf(codes.Unimplemented, "method GetRankingModel not implemented")
 }
+func (UnimplementedMasterServer) GetRankingModelDelta(*DeltaInfo, grpc.ServerStreamingServer[Fragment]) error {
+	return status.Errorf(codes.Unimplemented, "method GetRankingModelDelta not implemented")
+}
 func (UnimplementedMasterServer) GetClickModel(*VersionInfo, grpc.ServerStreamingServer[Fragment]) error {
 	return status.Errorf(codes.Unimplemented, "method GetClickModel not implemented")
 }
+func (UnimplementedMasterServer) GetSessionModel(*VersionInfo, grpc.ServerStreamingServer[Fragment]) error {
+	return status.Errorf(codes.Unimplemented, "method GetSessionModel not implemented")
+}
+func (UnimplementedMasterServer) GetTwoTowerModel(*VersionInfo, grpc.ServerStreamingServer[Fragment]) error {
+	return status.Errorf(codes.Unimplemented, "method GetTwoTowerModel not implemented")
+}
 func (UnimplementedMasterServer) PushProgress(context.Context, *PushProgressRequest) (*PushProgressResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method PushProgress not implemented")
 }
+func (UnimplementedMasterServer) PushShard(context.Context, *Shard) (*PushShardResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PushShard not implemented")
+}
 func (UnimplementedMasterServer) mustEmbedUnimplementedMasterServer() {}
 func (UnimplementedMasterServer) testEmbeddedByValue()                {}
 
@@ -188,6 +304,17 @@ func _Master_GetMeta_Handler(srv interface{}, ctx context.Context, dec func(inte
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Master_WatchMeta_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(NodeInfo)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(MasterServer).WatchMeta(m, &grpc.GenericServerStream[NodeInfo, Meta]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Master_WatchMetaServer = grpc.ServerStreamingServer[Meta]
+
 func _Master_GetRankingModel_Handler(srv interface{}, stream grpc.ServerStream) error {
 	m := new(VersionInfo)
 	if err := stream.RecvMsg(m); err != nil {
@@ -199,6 +326,17 @@ func _Master_GetRankingModel_Handler(srv interface{}, stream grpc.ServerStream)
 // This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
 type Master_GetRankingModelServer = grpc.ServerStreamingServer[Fragment]
 
+func _Master_GetRankingModelDelta_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(DeltaInfo)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(MasterServer).GetRankingModelDelta(m, &grpc.GenericServerStream[DeltaInfo, Fragment]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Master_GetRankingModelDeltaServer = grpc.ServerStreamingServer[Fragment]
+
 func _Master_GetClickModel_Handler(srv interface{}, stream grpc.ServerStream) error {
 	m := new(VersionInfo)
 	if err := stream.RecvMsg(m); err != nil {
@@ -210,6 +348,28 @@ func _Master_GetClickModel_Handler(srv interface{}, stream grpc.ServerStream) er
 // This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
 type Master_GetClickModelServer = grpc.ServerStreamingServer[Fragment]
 
+func _Master_GetSessionModel_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(VersionInfo)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(MasterServer).GetSessionModel(m, &grpc.GenericServerStream[VersionInfo, Fragment]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Master_GetSessionModelServer = grpc.ServerStreamingServer[Fragment]
+
+func _Master_GetTwoTowerModel_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(VersionInfo)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(MasterServer).GetTwoTowerModel(m, &grpc.GenericServerStream[VersionInfo, Fragment]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Master_GetTwoTowerModelServer = grpc.ServerStreamingServer[Fragment]
+
 func _Master_PushProgress_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(PushProgressRequest)
 	if err := dec(in); err != nil {
@@ -228,6 +388,24 @@ func _Master_PushProgress_Handler(srv interface{}, ctx context.Context, dec func
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Master_PushShard_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Shard)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MasterServer).PushShard(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Master_PushShard_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MasterServer).PushShard(ctx, req.(*Shard))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // Master_ServiceDesc is the grpc.ServiceDesc for Master service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -243,18 +421,42 @@ var Master_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "PushProgress",
 			Handler:    _Master_PushProgress_Handler,
 		},
+		{
+			MethodName: "PushShard",
+			Handler:    _Master_PushShard_Handler,
+		},
 	},
 	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchMeta",
+			Handler:       _Master_WatchMeta_Handler,
+			ServerStreams: true,
+		},
 		{
 			StreamName:    "GetRankingModel",
 			Handler:       _Master_GetRankingModel_Handler,
 			ServerStreams: true,
 		},
+		{
+			StreamName:    "GetRankingModelDelta",
+			Handler:       _Master_GetRankingModelDelta_Handler,
+			ServerStreams: true,
+		},
 		{
 			StreamName:    "GetClickModel",
 			Handler:       _Master_GetClickModel_Handler,
 			ServerStreams: true,
 		},
+		{
+			StreamName:    "GetSessionModel",
+			Handler:       _Master_GetSessionModel_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "GetTwoTowerModel",
+			Handler:       _Master_GetTwoTowerModel_Handler,
+			ServerStreams: true,
+		},
 	},
 	Metadata: "protocol.proto",
 }
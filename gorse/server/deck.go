@@ -0,0 +1,311 @@
+// Copyright 2024 gorse Project Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/emicklei/go-restful/v3"
+	"github.com/google/uuid"
+	"github.com/juju/errors"
+	"github.com/samber/lo"
+	"github.com/thoas/go-funk"
+	"github.com/zhenghaoz/gorse/base/heap"
+	"github.com/zhenghaoz/gorse/base/log"
+	"github.com/zhenghaoz/gorse/storage/cache"
+	"github.com/zhenghaoz/gorse/storage/data"
+	"go.uber.org/zap"
+)
+
+// Deck is a swipe deck of a user. Cards served by the deck are never served again, so clients page through the deck
+// by the cursor instead of offsets. Positive feedback sent to the deck promotes similar items in the rest of the deck.
+type Deck struct {
+	Cursor     string
+	Version    int
+	UserId     string
+	Categories []string
+	Served     []string
+	Liked      []string
+	ExpireTime time.Time
+	// value is the serialized deck in the cache store, which is compared before the deck is saved again.
+	value string
+}
+
+// maxDeckRetries is the max number of retries to update a deck modified concurrently.
+const maxDeckRetries = 3
+
+// errDeckConflict is returned if a deck has been modified since it was loaded.
+var errDeckConflict = errors.New("deck was modified concurrently")
+
+// Expired checks whether the deck is expired.
+func (deck *Deck) Expired() bool {
+	return time.Now().After(deck.ExpireTime)
+}
+
+func (s *RestServer) loadDeck(ctx context.Context, cursor string) (*Deck, error) {
+	value, err := s.CacheClient.Get(ctx, cache.Key(cache.Decks, cursor)).String()
+	if err != nil {
+		if errors.Is(err, errors.NotFound) {
+			return nil, errors.NotFoundf("deck %s", cursor)
+		}
+		return nil, errors.Trace(err)
+	}
+	deck := Deck{value: value}
+	if err = json.Unmarshal([]byte(value), &deck); err != nil {
+		return nil, errors.Trace(err)
+	}
+	if deck.Expired() {
+		if err = s.CacheClient.Delete(ctx, cache.Key(cache.Decks, cursor)); err != nil {
+			return nil, errors.Trace(err)
+		}
+		return nil, errors.NotFoundf("deck %s", cursor)
+	}
+	return &deck, nil
+}
+
+// marshalDeck bumps the version of the deck, extends its expire time and serializes it.
+func (s *RestServer) marshalDeck(deck *Deck) (string, error) {
+	deck.Version++
+	deck.ExpireTime = time.Now().Add(s.Config.Server.DeckTTL)
+	bytes, err := json.Marshal(deck)
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	return string(bytes), nil
+}
+
+// saveDeck saves a new deck.
+func (s *RestServer) saveDeck(ctx context.Context, deck *Deck) error {
+	value, err := s.marshalDeck(deck)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if err = s.CacheClient.Set(ctx, cache.String(cache.Key(cache.Decks, deck.Cursor), value)); err != nil {
+		return errors.Trace(err)
+	}
+	deck.value = value
+	return nil
+}
+
+// compareAndSaveDeck saves the deck if it hasn't been saved since it was loaded. The comparison is an atomic
+// compare-and-set in the cache store, so that concurrent updates of a deck are safe across server nodes. The version of
+// the deck is bumped on success, otherwise errDeckConflict is returned.
+func (s *RestServer) compareAndSaveDeck(ctx context.Context, deck *Deck) error {
+	saved := *deck
+	value, err := s.marshalDeck(&saved)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	swapped, err := s.CacheClient.CompareAndSet(ctx, cache.Key(cache.Decks, deck.Cursor), deck.value, value)
+	if err != nil {
+		return errors.Trace(err)
+	} else if !swapped {
+		return errors.Trace(errDeckConflict)
+	}
+	saved.value = value
+	*deck = saved
+	return nil
+}
+
+// updateDeck loads the deck, applies the update and saves the deck. The update is applied to the latest deck again if
+// the deck has been modified concurrently.
+func (s *RestServer) updateDeck(ctx context.Context, cursor string, update func(deck *Deck) error) (*Deck, error) {
+	for i := 0; ; i++ {
+		deck, err := s.loadDeck(ctx, cursor)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		if err = update(deck); err != nil {
+			return nil, errors.Trace(err)
+		}
+		if err = s.compareAndSaveDeck(ctx, deck); err == nil {
+			return deck, nil
+		} else if !errors.Is(err, errDeckConflict) || i >= maxDeckRetries {
+			return nil, errors.Trace(err)
+		}
+	}
+}
+
+// deckError writes the error of a deck update to the response.
+func deckError(response *restful.Response, err error) {
+	if errors.Is(err, errors.NotFound) {
+		PageNotFound(response, err)
+	} else if errors.Is(err, errDeckConflict) {
+		Error(response, http.StatusConflict, err)
+	} else {
+		InternalServerError(response, err)
+	}
+}
+
+func (s *RestServer) createDeck(request *restful.Request, response *restful.Response) {
+	ctx := context.Background()
+	if request != nil && request.Request != nil {
+		ctx = request.Request.Context()
+	}
+	deck := &Deck{
+		Cursor:     uuid.New().String(),
+		UserId:     request.PathParameter("user-id"),
+		Categories: ReadCategories(request),
+	}
+	if err := s.saveDeck(ctx, deck); err != nil {
+		InternalServerError(response, err)
+		return
+	}
+	if err := s.CacheClient.AddSet(ctx, cache.Key(cache.UserDecks, deck.UserId), deck.Cursor); err != nil {
+		InternalServerError(response, err)
+		return
+	}
+	Ok(response, deck)
+}
+
+func (s *RestServer) getDeck(request *restful.Request, response *restful.Response) {
+	ctx := context.Background()
+	if request != nil && request.Request != nil {
+		ctx = request.Request.Context()
+	}
+	n, err := ParseInt(request, "n", s.Config.Server.DefaultN)
+	if err != nil {
+		BadRequest(response, err)
+		return
+	}
+	// cards are recommended again if the deck is modified concurrently, so that no card is served twice
+	var recommendCtx *recommendContext
+	deck, err := s.updateDeck(ctx, request.PathParameter("cursor"), func(deck *Deck) error {
+		// served cards are excluded and liked cards lead the rest of the deck
+		fallbacks, err := s.recommenders(s.Config.ForUser(deck.UserId))
+		if err != nil {
+			return errors.Trace(err)
+		}
+		recommenders := append([]Recommender{
+			func(ctx *recommendContext) error {
+				ctx.excludeSet.Append(deck.Served...)
+				return nil
+			},
+			s.recommendDeck(deck),
+		}, fallbacks...)
+		recommendCtx, err = s.recommend(ctx, response, deck.UserId, deck.Categories, nil, n, recommenders...)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		deck.Served = append(deck.Served, recommendCtx.results...)
+		return nil
+	})
+	if err != nil {
+		deckError(response, err)
+		return
+	}
+	// log impressions
+	if s.Config.Server.LogImpressions {
+		position := len(deck.Served) - len(recommendCtx.results)
+		impressions := make([]data.Impression, len(recommendCtx.results))
+		for i := range recommendCtx.results {
+			impressions[i] = data.Impression{
+				UserId:     deck.UserId,
+				ItemId:     recommendCtx.results[i],
+				Position:   position + i,
+				Source:     recommendCtx.sources[i],
+				Score:      recommendCtx.scores[i],
+				Propensity: recommendCtx.propensity(i),
+			}
+		}
+		s.logImpressions(ctx, response, impressions)
+	}
+	Ok(response, recommendCtx.results)
+}
+
+// recommendDeck recommends items similar to items liked in the deck. Recently liked items are used first.
+func (s *RestServer) recommendDeck(deck *Deck) Recommender {
+	return func(ctx *recommendContext) error {
+		if len(ctx.results) >= ctx.n || len(deck.Liked) == 0 {
+			return nil
+		}
+		candidates := make(map[string]float64)
+		for _, itemId := range lo.Slice(lo.Reverse(append([]string{}, deck.Liked...)), 0, ctx.config.Recommend.Online.NumFeedbackFallbackItemBased) {
+			similarItems, err := s.CacheClient.SearchScores(ctx.context, cache.ItemNeighbors, itemId, ctx.categories, 0, ctx.config.Recommend.CacheSize)
+			if err != nil {
+				return errors.Trace(err)
+			}
+			for _, item := range similarItems {
+				if !ctx.excludeSet.Contains(item.Id) {
+					candidates[item.Id] += item.Score
+				}
+			}
+		}
+		filter := heap.NewTopKFilter[string, float64](ctx.n - len(ctx.results))
+		for id, score := range candidates {
+			filter.Push(id, score)
+		}
+		ids, scores := filter.PopAll()
+		for i := range ids {
+			ctx.push(ImpressionSourceDeck, ids[i], scores[i])
+		}
+		ctx.numPrevStage = len(ctx.results)
+		return nil
+	}
+}
+
+func (s *RestServer) insertDeckFeedback(request *restful.Request, response *restful.Response) {
+	ctx := context.Background()
+	if request != nil && request.Request != nil {
+		ctx = request.Request.Context()
+	}
+	deck, err := s.loadDeck(ctx, request.PathParameter("cursor"))
+	if err != nil {
+		if errors.Is(err, errors.NotFound) {
+			PageNotFound(response, err)
+		} else {
+			InternalServerError(response, err)
+		}
+		return
+	}
+	var feedbackLiterTime []Feedback
+	if err = request.ReadEntity(&feedbackLiterTime); err != nil {
+		BadRequest(response, err)
+		return
+	}
+	// feedback belongs to the owner of the deck
+	var liked []string
+	feedback := make([]data.Feedback, len(feedbackLiterTime))
+	for i := range feedback {
+		feedbackLiterTime[i].UserId = deck.UserId
+		feedback[i], err = feedbackLiterTime[i].ToDataFeedback()
+		if err != nil {
+			BadRequest(response, err)
+			return
+		}
+		if feedback[i].Timestamp.IsZero() {
+			feedback[i].Timestamp = time.Now()
+		}
+		if funk.ContainsString(s.Config.Recommend.DataSource.PositiveFeedbackTypes, feedback[i].FeedbackType) {
+			liked = append(liked, feedback[i].ItemId)
+		}
+	}
+	if err = s.saveFeedback(ctx, feedback, true); err != nil {
+		InternalServerError(response, err)
+		return
+	}
+	if _, err = s.updateDeck(ctx, deck.Cursor, func(deck *Deck) error {
+		deck.Liked = append(deck.Liked, liked...)
+		return nil
+	}); err != nil {
+		deckError(response, err)
+		return
+	}
+	log.ResponseLogger(response).Info("Insert deck feedback successfully", zap.Int("num_feedback", len(feedback)))
+	Ok(response, Success{RowAffected: len(feedback)})
+}
@@ -0,0 +1,160 @@
+// Copyright 2024 gorse Project Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/samber/lo"
+	"github.com/steinfletcher/apitest"
+	"github.com/stretchr/testify/assert"
+	"github.com/zhenghaoz/gorse/storage/cache"
+	"github.com/zhenghaoz/gorse/storage/data"
+)
+
+func (suite *ServerTestSuite) TestDeck() {
+	ctx := context.Background()
+	t := suite.T()
+	suite.Config.Recommend.DataSource.PositiveFeedbackTypes = []string{"like"}
+	suite.Config.Recommend.Online.FallbackRecommend = []string{"latest"}
+	// insert offline recommendation
+	err := suite.CacheClient.AddScores(ctx, cache.OfflineRecommend, "0", []cache.Score{
+		{Id: "1", Score: 99, Categories: []string{""}},
+		{Id: "2", Score: 98, Categories: []string{""}},
+		{Id: "3", Score: 97, Categories: []string{""}}})
+	assert.NoError(t, err)
+	// insert latest
+	err = suite.CacheClient.AddScores(ctx, cache.NonPersonalized, cache.Latest, []cache.Score{
+		{Id: "3", Score: 95, Categories: []string{""}},
+		{Id: "4", Score: 94, Categories: []string{""}},
+		{Id: "5", Score: 93, Categories: []string{""}}})
+	assert.NoError(t, err)
+	// insert similar items
+	err = suite.CacheClient.AddScores(ctx, cache.ItemNeighbors, "1", []cache.Score{
+		{Id: "5", Score: 1, Categories: []string{""}}})
+	assert.NoError(t, err)
+
+	// create deck
+	var deck Deck
+	apitest.New().
+		Handler(suite.handler).
+		Post("/api/deck/0").
+		Header("X-API-Key", apiKey).
+		Expect(t).
+		Status(http.StatusOK).
+		End().
+		JSON(&deck)
+	assert.NotEmpty(t, deck.Cursor)
+	assert.Equal(t, "0", deck.UserId)
+	// get first cards
+	apitest.New().
+		Handler(suite.handler).
+		Get("/api/deck/"+deck.Cursor).
+		Header("X-API-Key", apiKey).
+		QueryParams(map[string]string{
+			"n": "1",
+		}).
+		Expect(t).
+		Status(http.StatusOK).
+		Body(suite.marshal([]string{"1"})).
+		End()
+	// feedback promotes similar items
+	apitest.New().
+		Handler(suite.handler).
+		Post("/api/deck/"+deck.Cursor+"/feedback").
+		Header("X-API-Key", apiKey).
+		JSON([]Feedback{{FeedbackKey: data.FeedbackKey{FeedbackType: "like", ItemId: "1"}}}).
+		Expect(t).
+		Status(http.StatusOK).
+		Body(`{"RowAffected": 1}`).
+		End()
+	feedback, err := suite.DataClient.GetUserFeedback(ctx, "0", lo.ToPtr(time.Now()))
+	assert.NoError(t, err)
+	assert.Len(t, feedback, 1)
+	apitest.New().
+		Handler(suite.handler).
+		Get("/api/deck/"+deck.Cursor).
+		Header("X-API-Key", apiKey).
+		QueryParams(map[string]string{
+			"n": "2",
+		}).
+		Expect(t).
+		Status(http.StatusOK).
+		Body(suite.marshal([]string{"5", "2"})).
+		End()
+	// served cards are never served again
+	apitest.New().
+		Handler(suite.handler).
+		Get("/api/deck/"+deck.Cursor).
+		Header("X-API-Key", apiKey).
+		QueryParams(map[string]string{
+			"n": "3",
+		}).
+		Expect(t).
+		Status(http.StatusOK).
+		Body(suite.marshal([]string{"3", "4"})).
+		End()
+
+	// stale writes are rejected
+	fresh, err := suite.loadDeck(ctx, deck.Cursor)
+	assert.NoError(t, err)
+	stale, err := suite.loadDeck(ctx, deck.Cursor)
+	assert.NoError(t, err)
+	assert.NoError(t, suite.compareAndSaveDeck(ctx, fresh))
+	assert.ErrorIs(t, suite.compareAndSaveDeck(ctx, stale), errDeckConflict)
+	assert.NoError(t, suite.compareAndSaveDeck(ctx, fresh))
+	// concurrent feedback is never lost
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			apitest.New().
+				Handler(suite.handler).
+				Post("/api/deck/"+deck.Cursor+"/feedback").
+				Header("X-API-Key", apiKey).
+				JSON([]Feedback{{FeedbackKey: data.FeedbackKey{FeedbackType: "like", ItemId: strconv.Itoa(10 + i)}}}).
+				Expect(t).
+				Status(http.StatusOK).
+				End()
+		}(i)
+	}
+	wg.Wait()
+	updated, err := suite.loadDeck(ctx, deck.Cursor)
+	assert.NoError(t, err)
+	assert.Len(t, updated.Liked, 11)
+
+	// expired deck
+	suite.Config.Server.DeckTTL = -time.Second
+	apitest.New().
+		Handler(suite.handler).
+		Post("/api/deck/"+deck.Cursor+"/feedback").
+		Header("X-API-Key", apiKey).
+		JSON([]Feedback{}).
+		Expect(t).
+		Status(http.StatusOK).
+		End()
+	apitest.New().
+		Handler(suite.handler).
+		Get("/api/deck/"+deck.Cursor).
+		Header("X-API-Key", apiKey).
+		Expect(t).
+		Status(http.StatusNotFound).
+		End()
+}
@@ -0,0 +1,158 @@
+// Copyright 2024 gorse Project Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"time"
+
+	"github.com/emicklei/go-restful/v3"
+	"github.com/juju/errors"
+	"github.com/samber/lo"
+	"github.com/zhenghaoz/gorse/base/heap"
+	"github.com/zhenghaoz/gorse/base/log"
+	"github.com/zhenghaoz/gorse/logics"
+	"github.com/zhenghaoz/gorse/storage/cache"
+	"github.com/zhenghaoz/gorse/storage/data"
+	"go.uber.org/zap"
+)
+
+func (s *RestServer) insertOnboarding(request *restful.Request, response *restful.Response) {
+	ctx := context.Background()
+	if request != nil && request.Request != nil {
+		ctx = request.Request.Context()
+	}
+	userId := request.PathParameter("user-id")
+	var onboarding logics.Onboarding
+	if err := request.ReadEntity(&onboarding); err != nil {
+		BadRequest(response, err)
+		return
+	}
+	if len(onboarding.Items) == 0 && len(onboarding.Categories) == 0 && len(onboarding.Labels) == 0 {
+		BadRequest(response, errors.New("seed items, categories or labels are required"))
+		return
+	}
+	onboarding.Items = lo.Uniq(onboarding.Items)
+	onboarding.Timestamp = time.Now()
+	// insert the user if not exists
+	if _, err := s.DataClient.GetUser(ctx, userId); errors.Is(err, errors.NotFound) {
+		if err = s.DataClient.BatchInsertUsers(ctx, []data.User{{UserId: userId}}); err != nil {
+			InternalServerError(response, err)
+			return
+		}
+	} else if err != nil {
+		InternalServerError(response, err)
+		return
+	}
+	// insert seed items as feedback
+	userConfig := s.Config.ForUser(userId)
+	feedback := make([]data.Feedback, len(onboarding.Items))
+	for i, itemId := range onboarding.Items {
+		feedback[i] = data.Feedback{
+			FeedbackKey: data.FeedbackKey{
+				FeedbackType: userConfig.Recommend.Onboarding.FeedbackType,
+				UserId:       userId,
+				ItemId:       itemId,
+			},
+			Timestamp: onboarding.Timestamp,
+		}
+	}
+	if len(feedback) > 0 {
+		if err := s.saveFeedback(ctx, feedback, true); err != nil {
+			InternalServerError(response, err)
+			return
+		}
+	}
+	// build the taste vector from seed items
+	onboarding.TasteVector = nil
+	if len(onboarding.Items) > 0 {
+		items, err := s.DataClient.BatchGetItems(ctx, onboarding.Items)
+		if err != nil {
+			InternalServerError(response, err)
+			return
+		}
+		onboarding.TasteVector = logics.NewTasteVector(items, userConfig.Recommend.ImageEmbeddings)
+	}
+	if err := logics.SaveOnboarding(ctx, s.CacheClient, userId, &onboarding); err != nil {
+		InternalServerError(response, err)
+		return
+	}
+	log.ResponseLogger(response).Info("Insert onboarding successfully", zap.String("user_id", userId),
+		zap.Int("num_items", len(onboarding.Items)))
+	Ok(response, Success{RowAffected: len(feedback)})
+}
+
+// RecommendOnboarding recommends items similar to seed items of the onboarding quiz. Latest and popular items matching
+// preferences are recommended if no seed item is picked. The fraction of recommendation fades out as the user inserts
+// organic feedback.
+func (s *RestServer) RecommendOnboarding(ctx *recommendContext) error {
+	if len(ctx.results) >= ctx.n {
+		return nil
+	}
+	onboarding, err := logics.LoadOnboarding(ctx.context, s.CacheClient, ctx.userId)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if onboarding == nil {
+		return nil
+	}
+	weight := onboarding.Weight(ctx.config.Recommend.Onboarding, ctx.config.Recommend.DataSource.PositiveFeedbackTypes, ctx.userFeedback)
+	size := lo.Min([]int{onboarding.Size(weight, ctx.n), ctx.n - len(ctx.results)})
+	if size <= 0 {
+		return nil
+	}
+	candidates := make(map[string]float64)
+	if len(onboarding.Items) == 0 {
+		candidates, err = logics.FallbackCandidates(ctx.context, s.CacheClient, ctx.categories, ctx.config.Recommend.CacheSize, ctx.excludeSet)
+		if err != nil {
+			return errors.Trace(err)
+		}
+	}
+	for _, itemId := range onboarding.Items {
+		similarItems, err := s.CacheClient.SearchScores(ctx.context, cache.ItemNeighbors, itemId, ctx.categories, 0, ctx.config.Recommend.CacheSize)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		for _, item := range similarItems {
+			if !ctx.excludeSet.Contains(item.Id) {
+				candidates[item.Id] += item.Score
+			}
+		}
+	}
+	// filter candidates by preferred categories and labels
+	if len(candidates) > 0 && (len(onboarding.Categories) > 0 || len(onboarding.Labels) > 0) {
+		items, err := s.DataClient.BatchGetItems(ctx.context, lo.Keys(candidates))
+		if err != nil {
+			return errors.Trace(err)
+		}
+		matched := make(map[string]float64, len(items))
+		for i := range items {
+			if onboarding.Match(&items[i]) {
+				matched[items[i].ItemId] = candidates[items[i].ItemId]
+			}
+		}
+		candidates = matched
+	}
+	filter := heap.NewTopKFilter[string, float64](size)
+	for id, score := range candidates {
+		filter.Push(id, score)
+	}
+	ids, scores := filter.PopAll()
+	for i := range ids {
+		ctx.push(ImpressionSourceOnboarding, ids[i], scores[i])
+	}
+	ctx.numPrevStage = len(ctx.results)
+	return nil
+}
@@ -0,0 +1,167 @@
+// Copyright 2024 gorse Project Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/samber/lo"
+	"github.com/steinfletcher/apitest"
+	"github.com/stretchr/testify/assert"
+	"github.com/zhenghaoz/gorse/logics"
+	"github.com/zhenghaoz/gorse/storage/cache"
+	"github.com/zhenghaoz/gorse/storage/data"
+)
+
+func (suite *ServerTestSuite) TestOnboarding() {
+	ctx := context.Background()
+	t := suite.T()
+	suite.Config.Recommend.DataSource.PositiveFeedbackTypes = []string{"like"}
+	suite.Config.Recommend.Online.FallbackRecommend = []string{"latest"}
+	suite.Config.Recommend.Onboarding.Weight = 0.5
+	suite.Config.Recommend.Onboarding.NumFeedback = 2
+	suite.Config.Recommend.ImageEmbeddings.EmbeddingLabel = "embedding"
+	suite.Config.Recommend.ImageEmbeddings.EmbeddingDim = 2
+	// insert items
+	err := suite.DataClient.BatchInsertItems(ctx, []data.Item{
+		{ItemId: "1", Labels: map[string]any{"embedding": []any{1.0, 2.0}}},
+		{ItemId: "2", Categories: []string{"a"}},
+		{ItemId: "3", Categories: []string{"b"}},
+		{ItemId: "4", Categories: []string{"a"}},
+	})
+	assert.NoError(t, err)
+	// insert similar items
+	err = suite.CacheClient.AddScores(ctx, cache.ItemNeighbors, "1", []cache.Score{
+		{Id: "2", Score: 3, Categories: []string{""}},
+		{Id: "3", Score: 2, Categories: []string{""}},
+		{Id: "4", Score: 1, Categories: []string{""}}})
+	assert.NoError(t, err)
+	// insert latest
+	err = suite.CacheClient.AddScores(ctx, cache.NonPersonalized, cache.Latest, []cache.Score{
+		{Id: "5", Score: 95, Categories: []string{""}},
+		{Id: "6", Score: 94, Categories: []string{""}},
+		{Id: "7", Score: 93, Categories: []string{""}}})
+	assert.NoError(t, err)
+
+	// empty onboarding
+	apitest.New().
+		Handler(suite.handler).
+		Post("/api/user/0/onboarding").
+		Header("X-API-Key", apiKey).
+		JSON(logics.Onboarding{}).
+		Expect(t).
+		Status(http.StatusBadRequest).
+		End()
+	// insert onboarding
+	apitest.New().
+		Handler(suite.handler).
+		Post("/api/user/0/onboarding").
+		Header("X-API-Key", apiKey).
+		JSON(logics.Onboarding{Items: []string{"1"}, Categories: []string{"a"}}).
+		Expect(t).
+		Status(http.StatusOK).
+		Body(`{"RowAffected": 1}`).
+		End()
+	_, err = suite.DataClient.GetUser(ctx, "0")
+	assert.NoError(t, err)
+	feedback, err := suite.DataClient.GetUserFeedback(ctx, "0", lo.ToPtr(time.Now()), "onboarding")
+	assert.NoError(t, err)
+	assert.Len(t, feedback, 1)
+	// the taste vector is built from seed items
+	onboarding, err := logics.LoadOnboarding(ctx, suite.CacheClient, "0")
+	assert.NoError(t, err)
+	assert.Equal(t, []float32{1, 2}, onboarding.TasteVector)
+	// similar items of seed items in preferred categories
+	apitest.New().
+		Handler(suite.handler).
+		Get("/api/recommend/0").
+		Header("X-API-Key", apiKey).
+		QueryParams(map[string]string{
+			"n": "4",
+		}).
+		Expect(t).
+		Status(http.StatusOK).
+		Body(suite.marshal([]string{"2", "4", "5", "6"})).
+		End()
+	// fade out by organic feedback
+	apitest.New().
+		Handler(suite.handler).
+		Post("/api/feedback").
+		Header("X-API-Key", apiKey).
+		JSON([]data.Feedback{{FeedbackKey: data.FeedbackKey{FeedbackType: "like", UserId: "0", ItemId: "8"}, Timestamp: time.Now()}}).
+		Expect(t).
+		Status(http.StatusOK).
+		End()
+	apitest.New().
+		Handler(suite.handler).
+		Get("/api/recommend/0").
+		Header("X-API-Key", apiKey).
+		QueryParams(map[string]string{
+			"n": "4",
+		}).
+		Expect(t).
+		Status(http.StatusOK).
+		Body(suite.marshal([]string{"2", "5", "6", "7"})).
+		End()
+}
+
+func (suite *ServerTestSuite) TestOnboarding_Categories() {
+	ctx := context.Background()
+	t := suite.T()
+	suite.Config.Recommend.Online.FallbackRecommend = []string{"latest"}
+	suite.Config.Recommend.Onboarding.Weight = 0.5
+	// insert items
+	err := suite.DataClient.BatchInsertItems(ctx, []data.Item{
+		{ItemId: "2", Categories: []string{"a"}},
+		{ItemId: "3", Categories: []string{"b"}},
+		{ItemId: "4", Categories: []string{"a"}},
+		{ItemId: "5"},
+	})
+	assert.NoError(t, err)
+	// insert latest and popular
+	err = suite.CacheClient.AddScores(ctx, cache.NonPersonalized, cache.Latest, []cache.Score{
+		{Id: "3", Score: 95, Categories: []string{""}},
+		{Id: "2", Score: 94, Categories: []string{""}}})
+	assert.NoError(t, err)
+	err = suite.CacheClient.AddScores(ctx, cache.NonPersonalized, cache.Popular, []cache.Score{
+		{Id: "4", Score: 10, Categories: []string{""}},
+		{Id: "5", Score: 9, Categories: []string{""}}})
+	assert.NoError(t, err)
+
+	// insert onboarding without seed items
+	apitest.New().
+		Handler(suite.handler).
+		Post("/api/user/0/onboarding").
+		Header("X-API-Key", apiKey).
+		JSON(logics.Onboarding{Categories: []string{"a"}}).
+		Expect(t).
+		Status(http.StatusOK).
+		Body(`{"RowAffected": 0}`).
+		End()
+	// latest and popular items in preferred categories
+	apitest.New().
+		Handler(suite.handler).
+		Get("/api/recommend/0").
+		Header("X-API-Key", apiKey).
+		QueryParams(map[string]string{
+			"n": "4",
+		}).
+		Expect(t).
+		Status(http.StatusOK).
+		Body(suite.marshal([]string{"4", "2", "3"})).
+		End()
+}
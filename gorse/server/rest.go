@@ -36,6 +36,8 @@ import (
 	"github.com/zhenghaoz/gorse/base/heap"
 	"github.com/zhenghaoz/gorse/base/log"
 	"github.com/zhenghaoz/gorse/config"
+	"github.com/zhenghaoz/gorse/logics"
+	"github.com/zhenghaoz/gorse/model/click"
 	"github.com/zhenghaoz/gorse/storage/cache"
 	"github.com/zhenghaoz/gorse/storage/data"
 	"go.opentelemetry.io/contrib/instrumentation/github.com/emicklei/go-restful/otelrestful"
@@ -64,6 +66,11 @@ type RestServer struct {
 	DisableLog bool
 	WebService *restful.WebService
 	HttpServer *http.Server
+
+	// Tenants dispatches requests to tenants if set.
+	Tenants *TenantRouter
+	// TenantAPIKey is the API key of the tenant served by this server.
+	TenantAPIKey string
 }
 
 // StartHttpServer starts the REST-ful API server.
@@ -96,7 +103,7 @@ func (s *RestServer) StartHttpServer(container *restful.Container) {
 
 	// Add container filter to enable CORS
 	cors := restful.CrossOriginResourceSharing{
-		AllowedHeaders: []string{"Content-Type", "Accept", "X-API-Key"},
+		AllowedHeaders: []string{"Content-Type", "Accept", "X-API-Key", HeaderTenant},
 		AllowedDomains: s.Config.Master.HttpCorsDomains,
 		AllowedMethods: s.Config.Master.HttpCorsMethods,
 		CookiesAllowed: false,
@@ -108,9 +115,14 @@ func (s *RestServer) StartHttpServer(container *restful.Container) {
 		zap.Strings("cors_methods", s.Config.Master.HttpCorsMethods),
 		zap.Strings("cors_domains", s.Config.Master.HttpCorsDomains),
 	)
+	var handler http.Handler = container
+	if s.Tenants != nil {
+		s.Tenants.Default = container
+		handler = s.Tenants
+	}
 	s.HttpServer = &http.Server{
 		Addr:    fmt.Sprintf("%s:%d", s.HttpHost, s.HttpPort),
-		Handler: container,
+		Handler: handler,
 	}
 	if err := s.HttpServer.ListenAndServe(); err != http.ErrServerClosed {
 		log.Logger().Fatal("failed to start http server", zap.Error(err))
@@ -139,12 +151,13 @@ func (s *RestServer) AuthFilter(req *restful.Request, resp *restful.Response, ch
 		chain.ProcessFilter(req, resp)
 		return
 	}
-	if s.Config.Server.APIKey == "" {
+	if s.Config.Server.APIKey == "" && s.TenantAPIKey == "" {
 		chain.ProcessFilter(req, resp)
 		return
 	}
 	apikey := req.HeaderParameter("X-API-Key")
-	if apikey == s.Config.Server.APIKey {
+	if (s.Config.Server.APIKey != "" && apikey == s.Config.Server.APIKey) ||
+		(s.TenantAPIKey != "" && apikey == s.TenantAPIKey) {
 		chain.ProcessFilter(req, resp)
 		return
 	}
@@ -208,6 +221,15 @@ func (s *RestServer) CreateWebService() {
 		Reads(data.UserPatch{}).
 		Returns(http.StatusOK, "OK", Success{}).
 		Writes(Success{}))
+	// Insert onboarding of a user
+	ws.Route(ws.POST("/user/{user-id}/onboarding").To(s.insertOnboarding).
+		Doc("Insert seed items, categories and labels picked by a user in an onboarding quiz.").
+		Metadata(restfulspec.KeyOpenAPITags, []string{UsersAPITag}).
+		Param(ws.HeaderParameter("X-API-Key", "API key").DataType("string")).
+		Param(ws.PathParameter("user-id", "ID of the user").DataType("string")).
+		Reads(logics.Onboarding{}).
+		Returns(http.StatusOK, "OK", Success{}).
+		Writes(Success{}))
 	// Get a user
 	ws.Route(ws.GET("/user/{user-id}").To(s.getUser).
 		Doc("Get a user.").
@@ -235,10 +257,11 @@ func (s *RestServer) CreateWebService() {
 		Writes(UserIterator{}))
 	// Delete a user
 	ws.Route(ws.DELETE("/user/{user-id}").To(s.deleteUser).
-		Doc("Delete a user and his or her feedback.").
+		Doc("Delete a user and his or her feedback. If erase is true, the user is erased from every store and the completion report is returned.").
 		Metadata(restfulspec.KeyOpenAPITags, []string{UsersAPITag}).
 		Param(ws.HeaderParameter("X-API-Key", "API key").DataType("string")).
 		Param(ws.PathParameter("user-id", "ID of the user to delete").DataType("string")).
+		Param(ws.QueryParameter("erase", "Erase the user from every store").DataType("boolean")).
 		Returns(http.StatusOK, "OK", Success{}).
 		Writes(Success{}))
 
@@ -266,6 +289,8 @@ func (s *RestServer) CreateWebService() {
 		Param(ws.HeaderParameter("X-API-Key", "API key").DataType("string")).
 		Param(ws.QueryParameter("n", "Number of returned items").DataType("integer")).
 		Param(ws.QueryParameter("cursor", "Cursor for the next page").DataType("string")).
+		Param(ws.QueryParameter("query", "Query on labels, categories, hidden state and timestamps in JSON, e.g. "+
+			`{"labels":[{"path":"brand","op":"eq","value":"Levi's"}],"categories":["jeans"],"is_hidden":false}`).DataType("string")).
 		Returns(http.StatusOK, "OK", ItemIterator{}).
 		Writes(ItemIterator{}))
 	// Get item
@@ -521,6 +546,7 @@ func (s *RestServer) CreateWebService() {
 		Param(ws.QueryParameter("category", "Category of the returned items (support multi-categories filtering)").DataType("string")).
 		Param(ws.QueryParameter("write-back-type", "Type of write back feedback").DataType("string")).
 		Param(ws.QueryParameter("write-back-delay", "Timestamp delay of write back feedback (format 0h0m0s)").DataType("string")).
+		Param(ws.QueryParameter("context", "Context of the request in JSON such as {\"device\":\"mobile\"}").DataType("string")).
 		Param(ws.QueryParameter("n", "Number of returned items").DataType("integer")).
 		Param(ws.QueryParameter("offset", "Offset of returned items").DataType("integer")).
 		Returns(http.StatusOK, "OK", []string{}).
@@ -533,6 +559,7 @@ func (s *RestServer) CreateWebService() {
 		Param(ws.PathParameter("category", "Category of the returned items").DataType("string")).
 		Param(ws.QueryParameter("write-back-type", "Type of write back feedback").DataType("string")).
 		Param(ws.QueryParameter("write-back-delay", "Timestamp delay of write back feedback (format 0h0m0s)").DataType("string")).
+		Param(ws.QueryParameter("context", "Context of the request in JSON such as {\"device\":\"mobile\"}").DataType("string")).
 		Param(ws.QueryParameter("n", "Number of returned items").DataType("integer")).
 		Param(ws.QueryParameter("offset", "Offset of returned items").DataType("integer")).
 		Returns(http.StatusOK, "OK", []string{}).
@@ -556,6 +583,30 @@ func (s *RestServer) CreateWebService() {
 		Reads([]Feedback{}).
 		Returns(http.StatusOK, "OK", []cache.Score{}).
 		Writes([]cache.Score{}))
+	ws.Route(ws.POST("/deck/{user-id}").To(s.createDeck).
+		Doc("Create a swipe deck for user.").
+		Metadata(restfulspec.KeyOpenAPITags, []string{RecommendationAPITag}).
+		Param(ws.HeaderParameter("X-API-Key", "API key").DataType("string")).
+		Param(ws.PathParameter("user-id", "ID of the user").DataType("string")).
+		Param(ws.QueryParameter("category", "Category of the returned items (support multi-categories filtering)").DataType("string")).
+		Returns(http.StatusOK, "OK", Deck{}).
+		Writes(Deck{}))
+	ws.Route(ws.GET("/deck/{cursor}").To(s.getDeck).
+		Doc("Get next cards in a swipe deck.").
+		Metadata(restfulspec.KeyOpenAPITags, []string{RecommendationAPITag}).
+		Param(ws.HeaderParameter("X-API-Key", "API key").DataType("string")).
+		Param(ws.PathParameter("cursor", "Cursor of the deck").DataType("string")).
+		Param(ws.QueryParameter("n", "Number of returned items").DataType("integer")).
+		Returns(http.StatusOK, "OK", []string{}).
+		Writes([]string{}))
+	ws.Route(ws.POST("/deck/{cursor}/feedback").To(s.insertDeckFeedback).
+		Doc("Insert feedback to cards in a swipe deck.").
+		Metadata(restfulspec.KeyOpenAPITags, []string{RecommendationAPITag, FeedbackAPITag}).
+		Param(ws.HeaderParameter("X-API-Key", "API key").DataType("string")).
+		Param(ws.PathParameter("cursor", "Cursor of the deck").DataType("string")).
+		Reads([]Feedback{}).
+		Returns(http.StatusOK, "OK", Success{}).
+		Writes(Success{}))
 
 	ws.Route(ws.GET("/measurements/{name}").To(s.getMeasurements).
 		Doc("Get measurements.").
@@ -578,6 +629,15 @@ func ParseInt(request *restful.Request, name string, fallback int) (value int, e
 	return
 }
 
+// ParseBool parses booleans from the query parameter.
+func ParseBool(request *restful.Request, name string, fallback bool) (bool, error) {
+	valueString := request.QueryParameter(name)
+	if valueString == "" {
+		return fallback, nil
+	}
+	return strconv.ParseBool(valueString)
+}
+
 // ParseDuration parses duration from the query parameter.
 func ParseDuration(request *restful.Request, name string) (time.Duration, error) {
 	valueString := request.QueryParameter(name)
@@ -587,6 +647,19 @@ func ParseDuration(request *restful.Request, name string) (time.Duration, error)
 	return time.ParseDuration(valueString)
 }
 
+// ParseContext parses a request context in JSON such as {"device":"mobile"} from a query parameter.
+func ParseContext(request *restful.Request, name string) (map[string]string, error) {
+	valueString := request.QueryParameter(name)
+	if valueString == "" {
+		return nil, nil
+	}
+	var requestContext map[string]string
+	if err := json.Unmarshal([]byte(valueString), &requestContext); err != nil {
+		return nil, errors.Annotatef(err, "invalid context `%s`", valueString)
+	}
+	return requestContext, nil
+}
+
 func (s *RestServer) SearchDocuments(collection, subset string, categories []string,
 	iteratee func(item cache.Score) (any, error),
 	request *restful.Request, response *restful.Response,
@@ -738,11 +811,49 @@ func (s *RestServer) getCollaborative(request *restful.Request, response *restfu
 	s.SearchDocuments(cache.OfflineRecommend, userId, categories, nil, request, response)
 }
 
+// Sources of recommended items recorded in impressions.
+const (
+	ImpressionSourceOffline       = "offline"
+	ImpressionSourceCollaborative = "collaborative"
+	ImpressionSourceItemBased     = "item_based"
+	ImpressionSourceUserBased     = "user_based"
+	ImpressionSourceImageBased    = "image_based"
+	ImpressionSourceLatest        = "latest"
+	ImpressionSourcePopular       = "popular"
+	ImpressionSourceSession       = "session"
+	ImpressionSourceDeck          = "deck"
+	ImpressionSourceOnboarding    = "onboarding"
+)
+
+// ImpressionSources are all sources of recommended items.
+var ImpressionSources = []string{
+	ImpressionSourceOffline,
+	ImpressionSourceCollaborative,
+	ImpressionSourceItemBased,
+	ImpressionSourceUserBased,
+	ImpressionSourceImageBased,
+	ImpressionSourceLatest,
+	ImpressionSourcePopular,
+	ImpressionSourceSession,
+	ImpressionSourceDeck,
+	ImpressionSourceOnboarding,
+}
+
 // Recommend items to users.
 // 1. If there are recommendations in cache, return cached recommendations.
 // 2. If there are historical interactions of the users, return similar items.
 // 3. Otherwise, return fallback recommendation (popular/latest).
 func (s *RestServer) Recommend(ctx context.Context, response *restful.Response, userId string, categories []string, n int, recommenders ...Recommender) ([]string, error) {
+	recommendCtx, err := s.recommend(ctx, response, userId, categories, nil, n, recommenders...)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return recommendCtx.results, nil
+}
+
+// recommend executes recommenders and returns the context containing recommended items with their sources and scores.
+// Offline recommendation is re-ranked by the click model if the context of the request is given.
+func (s *RestServer) recommend(ctx context.Context, response *restful.Response, userId string, categories []string, requestContext map[string]string, n int, recommenders ...Recommender) (*recommendContext, error) {
 	initStart := time.Now()
 
 	// create context
@@ -750,6 +861,7 @@ func (s *RestServer) Recommend(ctx context.Context, response *restful.Response,
 	if err != nil {
 		return nil, errors.Trace(err)
 	}
+	recommendCtx.contextFeatures = click.ConvertContextToFeatures(requestContext)
 
 	// execute recommenders
 	for _, recommender := range recommenders {
@@ -762,6 +874,9 @@ func (s *RestServer) Recommend(ctx context.Context, response *restful.Response,
 	// return recommendations
 	if len(recommendCtx.results) > n {
 		recommendCtx.results = recommendCtx.results[:n]
+		recommendCtx.sources = recommendCtx.sources[:n]
+		recommendCtx.scores = recommendCtx.scores[:n]
+		recommendCtx.propensities = recommendCtx.propensities[:n]
 	}
 	totalTime := time.Since(initStart)
 	log.ResponseLogger(response).Info("complete recommendation",
@@ -779,18 +894,28 @@ func (s *RestServer) Recommend(ctx context.Context, response *restful.Response,
 		zap.Duration("user_based_recommend_time", recommendCtx.userBasedTime),
 		zap.Duration("load_latest_time", recommendCtx.loadLatestTime),
 		zap.Duration("load_popular_time", recommendCtx.loadPopularTime))
-	return recommendCtx.results, nil
+	return recommendCtx, nil
 }
 
 type recommendContext struct {
 	context      context.Context
+	config       *config.Config
 	userId       string
 	categories   []string
 	userFeedback []data.Feedback
 	n            int
 	results      []string
+	sources      []string
+	scores       []float64
 	excludeSet   mapset.Set[string]
 
+	// probabilities that items are picked by recommenders and the share of traffic routed to the experiment arm
+	propensities []float64
+	armShare     float64
+
+	// features of the request context, e.g., device and time of day
+	contextFeatures []click.Feature
+
 	numPrevStage         int
 	numFromLatest        int
 	numFromPopular       int
@@ -816,35 +941,62 @@ func (s *RestServer) createRecommendContext(ctx context.Context, userId string,
 	if err != nil {
 		return nil, errors.Trace(err)
 	}
+	// apply overrides of experiments
+	userConfig := s.Config.ForUser(userId)
 	excludeSet := mapset.NewSet[string]()
 	for _, item := range userFeedback {
-		if !s.Config.Recommend.Replacement.EnableReplacement {
+		if !userConfig.Recommend.Replacement.EnableReplacement {
 			excludeSet.Add(item.ItemId)
 		}
 	}
 	return &recommendContext{
+		config:       userConfig,
 		userId:       userId,
 		categories:   categories,
 		n:            n,
 		excludeSet:   excludeSet,
 		userFeedback: userFeedback,
 		context:      ctx,
+		armShare:     s.Config.ArmShare(userId),
 	}, nil
 }
 
+// push appends an item recommended by a deterministic source to results.
+func (ctx *recommendContext) push(source, itemId string, score float64) {
+	ctx.pushWithPropensity(source, itemId, score, 1)
+}
+
+// pushWithPropensity appends an item recommended by a source to results with the probability that the source picks
+// the item, which is zero if unknown.
+func (ctx *recommendContext) pushWithPropensity(source, itemId string, score, propensity float64) {
+	ctx.results = append(ctx.results, itemId)
+	ctx.sources = append(ctx.sources, source)
+	ctx.scores = append(ctx.scores, score)
+	ctx.propensities = append(ctx.propensities, propensity)
+	ctx.excludeSet.Add(itemId)
+}
+
+// propensity returns the probability that the i-th item is served to the user.
+func (ctx *recommendContext) propensity(i int) float64 {
+	return ctx.armShare * ctx.propensities[i]
+}
+
 type Recommender func(ctx *recommendContext) error
 
 func (s *RestServer) RecommendOffline(ctx *recommendContext) error {
 	if len(ctx.results) < ctx.n {
 		start := time.Now()
-		recommendation, err := s.CacheClient.SearchScores(ctx.context, cache.OfflineRecommend, ctx.userId, ctx.categories, 0, s.Config.Recommend.CacheSize)
+		recommendation, err := s.CacheClient.SearchScores(ctx.context, cache.OfflineRecommend, ctx.userId, ctx.categories, 0, ctx.config.Recommend.CacheSize)
 		if err != nil {
 			return errors.Trace(err)
 		}
+		if len(ctx.contextFeatures) > 0 && ctx.config.Recommend.Offline.EnableClickThroughPrediction {
+			recommendation = s.rankByClickThroughRate(ctx, recommendation)
+		}
+		propensities := s.offlinePropensities(ctx)
 		for _, item := range recommendation {
 			if !ctx.excludeSet.Contains(item.Id) {
-				ctx.results = append(ctx.results, item.Id)
-				ctx.excludeSet.Add(item.Id)
+				ctx.pushWithPropensity(ImpressionSourceOffline, item.Id, item.Score, propensities[item.Id])
 			}
 		}
 		ctx.loadOfflineRecTime = time.Since(start)
@@ -854,17 +1006,108 @@ func (s *RestServer) RecommendOffline(ctx *recommendContext) error {
 	return nil
 }
 
+// offlinePropensities loads probabilities that items of offline recommendation are picked by exploration or
+// exploitation. Propensities of the first category are used if categories are given. Propensities are unknown if
+// they fail to load.
+func (s *RestServer) offlinePropensities(ctx *recommendContext) map[string]float64 {
+	value, err := s.CacheClient.Get(ctx.context, cache.Key(cache.OfflineRecommendPropensity, ctx.userId)).String()
+	if err != nil {
+		if !errors.Is(err, errors.NotFound) {
+			log.Logger().Warn("failed to load offline recommendation propensities", zap.String("user_id", ctx.userId), zap.Error(err))
+		}
+		return nil
+	}
+	var propensities map[string]map[string]float64
+	if err = json.Unmarshal([]byte(value), &propensities); err != nil {
+		log.Logger().Warn("failed to parse offline recommendation propensities", zap.String("user_id", ctx.userId), zap.Error(err))
+		return nil
+	}
+	category := ""
+	if len(ctx.categories) > 0 {
+		category = ctx.categories[0]
+	}
+	return propensities[category]
+}
+
+// rankByClickThroughRate re-ranks candidates by the click model given the context of the request. Only the first n
+// candidates plus a margin are re-ranked and the rest keep their offline order. Candidates are returned as is if the
+// click model isn't ready or labels of the user and candidates fail to load.
+func (s *RestServer) rankByClickThroughRate(ctx *recommendContext, candidates []cache.Score) []cache.Score {
+	clickModel, _ := s.LoadClickModel()
+	if clickModel == nil || clickModel.Invalid() || len(candidates) == 0 {
+		return candidates
+	}
+	// candidates to fill the rest of results, plus a margin, are re-ranked
+	numRanked, numNeeded := 0, ctx.n-len(ctx.results)+ctx.config.Recommend.Offline.ClickThroughMargin
+	for numRanked < len(candidates) && numNeeded > 0 {
+		if !ctx.excludeSet.Contains(candidates[numRanked].Id) {
+			numNeeded--
+		}
+		numRanked++
+	}
+	// load labels of the user and candidates
+	var userFeatures []click.Feature
+	user, err := s.DataClient.GetUser(ctx.context, ctx.userId)
+	if err == nil {
+		userFeatures = click.ConvertLabelsToFeatures(user.Labels)
+	} else if !errors.Is(err, errors.NotFound) {
+		log.Logger().Warn("failed to load user for click-through rate prediction",
+			zap.String("user_id", ctx.userId), zap.Error(err))
+		return candidates
+	}
+	items, err := s.DataClient.BatchGetItems(ctx.context, lo.Map(candidates[:numRanked], func(candidate cache.Score, _ int) string {
+		return candidate.Id
+	}))
+	if err != nil {
+		log.Logger().Warn("failed to load items for click-through rate prediction",
+			zap.String("user_id", ctx.userId), zap.Error(err))
+		return candidates
+	}
+	itemLabels := make(map[string]any, len(items))
+	for _, item := range items {
+		itemLabels[item.ItemId] = item.Labels
+	}
+	// predict click-through rates
+	result := make([]cache.Score, len(candidates))
+	copy(result, candidates)
+	ranked := result[:numRanked]
+	if batchPredictor, ok := clickModel.(click.BatchInference); ok {
+		inputs := make([]lo.Tuple5[string, string, []click.Feature, []click.Feature, []click.Feature], len(ranked))
+		for i, candidate := range ranked {
+			inputs[i].A = ctx.userId
+			inputs[i].B = candidate.Id
+			inputs[i].C = userFeatures
+			inputs[i].D = click.ConvertLabelsToFeatures(itemLabels[candidate.Id])
+			inputs[i].E = ctx.contextFeatures
+		}
+		for i, score := range batchPredictor.BatchPredict(inputs) {
+			ranked[i].Score = float64(score)
+		}
+	} else {
+		for i, candidate := range ranked {
+			ranked[i].Score = float64(clickModel.Predict(ctx.userId, candidate.Id, userFeatures,
+				click.ConvertLabelsToFeatures(itemLabels[candidate.Id]), ctx.contextFeatures))
+		}
+	}
+	if calibrator := click.GetCalibrator(clickModel); calibrator != nil {
+		for i := range ranked {
+			ranked[i].Score = float64(calibrator.Calibrate(float32(ranked[i].Score)))
+		}
+	}
+	cache.SortDocuments(ranked)
+	return result
+}
+
 func (s *RestServer) RecommendCollaborative(ctx *recommendContext) error {
 	if len(ctx.results) < ctx.n {
 		start := time.Now()
-		collaborativeRecommendation, err := s.CacheClient.SearchScores(ctx.context, cache.CollaborativeRecommend, ctx.userId, ctx.categories, 0, s.Config.Recommend.CacheSize)
+		collaborativeRecommendation, err := s.CacheClient.SearchScores(ctx.context, cache.CollaborativeRecommend, ctx.userId, ctx.categories, 0, ctx.config.Recommend.CacheSize)
 		if err != nil {
 			return errors.Trace(err)
 		}
 		for _, item := range collaborativeRecommendation {
 			if !ctx.excludeSet.Contains(item.Id) {
-				ctx.results = append(ctx.results, item.Id)
-				ctx.excludeSet.Add(item.Id)
+				ctx.push(ImpressionSourceCollaborative, item.Id, item.Score)
 			}
 		}
 		ctx.loadColRecTime = time.Since(start)
@@ -879,13 +1122,13 @@ func (s *RestServer) RecommendUserBased(ctx *recommendContext) error {
 		start := time.Now()
 		candidates := make(map[string]float64)
 		// load similar users
-		similarUsers, err := s.CacheClient.SearchScores(ctx.context, cache.UserNeighbors, ctx.userId, []string{""}, 0, s.Config.Recommend.CacheSize)
+		similarUsers, err := s.CacheClient.SearchScores(ctx.context, cache.UserNeighbors, ctx.userId, []string{""}, 0, ctx.config.Recommend.CacheSize)
 		if err != nil {
 			return errors.Trace(err)
 		}
 		for _, user := range similarUsers {
 			// load historical feedback
-			feedbacks, err := s.DataClient.GetUserFeedback(ctx.context, user.Id, s.Config.Now(), s.Config.Recommend.DataSource.PositiveFeedbackTypes...)
+			feedbacks, err := s.DataClient.GetUserFeedback(ctx.context, user.Id, s.Config.Now(), ctx.config.Recommend.DataSource.PositiveFeedbackTypes...)
 			if err != nil {
 				return errors.Trace(err)
 			}
@@ -897,7 +1140,7 @@ func (s *RestServer) RecommendUserBased(ctx *recommendContext) error {
 						return errors.Trace(err)
 					}
 					if funk.Equal(ctx.categories, []string{""}) || funk.Subset(ctx.categories, item.Categories) {
-						candidates[feedback.ItemId] += user.Score
+						candidates[feedback.ItemId] += user.Score * ctx.config.Recommend.DataSource.Confidence(feedback.FeedbackType, feedback.Value)
 					}
 				}
 			}
@@ -908,9 +1151,10 @@ func (s *RestServer) RecommendUserBased(ctx *recommendContext) error {
 		for id, score := range candidates {
 			filter.Push(id, score)
 		}
-		ids, _ := filter.PopAll()
-		ctx.results = append(ctx.results, ids...)
-		ctx.excludeSet.Append(ids...)
+		ids, scores := filter.PopAll()
+		for i := range ids {
+			ctx.push(ImpressionSourceUserBased, ids[i], scores[i])
+		}
 		ctx.userBasedTime = time.Since(start)
 		ctx.numFromUserBased = len(ctx.results) - ctx.numPrevStage
 		ctx.numPrevStage = len(ctx.results)
@@ -923,12 +1167,12 @@ func (s *RestServer) RecommendItemBased(ctx *recommendContext) error {
 		start := time.Now()
 		// truncate user feedback
 		data.SortFeedbacks(ctx.userFeedback)
-		userFeedback := make([]data.Feedback, 0, s.Config.Recommend.Online.NumFeedbackFallbackItemBased)
+		userFeedback := make([]data.Feedback, 0, ctx.config.Recommend.Online.NumFeedbackFallbackItemBased)
 		for _, feedback := range ctx.userFeedback {
-			if s.Config.Recommend.Online.NumFeedbackFallbackItemBased <= len(userFeedback) {
+			if ctx.config.Recommend.Online.NumFeedbackFallbackItemBased <= len(userFeedback) {
 				break
 			}
-			if funk.ContainsString(s.Config.Recommend.DataSource.PositiveFeedbackTypes, feedback.FeedbackType) {
+			if funk.ContainsString(ctx.config.Recommend.DataSource.PositiveFeedbackTypes, feedback.FeedbackType) {
 				userFeedback = append(userFeedback, feedback)
 			}
 		}
@@ -936,14 +1180,15 @@ func (s *RestServer) RecommendItemBased(ctx *recommendContext) error {
 		candidates := make(map[string]float64)
 		for _, feedback := range userFeedback {
 			// load similar items
-			similarItems, err := s.CacheClient.SearchScores(ctx.context, cache.ItemNeighbors, feedback.ItemId, ctx.categories, 0, s.Config.Recommend.CacheSize)
+			similarItems, err := s.CacheClient.SearchScores(ctx.context, cache.ItemNeighbors, feedback.ItemId, ctx.categories, 0, ctx.config.Recommend.CacheSize)
 			if err != nil {
 				return errors.Trace(err)
 			}
 			// add unseen items
+			confidence := ctx.config.Recommend.DataSource.Confidence(feedback.FeedbackType, feedback.Value)
 			for _, item := range similarItems {
 				if !ctx.excludeSet.Contains(item.Id) {
-					candidates[item.Id] += item.Score
+					candidates[item.Id] += item.Score * confidence
 				}
 			}
 		}
@@ -953,9 +1198,10 @@ func (s *RestServer) RecommendItemBased(ctx *recommendContext) error {
 		for id, score := range candidates {
 			filter.Push(id, score)
 		}
-		ids, _ := filter.PopAll()
-		ctx.results = append(ctx.results, ids...)
-		ctx.excludeSet.Append(ids...)
+		ids, scores := filter.PopAll()
+		for i := range ids {
+			ctx.push(ImpressionSourceItemBased, ids[i], scores[i])
+		}
 		ctx.itemBasedTime = time.Since(start)
 		ctx.numFromItemBased = len(ctx.results) - ctx.numPrevStage
 		ctx.numPrevStage = len(ctx.results)
@@ -966,14 +1212,13 @@ func (s *RestServer) RecommendItemBased(ctx *recommendContext) error {
 func (s *RestServer) RecommendLatest(ctx *recommendContext) error {
 	if len(ctx.results) < ctx.n {
 		start := time.Now()
-		items, err := s.CacheClient.SearchScores(ctx.context, cache.NonPersonalized, cache.Latest, ctx.categories, 0, s.Config.Recommend.CacheSize)
+		items, err := s.CacheClient.SearchScores(ctx.context, cache.NonPersonalized, cache.Latest, ctx.categories, 0, ctx.config.Recommend.CacheSize)
 		if err != nil {
 			return errors.Trace(err)
 		}
 		for _, item := range items {
 			if !ctx.excludeSet.Contains(item.Id) {
-				ctx.results = append(ctx.results, item.Id)
-				ctx.excludeSet.Add(item.Id)
+				ctx.push(ImpressionSourceLatest, item.Id, item.Score)
 			}
 		}
 		ctx.loadLatestTime = time.Since(start)
@@ -986,14 +1231,13 @@ func (s *RestServer) RecommendLatest(ctx *recommendContext) error {
 func (s *RestServer) RecommendPopular(ctx *recommendContext) error {
 	if len(ctx.results) < ctx.n {
 		start := time.Now()
-		items, err := s.CacheClient.SearchScores(ctx.context, cache.NonPersonalized, cache.Popular, ctx.categories, 0, s.Config.Recommend.CacheSize)
+		items, err := s.CacheClient.SearchScores(ctx.context, cache.NonPersonalized, cache.Popular, ctx.categories, 0, ctx.config.Recommend.CacheSize)
 		if err != nil {
 			return errors.Trace(err)
 		}
 		for _, item := range items {
 			if !ctx.excludeSet.Contains(item.Id) {
-				ctx.results = append(ctx.results, item.Id)
-				ctx.excludeSet.Add(item.Id)
+				ctx.push(ImpressionSourcePopular, item.Id, item.Score)
 			}
 		}
 		ctx.loadPopularTime = time.Since(start)
@@ -1006,26 +1250,27 @@ func (s *RestServer) RecommendPopular(ctx *recommendContext) error {
 func (s *RestServer) RecommendImageBased(ctx *recommendContext) error {
 	if len(ctx.results) < ctx.n {
 		start := time.Now()
-		// Get user's positive feedback items
-		positiveItems := make([]string, 0)
+		// Get user's positive feedback
+		positiveFeedback := make([]data.Feedback, 0)
 		for _, feedback := range ctx.userFeedback {
-			if funk.ContainsString(s.Config.Recommend.DataSource.PositiveFeedbackTypes, feedback.FeedbackType) {
-				positiveItems = append(positiveItems, feedback.ItemId)
+			if funk.ContainsString(ctx.config.Recommend.DataSource.PositiveFeedbackTypes, feedback.FeedbackType) {
+				positiveFeedback = append(positiveFeedback, feedback)
 			}
 		}
 
 		// Collect candidates with scores
 		candidates := make(map[string]float64)
-		for _, itemId := range positiveItems {
+		for _, feedback := range positiveFeedback {
 			// Get similar items based on image embeddings
-			similarItems, err := s.CacheClient.SearchScores(ctx.context, cache.ImageSimilar, itemId, ctx.categories, 0, s.Config.Recommend.ImageEmbeddings.NumSimilar)
+			similarItems, err := s.CacheClient.SearchScores(ctx.context, cache.ImageSimilar, feedback.ItemId, ctx.categories, 0, ctx.config.Recommend.ImageEmbeddings.NumSimilar)
 			if err != nil {
 				return errors.Trace(err)
 			}
 			// Add unseen items
+			confidence := ctx.config.Recommend.DataSource.Confidence(feedback.FeedbackType, feedback.Value)
 			for _, item := range similarItems {
 				if !ctx.excludeSet.Contains(item.Id) {
-					candidates[item.Id] += item.Score * s.Config.Recommend.ImageEmbeddings.ImageWeight
+					candidates[item.Id] += item.Score * ctx.config.Recommend.ImageEmbeddings.ImageWeight * confidence
 				}
 			}
 		}
@@ -1036,9 +1281,10 @@ func (s *RestServer) RecommendImageBased(ctx *recommendContext) error {
 		for id, score := range candidates {
 			filter.Push(id, score)
 		}
-		ids, _ := filter.PopAll()
-		ctx.results = append(ctx.results, ids...)
-		ctx.excludeSet.Append(ids...)
+		ids, scores := filter.PopAll()
+		for i := range ids {
+			ctx.push(ImpressionSourceImageBased, ids[i], scores[i])
+		}
 		ctx.imageBasedTime = time.Since(start)
 		ctx.numFromImageBased = len(ctx.results) - ctx.numPrevStage
 		ctx.numPrevStage = len(ctx.results)
@@ -1046,6 +1292,32 @@ func (s *RestServer) RecommendImageBased(ctx *recommendContext) error {
 	return nil
 }
 
+// recommenders returns the offline recommender followed by the fallback chain of a user's configuration.
+func (s *RestServer) recommenders(userConfig *config.Config) ([]Recommender, error) {
+	recommenders := []Recommender{s.RecommendOnboarding, s.RecommendOffline}
+	for _, recommender := range userConfig.Recommend.Online.FallbackRecommend {
+		switch recommender {
+		case "collaborative":
+			recommenders = append(recommenders, s.RecommendCollaborative)
+		case "item_based":
+			recommenders = append(recommenders, s.RecommendItemBased)
+		case "user_based":
+			recommenders = append(recommenders, s.RecommendUserBased)
+		case "image_based":
+			if userConfig.Recommend.ImageEmbeddings.EnableImageRecommend {
+				recommenders = append(recommenders, s.RecommendImageBased)
+			}
+		case "latest":
+			recommenders = append(recommenders, s.RecommendLatest)
+		case "popular":
+			recommenders = append(recommenders, s.RecommendPopular)
+		default:
+			return nil, fmt.Errorf("unknown fallback recommendation method `%s`", recommender)
+		}
+	}
+	return recommenders, nil
+}
+
 func (s *RestServer) getRecommend(request *restful.Request, response *restful.Response) {
 	ctx := context.Background()
 	if request != nil && request.Request != nil {
@@ -1070,35 +1342,38 @@ func (s *RestServer) getRecommend(request *restful.Request, response *restful.Re
 		BadRequest(response, err)
 		return
 	}
+	requestContext, err := ParseContext(request, "context")
+	if err != nil {
+		BadRequest(response, err)
+		return
+	}
 	// online recommendation
-	recommenders := []Recommender{s.RecommendOffline}
-	for _, recommender := range s.Config.Recommend.Online.FallbackRecommend {
-		switch recommender {
-		case "collaborative":
-			recommenders = append(recommenders, s.RecommendCollaborative)
-		case "item_based":
-			recommenders = append(recommenders, s.RecommendItemBased)
-		case "user_based":
-			recommenders = append(recommenders, s.RecommendUserBased)
-		case "image_based":
-			if s.Config.Recommend.ImageEmbeddings.EnableImageRecommend {
-				recommenders = append(recommenders, s.RecommendImageBased)
-			}
-		case "latest":
-			recommenders = append(recommenders, s.RecommendLatest)
-		case "popular":
-			recommenders = append(recommenders, s.RecommendPopular)
-		default:
-			InternalServerError(response, fmt.Errorf("unknown fallback recommendation method `%s`", recommender))
-			return
-		}
+	recommenders, err := s.recommenders(s.Config.ForUser(userId))
+	if err != nil {
+		InternalServerError(response, err)
+		return
 	}
-	results, err := s.Recommend(ctx, response, userId, categories, offset+n, recommenders...)
+	recommendCtx, err := s.recommend(ctx, response, userId, categories, requestContext, offset+n, recommenders...)
 	if err != nil {
 		InternalServerError(response, err)
 		return
 	}
-	results = results[mathutil.Min(offset, len(results)):]
+	results := recommendCtx.results[mathutil.Min(offset, len(recommendCtx.results)):]
+	// log impressions
+	if s.Config.Server.LogImpressions {
+		impressions := make([]data.Impression, 0, len(results))
+		for i := mathutil.Min(offset, len(recommendCtx.results)); i < len(recommendCtx.results); i++ {
+			impressions = append(impressions, data.Impression{
+				UserId:     userId,
+				ItemId:     recommendCtx.results[i],
+				Position:   i,
+				Source:     recommendCtx.sources[i],
+				Score:      recommendCtx.scores[i],
+				Propensity: recommendCtx.propensity(i),
+			})
+		}
+		s.logImpressions(ctx, response, impressions)
+	}
 	// write back
 	if writeBackFeedback != "" {
 		startTime := time.Now()
@@ -1111,6 +1386,7 @@ func (s *RestServer) getRecommend(request *restful.Request, response *restful.Re
 					FeedbackType: writeBackFeedback,
 				},
 				Timestamp: startTime.Add(writeBackDelay),
+				Context:   requestContext,
 			}
 			err = s.DataClient.BatchInsertFeedback(ctx, []data.Feedback{feedback}, false, false, false)
 			if err != nil {
@@ -1158,46 +1434,59 @@ func (s *RestServer) sessionRecommend(request *restful.Request, response *restfu
 	}
 	data.SortFeedbacks(dataFeedback)
 
-	// item-based recommendation
+	// collect positive feedback
 	var excludeSet = mapset.NewSet[string]()
 	var userFeedback []data.Feedback
+	var userId string
 	for _, feedback := range dataFeedback {
 		excludeSet.Add(feedback.ItemId)
+		if userId == "" {
+			userId = feedback.UserId
+		}
 		if funk.ContainsString(s.Config.Recommend.DataSource.PositiveFeedbackTypes, feedback.FeedbackType) {
 			userFeedback = append(userFeedback, feedback)
 		}
 	}
-	// collect candidates
-	candidates := make(map[string]float64)
-	usedFeedbackCount := 0
-	for _, feedback := range userFeedback {
-		// load similar items
-		similarItems, err := s.CacheClient.SearchScores(ctx, cache.ItemNeighbors, feedback.ItemId, []string{category}, 0, s.Config.Recommend.CacheSize)
-		if err != nil {
-			BadRequest(response, err)
-			return
-		}
-		// add unseen items
-		// similarItems = s.FilterOutHiddenScores(response, similarItems, "")
-		for _, item := range similarItems {
-			if !excludeSet.Contains(item.Id) {
-				candidates[item.Id] += item.Score
+	// sequence-aware recommendation
+	names, scores, err := s.recommendBySessionModel(ctx, userFeedback, excludeSet, category, n+offset)
+	if err != nil {
+		InternalServerError(response, err)
+		return
+	}
+	if len(names) == 0 {
+		// fall back to item-based recommendation
+		candidates := make(map[string]float64)
+		usedFeedbackCount := 0
+		for _, feedback := range userFeedback {
+			// load similar items
+			similarItems, err := s.CacheClient.SearchScores(ctx, cache.ItemNeighbors, feedback.ItemId, []string{category}, 0, s.Config.Recommend.CacheSize)
+			if err != nil {
+				BadRequest(response, err)
+				return
 			}
-		}
-		// finish recommendation if the number of used feedbacks is enough
-		if len(similarItems) > 0 {
-			usedFeedbackCount++
-			if usedFeedbackCount >= s.Config.Recommend.Online.NumFeedbackFallbackItemBased {
-				break
+			// add unseen items
+			// similarItems = s.FilterOutHiddenScores(response, similarItems, "")
+			confidence := s.Config.Recommend.DataSource.Confidence(feedback.FeedbackType, feedback.Value)
+			for _, item := range similarItems {
+				if !excludeSet.Contains(item.Id) {
+					candidates[item.Id] += item.Score * confidence
+				}
+			}
+			// finish recommendation if the number of used feedbacks is enough
+			if len(similarItems) > 0 {
+				usedFeedbackCount++
+				if usedFeedbackCount >= s.Config.Recommend.Online.NumFeedbackFallbackItemBased {
+					break
+				}
 			}
 		}
+		// collect top k
+		filter := heap.NewTopKFilter[string, float64](n + offset)
+		for id, score := range candidates {
+			filter.Push(id, score)
+		}
+		names, scores = filter.PopAll()
 	}
-	// collect top k
-	filter := heap.NewTopKFilter[string, float64](n + offset)
-	for id, score := range candidates {
-		filter.Push(id, score)
-	}
-	names, scores := filter.PopAll()
 	result := lo.Map(names, func(_ string, i int) cache.Score {
 		return cache.Score{
 			Id:    names[i],
@@ -1210,10 +1499,81 @@ func (s *RestServer) sessionRecommend(request *restful.Request, response *restfu
 		result = nil
 	}
 	result = result[:lo.Min([]int{len(result), n})]
+	// log impressions, which are skipped for anonymous sessions since they couldn't be joined with feedback
+	if s.Config.Server.LogImpressions && userId != "" {
+		impressions := make([]data.Impression, len(result))
+		for i, item := range result {
+			impressions[i] = data.Impression{
+				UserId:     userId,
+				ItemId:     item.Id,
+				Position:   offset + i,
+				Source:     ImpressionSourceSession,
+				Score:      item.Score,
+				Propensity: s.Config.ArmShare(userId),
+			}
+		}
+		s.logImpressions(ctx, response, impressions)
+	}
 	// Send result
 	Ok(response, result)
 }
 
+// recommendBySessionModel recommends items following positive feedback in a session using the session model. Feedback
+// is sorted from the latest to the earliest. Nothing is recommended if the session model is unavailable or none of items
+// in the session is known by the model.
+func (s *RestServer) recommendBySessionModel(ctx context.Context, feedback []data.Feedback, excludeSet mapset.Set[string], category string, n int) ([]string, []float64, error) {
+	sessionModel, _ := s.LoadSessionModel()
+	if sessionModel == nil || sessionModel.Invalid() || len(feedback) == 0 {
+		return nil, nil, nil
+	}
+	sequence := make([]string, len(feedback))
+	for i, f := range feedback {
+		sequence[len(feedback)-1-i] = f.ItemId
+	}
+	itemIds, itemScores := sessionModel.Recommend(sequence, s.Config.Recommend.CacheSize)
+	if len(itemIds) == 0 {
+		return nil, nil, nil
+	}
+	// remove invisible items and items not in the category
+	items, err := s.DataClient.BatchGetItems(ctx, itemIds)
+	if err != nil {
+		return nil, nil, errors.Trace(err)
+	}
+	candidates := make(map[string]bool, len(items))
+	for _, item := range items {
+		candidates[item.ItemId] = item.IsVisible() && (category == "" || lo.Contains(item.Categories, category))
+	}
+	filter := heap.NewTopKFilter[string, float64](n)
+	for i, itemId := range itemIds {
+		if candidates[itemId] && !excludeSet.Contains(itemId) {
+			filter.Push(itemId, float64(itemScores[i]))
+		}
+	}
+	names, scores := filter.PopAll()
+	return names, scores, nil
+}
+
+// logImpressions saves impressions of a response to the data store. Impressions are tagged with the request ID so
+// that they can be joined with feedback later. Failures are logged but never fail the request.
+func (s *RestServer) logImpressions(ctx context.Context, response *restful.Response, impressions []data.Impression) {
+	if len(impressions) == 0 {
+		return
+	}
+	requestId := response.Header().Get("X-Request-ID")
+	if requestId == "" {
+		requestId = uuid.New().String()
+		response.AddHeader("X-Request-ID", requestId)
+	}
+	timestamp := time.Now()
+	for i := range impressions {
+		impressions[i].RequestId = requestId
+		impressions[i].Timestamp = timestamp
+	}
+	if err := s.DataClient.BatchInsertImpressions(ctx, impressions); err != nil {
+		log.ResponseLogger(response).Error("failed to log impressions", zap.Error(err))
+	}
+}
+
 // Success is the returned data structure for data insert operations.
 type Success struct {
 	RowAffected int
@@ -1235,7 +1595,12 @@ func (s *RestServer) insertUser(request *restful.Request, response *restful.Resp
 		BadRequest(response, err)
 		return
 	}
-	if err := s.DataClient.BatchInsertUsers(ctx, []data.User{temp}); err != nil {
+	eventCtx, err := logics.WithEvents(ctx, s.Config, data.EventUserUpserted, []data.User{temp}, userIdOf)
+	if err != nil {
+		InternalServerError(response, err)
+		return
+	}
+	if err := s.DataClient.BatchInsertUsers(eventCtx, []data.User{temp}); err != nil {
 		InternalServerError(response, err)
 		return
 	}
@@ -1265,7 +1630,13 @@ func (s *RestServer) modifyUser(request *restful.Request, response *restful.Resp
 		BadRequest(response, err)
 		return
 	}
-	if err := s.DataClient.ModifyUser(ctx, userId, patch); err != nil {
+	eventCtx, err := logics.WithEvents(ctx, s.Config, data.EventUserUpserted, []data.UserPatch{patch},
+		func(data.UserPatch) string { return userId })
+	if err != nil {
+		InternalServerError(response, err)
+		return
+	}
+	if err := s.DataClient.ModifyUser(eventCtx, userId, patch); err != nil {
 		InternalServerError(response, err)
 		return
 	}
@@ -1315,7 +1686,12 @@ func (s *RestServer) insertUsers(request *restful.Request, response *restful.Res
 		}
 	}
 	// range temp and achieve user
-	if err := s.DataClient.BatchInsertUsers(ctx, temp); err != nil {
+	eventCtx, err := logics.WithEvents(ctx, s.Config, data.EventUserUpserted, temp, userIdOf)
+	if err != nil {
+		InternalServerError(response, err)
+		return
+	}
+	if err := s.DataClient.BatchInsertUsers(eventCtx, temp); err != nil {
 		InternalServerError(response, err)
 		return
 	}
@@ -1364,7 +1740,35 @@ func (s *RestServer) deleteUser(request *restful.Request, response *restful.Resp
 	}
 	// get user-id and put into temp
 	userId := request.PathParameter("user-id")
-	if err := s.DataClient.DeleteUser(ctx, userId); err != nil {
+	erase, err := ParseBool(request, "erase", false)
+	if err != nil {
+		BadRequest(response, err)
+		return
+	}
+	if erase {
+		eventCtx, err := logics.WithEvents(ctx, s.Config, data.EventUserDeleted, []string{userId}, idOf)
+		if err != nil {
+			InternalServerError(response, err)
+			return
+		}
+		report, err := logics.EraseUser(eventCtx, s.DataClient, s.CacheClient, userId,
+			s.Config.Recommend.Collaborative.ModelFitPeriod)
+		if err != nil {
+			InternalServerError(response, err)
+			return
+		}
+		log.ResponseLogger(response).Info("erase user", zap.String("user_id", userId),
+			zap.Int("n_impressions", report.Impressions), zap.Strings("cache_keys", report.CacheKeys),
+			zap.Time("models_retained_until", report.RetainedUntil))
+		Ok(response, report)
+		return
+	}
+	eventCtx, err := logics.WithEvents(ctx, s.Config, data.EventUserDeleted, []string{userId}, idOf)
+	if err != nil {
+		InternalServerError(response, err)
+		return
+	}
+	if err = s.DataClient.DeleteUser(eventCtx, userId); err != nil {
 		InternalServerError(response, err)
 		return
 	}
@@ -1410,6 +1814,7 @@ type Item struct {
 	Timestamp  string
 	Labels     any
 	Comment    string
+	Status     data.ItemStatus
 }
 
 func (s *RestServer) batchInsertItems(ctx context.Context, response *restful.Response, temp []Item) {
@@ -1451,14 +1856,26 @@ func (s *RestServer) batchInsertItems(ctx context.Context, response *restful.Res
 				return
 			}
 		}
-		items = append(items, data.Item{
+		dataItem := data.Item{
 			ItemId:     item.ItemId,
 			IsHidden:   item.IsHidden,
 			Categories: item.Categories,
 			Timestamp:  timestamp,
 			Labels:     item.Labels,
 			Comment:    item.Comment,
-		})
+		}
+		// keep the lifecycle of the existed item
+		if existedItem, exist := existedItemsSet[item.ItemId]; exist {
+			dataItem.Status = existedItem.Status
+			dataItem.StatusHistory = existedItem.StatusHistory
+		}
+		if item.Status != "" {
+			if err = dataItem.Transit(item.Status, time.Now()); err != nil {
+				BadRequest(response, err)
+				return
+			}
+		}
+		items = append(items, dataItem)
 		// insert to latest items cache
 		if err = s.CacheClient.AddScores(ctx, cache.NonPersonalized, cache.Latest, []cache.Score{{
 			Id:         item.ItemId,
@@ -1472,7 +1889,7 @@ func (s *RestServer) batchInsertItems(ctx context.Context, response *restful.Res
 		// update items cache
 		if err = s.CacheClient.UpdateScores(ctx, cache.ItemCache, nil, item.ItemId, cache.ScorePatch{
 			Categories: withWildCard(item.Categories),
-			IsHidden:   &item.IsHidden,
+			IsHidden:   proto.Bool(!dataItem.IsVisible()),
 		}); err != nil {
 			InternalServerError(response, err)
 			return
@@ -1483,7 +1900,12 @@ func (s *RestServer) batchInsertItems(ctx context.Context, response *restful.Res
 
 	// insert items
 	start = time.Now()
-	if err = s.DataClient.BatchInsertItems(ctx, items); err != nil {
+	eventCtx, err := logics.WithEvents(ctx, s.Config, data.EventItemUpserted, items, itemIdOf)
+	if err != nil {
+		InternalServerError(response, err)
+		return
+	}
+	if err = s.DataClient.BatchInsertItems(eventCtx, items); err != nil {
 		InternalServerError(response, err)
 		return
 	}
@@ -1572,13 +1994,6 @@ func (s *RestServer) modifyItem(request *restful.Request, response *restful.Resp
 		BadRequest(response, err)
 		return
 	}
-	// remove hidden item from cache
-	if patch.IsHidden != nil {
-		if err := s.CacheClient.UpdateScores(ctx, cache.ItemCache, nil, itemId, cache.ScorePatch{IsHidden: patch.IsHidden}); err != nil {
-			InternalServerError(response, err)
-			return
-		}
-	}
 	// add item to latest items cache
 	if patch.Timestamp != nil {
 		if err := s.CacheClient.UpdateScores(ctx, []string{cache.NonPersonalized}, proto.String(cache.Latest), itemId, cache.ScorePatch{Score: proto.Float64(float64(patch.Timestamp.Unix()))}); err != nil {
@@ -1594,10 +2009,54 @@ func (s *RestServer) modifyItem(request *restful.Request, response *restful.Resp
 		}
 	}
 	// modify item
-	if err := s.DataClient.ModifyItem(ctx, itemId, patch); err != nil {
+	// an item hidden, reserved or sold by the patch is published as hidden
+	eventType := data.EventItemUpserted
+	var item data.Item
+	if patch.IsHidden != nil || patch.Status != nil {
+		var err error
+		item, err = s.DataClient.GetItem(ctx, itemId)
+		if errors.Is(err, errors.NotFound) {
+			// the item is hidden in cache before it is inserted
+			item = data.Item{ItemId: itemId}
+		} else if err != nil {
+			InternalServerError(response, err)
+			return
+		}
+		if patch.IsHidden != nil {
+			item.IsHidden = *patch.IsHidden
+		}
+		if patch.Status != nil {
+			item.Status = *patch.Status
+		}
+		if !item.IsVisible() {
+			eventType = data.EventItemHidden
+		}
+	}
+	eventCtx, err := logics.WithEvents(ctx, s.Config, eventType, []data.ItemPatch{patch},
+		func(data.ItemPatch) string { return itemId })
+	if err != nil {
 		InternalServerError(response, err)
 		return
 	}
+	if err := s.DataClient.ModifyItem(eventCtx, itemId, patch); err != nil {
+		if errors.Is(err, errors.NotValid) {
+			BadRequest(response, err)
+		} else if errors.Is(err, errors.NotFound) {
+			PageNotFound(response, err)
+		} else if errors.Is(err, data.ErrStatusConflict) {
+			Error(response, http.StatusConflict, err)
+		} else {
+			InternalServerError(response, err)
+		}
+		return
+	}
+	// remove hidden, reserved or sold item from cache
+	if patch.IsHidden != nil || patch.Status != nil {
+		if err = s.CacheClient.UpdateScores(ctx, cache.ItemCache, nil, itemId, cache.ScorePatch{IsHidden: proto.Bool(!item.IsVisible())}); err != nil {
+			InternalServerError(response, err)
+			return
+		}
+	}
 	// insert modify timestamp
 	if err := s.CacheClient.Set(ctx, cache.Time(cache.Key(cache.LastModifyItemTime, itemId), time.Now())); err != nil {
 		return
@@ -1622,7 +2081,17 @@ func (s *RestServer) getItems(request *restful.Request, response *restful.Respon
 		BadRequest(response, err)
 		return
 	}
-	cursor, items, err := s.DataClient.GetItems(ctx, cursor, n, nil)
+	var items []data.Item
+	if text := request.QueryParameter("query"); text != "" {
+		query, err := data.ParseItemQuery(text)
+		if err != nil {
+			BadRequest(response, err)
+			return
+		}
+		cursor, items, err = s.DataClient.GetItemsByQuery(ctx, query, cursor, n)
+	} else {
+		cursor, items, err = s.DataClient.GetItems(ctx, cursor, n, nil)
+	}
 	if err != nil {
 		InternalServerError(response, err)
 		return
@@ -1657,7 +2126,12 @@ func (s *RestServer) deleteItem(request *restful.Request, response *restful.Resp
 	}
 	itemId := request.PathParameter("item-id")
 	// delete item from database
-	if err := s.DataClient.DeleteItem(ctx, itemId); err != nil {
+	eventCtx, err := logics.WithEvents(ctx, s.Config, data.EventItemDeleted, []string{itemId}, idOf)
+	if err != nil {
+		InternalServerError(response, err)
+		return
+	}
+	if err := s.DataClient.DeleteItem(eventCtx, itemId); err != nil {
 		InternalServerError(response, err)
 		return
 	}
@@ -1687,7 +2161,12 @@ func (s *RestServer) insertItemCategory(request *restful.Request, response *rest
 		item.Categories = append(item.Categories, category)
 	}
 	// insert category to database
-	if err = s.DataClient.BatchInsertItems(ctx, []data.Item{item}); err != nil {
+	eventCtx, err := logics.WithEvents(ctx, s.Config, data.EventItemUpserted, []data.Item{item}, itemIdOf)
+	if err != nil {
+		InternalServerError(response, err)
+		return
+	}
+	if err = s.DataClient.BatchInsertItems(eventCtx, []data.Item{item}); err != nil {
 		InternalServerError(response, err)
 		return
 	}
@@ -1726,7 +2205,12 @@ func (s *RestServer) deleteItemCategory(request *restful.Request, response *rest
 		return
 	}
 	// delete category from database
-	if err = s.DataClient.BatchInsertItems(ctx, []data.Item{item}); err != nil {
+	eventCtx, err := logics.WithEvents(ctx, s.Config, data.EventItemUpserted, []data.Item{item}, itemIdOf)
+	if err != nil {
+		InternalServerError(response, err)
+		return
+	}
+	if err = s.DataClient.BatchInsertItems(eventCtx, []data.Item{item}); err != nil {
 		InternalServerError(response, err)
 		return
 	}
@@ -1738,12 +2222,19 @@ type Feedback struct {
 	data.FeedbackKey
 	Timestamp string
 	Comment   string
+	Value     float64
+	Context   map[string]string
 }
 
 func (f Feedback) ToDataFeedback() (data.Feedback, error) {
 	var feedback data.Feedback
 	feedback.FeedbackKey = f.FeedbackKey
 	feedback.Comment = f.Comment
+	if f.Value < 0 {
+		return data.Feedback{}, errors.New("value of feedback must be non-negative")
+	}
+	feedback.Value = f.Value
+	feedback.Context = f.Context
 	if f.Timestamp != "" {
 		var err error
 		feedback.Timestamp, err = dateparse.ParseAny(f.Timestamp)
@@ -1769,33 +2260,14 @@ func (s *RestServer) insertFeedback(overwrite bool) func(request *restful.Reques
 		// parse datetime
 		var err error
 		feedback := make([]data.Feedback, len(feedbackLiterTime))
-		users := mapset.NewSet[string]()
-		items := mapset.NewSet[string]()
 		for i := range feedback {
-			users.Add(feedbackLiterTime[i].UserId)
-			items.Add(feedbackLiterTime[i].ItemId)
 			feedback[i], err = feedbackLiterTime[i].ToDataFeedback()
 			if err != nil {
 				BadRequest(response, err)
 				return
 			}
 		}
-		// insert feedback to data store
-		err = s.DataClient.BatchInsertFeedback(ctx, feedback,
-			s.Config.Server.AutoInsertUser,
-			s.Config.Server.AutoInsertItem, overwrite)
-		if err != nil {
-			InternalServerError(response, err)
-			return
-		}
-		values := make([]cache.Value, 0, users.Cardinality()+items.Cardinality())
-		for _, userId := range users.ToSlice() {
-			values = append(values, cache.Time(cache.Key(cache.LastModifyUserTime, userId), time.Now()))
-		}
-		for _, itemId := range items.ToSlice() {
-			values = append(values, cache.Time(cache.Key(cache.LastModifyItemTime, itemId), time.Now()))
-		}
-		if err = s.CacheClient.Set(ctx, values...); err != nil {
+		if err = s.saveFeedback(ctx, feedback, overwrite); err != nil {
 			InternalServerError(response, err)
 			return
 		}
@@ -1804,6 +2276,47 @@ func (s *RestServer) insertFeedback(overwrite bool) func(request *restful.Reques
 	}
 }
 
+// saveFeedback inserts feedback to the data store and updates modification time of related users and items.
+func (s *RestServer) saveFeedback(ctx context.Context, feedback []data.Feedback, overwrite bool) error {
+	users := mapset.NewSet[string]()
+	items := mapset.NewSet[string]()
+	for _, f := range feedback {
+		users.Add(f.UserId)
+		items.Add(f.ItemId)
+	}
+	// insert feedback to data store
+	eventCtx, err := logics.WithEvents(ctx, s.Config, data.EventFeedbackInserted, feedback,
+		func(f data.Feedback) string { return f.UserId })
+	if err != nil {
+		return errors.Trace(err)
+	}
+	err = s.DataClient.BatchInsertFeedback(eventCtx, feedback,
+		s.Config.Server.AutoInsertUser,
+		s.Config.Server.AutoInsertItem, overwrite)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	values := make([]cache.Value, 0, users.Cardinality()+items.Cardinality())
+	for _, userId := range users.ToSlice() {
+		values = append(values, cache.Time(cache.Key(cache.LastModifyUserTime, userId), time.Now()))
+	}
+	for _, itemId := range items.ToSlice() {
+		values = append(values, cache.Time(cache.Key(cache.LastModifyItemTime, itemId), time.Now()))
+	}
+	if err = s.CacheClient.Set(ctx, values...); err != nil {
+		return errors.Trace(err)
+	}
+	// request real-time refresh of offline recommendation
+	if s.Config.Recommend.Offline.EnableRealtimeRefresh {
+		for _, userId := range users.ToSlice() {
+			if err = s.CacheClient.Push(ctx, cache.RefreshUsers, userId); err != nil {
+				return errors.Trace(err)
+			}
+		}
+	}
+	return nil
+}
+
 // FeedbackIterator is the iterator for feedback.
 type FeedbackIterator struct {
 	Cursor   string
@@ -2021,6 +2534,13 @@ func Text(response *restful.Response, content string) {
 	}
 }
 
+// Key functions of change events.
+var (
+	idOf     = func(id string) string { return id }
+	userIdOf = func(user data.User) string { return user.UserId }
+	itemIdOf = func(item data.Item) string { return item.ItemId }
+)
+
 func withWildCard(categories []string) []string {
 	result := make([]string, len(categories), len(categories)+1)
 	copy(result, categories)
@@ -18,6 +18,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"sort"
 	"strconv"
 	"testing"
 	"time"
@@ -28,6 +29,11 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/suite"
 	"github.com/zhenghaoz/gorse/config"
+	"github.com/zhenghaoz/gorse/logics"
+	"github.com/zhenghaoz/gorse/model"
+	"github.com/zhenghaoz/gorse/model/click"
+	"github.com/zhenghaoz/gorse/model/ranking"
+	"github.com/zhenghaoz/gorse/model/session"
 	"github.com/zhenghaoz/gorse/storage/cache"
 	"github.com/zhenghaoz/gorse/storage/data"
 	"google.golang.org/protobuf/proto"
@@ -78,6 +84,7 @@ func (suite *ServerTestSuite) SetupTest() {
 	// configuration
 	suite.Config = config.GetDefaultConfig()
 	suite.Config.Server.APIKey = apiKey
+	suite.StoreSessionModel(nil, 0)
 }
 
 func (suite *ServerTestSuite) marshal(v interface{}) string {
@@ -151,6 +158,42 @@ func (suite *ServerTestSuite) TestUsers() {
 		Expect(t).
 		Status(http.StatusNotFound).
 		End()
+	// test erase
+	ctx := context.Background()
+	err := suite.CacheClient.AddScores(ctx, cache.OfflineRecommend, "2", []cache.Score{{Id: "0", Score: 1, Categories: []string{""}}})
+	assert.NoError(t, err)
+	err = suite.CacheClient.AddScores(ctx, cache.UserNeighbors, "1", []cache.Score{{Id: "2", Score: 1, Categories: []string{""}}})
+	assert.NoError(t, err)
+	var report logics.ErasureReport
+	apitest.New().
+		Handler(suite.handler).
+		Delete("/api/user/2").
+		Header("X-API-Key", apiKey).
+		QueryParams(map[string]string{"erase": "true"}).
+		Expect(t).
+		Status(http.StatusOK).
+		End().
+		JSON(&report)
+	assert.Equal(t, "2", report.UserId)
+	assert.Contains(t, report.CacheKeys, cache.Key(cache.OfflineRecommend, "2"))
+	apitest.New().
+		Handler(suite.handler).
+		Get("/api/user/2").
+		Header("X-API-Key", apiKey).
+		Expect(t).
+		Status(http.StatusNotFound).
+		End()
+	scores, err := suite.CacheClient.SearchScores(ctx, cache.UserNeighbors, "1", nil, 0, -1)
+	assert.NoError(t, err)
+	assert.Empty(t, scores)
+	apitest.New().
+		Handler(suite.handler).
+		Delete("/api/user/2").
+		Header("X-API-Key", apiKey).
+		QueryParams(map[string]string{"erase": "maybe"}).
+		Expect(t).
+		Status(http.StatusBadRequest).
+		End()
 	// test modify
 	apitest.New().
 		Handler(suite.handler).
@@ -287,6 +330,33 @@ func (suite *ServerTestSuite) TestItems() {
 			Items:  items,
 		})).
 		End()
+	// get items by query
+	apitest.New().
+		Handler(suite.handler).
+		Get("/api/items").
+		Header("X-API-Key", apiKey).
+		QueryParams(map[string]string{
+			"query": `{"is_hidden":true,"begin_time":"1997-01-01T00:00:00Z"}`,
+		}).
+		Expect(t).
+		Status(http.StatusOK).
+		Body(suite.marshal(ItemIterator{
+			Cursor: "",
+			Items: lo.Filter(items, func(item data.Item, _ int) bool {
+				return item.IsHidden && item.Timestamp.Year() >= 1997
+			}),
+		})).
+		End()
+	apitest.New().
+		Handler(suite.handler).
+		Get("/api/items").
+		Header("X-API-Key", apiKey).
+		QueryParams(map[string]string{
+			"query": `{"labels":[{"path":"brand","op":"like","value":"levis"}]}`,
+		}).
+		Expect(t).
+		Status(http.StatusBadRequest).
+		End()
 	// get latest items
 	apitest.New().
 		Handler(suite.handler).
@@ -656,13 +726,94 @@ func (suite *ServerTestSuite) TestItems() {
 		End()
 }
 
+func (suite *ServerTestSuite) TestItemStatus() {
+	t := suite.T()
+	timestamp := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	latest := func(ids ...string) {
+		apitest.New().
+			Handler(suite.handler).
+			Get("/api/latest").
+			Header("X-API-Key", apiKey).
+			Expect(t).
+			Status(http.StatusOK).
+			Body(suite.marshal(lo.Map(ids, func(id string, _ int) cache.Score {
+				return cache.Score{Id: id, Score: float64(timestamp.Unix())}
+			}))).
+			End()
+	}
+	transit := func(status data.ItemStatus, code int) {
+		apitest.New().
+			Handler(suite.handler).
+			Patch("/api/item/0").
+			Header("X-API-Key", apiKey).
+			JSON(data.ItemPatch{Status: &status}).
+			Expect(t).
+			Status(code).
+			End()
+	}
+	// drafts aren't recommended
+	apitest.New().
+		Handler(suite.handler).
+		Post("/api/item").
+		Header("X-API-Key", apiKey).
+		JSON(Item{ItemId: "0", Timestamp: timestamp.String(), Status: data.StatusDraft}).
+		Expect(t).
+		Status(http.StatusOK).
+		End()
+	latest()
+	transit(data.StatusActive, http.StatusOK)
+	latest("0")
+	// reserved and sold items aren't recommended
+	transit(data.StatusReserved, http.StatusOK)
+	latest()
+	transit(data.StatusSold, http.StatusOK)
+	latest()
+	// transitions not allowed are rejected
+	transit(data.StatusActive, http.StatusBadRequest)
+	transit("unknown", http.StatusBadRequest)
+	// relisted items are recommended
+	transit(data.StatusRelisted, http.StatusOK)
+	latest("0")
+	// the status is kept if items are overwritten without status
+	apitest.New().
+		Handler(suite.handler).
+		Post("/api/item").
+		Header("X-API-Key", apiKey).
+		JSON(Item{ItemId: "0", Timestamp: timestamp.String(), Comment: "overwrite"}).
+		Expect(t).
+		Status(http.StatusOK).
+		End()
+	var item data.Item
+	apitest.New().
+		Handler(suite.handler).
+		Get("/api/item/0").
+		Header("X-API-Key", apiKey).
+		Expect(t).
+		Status(http.StatusOK).
+		End().
+		JSON(&item)
+	assert.Equal(t, "overwrite", item.Comment)
+	assert.Equal(t, data.StatusRelisted, item.Status)
+	assert.Equal(t, []data.ItemStatus{data.StatusDraft, data.StatusActive, data.StatusReserved, data.StatusSold, data.StatusRelisted},
+		lo.Map(item.StatusHistory, func(change data.StatusChange, _ int) data.ItemStatus { return change.Status }))
+	// unknown items
+	apitest.New().
+		Handler(suite.handler).
+		Patch("/api/item/1").
+		Header("X-API-Key", apiKey).
+		JSON(data.ItemPatch{Status: lo.ToPtr(data.StatusSold)}).
+		Expect(t).
+		Status(http.StatusNotFound).
+		End()
+}
+
 func (suite *ServerTestSuite) TestFeedback() {
 	ctx := context.Background()
 	t := suite.T()
 	// Insert ret
 	feedback := []data.Feedback{
 		{FeedbackKey: data.FeedbackKey{FeedbackType: "click", UserId: "0", ItemId: "0"}},
-		{FeedbackKey: data.FeedbackKey{FeedbackType: "click", UserId: "1", ItemId: "2"}},
+		{FeedbackKey: data.FeedbackKey{FeedbackType: "click", UserId: "1", ItemId: "2"}, Context: map[string]string{"device": "mobile"}},
 		{FeedbackKey: data.FeedbackKey{FeedbackType: "click", UserId: "2", ItemId: "4"}},
 		{FeedbackKey: data.FeedbackKey{FeedbackType: "click", UserId: "3", ItemId: "6"}},
 		{FeedbackKey: data.FeedbackKey{FeedbackType: "click", UserId: "4", ItemId: "8"}},
@@ -751,7 +902,7 @@ func (suite *ServerTestSuite) TestFeedback() {
 		Header("X-API-Key", apiKey).
 		Expect(t).
 		Status(http.StatusOK).
-		Body(`[{"FeedbackType":"click", "UserId": "2", "ItemId": "4", "Timestamp":"0001-01-01T00:00:00Z","Comment":""}]`).
+		Body(`[{"FeedbackType":"click", "UserId": "2", "ItemId": "4", "Timestamp":"0001-01-01T00:00:00Z","Comment":"","Value":0}]`).
 		End()
 	apitest.New().
 		Handler(suite.handler).
@@ -759,7 +910,7 @@ func (suite *ServerTestSuite) TestFeedback() {
 		Header("X-API-Key", apiKey).
 		Expect(t).
 		Status(http.StatusOK).
-		Body(`[{"FeedbackType":"click", "UserId": "2", "ItemId": "4", "Timestamp":"0001-01-01T00:00:00Z","Comment":""}]`).
+		Body(`[{"FeedbackType":"click", "UserId": "2", "ItemId": "4", "Timestamp":"0001-01-01T00:00:00Z","Comment":"","Value":0}]`).
 		End()
 	// test overwrite
 	apitest.New().
@@ -808,6 +959,37 @@ func (suite *ServerTestSuite) TestFeedback() {
 		End()
 }
 
+func (suite *ServerTestSuite) TestFeedbackValue() {
+	t := suite.T()
+	// insert feedback with value
+	apitest.New().
+		Handler(suite.handler).
+		Post("/api/feedback").
+		Header("X-API-Key", apiKey).
+		JSON([]Feedback{{FeedbackKey: data.FeedbackKey{FeedbackType: "star", UserId: "0", ItemId: "0"}, Value: 4.5}}).
+		Expect(t).
+		Status(http.StatusOK).
+		Body(`{"RowAffected": 1}`).
+		End()
+	apitest.New().
+		Handler(suite.handler).
+		Get("/api/user/0/feedback/star").
+		Header("X-API-Key", apiKey).
+		Expect(t).
+		Status(http.StatusOK).
+		Body(`[{"FeedbackType":"star", "UserId": "0", "ItemId": "0", "Timestamp":"0001-01-01T00:00:00Z","Comment":"","Value":4.5}]`).
+		End()
+	// insert feedback with negative value
+	apitest.New().
+		Handler(suite.handler).
+		Post("/api/feedback").
+		Header("X-API-Key", apiKey).
+		JSON([]Feedback{{FeedbackKey: data.FeedbackKey{FeedbackType: "star", UserId: "0", ItemId: "1"}, Value: -1}}).
+		Expect(t).
+		Status(http.StatusBadRequest).
+		End()
+}
+
 func (suite *ServerTestSuite) TestNonPersonalizedRecommend() {
 	ctx := context.Background()
 	type ListOperator struct {
@@ -1541,6 +1723,355 @@ func (suite *ServerTestSuite) TestGetRecommendsFallbackPreCached() {
 		End()
 }
 
+func (suite *ServerTestSuite) TestGetRecommendsExperiment() {
+	ctx := context.Background()
+	t := suite.T()
+	// insert latest
+	err := suite.CacheClient.AddScores(ctx, cache.NonPersonalized, cache.Latest, []cache.Score{
+		{Id: "1", Score: 95, Categories: []string{""}},
+		{Id: "2", Score: 94, Categories: []string{""}}})
+	assert.NoError(t, err)
+	// insert popular
+	err = suite.CacheClient.AddScores(ctx, cache.NonPersonalized, cache.Popular, []cache.Score{
+		{Id: "3", Score: 91, Categories: []string{""}},
+		{Id: "4", Score: 90, Categories: []string{""}}})
+	assert.NoError(t, err)
+	// users in the variant fall back to latest items
+	suite.Config.Recommend.Online.FallbackRecommend = []string{"popular"}
+	suite.Config.Experiments = []config.ExperimentConfig{{
+		Name:    "fallback",
+		Traffic: 0.5,
+		Variants: []config.VariantConfig{{
+			Name:     "latest",
+			Override: map[string]any{"online": map[string]any{"fallback_recommend": []string{"latest"}}},
+		}},
+	}}
+	for i := 0; i < 10; i++ {
+		userId := strconv.Itoa(i)
+		expected := []string{"3", "4"}
+		if _, ok := suite.Config.Experiments[0].Assign(userId); ok {
+			expected = []string{"1", "2"}
+		}
+		apitest.New().
+			Handler(suite.handler).
+			Get("/api/recommend/"+userId).
+			Header("X-API-Key", apiKey).
+			QueryParams(map[string]string{
+				"n": "2",
+			}).
+			Expect(t).
+			Status(http.StatusOK).
+			Body(suite.marshal(expected)).
+			End()
+	}
+}
+
+func (suite *ServerTestSuite) TestInsertFeedbackRealtimeRefresh() {
+	ctx := context.Background()
+	t := suite.T()
+	suite.Config.Recommend.Offline.EnableRealtimeRefresh = true
+	apitest.New().
+		Handler(suite.handler).
+		Post("/api/feedback").
+		Header("X-API-Key", apiKey).
+		JSON([]data.Feedback{
+			{FeedbackKey: data.FeedbackKey{FeedbackType: "click", UserId: "0", ItemId: "0"}},
+			{FeedbackKey: data.FeedbackKey{FeedbackType: "click", UserId: "0", ItemId: "1"}},
+			{FeedbackKey: data.FeedbackKey{FeedbackType: "click", UserId: "1", ItemId: "0"}},
+		}).
+		Expect(t).
+		Status(http.StatusOK).
+		Body(`{"RowAffected": 3}`).
+		End()
+	// users are pushed to the queue once
+	remain, err := suite.CacheClient.Remain(ctx, cache.RefreshUsers)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), remain)
+}
+
+func (suite *ServerTestSuite) TestChangeEvents() {
+	ctx := context.Background()
+	t := suite.T()
+	// events are not written if disabled
+	apitest.New().
+		Handler(suite.handler).
+		Post("/api/item").
+		Header("X-API-Key", apiKey).
+		JSON(Item{ItemId: "0"}).
+		Expect(t).
+		Status(http.StatusOK).
+		End()
+	events, err := suite.DataClient.GetEvents(ctx, 100)
+	assert.NoError(t, err)
+	assert.Empty(t, events)
+
+	suite.Config.Events.Enable = true
+	apitest.New().
+		Handler(suite.handler).
+		Post("/api/items").
+		Header("X-API-Key", apiKey).
+		JSON([]Item{{ItemId: "1", Comment: "comment"}, {ItemId: "2"}}).
+		Expect(t).
+		Status(http.StatusOK).
+		End()
+	apitest.New().
+		Handler(suite.handler).
+		Patch("/api/item/1").
+		Header("X-API-Key", apiKey).
+		JSON(data.ItemPatch{IsHidden: proto.Bool(true)}).
+		Expect(t).
+		Status(http.StatusOK).
+		End()
+	apitest.New().
+		Handler(suite.handler).
+		Delete("/api/item/2").
+		Header("X-API-Key", apiKey).
+		Expect(t).
+		Status(http.StatusOK).
+		End()
+	apitest.New().
+		Handler(suite.handler).
+		Post("/api/user").
+		Header("X-API-Key", apiKey).
+		JSON(data.User{UserId: "0"}).
+		Expect(t).
+		Status(http.StatusOK).
+		End()
+	apitest.New().
+		Handler(suite.handler).
+		Post("/api/feedback").
+		Header("X-API-Key", apiKey).
+		JSON([]data.Feedback{{FeedbackKey: data.FeedbackKey{FeedbackType: "click", UserId: "0", ItemId: "1"}}}).
+		Expect(t).
+		Status(http.StatusOK).
+		End()
+	apitest.New().
+		Handler(suite.handler).
+		Delete("/api/user/0").
+		Header("X-API-Key", apiKey).
+		Expect(t).
+		Status(http.StatusOK).
+		End()
+
+	events, err = suite.DataClient.GetEvents(ctx, 100)
+	assert.NoError(t, err)
+	assert.Equal(t, []lo.Tuple2[string, string]{
+		{A: data.EventItemUpserted, B: "1"},
+		{A: data.EventItemUpserted, B: "2"},
+		{A: data.EventItemHidden, B: "1"},
+		{A: data.EventItemDeleted, B: "2"},
+		{A: data.EventUserUpserted, B: "0"},
+		{A: data.EventFeedbackInserted, B: "0"},
+		{A: data.EventUserDeleted, B: "0"},
+	}, lo.Map(events, func(event data.Event, _ int) lo.Tuple2[string, string] {
+		return lo.Tuple2[string, string]{A: event.Type, B: event.Key}
+	}))
+	var item data.Item
+	assert.NoError(t, json.Unmarshal([]byte(events[0].Payload), &item))
+	assert.Equal(t, "comment", item.Comment)
+	var feedback data.Feedback
+	assert.NoError(t, json.Unmarshal([]byte(events[5].Payload), &feedback))
+	assert.Equal(t, "1", feedback.ItemId)
+
+	// events aren't written for failed changes
+	apitest.New().
+		Handler(suite.handler).
+		Patch("/api/item/1").
+		Header("X-API-Key", apiKey).
+		JSON(data.ItemPatch{Status: lo.ToPtr(data.StatusRelisted)}).
+		Expect(t).
+		Status(http.StatusBadRequest).
+		End()
+	events, err = suite.DataClient.GetEvents(ctx, 100)
+	assert.NoError(t, err)
+	assert.Len(t, events, 7)
+}
+
+func (suite *ServerTestSuite) TestGetRecommendsImpressions() {
+	ctx := context.Background()
+	t := suite.T()
+	suite.Config.Server.LogImpressions = true
+	suite.Config.Recommend.Online.FallbackRecommend = []string{"latest"}
+	// insert offline recommendation
+	err := suite.CacheClient.AddScores(ctx, cache.OfflineRecommend, "0", []cache.Score{
+		{Id: "1", Score: 99, Categories: []string{""}},
+		{Id: "2", Score: 98, Categories: []string{""}}})
+	assert.NoError(t, err)
+	err = suite.CacheClient.Set(ctx, cache.String(cache.Key(cache.OfflineRecommendPropensity, "0"),
+		`{"": {"1": 0.7, "2": 0.4}}`))
+	assert.NoError(t, err)
+	// insert latest
+	err = suite.CacheClient.AddScores(ctx, cache.NonPersonalized, cache.Latest, []cache.Score{
+		{Id: "2", Score: 95, Categories: []string{""}},
+		{Id: "3", Score: 94, Categories: []string{""}}})
+	assert.NoError(t, err)
+	apitest.New().
+		Handler(suite.handler).
+		Get("/api/recommend/0").
+		Header("X-API-Key", apiKey).
+		QueryParams(map[string]string{
+			"n":      "2",
+			"offset": "1",
+		}).
+		Expect(t).
+		Status(http.StatusOK).
+		Body(suite.marshal([]string{"2", "3"})).
+		End()
+
+	impressionChan, errChan := suite.DataClient.GetImpressionStream(ctx, 10, nil, nil)
+	var impressions []data.Impression
+	for batch := range impressionChan {
+		impressions = append(impressions, batch...)
+	}
+	assert.NoError(t, <-errChan)
+	assert.Len(t, impressions, 2)
+	sort.Slice(impressions, func(i, j int) bool {
+		return impressions[i].Position < impressions[j].Position
+	})
+	assert.NotEmpty(t, impressions[0].RequestId)
+	assert.Equal(t, impressions[0].RequestId, impressions[1].RequestId)
+	assert.Equal(t, data.Impression{RequestId: impressions[0].RequestId, UserId: "0", ItemId: "2", Position: 1,
+		Source: ImpressionSourceOffline, Score: 98, Propensity: 0.4, Timestamp: impressions[0].Timestamp}, impressions[0])
+	assert.Equal(t, data.Impression{RequestId: impressions[0].RequestId, UserId: "0", ItemId: "3", Position: 2,
+		Source: ImpressionSourceLatest, Score: 94, Propensity: 1, Timestamp: impressions[1].Timestamp}, impressions[1])
+}
+
+func (suite *ServerTestSuite) TestSessionRecommendImpressions() {
+	ctx := context.Background()
+	t := suite.T()
+	suite.Config.Server.LogImpressions = true
+	suite.Config.Recommend.DataSource.PositiveFeedbackTypes = []string{"a"}
+	err := suite.CacheClient.AddScores(ctx, cache.ItemNeighbors, "0", []cache.Score{
+		{Id: "1", Score: 2, Categories: []string{""}},
+		{Id: "2", Score: 1, Categories: []string{""}},
+	})
+	assert.NoError(t, err)
+	getImpressions := func() []data.Impression {
+		impressionChan, errChan := suite.DataClient.GetImpressionStream(ctx, 10, nil, nil)
+		var impressions []data.Impression
+		for batch := range impressionChan {
+			impressions = append(impressions, batch...)
+		}
+		assert.NoError(t, <-errChan)
+		sort.Slice(impressions, func(i, j int) bool {
+			return impressions[i].Position < impressions[j].Position
+		})
+		return impressions
+	}
+	// impressions of anonymous sessions are skipped
+	apitest.New().
+		Handler(suite.handler).
+		Post("/api/session/recommend").
+		Header("X-API-Key", apiKey).
+		JSON([]data.Feedback{{FeedbackKey: data.FeedbackKey{FeedbackType: "a", ItemId: "0"}}}).
+		Expect(t).
+		Status(http.StatusOK).
+		End()
+	assert.Empty(t, getImpressions())
+	// impressions of sessions are attributed to the user
+	apitest.New().
+		Handler(suite.handler).
+		Post("/api/session/recommend").
+		Header("X-API-Key", apiKey).
+		JSON([]data.Feedback{{FeedbackKey: data.FeedbackKey{FeedbackType: "a", UserId: "u", ItemId: "0"}}}).
+		Expect(t).
+		Status(http.StatusOK).
+		End()
+	impressions := getImpressions()
+	if assert.Len(t, impressions, 2) {
+		assert.Equal(t, data.Impression{RequestId: impressions[0].RequestId, UserId: "u", ItemId: "1", Position: 0,
+			Source: ImpressionSourceSession, Score: 2, Propensity: 1, Timestamp: impressions[0].Timestamp}, impressions[0])
+		assert.Equal(t, data.Impression{RequestId: impressions[0].RequestId, UserId: "u", ItemId: "2", Position: 1,
+			Source: ImpressionSourceSession, Score: 1, Propensity: 1, Timestamp: impressions[1].Timestamp}, impressions[1])
+	}
+}
+
+func (suite *ServerTestSuite) TestGetRecommendsWithContext() {
+	ctx := context.Background()
+	t := suite.T()
+	suite.Config.Recommend.Offline.EnableClickThroughPrediction = true
+	// insert user, items and offline recommendation
+	err := suite.DataClient.BatchInsertUsers(ctx, []data.User{{UserId: "0"}})
+	assert.NoError(t, err)
+	err = suite.DataClient.BatchInsertItems(ctx, []data.Item{
+		{ItemId: "1", Labels: []any{"a"}},
+		{ItemId: "2", Labels: []any{"b"}},
+	})
+	assert.NoError(t, err)
+	err = suite.CacheClient.AddScores(ctx, cache.OfflineRecommend, "0", []cache.Score{
+		{Id: "1", Score: 99, Categories: []string{""}},
+		{Id: "2", Score: 98, Categories: []string{""}}})
+	assert.NoError(t, err)
+	// create factorization machine: mobile users prefer items labeled "b"
+	builder := click.NewUnifiedMapIndexBuilder()
+	builder.AddUser("0")
+	builder.AddItem("1")
+	builder.AddItem("2")
+	builder.AddItemLabel("a")
+	builder.AddItemLabel("b")
+	builder.AddCtxLabel("device.mobile")
+	fm := click.NewFM(click.FMClassification, model.Params{model.NFactors: 2})
+	fm.Index = builder.Build()
+	fm.W = []float32{0, 0, 0, 0, 0, 0}
+	fm.V = [][]float32{{0, 0}, {0, 0}, {0, 0}, {1, 0}, {0, 1}, {0, 1}}
+	suite.StoreClickModel(fm, 1)
+
+	// offline recommendation is served as is without context
+	apitest.New().
+		Handler(suite.handler).
+		Get("/api/recommend/0").
+		Header("X-API-Key", apiKey).
+		Expect(t).
+		Status(http.StatusOK).
+		Body(suite.marshal([]string{"1", "2"})).
+		End()
+	// candidates beyond the margin keep their offline order
+	suite.Config.Recommend.Offline.ClickThroughMargin = 0
+	apitest.New().
+		Handler(suite.handler).
+		Get("/api/recommend/0").
+		Header("X-API-Key", apiKey).
+		QueryParams(map[string]string{
+			"context": `{"device":"mobile"}`,
+			"n":       "1",
+		}).
+		Expect(t).
+		Status(http.StatusOK).
+		Body(suite.marshal([]string{"1"})).
+		End()
+	suite.Config.Recommend.Offline.ClickThroughMargin = 1
+	// offline recommendation is re-ranked with context
+	apitest.New().
+		Handler(suite.handler).
+		Get("/api/recommend/0").
+		Header("X-API-Key", apiKey).
+		QueryParams(map[string]string{
+			"context":         `{"device":"mobile"}`,
+			"write-back-type": "read",
+			"n":               "1",
+		}).
+		Expect(t).
+		Status(http.StatusOK).
+		Body(suite.marshal([]string{"2"})).
+		End()
+	// context is written back with feedback
+	feedback, err := suite.DataClient.GetUserFeedback(ctx, "0", suite.Config.Now(), "read")
+	assert.NoError(t, err)
+	if assert.Len(t, feedback, 1) {
+		assert.Equal(t, "2", feedback[0].ItemId)
+		assert.Equal(t, map[string]string{"device": "mobile"}, feedback[0].Context)
+	}
+	// invalid context
+	apitest.New().
+		Handler(suite.handler).
+		Get("/api/recommend/0").
+		Header("X-API-Key", apiKey).
+		QueryParams(map[string]string{"context": "{"}).
+		Expect(t).
+		Status(http.StatusBadRequest).
+		End()
+}
+
 func (suite *ServerTestSuite) TestSessionRecommend() {
 	ctx := context.Background()
 	t := suite.T()
@@ -1642,6 +2173,87 @@ func (suite *ServerTestSuite) TestSessionRecommend() {
 		End()
 }
 
+func (suite *ServerTestSuite) TestSessionRecommendByModel() {
+	ctx := context.Background()
+	t := suite.T()
+	suite.Config.Recommend.DataSource.PositiveFeedbackTypes = []string{"a"}
+
+	// train session model on items walking through a cycle
+	dataset := session.NewDataSet()
+	for i := 0; i < 200; i++ {
+		itemIds := make([]string, 5+i%5)
+		for j := range itemIds {
+			itemIds[j] = strconv.Itoa((i + j) % 10)
+		}
+		dataset.AddSequence(itemIds...)
+	}
+	trainSet, testSet := dataset.Split(20, 0)
+	sessionModel := session.NewSASRec(model.Params{model.NEpochs: 10, model.MaxLength: 10, model.Lr: 0.01})
+	sessionModel.Fit(ctx, trainSet, testSet, ranking.NewFitConfig())
+	suite.StoreSessionModel(sessionModel, 1)
+
+	// insert items: even items belong to category "a" and item 7 is hidden
+	for i := 0; i < 10; i++ {
+		item := data.Item{ItemId: strconv.Itoa(i), IsHidden: i == 7}
+		if i%2 == 0 {
+			item.Categories = []string{"a"}
+		}
+		err := suite.DataClient.BatchInsertItems(ctx, []data.Item{item})
+		assert.NoError(t, err)
+	}
+
+	feedback := []data.Feedback{
+		{FeedbackKey: data.FeedbackKey{FeedbackType: "a", UserId: "0", ItemId: "5"}, Timestamp: time.Date(2010, 1, 1, 1, 1, 1, 1, time.UTC)},
+		{FeedbackKey: data.FeedbackKey{FeedbackType: "a", UserId: "0", ItemId: "4"}, Timestamp: time.Date(2009, 1, 1, 1, 1, 1, 1, time.UTC)},
+		{FeedbackKey: data.FeedbackKey{FeedbackType: "a", UserId: "0", ItemId: "3"}, Timestamp: time.Date(2008, 1, 1, 1, 1, 1, 1, time.UTC)},
+	}
+	recommend := func(url string, feedback []data.Feedback) []cache.Score {
+		var scores []cache.Score
+		apitest.New().
+			Handler(suite.handler).
+			Post(url).
+			Header("X-API-Key", apiKey).
+			QueryParams(map[string]string{
+				"n": "3",
+			}).
+			JSON(feedback).
+			Expect(t).
+			Status(http.StatusOK).
+			Assert(func(response *http.Response, _ *http.Request) error {
+				return json.NewDecoder(response.Body).Decode(&scores)
+			}).
+			End()
+		return scores
+	}
+	// the next item in the cycle is recommended
+	scores := recommend("/api/session/recommend", feedback)
+	assert.Len(t, scores, 3)
+	assert.Equal(t, "6", scores[0].Id)
+	for _, score := range scores {
+		assert.NotContains(t, []string{"3", "4", "5", "7"}, score.Id)
+	}
+	// recommend items in category
+	scores = recommend("/api/session/recommend/a", feedback)
+	assert.Len(t, scores, 3)
+	assert.Equal(t, "6", scores[0].Id)
+	for _, score := range scores {
+		id, err := strconv.Atoi(score.Id)
+		assert.NoError(t, err)
+		assert.Zero(t, id%2)
+	}
+
+	// fall back to item-based recommendation for unknown items
+	err := suite.CacheClient.AddScores(ctx, cache.ItemNeighbors, "100", []cache.Score{
+		{Id: "1", Score: 2, Categories: []string{""}},
+		{Id: "2", Score: 1, Categories: []string{""}},
+	})
+	assert.NoError(t, err)
+	scores = recommend("/api/session/recommend", []data.Feedback{
+		{FeedbackKey: data.FeedbackKey{FeedbackType: "a", UserId: "0", ItemId: "100"}, Timestamp: time.Date(2010, 1, 1, 1, 1, 1, 1, time.UTC)},
+	})
+	assert.Equal(t, []string{"1", "2"}, lo.Map(scores, func(score cache.Score, _ int) string { return score.Id }))
+}
+
 func (suite *ServerTestSuite) TestVisibility() {
 	ctx := context.Background()
 	t := suite.T()
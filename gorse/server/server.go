@@ -15,11 +15,14 @@
 package server
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
 	"math/rand"
+	"net/http"
 	"os"
+	"reflect"
 	"strings"
 	"time"
 
@@ -28,8 +31,10 @@ import (
 	"github.com/juju/errors"
 	"github.com/samber/lo"
 	"github.com/zhenghaoz/gorse/base"
+	"github.com/zhenghaoz/gorse/base/encoding"
 	"github.com/zhenghaoz/gorse/base/log"
 	"github.com/zhenghaoz/gorse/cmd/version"
+	encoding2 "github.com/zhenghaoz/gorse/common/encoding"
 	"github.com/zhenghaoz/gorse/common/util"
 	"github.com/zhenghaoz/gorse/config"
 	"github.com/zhenghaoz/gorse/protocol"
@@ -59,6 +64,17 @@ type Server struct {
 	tlsConfig    *util.TLSConfig
 	testMode     bool
 	cacheFile    string
+	tenants      map[string]*tenantServer
+}
+
+// tenantServer is the REST server of a tenant and the stores it connects to.
+type tenantServer struct {
+	*RestServer
+	handler    http.Handler
+	dataStore  string
+	cacheStore string
+	tenant     config.TenantConfig
+	config     []byte // JSON encoded configuration of the tenant
 }
 
 // NewServer creates a server node.
@@ -80,7 +96,9 @@ func NewServer(
 			HttpHost:   serverHost,
 			HttpPort:   serverPort,
 			WebService: new(restful.WebService),
+			Tenants:    NewTenantRouter(),
 		},
+		tenants: make(map[string]*tenantServer),
 	}
 	return s
 }
@@ -166,6 +184,7 @@ func (s *Server) Sync() {
 			log.Logger().Error("failed to parse master config", zap.Error(err))
 			goto sleep
 		}
+		s.Config.ResetExperimentConfigs()
 
 		// connect to data store
 		if s.dataPath != s.Config.Database.DataStore || s.dataPrefix != s.Config.Database.DataTablePrefix {
@@ -175,7 +194,8 @@ func (s *Server) Sync() {
 			} else {
 				log.Logger().Info("connect data store",
 					zap.String("database", log.RedactDBURL(s.Config.Database.DataStore)))
-				if s.DataClient, err = data.Open(s.Config.Database.DataStore, s.Config.Database.DataTablePrefix); err != nil {
+				if s.DataClient, err = data.Open(s.Config.Database.DataStore, s.Config.Database.DataTablePrefix,
+					storage.WithIndexedLabels(s.Config.Database.MySQL.IndexedLabels)); err != nil {
 					log.Logger().Error("failed to connect data store", zap.Error(err))
 					goto sleep
 				}
@@ -201,6 +221,9 @@ func (s *Server) Sync() {
 			s.cachePrefix = s.Config.Database.CacheTablePrefix
 		}
 
+		// connect to stores of tenants
+		s.syncTenants()
+
 		// create trace provider
 		if !s.traceConfig.Equal(s.Config.Tracing) {
 			log.Logger().Info("create trace provider", zap.Any("tracing_config", s.Config.Tracing))
@@ -214,6 +237,12 @@ func (s *Server) Sync() {
 			s.traceConfig = s.Config.Tracing
 		}
 
+		// pull session model
+		s.syncSessionModel(meta.SessionModelVersion)
+
+		// pull click model
+		s.syncClickModel(meta.ClickModelVersion)
+
 	sleep:
 		if s.testMode {
 			return
@@ -221,3 +250,123 @@ func (s *Server) Sync() {
 		time.Sleep(s.Config.Master.MetaTimeout)
 	}
 }
+
+// syncSessionModel pulls the session model from the master if its version changed.
+func (s *Server) syncSessionModel(latestVersion int64) {
+	_, currentVersion := s.LoadSessionModel()
+	if latestVersion == 0 || latestVersion == currentVersion {
+		return
+	}
+	log.Logger().Info("new session model found",
+		zap.String("old_version", encoding.Hex(currentVersion)),
+		zap.String("new_version", encoding.Hex(latestVersion)))
+	receiver, err := s.masterClient.GetSessionModel(context.Background(), &protocol.VersionInfo{Version: latestVersion})
+	if err != nil {
+		log.Logger().Error("failed to pull session model", zap.Error(err))
+		return
+	}
+	sessionModel, err := encoding2.UnmarshalSessionModel(receiver)
+	if err != nil {
+		log.Logger().Error("failed to unmarshal session model", zap.Error(err))
+		return
+	}
+	s.StoreSessionModel(sessionModel, latestVersion)
+	log.Logger().Info("synced session model", zap.String("version", encoding.Hex(latestVersion)))
+}
+
+// syncClickModel pulls the click model from the master if its version changed.
+func (s *Server) syncClickModel(latestVersion int64) {
+	_, currentVersion := s.LoadClickModel()
+	if latestVersion == 0 || latestVersion == currentVersion {
+		return
+	}
+	log.Logger().Info("new click model found",
+		zap.String("old_version", encoding.Hex(currentVersion)),
+		zap.String("new_version", encoding.Hex(latestVersion)))
+	receiver, err := s.masterClient.GetClickModel(context.Background(), &protocol.VersionInfo{Version: latestVersion})
+	if err != nil {
+		log.Logger().Error("failed to pull click model", zap.Error(err))
+		return
+	}
+	clickModel, err := encoding2.UnmarshalClickModel(receiver)
+	if err != nil {
+		log.Logger().Error("failed to unmarshal click model", zap.Error(err))
+		return
+	}
+	s.StoreClickModel(clickModel, latestVersion)
+	log.Logger().Info("synced click model", zap.String("version", encoding.Hex(latestVersion)))
+}
+
+// syncTenants connects to stores of tenants and updates configurations of tenants. The configuration of a tenant is
+// read by requests being served, so it is never modified in place. Instead, a REST server with the new configuration
+// replaces the REST server of the tenant.
+func (s *Server) syncTenants() {
+	for _, tenant := range s.Config.Tenants {
+		cfg, err := s.Config.ForTenant(tenant)
+		if err != nil {
+			log.Logger().Error("failed to create tenant config", zap.String("tenant", tenant.Name), zap.Error(err))
+			continue
+		}
+		cfgJSON, err := json.Marshal(cfg)
+		if err != nil {
+			log.Logger().Error("failed to encode tenant config", zap.String("tenant", tenant.Name), zap.Error(err))
+			continue
+		}
+		ts, exist := s.tenants[tenant.Name]
+		if exist && ts.dataStore == cfg.Database.DataStore && ts.cacheStore == cfg.Database.CacheStore &&
+			ts.Config.Database.TablePrefix == cfg.Database.TablePrefix {
+			if !bytes.Equal(ts.config, cfgJSON) || !reflect.DeepEqual(ts.tenant, tenant) {
+				s.serveTenant(tenant, &config.Settings{Config: cfg, DataClient: ts.DataClient, CacheClient: ts.CacheClient}, cfgJSON)
+			}
+			continue
+		}
+		settings := &config.Settings{Config: cfg}
+		log.Logger().Info("connect stores of tenant", zap.String("tenant", tenant.Name))
+		if settings.DataClient, err = data.Open(cfg.Database.DataStore, cfg.Database.DataTablePrefix,
+			storage.WithIndexedLabels(cfg.Database.MySQL.IndexedLabels)); err != nil {
+			log.Logger().Error("failed to connect data store", zap.String("tenant", tenant.Name), zap.Error(err))
+			continue
+		}
+		if settings.CacheClient, err = cache.Open(cfg.Database.CacheStore, cfg.Database.CacheTablePrefix); err != nil {
+			log.Logger().Error("failed to connect cache store", zap.String("tenant", tenant.Name), zap.Error(err))
+			_ = settings.DataClient.Close()
+			continue
+		}
+		if exist {
+			s.closeTenant(ts)
+		}
+		s.serveTenant(tenant, settings, cfgJSON)
+	}
+	// remove deleted tenants
+	for name, ts := range s.tenants {
+		if _, exist := s.Config.GetTenant(name); !exist {
+			s.Tenants.Remove(name)
+			s.closeTenant(ts)
+			delete(s.tenants, name)
+		}
+	}
+}
+
+// serveTenant creates the REST server of a tenant and routes requests of the tenant to it.
+func (s *Server) serveTenant(tenant config.TenantConfig, settings *config.Settings, cfgJSON []byte) {
+	restServer, handler := NewTenantServer(settings, tenant)
+	restServer.DisableLog = s.DisableLog
+	s.tenants[tenant.Name] = &tenantServer{
+		RestServer: restServer,
+		handler:    handler,
+		dataStore:  settings.Config.Database.DataStore,
+		cacheStore: settings.Config.Database.CacheStore,
+		tenant:     tenant,
+		config:     cfgJSON,
+	}
+	s.Tenants.Set(tenant, handler)
+}
+
+func (s *Server) closeTenant(ts *tenantServer) {
+	if err := ts.DataClient.Close(); err != nil {
+		log.Logger().Error("failed to close data store", zap.Error(err))
+	}
+	if err := ts.CacheClient.Close(); err != nil {
+		log.Logger().Error("failed to close cache store", zap.Error(err))
+	}
+}
@@ -0,0 +1,122 @@
+// Copyright 2024 gorse Project Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+
+	"github.com/emicklei/go-restful/v3"
+	"github.com/samber/lo"
+	"github.com/zhenghaoz/gorse/config"
+)
+
+const (
+	// HeaderTenant is the header to select a tenant.
+	HeaderTenant = "X-Gorse-Tenant"
+	// CookieTenant is the cookie to select a tenant in the dashboard.
+	CookieTenant = "tenant"
+)
+
+// TenantRouter dispatches requests to handlers of tenants. A tenant is selected by the X-Gorse-Tenant header, the
+// tenant cookie or the API key of the tenant. Requests without tenant are handled by the default handler.
+type TenantRouter struct {
+	Default  http.Handler
+	mutex    sync.RWMutex
+	handlers map[string]http.Handler
+	apiKeys  map[string]string
+}
+
+// NewTenantRouter creates a router without tenants.
+func NewTenantRouter() *TenantRouter {
+	return &TenantRouter{
+		handlers: make(map[string]http.Handler),
+		apiKeys:  make(map[string]string),
+	}
+}
+
+// Set the handler of a tenant.
+func (r *TenantRouter) Set(tenant config.TenantConfig, handler http.Handler) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	for apiKey, name := range r.apiKeys {
+		if name == tenant.Name {
+			delete(r.apiKeys, apiKey)
+		}
+	}
+	r.handlers[tenant.Name] = handler
+	if tenant.APIKey != "" {
+		r.apiKeys[tenant.APIKey] = tenant.Name
+	}
+}
+
+// Remove the handler of a tenant.
+func (r *TenantRouter) Remove(name string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	for apiKey, tenant := range r.apiKeys {
+		if tenant == name {
+			delete(r.apiKeys, apiKey)
+		}
+	}
+	delete(r.handlers, name)
+}
+
+// Tenants returns names of tenants in order.
+func (r *TenantRouter) Tenants() []string {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	names := lo.Keys(r.handlers)
+	sort.Strings(names)
+	return names
+}
+
+func (r *TenantRouter) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	name := req.Header.Get(HeaderTenant)
+	if name == "" {
+		if cookie, err := req.Cookie(CookieTenant); err == nil {
+			name = cookie.Value
+		}
+	}
+	r.mutex.RLock()
+	if name == "" {
+		name = r.apiKeys[req.Header.Get("X-API-Key")]
+	}
+	handler, exist := r.handlers[name]
+	r.mutex.RUnlock()
+	if name == "" {
+		r.Default.ServeHTTP(w, req)
+	} else if exist {
+		handler.ServeHTTP(w, req)
+	} else {
+		http.Error(w, fmt.Sprintf("tenant %s not found", name), http.StatusNotFound)
+	}
+}
+
+// NewTenantServer creates the REST server of a tenant, which uses the configuration and database clients of the
+// tenant. The handler of the tenant serves REST APIs only.
+func NewTenantServer(settings *config.Settings, tenant config.TenantConfig) (*RestServer, http.Handler) {
+	s := &RestServer{
+		Settings:     settings,
+		WebService:   new(restful.WebService),
+		TenantAPIKey: tenant.APIKey,
+	}
+	s.CreateWebService()
+	container := restful.NewContainer()
+	container.Add(s.WebService)
+	return s, container
+}
@@ -0,0 +1,174 @@
+// Copyright 2024 gorse Project Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/emicklei/go-restful/v3"
+	"github.com/juju/errors"
+	"github.com/steinfletcher/apitest"
+	"github.com/stretchr/testify/assert"
+	"github.com/zhenghaoz/gorse/config"
+	"github.com/zhenghaoz/gorse/storage/cache"
+	"github.com/zhenghaoz/gorse/storage/data"
+)
+
+func TestTenantRouter(t *testing.T) {
+	ctx := context.Background()
+	cfg := config.GetDefaultConfig()
+	cfg.Server.APIKey = apiKey
+	cfg.Tenants = []config.TenantConfig{{Name: "eu", APIKey: "eu_api_key", TablePrefix: "eu_"}}
+
+	// create the server of the cluster
+	settings := &config.Settings{Config: cfg}
+	var err error
+	settings.DataClient, err = data.Open(fmt.Sprintf("sqlite://%s/data.db", t.TempDir()), "")
+	assert.NoError(t, err)
+	assert.NoError(t, settings.DataClient.Init())
+	defer settings.DataClient.Close()
+	settings.CacheClient, err = cache.Open(fmt.Sprintf("sqlite://%s/cache.db", t.TempDir()), "")
+	assert.NoError(t, err)
+	assert.NoError(t, settings.CacheClient.Init())
+	defer settings.CacheClient.Close()
+	s := &RestServer{Settings: settings, WebService: new(restful.WebService)}
+	s.CreateWebService()
+	container := restful.NewContainer()
+	container.Add(s.WebService)
+
+	// create the server of the tenant
+	tenantConfig, err := cfg.ForTenant(cfg.Tenants[0])
+	assert.NoError(t, err)
+	tenantSettings := &config.Settings{Config: tenantConfig}
+	// index names aren't prefixed in SQLite so the tenant uses another database
+	tenantSettings.DataClient, err = data.Open(fmt.Sprintf("sqlite://%s/data.db", t.TempDir()), tenantConfig.Database.DataTablePrefix)
+	assert.NoError(t, err)
+	assert.NoError(t, tenantSettings.DataClient.Init())
+	defer tenantSettings.DataClient.Close()
+	tenantSettings.CacheClient, err = cache.Open(fmt.Sprintf("sqlite://%s/cache.db", t.TempDir()), tenantConfig.Database.CacheTablePrefix)
+	assert.NoError(t, err)
+	assert.NoError(t, tenantSettings.CacheClient.Init())
+	defer tenantSettings.CacheClient.Close()
+	_, tenantHandler := NewTenantServer(tenantSettings, cfg.Tenants[0])
+
+	router := NewTenantRouter()
+	router.Default = container
+	router.Set(cfg.Tenants[0], tenantHandler)
+	assert.Equal(t, []string{"eu"}, router.Tenants())
+
+	// insert an item for the tenant by the API key of the tenant
+	apitest.New().
+		Handler(router).
+		Post("/api/item").
+		Header("X-API-Key", "eu_api_key").
+		JSON(Item{ItemId: "eu"}).
+		Expect(t).
+		Status(http.StatusOK).
+		End()
+	// insert an item for the tenant by the header with the global API key
+	apitest.New().
+		Handler(router).
+		Post("/api/item").
+		Header("X-API-Key", apiKey).
+		Header(HeaderTenant, "eu").
+		JSON(Item{ItemId: "eu2"}).
+		Expect(t).
+		Status(http.StatusOK).
+		End()
+	// insert an item for the cluster
+	apitest.New().
+		Handler(router).
+		Post("/api/item").
+		Header("X-API-Key", apiKey).
+		JSON(Item{ItemId: "global"}).
+		Expect(t).
+		Status(http.StatusOK).
+		End()
+	// the API key of a tenant is invalid for the cluster
+	apitest.New().
+		Handler(container).
+		Post("/api/item").
+		Header("X-API-Key", "eu_api_key").
+		JSON(Item{ItemId: "global"}).
+		Expect(t).
+		Status(http.StatusUnauthorized).
+		End()
+	// unknown tenant
+	apitest.New().
+		Handler(router).
+		Get("/api/item/eu").
+		Header("X-API-Key", apiKey).
+		Header(HeaderTenant, "us").
+		Expect(t).
+		Status(http.StatusNotFound).
+		End()
+
+	// items are isolated
+	_, err = tenantSettings.DataClient.GetItem(ctx, "eu")
+	assert.NoError(t, err)
+	_, err = tenantSettings.DataClient.GetItem(ctx, "eu2")
+	assert.NoError(t, err)
+	_, err = tenantSettings.DataClient.GetItem(ctx, "global")
+	assert.True(t, errors.Is(err, errors.NotFound))
+	_, err = settings.DataClient.GetItem(ctx, "global")
+	assert.NoError(t, err)
+	_, err = settings.DataClient.GetItem(ctx, "eu")
+	assert.True(t, errors.Is(err, errors.NotFound))
+
+	// remove the tenant
+	router.Remove("eu")
+	assert.Empty(t, router.Tenants())
+	apitest.New().
+		Handler(router).
+		Get("/api/item/eu").
+		Header("X-API-Key", "eu_api_key").
+		Expect(t).
+		Status(http.StatusUnauthorized).
+		End()
+}
+
+func TestServer_SyncTenants(t *testing.T) {
+	s := NewServer("", 0, "", 0, "", nil)
+	s.Config.Database.DataStore = fmt.Sprintf("sqlite://%s/data.db", t.TempDir())
+	s.Config.Database.CacheStore = fmt.Sprintf("sqlite://%s/cache.db", t.TempDir())
+	s.Config.Tenants = []config.TenantConfig{{Name: "eu", APIKey: "eu_api_key", TablePrefix: "eu_"}}
+	s.syncTenants()
+	ts, exist := s.tenants["eu"]
+	if !assert.True(t, exist) {
+		return
+	}
+	defer func() { s.closeTenant(s.tenants["eu"]) }()
+	assert.Equal(t, 100, ts.Config.Recommend.CacheSize)
+
+	// the server of the tenant is kept if the configuration isn't changed
+	s.syncTenants()
+	assert.Same(t, ts, s.tenants["eu"])
+	// the server of the tenant is replaced if the configuration is changed, and the configuration in use is untouched
+	s.Config.Tenants[0].Override = map[string]any{"cache_size": 200}
+	s.syncTenants()
+	updated := s.tenants["eu"]
+	assert.NotSame(t, ts, updated)
+	assert.Equal(t, 100, ts.Config.Recommend.CacheSize)
+	assert.Equal(t, 200, updated.Config.Recommend.CacheSize)
+	assert.Same(t, ts.DataClient, updated.DataClient)
+	// the API key of the tenant is updated
+	s.Config.Tenants[0].APIKey = "eu_api_key_2"
+	s.syncTenants()
+	assert.Equal(t, "eu_api_key_2", s.tenants["eu"].TenantAPIKey)
+	assert.Equal(t, "eu_api_key", updated.TenantAPIKey)
+}
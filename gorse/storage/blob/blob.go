@@ -18,6 +18,7 @@ import (
 	"context"
 	"fmt"
 	"github.com/juju/errors"
+	"github.com/zhenghaoz/gorse/base/encoding"
 	"github.com/zhenghaoz/gorse/base/log"
 	"github.com/zhenghaoz/gorse/protocol"
 	"go.uber.org/zap"
@@ -140,6 +141,57 @@ func (s *MasterStoreServer) DownloadBlob(request *protocol.DownloadBlobRequest,
 	return nil
 }
 
+// WriteBlob writes a blob on the master. Data is written to a temporary file before renamed to the blob, so that
+// the blob is never partially written.
+func (s *MasterStoreServer) WriteBlob(name string, write func(w io.Writer) error) error {
+	file, err := os.CreateTemp(s.dir, "write-*")
+	if err != nil {
+		return err
+	}
+	defer func(file *os.File) {
+		_ = file.Close()
+		_ = os.Remove(file.Name())
+	}(file)
+	if err = write(file); err != nil {
+		return err
+	}
+	if err = file.Close(); err != nil {
+		return err
+	}
+	return os.Rename(file.Name(), path.Join(s.dir, name))
+}
+
+// ReadBlob reads a blob on the master.
+func (s *MasterStoreServer) ReadBlob(name string, read func(r io.Reader) error) error {
+	file, err := os.Open(path.Join(s.dir, name))
+	if err != nil {
+		return err
+	}
+	defer func(file *os.File) {
+		err = file.Close()
+		if err != nil {
+			log.Logger().Error("failed to close file", zap.Error(err))
+		}
+	}(file)
+	return read(file)
+}
+
+// RemoveBlob removes a blob on the master.
+func (s *MasterStoreServer) RemoveBlob(name string) error {
+	return os.Remove(path.Join(s.dir, name))
+}
+
+// Types of models stored in blobs.
+const (
+	RankingModelType = "ranking"
+	ClickModelType   = "click"
+)
+
+// ModelBlobName returns the name of the blob storing a version of a model.
+func ModelBlobName(modelType string, version int64) string {
+	return fmt.Sprintf("%s-%s", modelType, encoding.Hex(version))
+}
+
 type MasterStoreClient struct {
 	client protocol.BlobStoreClient
 }
@@ -15,9 +15,11 @@
 package blob
 
 import (
+	"bytes"
 	"github.com/stretchr/testify/assert"
 	"github.com/zhenghaoz/gorse/protocol"
 	"google.golang.org/grpc"
+	"io"
 	"net"
 	"os"
 	"path"
@@ -65,3 +67,30 @@ func TestBlob(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, "hello world", string(downloadContent))
 }
+
+func TestMasterStoreServer(t *testing.T) {
+	server := NewMasterStoreServer(path.Join(t.TempDir(), "blob"))
+
+	// write blob
+	err := server.WriteBlob("test", func(w io.Writer) error {
+		_, err := w.Write([]byte("hello world"))
+		return err
+	})
+	assert.NoError(t, err)
+
+	// read blob
+	var buf bytes.Buffer
+	err = server.ReadBlob("test", func(r io.Reader) error {
+		_, err := io.Copy(&buf, r)
+		return err
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "hello world", buf.String())
+
+	// remove blob
+	err = server.RemoveBlob("test")
+	assert.NoError(t, err)
+	err = server.ReadBlob("test", func(r io.Reader) error { return nil })
+	assert.ErrorIs(t, err, os.ErrNotExist)
+	assert.Equal(t, "ranking-ff", ModelBlobName("ranking", 255))
+}
@@ -77,6 +77,11 @@ const (
 	//	Recommendation digest      - offline_recommend_digest/{user_id}
 	OfflineRecommendDigest = "offline_recommend_digest"
 
+	// OfflineRecommendPropensity is the JSON encoded probabilities that items of offline recommendation are picked
+	// by exploration or exploitation, which are grouped by categories.
+	//	Recommendation propensity  - offline_recommend_propensity/{user_id}
+	OfflineRecommendPropensity = "offline_recommend_propensity"
+
 	NonPersonalized = "non-personalized"
 	Latest          = "latest"
 	Popular         = "popular"
@@ -85,6 +90,38 @@ const (
 	//	Global item categories - item_categories
 	ItemCategories = "item_categories"
 
+	// RefreshUsers is the queue of users waiting for real-time refresh of offline recommendation.
+	//  Refresh users - refresh_users
+	RefreshUsers = "refresh_users"
+
+	// Decks is the JSON encoded state of swipe decks.
+	//  Deck - decks/{cursor}
+	Decks = "decks"
+
+	// UserDecks is the set of cursors of swipe decks created by users.
+	//  User decks - user_decks/{user_id}
+	UserDecks = "user_decks"
+
+	// Onboarding is the JSON encoded onboarding profile of users.
+	//  Onboarding - onboarding/{user_id}
+	Onboarding = "onboarding"
+
+	// Erasures is the audit log of erased users. The score is the timestamp of erasure.
+	//  Erasures - erasures
+	Erasures = "erasures"
+
+	// EventDelivered is the set of IDs of pending change events delivered to a webhook.
+	//  Event delivered - event_delivered/{webhook}
+	EventDelivered = "event_delivered"
+
+	// EventFailures is the number of failed polls to deliver a pending change event to a webhook.
+	//  Event failures - event_failures/{webhook}/{event_id}
+	EventFailures = "event_failures"
+
+	// EventDeadLetters is the queue of messages of change events failed to be delivered to a webhook.
+	//  Event dead letters - event_dead_letters/{webhook}
+	EventDeadLetters = "event_dead_letters"
+
 	LastModifyItemTime          = "last_modify_item_time"           // the latest timestamp that a user related data was modified
 	LastModifyUserTime          = "last_modify_user_time"           // the latest timestamp that an item related data was modified
 	LastUpdateUserRecommendTime = "last_update_user_recommend_time" // the latest timestamp that a user's recommendation was updated
@@ -108,6 +145,10 @@ const (
 	UserNeighborIndexRecall    = "user_neighbor_index_recall"
 	ItemNeighborIndexRecall    = "item_neighbor_index_recall"
 	MatchingIndexRecall        = "matching_index_recall"
+
+	// Experiments is the JSON encoded list of experiments managed by the master API.
+	//  Experiments - global_meta/experiments
+	Experiments = "experiments"
 )
 
 var ItemCache = []string{NonPersonalized, ItemNeighbors, OfflineRecommend}
@@ -275,6 +316,9 @@ type Database interface {
 	Set(ctx context.Context, values ...Value) error
 	Get(ctx context.Context, name string) *ReturnValue
 	Delete(ctx context.Context, name string) error
+	// CompareAndSet sets a value only if the current value equals the old value. It returns false if the value
+	// doesn't exist or has been modified. The new value must differ from the old value.
+	CompareAndSet(ctx context.Context, name, oldValue, newValue string) (bool, error)
 
 	GetSet(ctx context.Context, key string) ([]string, error)
 	SetSet(ctx context.Context, key string, members ...string) error
@@ -283,10 +327,13 @@ type Database interface {
 
 	Push(ctx context.Context, name, value string) error
 	Pop(ctx context.Context, name string) (string, error)
+	PopBefore(ctx context.Context, name string, timestamp time.Time) (string, error)
 	Remain(ctx context.Context, name string) (int64, error)
+	Remove(ctx context.Context, name, value string) error
 
 	AddScores(ctx context.Context, collection, subset string, documents []Score) error
 	SearchScores(ctx context.Context, collection, subset string, query []string, begin, end int) ([]Score, error)
+	ScanScores(ctx context.Context, callback func(collection, subset string, document Score) error) error
 	DeleteScores(ctx context.Context, collection []string, condition ScoreCondition) error
 	UpdateScores(ctx context.Context, collections []string, subset *string, id string, patch ScorePatch) error
 
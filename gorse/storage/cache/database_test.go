@@ -141,6 +141,25 @@ func (suite *baseTestSuite) TestSet() {
 	suite.NoError(err)
 }
 
+func (suite *baseTestSuite) TestCompareAndSet() {
+	ctx := context.Background()
+	swapped, err := suite.CompareAndSet(ctx, "cas", "", "1")
+	suite.NoError(err)
+	suite.False(swapped)
+	err = suite.Set(ctx, String("cas", "1"))
+	suite.NoError(err)
+	swapped, err = suite.CompareAndSet(ctx, "cas", "1", "2")
+	suite.NoError(err)
+	suite.True(swapped)
+	// the value has been modified since it was read
+	swapped, err = suite.CompareAndSet(ctx, "cas", "1", "3")
+	suite.NoError(err)
+	suite.False(swapped)
+	value, err := suite.Get(ctx, "cas").String()
+	suite.NoError(err)
+	suite.Equal("2", value)
+}
+
 func (suite *baseTestSuite) TestScan() {
 	ctx := context.Background()
 	err := suite.Database.Set(ctx, String("1", "1"))
@@ -225,6 +244,54 @@ func (suite *baseTestSuite) TestPushPop() {
 	suite.ErrorIs(err, io.EOF)
 }
 
+func (suite *baseTestSuite) TestPopBefore() {
+	ctx := context.Background()
+	err := suite.Push(ctx, "d", "1")
+	suite.NoError(err)
+	err = suite.Push(ctx, "d", "2")
+	suite.NoError(err)
+	timestamp := time.Now()
+	time.Sleep(time.Millisecond)
+	err = suite.Push(ctx, "d", "3")
+	suite.NoError(err)
+	// pushing again moves a value after the timestamp
+	err = suite.Push(ctx, "d", "1")
+	suite.NoError(err)
+
+	value, err := suite.PopBefore(ctx, "d", timestamp)
+	suite.NoError(err)
+	suite.Equal("2", value)
+	_, err = suite.PopBefore(ctx, "d", timestamp)
+	suite.ErrorIs(err, io.EOF)
+	count, err := suite.Remain(ctx, "d")
+	suite.NoError(err)
+	suite.Equal(int64(2), count)
+	value, err = suite.PopBefore(ctx, "d", time.Now())
+	suite.NoError(err)
+	suite.Equal("3", value)
+}
+
+func (suite *baseTestSuite) TestRemove() {
+	ctx := context.Background()
+	for _, value := range []string{"1", "2", "3"} {
+		err := suite.Push(ctx, "c", value)
+		suite.NoError(err)
+	}
+	err := suite.Remove(ctx, "c", "2")
+	suite.NoError(err)
+	err = suite.Remove(ctx, "c", "4")
+	suite.NoError(err)
+	count, err := suite.Remain(ctx, "c")
+	suite.NoError(err)
+	suite.Equal(int64(2), count)
+	value, err := suite.Pop(ctx, "c")
+	suite.NoError(err)
+	suite.Equal("1", value)
+	value, err = suite.Pop(ctx, "c")
+	suite.NoError(err)
+	suite.Equal("3", value)
+}
+
 func (suite *baseTestSuite) TestDocument() {
 	ts := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
 	ctx := context.Background()
@@ -462,6 +529,36 @@ func (suite *baseTestSuite) TestSubsetDocument() {
 	suite.Equal("2", documents[0].Id)
 }
 
+func (suite *baseTestSuite) TestScanScores() {
+	ts := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	ctx := context.Background()
+	err := suite.AddScores(ctx, "b", "", []Score{{Id: "3", Score: 3, Categories: []string{"b"}, Timestamp: ts}})
+	suite.NoError(err)
+	err = suite.AddScores(ctx, "a", "a", []Score{
+		{Id: "2", Score: 2, Categories: []string{"a"}, Timestamp: ts},
+		{Id: "1", Score: 1, IsHidden: true, Categories: []string{"a"}, Timestamp: ts},
+	})
+	suite.NoError(err)
+
+	// hidden documents are scanned
+	type document struct {
+		Collection string
+		Subset     string
+		Score
+	}
+	var documents []document
+	err = suite.ScanScores(ctx, func(collection, subset string, score Score) error {
+		documents = append(documents, document{Collection: collection, Subset: subset, Score: score})
+		return nil
+	})
+	suite.NoError(err)
+	suite.Equal([]document{
+		{Collection: "a", Subset: "a", Score: Score{Id: "1", Score: 1, IsHidden: true, Categories: []string{"a"}, Timestamp: ts}},
+		{Collection: "a", Subset: "a", Score: Score{Id: "2", Score: 2, Categories: []string{"a"}, Timestamp: ts}},
+		{Collection: "b", Subset: "", Score: Score{Id: "3", Score: 3, Categories: []string{"b"}, Timestamp: ts}},
+	}, documents)
+}
+
 func (suite *baseTestSuite) TestTimeSeries() {
 	ts := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
 	ctx := context.Background()
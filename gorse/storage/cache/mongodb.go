@@ -227,6 +227,17 @@ func (m MongoDB) Delete(ctx context.Context, name string) error {
 	return errors.Trace(err)
 }
 
+func (m MongoDB) CompareAndSet(ctx context.Context, name, oldValue, newValue string) (bool, error) {
+	c := m.client.Database(m.dbName).Collection(m.ValuesTable())
+	result, err := c.UpdateOne(ctx,
+		bson.M{"_id": bson.M{"$eq": name}, "value": bson.M{"$eq": oldValue}},
+		bson.M{"$set": bson.M{"value": newValue}})
+	if err != nil {
+		return false, errors.Trace(err)
+	}
+	return result.MatchedCount > 0, nil
+}
+
 func (m MongoDB) GetSet(ctx context.Context, name string) ([]string, error) {
 	c := m.client.Database(m.dbName).Collection(m.SetsTable())
 	r, err := c.Find(ctx, bson.M{"name": name})
@@ -311,12 +322,35 @@ func (m MongoDB) Pop(ctx context.Context, name string) (string, error) {
 	return b["value"].(string), nil
 }
 
+// PopBefore pops the earliest value pushed before the timestamp. It returns io.EOF if there is no such value.
+func (m MongoDB) PopBefore(ctx context.Context, name string, timestamp time.Time) (string, error) {
+	result := m.client.Database(m.dbName).Collection(m.MessageTable()).FindOneAndDelete(ctx,
+		bson.M{"name": name, "timestamp": bson.M{"$lt": timestamp.UnixNano()}},
+		options.FindOneAndDelete().SetSort(bson.M{"timestamp": 1}))
+	if err := result.Err(); err == mongo.ErrNoDocuments {
+		return "", io.EOF
+	} else if err != nil {
+		return "", errors.Trace(err)
+	}
+	var b bson.M
+	if err := result.Decode(&b); err != nil {
+		return "", errors.Trace(err)
+	}
+	return b["value"].(string), nil
+}
+
 func (m MongoDB) Remain(ctx context.Context, name string) (int64, error) {
 	return m.client.Database(m.dbName).Collection(m.MessageTable()).CountDocuments(ctx, bson.M{
 		"name": name,
 	})
 }
 
+// Remove removes a value from a queue.
+func (m MongoDB) Remove(ctx context.Context, name, value string) error {
+	_, err := m.client.Database(m.dbName).Collection(m.MessageTable()).DeleteOne(ctx, bson.M{"name": name, "value": value})
+	return errors.Trace(err)
+}
+
 func (m MongoDB) AddScores(ctx context.Context, collection, subset string, documents []Score) error {
 	if len(documents) == 0 {
 		return nil
@@ -369,6 +403,40 @@ func (m MongoDB) SearchScores(ctx context.Context, collection, subset string, qu
 	return documents, nil
 }
 
+// ScanScores scans all documents including hidden ones ordered by collection, subset and id.
+func (m MongoDB) ScanScores(ctx context.Context, callback func(collection, subset string, document Score) error) error {
+	opt := options.Find().SetSort(bson.D{{Key: "collection", Value: 1}, {Key: "subset", Value: 1}, {Key: "id", Value: 1}})
+	cur, err := m.client.Database(m.dbName).Collection(m.DocumentTable()).Find(ctx, bson.M{}, opt)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	defer cur.Close(ctx)
+	for cur.Next(ctx) {
+		var document struct {
+			Collection string    `bson:"collection"`
+			Subset     string    `bson:"subset"`
+			Id         string    `bson:"id"`
+			Score      float64   `bson:"score"`
+			IsHidden   bool      `bson:"is_hidden"`
+			Categories []string  `bson:"categories"`
+			Timestamp  time.Time `bson:"timestamp"`
+		}
+		if err = cur.Decode(&document); err != nil {
+			return errors.Trace(err)
+		}
+		if err = callback(document.Collection, document.Subset, Score{
+			Id:         document.Id,
+			Score:      document.Score,
+			IsHidden:   document.IsHidden,
+			Categories: document.Categories,
+			Timestamp:  document.Timestamp.In(time.UTC),
+		}); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	return errors.Trace(cur.Err())
+}
+
 func (m MongoDB) UpdateScores(ctx context.Context, collections []string, subset *string, id string, patch ScorePatch) error {
 	if len(collections) == 0 {
 		return nil
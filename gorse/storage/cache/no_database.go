@@ -58,6 +58,11 @@ func (NoDatabase) Delete(_ context.Context, _ string) error {
 	return ErrNoDatabase
 }
 
+// CompareAndSet method of NoDatabase returns ErrNoDatabase.
+func (NoDatabase) CompareAndSet(_ context.Context, _, _, _ string) (bool, error) {
+	return false, ErrNoDatabase
+}
+
 // GetSet method of NoDatabase returns ErrNoDatabase.
 func (NoDatabase) GetSet(_ context.Context, _ string) ([]string, error) {
 	return nil, ErrNoDatabase
@@ -86,10 +91,18 @@ func (NoDatabase) Pop(_ context.Context, _ string) (string, error) {
 	return "", ErrNoDatabase
 }
 
+func (NoDatabase) PopBefore(_ context.Context, _ string, _ time.Time) (string, error) {
+	return "", ErrNoDatabase
+}
+
 func (NoDatabase) Remain(_ context.Context, _ string) (int64, error) {
 	return 0, ErrNoDatabase
 }
 
+func (NoDatabase) Remove(_ context.Context, _, _ string) error {
+	return ErrNoDatabase
+}
+
 func (NoDatabase) AddScores(_ context.Context, _, _ string, _ []Score) error {
 	return ErrNoDatabase
 }
@@ -98,6 +111,10 @@ func (NoDatabase) SearchScores(_ context.Context, _, _ string, _ []string, _, _
 	return nil, ErrNoDatabase
 }
 
+func (NoDatabase) ScanScores(_ context.Context, _ func(string, string, Score) error) error {
+	return ErrNoDatabase
+}
+
 func (NoDatabase) UpdateScores(context.Context, []string, *string, string, ScorePatch) error {
 	return ErrNoDatabase
 }
@@ -45,6 +45,8 @@ func TestNoDatabase(t *testing.T) {
 	assert.ErrorIs(t, err, ErrNoDatabase)
 	err = database.Delete(ctx, Key("", ""))
 	assert.ErrorIs(t, err, ErrNoDatabase)
+	_, err = database.CompareAndSet(ctx, Key("", ""), "", "")
+	assert.ErrorIs(t, err, ErrNoDatabase)
 
 	_, err = database.GetSet(ctx, "")
 	assert.ErrorIs(t, err, ErrNoDatabase)
@@ -59,13 +61,19 @@ func TestNoDatabase(t *testing.T) {
 	assert.ErrorIs(t, err, ErrNoDatabase)
 	_, err = database.Pop(ctx, "")
 	assert.ErrorIs(t, err, ErrNoDatabase)
+	_, err = database.PopBefore(ctx, "", time.Time{})
+	assert.ErrorIs(t, err, ErrNoDatabase)
 	_, err = database.Remain(ctx, "")
 	assert.ErrorIs(t, err, ErrNoDatabase)
+	err = database.Remove(ctx, "", "")
+	assert.ErrorIs(t, err, ErrNoDatabase)
 
 	err = database.AddScores(ctx, "", "", nil)
 	assert.ErrorIs(t, err, ErrNoDatabase)
 	_, err = database.SearchScores(ctx, "", "", nil, 0, 0)
 	assert.ErrorIs(t, err, ErrNoDatabase)
+	err = database.ScanScores(ctx, nil)
+	assert.ErrorIs(t, err, ErrNoDatabase)
 	err = database.UpdateScores(ctx, nil, nil, "", ScorePatch{})
 	assert.ErrorIs(t, err, ErrNoDatabase)
 	err = database.DeleteScores(ctx, nil, ScoreCondition{})
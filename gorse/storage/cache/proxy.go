@@ -74,6 +74,14 @@ func (p *ProxyServer) Delete(ctx context.Context, request *protocol.DeleteReques
 	return &protocol.DeleteResponse{}, p.database.Delete(ctx, request.GetName())
 }
 
+func (p *ProxyServer) CompareAndSet(ctx context.Context, request *protocol.CompareAndSetRequest) (*protocol.CompareAndSetResponse, error) {
+	swapped, err := p.database.CompareAndSet(ctx, request.GetName(), request.GetOldValue(), request.GetNewValue())
+	if err != nil {
+		return nil, err
+	}
+	return &protocol.CompareAndSetResponse{Swapped: swapped}, nil
+}
+
 func (p *ProxyServer) GetSet(ctx context.Context, request *protocol.GetSetRequest) (*protocol.GetSetResponse, error) {
 	members, err := p.database.GetSet(ctx, request.GetKey())
 	if err != nil {
@@ -109,6 +117,17 @@ func (p *ProxyServer) Pop(ctx context.Context, request *protocol.PopRequest) (*p
 	return &protocol.PopResponse{Value: proto.String(value)}, nil
 }
 
+func (p *ProxyServer) PopBefore(ctx context.Context, request *protocol.PopBeforeRequest) (*protocol.PopResponse, error) {
+	value, err := p.database.PopBefore(ctx, request.GetName(), request.GetTimestamp().AsTime())
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return &protocol.PopResponse{}, nil
+		}
+		return nil, err
+	}
+	return &protocol.PopResponse{Value: proto.String(value)}, nil
+}
+
 func (p *ProxyServer) Remain(ctx context.Context, request *protocol.RemainRequest) (*protocol.RemainResponse, error) {
 	count, err := p.database.Remain(ctx, request.GetName())
 	if err != nil {
@@ -117,6 +136,10 @@ func (p *ProxyServer) Remain(ctx context.Context, request *protocol.RemainReques
 	return &protocol.RemainResponse{Count: count}, nil
 }
 
+func (p *ProxyServer) Remove(ctx context.Context, request *protocol.RemoveRequest) (*protocol.RemoveResponse, error) {
+	return &protocol.RemoveResponse{}, p.database.Remove(ctx, request.GetName(), request.GetValue())
+}
+
 func (p *ProxyServer) AddScores(ctx context.Context, request *protocol.AddScoresRequest) (*protocol.AddScoresResponse, error) {
 	scores := make([]Score, len(request.Documents))
 	for i, doc := range request.Documents {
@@ -218,6 +241,10 @@ func (p ProxyClient) Scan(_ func(string) error) error {
 	return errors.MethodNotAllowedf("scan is not allowed in proxy client")
 }
 
+func (p ProxyClient) ScanScores(_ context.Context, _ func(string, string, Score) error) error {
+	return errors.MethodNotAllowedf("scan scores is not allowed in proxy client")
+}
+
 func (p ProxyClient) Purge() error {
 	return errors.MethodNotAllowedf("purge is not allowed in proxy client")
 }
@@ -256,6 +283,18 @@ func (p ProxyClient) Delete(ctx context.Context, name string) error {
 	return err
 }
 
+func (p ProxyClient) CompareAndSet(ctx context.Context, name, oldValue, newValue string) (bool, error) {
+	resp, err := p.CacheStoreClient.CompareAndSet(ctx, &protocol.CompareAndSetRequest{
+		Name:     name,
+		OldValue: oldValue,
+		NewValue: newValue,
+	})
+	if err != nil {
+		return false, err
+	}
+	return resp.GetSwapped(), nil
+}
+
 func (p ProxyClient) GetSet(ctx context.Context, key string) ([]string, error) {
 	resp, err := p.CacheStoreClient.GetSet(ctx, &protocol.GetSetRequest{
 		Key: key,
@@ -311,6 +350,20 @@ func (p ProxyClient) Pop(ctx context.Context, name string) (string, error) {
 	return resp.GetValue(), nil
 }
 
+func (p ProxyClient) PopBefore(ctx context.Context, name string, timestamp time.Time) (string, error) {
+	resp, err := p.CacheStoreClient.PopBefore(ctx, &protocol.PopBeforeRequest{
+		Name:      name,
+		Timestamp: timestamppb.New(timestamp),
+	})
+	if err != nil {
+		return "", err
+	}
+	if resp.Value == nil {
+		return "", io.EOF
+	}
+	return resp.GetValue(), nil
+}
+
 func (p ProxyClient) Remain(ctx context.Context, name string) (int64, error) {
 	resp, err := p.CacheStoreClient.Remain(ctx, &protocol.RemainRequest{
 		Name: name,
@@ -321,6 +374,14 @@ func (p ProxyClient) Remain(ctx context.Context, name string) (int64, error) {
 	return resp.Count, nil
 }
 
+func (p ProxyClient) Remove(ctx context.Context, name, value string) error {
+	_, err := p.CacheStoreClient.Remove(ctx, &protocol.RemoveRequest{
+		Name:  name,
+		Value: value,
+	})
+	return err
+}
+
 func (p ProxyClient) AddScores(ctx context.Context, collection, subset string, documents []Score) error {
 	scores := make([]*protocol.Score, len(documents))
 	for i, doc := range documents {
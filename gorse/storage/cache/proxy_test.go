@@ -82,6 +82,10 @@ func (suite *ProxyTestSuite) TestScan() {
 	suite.T().Skip()
 }
 
+func (suite *ProxyTestSuite) TestScanScores() {
+	suite.T().Skip()
+}
+
 func TestProxy(t *testing.T) {
 	suite.Run(t, new(ProxyTestSuite))
 }
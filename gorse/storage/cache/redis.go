@@ -19,6 +19,7 @@ import (
 	"encoding/base64"
 	"fmt"
 	"io"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -168,6 +169,34 @@ func (r *Redis) Delete(ctx context.Context, key string) error {
 	return r.client.Del(ctx, r.Key(key)).Err()
 }
 
+// CompareAndSet sets a value in Redis if the value hasn't been modified since it was read.
+func (r *Redis) CompareAndSet(ctx context.Context, name, oldValue, newValue string) (bool, error) {
+	key := r.Key(name)
+	var swapped bool
+	err := r.client.Watch(ctx, func(tx *redis.Tx) error {
+		value, err := tx.Get(ctx, key).Result()
+		if errors.Is(err, redis.Nil) {
+			return nil
+		} else if err != nil {
+			return err
+		} else if value != oldValue {
+			return nil
+		}
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			return pipe.Set(ctx, key, newValue, 0).Err()
+		})
+		swapped = err == nil
+		return err
+	}, key)
+	if errors.Is(err, redis.TxFailedErr) {
+		// the value was modified concurrently
+		return false, nil
+	} else if err != nil {
+		return false, errors.Trace(err)
+	}
+	return swapped, nil
+}
+
 // GetSet returns members of a set from Redis.
 func (r *Redis) GetSet(ctx context.Context, key string) ([]string, error) {
 	return r.client.SMembers(ctx, r.Key(key)).Result()
@@ -229,10 +258,50 @@ func (r *Redis) Pop(ctx context.Context, name string) (string, error) {
 	return z[0].Member.(string), nil
 }
 
+// PopBefore pops the earliest value pushed before the timestamp. It returns io.EOF if there is no such value.
+func (r *Redis) PopBefore(ctx context.Context, name string, timestamp time.Time) (string, error) {
+	key := r.Key(name)
+	for {
+		var value string
+		err := r.client.Watch(ctx, func(tx *redis.Tx) error {
+			values, err := tx.ZRangeByScore(ctx, key, &redis.ZRangeBy{
+				Min:   "-inf",
+				Max:   "(" + strconv.FormatInt(timestamp.UnixNano(), 10),
+				Count: 1,
+			}).Result()
+			if err != nil {
+				return err
+			}
+			if len(values) == 0 {
+				return io.EOF
+			}
+			value = values[0]
+			_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+				return pipe.ZRem(ctx, key, value).Err()
+			})
+			return err
+		}, key)
+		if errors.Is(err, redis.TxFailedErr) {
+			// the queue was modified concurrently
+			continue
+		} else if errors.Is(err, io.EOF) {
+			return "", io.EOF
+		} else if err != nil {
+			return "", errors.Trace(err)
+		}
+		return value, nil
+	}
+}
+
 func (r *Redis) Remain(ctx context.Context, name string) (int64, error) {
 	return r.client.ZCard(ctx, r.Key(name)).Result()
 }
 
+// Remove removes a value from a queue.
+func (r *Redis) Remove(ctx context.Context, name, value string) error {
+	return r.client.ZRem(ctx, r.Key(name), value).Err()
+}
+
 func (r *Redis) documentKey(collection, subset, value string) string {
 	return r.DocumentTable() + ":" + collection + ":" + subset + ":" + value
 }
@@ -277,31 +346,80 @@ func (r *Redis) SearchScores(ctx context.Context, collection, subset string, que
 	}
 	documents := make([]Score, 0, len(result.Docs))
 	for _, doc := range result.Docs {
-		var document Score
-		document.Id = doc.Fields["id"]
-		score, err := strconv.ParseFloat(doc.Fields["score"], 64)
+		document, err := decodeDocument(doc.Fields)
 		if err != nil {
 			return nil, errors.Trace(err)
 		}
-		document.Score = score
-		isHidden, err := strconv.ParseInt(doc.Fields["is_hidden"], 10, 64)
+		documents = append(documents, document)
+	}
+	return documents, nil
+}
+
+// ScanScores scans all documents including hidden ones ordered by collection, subset and id.
+func (r *Redis) ScanScores(ctx context.Context, callback func(collection, subset string, document Score) error) error {
+	var (
+		keys   []string
+		result []string
+		cursor uint64
+		err    error
+	)
+	for {
+		result, cursor, err = r.client.Scan(ctx, cursor, r.DocumentTable()+":*", 0).Result()
 		if err != nil {
-			return nil, errors.Trace(err)
+			return errors.Trace(err)
+		}
+		keys = append(keys, result...)
+		if cursor == 0 {
+			break
 		}
-		document.IsHidden = isHidden != 0
-		categories, err := decodeCategories(doc.Fields["categories"])
+	}
+	// keys are sorted to keep the order stable between scans
+	sort.Strings(keys)
+	keys = lo.Uniq(keys)
+	for _, key := range keys {
+		fields, err := r.client.HGetAll(ctx, key).Result()
 		if err != nil {
-			return nil, errors.Trace(err)
+			return errors.Trace(err)
 		}
-		document.Categories = categories
-		timestamp, err := strconv.ParseInt(doc.Fields["timestamp"], 10, 64)
+		if len(fields) == 0 {
+			continue
+		}
+		document, err := decodeDocument(fields)
 		if err != nil {
-			return nil, errors.Trace(err)
+			return errors.Trace(err)
+		}
+		if err = callback(fields["collection"], fields["subset"], document); err != nil {
+			return errors.Trace(err)
 		}
-		document.Timestamp = time.UnixMicro(timestamp).In(time.UTC)
-		documents = append(documents, document)
 	}
-	return documents, nil
+	return nil
+}
+
+// decodeDocument decodes a document from fields of a hash.
+func decodeDocument(fields map[string]string) (Score, error) {
+	var document Score
+	document.Id = fields["id"]
+	score, err := strconv.ParseFloat(fields["score"], 64)
+	if err != nil {
+		return document, errors.Trace(err)
+	}
+	document.Score = score
+	isHidden, err := strconv.ParseInt(fields["is_hidden"], 10, 64)
+	if err != nil {
+		return document, errors.Trace(err)
+	}
+	document.IsHidden = isHidden != 0
+	categories, err := decodeCategories(fields["categories"])
+	if err != nil {
+		return document, errors.Trace(err)
+	}
+	document.Categories = categories
+	timestamp, err := strconv.ParseInt(fields["timestamp"], 10, 64)
+	if err != nil {
+		return document, errors.Trace(err)
+	}
+	document.Timestamp = time.UnixMicro(timestamp).In(time.UTC)
+	return document, nil
 }
 
 func (r *Redis) UpdateScores(ctx context.Context, collections []string, subset *string, id string, patch ScorePatch) error {
@@ -94,15 +94,15 @@ type SQLSet struct {
 }
 
 type SQLSortedSet struct {
-	Name   string  `gorm:"type:varchar(256);primaryKey;index:name"`
+	Name   string  `gorm:"type:varchar(256);primaryKey"`
 	Member string  `gorm:"type:varchar(256);primaryKey"`
-	Score  float64 `gorm:"type:double precision;not null;index:name"`
+	Score  float64 `gorm:"type:double precision;not null"`
 }
 
 type Message struct {
-	Name      string `gorm:"primaryKey;index:timestamp"`
+	Name      string `gorm:"primaryKey"`
 	Value     string `gorm:"primaryKey"`
-	Timestamp int64  `gorm:"index:timestamp"`
+	Timestamp int64
 }
 
 type PostgresDocument struct {
@@ -145,6 +145,12 @@ func (db *SQLDatabase) Init() error {
 	if err != nil {
 		return errors.Trace(err)
 	}
+	if err = db.createIndex(&SQLSortedSet{}, "name", "name", "score"); err != nil {
+		return errors.Trace(err)
+	}
+	if err = db.createIndex(&Message{}, "timestamp", "name", "timestamp"); err != nil {
+		return errors.Trace(err)
+	}
 	switch db.driver {
 	case Postgres:
 		err = db.gormDB.AutoMigrate(&PostgresDocument{})
@@ -157,11 +163,13 @@ func (db *SQLDatabase) Init() error {
 			return errors.Trace(err)
 		}
 		// create index
-		err = db.gormDB.Exec(fmt.Sprintf("CREATE INDEX IF NOT EXISTS idx_collection_subset_categories ON %s USING GIN (collection, subset, categories)", db.DocumentTable())).Error
+		err = db.gormDB.Exec(fmt.Sprintf("CREATE INDEX IF NOT EXISTS %s ON %s USING GIN (collection, subset, categories)",
+			db.IndexName("idx_collection_subset_categories"), db.DocumentTable())).Error
 		if err != nil {
 			return errors.Trace(err)
 		}
-		err = db.gormDB.Exec(fmt.Sprintf("CREATE INDEX IF NOT EXISTS idx_collection_id ON %s (collection, id)", db.DocumentTable())).Error
+		err = db.gormDB.Exec(fmt.Sprintf("CREATE INDEX IF NOT EXISTS %s ON %s (collection, id)",
+			db.IndexName("idx_collection_id"), db.DocumentTable())).Error
 		if err != nil {
 			return errors.Trace(err)
 		}
@@ -194,6 +202,30 @@ func (db *SQLDatabase) Init() error {
 	return errors.Trace(err)
 }
 
+// createIndex creates an index on columns of the table of a model if the index doesn't exist. Index names are
+// prefixed in PostgreSQL and SQLite since they are global in these databases.
+func (db *SQLDatabase) createIndex(model any, name string, columns ...string) error {
+	stmt := &gorm.Statement{DB: db.gormDB}
+	if err := stmt.Parse(model); err != nil {
+		return errors.Trace(err)
+	}
+	table := db.gormDB.Statement.Quote(stmt.Table)
+	quoted := lo.Map(columns, func(column string, _ int) string {
+		return db.gormDB.Statement.Quote(column)
+	})
+	if db.driver == MySQL {
+		err := db.gormDB.Exec(fmt.Sprintf("ALTER TABLE %s ADD INDEX %s (%s)", table,
+			db.gormDB.Statement.Quote(name), strings.Join(quoted, ", "))).Error
+		if mysqlErr, ok := err.(*mysql.MySQLError); ok && mysqlErr.Number == 1061 {
+			// ignore duplicate index error
+			return nil
+		}
+		return errors.Trace(err)
+	}
+	return errors.Trace(db.gormDB.Exec(fmt.Sprintf("CREATE INDEX IF NOT EXISTS %s ON %s (%s)",
+		db.gormDB.Statement.Quote(db.IndexName(name)), table, strings.Join(quoted, ", "))).Error)
+}
+
 func (db *SQLDatabase) Scan(work func(string) error) error {
 	var (
 		valuerRows *sql.Rows
@@ -294,6 +326,16 @@ func (db *SQLDatabase) Delete(ctx context.Context, name string) error {
 	return errors.Trace(err)
 }
 
+func (db *SQLDatabase) CompareAndSet(ctx context.Context, name, oldValue, newValue string) (bool, error) {
+	result := db.gormDB.WithContext(ctx).Table(db.ValuesTable()).
+		Where("name = ? AND value = ?", name, oldValue).
+		Update("value", newValue)
+	if result.Error != nil {
+		return false, errors.Trace(result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
+
 func (db *SQLDatabase) GetSet(ctx context.Context, key string) ([]string, error) {
 	rs, err := db.gormDB.WithContext(ctx).Table(db.SetsTable()).Select("member").Where("name = ?", key).Rows()
 	if err != nil {
@@ -386,11 +428,41 @@ func (db *SQLDatabase) Pop(ctx context.Context, name string) (string, error) {
 	return message.Value, err
 }
 
+// PopBefore pops the earliest value pushed before the timestamp. It returns io.EOF if there is no such value.
+func (db *SQLDatabase) PopBefore(ctx context.Context, name string, timestamp time.Time) (string, error) {
+	for {
+		var messages []Message
+		if err := db.gormDB.WithContext(ctx).Order("timestamp").Limit(1).
+			Find(&messages, "name = ? AND timestamp < ?", name, timestamp.UnixNano()).Error; err != nil {
+			return "", errors.Trace(err)
+		}
+		if len(messages) == 0 {
+			return "", io.EOF
+		}
+		message := messages[0]
+		// the message might be popped or pushed again concurrently
+		result := db.gormDB.WithContext(ctx).
+			Where("name = ? AND value = ? AND timestamp = ?", message.Name, message.Value, message.Timestamp).
+			Delete(&Message{})
+		if result.Error != nil {
+			return "", errors.Trace(result.Error)
+		}
+		if result.RowsAffected > 0 {
+			return message.Value, nil
+		}
+	}
+}
+
 func (db *SQLDatabase) Remain(ctx context.Context, name string) (count int64, err error) {
 	err = db.gormDB.WithContext(ctx).Model(&Message{}).Where("name = ?", name).Count(&count).Error
 	return
 }
 
+// Remove removes a value from a queue.
+func (db *SQLDatabase) Remove(ctx context.Context, name, value string) error {
+	return db.gormDB.WithContext(ctx).Where("name = ? AND value = ?", name, value).Delete(&Message{}).Error
+}
+
 func (db *SQLDatabase) AddScores(ctx context.Context, collection, subset string, documents []Score) error {
 	var rows any
 	switch db.driver {
@@ -479,6 +551,53 @@ func (db *SQLDatabase) SearchScores(ctx context.Context, collection, subset stri
 	return documents, nil
 }
 
+// ScanScores scans all documents including hidden ones ordered by collection, subset and id.
+func (db *SQLDatabase) ScanScores(ctx context.Context, callback func(collection, subset string, document Score) error) error {
+	rows, err := db.gormDB.WithContext(ctx).
+		Table(db.DocumentTable()).
+		Select("collection, subset, id, score, is_hidden, categories, timestamp").
+		Order("collection, subset, id").
+		Rows()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		switch db.driver {
+		case Postgres:
+			var document PostgresDocument
+			if err = rows.Scan(&document.Collection, &document.Subset, &document.Id, &document.Score,
+				&document.IsHidden, &document.Categories, &document.Timestamp); err != nil {
+				return errors.Trace(err)
+			}
+			if err = callback(document.Collection, document.Subset, Score{
+				Id:         document.Id,
+				Score:      document.Score,
+				IsHidden:   document.IsHidden,
+				Categories: document.Categories,
+				Timestamp:  document.Timestamp.In(time.UTC),
+			}); err != nil {
+				return errors.Trace(err)
+			}
+		case SQLite, MySQL:
+			var document SQLDocument
+			if err = db.gormDB.ScanRows(rows, &document); err != nil {
+				return errors.Trace(err)
+			}
+			if err = callback(document.Collection, document.Subset, Score{
+				Id:         document.Id,
+				Score:      document.Score,
+				IsHidden:   document.IsHidden,
+				Categories: document.Categories,
+				Timestamp:  document.Timestamp.In(time.UTC),
+			}); err != nil {
+				return errors.Trace(err)
+			}
+		}
+	}
+	return errors.Trace(rows.Err())
+}
+
 func (db *SQLDatabase) UpdateScores(ctx context.Context, collections []string, subset *string, id string, patch ScorePatch) error {
 	if len(collections) == 0 {
 		return nil
@@ -132,6 +132,25 @@ func TestSQLite(t *testing.T) {
 	suite.Run(t, new(SQLiteTestSuite))
 }
 
+func TestSQLite_TablePrefix(t *testing.T) {
+	// tables of different prefixes share the database
+	path := fmt.Sprintf("sqlite://%s/sqlite.db", t.TempDir())
+	for _, prefix := range []string{"gorse_", "tenant_"} {
+		database, err := Open(path, prefix)
+		assert.NoError(t, err)
+		assert.NoError(t, database.Init())
+		assert.NoError(t, database.Close())
+	}
+	database, err := Open(path, "")
+	assert.NoError(t, err)
+	defer database.Close()
+	var indexes []string
+	err = database.(*SQLDatabase).gormDB.Raw("SELECT name FROM sqlite_master WHERE type = 'index' AND name LIKE '%timestamp' ORDER BY name").
+		Scan(&indexes).Error
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"gorse_timestamp", "tenant_timestamp"}, indexes)
+}
+
 func assertQuery(t *testing.T, connection *sql.DB, sql string, expected string) {
 	rows, err := connection.Query(sql)
 	assert.NoError(t, err)
@@ -25,6 +25,7 @@ import (
 	"time"
 
 	"github.com/XSAM/otelsql"
+	mapset "github.com/deckarep/golang-set/v2"
 	"github.com/juju/errors"
 	"github.com/samber/lo"
 	"github.com/zhenghaoz/gorse/base/jsonutil"
@@ -54,6 +55,8 @@ var (
 	ErrUserNotExist = errors.NotFoundf("user")
 	ErrItemNotExist = errors.NotFoundf("item")
 	ErrNoDatabase   = errors.NotAssignedf("database")
+	// ErrStatusConflict is returned if the status of an item is changed by others during a transition.
+	ErrStatusConflict = errors.New("item status was modified concurrently")
 )
 
 // ValidateLabels checks if labels are valid. Labels are valid if consists of:
@@ -108,6 +111,104 @@ type Item struct {
 	Timestamp  time.Time `gorm:"column:time_stamp" mapstructure:"timestamp"`
 	Labels     any       `gorm:"serializer:json" mapstructure:"labels"`
 	Comment    string    `mapsstructure:"comment"`
+	// Status is the lifecycle status of the item. Items without status are active.
+	Status ItemStatus `mapstructure:"status"`
+	// StatusHistory records changes of the status.
+	StatusHistory []StatusChange `gorm:"serializer:json" mapstructure:"status_history"`
+}
+
+// IsVisible returns true if the item could be recommended.
+func (item *Item) IsVisible() bool {
+	return !item.IsHidden && item.Status.IsVisible()
+}
+
+// Transit changes the status of the item and records the change. Transitions not allowed are rejected.
+func (item *Item) Transit(status ItemStatus, timestamp time.Time) error {
+	if err := status.Validate(); err != nil {
+		return err
+	}
+	if item.Status.orActive() == status.orActive() {
+		return nil
+	}
+	if !lo.Contains(itemTransitions[item.Status.orActive()], status.orActive()) {
+		return errors.NotValidf("transition of item %s from %s to %s", item.ItemId, item.Status.orActive(), status.orActive())
+	}
+	item.Status = status
+	item.StatusHistory = append(item.StatusHistory, StatusChange{Status: status, Timestamp: timestamp})
+	return nil
+}
+
+// TimeToSell returns the time when a sold item is listed and the time when it is sold. The listing time is the
+// latest change to active or relisted before the sale, or the timestamp of the item if the change isn't recorded.
+func (item *Item) TimeToSell() (listed, sold time.Time, ok bool) {
+	if item.Status != StatusSold {
+		return time.Time{}, time.Time{}, false
+	}
+	listed = item.Timestamp
+	for _, change := range item.StatusHistory {
+		switch change.Status {
+		case StatusActive, StatusRelisted:
+			listed = change.Timestamp
+		case StatusSold:
+			sold = change.Timestamp
+		}
+	}
+	if listed.IsZero() || sold.IsZero() || sold.Before(listed) {
+		return time.Time{}, time.Time{}, false
+	}
+	return listed, sold, true
+}
+
+// ItemStatus is the lifecycle status of an item.
+type ItemStatus string
+
+const (
+	StatusDraft    ItemStatus = "draft"
+	StatusActive   ItemStatus = "active"
+	StatusReserved ItemStatus = "reserved"
+	StatusSold     ItemStatus = "sold"
+	StatusRelisted ItemStatus = "relisted"
+)
+
+// itemTransitions are allowed transitions between statuses. A relisted item keeps its feedback so that it is
+// recommended based on its history.
+var itemTransitions = map[ItemStatus][]ItemStatus{
+	StatusDraft:    {StatusActive},
+	StatusActive:   {StatusDraft, StatusReserved, StatusSold},
+	StatusReserved: {StatusActive, StatusSold},
+	StatusSold:     {StatusRelisted},
+	StatusRelisted: {StatusReserved, StatusSold},
+}
+
+// Validate returns an error if the status is unknown. The empty status is valid.
+func (status ItemStatus) Validate() error {
+	if _, ok := itemTransitions[status.orActive()]; !ok {
+		return errors.NotValidf("item status %q", status)
+	}
+	return nil
+}
+
+// IsVisible returns true if items in the status could be recommended. Drafts, reserved and sold items are invisible.
+func (status ItemStatus) IsVisible() bool {
+	switch status.orActive() {
+	case StatusActive, StatusRelisted:
+		return true
+	default:
+		return false
+	}
+}
+
+func (status ItemStatus) orActive() ItemStatus {
+	if status == "" {
+		return StatusActive
+	}
+	return status
+}
+
+// StatusChange is a change of the status of an item.
+type StatusChange struct {
+	Status    ItemStatus
+	Timestamp time.Time
 }
 
 // ItemPatch is the modification on an item.
@@ -117,6 +218,107 @@ type ItemPatch struct {
 	Timestamp  *time.Time
 	Labels     any
 	Comment    *string
+	Status     *ItemStatus
+}
+
+// Operator compares a label with a value.
+type Operator string
+
+const (
+	OpEq Operator = "eq"
+	OpNe Operator = "ne"
+	OpLt Operator = "lt"
+	OpLe Operator = "le"
+	OpGt Operator = "gt"
+	OpGe Operator = "ge"
+)
+
+var sqlOperators = map[Operator]string{
+	OpEq: "=",
+	OpNe: "<>",
+	OpLt: "<",
+	OpLe: "<=",
+	OpGt: ">",
+	OpGe: ">=",
+}
+
+// LabelPredicate compares the label at a path with a value. The path is a dot-separated list of keys, e.g.
+// "size.eu" refers to 42 in {"size": {"eu": 42}}. The value is a string or a number, and only labels of the
+// same type are matched.
+type LabelPredicate struct {
+	Path  string   `json:"path"`
+	Op    Operator `json:"op"`
+	Value any      `json:"value"`
+}
+
+// Keys returns keys in the path of the label.
+func (p LabelPredicate) Keys() []string {
+	return strings.Split(p.Path, ".")
+}
+
+// IsNumber returns true if the value is a number.
+func (p LabelPredicate) IsNumber() bool {
+	_, ok := p.Value.(float64)
+	return ok
+}
+
+// ItemQuery is a conjunction of predicates on items.
+type ItemQuery struct {
+	// Labels are predicates on labels.
+	Labels []LabelPredicate `json:"labels,omitempty"`
+	// Categories are categories that items must belong to.
+	Categories []string `json:"categories,omitempty"`
+	// IsHidden matches hidden or visible items.
+	IsHidden *bool `json:"is_hidden,omitempty"`
+	// BeginTime is the inclusive lower bound of timestamps.
+	BeginTime *time.Time `json:"begin_time,omitempty"`
+	// EndTime is the inclusive upper bound of timestamps.
+	EndTime *time.Time `json:"end_time,omitempty"`
+}
+
+// ParseItemQuery parses an item query in JSON, e.g.
+//
+//	{"labels": [{"path": "brand", "op": "eq", "value": "Levi's"}], "categories": ["jeans"], "is_hidden": false}
+func ParseItemQuery(text string) (ItemQuery, error) {
+	var query ItemQuery
+	decoder := json.NewDecoder(strings.NewReader(text))
+	decoder.UseNumber()
+	if err := decoder.Decode(&query); err != nil {
+		return ItemQuery{}, errors.Trace(err)
+	}
+	if err := query.Validate(); err != nil {
+		return ItemQuery{}, err
+	}
+	return query, nil
+}
+
+// Validate checks predicates and converts numbers in values to float64.
+func (q *ItemQuery) Validate() error {
+	for i := range q.Labels {
+		predicate := &q.Labels[i]
+		for _, key := range predicate.Keys() {
+			if key == "" || strings.ContainsAny(key, `"\`) {
+				return errors.NotValidf("label path %q", predicate.Path)
+			}
+		}
+		if _, ok := sqlOperators[predicate.Op]; !ok {
+			return errors.NotValidf("operator %q", predicate.Op)
+		}
+		switch value := predicate.Value.(type) {
+		case string, float64:
+		case json.Number:
+			number, err := value.Float64()
+			if err != nil {
+				return errors.Trace(err)
+			}
+			predicate.Value = number
+		case int:
+			predicate.Value = float64(value)
+		default:
+			return errors.NotValidf("value %v of label %q", predicate.Value, predicate.Path)
+		}
+	}
+	return nil
 }
 
 // User stores meta data about user.
@@ -144,8 +346,71 @@ type FeedbackKey struct {
 // Feedback stores feedback.
 type Feedback struct {
 	FeedbackKey `gorm:"embedded" mapstructure:",squash"`
-	Timestamp   time.Time `gorm:"column:time_stamp" mapsstructure:"timestamp"`
-	Comment     string    `gorm:"column:comment" mapsstructure:"comment"`
+	Timestamp   time.Time         `gorm:"column:time_stamp" mapsstructure:"timestamp"`
+	Comment     string            `gorm:"column:comment" mapsstructure:"comment"`
+	Value       float64           `gorm:"column:value" mapstructure:"value"`
+	Context     map[string]string `gorm:"column:context;serializer:json" mapstructure:"context" json:"Context,omitempty"` // context where the feedback occurred
+}
+
+// Impression is an item served to a user by a recommendation request. Source is the recommender that produced the
+// item. Propensity is the probability that the item was served, which is zero if unknown.
+type Impression struct {
+	RequestId  string    `gorm:"column:request_id"`
+	UserId     string    `gorm:"column:user_id"`
+	ItemId     string    `gorm:"column:item_id"`
+	Position   int       `gorm:"column:position"`
+	Source     string    `gorm:"column:source"`
+	Score      float64   `gorm:"column:score"`
+	Propensity float64   `gorm:"column:propensity"`
+	Timestamp  time.Time `gorm:"column:time_stamp"`
+}
+
+// Change event types written to the outbox.
+const (
+	EventItemUpserted       = "item.upserted"
+	EventItemHidden         = "item.hidden"
+	EventItemDeleted        = "item.deleted"
+	EventUserUpserted       = "user.upserted"
+	EventUserDeleted        = "user.deleted"
+	EventFeedbackInserted   = "feedback.inserted"
+	EventRecommendRefreshed = "recommend.refreshed"
+)
+
+// UserEventTypes are types of change events keyed by user IDs.
+var UserEventTypes = []string{EventUserUpserted, EventUserDeleted, EventFeedbackInserted, EventRecommendRefreshed}
+
+// Event is a change event in the outbox. Id is assigned by the data store and increases with insertion order. Key is
+// the ID of the changed entity (the user ID for feedback) and Payload is the JSON encoded change. Events stay in the
+// outbox until they are dispatched, so IDs only order pending events and are never used as a delivery cursor.
+type Event struct {
+	Id        int64     `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
+	Type      string    `gorm:"column:event_type" json:"type"`
+	Key       string    `gorm:"column:event_key" json:"key"`
+	Payload   string    `gorm:"column:payload" json:"payload"`
+	Timestamp time.Time `gorm:"column:time_stamp" json:"timestamp"`
+}
+
+type eventsKey struct{}
+
+// WithEvents returns a context carrying change events. A write of users, items or feedback with the context commits the
+// events to the outbox in the same transaction as the change, so events are never lost or published for failed writes.
+func WithEvents(ctx context.Context, events []Event) context.Context {
+	return context.WithValue(ctx, eventsKey{}, events)
+}
+
+// eventsFromContext returns change events carried by a context.
+func eventsFromContext(ctx context.Context) []Event {
+	events, _ := ctx.Value(eventsKey{}).([]Event)
+	return events
+}
+
+// NewEvent creates a change event with a JSON encoded payload.
+func NewEvent(eventType, key string, payload any) (Event, error) {
+	bytes, err := json.Marshal(payload)
+	if err != nil {
+		return Event{}, errors.Trace(err)
+	}
+	return Event{Type: eventType, Key: key, Payload: string(bytes), Timestamp: time.Now()}, nil
 }
 
 type UserFeedback Feedback
@@ -262,6 +527,7 @@ type Database interface {
 	GetItem(ctx context.Context, itemId string) (Item, error)
 	ModifyItem(ctx context.Context, itemId string, patch ItemPatch) error
 	GetItems(ctx context.Context, cursor string, n int, beginTime *time.Time) (string, []Item, error)
+	GetItemsByQuery(ctx context.Context, query ItemQuery, cursor string, n int) (string, []Item, error)
 	GetItemFeedback(ctx context.Context, itemId string, feedbackTypes ...string) ([]Feedback, error)
 	BatchInsertUsers(ctx context.Context, users []User) error
 	DeleteUser(ctx context.Context, userId string) error
@@ -279,6 +545,13 @@ type Database interface {
 	CountUsers(ctx context.Context) (int, error)
 	CountItems(ctx context.Context) (int, error)
 	CountFeedback(ctx context.Context) (int, error)
+	BatchInsertImpressions(ctx context.Context, impressions []Impression) error
+	GetImpressionStream(ctx context.Context, batchSize int, beginTime, endTime *time.Time) (chan []Impression, chan error)
+	DeleteUserImpressions(ctx context.Context, userId string) (int, error)
+	InsertEvents(ctx context.Context, events []Event) error
+	GetEvents(ctx context.Context, n int) ([]Event, error)
+	DeleteEvents(ctx context.Context, ids []int64) error
+	DeleteUserEvents(ctx context.Context, userId string) (int, error)
 }
 
 // Open a connection to a database.
@@ -304,6 +577,7 @@ func Open(path, tablePrefix string, opts ...storage.Option) (Database, error) {
 		database := new(SQLDatabase)
 		database.driver = MySQL
 		database.TablePrefix = storage.TablePrefix(tablePrefix)
+		database.indexedLabels = mapset.NewSet(option.IndexedLabels...)
 		if database.client, err = otelsql.Open("mysql", name,
 			otelsql.WithAttributes(semconv.DBSystemMySQL),
 			otelsql.WithSpanOptions(otelsql.SpanOptions{DisableErrSkip: true}),
@@ -251,11 +251,11 @@ func (suite *baseTestSuite) TestFeedback() {
 	// insert feedbacks
 	timestamp := time.Date(1996, 3, 15, 0, 0, 0, 0, time.UTC)
 	feedback := []Feedback{
-		{FeedbackKey{positiveFeedbackType, "0", "8"}, timestamp, "comment"},
-		{FeedbackKey{positiveFeedbackType, "1", "6"}, timestamp, "comment"},
-		{FeedbackKey{positiveFeedbackType, "2", "4"}, timestamp, "comment"},
-		{FeedbackKey{positiveFeedbackType, "3", "2"}, timestamp, "comment"},
-		{FeedbackKey{positiveFeedbackType, "4", "0"}, timestamp, "comment"},
+		{FeedbackKey{positiveFeedbackType, "0", "8"}, timestamp, "comment", 1, map[string]string{"device": "mobile"}},
+		{FeedbackKey{positiveFeedbackType, "1", "6"}, timestamp, "comment", 2, nil},
+		{FeedbackKey{positiveFeedbackType, "2", "4"}, timestamp, "comment", 3, map[string]string{"device": "desktop", "surface": "home"}},
+		{FeedbackKey{positiveFeedbackType, "3", "2"}, timestamp, "comment", 4, nil},
+		{FeedbackKey{positiveFeedbackType, "4", "0"}, timestamp, "comment", 5, nil},
 	}
 	err = suite.Database.BatchInsertFeedback(ctx, feedback, true, true, true)
 	suite.NoError(err)
@@ -266,11 +266,11 @@ func (suite *baseTestSuite) TestFeedback() {
 	suite.NoError(err)
 	// future feedback
 	futureFeedback := []Feedback{
-		{FeedbackKey{duplicateFeedbackType, "0", "0"}, time.Now().Add(time.Hour), "comment"},
-		{FeedbackKey{duplicateFeedbackType, "1", "2"}, time.Now().Add(time.Hour), "comment"},
-		{FeedbackKey{duplicateFeedbackType, "2", "4"}, time.Now().Add(time.Hour), "comment"},
-		{FeedbackKey{duplicateFeedbackType, "3", "6"}, time.Now().Add(time.Hour), "comment"},
-		{FeedbackKey{duplicateFeedbackType, "4", "8"}, time.Now().Add(time.Hour), "comment"},
+		{FeedbackKey{duplicateFeedbackType, "0", "0"}, time.Now().Add(time.Hour), "comment", 0, nil},
+		{FeedbackKey{duplicateFeedbackType, "1", "2"}, time.Now().Add(time.Hour), "comment", 0, nil},
+		{FeedbackKey{duplicateFeedbackType, "2", "4"}, time.Now().Add(time.Hour), "comment", 0, nil},
+		{FeedbackKey{duplicateFeedbackType, "3", "6"}, time.Now().Add(time.Hour), "comment", 0, nil},
+		{FeedbackKey{duplicateFeedbackType, "4", "8"}, time.Now().Add(time.Hour), "comment", 0, nil},
 	}
 	err = suite.Database.BatchInsertFeedback(ctx, futureFeedback, true, true, true)
 	suite.NoError(err)
@@ -536,15 +536,145 @@ func (suite *baseTestSuite) TestItems() {
 	suite.NoError(err)
 }
 
+func (suite *baseTestSuite) TestItemsByQuery() {
+	ctx := context.Background()
+	items := []Item{
+		{
+			ItemId:     "0",
+			Categories: []string{"jeans"},
+			Timestamp:  time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+			Labels:     map[string]any{"brand": "levis", "size": map[string]any{"eu": float64(40)}, "price": float64(50)},
+		},
+		{
+			ItemId:     "1",
+			Categories: []string{"jeans", "sale"},
+			Timestamp:  time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC),
+			Labels:     map[string]any{"brand": "levis", "size": map[string]any{"eu": float64(42)}, "price": float64(30)},
+		},
+		{
+			ItemId:     "2",
+			IsHidden:   true,
+			Categories: []string{"jeans", "sale"},
+			Timestamp:  time.Date(2024, 1, 3, 0, 0, 0, 0, time.UTC),
+			Labels:     map[string]any{"brand": "levis", "size": map[string]any{"eu": float64(42)}, "price": "unknown"},
+		},
+		{
+			ItemId:     "3",
+			Categories: []string{"shirts"},
+			Timestamp:  time.Date(2024, 1, 4, 0, 0, 0, 0, time.UTC),
+			Labels:     map[string]any{"brand": "wrangler", "size": map[string]any{"eu": "M"}},
+		},
+		{
+			ItemId:     "4",
+			Categories: []string{"sale"},
+			Timestamp:  time.Date(2024, 1, 5, 0, 0, 0, 0, time.UTC),
+			Labels:     []any{"levis"},
+		},
+	}
+	err := suite.Database.BatchInsertItems(ctx, items)
+	suite.NoError(err)
+	query := func(query ItemQuery) []string {
+		var (
+			ids    []string
+			cursor string
+		)
+		for {
+			var batch []Item
+			cursor, batch, err = suite.Database.GetItemsByQuery(ctx, query, cursor, 2)
+			suite.NoError(err)
+			for _, item := range batch {
+				ids = append(ids, item.ItemId)
+			}
+			if cursor == "" || len(batch) == 0 {
+				return ids
+			}
+		}
+	}
+
+	// string labels
+	suite.Equal([]string{"0", "1", "2"}, query(ItemQuery{Labels: []LabelPredicate{{Path: "brand", Op: OpEq, Value: "levis"}}}))
+	suite.Equal([]string{"3"}, query(ItemQuery{Labels: []LabelPredicate{{Path: "brand", Op: OpNe, Value: "levis"}}}))
+	suite.Equal([]string{"3"}, query(ItemQuery{Labels: []LabelPredicate{{Path: "size.eu", Op: OpEq, Value: "M"}}}))
+	// numeric labels
+	suite.Equal([]string{"1", "2"}, query(ItemQuery{Labels: []LabelPredicate{{Path: "size.eu", Op: OpEq, Value: float64(42)}}}))
+	suite.Equal([]string{"0"}, query(ItemQuery{Labels: []LabelPredicate{{Path: "size.eu", Op: OpLt, Value: float64(42)}}}))
+	suite.Equal([]string{"1"}, query(ItemQuery{Labels: []LabelPredicate{{Path: "price", Op: OpLe, Value: float64(30)}}}))
+	suite.Equal([]string{"0"}, query(ItemQuery{Labels: []LabelPredicate{{Path: "price", Op: OpGt, Value: float64(30)}}}))
+	suite.Equal([]string{"0", "1"}, query(ItemQuery{Labels: []LabelPredicate{{Path: "price", Op: OpGe, Value: float64(30)}}}))
+	// conjunction of labels
+	suite.Equal([]string{"1", "2"}, query(ItemQuery{Labels: []LabelPredicate{
+		{Path: "brand", Op: OpEq, Value: "levis"},
+		{Path: "size.eu", Op: OpGe, Value: float64(41)},
+	}}))
+	// categories
+	suite.Equal([]string{"1", "2", "4"}, query(ItemQuery{Categories: []string{"sale"}}))
+	suite.Equal([]string{"1", "2"}, query(ItemQuery{Categories: []string{"jeans", "sale"}}))
+	// hidden state
+	suite.Equal([]string{"2"}, query(ItemQuery{IsHidden: lo.ToPtr(true)}))
+	suite.Equal([]string{"1", "4"}, query(ItemQuery{IsHidden: lo.ToPtr(false), Categories: []string{"sale"}}))
+	// time range
+	suite.Equal([]string{"1", "2", "3"}, query(ItemQuery{
+		BeginTime: lo.ToPtr(time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)),
+		EndTime:   lo.ToPtr(time.Date(2024, 1, 4, 0, 0, 0, 0, time.UTC)),
+	}))
+	// all items
+	suite.Equal([]string{"0", "1", "2", "3", "4"}, query(ItemQuery{}))
+	// invalid query
+	_, _, err = suite.Database.GetItemsByQuery(ctx, ItemQuery{Labels: []LabelPredicate{{Path: "brand", Op: "like", Value: "levis"}}}, "", 10)
+	suite.Error(err)
+}
+
+func (suite *baseTestSuite) TestItemStatus() {
+	ctx := context.Background()
+	timestamp := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	err := suite.Database.BatchInsertItems(ctx, []Item{{ItemId: "0", Timestamp: timestamp, Status: StatusDraft}})
+	suite.NoError(err)
+	item, err := suite.Database.GetItem(ctx, "0")
+	suite.NoError(err)
+	suite.Equal(StatusDraft, item.Status)
+	suite.False(item.IsVisible())
+
+	// move through the lifecycle
+	for _, status := range []ItemStatus{StatusActive, StatusReserved, StatusSold, StatusRelisted} {
+		err = suite.Database.ModifyItem(ctx, "0", ItemPatch{Status: lo.ToPtr(status)})
+		suite.NoError(err)
+	}
+	item, err = suite.Database.GetItem(ctx, "0")
+	suite.NoError(err)
+	suite.Equal(StatusRelisted, item.Status)
+	suite.True(item.IsVisible())
+	suite.Equal(timestamp, item.Timestamp)
+	suite.Equal([]ItemStatus{StatusActive, StatusReserved, StatusSold, StatusRelisted},
+		lo.Map(item.StatusHistory, func(change StatusChange, _ int) ItemStatus { return change.Status }))
+	for i := 1; i < len(item.StatusHistory); i++ {
+		suite.False(item.StatusHistory[i].Timestamp.Before(item.StatusHistory[i-1].Timestamp))
+	}
+
+	// transitions not allowed are rejected
+	err = suite.Database.ModifyItem(ctx, "0", ItemPatch{Status: lo.ToPtr(StatusDraft)})
+	suite.True(errors.Is(err, errors.NotValid))
+	err = suite.Database.ModifyItem(ctx, "0", ItemPatch{Status: lo.ToPtr(ItemStatus("unknown"))})
+	suite.True(errors.Is(err, errors.NotValid))
+	err = suite.Database.ModifyItem(ctx, "1", ItemPatch{Status: lo.ToPtr(StatusSold)})
+	suite.True(errors.Is(err, errors.NotFound))
+	// transitions to the same status are ignored
+	err = suite.Database.ModifyItem(ctx, "0", ItemPatch{Status: lo.ToPtr(StatusRelisted)})
+	suite.NoError(err)
+	item, err = suite.Database.GetItem(ctx, "0")
+	suite.NoError(err)
+	suite.Equal(StatusRelisted, item.Status)
+	suite.Len(item.StatusHistory, 4)
+}
+
 func (suite *baseTestSuite) TestDeleteUser() {
 	ctx := context.Background()
 	// Insert ret
 	feedback := []Feedback{
-		{FeedbackKey{positiveFeedbackType, "a", "0"}, time.Date(1996, 3, 15, 0, 0, 0, 0, time.UTC), "comment"},
-		{FeedbackKey{positiveFeedbackType, "a", "2"}, time.Date(1996, 3, 15, 0, 0, 0, 0, time.UTC), "comment"},
-		{FeedbackKey{positiveFeedbackType, "a", "4"}, time.Date(1996, 3, 15, 0, 0, 0, 0, time.UTC), "comment"},
-		{FeedbackKey{positiveFeedbackType, "a", "6"}, time.Date(1996, 3, 15, 0, 0, 0, 0, time.UTC), "comment"},
-		{FeedbackKey{positiveFeedbackType, "a", "8"}, time.Date(1996, 3, 15, 0, 0, 0, 0, time.UTC), "comment"},
+		{FeedbackKey{positiveFeedbackType, "a", "0"}, time.Date(1996, 3, 15, 0, 0, 0, 0, time.UTC), "comment", 0, nil},
+		{FeedbackKey{positiveFeedbackType, "a", "2"}, time.Date(1996, 3, 15, 0, 0, 0, 0, time.UTC), "comment", 0, nil},
+		{FeedbackKey{positiveFeedbackType, "a", "4"}, time.Date(1996, 3, 15, 0, 0, 0, 0, time.UTC), "comment", 0, nil},
+		{FeedbackKey{positiveFeedbackType, "a", "6"}, time.Date(1996, 3, 15, 0, 0, 0, 0, time.UTC), "comment", 0, nil},
+		{FeedbackKey{positiveFeedbackType, "a", "8"}, time.Date(1996, 3, 15, 0, 0, 0, 0, time.UTC), "comment", 0, nil},
 	}
 	err := suite.Database.BatchInsertFeedback(ctx, feedback, true, true, true)
 	suite.NoError(err)
@@ -565,11 +695,11 @@ func (suite *baseTestSuite) TestDeleteItem() {
 	ctx := context.Background()
 	// Insert ret
 	feedbacks := []Feedback{
-		{FeedbackKey{positiveFeedbackType, "0", "b"}, time.Date(1996, 3, 15, 0, 0, 0, 0, time.UTC), "comment"},
-		{FeedbackKey{positiveFeedbackType, "1", "b"}, time.Date(1996, 3, 15, 0, 0, 0, 0, time.UTC), "comment"},
-		{FeedbackKey{positiveFeedbackType, "2", "b"}, time.Date(1996, 3, 15, 0, 0, 0, 0, time.UTC), "comment"},
-		{FeedbackKey{positiveFeedbackType, "3", "b"}, time.Date(1996, 3, 15, 0, 0, 0, 0, time.UTC), "comment"},
-		{FeedbackKey{positiveFeedbackType, "4", "b"}, time.Date(1996, 3, 15, 0, 0, 0, 0, time.UTC), "comment"},
+		{FeedbackKey{positiveFeedbackType, "0", "b"}, time.Date(1996, 3, 15, 0, 0, 0, 0, time.UTC), "comment", 0, nil},
+		{FeedbackKey{positiveFeedbackType, "1", "b"}, time.Date(1996, 3, 15, 0, 0, 0, 0, time.UTC), "comment", 0, nil},
+		{FeedbackKey{positiveFeedbackType, "2", "b"}, time.Date(1996, 3, 15, 0, 0, 0, 0, time.UTC), "comment", 0, nil},
+		{FeedbackKey{positiveFeedbackType, "3", "b"}, time.Date(1996, 3, 15, 0, 0, 0, 0, time.UTC), "comment", 0, nil},
+		{FeedbackKey{positiveFeedbackType, "4", "b"}, time.Date(1996, 3, 15, 0, 0, 0, 0, time.UTC), "comment", 0, nil},
 	}
 	err := suite.Database.BatchInsertFeedback(ctx, feedbacks, true, true, true)
 	suite.NoError(err)
@@ -589,11 +719,11 @@ func (suite *baseTestSuite) TestDeleteItem() {
 func (suite *baseTestSuite) TestDeleteFeedback() {
 	ctx := context.Background()
 	feedbacks := []Feedback{
-		{FeedbackKey{"type1", "2", "3"}, time.Date(1996, 3, 15, 0, 0, 0, 0, time.UTC), "comment"},
-		{FeedbackKey{"type2", "2", "3"}, time.Date(1996, 3, 15, 0, 0, 0, 0, time.UTC), "comment"},
-		{FeedbackKey{"type3", "2", "3"}, time.Date(1996, 3, 15, 0, 0, 0, 0, time.UTC), "comment"},
-		{FeedbackKey{"type1", "2", "4"}, time.Date(1996, 3, 15, 0, 0, 0, 0, time.UTC), "comment"},
-		{FeedbackKey{"type1", "1", "3"}, time.Date(1996, 3, 15, 0, 0, 0, 0, time.UTC), "comment"},
+		{FeedbackKey{"type1", "2", "3"}, time.Date(1996, 3, 15, 0, 0, 0, 0, time.UTC), "comment", 0, nil},
+		{FeedbackKey{"type2", "2", "3"}, time.Date(1996, 3, 15, 0, 0, 0, 0, time.UTC), "comment", 0, nil},
+		{FeedbackKey{"type3", "2", "3"}, time.Date(1996, 3, 15, 0, 0, 0, 0, time.UTC), "comment", 0, nil},
+		{FeedbackKey{"type1", "2", "4"}, time.Date(1996, 3, 15, 0, 0, 0, 0, time.UTC), "comment", 0, nil},
+		{FeedbackKey{"type1", "1", "3"}, time.Date(1996, 3, 15, 0, 0, 0, 0, time.UTC), "comment", 0, nil},
 	}
 	err := suite.Database.BatchInsertFeedback(ctx, feedbacks, true, true, true)
 	suite.NoError(err)
@@ -673,11 +803,11 @@ func (suite *baseTestSuite) TestTimeLimit() {
 
 	// insert feedback
 	feedbacks := []Feedback{
-		{FeedbackKey{"type1", "2", "3"}, time.Date(1996, 3, 15, 0, 0, 0, 0, time.UTC), "comment"},
-		{FeedbackKey{"type2", "2", "3"}, time.Date(1997, 3, 15, 0, 0, 0, 0, time.UTC), "comment"},
-		{FeedbackKey{"type3", "2", "3"}, time.Date(1998, 3, 15, 0, 0, 0, 0, time.UTC), "comment"},
-		{FeedbackKey{"type1", "2", "4"}, time.Date(1999, 3, 15, 0, 0, 0, 0, time.UTC), "comment"},
-		{FeedbackKey{"type1", "1", "3"}, time.Date(2000, 3, 15, 0, 0, 0, 0, time.UTC), "comment"},
+		{FeedbackKey{"type1", "2", "3"}, time.Date(1996, 3, 15, 0, 0, 0, 0, time.UTC), "comment", 0, nil},
+		{FeedbackKey{"type2", "2", "3"}, time.Date(1997, 3, 15, 0, 0, 0, 0, time.UTC), "comment", 0, nil},
+		{FeedbackKey{"type3", "2", "3"}, time.Date(1998, 3, 15, 0, 0, 0, 0, time.UTC), "comment", 0, nil},
+		{FeedbackKey{"type1", "2", "4"}, time.Date(1999, 3, 15, 0, 0, 0, 0, time.UTC), "comment", 0, nil},
+		{FeedbackKey{"type1", "1", "3"}, time.Date(2000, 3, 15, 0, 0, 0, 0, time.UTC), "comment", 0, nil},
 	}
 	err = suite.Database.BatchInsertFeedback(ctx, feedbacks, true, true, true)
 	suite.NoError(err)
@@ -808,6 +938,141 @@ func (suite *baseTestSuite) TestPurge() {
 	suite.NoError(err)
 }
 
+func (suite *baseTestSuite) TestImpressions() {
+	ctx := context.Background()
+	timestamp := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	impressions := lo.Map(lo.Range(10), func(i int, _ int) Impression {
+		return Impression{
+			RequestId:  strconv.Itoa(i / 5),
+			UserId:     "1",
+			ItemId:     strconv.Itoa(i),
+			Position:   i % 5,
+			Source:     "popular",
+			Score:      float64(10 - i),
+			Propensity: 0.5,
+			Timestamp:  timestamp.Add(time.Duration(i) * time.Hour),
+		}
+	})
+	err := suite.Database.BatchInsertImpressions(ctx, impressions)
+	suite.NoError(err)
+	// duplicate impressions are ignored
+	if !suite.isClickHouse() {
+		err = suite.Database.BatchInsertImpressions(ctx, impressions[:1])
+		suite.NoError(err)
+	}
+	// get impressions
+	var results []Impression
+	impressionChan, errChan := suite.Database.GetImpressionStream(ctx, 3, lo.ToPtr(timestamp.Add(time.Hour)), lo.ToPtr(timestamp.Add(8*time.Hour)))
+	for batch := range impressionChan {
+		results = append(results, batch...)
+	}
+	suite.NoError(<-errChan)
+	if suite.Equal(8, len(results)) {
+		for i, impression := range results {
+			suite.Equal(impressions[i+1].RequestId, impression.RequestId)
+			suite.Equal(impressions[i+1].ItemId, impression.ItemId)
+			suite.Equal(impressions[i+1].Position, impression.Position)
+			suite.Equal(impressions[i+1].Source, impression.Source)
+			suite.Equal(impressions[i+1].Score, impression.Score)
+			suite.Equal(impressions[i+1].Propensity, impression.Propensity)
+			suite.True(impressions[i+1].Timestamp.Equal(impression.Timestamp))
+		}
+	}
+	// delete impressions of a user
+	err = suite.Database.BatchInsertImpressions(ctx, []Impression{{RequestId: "2", UserId: "2", ItemId: "0", Timestamp: timestamp}})
+	suite.NoError(err)
+	count, err := suite.Database.DeleteUserImpressions(ctx, "1")
+	suite.NoError(err)
+	if !suite.isClickHouse() {
+		suite.Equal(10, count)
+	}
+	results = nil
+	impressionChan, errChan = suite.Database.GetImpressionStream(ctx, 3, nil, nil)
+	for batch := range impressionChan {
+		results = append(results, batch...)
+	}
+	suite.NoError(<-errChan)
+	if suite.Equal(1, len(results)) {
+		suite.Equal("2", results[0].UserId)
+	}
+}
+
+func (suite *baseTestSuite) TestEvents() {
+	ctx := context.Background()
+	// insert events
+	var events []Event
+	for i := 0; i < 5; i++ {
+		event, err := NewEvent(EventItemUpserted, strconv.Itoa(i), Item{ItemId: strconv.Itoa(i)})
+		suite.NoError(err)
+		events = append(events, event)
+	}
+	err := suite.Database.InsertEvents(ctx, events[:3])
+	suite.NoError(err)
+	err = suite.Database.InsertEvents(ctx, events[3:])
+	suite.NoError(err)
+	// get pending events in order
+	results, err := suite.Database.GetEvents(ctx, 10)
+	suite.NoError(err)
+	if suite.Equal(5, len(results)) {
+		for i, event := range results {
+			suite.Equal(EventItemUpserted, event.Type)
+			suite.Equal(strconv.Itoa(i), event.Key)
+			suite.JSONEq(events[i].Payload, event.Payload)
+			if i > 0 {
+				suite.Greater(event.Id, results[i-1].Id)
+			}
+		}
+		// get a batch of events
+		batch, err := suite.Database.GetEvents(ctx, 2)
+		suite.NoError(err)
+		suite.Equal([]string{"0", "1"}, lo.Map(batch, func(event Event, _ int) string { return event.Key }))
+		// delete dispatched events
+		err = suite.Database.DeleteEvents(ctx, []int64{results[0].Id, results[2].Id})
+		suite.NoError(err)
+		if !suite.isClickHouse() {
+			after, err := suite.Database.GetEvents(ctx, 10)
+			suite.NoError(err)
+			suite.Equal([]string{"1", "3", "4"}, lo.Map(after, func(event Event, _ int) string { return event.Key }))
+		}
+	}
+}
+
+func (suite *baseTestSuite) TestWriteWithEvents() {
+	if suite.isClickHouse() {
+		suite.T().Skip("ClickHouse doesn't delete events synchronously")
+	}
+	if _, isMongo := suite.Database.(*MongoDB); isMongo {
+		suite.T().Skip("MongoDB transactions require a replica set")
+	}
+	if _, isProxy := suite.Database.(*ProxyClient); isProxy {
+		suite.T().Skip("data store proxy doesn't support events")
+	}
+	ctx := context.Background()
+	withEvent := func(eventType, key string) context.Context {
+		event, err := NewEvent(eventType, key, nil)
+		suite.NoError(err)
+		return WithEvents(ctx, []Event{event})
+	}
+	// events are committed with writes
+	suite.NoError(suite.Database.BatchInsertUsers(withEvent(EventUserUpserted, "0"), []User{{UserId: "0"}}))
+	suite.NoError(suite.Database.ModifyUser(withEvent(EventUserUpserted, "0"), "0", UserPatch{Comment: proto.String("comment")}))
+	suite.NoError(suite.Database.BatchInsertItems(withEvent(EventItemUpserted, "0"), []Item{{ItemId: "0"}}))
+	suite.NoError(suite.Database.ModifyItem(withEvent(EventItemHidden, "0"), "0", ItemPatch{IsHidden: proto.Bool(true)}))
+	suite.NoError(suite.Database.BatchInsertFeedback(withEvent(EventFeedbackInserted, "0"),
+		[]Feedback{{FeedbackKey: FeedbackKey{FeedbackType: "click", UserId: "0", ItemId: "0"}}}, false, false, true))
+	// events aren't committed with failed writes
+	suite.Error(suite.Database.ModifyItem(withEvent(EventItemUpserted, "0"), "0", ItemPatch{Status: lo.ToPtr(StatusRelisted)}))
+	suite.NoError(suite.Database.DeleteItem(withEvent(EventItemDeleted, "0"), "0"))
+	suite.NoError(suite.Database.DeleteUser(withEvent(EventUserDeleted, "0"), "0"))
+	events, err := suite.Database.GetEvents(ctx, 10)
+	suite.NoError(err)
+	suite.Equal([]string{EventUserUpserted, EventUserUpserted, EventItemUpserted, EventItemHidden, EventFeedbackInserted,
+		EventItemDeleted, EventUserDeleted}, lo.Map(events, func(event Event, _ int) string { return event.Type }))
+	user, err := suite.Database.GetUser(ctx, "0")
+	suite.ErrorIs(err, errors.NotFound)
+	suite.Empty(user.UserId)
+}
+
 func TestSortFeedbacks(t *testing.T) {
 	feedback := []Feedback{
 		{FeedbackKey: FeedbackKey{"star", "1", "1"}, Timestamp: time.Date(2000, 10, 1, 0, 0, 0, 0, time.UTC)},
@@ -838,6 +1103,72 @@ func TestValidateLabels(t *testing.T) {
 	assert.Error(t, ValidateLabels(map[string]any{"city": "wenzhou", "tags": []any{"1", "2", json.Number("3")}}))
 }
 
+func TestItemStatus(t *testing.T) {
+	listed := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	item := Item{ItemId: "0", Timestamp: listed}
+	assert.True(t, item.IsVisible())
+	_, _, ok := item.TimeToSell()
+	assert.False(t, ok)
+
+	// sell an active item without recorded listing
+	assert.NoError(t, item.Transit(StatusReserved, listed.Add(time.Hour)))
+	assert.False(t, item.IsVisible())
+	assert.NoError(t, item.Transit(StatusSold, listed.Add(2*time.Hour)))
+	assert.False(t, item.IsVisible())
+	begin, end, ok := item.TimeToSell()
+	assert.True(t, ok)
+	assert.Equal(t, listed, begin)
+	assert.Equal(t, listed.Add(2*time.Hour), end)
+
+	// relist and sell again
+	assert.True(t, errors.Is(item.Transit(StatusActive, listed.Add(3*time.Hour)), errors.NotValid))
+	assert.NoError(t, item.Transit(StatusRelisted, listed.Add(3*time.Hour)))
+	assert.True(t, item.IsVisible())
+	_, _, ok = item.TimeToSell()
+	assert.False(t, ok)
+	assert.NoError(t, item.Transit(StatusSold, listed.Add(5*time.Hour)))
+	begin, end, ok = item.TimeToSell()
+	assert.True(t, ok)
+	assert.Equal(t, listed.Add(3*time.Hour), begin)
+	assert.Equal(t, listed.Add(5*time.Hour), end)
+	assert.Len(t, item.StatusHistory, 4)
+
+	// unchanged status isn't recorded
+	assert.NoError(t, item.Transit(StatusSold, listed.Add(6*time.Hour)))
+	assert.Len(t, item.StatusHistory, 4)
+	// hidden items are invisible
+	item = Item{IsHidden: true, Status: StatusActive}
+	assert.False(t, item.IsVisible())
+	assert.True(t, errors.Is(ItemStatus("unknown").Validate(), errors.NotValid))
+}
+
+func TestParseItemQuery(t *testing.T) {
+	query, err := ParseItemQuery(`{"labels":[{"path":"brand","op":"eq","value":"levis"},{"path":"size.eu","op":"ge","value":42}],` +
+		`"categories":["jeans"],"is_hidden":false,"begin_time":"2024-01-01T00:00:00Z"}`)
+	assert.NoError(t, err)
+	assert.Equal(t, ItemQuery{
+		Labels: []LabelPredicate{
+			{Path: "brand", Op: OpEq, Value: "levis"},
+			{Path: "size.eu", Op: OpGe, Value: float64(42)},
+		},
+		Categories: []string{"jeans"},
+		IsHidden:   lo.ToPtr(false),
+		BeginTime:  lo.ToPtr(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)),
+	}, query)
+	assert.Equal(t, []string{"size", "eu"}, query.Labels[1].Keys())
+	// invalid queries
+	_, err = ParseItemQuery(`{"labels":[{"path":"brand","op":"like","value":"levis"}]}`)
+	assert.True(t, errors.Is(err, errors.NotValid))
+	_, err = ParseItemQuery(`{"labels":[{"path":"size..eu","op":"eq","value":"M"}]}`)
+	assert.True(t, errors.Is(err, errors.NotValid))
+	_, err = ParseItemQuery(`{"labels":[{"path":"brand\\","op":"eq","value":"M"}]}`)
+	assert.True(t, errors.Is(err, errors.NotValid))
+	_, err = ParseItemQuery(`{"labels":[{"path":"tags","op":"eq","value":["a"]}]}`)
+	assert.True(t, errors.Is(err, errors.NotValid))
+	_, err = ParseItemQuery(`{"labels":`)
+	assert.Error(t, err)
+}
+
 func benchmarkCountItems(b *testing.B, db Database) {
 	ctx := context.Background()
 	// Insert 10,000 items
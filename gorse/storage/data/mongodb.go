@@ -75,7 +75,7 @@ func (db *MongoDB) Init() error {
 	ctx := context.Background()
 	d := db.client.Database(db.dbName)
 	// list collections
-	var hasUsers, hasItems, hasFeedback bool
+	var hasUsers, hasItems, hasFeedback, hasImpressions, hasEvents bool
 	collections, err := d.ListCollectionNames(ctx, bson.M{})
 	if err != nil {
 		return errors.Trace(err)
@@ -88,6 +88,10 @@ func (db *MongoDB) Init() error {
 			hasItems = true
 		case db.FeedbackTable():
 			hasFeedback = true
+		case db.ImpressionsTable():
+			hasImpressions = true
+		case db.EventsTable():
+			hasEvents = true
 		}
 	}
 	// create collections
@@ -106,6 +110,16 @@ func (db *MongoDB) Init() error {
 			return errors.Trace(err)
 		}
 	}
+	if !hasImpressions {
+		if err = d.CreateCollection(ctx, db.ImpressionsTable()); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	if !hasEvents {
+		if err = d.CreateCollection(ctx, db.EventsTable()); err != nil {
+			return errors.Trace(err)
+		}
+	}
 	// create index
 	_, err = d.Collection(db.UsersTable()).Indexes().CreateOne(ctx, mongo.IndexModel{
 		Keys: bson.M{
@@ -125,6 +139,13 @@ func (db *MongoDB) Init() error {
 	if err != nil {
 		return errors.Trace(err)
 	}
+	_, err = d.Collection(db.ItemsTable()).Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.M{"categories": 1}},
+		{Keys: bson.M{"labels.$**": 1}},
+	})
+	if err != nil {
+		return errors.Trace(err)
+	}
 	_, err = d.Collection(db.FeedbackTable()).Indexes().CreateOne(ctx, mongo.IndexModel{
 		Keys: bson.M{
 			"feedbackkey": 1,
@@ -150,6 +171,33 @@ func (db *MongoDB) Init() error {
 	if err != nil {
 		return errors.Trace(err)
 	}
+	_, err = d.Collection(db.ImpressionsTable()).Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{
+			{"requestid", 1},
+			{"position", 1},
+		},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		return errors.Trace(err)
+	}
+	_, err = d.Collection(db.ImpressionsTable()).Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.M{
+			"timestamp": 1,
+		},
+	})
+	if err != nil {
+		return errors.Trace(err)
+	}
+	_, err = d.Collection(db.EventsTable()).Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.M{
+			"id": 1,
+		},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		return errors.Trace(err)
+	}
 	return nil
 }
 
@@ -163,7 +211,7 @@ func (db *MongoDB) Close() error {
 }
 
 func (db *MongoDB) Purge() error {
-	tables := []string{db.ItemsTable(), db.FeedbackTable(), db.UsersTable()}
+	tables := []string{db.ItemsTable(), db.FeedbackTable(), db.UsersTable(), db.ImpressionsTable(), db.EventsTable(), db.eventSequenceTable()}
 	for _, tableName := range tables {
 		c := db.client.Database(db.dbName).Collection(tableName)
 		_, err := c.DeleteMany(context.Background(), bson.D{})
@@ -176,19 +224,21 @@ func (db *MongoDB) Purge() error {
 
 // BatchInsertItems insert items into MongoDB.
 func (db *MongoDB) BatchInsertItems(ctx context.Context, items []Item) error {
-	if len(items) == 0 {
-		return nil
-	}
-	c := db.client.Database(db.dbName).Collection(db.ItemsTable())
-	var models []mongo.WriteModel
-	for _, item := range items {
-		models = append(models, mongo.NewUpdateOneModel().
-			SetUpsert(true).
-			SetFilter(bson.M{"itemid": bson.M{"$eq": item.ItemId}}).
-			SetUpdate(bson.M{"$set": item}))
-	}
-	_, err := c.BulkWrite(ctx, models)
-	return errors.Trace(err)
+	return db.withEvents(ctx, func(ctx context.Context) error {
+		if len(items) == 0 {
+			return nil
+		}
+		c := db.client.Database(db.dbName).Collection(db.ItemsTable())
+		var models []mongo.WriteModel
+		for _, item := range items {
+			models = append(models, mongo.NewUpdateOneModel().
+				SetUpsert(true).
+				SetFilter(bson.M{"itemid": bson.M{"$eq": item.ItemId}}).
+				SetUpdate(bson.M{"$set": item}))
+		}
+		_, err := c.BulkWrite(ctx, models)
+		return errors.Trace(err)
+	})
 }
 
 func (db *MongoDB) BatchGetItems(ctx context.Context, itemIds []string) ([]Item, error) {
@@ -215,41 +265,75 @@ func (db *MongoDB) BatchGetItems(ctx context.Context, itemIds []string) ([]Item,
 
 // ModifyItem modify an item in MongoDB.
 func (db *MongoDB) ModifyItem(ctx context.Context, itemId string, patch ItemPatch) error {
-	// create update
-	update := bson.M{}
-	if patch.IsHidden != nil {
-		update["ishidden"] = patch.IsHidden
-	}
-	if patch.Categories != nil {
-		update["categories"] = patch.Categories
-	}
-	if patch.Comment != nil {
-		update["comment"] = patch.Comment
-	}
-	if patch.Labels != nil {
-		update["labels"] = patch.Labels
-	}
-	if patch.Timestamp != nil {
-		update["timestamp"] = patch.Timestamp
-	}
-	// execute
-	c := db.client.Database(db.dbName).Collection(db.ItemsTable())
-	_, err := c.UpdateOne(ctx, bson.M{"itemid": bson.M{"$eq": itemId}}, bson.M{"$set": update})
-	return errors.Trace(err)
+	return db.withEvents(ctx, func(ctx context.Context) error {
+		// create update
+		update := bson.M{}
+		if patch.IsHidden != nil {
+			update["ishidden"] = patch.IsHidden
+		}
+		if patch.Categories != nil {
+			update["categories"] = patch.Categories
+		}
+		if patch.Comment != nil {
+			update["comment"] = patch.Comment
+		}
+		if patch.Labels != nil {
+			update["labels"] = patch.Labels
+		}
+		if patch.Timestamp != nil {
+			update["timestamp"] = patch.Timestamp
+		}
+		filter := bson.M{"itemid": bson.M{"$eq": itemId}}
+		if patch.Status != nil {
+			item, err := db.GetItem(ctx, itemId)
+			if err != nil {
+				return errors.Trace(err)
+			}
+			status := item.Status
+			if err = item.Transit(*patch.Status, time.Now()); err != nil {
+				return errors.Trace(err)
+			}
+			if item.Status != status {
+				// the status is written only if it isn't changed since it was read
+				if status == "" {
+					filter["status"] = bson.M{"$in": bson.A{"", nil}}
+				} else {
+					filter["status"] = status
+				}
+				update["status"] = item.Status
+				update["statushistory"] = item.StatusHistory
+			}
+		}
+		if len(update) == 0 {
+			return nil
+		}
+		// execute
+		c := db.client.Database(db.dbName).Collection(db.ItemsTable())
+		result, err := c.UpdateOne(ctx, filter, bson.M{"$set": update})
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if _, conditional := filter["status"]; conditional && result.MatchedCount == 0 {
+			return errors.Trace(ErrStatusConflict)
+		}
+		return nil
+	})
 }
 
 // DeleteItem deletes a item from MongoDB.
 func (db *MongoDB) DeleteItem(ctx context.Context, itemId string) error {
-	c := db.client.Database(db.dbName).Collection(db.ItemsTable())
-	_, err := c.DeleteOne(ctx, bson.M{"itemid": itemId})
-	if err != nil {
+	return db.withEvents(ctx, func(ctx context.Context) error {
+		c := db.client.Database(db.dbName).Collection(db.ItemsTable())
+		_, err := c.DeleteOne(ctx, bson.M{"itemid": itemId})
+		if err != nil {
+			return errors.Trace(err)
+		}
+		c = db.client.Database(db.dbName).Collection(db.FeedbackTable())
+		_, err = c.DeleteMany(ctx, bson.M{
+			"feedbackkey.itemid": bson.M{"$eq": itemId},
+		})
 		return errors.Trace(err)
-	}
-	c = db.client.Database(db.dbName).Collection(db.FeedbackTable())
-	_, err = c.DeleteMany(ctx, bson.M{
-		"feedbackkey.itemid": bson.M{"$eq": itemId},
 	})
-	return errors.Trace(err)
 }
 
 // GetItem returns a item from MongoDB.
@@ -302,6 +386,74 @@ func (db *MongoDB) GetItems(ctx context.Context, cursor string, n int, timeLimit
 	return base64.StdEncoding.EncodeToString([]byte(cursor)), items, nil
 }
 
+var mongoOperators = map[Operator]string{
+	OpEq: "$eq",
+	OpNe: "$ne",
+	OpLt: "$lt",
+	OpLe: "$lte",
+	OpGt: "$gt",
+	OpGe: "$gte",
+}
+
+// GetItemsByQuery returns items matching a query from MongoDB.
+func (db *MongoDB) GetItemsByQuery(ctx context.Context, query ItemQuery, cursor string, n int) (string, []Item, error) {
+	if err := query.Validate(); err != nil {
+		return "", nil, errors.Trace(err)
+	}
+	buf, err := base64.StdEncoding.DecodeString(cursor)
+	if err != nil {
+		return "", nil, errors.Trace(err)
+	}
+	cursorItem := string(buf)
+	c := db.client.Database(db.dbName).Collection(db.ItemsTable())
+	opt := options.Find()
+	opt.SetLimit(int64(n))
+	opt.SetSort(bson.D{{"itemid", 1}})
+	conditions := bson.A{bson.M{"itemid": bson.M{"$gt": cursorItem}}}
+	for _, predicate := range query.Labels {
+		bsonType := "string"
+		if predicate.IsNumber() {
+			bsonType = "number"
+		}
+		conditions = append(conditions, bson.M{"labels." + predicate.Path: bson.M{
+			"$type":                      bsonType,
+			mongoOperators[predicate.Op]: predicate.Value,
+		}})
+	}
+	if len(query.Categories) > 0 {
+		conditions = append(conditions, bson.M{"categories": bson.M{"$all": query.Categories}})
+	}
+	if query.IsHidden != nil {
+		conditions = append(conditions, bson.M{"ishidden": *query.IsHidden})
+	}
+	if query.BeginTime != nil {
+		conditions = append(conditions, bson.M{"timestamp": bson.M{"$gte": *query.BeginTime}})
+	}
+	if query.EndTime != nil {
+		conditions = append(conditions, bson.M{"timestamp": bson.M{"$lte": *query.EndTime}})
+	}
+	r, err := c.Find(ctx, bson.M{"$and": conditions}, opt)
+	if err != nil {
+		return "", nil, errors.Trace(err)
+	}
+	items := make([]Item, 0)
+	defer r.Close(ctx)
+	for r.Next(ctx) {
+		var item Item
+		if err = r.Decode(&item); err != nil {
+			return "", nil, errors.Trace(err)
+		}
+		item.Labels = unpack(item.Labels)
+		items = append(items, item)
+	}
+	if len(items) == n {
+		cursor = items[n-1].ItemId
+	} else {
+		cursor = ""
+	}
+	return base64.StdEncoding.EncodeToString([]byte(cursor)), items, nil
+}
+
 // GetItemStream read items from MongoDB by stream.
 func (db *MongoDB) GetItemStream(ctx context.Context, batchSize int, timeLimit *time.Time) (chan []Item, chan error) {
 	itemChan := make(chan []Item, bufSize)
@@ -376,52 +528,58 @@ func (db *MongoDB) GetItemFeedback(ctx context.Context, itemId string, feedbackT
 
 // BatchInsertUsers inserts a user into MongoDB.
 func (db *MongoDB) BatchInsertUsers(ctx context.Context, users []User) error {
-	if len(users) == 0 {
-		return nil
-	}
-	c := db.client.Database(db.dbName).Collection(db.UsersTable())
-	var models []mongo.WriteModel
-	for _, user := range users {
-		models = append(models, mongo.NewUpdateOneModel().
-			SetUpsert(true).
-			SetFilter(bson.M{"userid": bson.M{"$eq": user.UserId}}).
-			SetUpdate(bson.M{"$set": user}))
-	}
-	_, err := c.BulkWrite(ctx, models)
-	return errors.Trace(err)
+	return db.withEvents(ctx, func(ctx context.Context) error {
+		if len(users) == 0 {
+			return nil
+		}
+		c := db.client.Database(db.dbName).Collection(db.UsersTable())
+		var models []mongo.WriteModel
+		for _, user := range users {
+			models = append(models, mongo.NewUpdateOneModel().
+				SetUpsert(true).
+				SetFilter(bson.M{"userid": bson.M{"$eq": user.UserId}}).
+				SetUpdate(bson.M{"$set": user}))
+		}
+		_, err := c.BulkWrite(ctx, models)
+		return errors.Trace(err)
+	})
 }
 
 // ModifyUser modify a user in MongoDB.
 func (db *MongoDB) ModifyUser(ctx context.Context, userId string, patch UserPatch) error {
-	// create patch
-	update := bson.M{}
-	if patch.Labels != nil {
-		update["labels"] = patch.Labels
-	}
-	if patch.Comment != nil {
-		update["comment"] = patch.Comment
-	}
-	if patch.Subscribe != nil {
-		update["subscribe"] = patch.Subscribe
-	}
-	// execute
-	c := db.client.Database(db.dbName).Collection(db.UsersTable())
-	_, err := c.UpdateOne(ctx, bson.M{"userid": bson.M{"$eq": userId}}, bson.M{"$set": update})
-	return errors.Trace(err)
+	return db.withEvents(ctx, func(ctx context.Context) error {
+		// create patch
+		update := bson.M{}
+		if patch.Labels != nil {
+			update["labels"] = patch.Labels
+		}
+		if patch.Comment != nil {
+			update["comment"] = patch.Comment
+		}
+		if patch.Subscribe != nil {
+			update["subscribe"] = patch.Subscribe
+		}
+		// execute
+		c := db.client.Database(db.dbName).Collection(db.UsersTable())
+		_, err := c.UpdateOne(ctx, bson.M{"userid": bson.M{"$eq": userId}}, bson.M{"$set": update})
+		return errors.Trace(err)
+	})
 }
 
 // DeleteUser deletes a user from MongoDB.
 func (db *MongoDB) DeleteUser(ctx context.Context, userId string) error {
-	c := db.client.Database(db.dbName).Collection(db.UsersTable())
-	_, err := c.DeleteOne(ctx, bson.M{"userid": userId})
-	if err != nil {
+	return db.withEvents(ctx, func(ctx context.Context) error {
+		c := db.client.Database(db.dbName).Collection(db.UsersTable())
+		_, err := c.DeleteOne(ctx, bson.M{"userid": userId})
+		if err != nil {
+			return errors.Trace(err)
+		}
+		c = db.client.Database(db.dbName).Collection(db.FeedbackTable())
+		_, err = c.DeleteMany(ctx, bson.M{
+			"feedbackkey.userid": bson.M{"$eq": userId},
+		})
 		return errors.Trace(err)
-	}
-	c = db.client.Database(db.dbName).Collection(db.FeedbackTable())
-	_, err = c.DeleteMany(ctx, bson.M{
-		"feedbackkey.userid": bson.M{"$eq": userId},
 	})
-	return errors.Trace(err)
 }
 
 // GetUser returns a user from MongoDB.
@@ -541,94 +699,96 @@ func (db *MongoDB) GetUserFeedback(ctx context.Context, userId string, endTime *
 
 // BatchInsertFeedback returns multiple feedback into MongoDB.
 func (db *MongoDB) BatchInsertFeedback(ctx context.Context, feedback []Feedback, insertUser, insertItem, overwrite bool) error {
-	// skip empty list
-	if len(feedback) == 0 {
-		return nil
-	}
-	// collect users and items
-	users := mapset.NewSet[string]()
-	items := mapset.NewSet[string]()
-	for _, v := range feedback {
-		users.Add(v.UserId)
-		items.Add(v.ItemId)
-	}
-	// insert users
-	userList := users.ToSlice()
-	if insertUser {
-		var models []mongo.WriteModel
-		for _, userId := range userList {
-			models = append(models, mongo.NewUpdateOneModel().
-				SetUpsert(true).
-				SetFilter(bson.M{"userid": bson.M{"$eq": userId}}).
-				SetUpdate(bson.M{"$setOnInsert": User{UserId: userId}}))
+	return db.withEvents(ctx, func(ctx context.Context) error {
+		// skip empty list
+		if len(feedback) == 0 {
+			return nil
 		}
-		c := db.client.Database(db.dbName).Collection(db.UsersTable())
-		_, err := c.BulkWrite(ctx, models)
-		if err != nil {
-			return errors.Trace(err)
+		// collect users and items
+		users := mapset.NewSet[string]()
+		items := mapset.NewSet[string]()
+		for _, v := range feedback {
+			users.Add(v.UserId)
+			items.Add(v.ItemId)
 		}
-	} else {
-		for _, userId := range userList {
-			_, err := db.GetUser(ctx, userId)
+		// insert users
+		userList := users.ToSlice()
+		if insertUser {
+			var models []mongo.WriteModel
+			for _, userId := range userList {
+				models = append(models, mongo.NewUpdateOneModel().
+					SetUpsert(true).
+					SetFilter(bson.M{"userid": bson.M{"$eq": userId}}).
+					SetUpdate(bson.M{"$setOnInsert": User{UserId: userId}}))
+			}
+			c := db.client.Database(db.dbName).Collection(db.UsersTable())
+			_, err := c.BulkWrite(ctx, models)
 			if err != nil {
-				if errors.Is(err, errors.NotFound) {
-					users.Remove(userId)
-					continue
-				}
 				return errors.Trace(err)
 			}
+		} else {
+			for _, userId := range userList {
+				_, err := db.GetUser(ctx, userId)
+				if err != nil {
+					if errors.Is(err, errors.NotFound) {
+						users.Remove(userId)
+						continue
+					}
+					return errors.Trace(err)
+				}
+			}
 		}
-	}
-	// insert items
-	itemList := items.ToSlice()
-	if insertItem {
-		var models []mongo.WriteModel
-		for _, itemId := range itemList {
-			models = append(models, mongo.NewUpdateOneModel().
-				SetUpsert(true).
-				SetFilter(bson.M{"itemid": bson.M{"$eq": itemId}}).
-				SetUpdate(bson.M{"$setOnInsert": Item{ItemId: itemId}}))
-		}
-		c := db.client.Database(db.dbName).Collection(db.ItemsTable())
-		_, err := c.BulkWrite(ctx, models)
-		if err != nil {
-			return errors.Trace(err)
-		}
-	} else {
-		for _, itemId := range itemList {
-			_, err := db.GetItem(ctx, itemId)
+		// insert items
+		itemList := items.ToSlice()
+		if insertItem {
+			var models []mongo.WriteModel
+			for _, itemId := range itemList {
+				models = append(models, mongo.NewUpdateOneModel().
+					SetUpsert(true).
+					SetFilter(bson.M{"itemid": bson.M{"$eq": itemId}}).
+					SetUpdate(bson.M{"$setOnInsert": Item{ItemId: itemId}}))
+			}
+			c := db.client.Database(db.dbName).Collection(db.ItemsTable())
+			_, err := c.BulkWrite(ctx, models)
 			if err != nil {
-				if errors.Is(err, errors.NotFound) {
-					items.Remove(itemId)
-					continue
-				}
 				return errors.Trace(err)
 			}
+		} else {
+			for _, itemId := range itemList {
+				_, err := db.GetItem(ctx, itemId)
+				if err != nil {
+					if errors.Is(err, errors.NotFound) {
+						items.Remove(itemId)
+						continue
+					}
+					return errors.Trace(err)
+				}
+			}
 		}
-	}
-	// insert feedback
-	c := db.client.Database(db.dbName).Collection(db.FeedbackTable())
-	var models []mongo.WriteModel
-	for _, f := range feedback {
-		if users.Contains(f.UserId) && items.Contains(f.ItemId) {
-			model := mongo.NewUpdateOneModel().
-				SetUpsert(true).
-				SetFilter(bson.M{
-					"feedbackkey": f.FeedbackKey,
-				})
-			if overwrite {
-				model.SetUpdate(bson.M{"$set": f})
-			} else {
-				model.SetUpdate(bson.M{"$setOnInsert": f})
+		// insert feedback
+		c := db.client.Database(db.dbName).Collection(db.FeedbackTable())
+		var models []mongo.WriteModel
+		for _, f := range feedback {
+			if users.Contains(f.UserId) && items.Contains(f.ItemId) {
+				model := mongo.NewUpdateOneModel().
+					SetUpsert(true).
+					SetFilter(bson.M{
+						"feedbackkey": f.FeedbackKey,
+					})
+				if overwrite {
+					model.SetUpdate(bson.M{"$set": f})
+				} else {
+					model.SetUpdate(bson.M{"$setOnInsert": f})
+				}
+				models = append(models, model)
 			}
-			models = append(models, model)
 		}
-	}
-	if len(models) == 0 {
-		return nil
-	}
-	_, err := c.BulkWrite(ctx, models)
-	return errors.Trace(err)
+		if len(models) == 0 {
+			return nil
+		}
+		_, err := c.BulkWrite(ctx, models)
+		return errors.Trace(err)
+	})
 }
 
 // GetFeedback returns multiple feedback from MongoDB.
@@ -824,3 +984,172 @@ func (db *MongoDB) CountFeedback(ctx context.Context) (int, error) {
 	n, err := db.client.Database(db.dbName).Collection(db.FeedbackTable()).EstimatedDocumentCount(ctx)
 	return int(n), err
 }
+
+// BatchInsertImpressions appends impressions to MongoDB. Existing impressions are ignored.
+func (db *MongoDB) BatchInsertImpressions(ctx context.Context, impressions []Impression) error {
+	if len(impressions) == 0 {
+		return nil
+	}
+	c := db.client.Database(db.dbName).Collection(db.ImpressionsTable())
+	var models []mongo.WriteModel
+	for _, impression := range impressions {
+		models = append(models, mongo.NewUpdateOneModel().
+			SetUpsert(true).
+			SetFilter(bson.M{"requestid": impression.RequestId, "position": impression.Position}).
+			SetUpdate(bson.M{"$setOnInsert": impression}))
+	}
+	_, err := c.BulkWrite(ctx, models)
+	return errors.Trace(err)
+}
+
+// GetImpressionStream reads impressions served in [beginTime, endTime] from MongoDB by stream.
+func (db *MongoDB) GetImpressionStream(ctx context.Context, batchSize int, beginTime, endTime *time.Time) (chan []Impression, chan error) {
+	impressionChan := make(chan []Impression, bufSize)
+	errChan := make(chan error, 1)
+	go func() {
+		defer close(impressionChan)
+		defer close(errChan)
+		// send query
+		c := db.client.Database(db.dbName).Collection(db.ImpressionsTable())
+		filter := make(bson.M)
+		if beginTime != nil || endTime != nil {
+			timestampConditions := bson.M{}
+			if beginTime != nil {
+				timestampConditions["$gte"] = *beginTime
+			}
+			if endTime != nil {
+				timestampConditions["$lte"] = *endTime
+			}
+			filter["timestamp"] = timestampConditions
+		}
+		r, err := c.Find(ctx, filter, options.Find().SetSort(bson.D{{"timestamp", 1}}))
+		if err != nil {
+			errChan <- errors.Trace(err)
+			return
+		}
+		impressions := make([]Impression, 0, batchSize)
+		defer r.Close(ctx)
+		for r.Next(ctx) {
+			var impression Impression
+			if err = r.Decode(&impression); err != nil {
+				errChan <- errors.Trace(err)
+				return
+			}
+			impressions = append(impressions, impression)
+			if len(impressions) == batchSize {
+				impressionChan <- impressions
+				impressions = make([]Impression, 0, batchSize)
+			}
+		}
+		if len(impressions) > 0 {
+			impressionChan <- impressions
+		}
+		errChan <- nil
+	}()
+	return impressionChan, errChan
+}
+
+// DeleteUserImpressions deletes impressions served to a user from MongoDB.
+func (db *MongoDB) DeleteUserImpressions(ctx context.Context, userId string) (int, error) {
+	c := db.client.Database(db.dbName).Collection(db.ImpressionsTable())
+	r, err := c.DeleteMany(ctx, bson.M{"userid": userId})
+	if err != nil {
+		return 0, errors.Trace(err)
+	}
+	return int(r.DeletedCount), nil
+}
+
+// eventSequenceTable returns the collection holding the last assigned event ID.
+func (db *MongoDB) eventSequenceTable() string {
+	return db.EventsTable() + "_sequence"
+}
+
+// withEvents runs a write with change events carried by the context. Events are inserted in the same transaction as
+// the write, which requires MongoDB deployed as a replica set or a sharded cluster.
+func (db *MongoDB) withEvents(ctx context.Context, write func(ctx context.Context) error) error {
+	events := eventsFromContext(ctx)
+	if len(events) == 0 {
+		return write(ctx)
+	}
+	session, err := db.client.StartSession()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	defer session.EndSession(ctx)
+	_, err = session.WithTransaction(ctx, func(ctx mongo.SessionContext) (interface{}, error) {
+		if err := write(ctx); err != nil {
+			return nil, err
+		}
+		return nil, db.insertEvents(ctx, events)
+	})
+	return errors.Trace(err)
+}
+
+// InsertEvents appends change events to MongoDB without any change of data, such as refreshed recommendation.
+func (db *MongoDB) InsertEvents(ctx context.Context, events []Event) error {
+	if len(events) == 0 {
+		return nil
+	}
+	return db.insertEvents(ctx, events)
+}
+
+// insertEvents appends change events to MongoDB. A range of event IDs is reserved by incrementing the sequence.
+func (db *MongoDB) insertEvents(ctx context.Context, events []Event) error {
+	var sequence struct {
+		Value int64 `bson:"value"`
+	}
+	err := db.client.Database(db.dbName).Collection(db.eventSequenceTable()).FindOneAndUpdate(ctx,
+		bson.M{"_id": "events"},
+		bson.M{"$inc": bson.M{"value": int64(len(events))}},
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)).Decode(&sequence)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	documents := make([]interface{}, 0, len(events))
+	for i, event := range events {
+		event.Id = sequence.Value - int64(len(events)) + int64(i) + 1
+		documents = append(documents, event)
+	}
+	_, err = db.client.Database(db.dbName).Collection(db.EventsTable()).InsertMany(ctx, documents)
+	return errors.Trace(err)
+}
+
+// GetEvents returns at most n pending change events from MongoDB in the order of IDs.
+func (db *MongoDB) GetEvents(ctx context.Context, n int) ([]Event, error) {
+	c := db.client.Database(db.dbName).Collection(db.EventsTable())
+	r, err := c.Find(ctx, bson.M{},
+		options.Find().SetSort(bson.M{"id": 1}).SetLimit(int64(n)))
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	defer r.Close(ctx)
+	var events []Event
+	for r.Next(ctx) {
+		var event Event
+		if err = r.Decode(&event); err != nil {
+			return nil, errors.Trace(err)
+		}
+		events = append(events, event)
+	}
+	return events, nil
+}
+
+// DeleteEvents deletes dispatched change events from MongoDB.
+func (db *MongoDB) DeleteEvents(ctx context.Context, ids []int64) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	c := db.client.Database(db.dbName).Collection(db.EventsTable())
+	_, err := c.DeleteMany(ctx, bson.M{"id": bson.M{"$in": ids}})
+	return errors.Trace(err)
+}
+
+// DeleteUserEvents deletes pending change events keyed by a user from MongoDB.
+func (db *MongoDB) DeleteUserEvents(ctx context.Context, userId string) (int, error) {
+	c := db.client.Database(db.dbName).Collection(db.EventsTable())
+	r, err := c.DeleteMany(ctx, bson.M{"key": userId, "type": bson.M{"$in": UserEventTypes}})
+	if err != nil {
+		return 0, errors.Trace(err)
+	}
+	return int(r.DeletedCount), nil
+}
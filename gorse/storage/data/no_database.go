@@ -70,6 +70,11 @@ func (NoDatabase) GetItems(_ context.Context, _ string, _ int, _ *time.Time) (st
 	return "", nil, ErrNoDatabase
 }
 
+// GetItemsByQuery method of NoDatabase returns ErrNoDatabase.
+func (NoDatabase) GetItemsByQuery(_ context.Context, _ ItemQuery, _ string, _ int) (string, []Item, error) {
+	return "", nil, ErrNoDatabase
+}
+
 // GetItemStream method of NoDatabase returns ErrNoDatabase.
 func (NoDatabase) GetItemStream(_ context.Context, _ int, _ *time.Time) (chan []Item, chan error) {
 	itemChan := make(chan []Item, bufSize)
@@ -175,3 +180,45 @@ func (d NoDatabase) CountItems(_ context.Context) (int, error) {
 func (d NoDatabase) CountFeedback(_ context.Context) (int, error) {
 	return 0, ErrNoDatabase
 }
+
+// BatchInsertImpressions method of NoDatabase returns ErrNoDatabase.
+func (d NoDatabase) BatchInsertImpressions(_ context.Context, _ []Impression) error {
+	return ErrNoDatabase
+}
+
+// GetImpressionStream method of NoDatabase returns ErrNoDatabase.
+func (NoDatabase) GetImpressionStream(_ context.Context, _ int, _, _ *time.Time) (chan []Impression, chan error) {
+	impressionChan := make(chan []Impression, bufSize)
+	errChan := make(chan error, 1)
+	go func() {
+		defer close(impressionChan)
+		defer close(errChan)
+		errChan <- ErrNoDatabase
+	}()
+	return impressionChan, errChan
+}
+
+// DeleteUserImpressions method of NoDatabase returns ErrNoDatabase.
+func (NoDatabase) DeleteUserImpressions(_ context.Context, _ string) (int, error) {
+	return 0, ErrNoDatabase
+}
+
+// InsertEvents method of NoDatabase returns ErrNoDatabase.
+func (NoDatabase) InsertEvents(_ context.Context, _ []Event) error {
+	return ErrNoDatabase
+}
+
+// GetEvents method of NoDatabase returns ErrNoDatabase.
+func (NoDatabase) GetEvents(_ context.Context, _ int) ([]Event, error) {
+	return nil, ErrNoDatabase
+}
+
+// DeleteEvents method of NoDatabase returns ErrNoDatabase.
+func (NoDatabase) DeleteEvents(_ context.Context, _ []int64) error {
+	return ErrNoDatabase
+}
+
+// DeleteUserEvents method of NoDatabase returns ErrNoDatabase.
+func (NoDatabase) DeleteUserEvents(_ context.Context, _ string) (int, error) {
+	return 0, ErrNoDatabase
+}
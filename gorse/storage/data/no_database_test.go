@@ -48,6 +48,8 @@ func TestNoDatabase(t *testing.T) {
 	assert.ErrorIs(t, err, ErrNoDatabase)
 	_, _, err = database.GetItems(ctx, "", 0, nil)
 	assert.ErrorIs(t, err, ErrNoDatabase)
+	_, _, err = database.GetItemsByQuery(ctx, ItemQuery{}, "", 0)
+	assert.ErrorIs(t, err, ErrNoDatabase)
 	err = database.DeleteItem(ctx, "")
 	assert.ErrorIs(t, err, ErrNoDatabase)
 	_, c := database.GetItemStream(ctx, 0, nil)
@@ -89,4 +91,20 @@ func TestNoDatabase(t *testing.T) {
 	assert.ErrorIs(t, err, ErrNoDatabase)
 	_, err = database.CountFeedback(ctx)
 	assert.ErrorIs(t, err, ErrNoDatabase)
+
+	err = database.BatchInsertImpressions(ctx, nil)
+	assert.ErrorIs(t, err, ErrNoDatabase)
+	_, c = database.GetImpressionStream(ctx, 0, nil, nil)
+	assert.ErrorIs(t, <-c, ErrNoDatabase)
+	_, err = database.DeleteUserImpressions(ctx, "")
+	assert.ErrorIs(t, err, ErrNoDatabase)
+
+	err = database.InsertEvents(ctx, nil)
+	assert.ErrorIs(t, err, ErrNoDatabase)
+	_, err = database.GetEvents(ctx, 0)
+	assert.ErrorIs(t, err, ErrNoDatabase)
+	err = database.DeleteEvents(ctx, nil)
+	assert.ErrorIs(t, err, ErrNoDatabase)
+	_, err = database.DeleteUserEvents(ctx, "")
+	assert.ErrorIs(t, err, ErrNoDatabase)
 }
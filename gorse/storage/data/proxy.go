@@ -187,6 +187,8 @@ func (p *ProxyServer) GetItemFeedback(ctx context.Context, in *protocol.GetItemF
 			ItemId:       f.ItemId,
 			Timestamp:    timestamppb.New(f.Timestamp),
 			Comment:      f.Comment,
+			Value:        f.Value,
+			Context:      encodeFeedbackContext(f.Context),
 		}
 	}
 	return &protocol.GetFeedbackResponse{Feedback: pbFeedback}, nil
@@ -292,6 +294,8 @@ func (p *ProxyServer) GetUserFeedback(ctx context.Context, in *protocol.GetUserF
 			ItemId:       f.ItemId,
 			Timestamp:    timestamppb.New(f.Timestamp),
 			Comment:      f.Comment,
+			Value:        f.Value,
+			Context:      encodeFeedbackContext(f.Context),
 		}
 	}
 	return &protocol.GetFeedbackResponse{Feedback: pbFeedback}, nil
@@ -310,6 +314,8 @@ func (p *ProxyServer) GetUserItemFeedback(ctx context.Context, in *protocol.GetU
 			ItemId:       f.ItemId,
 			Timestamp:    timestamppb.New(f.Timestamp),
 			Comment:      f.Comment,
+			Value:        f.Value,
+			Context:      encodeFeedbackContext(f.Context),
 		}
 	}
 	return &protocol.GetFeedbackResponse{Feedback: pbFeedback}, nil
@@ -331,6 +337,8 @@ func (p *ProxyServer) BatchInsertFeedback(ctx context.Context, in *protocol.Batc
 			},
 			Timestamp: f.Timestamp.AsTime(),
 			Comment:   f.Comment,
+			Value:     f.Value,
+			Context:   decodeFeedbackContext(f.Context),
 		}
 	}
 	err := p.database.BatchInsertFeedback(ctx, feedback, in.InsertUser, in.InsertItem, in.Overwrite)
@@ -357,6 +365,8 @@ func (p *ProxyServer) GetFeedback(ctx context.Context, in *protocol.GetFeedbackR
 			ItemId:       f.ItemId,
 			Timestamp:    timestamppb.New(f.Timestamp),
 			Comment:      f.Comment,
+			Value:        f.Value,
+			Context:      encodeFeedbackContext(f.Context),
 		}
 	}
 	return &protocol.GetFeedbackResponse{Cursor: cursor, Feedback: pbFeedback}, nil
@@ -452,6 +462,8 @@ func (p *ProxyServer) GetFeedbackStream(in *protocol.GetFeedbackStreamRequest, s
 				ItemId:       f.ItemId,
 				Timestamp:    timestamppb.New(f.Timestamp),
 				Comment:      f.Comment,
+				Value:        f.Value,
+				Context:      encodeFeedbackContext(f.Context),
 			}
 		}
 		err := stream.Send(&protocol.GetFeedbackStreamResponse{Feedback: pbFeedback})
@@ -509,6 +521,9 @@ func (p ProxyClient) Purge() error {
 }
 
 func (p ProxyClient) BatchInsertItems(ctx context.Context, items []Item) error {
+	if err := checkEvents(ctx); err != nil {
+		return err
+	}
 	pbItems := make([]*protocol.Item, len(items))
 	for i, item := range items {
 		labels, err := json.Marshal(item.Labels)
@@ -553,6 +568,9 @@ func (p ProxyClient) BatchGetItems(ctx context.Context, itemIds []string) ([]Ite
 }
 
 func (p ProxyClient) DeleteItem(ctx context.Context, itemId string) error {
+	if err := checkEvents(ctx); err != nil {
+		return err
+	}
 	_, err := p.DataStoreClient.DeleteItem(ctx, &protocol.DeleteItemRequest{ItemId: itemId})
 	return err
 }
@@ -580,6 +598,9 @@ func (p ProxyClient) GetItem(ctx context.Context, itemId string) (Item, error) {
 }
 
 func (p ProxyClient) ModifyItem(ctx context.Context, itemId string, patch ItemPatch) error {
+	if err := checkEvents(ctx); err != nil {
+		return err
+	}
 	var labels []byte
 	if patch.Labels != nil {
 		var err error
@@ -633,6 +654,11 @@ func (p ProxyClient) GetItems(ctx context.Context, cursor string, n int, beginTi
 	return resp.Cursor, items, nil
 }
 
+// GetItemsByQuery isn't supported by the data store proxy.
+func (p ProxyClient) GetItemsByQuery(_ context.Context, _ ItemQuery, _ string, _ int) (string, []Item, error) {
+	return "", nil, errors.NotSupportedf("item queries in data store proxy")
+}
+
 func (p ProxyClient) GetItemFeedback(ctx context.Context, itemId string, feedbackTypes ...string) ([]Feedback, error) {
 	resp, err := p.DataStoreClient.GetItemFeedback(ctx, &protocol.GetItemFeedbackRequest{
 		ItemId:        itemId,
@@ -651,12 +677,17 @@ func (p ProxyClient) GetItemFeedback(ctx context.Context, itemId string, feedbac
 			},
 			Timestamp: f.Timestamp.AsTime(),
 			Comment:   f.Comment,
+			Value:     f.Value,
+			Context:   decodeFeedbackContext(f.Context),
 		}
 	}
 	return feedback, nil
 }
 
 func (p ProxyClient) BatchInsertUsers(ctx context.Context, users []User) error {
+	if err := checkEvents(ctx); err != nil {
+		return err
+	}
 	pbUsers := make([]*protocol.User, len(users))
 	for i, user := range users {
 		labels, err := json.Marshal(user.Labels)
@@ -675,6 +706,9 @@ func (p ProxyClient) BatchInsertUsers(ctx context.Context, users []User) error {
 }
 
 func (p ProxyClient) DeleteUser(ctx context.Context, userId string) error {
+	if err := checkEvents(ctx); err != nil {
+		return err
+	}
 	_, err := p.DataStoreClient.DeleteUser(ctx, &protocol.DeleteUserRequest{UserId: userId})
 	return err
 }
@@ -700,6 +734,9 @@ func (p ProxyClient) GetUser(ctx context.Context, userId string) (User, error) {
 }
 
 func (p ProxyClient) ModifyUser(ctx context.Context, userId string, patch UserPatch) error {
+	if err := checkEvents(ctx); err != nil {
+		return err
+	}
 	var labels []byte
 	if patch.Labels != nil {
 		var err error
@@ -763,6 +800,8 @@ func (p ProxyClient) GetUserFeedback(ctx context.Context, userId string, endTime
 			},
 			Timestamp: f.Timestamp.AsTime(),
 			Comment:   f.Comment,
+			Value:     f.Value,
+			Context:   decodeFeedbackContext(f.Context),
 		}
 	}
 	return feedback, nil
@@ -787,6 +826,8 @@ func (p ProxyClient) GetUserItemFeedback(ctx context.Context, userId, itemId str
 			},
 			Timestamp: f.Timestamp.AsTime(),
 			Comment:   f.Comment,
+			Value:     f.Value,
+			Context:   decodeFeedbackContext(f.Context),
 		}
 	}
 	return feedback, nil
@@ -805,6 +846,9 @@ func (p ProxyClient) DeleteUserItemFeedback(ctx context.Context, userId, itemId
 }
 
 func (p ProxyClient) BatchInsertFeedback(ctx context.Context, feedback []Feedback, insertUser, insertItem, overwrite bool) error {
+	if err := checkEvents(ctx); err != nil {
+		return err
+	}
 	reqFeedback := make([]*protocol.Feedback, len(feedback))
 	for i, f := range feedback {
 		reqFeedback[i] = &protocol.Feedback{
@@ -813,6 +857,8 @@ func (p ProxyClient) BatchInsertFeedback(ctx context.Context, feedback []Feedbac
 			ItemId:       f.ItemId,
 			Timestamp:    timestamppb.New(f.Timestamp),
 			Comment:      f.Comment,
+			Value:        f.Value,
+			Context:      encodeFeedbackContext(f.Context),
 		}
 	}
 	_, err := p.DataStoreClient.BatchInsertFeedback(ctx, &protocol.BatchInsertFeedbackRequest{
@@ -852,6 +898,8 @@ func (p ProxyClient) GetFeedback(ctx context.Context, cursor string, n int, begi
 			},
 			Timestamp: f.Timestamp.AsTime(),
 			Comment:   f.Comment,
+			Value:     f.Value,
+			Context:   decodeFeedbackContext(f.Context),
 		}
 	}
 	return resp.Cursor, feedback, nil
@@ -993,6 +1041,8 @@ func (p ProxyClient) GetFeedbackStream(ctx context.Context, batchSize int, optio
 					},
 					Timestamp: f.Timestamp.AsTime(),
 					Comment:   f.Comment,
+					Value:     f.Value,
+					Context:   decodeFeedbackContext(f.Context),
 				}
 			}
 			feedbackChan <- feedback
@@ -1024,3 +1074,72 @@ func (p ProxyClient) CountFeedback(ctx context.Context) (int, error) {
 	}
 	return int(resp.Count), nil
 }
+
+// BatchInsertImpressions isn't supported by the data store proxy.
+func (p ProxyClient) BatchInsertImpressions(_ context.Context, _ []Impression) error {
+	return errors.NotSupportedf("impressions in data store proxy")
+}
+
+// GetImpressionStream isn't supported by the data store proxy.
+func (p ProxyClient) GetImpressionStream(_ context.Context, _ int, _, _ *time.Time) (chan []Impression, chan error) {
+	impressionChan := make(chan []Impression, bufSize)
+	errChan := make(chan error, 1)
+	go func() {
+		defer close(impressionChan)
+		defer close(errChan)
+		errChan <- errors.NotSupportedf("impressions in data store proxy")
+	}()
+	return impressionChan, errChan
+}
+
+// DeleteUserImpressions deletes nothing since impressions aren't stored by the data store proxy.
+func (p ProxyClient) DeleteUserImpressions(_ context.Context, _ string) (int, error) {
+	return 0, nil
+}
+
+// checkEvents rejects writes carrying change events since events aren't supported by the data store proxy.
+func checkEvents(ctx context.Context) error {
+	if len(eventsFromContext(ctx)) > 0 {
+		return errors.NotSupportedf("events in data store proxy")
+	}
+	return nil
+}
+
+// InsertEvents isn't supported by the data store proxy.
+func (p ProxyClient) InsertEvents(_ context.Context, _ []Event) error {
+	return errors.NotSupportedf("events in data store proxy")
+}
+
+// GetEvents isn't supported by the data store proxy.
+func (p ProxyClient) GetEvents(_ context.Context, _ int) ([]Event, error) {
+	return nil, errors.NotSupportedf("events in data store proxy")
+}
+
+// DeleteEvents isn't supported by the data store proxy.
+func (p ProxyClient) DeleteEvents(_ context.Context, _ []int64) error {
+	return errors.NotSupportedf("events in data store proxy")
+}
+
+// DeleteUserEvents deletes nothing since events aren't stored by the data store proxy.
+func (p ProxyClient) DeleteUserEvents(_ context.Context, _ string) (int, error) {
+	return 0, nil
+}
+
+// encodeFeedbackContext encodes the context of feedback to JSON.
+func encodeFeedbackContext(context map[string]string) []byte {
+	if context == nil {
+		return nil
+	}
+	buf, _ := json.Marshal(context)
+	return buf
+}
+
+// decodeFeedbackContext decodes the context of feedback from JSON. Invalid contexts are dropped.
+func decodeFeedbackContext(buf []byte) map[string]string {
+	if len(buf) == 0 {
+		return nil
+	}
+	var context map[string]string
+	_ = json.Unmarshal(buf, &context)
+	return context
+}
@@ -74,6 +74,22 @@ func (suite *ProxyTestSuite) TestPurge() {
 	suite.T().Skip()
 }
 
+func (suite *ProxyTestSuite) TestImpressions() {
+	suite.T().Skip()
+}
+
+func (suite *ProxyTestSuite) TestEvents() {
+	suite.T().Skip()
+}
+
+func (suite *ProxyTestSuite) TestItemsByQuery() {
+	suite.T().Skip()
+}
+
+func (suite *ProxyTestSuite) TestItemStatus() {
+	suite.T().Skip()
+}
+
 func TestProxy(t *testing.T) {
 	suite.Run(t, new(ProxyTestSuite))
 }
@@ -19,6 +19,8 @@ import (
 	"database/sql"
 	"encoding/base64"
 	"fmt"
+	"hash/fnv"
+	"strings"
 	"time"
 
 	mapset "github.com/deckarep/golang-set/v2"
@@ -30,6 +32,7 @@ import (
 	"github.com/zhenghaoz/gorse/base/jsonutil"
 	"github.com/zhenghaoz/gorse/base/log"
 	"github.com/zhenghaoz/gorse/storage"
+	"go.uber.org/zap"
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
 	_ "modernc.org/sqlite"
@@ -47,12 +50,14 @@ const (
 )
 
 type SQLItem struct {
-	ItemId     string    `gorm:"column:item_id;primaryKey"`
-	IsHidden   bool      `gorm:"column:is_hidden"`
-	Categories string    `gorm:"column:categories"`
-	Timestamp  time.Time `gorm:"column:time_stamp"`
-	Labels     string    `gorm:"column:labels"`
-	Comment    string    `gorm:"column:comment"`
+	ItemId        string    `gorm:"column:item_id;primaryKey"`
+	IsHidden      bool      `gorm:"column:is_hidden"`
+	Categories    string    `gorm:"column:categories"`
+	Timestamp     time.Time `gorm:"column:time_stamp"`
+	Labels        string    `gorm:"column:labels"`
+	Comment       string    `gorm:"column:comment"`
+	Status        string    `gorm:"column:status"`
+	StatusHistory string    `gorm:"column:status_history"`
 }
 
 func NewSQLItem(item Item) (sqlItem SQLItem) {
@@ -65,6 +70,9 @@ func NewSQLItem(item Item) (sqlItem SQLItem) {
 	buf, _ = jsonutil.Marshal(item.Labels)
 	sqlItem.Labels = string(buf)
 	sqlItem.Comment = item.Comment
+	sqlItem.Status = string(item.Status)
+	buf, _ = jsonutil.Marshal(item.StatusHistory)
+	sqlItem.StatusHistory = string(buf)
 	return
 }
 
@@ -109,17 +117,36 @@ func NewClickhouseUser(user User) (clickhouseUser ClickhouseUser) {
 	return
 }
 
+type SQLFeedback struct {
+	FeedbackKey `gorm:"embedded"`
+	Timestamp   time.Time `gorm:"column:time_stamp"`
+	Comment     string    `gorm:"column:comment"`
+	Value       float64   `gorm:"column:value"`
+	Context     string    `gorm:"column:context"`
+}
+
+func NewSQLFeedback(feedback Feedback) (sqlFeedback SQLFeedback) {
+	sqlFeedback.FeedbackKey = feedback.FeedbackKey
+	sqlFeedback.Timestamp = feedback.Timestamp
+	sqlFeedback.Comment = feedback.Comment
+	sqlFeedback.Value = feedback.Value
+	buf, _ := jsonutil.Marshal(feedback.Context)
+	sqlFeedback.Context = string(buf)
+	return
+}
+
 type ClickHouseFeedback struct {
-	Feedback `gorm:"embedded"`
-	Version  time.Time `gorm:"column:version"`
+	SQLFeedback `gorm:"embedded"`
+	Version     time.Time `gorm:"column:version"`
 }
 
 // SQLDatabase use MySQL as data storage.
 type SQLDatabase struct {
 	storage.TablePrefix
-	gormDB *gorm.DB
-	client *sql.DB
-	driver SQLDriver
+	gormDB        *gorm.DB
+	client        *sql.DB
+	driver        SQLDriver
+	indexedLabels mapset.Set[string] // paths of labels indexed by generated columns in MySQL
 }
 
 // Optimize is used by ClickHouse only.
@@ -141,12 +168,14 @@ func (d *SQLDatabase) Init() error {
 	case MySQL:
 		// create tables
 		type Items struct {
-			ItemId     string    `gorm:"column:item_id;type:varchar(256) not null;primaryKey"`
-			IsHidden   bool      `gorm:"column:is_hidden;type:bool;not null"`
-			Categories []string  `gorm:"column:categories;type:json;not null"`
-			Timestamp  time.Time `gorm:"column:time_stamp;type:datetime;not null"`
-			Labels     []string  `gorm:"column:labels;type:json;not null"`
-			Comment    string    `gorm:"column:comment;type:text;not null"`
+			ItemId        string    `gorm:"column:item_id;type:varchar(256) not null;primaryKey"`
+			IsHidden      bool      `gorm:"column:is_hidden;type:bool;not null"`
+			Categories    []string  `gorm:"column:categories;type:json;not null"`
+			Timestamp     time.Time `gorm:"column:time_stamp;type:datetime;not null"`
+			Labels        []string  `gorm:"column:labels;type:json;not null"`
+			Comment       string    `gorm:"column:comment;type:text;not null"`
+			Status        string    `gorm:"column:status;type:varchar(256);not null;default:''"`
+			StatusHistory []string  `gorm:"column:status_history;type:json"`
 		}
 		type Users struct {
 			UserId    string   `gorm:"column:user_id;type:varchar(256);not null;primaryKey"`
@@ -160,20 +189,52 @@ func (d *SQLDatabase) Init() error {
 			ItemId       string    `gorm:"column:item_id;type:varchar(256);not null;primaryKey;index:item_id"`
 			Timestamp    time.Time `gorm:"column:time_stamp;type:datetime;not null"`
 			Comment      string    `gorm:"column:comment;type:text;not null"`
-		}
-		err := d.gormDB.Set("gorm:table_options", "ENGINE=InnoDB").AutoMigrate(Users{}, Items{}, Feedback{})
+			Value        float64   `gorm:"column:value;type:double;not null;default:0"`
+			Context      string    `gorm:"column:context;type:json"`
+		}
+		type Impressions struct {
+			RequestId  string    `gorm:"column:request_id;type:varchar(256);not null;primaryKey"`
+			Position   int       `gorm:"column:position;type:int;not null;primaryKey"`
+			UserId     string    `gorm:"column:user_id;type:varchar(256);not null;index:user_id"`
+			ItemId     string    `gorm:"column:item_id;type:varchar(256);not null"`
+			Source     string    `gorm:"column:source;type:varchar(256);not null"`
+			Score      float64   `gorm:"column:score;type:double;not null"`
+			Propensity float64   `gorm:"column:propensity;type:double;not null;default:0"`
+			Timestamp  time.Time `gorm:"column:time_stamp;type:datetime;not null;index:time_stamp"`
+		}
+		type Events struct {
+			Id        int64     `gorm:"column:id;type:bigint;not null;primaryKey;autoIncrement"`
+			Type      string    `gorm:"column:event_type;type:varchar(256);not null"`
+			Key       string    `gorm:"column:event_key;type:varchar(256);not null"`
+			Payload   string    `gorm:"column:payload;type:text;not null"`
+			Timestamp time.Time `gorm:"column:time_stamp;type:datetime;not null"`
+		}
+		err := d.gormDB.Set("gorm:table_options", "ENGINE=InnoDB").AutoMigrate(Users{}, Items{}, Feedback{}, Impressions{}, Events{})
 		if err != nil {
 			return errors.Trace(err)
 		}
+		// create a multi-valued index on categories for item queries (MySQL 8.0.17+)
+		if !d.gormDB.Migrator().HasIndex(d.ItemsTable(), "categories_index") {
+			err = d.gormDB.Exec(fmt.Sprintf("CREATE INDEX categories_index ON %s ((CAST(categories AS CHAR(256) ARRAY)))", d.ItemsTable())).Error
+			if err != nil {
+				log.Logger().Warn("failed to create index on categories", zap.Error(err))
+			}
+		}
+		// create indexed generated columns on labels for item queries
+		for _, path := range d.indexedLabels.ToSlice() {
+			d.createLabelIndex(path)
+		}
 	case Postgres:
 		// create tables
 		type Items struct {
-			ItemId     string    `gorm:"column:item_id;type:varchar(256);not null;primaryKey"`
-			IsHidden   bool      `gorm:"column:is_hidden;type:bool;not null;default:false"`
-			Categories string    `gorm:"column:categories;type:json;not null;default:'[]'"`
-			Timestamp  time.Time `gorm:"column:time_stamp;type:timestamptz;not null"`
-			Labels     string    `gorm:"column:labels;type:json;not null;default:'[]'"`
-			Comment    string    `gorm:"column:comment;type:text;not null;default:''"`
+			ItemId        string    `gorm:"column:item_id;type:varchar(256);not null;primaryKey"`
+			IsHidden      bool      `gorm:"column:is_hidden;type:bool;not null;default:false"`
+			Categories    string    `gorm:"column:categories;type:json;not null;default:'[]'"`
+			Timestamp     time.Time `gorm:"column:time_stamp;type:timestamptz;not null"`
+			Labels        string    `gorm:"column:labels;type:json;not null;default:'[]'"`
+			Comment       string    `gorm:"column:comment;type:text;not null;default:''"`
+			Status        string    `gorm:"column:status;type:varchar(256);not null;default:''"`
+			StatusHistory string    `gorm:"column:status_history;type:json;not null;default:'[]'"`
 		}
 		type Users struct {
 			UserId    string `gorm:"column:user_id;type:varchar(256) not null;primaryKey"`
@@ -183,24 +244,56 @@ func (d *SQLDatabase) Init() error {
 		}
 		type Feedback struct {
 			FeedbackType string    `gorm:"column:feedback_type;type:varchar(256);not null;primaryKey"`
-			UserId       string    `gorm:"column:user_id;type:varchar(256);not null;primaryKey;index:user_id_index"`
-			ItemId       string    `gorm:"column:item_id;type:varchar(256);not null;primaryKey;index:item_id_index"`
+			UserId       string    `gorm:"column:user_id;type:varchar(256);not null;primaryKey"`
+			ItemId       string    `gorm:"column:item_id;type:varchar(256);not null;primaryKey"`
 			Timestamp    time.Time `gorm:"column:time_stamp;type:timestamptz;not null"`
 			Comment      string    `gorm:"column:comment;type:text;not null;default:''"`
+			Value        float64   `gorm:"column:value;type:double precision;not null;default:0"`
+			Context      string    `gorm:"column:context;type:json;not null;default:'null'"`
+		}
+		type Impressions struct {
+			RequestId  string    `gorm:"column:request_id;type:varchar(256);not null;primaryKey"`
+			Position   int       `gorm:"column:position;type:integer;not null;primaryKey"`
+			UserId     string    `gorm:"column:user_id;type:varchar(256);not null"`
+			ItemId     string    `gorm:"column:item_id;type:varchar(256);not null"`
+			Source     string    `gorm:"column:source;type:varchar(256);not null"`
+			Score      float64   `gorm:"column:score;type:double precision;not null"`
+			Propensity float64   `gorm:"column:propensity;type:double precision;not null;default:0"`
+			Timestamp  time.Time `gorm:"column:time_stamp;type:timestamptz;not null"`
+		}
+		type Events struct {
+			Id        int64     `gorm:"column:id;type:bigserial;not null;primaryKey;autoIncrement"`
+			Type      string    `gorm:"column:event_type;type:varchar(256);not null"`
+			Key       string    `gorm:"column:event_key;type:varchar(256);not null"`
+			Payload   string    `gorm:"column:payload;type:text;not null;default:''"`
+			Timestamp time.Time `gorm:"column:time_stamp;type:timestamptz;not null"`
 		}
-		err := d.gormDB.AutoMigrate(Users{}, Items{}, Feedback{})
+		err := d.gormDB.AutoMigrate(Users{}, Items{}, Feedback{}, Impressions{}, Events{})
 		if err != nil {
 			return errors.Trace(err)
 		}
+		if err = d.createIndexes(); err != nil {
+			return errors.Trace(err)
+		}
+		// create GIN indexes on labels and categories for item queries
+		for _, column := range []string{"labels", "categories"} {
+			err = d.gormDB.Exec(fmt.Sprintf("CREATE INDEX IF NOT EXISTS %s_%s_index ON %s USING GIN ((%s::jsonb) jsonb_path_ops)",
+				d.ItemsTable(), column, d.ItemsTable(), column)).Error
+			if err != nil {
+				return errors.Trace(err)
+			}
+		}
 	case SQLite:
 		// create tables
 		type Items struct {
-			ItemId     string `gorm:"column:item_id;type:varchar(256);not null;primaryKey"`
-			IsHidden   bool   `gorm:"column:is_hidden;type:bool;not null;default:false"`
-			Categories string `gorm:"column:categories;type:json;not null;default:'[]'"`
-			Timestamp  string `gorm:"column:time_stamp;type:datetime;not null;default:'0001-01-01'"`
-			Labels     string `gorm:"column:labels;type:json;not null;default:'[]'"`
-			Comment    string `gorm:"column:comment;type:text;not null;default:''"`
+			ItemId        string `gorm:"column:item_id;type:varchar(256);not null;primaryKey"`
+			IsHidden      bool   `gorm:"column:is_hidden;type:bool;not null;default:false"`
+			Categories    string `gorm:"column:categories;type:json;not null;default:'[]'"`
+			Timestamp     string `gorm:"column:time_stamp;type:datetime;not null;default:'0001-01-01'"`
+			Labels        string `gorm:"column:labels;type:json;not null;default:'[]'"`
+			Comment       string `gorm:"column:comment;type:text;not null;default:''"`
+			Status        string `gorm:"column:status;type:varchar(256);not null;default:''"`
+			StatusHistory string `gorm:"column:status_history;type:json;not null;default:'[]'"`
 		}
 		type Users struct {
 			UserId    string `gorm:"column:user_id;type:varchar(256) not null;primaryKey"`
@@ -209,26 +302,50 @@ func (d *SQLDatabase) Init() error {
 			Comment   string `gorm:"column:comment;type:text;not null;default:''"`
 		}
 		type Feedback struct {
-			FeedbackType string `gorm:"column:feedback_type;type:varchar(256);not null;primaryKey"`
-			UserId       string `gorm:"column:user_id;type:varchar(256);not null;primaryKey;index:user_id_index"`
-			ItemId       string `gorm:"column:item_id;type:varchar(256);not null;primaryKey;index:item_id_index"`
-			Timestamp    string `gorm:"column:time_stamp;type:datetime;not null;default:'0001-01-01'"`
-			Comment      string `gorm:"column:comment;type:text;not null;default:''"`
-		}
-		err := d.gormDB.AutoMigrate(Users{}, Items{}, Feedback{})
+			FeedbackType string  `gorm:"column:feedback_type;type:varchar(256);not null;primaryKey"`
+			UserId       string  `gorm:"column:user_id;type:varchar(256);not null;primaryKey"`
+			ItemId       string  `gorm:"column:item_id;type:varchar(256);not null;primaryKey"`
+			Timestamp    string  `gorm:"column:time_stamp;type:datetime;not null;default:'0001-01-01'"`
+			Comment      string  `gorm:"column:comment;type:text;not null;default:''"`
+			Value        float64 `gorm:"column:value;type:double;not null;default:0"`
+			Context      string  `gorm:"column:context;type:json;not null;default:'null'"`
+		}
+		type Impressions struct {
+			RequestId  string  `gorm:"column:request_id;type:varchar(256);not null;primaryKey"`
+			Position   int     `gorm:"column:position;type:integer;not null;primaryKey"`
+			UserId     string  `gorm:"column:user_id;type:varchar(256);not null"`
+			ItemId     string  `gorm:"column:item_id;type:varchar(256);not null"`
+			Source     string  `gorm:"column:source;type:varchar(256);not null"`
+			Score      float64 `gorm:"column:score;type:double;not null"`
+			Propensity float64 `gorm:"column:propensity;type:double;not null;default:0"`
+			Timestamp  string  `gorm:"column:time_stamp;type:datetime;not null;default:'0001-01-01'"`
+		}
+		type Events struct {
+			Id        int64  `gorm:"column:id;type:integer;not null;primaryKey;autoIncrement"`
+			Type      string `gorm:"column:event_type;type:varchar(256);not null"`
+			Key       string `gorm:"column:event_key;type:varchar(256);not null"`
+			Payload   string `gorm:"column:payload;type:text;not null;default:''"`
+			Timestamp string `gorm:"column:time_stamp;type:datetime;not null;default:'0001-01-01'"`
+		}
+		err := d.gormDB.AutoMigrate(Users{}, Items{}, Feedback{}, Impressions{}, Events{})
 		if err != nil {
 			return errors.Trace(err)
 		}
+		if err = d.createIndexes(); err != nil {
+			return errors.Trace(err)
+		}
 	case ClickHouse:
 		// create tables
 		type Items struct {
-			ItemId     string    `gorm:"column:item_id;type:String"`
-			IsHidden   int       `gorm:"column:is_hidden;type:Boolean;default:0"`
-			Categories string    `gorm:"column:categories;type:String;default:'[]'"`
-			Timestamp  time.Time `gorm:"column:time_stamp;type:Datetime64(9,'UTC')"`
-			Labels     string    `gorm:"column:labels;type:String;default:'[]'"`
-			Comment    string    `gorm:"column:comment;type:String"`
-			Version    struct{}  `gorm:"column:version;type:DateTime"`
+			ItemId        string    `gorm:"column:item_id;type:String"`
+			IsHidden      int       `gorm:"column:is_hidden;type:Boolean;default:0"`
+			Categories    string    `gorm:"column:categories;type:String;default:'[]'"`
+			Timestamp     time.Time `gorm:"column:time_stamp;type:Datetime64(9,'UTC')"`
+			Labels        string    `gorm:"column:labels;type:String;default:'[]'"`
+			Comment       string    `gorm:"column:comment;type:String"`
+			Status        string    `gorm:"column:status;type:String"`
+			StatusHistory string    `gorm:"column:status_history;type:String;default:'[]'"`
+			Version       struct{}  `gorm:"column:version;type:DateTime"`
 		}
 		err := d.gormDB.Set("gorm:table_options", "ENGINE = ReplacingMergeTree(version) ORDER BY item_id").AutoMigrate(Items{})
 		if err != nil {
@@ -251,12 +368,39 @@ func (d *SQLDatabase) Init() error {
 			ItemId       string    `gorm:"column:item_id;type:String"`
 			Timestamp    time.Time `gorm:"column:time_stamp;type:DateTime64(9,'UTC')"`
 			Comment      string    `gorm:"column:comment;type:String"`
+			Value        float64   `gorm:"column:value;type:Float64"`
+			Context      string    `gorm:"column:context;type:String;default:'null'"`
 			Version      struct{}  `gorm:"column:version;type:DateTime"`
 		}
 		err = d.gormDB.Set("gorm:table_options", "ENGINE = ReplacingMergeTree(version) ORDER BY (feedback_type, user_id, item_id)").AutoMigrate(Feedback{})
 		if err != nil {
 			return errors.Trace(err)
 		}
+		type Impressions struct {
+			RequestId  string    `gorm:"column:request_id;type:String"`
+			Position   int       `gorm:"column:position;type:Int32"`
+			UserId     string    `gorm:"column:user_id;type:String"`
+			ItemId     string    `gorm:"column:item_id;type:String"`
+			Source     string    `gorm:"column:source;type:String"`
+			Score      float64   `gorm:"column:score;type:Float64"`
+			Propensity float64   `gorm:"column:propensity;type:Float64"`
+			Timestamp  time.Time `gorm:"column:time_stamp;type:DateTime64(9,'UTC')"`
+		}
+		err = d.gormDB.Set("gorm:table_options", "ENGINE = MergeTree() ORDER BY (time_stamp, request_id)").AutoMigrate(Impressions{})
+		if err != nil {
+			return errors.Trace(err)
+		}
+		type Events struct {
+			Id        int64     `gorm:"column:id;type:Int64"`
+			Type      string    `gorm:"column:event_type;type:String"`
+			Key       string    `gorm:"column:event_key;type:String"`
+			Payload   string    `gorm:"column:payload;type:String"`
+			Timestamp time.Time `gorm:"column:time_stamp;type:DateTime64(9,'UTC')"`
+		}
+		err = d.gormDB.Set("gorm:table_options", "ENGINE = MergeTree() ORDER BY id").AutoMigrate(Events{})
+		if err != nil {
+			return errors.Trace(err)
+		}
 		// create materialized views
 		type UserFeedback Feedback
 		err = d.gormDB.Set("gorm:table_options", "ENGINE = ReplacingMergeTree(version) ORDER BY (user_id, item_id, feedback_type)").AutoMigrate(UserFeedback{})
@@ -293,7 +437,7 @@ func (d *SQLDatabase) Close() error {
 
 func (d *SQLDatabase) Purge() error {
 	if d.driver == ClickHouse {
-		tables := []string{d.ItemsTable(), d.FeedbackTable(), d.UsersTable(), d.UserFeedbackTable(), d.ItemFeedbackTable()}
+		tables := []string{d.ItemsTable(), d.FeedbackTable(), d.UsersTable(), d.UserFeedbackTable(), d.ItemFeedbackTable(), d.ImpressionsTable(), d.EventsTable()}
 		for _, tableName := range tables {
 			err := d.gormDB.Exec(fmt.Sprintf("alter table %s delete where 1=1", tableName)).Error
 			if err != nil {
@@ -301,7 +445,7 @@ func (d *SQLDatabase) Purge() error {
 			}
 		}
 	} else {
-		tables := []string{d.ItemsTable(), d.FeedbackTable(), d.UsersTable()}
+		tables := []string{d.ItemsTable(), d.FeedbackTable(), d.UsersTable(), d.ImpressionsTable(), d.EventsTable()}
 		for _, tableName := range tables {
 			err := d.gormDB.Exec(fmt.Sprintf("DELETE FROM %s", tableName)).Error
 			if err != nil {
@@ -326,8 +470,9 @@ func (d *SQLDatabase) BatchInsertItems(ctx context.Context, items []Item) error
 				rows = append(rows, NewClickHouseItem(item))
 			}
 		}
-		err := d.gormDB.Create(rows).Error
-		return errors.Trace(err)
+		return d.withEvents(ctx, func(tx *gorm.DB) error {
+			return errors.Trace(tx.Create(rows).Error)
+		})
 	} else {
 		rows := make([]SQLItem, 0, len(items))
 		memo := mapset.NewSet[string]()
@@ -341,11 +486,12 @@ func (d *SQLDatabase) BatchInsertItems(ctx context.Context, items []Item) error
 				rows = append(rows, row)
 			}
 		}
-		err := d.gormDB.WithContext(ctx).Clauses(clause.OnConflict{
-			Columns:   []clause.Column{{Name: "item_id"}},
-			DoUpdates: clause.AssignmentColumns([]string{"is_hidden", "categories", "time_stamp", "labels", "comment"}),
-		}).Create(rows).Error
-		return errors.Trace(err)
+		return d.withEvents(ctx, func(tx *gorm.DB) error {
+			return errors.Trace(tx.Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "item_id"}},
+				DoUpdates: clause.AssignmentColumns([]string{"is_hidden", "categories", "time_stamp", "labels", "comment", "status", "status_history"}),
+			}).Create(rows).Error)
+		})
 	}
 }
 
@@ -355,7 +501,7 @@ func (d *SQLDatabase) BatchGetItems(ctx context.Context, itemIds []string) ([]It
 	}
 	result, err := d.gormDB.WithContext(ctx).
 		Table(d.ItemsTable()).
-		Select("item_id, is_hidden, categories, time_stamp, labels, comment").
+		Select("item_id, is_hidden, categories, time_stamp, labels, comment, status, status_history").
 		Where("item_id IN ?", itemIds).Rows()
 	if err != nil {
 		return nil, errors.Trace(err)
@@ -374,21 +520,23 @@ func (d *SQLDatabase) BatchGetItems(ctx context.Context, itemIds []string) ([]It
 
 // DeleteItem deletes a item from MySQL.
 func (d *SQLDatabase) DeleteItem(ctx context.Context, itemId string) error {
-	if err := d.gormDB.WithContext(ctx).Delete(&SQLItem{ItemId: itemId}).Error; err != nil {
-		return errors.Trace(err)
-	}
-	if err := d.gormDB.WithContext(ctx).Delete(&Feedback{}, "item_id = ?", itemId).Error; err != nil {
-		return errors.Trace(err)
-	}
-	if d.driver == ClickHouse {
-		if err := d.gormDB.WithContext(ctx).Delete(&ItemFeedback{}, "item_id = ?", itemId).Error; err != nil {
+	return d.withEvents(ctx, func(tx *gorm.DB) error {
+		if err := tx.Delete(&SQLItem{ItemId: itemId}).Error; err != nil {
 			return errors.Trace(err)
 		}
-		if err := d.gormDB.WithContext(ctx).Delete(&UserFeedback{}, "item_id = ?", itemId).Error; err != nil {
+		if err := tx.Delete(&Feedback{}, "item_id = ?", itemId).Error; err != nil {
 			return errors.Trace(err)
 		}
-	}
-	return nil
+		if d.driver == ClickHouse {
+			if err := tx.Delete(&ItemFeedback{}, "item_id = ?", itemId).Error; err != nil {
+				return errors.Trace(err)
+			}
+			if err := tx.Delete(&UserFeedback{}, "item_id = ?", itemId).Error; err != nil {
+				return errors.Trace(err)
+			}
+		}
+		return nil
+	})
 }
 
 // GetItem get a item from MySQL.
@@ -397,7 +545,7 @@ func (d *SQLDatabase) GetItem(ctx context.Context, itemId string) (Item, error)
 	var err error
 	result, err = d.gormDB.WithContext(ctx).
 		Table(d.ItemsTable()).
-		Select("item_id, is_hidden, categories, time_stamp, labels, comment").
+		Select("item_id, is_hidden, categories, time_stamp, labels, comment, status, status_history").
 		Where("item_id = ?", itemId).Rows()
 	if err != nil {
 		return Item{}, errors.Trace(err)
@@ -416,11 +564,29 @@ func (d *SQLDatabase) GetItem(ctx context.Context, itemId string) (Item, error)
 // ModifyItem modify an item in MySQL.
 func (d *SQLDatabase) ModifyItem(ctx context.Context, itemId string, patch ItemPatch) error {
 	// ignore empty patch
-	if patch.IsHidden == nil && patch.Categories == nil && patch.Labels == nil && patch.Comment == nil && patch.Timestamp == nil {
+	if patch.IsHidden == nil && patch.Categories == nil && patch.Labels == nil && patch.Comment == nil && patch.Timestamp == nil && patch.Status == nil {
 		log.Logger().Debug("empty item patch")
 		return nil
 	}
 	attributes := make(map[string]any)
+	// the status is written only if it isn't changed since it was read
+	var prevStatus *ItemStatus
+	if patch.Status != nil {
+		item, err := d.GetItem(ctx, itemId)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		status := item.Status
+		if err = item.Transit(*patch.Status, time.Now()); err != nil {
+			return errors.Trace(err)
+		}
+		if item.Status != status {
+			prevStatus = &status
+			text, _ := jsonutil.Marshal(item.StatusHistory)
+			attributes["status"] = string(item.Status)
+			attributes["status_history"] = string(text)
+		}
+	}
 	if patch.IsHidden != nil {
 		if *patch.IsHidden {
 			attributes["is_hidden"] = 1
@@ -447,8 +613,29 @@ func (d *SQLDatabase) ModifyItem(ctx context.Context, itemId string, patch ItemP
 			attributes["time_stamp"] = patch.Timestamp
 		}
 	}
-	err := d.gormDB.WithContext(ctx).Model(&SQLItem{ItemId: itemId}).Updates(attributes).Error
-	return errors.Trace(err)
+	if len(attributes) == 0 {
+		return nil
+	}
+	return d.withEvents(ctx, func(tx *gorm.DB) error {
+		tx = tx.Model(&SQLItem{ItemId: itemId})
+		if prevStatus == nil || d.driver == ClickHouse {
+			// mutations in ClickHouse don't report affected rows
+			return errors.Trace(tx.Updates(attributes).Error)
+		}
+		if *prevStatus == "" {
+			tx = tx.Where("(status = ? OR status IS NULL)", "")
+		} else {
+			tx = tx.Where("status = ?", string(*prevStatus))
+		}
+		result := tx.Updates(attributes)
+		if result.Error != nil {
+			return errors.Trace(result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return errors.Trace(ErrStatusConflict)
+		}
+		return nil
+	})
 }
 
 // GetItems returns items from MySQL.
@@ -460,13 +647,209 @@ func (d *SQLDatabase) GetItems(ctx context.Context, cursor string, n int, timeLi
 	cursorItem := string(buf)
 	tx := d.gormDB.WithContext(ctx).
 		Table(d.ItemsTable()).
-		Select("item_id, is_hidden, categories, time_stamp, labels, comment")
+		Select("item_id, is_hidden, categories, time_stamp, labels, comment, status, status_history")
 	if cursorItem != "" {
 		tx.Where("item_id >= ?", cursorItem)
 	}
 	if timeLimit != nil {
 		tx.Where("time_stamp >= ?", *timeLimit)
 	}
+	return d.pageItems(tx, n)
+}
+
+// GetItemsByQuery returns items matching a query.
+func (d *SQLDatabase) GetItemsByQuery(ctx context.Context, query ItemQuery, cursor string, n int) (string, []Item, error) {
+	if err := query.Validate(); err != nil {
+		return "", nil, errors.Trace(err)
+	}
+	buf, err := base64.StdEncoding.DecodeString(cursor)
+	if err != nil {
+		return "", nil, errors.Trace(err)
+	}
+	cursorItem := string(buf)
+	tx := d.gormDB.WithContext(ctx).
+		Table(d.ItemsTable()).
+		Select("item_id, is_hidden, categories, time_stamp, labels, comment, status, status_history")
+	if cursorItem != "" {
+		tx.Where("item_id >= ?", cursorItem)
+	}
+	for _, predicate := range query.Labels {
+		condition, args := d.labelCondition(predicate)
+		tx.Where(condition, args...)
+	}
+	if len(query.Categories) > 0 {
+		switch d.driver {
+		case MySQL:
+			tx.Where("JSON_CONTAINS(categories, ?)", jsonutil.MustMarshal(query.Categories))
+		case Postgres:
+			tx.Where("categories::jsonb @> ?::jsonb", jsonutil.MustMarshal(query.Categories))
+		case SQLite:
+			for _, category := range query.Categories {
+				tx.Where("EXISTS (SELECT 1 FROM json_each(categories) WHERE value = ?)", category)
+			}
+		case ClickHouse:
+			for _, category := range query.Categories {
+				tx.Where("has(JSONExtract(categories, 'Array(String)'), ?)", category)
+			}
+		}
+	}
+	if query.IsHidden != nil {
+		tx.Where("is_hidden = ?", *query.IsHidden)
+	}
+	if query.BeginTime != nil {
+		tx.Where("time_stamp >= ?", d.convertTimeZone(query.BeginTime))
+	}
+	if query.EndTime != nil {
+		tx.Where("time_stamp <= ?", d.convertTimeZone(query.EndTime))
+	}
+	return d.pageItems(tx, n)
+}
+
+// createIndexes creates indexes on feedback and impressions in PostgreSQL and SQLite. Index names are prefixed since
+// they are global in these databases.
+func (d *SQLDatabase) createIndexes() error {
+	for _, index := range []struct {
+		name   string
+		table  string
+		column string
+	}{
+		{name: "user_id_index", table: d.FeedbackTable(), column: "user_id"},
+		{name: "item_id_index", table: d.FeedbackTable(), column: "item_id"},
+		{name: "impressions_user_id_index", table: d.ImpressionsTable(), column: "user_id"},
+		{name: "impressions_time_stamp_index", table: d.ImpressionsTable(), column: "time_stamp"},
+	} {
+		if err := d.gormDB.Exec(fmt.Sprintf("CREATE INDEX IF NOT EXISTS %s ON %s (%s)",
+			d.IndexName(index.name), index.table, index.column)).Error; err != nil {
+			return errors.Trace(err)
+		}
+	}
+	return nil
+}
+
+// labelColumns returns names of generated columns of string and numeric values of the label at a path.
+func labelColumns(path string) (stringColumn, numberColumn string) {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(path))
+	name := fmt.Sprintf("label_%016x", h.Sum64())
+	return name + "_s", name + "_n"
+}
+
+// createLabelIndex creates generated columns and indexes on string and numeric values of the label at a path in
+// MySQL. Strings are truncated to 256 characters and values of other types are NULL.
+func (d *SQLDatabase) createLabelIndex(path string) {
+	value := fmt.Sprintf("JSON_EXTRACT(labels, '%s')", strings.ReplaceAll(jsonPath(strings.Split(path, ".")), "'", "''"))
+	stringColumn, numberColumn := labelColumns(path)
+	for _, column := range []struct {
+		name       string
+		definition string
+	}{
+		{stringColumn, fmt.Sprintf("VARCHAR(256) COLLATE utf8mb4_bin GENERATED ALWAYS AS (IF(JSON_TYPE(%s) = 'STRING', LEFT(JSON_UNQUOTE(%s), 256), NULL)) VIRTUAL", value, value)},
+		{numberColumn, fmt.Sprintf("DOUBLE GENERATED ALWAYS AS (IF(JSON_TYPE(%s) IN ('INTEGER', 'UNSIGNED INTEGER', 'DOUBLE', 'DECIMAL'), CAST(%s AS DOUBLE), NULL)) VIRTUAL", value, value)},
+	} {
+		if !d.gormDB.Migrator().HasColumn(d.ItemsTable(), column.name) {
+			if err := d.gormDB.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", d.ItemsTable(), column.name, column.definition)).Error; err != nil {
+				log.Logger().Warn("failed to create generated column on label", zap.String("path", path), zap.Error(err))
+				continue
+			}
+		}
+		if !d.gormDB.Migrator().HasIndex(d.ItemsTable(), column.name+"_index") {
+			if err := d.gormDB.Exec(fmt.Sprintf("CREATE INDEX %s_index ON %s (%s)", column.name, d.ItemsTable(), column.name)).Error; err != nil {
+				log.Logger().Warn("failed to create index on label", zap.String("path", path), zap.Error(err))
+			}
+		}
+	}
+}
+
+// labelCondition translates a label predicate to a SQL condition. Labels of other types are excluded since JSON
+// values of different types are comparable in databases.
+func (d *SQLDatabase) labelCondition(predicate LabelPredicate) (string, []any) {
+	op := sqlOperators[predicate.Op]
+	keys := predicate.Keys()
+	switch d.driver {
+	case MySQL:
+		path := jsonPath(keys)
+		types := "'STRING'"
+		if predicate.IsNumber() {
+			types = "'INTEGER', 'UNSIGNED INTEGER', 'DOUBLE', 'DECIMAL'"
+		}
+		condition := fmt.Sprintf("JSON_TYPE(JSON_EXTRACT(labels, ?)) IN (%s) AND JSON_EXTRACT(labels, ?) %s CAST(? AS JSON)", types, op)
+		args := []any{path, path, jsonutil.MustMarshal(predicate.Value)}
+		if indexOp, ok := indexOperators[predicate.Op]; ok && d.indexedLabels.Contains(predicate.Path) {
+			// generated columns narrow down candidates by the index, and the exact condition is still checked since
+			// strings are truncated and numbers are converted to doubles in generated columns
+			stringColumn, numberColumn := labelColumns(predicate.Path)
+			if predicate.IsNumber() {
+				condition = fmt.Sprintf("%s %s ? AND ", numberColumn, indexOp) + condition
+				args = append([]any{predicate.Value}, args...)
+			} else {
+				condition = fmt.Sprintf("%s %s LEFT(?, 256) AND ", stringColumn, indexOp) + condition
+				args = append([]any{predicate.Value}, args...)
+			}
+		}
+		return condition, args
+	case Postgres:
+		path := "{" + strings.Join(lo.Map(keys, func(key string, _ int) string { return `"` + key + `"` }), ",") + "}"
+		jsonType := "string"
+		if predicate.IsNumber() {
+			jsonType = "number"
+		}
+		condition := fmt.Sprintf("jsonb_typeof(labels::jsonb #> ?::text[]) = ? AND labels::jsonb #> ?::text[] %s ?::jsonb", op)
+		args := []any{path, jsonType, path, jsonutil.MustMarshal(predicate.Value)}
+		if predicate.Op == OpEq {
+			// containment is accelerated by the GIN index
+			var document any = predicate.Value
+			for i := len(keys) - 1; i >= 0; i-- {
+				document = map[string]any{keys[i]: document}
+			}
+			condition = "labels::jsonb @> ?::jsonb AND " + condition
+			args = append([]any{jsonutil.MustMarshal(document)}, args...)
+		}
+		return condition, args
+	case SQLite:
+		path := jsonPath(keys)
+		types := "'text'"
+		if predicate.IsNumber() {
+			types = "'integer', 'real'"
+		}
+		return fmt.Sprintf("json_type(labels, ?) IN (%s) AND json_extract(labels, ?) %s ?", types, op),
+			[]any{path, path, predicate.Value}
+	case ClickHouse:
+		placeholders := strings.Repeat(", ?", len(keys))
+		args := lo.Map(keys, func(key string, _ int) any { return key })
+		if predicate.IsNumber() {
+			return fmt.Sprintf("JSONType(labels%s) IN ('Int64', 'UInt64', 'Double') AND JSONExtractFloat(labels%s) %s ?", placeholders, placeholders, op),
+				append(append(args, args...), predicate.Value)
+		}
+		return fmt.Sprintf("JSONType(labels%s) = 'String' AND JSONExtractString(labels%s) %s ?", placeholders, placeholders, op),
+			append(append(args, args...), predicate.Value)
+	}
+	return "", nil
+}
+
+// indexOperators map operators to comparisons on generated columns of labels, which are loosened since truncation
+// and conversion in generated columns are monotonic but not strictly monotonic.
+var indexOperators = map[Operator]string{
+	OpEq: "=",
+	OpLt: "<=",
+	OpLe: "<=",
+	OpGt: ">=",
+	OpGe: ">=",
+}
+
+// jsonPath converts keys to a JSON path, e.g. $."size"."eu".
+func jsonPath(keys []string) string {
+	var builder strings.Builder
+	builder.WriteString("$")
+	for _, key := range keys {
+		builder.WriteString(`."`)
+		builder.WriteString(key)
+		builder.WriteString(`"`)
+	}
+	return builder.String()
+}
+
+// pageItems fetches n items and the cursor of the next page.
+func (d *SQLDatabase) pageItems(tx *gorm.DB, n int) (string, []Item, error) {
 	result, err := tx.Order("item_id").Limit(n + 1).Rows()
 	if err != nil {
 		return "", nil, errors.Trace(err)
@@ -496,7 +879,7 @@ func (d *SQLDatabase) GetItemStream(ctx context.Context, batchSize int, timeLimi
 		// send query
 		tx := d.gormDB.WithContext(ctx).
 			Table(d.ItemsTable()).
-			Select("item_id, is_hidden, categories, time_stamp, labels, comment")
+			Select("item_id, is_hidden, categories, time_stamp, labels, comment, status, status_history")
 		if timeLimit != nil {
 			tx.Where("time_stamp >= ?", *timeLimit)
 		}
@@ -536,7 +919,7 @@ func (d *SQLDatabase) GetItemFeedback(ctx context.Context, itemId string, feedba
 	} else {
 		tx = tx.Table(d.FeedbackTable())
 	}
-	tx.Select("user_id, item_id, feedback_type, time_stamp")
+	tx.Select("user_id, item_id, feedback_type, time_stamp, value, context")
 	switch d.driver {
 	case SQLite:
 		tx.Where("time_stamp <= DATETIME()")
@@ -579,8 +962,9 @@ func (d *SQLDatabase) BatchInsertUsers(ctx context.Context, users []User) error
 				rows = append(rows, NewClickhouseUser(user))
 			}
 		}
-		err := d.gormDB.Create(rows).Error
-		return errors.Trace(err)
+		return d.withEvents(ctx, func(tx *gorm.DB) error {
+			return errors.Trace(tx.Create(rows).Error)
+		})
 	} else {
 		rows := make([]SQLUser, 0, len(users))
 		memo := mapset.NewSet[string]()
@@ -590,31 +974,34 @@ func (d *SQLDatabase) BatchInsertUsers(ctx context.Context, users []User) error
 				rows = append(rows, NewSQLUser(user))
 			}
 		}
-		err := d.gormDB.WithContext(ctx).Clauses(clause.OnConflict{
-			Columns:   []clause.Column{{Name: "user_id"}},
-			DoUpdates: clause.AssignmentColumns([]string{"labels", "subscribe", "comment"}),
-		}).Create(rows).Error
-		return errors.Trace(err)
+		return d.withEvents(ctx, func(tx *gorm.DB) error {
+			return errors.Trace(tx.Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "user_id"}},
+				DoUpdates: clause.AssignmentColumns([]string{"labels", "subscribe", "comment"}),
+			}).Create(rows).Error)
+		})
 	}
 }
 
 // DeleteUser deletes a user from MySQL.
 func (d *SQLDatabase) DeleteUser(ctx context.Context, userId string) error {
-	if err := d.gormDB.WithContext(ctx).Delete(&SQLUser{UserId: userId}).Error; err != nil {
-		return errors.Trace(err)
-	}
-	if err := d.gormDB.WithContext(ctx).Delete(&Feedback{}, "user_id = ?", userId).Error; err != nil {
-		return errors.Trace(err)
-	}
-	if d.driver == ClickHouse {
-		if err := d.gormDB.WithContext(ctx).Delete(&ItemFeedback{}, "user_id = ?", userId).Error; err != nil {
+	return d.withEvents(ctx, func(tx *gorm.DB) error {
+		if err := tx.Delete(&SQLUser{UserId: userId}).Error; err != nil {
 			return errors.Trace(err)
 		}
-		if err := d.gormDB.WithContext(ctx).Delete(&UserFeedback{}, "user_id = ?", userId).Error; err != nil {
+		if err := tx.Delete(&Feedback{}, "user_id = ?", userId).Error; err != nil {
 			return errors.Trace(err)
 		}
-	}
-	return nil
+		if d.driver == ClickHouse {
+			if err := tx.Delete(&ItemFeedback{}, "user_id = ?", userId).Error; err != nil {
+				return errors.Trace(err)
+			}
+			if err := tx.Delete(&UserFeedback{}, "user_id = ?", userId).Error; err != nil {
+				return errors.Trace(err)
+			}
+		}
+		return nil
+	})
 }
 
 // GetUser returns a user from MySQL.
@@ -657,8 +1044,9 @@ func (d *SQLDatabase) ModifyUser(ctx context.Context, userId string, patch UserP
 		text, _ := jsonutil.Marshal(patch.Subscribe)
 		attributes["subscribe"] = string(text)
 	}
-	err := d.gormDB.WithContext(ctx).Model(&SQLUser{UserId: userId}).Updates(attributes).Error
-	return errors.Trace(err)
+	return d.withEvents(ctx, func(tx *gorm.DB) error {
+		return errors.Trace(tx.Model(&SQLUser{UserId: userId}).Updates(attributes).Error)
+	})
 }
 
 // GetUsers returns users from MySQL.
@@ -737,7 +1125,7 @@ func (d *SQLDatabase) GetUserFeedback(ctx context.Context, userId string, endTim
 	} else {
 		tx = tx.Table(d.FeedbackTable())
 	}
-	tx.Select("feedback_type, user_id, item_id, time_stamp, comment").
+	tx.Select("feedback_type, user_id, item_id, time_stamp, comment, value, context").
 		Where("user_id = ?", userId)
 	if endTime != nil {
 		tx.Where("time_stamp <= ?", d.convertTimeZone(endTime))
@@ -765,11 +1153,16 @@ func (d *SQLDatabase) GetUserFeedback(ctx context.Context, userId string, endTim
 // If insertUser set, new users will be inserted to user table.
 // If insertItem set, new items will be inserted to item table.
 func (d *SQLDatabase) BatchInsertFeedback(ctx context.Context, feedback []Feedback, insertUser, insertItem, overwrite bool) error {
-	tx := d.gormDB.WithContext(ctx)
 	// skip empty list
 	if len(feedback) == 0 {
 		return nil
 	}
+	return d.withEvents(ctx, func(tx *gorm.DB) error {
+		return d.batchInsertFeedback(tx, feedback, insertUser, insertItem, overwrite)
+	})
+}
+
+func (d *SQLDatabase) batchInsertFeedback(tx *gorm.DB, feedback []Feedback, insertUser, insertItem, overwrite bool) error {
 	// collect users and items
 	users := mapset.NewSet[string]()
 	items := mapset.NewSet[string]()
@@ -828,9 +1221,10 @@ func (d *SQLDatabase) BatchInsertFeedback(ctx context.Context, feedback []Feedba
 			err := tx.Create(lo.Map(itemList, func(itemId string, _ int) ClickHouseItem {
 				return ClickHouseItem{
 					SQLItem: SQLItem{
-						ItemId:     itemId,
-						Labels:     "[]",
-						Categories: "[]",
+						ItemId:        itemId,
+						Labels:        "[]",
+						Categories:    "[]",
+						StatusHistory: "[]",
 					},
 				}
 			})).Error
@@ -843,9 +1237,10 @@ func (d *SQLDatabase) BatchInsertFeedback(ctx context.Context, feedback []Feedba
 				DoNothing: true,
 			}).Create(lo.Map(itemList, func(itemId string, _ int) SQLItem {
 				return SQLItem{
-					ItemId:     itemId,
-					Labels:     "null",
-					Categories: "null",
+					ItemId:        itemId,
+					Labels:        "null",
+					Categories:    "null",
+					StatusHistory: "null",
 				}
 			})).Error
 			if err != nil {
@@ -875,8 +1270,8 @@ func (d *SQLDatabase) BatchInsertFeedback(ctx context.Context, feedback []Feedba
 					memo[lo.Tuple3[string, string, string]{f.FeedbackType, f.UserId, f.ItemId}] = struct{}{}
 					f.Timestamp = f.Timestamp.In(time.UTC)
 					rows = append(rows, ClickHouseFeedback{
-						Feedback: f,
-						Version:  lo.If(overwrite, time.Now().In(time.UTC)).Else(time.Time{}),
+						SQLFeedback: NewSQLFeedback(f),
+						Version:     lo.If(overwrite, time.Now().In(time.UTC)).Else(time.Time{}),
 					})
 				}
 			}
@@ -887,7 +1282,7 @@ func (d *SQLDatabase) BatchInsertFeedback(ctx context.Context, feedback []Feedba
 		err := tx.Create(rows).Error
 		return errors.Trace(err)
 	} else {
-		rows := make([]Feedback, 0, len(feedback))
+		rows := make([]SQLFeedback, 0, len(feedback))
 		memo := make(map[lo.Tuple3[string, string, string]]struct{})
 		for _, f := range feedback {
 			if users.Contains(f.UserId) && items.Contains(f.ItemId) {
@@ -896,7 +1291,7 @@ func (d *SQLDatabase) BatchInsertFeedback(ctx context.Context, feedback []Feedba
 					if d.driver == SQLite {
 						f.Timestamp = f.Timestamp.In(time.UTC)
 					}
-					rows = append(rows, f)
+					rows = append(rows, NewSQLFeedback(f))
 				}
 			}
 		}
@@ -906,7 +1301,7 @@ func (d *SQLDatabase) BatchInsertFeedback(ctx context.Context, feedback []Feedba
 		err := tx.Clauses(clause.OnConflict{
 			Columns:   []clause.Column{{Name: "feedback_type"}, {Name: "user_id"}, {Name: "item_id"}},
 			DoNothing: !overwrite,
-			DoUpdates: lo.If(overwrite, clause.AssignmentColumns([]string{"time_stamp", "comment"})).Else(nil),
+			DoUpdates: lo.If(overwrite, clause.AssignmentColumns([]string{"time_stamp", "comment", "value", "context"})).Else(nil),
 		}).Create(rows).Error
 		return errors.Trace(err)
 	}
@@ -918,7 +1313,7 @@ func (d *SQLDatabase) GetFeedback(ctx context.Context, cursor string, n int, beg
 	if err != nil {
 		return "", nil, errors.Trace(err)
 	}
-	tx := d.gormDB.WithContext(ctx).Table(d.FeedbackTable()).Select("feedback_type, user_id, item_id, time_stamp, comment")
+	tx := d.gormDB.WithContext(ctx).Table(d.FeedbackTable()).Select("feedback_type, user_id, item_id, time_stamp, comment, value, context")
 	if len(buf) > 0 {
 		var cursorKey FeedbackKey
 		if err := jsonutil.Unmarshal(buf, &cursorKey); err != nil {
@@ -971,7 +1366,7 @@ func (d *SQLDatabase) GetFeedbackStream(ctx context.Context, batchSize int, scan
 		// send query
 		tx := d.gormDB.WithContext(ctx).
 			Table(d.FeedbackTable()).
-			Select("feedback_type, user_id, item_id, time_stamp, comment")
+			Select("feedback_type, user_id, item_id, time_stamp, comment, value, context")
 		if len(scan.FeedbackTypes) > 0 {
 			tx.Where("feedback_type IN ?", scan.FeedbackTypes)
 		}
@@ -1034,7 +1429,7 @@ func (d *SQLDatabase) GetUserItemFeedback(ctx context.Context, userId, itemId st
 	} else {
 		tx = tx.Table(d.FeedbackTable())
 	}
-	tx.Select("feedback_type, user_id, item_id, time_stamp, comment").
+	tx.Select("feedback_type, user_id, item_id, time_stamp, comment, value, context").
 		Where("user_id = ? AND item_id = ?", userId, itemId)
 	if len(feedbackTypes) > 0 {
 		tx.Where("feedback_type IN ?", feedbackTypes)
@@ -1085,6 +1480,164 @@ func (d *SQLDatabase) DeleteUserItemFeedback(ctx context.Context, userId, itemId
 	return rowAffected, nil
 }
 
+// BatchInsertImpressions appends impressions to the impression table. Existing impressions are ignored.
+func (d *SQLDatabase) BatchInsertImpressions(ctx context.Context, impressions []Impression) error {
+	if len(impressions) == 0 {
+		return nil
+	}
+	rows := make([]Impression, 0, len(impressions))
+	for _, impression := range impressions {
+		impression.Timestamp = d.convertTimeZone(&impression.Timestamp)
+		rows = append(rows, impression)
+	}
+	tx := d.gormDB.WithContext(ctx).Table(d.ImpressionsTable())
+	if d.driver != ClickHouse {
+		tx = tx.Clauses(clause.OnConflict{DoNothing: true})
+	}
+	return errors.Trace(tx.Create(rows).Error)
+}
+
+// GetImpressionStream reads impressions served in [beginTime, endTime] by stream.
+func (d *SQLDatabase) GetImpressionStream(ctx context.Context, batchSize int, beginTime, endTime *time.Time) (chan []Impression, chan error) {
+	impressionChan := make(chan []Impression, bufSize)
+	errChan := make(chan error, 1)
+	go func() {
+		defer close(impressionChan)
+		defer close(errChan)
+		// send query
+		tx := d.gormDB.WithContext(ctx).
+			Table(d.ImpressionsTable()).
+			Select("request_id, user_id, item_id, position, source, score, propensity, time_stamp")
+		if beginTime != nil {
+			tx.Where("time_stamp >= ?", d.convertTimeZone(beginTime))
+		}
+		if endTime != nil {
+			tx.Where("time_stamp <= ?", d.convertTimeZone(endTime))
+		}
+		result, err := tx.Order("time_stamp").Rows()
+		if err != nil {
+			errChan <- errors.Trace(err)
+			return
+		}
+		// fetch result
+		impressions := make([]Impression, 0, batchSize)
+		defer result.Close()
+		for result.Next() {
+			var impression Impression
+			if err = d.gormDB.ScanRows(result, &impression); err != nil {
+				errChan <- errors.Trace(err)
+				return
+			}
+			impressions = append(impressions, impression)
+			if len(impressions) == batchSize {
+				impressionChan <- impressions
+				impressions = make([]Impression, 0, batchSize)
+			}
+		}
+		if len(impressions) > 0 {
+			impressionChan <- impressions
+		}
+		errChan <- nil
+	}()
+	return impressionChan, errChan
+}
+
+// DeleteUserImpressions deletes impressions served to a user.
+func (d *SQLDatabase) DeleteUserImpressions(ctx context.Context, userId string) (int, error) {
+	tx := d.gormDB.WithContext(ctx).Table(d.ImpressionsTable()).Where("user_id = ?", userId).Delete(&Impression{})
+	if tx.Error != nil {
+		return 0, errors.Trace(tx.Error)
+	}
+	return int(tx.RowsAffected), nil
+}
+
+// withEvents runs a write with change events carried by the context. Events are inserted in the same transaction as
+// the write. ClickHouse doesn't support transactions, so events are inserted after the write succeeds.
+func (d *SQLDatabase) withEvents(ctx context.Context, write func(tx *gorm.DB) error) error {
+	events := eventsFromContext(ctx)
+	db := d.gormDB.WithContext(ctx)
+	if len(events) == 0 {
+		return write(db)
+	}
+	if d.driver == ClickHouse {
+		if err := write(db); err != nil {
+			return err
+		}
+		return d.insertEvents(db, events)
+	}
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := write(tx); err != nil {
+			return err
+		}
+		return d.insertEvents(tx, events)
+	})
+}
+
+// InsertEvents appends change events to the outbox without any change of data, such as refreshed recommendation.
+func (d *SQLDatabase) InsertEvents(ctx context.Context, events []Event) error {
+	if len(events) == 0 {
+		return nil
+	}
+	return d.insertEvents(d.gormDB.WithContext(ctx), events)
+}
+
+// insertEvents appends change events to the outbox. Event IDs are assigned by auto increment, except in ClickHouse
+// where they are derived from the insertion time.
+func (d *SQLDatabase) insertEvents(tx *gorm.DB, events []Event) error {
+	rows := make([]Event, 0, len(events))
+	base := time.Now().UnixNano()
+	for i, event := range events {
+		event.Id = 0
+		if d.driver == ClickHouse {
+			event.Id = base + int64(i)
+		}
+		event.Timestamp = d.convertTimeZone(&event.Timestamp)
+		rows = append(rows, event)
+	}
+	return errors.Trace(tx.Table(d.EventsTable()).Create(rows).Error)
+}
+
+// GetEvents returns at most n pending change events in the order of IDs.
+func (d *SQLDatabase) GetEvents(ctx context.Context, n int) ([]Event, error) {
+	result, err := d.gormDB.WithContext(ctx).
+		Table(d.EventsTable()).
+		Select("id, event_type, event_key, payload, time_stamp").
+		Order("id").
+		Limit(n).
+		Rows()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	defer result.Close()
+	var events []Event
+	for result.Next() {
+		var event Event
+		if err = d.gormDB.ScanRows(result, &event); err != nil {
+			return nil, errors.Trace(err)
+		}
+		events = append(events, event)
+	}
+	return events, nil
+}
+
+// DeleteEvents deletes dispatched change events from the outbox.
+func (d *SQLDatabase) DeleteEvents(ctx context.Context, ids []int64) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return errors.Trace(d.gormDB.WithContext(ctx).Table(d.EventsTable()).Where("id IN ?", ids).Delete(&Event{}).Error)
+}
+
+// DeleteUserEvents deletes pending change events keyed by a user.
+func (d *SQLDatabase) DeleteUserEvents(ctx context.Context, userId string) (int, error) {
+	tx := d.gormDB.WithContext(ctx).Table(d.EventsTable()).
+		Where("event_key = ? AND event_type IN ?", userId, UserEventTypes).Delete(&Event{})
+	if tx.Error != nil {
+		return 0, errors.Trace(tx.Error)
+	}
+	return int(tx.RowsAffected), nil
+}
+
 func (d *SQLDatabase) convertTimeZone(timestamp *time.Time) time.Time {
 	switch d.driver {
 	case ClickHouse, SQLite:
@@ -15,16 +15,21 @@
 package data
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"os"
 	"strings"
 	"testing"
+	"time"
 
+	mapset "github.com/deckarep/golang-set/v2"
+	"github.com/samber/lo"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
 	"github.com/zhenghaoz/gorse/storage"
+	"gorm.io/gorm"
 )
 
 var (
@@ -62,7 +67,7 @@ func (suite *MySQLTestSuite) SetupSuite() {
 	err = databaseComm.Close()
 	suite.NoError(err)
 	// connect database
-	suite.Database, err = Open(mySqlDSN+dbName, "gorse_")
+	suite.Database, err = Open(mySqlDSN+dbName, "gorse_", storage.WithIndexedLabels([]string{"brand", "size.eu"}))
 	suite.NoError(err)
 	// create schema
 	err = suite.Database.Init()
@@ -75,12 +80,37 @@ func (suite *MySQLTestSuite) TestInit() {
 	connection := suite.Database.(*SQLDatabase).client
 	assertQuery(suite.T(), connection, fmt.Sprintf("SELECT @@%s", name), "READ-UNCOMMITTED")
 	assertQuery(suite.T(), connection, "SELECT @@sql_mode", "ONLY_FULL_GROUP_BY,STRICT_TRANS_TABLES,ERROR_FOR_DIVISION_BY_ZERO,NO_ENGINE_SUBSTITUTION")
+	// generated columns on labels are indexed
+	database := suite.Database.(*SQLDatabase)
+	for _, path := range []string{"brand", "size.eu"} {
+		stringColumn, numberColumn := labelColumns(path)
+		suite.True(database.gormDB.Migrator().HasIndex(database.ItemsTable(), stringColumn+"_index"))
+		suite.True(database.gormDB.Migrator().HasIndex(database.ItemsTable(), numberColumn+"_index"))
+	}
 }
 
 func TestMySQL(t *testing.T) {
 	suite.Run(t, new(MySQLTestSuite))
 }
 
+func TestSQLDatabase_LabelCondition(t *testing.T) {
+	database := &SQLDatabase{driver: MySQL, indexedLabels: mapset.NewSet("brand")}
+	stringColumn, numberColumn := labelColumns("brand")
+	// indexed labels are filtered by generated columns
+	condition, args := database.labelCondition(LabelPredicate{Path: "brand", Op: OpEq, Value: "levis"})
+	assert.True(t, strings.HasPrefix(condition, stringColumn+" = LEFT(?, 256) AND "))
+	assert.Equal(t, []any{"levis", `$."brand"`, `$."brand"`, `"levis"`}, args)
+	condition, args = database.labelCondition(LabelPredicate{Path: "brand", Op: OpLt, Value: float64(42)})
+	assert.True(t, strings.HasPrefix(condition, numberColumn+" <= ? AND "))
+	assert.Equal(t, []any{float64(42), `$."brand"`, `$."brand"`, `42`}, args)
+	// inequality isn't accelerated by indexes
+	condition, _ = database.labelCondition(LabelPredicate{Path: "brand", Op: OpNe, Value: "levis"})
+	assert.NotContains(t, condition, stringColumn)
+	// labels not indexed
+	condition, _ = database.labelCondition(LabelPredicate{Path: "size", Op: OpEq, Value: "M"})
+	assert.NotContains(t, condition, "label_")
+}
+
 type PostgresTestSuite struct {
 	baseTestSuite
 }
@@ -156,10 +186,50 @@ func (suite *SQLiteTestSuite) TearDownSuite() {
 	suite.NoError(suite.Database.Close())
 }
 
+func (suite *SQLiteTestSuite) TestItemStatusConflict() {
+	ctx := context.Background()
+	err := suite.Database.BatchInsertItems(ctx, []Item{{ItemId: "conflict", Timestamp: time.Now()}})
+	suite.NoError(err)
+
+	// the item is sold by others after its status is read
+	db := suite.Database.(*SQLDatabase)
+	err = db.gormDB.Callback().Update().Before("gorm:update").Register("test:sell", func(tx *gorm.DB) {
+		db.gormDB.Exec(fmt.Sprintf("UPDATE %s SET status = ? WHERE item_id = ?", db.ItemsTable()), StatusSold, "conflict")
+	})
+	suite.NoError(err)
+	err = suite.Database.ModifyItem(ctx, "conflict", ItemPatch{Status: lo.ToPtr(StatusReserved)})
+	suite.NoError(db.gormDB.Callback().Update().Remove("test:sell"))
+	suite.ErrorIs(err, ErrStatusConflict)
+	item, err := suite.Database.GetItem(ctx, "conflict")
+	suite.NoError(err)
+	suite.Equal(StatusSold, item.Status)
+	suite.Empty(item.StatusHistory)
+}
+
 func TestSQLite(t *testing.T) {
 	suite.Run(t, new(SQLiteTestSuite))
 }
 
+func TestSQLite_TablePrefix(t *testing.T) {
+	// tables of different prefixes share the database
+	path := fmt.Sprintf("sqlite://%s/sqlite.db", t.TempDir())
+	for _, prefix := range []string{"gorse_", "tenant_"} {
+		database, err := Open(path, prefix)
+		assert.NoError(t, err)
+		assert.NoError(t, database.Init())
+		assert.NoError(t, database.Close())
+	}
+	database, err := Open(path, "")
+	assert.NoError(t, err)
+	defer database.Close()
+	var indexes []string
+	err = database.(*SQLDatabase).gormDB.Raw("SELECT name FROM sqlite_master WHERE type = 'index' AND name LIKE '%user_id_index' ORDER BY name").
+		Scan(&indexes).Error
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"gorse_impressions_user_id_index", "gorse_user_id_index",
+		"tenant_impressions_user_id_index", "tenant_user_id_index"}, indexes)
+}
+
 func assertQuery(t *testing.T, connection *sql.DB, sql string, expected string) {
 	rows, err := connection.Query(sql)
 	assert.NoError(t, err)
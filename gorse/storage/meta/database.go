@@ -32,11 +32,23 @@ type Node struct {
 	UpdateTime time.Time
 }
 
+// Shard is the share of users owned by a worker. A worker is ready once recommendations for all its users have been
+// generated, and users moving to a worker are still handled by previous owners until the worker is ready.
+type Shard struct {
+	UUID            string
+	NumUsers        int
+	NumHandoffUsers int
+	Ready           bool
+	UpdateTime      time.Time
+}
+
 type Database interface {
 	Close() error
 	Init() error
 	UpdateNode(node *Node) error
 	ListNodes() ([]*Node, error)
+	UpdateShard(shard *Shard) error
+	ListShards() ([]*Shard, error)
 }
 
 // Open a connection to a database.
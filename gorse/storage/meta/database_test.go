@@ -62,3 +62,27 @@ func (suite *baseTestSuite) TestNodes() {
 		suite.Equal("v0.1.1", nodes[0].Version)
 	}
 }
+
+func (suite *baseTestSuite) TestShards() {
+	// Add nodes
+	err := suite.Database.UpdateNode(&Node{UUID: "worker-1", Type: "Worker", UpdateTime: time.Now()})
+	suite.NoError(err)
+	err = suite.Database.UpdateNode(&Node{UUID: "worker-2", Type: "Worker", UpdateTime: time.Now().Add(-time.Hour)})
+	suite.NoError(err)
+	// Add shards
+	err = suite.Database.UpdateShard(&Shard{UUID: "worker-1", NumUsers: 10, UpdateTime: time.Now()})
+	suite.NoError(err)
+	err = suite.Database.UpdateShard(&Shard{UUID: "worker-1", NumUsers: 12, NumHandoffUsers: 3, Ready: true, UpdateTime: time.Now()})
+	suite.NoError(err)
+	err = suite.Database.UpdateShard(&Shard{UUID: "worker-2", NumUsers: 20, Ready: true, UpdateTime: time.Now()})
+	suite.NoError(err)
+	// List shards of alive nodes
+	shards, err := suite.Database.ListShards()
+	suite.NoError(err)
+	if suite.Equal(1, len(shards)) {
+		suite.Equal("worker-1", shards[0].UUID)
+		suite.Equal(12, shards[0].NumUsers)
+		suite.Equal(3, shards[0].NumHandoffUsers)
+		suite.True(shards[0].Ready)
+	}
+}
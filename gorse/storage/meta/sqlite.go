@@ -51,6 +51,16 @@ CREATE TABLE IF NOT EXISTS cron_jobs (
 	start_time TIMESTAMP,
 	end_time TIMESTAMP,
 	update_time TIMESTAMP
+);`); err != nil {
+		return err
+	}
+	if _, err := s.db.Exec(`
+CREATE TABLE IF NOT EXISTS shards (
+	uuid TEXT PRIMARY KEY,
+	n_users INTEGER,
+	n_handoff_users INTEGER,
+	ready BOOLEAN,
+	update_time DATETIME
 );`); err != nil {
 		return err
 	}
@@ -96,3 +106,44 @@ DELETE FROM nodes WHERE update_time < datetime('now', ?)
 	}
 	return nodes, nil
 }
+
+func (s *SQLite) UpdateShard(shard *Shard) error {
+	_, err := s.db.Exec(`
+INSERT INTO shards (uuid, n_users, n_handoff_users, ready, update_time)
+VALUES (?, ?, ?, ?, ?)
+ON CONFLICT(uuid) DO UPDATE SET
+	n_users = excluded.n_users,
+	n_handoff_users = excluded.n_handoff_users,
+	ready = excluded.ready,
+	update_time = excluded.update_time
+`, shard.UUID, shard.NumUsers, shard.NumHandoffUsers, shard.Ready, shard.UpdateTime.UTC())
+	return err
+}
+
+func (s *SQLite) ListShards() ([]*Shard, error) {
+	// List shards of nodes within TTL
+	rs, err := s.db.Query(`
+SELECT shards.uuid, n_users, n_handoff_users, ready, shards.update_time FROM shards
+JOIN nodes ON shards.uuid = nodes.uuid
+WHERE nodes.update_time > datetime('now', ?)
+`, fmt.Sprintf("-%.0f seconds", s.ttl.Seconds()))
+	if err != nil {
+		return nil, err
+	}
+	defer rs.Close()
+	var shards []*Shard
+	for rs.Next() {
+		var shard Shard
+		if err = rs.Scan(&shard.UUID, &shard.NumUsers, &shard.NumHandoffUsers, &shard.Ready, &shard.UpdateTime); err != nil {
+			return nil, err
+		}
+		shards = append(shards, &shard)
+	}
+	// Delete shards of outdated nodes
+	if _, err = s.db.Exec(`
+DELETE FROM shards WHERE uuid NOT IN (SELECT uuid FROM nodes WHERE update_time > datetime('now', ?))
+`, fmt.Sprintf("-%.0f seconds", s.ttl.Seconds())); err != nil {
+		return nil, err
+	}
+	return shards, nil
+}
@@ -2,6 +2,7 @@ package storage
 
 type Options struct {
 	IsolationLevel string
+	IndexedLabels  []string
 }
 
 type Option func(*Options)
@@ -12,6 +13,13 @@ func WithIsolationLevel(isolationLevel string) Option {
 	}
 }
 
+// WithIndexedLabels sets paths of labels to be indexed by generated columns in MySQL.
+func WithIndexedLabels(paths []string) Option {
+	return func(o *Options) {
+		o.IndexedLabels = paths
+	}
+}
+
 func NewOptions(opts ...Option) Options {
 	opt := Options{
 		IsolationLevel: "READ-UNCOMMITTED",
@@ -125,6 +125,15 @@ func (tp TablePrefix) FeedbackTable() string {
 	return string(tp) + "feedback"
 }
 
+func (tp TablePrefix) ImpressionsTable() string {
+	return string(tp) + "impressions"
+}
+
+// EventsTable returns the outbox of change events.
+func (tp TablePrefix) EventsTable() string {
+	return string(tp) + "events"
+}
+
 // UserFeedbackTable returns the materialized view of user feedback.
 func (tp TablePrefix) UserFeedbackTable() string {
 	return string(tp) + "user_feedback"
@@ -135,6 +144,12 @@ func (tp TablePrefix) ItemFeedbackTable() string {
 	return string(tp) + "item_feedback"
 }
 
+// IndexName returns the name of an index. Index names are global in PostgreSQL and SQLite, so they are prefixed to
+// avoid conflicts between tables of different prefixes.
+func (tp TablePrefix) IndexName(name string) string {
+	return string(tp) + name
+}
+
 func (tp TablePrefix) Key(key string) string {
 	return string(tp) + key
 }
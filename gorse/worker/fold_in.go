@@ -0,0 +1,242 @@
+// Copyright 2024 gorse Project Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package worker
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	mapset "github.com/deckarep/golang-set/v2"
+	"github.com/jellydator/ttlcache/v3"
+	"github.com/juju/errors"
+	"github.com/samber/lo"
+	"github.com/zhenghaoz/gorse/base/floats"
+	"github.com/zhenghaoz/gorse/config"
+	"github.com/zhenghaoz/gorse/model/ranking"
+	"github.com/zhenghaoz/gorse/storage/cache"
+	"github.com/zhenghaoz/gorse/storage/data"
+)
+
+// FoldInCache caches factors folded into the ranking model for users and items absent from the last fit. Cached
+// factors are dropped once the ranking model is replaced, since the full fit on the master supersedes them. At most
+// capacity users and capacity items are cached and the least recently used ones are evicted. Factors of erased users
+// are dropped once the erasures are read from the audit log.
+type FoldInCache struct {
+	mutex       sync.Mutex
+	capacity    uint64
+	model       ranking.MatrixFactorization
+	foldIn      *ranking.FoldIn
+	users       *ttlcache.Cache[string, foldedFactor]
+	items       *ttlcache.Cache[string, foldedFactor]
+	erasedSince float64 // score of the latest erasure read from the audit log
+}
+
+// foldedFactor is a folded factor and the number of feedback it is folded by. The factor is folded again once the
+// number of feedback changes.
+type foldedFactor struct {
+	numFeedback int
+	factor      []float32
+}
+
+// NewFoldInCache creates an empty FoldInCache holding at most capacity users and capacity items.
+func NewFoldInCache(capacity uint64) *FoldInCache {
+	return &FoldInCache{
+		capacity: capacity,
+		users:    ttlcache.New(ttlcache.WithCapacity[string, foldedFactor](capacity)),
+		items:    ttlcache.New(ttlcache.WithCapacity[string, foldedFactor](capacity)),
+	}
+}
+
+// UserFactor returns the factor of a user. The factor is taken from the ranking model if the user is predictable,
+// otherwise it is folded in by items the user interacted with. It returns nil if the user couldn't be folded in.
+func (c *FoldInCache) UserFactor(m ranking.MatrixFactorization, userId string, itemIds []string) []float32 {
+	if userIndex := m.GetUserIndex().ToNumber(userId); m.IsUserPredictable(userIndex) {
+		return m.GetUserFactor(userIndex)
+	}
+	foldIn, users, _ := c.sync(m)
+	if cached := users.Get(userId); cached != nil && cached.Value().numFeedback == len(itemIds) {
+		return cached.Value().factor
+	}
+	factor := foldIn.FoldInUser(itemIds)
+	users.Set(userId, foldedFactor{numFeedback: len(itemIds), factor: factor}, ttlcache.NoTTL)
+	return factor
+}
+
+// ItemFactor returns the factor of an item. The factor is taken from the ranking model if the item is predictable,
+// otherwise it is folded in by users interacted with the item. It returns nil if the item couldn't be folded in.
+func (c *FoldInCache) ItemFactor(m ranking.MatrixFactorization, itemId string, userIds []string) []float32 {
+	if itemIndex := m.GetItemIndex().ToNumber(itemId); m.IsItemPredictable(itemIndex) {
+		return m.GetItemFactor(itemIndex)
+	}
+	foldIn, _, items := c.sync(m)
+	if cached := items.Get(itemId); cached != nil && cached.Value().numFeedback == len(userIds) {
+		return cached.Value().factor
+	}
+	factor := foldIn.FoldInItem(userIds)
+	items.Set(itemId, foldedFactor{numFeedback: len(userIds), factor: factor}, ttlcache.NoTTL)
+	return factor
+}
+
+// Len returns the number of folded users and items.
+func (c *FoldInCache) Len() (numUsers, numItems int) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.users.Len(), c.items.Len()
+}
+
+// ErasedSince returns the score of the latest erasure whose user has been forgotten.
+func (c *FoldInCache) ErasedSince() float64 {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.erasedSince
+}
+
+// Forget drops factors of erased users and advances the score of the latest erasure.
+func (c *FoldInCache) Forget(erasedSince float64, userIds ...string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	for _, userId := range userIds {
+		c.users.Delete(userId)
+	}
+	c.erasedSince = max(c.erasedSince, erasedSince)
+}
+
+// sync drops cached factors if the ranking model has been replaced. Caches are replaced rather than cleared, so
+// factors folded by the previous model are never written into caches of the current model.
+func (c *FoldInCache) sync(m ranking.MatrixFactorization) (*ranking.FoldIn, *ttlcache.Cache[string, foldedFactor], *ttlcache.Cache[string, foldedFactor]) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.model != m {
+		c.model = m
+		c.foldIn = ranking.NewFoldIn(m)
+		c.users = ttlcache.New(ttlcache.WithCapacity[string, foldedFactor](c.capacity))
+		c.items = ttlcache.New(ttlcache.WithCapacity[string, foldedFactor](c.capacity))
+	}
+	return c.foldIn, c.users, c.items
+}
+
+// foldInItems folds recently added items absent from the last fit into the ranking model by their positive feedback.
+// Items added before the last fit, less one fit period for feedback inserted during the fit, have been seen by the
+// ranking model and are left out. Positive feedback of recent items is loaded by a single scan instead of a query per
+// item. It returns factors of folded items.
+func (w *Worker) foldInItems(ctx context.Context, itemCache *ItemCache) (map[string][]float32, error) {
+	fitPeriod := w.Config.Recommend.Collaborative.ModelFitPeriod
+	beginTime := time.Now().Add(-2 * fitPeriod)
+	if fitTime, err := w.CacheClient.Get(ctx, cache.Key(cache.GlobalMeta, cache.LastFitMatchingModelTime)).Time(); err == nil {
+		beginTime = fitTime.Add(-fitPeriod)
+	}
+	// find recent items absent from the last fit
+	userIds := make(map[string][]string)
+	for itemId, item := range itemCache.Data {
+		itemIndex := w.RankingModel.GetItemIndex().ToNumber(itemId)
+		if item.Timestamp.Before(beginTime) || !itemCache.IsAvailable(itemId) || w.RankingModel.IsItemPredictable(itemIndex) {
+			continue
+		}
+		userIds[itemId] = nil
+	}
+	if len(userIds) == 0 {
+		return nil, nil
+	}
+	// load positive feedback of these items
+	feedbackChan, errChan := w.DataClient.GetFeedbackStream(ctx, batchSize,
+		data.WithBeginTime(beginTime), data.WithFeedbackTypes(w.Config.Recommend.DataSource.PositiveFeedbackTypes...))
+	for feedbacks := range feedbackChan {
+		for _, feedback := range feedbacks {
+			if users, exist := userIds[feedback.ItemId]; exist {
+				userIds[feedback.ItemId] = append(users, feedback.UserId)
+			}
+		}
+	}
+	if err := <-errChan; err != nil {
+		return nil, errors.Trace(err)
+	}
+	// fold in items
+	foldedItems := make(map[string][]float32)
+	for itemId, users := range userIds {
+		if factor := w.foldInCache.ItemFactor(w.RankingModel, itemId, users); factor != nil {
+			foldedItems[itemId] = factor
+		}
+	}
+	return foldedItems, nil
+}
+
+// forgetErasedUsers drops folded factors of users erased since the last check. The audit log of erasures is scored by
+// the time of erasure, so it is read in descending order until erasures older than the last check. Erasures in the
+// same second as the last check are read again since forgetting a user twice is harmless.
+func (w *Worker) forgetErasedUsers(ctx context.Context) error {
+	since := w.foldInCache.ErasedSince()
+	latest := since
+	var userIds []string
+	for begin, done := 0, false; !done; begin += batchSize {
+		scores, err := w.CacheClient.SearchScores(ctx, cache.Erasures, "", nil, begin, begin+batchSize)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		done = len(scores) < batchSize
+		for _, score := range scores {
+			if score.Score < since {
+				done = true
+				break
+			}
+			userIds = append(userIds, score.Id)
+			latest = max(latest, score.Score)
+		}
+	}
+	w.foldInCache.Forget(latest, userIds...)
+	return nil
+}
+
+// positiveFeedbackItems returns items in positive feedback.
+func positiveFeedbackItems(cfg *config.Config, feedbacks []data.Feedback) []string {
+	positiveTypes := mapset.NewSet(cfg.Recommend.DataSource.PositiveFeedbackTypes...)
+	var items []string
+	for _, feedback := range feedbacks {
+		if positiveTypes.Contains(feedback.FeedbackType) {
+			items = append(items, feedback.ItemId)
+		}
+	}
+	return items
+}
+
+// collaborativePredict predicts the score of an item for a user by the ranking model. Folded factors are used if the
+// user or the item is absent from the last fit.
+func (w *Worker) collaborativePredict(userId string, userFactor []float32, itemId string, foldedItems map[string][]float32) float32 {
+	userIndex := w.RankingModel.GetUserIndex().ToNumber(userId)
+	itemIndex := w.RankingModel.GetItemIndex().ToNumber(itemId)
+	if w.RankingModel.IsUserPredictable(userIndex) && w.RankingModel.IsItemPredictable(itemIndex) {
+		return w.RankingModel.InternalPredict(userIndex, itemIndex)
+	}
+	itemFactor, folded := foldedItems[itemId]
+	if !folded {
+		if !w.RankingModel.IsItemPredictable(itemIndex) {
+			return 0
+		}
+		itemFactor = w.RankingModel.GetItemFactor(itemIndex)
+	}
+	return floats.Dot(userFactor, itemFactor)
+}
+
+// sortByDistance sorts items by distances in ascending order and keeps the top n items.
+func sortByDistance(items []string, distances []float64, n int) ([]string, []float64) {
+	indices := lo.Range(len(items))
+	sort.SliceStable(indices, func(i, j int) bool {
+		return distances[indices[i]] < distances[indices[j]]
+	})
+	indices = indices[:min(n, len(indices))]
+	return lo.Map(indices, func(i, _ int) string { return items[i] }),
+		lo.Map(indices, func(i, _ int) float64 { return distances[i] })
+}
@@ -0,0 +1,81 @@
+// Copyright 2024 gorse Project Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package worker
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zhenghaoz/gorse/model"
+	"github.com/zhenghaoz/gorse/model/ranking"
+)
+
+func newFoldInModel() ranking.MatrixFactorization {
+	dataset := ranking.NewMapIndexDataset()
+	for i := 0; i < 20; i++ {
+		for j := 0; j < 5; j++ {
+			dataset.AddFeedback(strconv.Itoa(i), strconv.Itoa((i+j)%20), true)
+		}
+	}
+	m := ranking.NewALS(model.Params{model.NFactors: 16, model.NEpochs: 10})
+	m.Fit(context.Background(), dataset, dataset, ranking.NewFitConfig())
+	return m
+}
+
+func TestFoldInCache(t *testing.T) {
+	m := newFoldInModel()
+	cache := NewFoldInCache(10)
+
+	// factors of trained users and items are taken from the model
+	assert.Equal(t, m.GetUserFactor(m.GetUserIndex().ToNumber("0")), cache.UserFactor(m, "0", nil))
+	assert.Equal(t, m.GetItemFactor(m.GetItemIndex().ToNumber("0")), cache.ItemFactor(m, "0", nil))
+	numUsers, numItems := cache.Len()
+	assert.Zero(t, numUsers)
+	assert.Zero(t, numItems)
+
+	// factors of new users and items are folded in and cached
+	userFactor := cache.UserFactor(m, "new_user", []string{"0", "1", "2"})
+	assert.Len(t, userFactor, 16)
+	assert.Equal(t, userFactor, cache.UserFactor(m, "new_user", []string{"0", "1", "2"}))
+	itemFactor := cache.ItemFactor(m, "new_item", []string{"0", "1"})
+	assert.Len(t, itemFactor, 16)
+	assert.Nil(t, cache.ItemFactor(m, "cold_item", nil))
+	numUsers, numItems = cache.Len()
+	assert.Equal(t, 1, numUsers)
+	assert.Equal(t, 2, numItems)
+
+	// factors are folded again once feedback changes
+	assert.NotEqual(t, userFactor, cache.UserFactor(m, "new_user", []string{"0", "1", "2", "10"}))
+
+	// cached factors are dropped once the model is replaced
+	cache.UserFactor(newFoldInModel(), "0", nil)
+	cache.ItemFactor(newFoldInModel(), "new_item", []string{"0"})
+	numUsers, numItems = cache.Len()
+	assert.Zero(t, numUsers)
+	assert.Equal(t, 1, numItems)
+
+	// least recently used factors are evicted beyond the capacity
+	cache = NewFoldInCache(2)
+	cache.ItemFactor(m, "new_item_1", []string{"0"})
+	cache.ItemFactor(m, "new_item_2", []string{"1"})
+	cache.ItemFactor(m, "new_item_1", []string{"0"})
+	cache.ItemFactor(m, "new_item_3", []string{"2"})
+	_, numItems = cache.Len()
+	assert.Equal(t, 2, numItems)
+	assert.NotNil(t, cache.items.Get("new_item_1"))
+	assert.Nil(t, cache.items.Get("new_item_2"))
+}
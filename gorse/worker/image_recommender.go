@@ -19,9 +19,9 @@ import (
 
     mapset "github.com/deckarep/golang-set/v2"
     "github.com/juju/errors"
-    "github.com/samber/lo"
     "github.com/zhenghaoz/gorse/base/heap"
     "github.com/zhenghaoz/gorse/base/log"
+    "github.com/zhenghaoz/gorse/config"
     "github.com/zhenghaoz/gorse/storage/cache"
     "github.com/zhenghaoz/gorse/storage/data"
     "go.uber.org/zap"
@@ -30,11 +30,19 @@ import (
 // ImageBasedRecommender is the recommender using image similarity.
 type ImageBasedRecommender struct {
     *Worker
+    // Config shadows the configuration of the worker so that overrides of experiments could be applied.
+    Config *config.Config
 }
 
 // NewImageBasedRecommender creates a new ImageBasedRecommender.
 func NewImageBasedRecommender(w *Worker) *ImageBasedRecommender {
-    return &ImageBasedRecommender{Worker: w}
+    return &ImageBasedRecommender{Worker: w, Config: w.Config}
+}
+
+// WithConfig replaces the configuration used by the recommender.
+func (r *ImageBasedRecommender) WithConfig(cfg *config.Config) *ImageBasedRecommender {
+    r.Config = cfg
+    return r
 }
 
 // Recommend items to a user based on image similarity.
@@ -43,7 +51,7 @@ func (r *ImageBasedRecommender) Recommend(ctx context.Context, userId string, ca
     candidates := make(map[string][]string)
 
     // Get user's positive feedback items
-    positiveItems, err := r.loadUserPositiveFeedbackItems(ctx, userId)
+    positiveFeedback, err := r.loadUserPositiveFeedback(ctx, userId)
     if err != nil {
         return nil, 0, errors.Trace(err)
     }
@@ -52,7 +60,9 @@ func (r *ImageBasedRecommender) Recommend(ctx context.Context, userId string, ca
     for _, category := range append([]string{""}, categories...) {
         // Collect candidates with scores
         scores := make(map[string]float64)
-        for _, itemId := range positiveItems {
+        for _, feedback := range positiveFeedback {
+            itemId := feedback.ItemId
+            confidence := r.Config.Recommend.DataSource.Confidence(feedback.FeedbackType, feedback.Value)
             // Get similar items based on image embeddings
             similarItems, err := r.CacheClient.SearchScores(ctx, cache.ImageSimilar, itemId, []string{category}, 0, r.Config.Recommend.ImageEmbeddings.NumSimilar)
             if err != nil {
@@ -66,7 +76,7 @@ func (r *ImageBasedRecommender) Recommend(ctx context.Context, userId string, ca
             // Add unseen items
             for _, item := range similarItems {
                 if !excludeSet.Contains(item.Id) && itemCache.IsAvailable(item.Id) {
-                    scores[item.Id] += item.Score * r.Config.Recommend.ImageEmbeddings.ImageWeight
+                    scores[item.Id] += item.Score * r.Config.Recommend.ImageEmbeddings.ImageWeight * confidence
                 }
             }
         }
@@ -83,14 +93,12 @@ func (r *ImageBasedRecommender) Recommend(ctx context.Context, userId string, ca
     return candidates, time.Since(startTime), nil
 }
 
-// loadUserPositiveFeedbackItems loads positive feedback items for a user.
-func (r *ImageBasedRecommender) loadUserPositiveFeedbackItems(ctx context.Context, userId string) ([]string, error) {
+// loadUserPositiveFeedback loads positive feedback for a user.
+func (r *ImageBasedRecommender) loadUserPositiveFeedback(ctx context.Context, userId string) ([]data.Feedback, error) {
     now := time.Now()
     feedbacks, err := r.DataClient.GetUserFeedback(ctx, userId, &now, r.Config.Recommend.DataSource.PositiveFeedbackTypes...)
     if err != nil {
         return nil, errors.Trace(err)
     }
-    return lo.Map(feedbacks, func(feedback data.Feedback, _ int) string {
-        return feedback.ItemId
-    }), nil
+    return feedbacks, nil
 } 
\ No newline at end of file
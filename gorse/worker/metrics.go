@@ -20,8 +20,10 @@ import (
 )
 
 const (
-	LabelStep = "step"
-	LabelData = "data"
+	LabelStep   = "step"
+	LabelData   = "data"
+	LabelModel  = "model"
+	LabelMetric = "metric"
 )
 
 var (
@@ -50,4 +52,9 @@ var (
 		Subsystem: "worker",
 		Name:      "memory_inuse_bytes",
 	}, []string{LabelData})
+	ShadowAgreementVec = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "gorse",
+		Subsystem: "worker",
+		Name:      "shadow_agreement",
+	}, []string{LabelModel, LabelMetric})
 )
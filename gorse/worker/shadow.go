@@ -0,0 +1,296 @@
+// Copyright 2024 gorse Project Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package worker
+
+import (
+	"io"
+	"os"
+	"sort"
+	"sync"
+
+	"github.com/juju/errors"
+	"github.com/samber/lo"
+	"github.com/zhenghaoz/gorse/base/encoding"
+	"github.com/zhenghaoz/gorse/base/log"
+	"github.com/zhenghaoz/gorse/model/click"
+	"github.com/zhenghaoz/gorse/model/ranking"
+	"github.com/zhenghaoz/gorse/storage/blob"
+	"github.com/zhenghaoz/gorse/storage/cache"
+	"github.com/zhenghaoz/gorse/storage/data"
+	"go.uber.org/zap"
+)
+
+// shadowTopK is the number of top items compared between the served model and the candidate model.
+const shadowTopK = 10
+
+// ShadowEvaluator evaluates candidate models in shadow mode. Recommendations ranked by served models are scored by
+// candidate models as well, and agreements between served models and candidate models are accumulated until flushed.
+type ShadowEvaluator struct {
+	mutex               sync.Mutex
+	rankingModel        ranking.MatrixFactorization
+	rankingModelVersion int64
+	clickModel          click.FactorizationMachine
+	clickModelVersion   int64
+	idleClickModels     []click.FactorizationMachine
+	rankingAgreement    agreementStats
+	clickAgreement      agreementStats
+}
+
+// agreementStats accumulates agreements between the served model and the candidate model.
+type agreementStats struct {
+	count       int
+	overlap     float64
+	correlation float64
+}
+
+func (s *agreementStats) add(overlap, correlation float64) {
+	s.count++
+	s.overlap += overlap
+	s.correlation += correlation
+}
+
+// NewShadowEvaluator creates a ShadowEvaluator without candidate models.
+func NewShadowEvaluator() *ShadowEvaluator {
+	return &ShadowEvaluator{}
+}
+
+// SetRankingModel sets the candidate ranking model. Shadow evaluation of ranking models stops if the model is nil.
+func (e *ShadowEvaluator) SetRankingModel(m ranking.MatrixFactorization, version int64) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	e.rankingModel = m
+	e.rankingModelVersion = version
+	e.rankingAgreement = agreementStats{}
+}
+
+// SetClickModel sets the candidate click model. Shadow evaluation of click models stops if the model is nil.
+func (e *ShadowEvaluator) SetClickModel(m click.FactorizationMachine, version int64) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	e.clickModel = m
+	e.clickModelVersion = version
+	e.idleClickModels = nil
+	e.clickAgreement = agreementStats{}
+}
+
+// RankingModelVersion returns the version of the candidate ranking model.
+func (e *ShadowEvaluator) RankingModelVersion() int64 {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	return e.rankingModelVersion
+}
+
+// ClickModelVersion returns the version of the candidate click model.
+func (e *ShadowEvaluator) ClickModelVersion() int64 {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	return e.clickModelVersion
+}
+
+// EvaluateRanking scores items recommended to a user by the candidate ranking model and compares them with scores
+// predicted by the served ranking model. Users unknown to the candidate model are skipped.
+func (e *ShadowEvaluator) EvaluateRanking(userId string, items []string, predict func(itemId string) float32) {
+	e.mutex.Lock()
+	candidate := e.rankingModel
+	e.mutex.Unlock()
+	if candidate == nil || len(items) == 0 || !candidate.IsUserPredictable(candidate.GetUserIndex().ToNumber(userId)) {
+		return
+	}
+	served := make([]float64, len(items))
+	shadow := make([]float64, len(items))
+	for i, itemId := range items {
+		served[i] = float64(predict(itemId))
+		shadow[i] = float64(candidate.Predict(userId, itemId))
+	}
+	overlap, correlation := agreement(served, shadow, shadowTopK)
+	e.mutex.Lock()
+	e.rankingAgreement.add(overlap, correlation)
+	e.mutex.Unlock()
+}
+
+// EvaluateClick scores items ranked by the served click model by the candidate click model, and compares scores of
+// both models. Click models aren't safe for concurrent prediction, so each evaluation borrows an idle copy of the
+// candidate model and predicts outside the lock.
+func (e *ShadowEvaluator) EvaluateClick(user *data.User, scores []cache.Score, itemCache *ItemCache) {
+	e.mutex.Lock()
+	candidate, version := e.clickModel, e.clickModelVersion
+	var copied click.FactorizationMachine
+	if n := len(e.idleClickModels); n > 0 {
+		copied = e.idleClickModels[n-1]
+		e.idleClickModels = e.idleClickModels[:n-1]
+	}
+	e.mutex.Unlock()
+	if candidate == nil || len(scores) == 0 {
+		return
+	}
+	if copied == nil {
+		copied = click.Spawn(candidate)
+	}
+	var served, shadow []float64
+	userFeatures := click.ConvertLabelsToFeatures(user.Labels)
+	for _, score := range scores {
+		if item, exist := itemCache.Get(score.Id); exist {
+			served = append(served, score.Score)
+			shadow = append(shadow, float64(copied.Predict(user.UserId, item.ItemId,
+				userFeatures, click.ConvertLabelsToFeatures(item.Labels), nil)))
+		}
+	}
+	var overlap, correlation float64
+	if len(served) > 0 {
+		overlap, correlation = agreement(served, shadow, shadowTopK)
+	}
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	// the copy and the agreement are dropped if the candidate model has been replaced
+	if e.clickModel != nil && e.clickModelVersion == version {
+		e.idleClickModels = append(e.idleClickModels, copied)
+		if len(served) > 0 {
+			e.clickAgreement.add(overlap, correlation)
+		}
+	}
+}
+
+// Flush logs agreements accumulated since the last flush.
+func (e *ShadowEvaluator) Flush(rankingModelVersion, clickModelVersion int64) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	if e.rankingModel != nil && e.rankingAgreement.count > 0 {
+		e.rankingAgreement.flush(blob.RankingModelType, rankingModelVersion, e.rankingModelVersion)
+	}
+	if e.clickModel != nil && e.clickAgreement.count > 0 {
+		e.clickAgreement.flush(blob.ClickModelType, clickModelVersion, e.clickModelVersion)
+	}
+}
+
+func (s *agreementStats) flush(modelType string, servedVersion, candidateVersion int64) {
+	overlap := s.overlap / float64(s.count)
+	correlation := s.correlation / float64(s.count)
+	log.Logger().Info("shadow model agreement",
+		zap.String("type", modelType),
+		zap.String("served_version", encoding.Hex(servedVersion)),
+		zap.String("candidate_version", encoding.Hex(candidateVersion)),
+		zap.Int("n_users", s.count),
+		zap.Float64("top_k_overlap", overlap),
+		zap.Float64("rank_correlation", correlation))
+	ShadowAgreementVec.WithLabelValues(modelType, "top_k_overlap").Set(overlap)
+	ShadowAgreementVec.WithLabelValues(modelType, "rank_correlation").Set(correlation)
+	*s = agreementStats{}
+}
+
+// agreement measures the agreement between scores of the served model and scores of the candidate model by the
+// overlap of top k items and the Spearman's rank correlation.
+func agreement(served, candidate []float64, k int) (overlap, correlation float64) {
+	n := len(served)
+	servedOrder, candidateOrder := argSortDesc(served), argSortDesc(candidate)
+	// overlap of top k items
+	k = min(k, n)
+	topK := lo.SliceToMap(servedOrder[:k], func(i int) (int, struct{}) { return i, struct{}{} })
+	for _, i := range candidateOrder[:k] {
+		if _, exist := topK[i]; exist {
+			overlap++
+		}
+	}
+	overlap /= float64(k)
+	// Spearman's rank correlation
+	if n < 2 {
+		return overlap, 1
+	}
+	servedRanks, candidateRanks := make([]int, n), make([]int, n)
+	for rank := 0; rank < n; rank++ {
+		servedRanks[servedOrder[rank]] = rank
+		candidateRanks[candidateOrder[rank]] = rank
+	}
+	var sum float64
+	for i := 0; i < n; i++ {
+		d := float64(servedRanks[i] - candidateRanks[i])
+		sum += d * d
+	}
+	correlation = 1 - 6*sum/float64(n*(n*n-1))
+	return overlap, correlation
+}
+
+// argSortDesc returns indices of scores in descending order of scores.
+func argSortDesc(scores []float64) []int {
+	indices := lo.Range(len(scores))
+	sort.SliceStable(indices, func(i, j int) bool {
+		return scores[indices[i]] > scores[indices[j]]
+	})
+	return indices
+}
+
+// pullShadowModels downloads candidate models from the blob store of the master. Shadow evaluation stops once the
+// master stops publishing candidate models.
+func (w *Worker) pullShadowModels() {
+	if version := w.latestShadowRankingModelVersion; version != w.shadow.RankingModelVersion() {
+		if version == 0 {
+			w.shadow.SetRankingModel(nil, 0)
+			log.Logger().Info("stop shadow evaluation of ranking model")
+		} else {
+			var rankingModel ranking.MatrixFactorization
+			err := w.downloadModel(blob.ModelBlobName(blob.RankingModelType, version), func(r io.Reader) (err error) {
+				rankingModel, err = ranking.UnmarshalModel(r)
+				return
+			})
+			if err != nil {
+				log.Logger().Error("failed to pull candidate ranking model", zap.Error(err))
+			} else {
+				w.shadow.SetRankingModel(rankingModel, version)
+				log.Logger().Info("start shadow evaluation of ranking model", zap.String("version", encoding.Hex(version)))
+			}
+		}
+	}
+	if version := w.latestShadowClickModelVersion; version != w.shadow.ClickModelVersion() {
+		if version == 0 {
+			w.shadow.SetClickModel(nil, 0)
+			log.Logger().Info("stop shadow evaluation of click model")
+		} else {
+			var clickModel click.FactorizationMachine
+			err := w.downloadModel(blob.ModelBlobName(blob.ClickModelType, version), func(r io.Reader) (err error) {
+				clickModel, err = click.UnmarshalModel(r)
+				return
+			})
+			if err != nil {
+				log.Logger().Error("failed to pull candidate click model", zap.Error(err))
+			} else {
+				w.shadow.SetClickModel(clickModel, version)
+				log.Logger().Info("start shadow evaluation of click model", zap.String("version", encoding.Hex(version)))
+			}
+		}
+	}
+}
+
+// downloadModel downloads a model from the blob store of the master.
+func (w *Worker) downloadModel(name string, unmarshal func(r io.Reader) error) error {
+	file, err := os.CreateTemp("", "gorse-model-*")
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if err = file.Close(); err != nil {
+		return errors.Trace(err)
+	}
+	defer func() {
+		_ = os.Remove(file.Name())
+	}()
+	if err = w.blobClient.DownloadBlob(name, file.Name()); err != nil {
+		return errors.Trace(err)
+	}
+	if file, err = os.Open(file.Name()); err != nil {
+		return errors.Trace(err)
+	}
+	defer func(file *os.File) {
+		_ = file.Close()
+	}(file)
+	return errors.Trace(unmarshal(file))
+}
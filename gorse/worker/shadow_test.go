@@ -0,0 +1,111 @@
+// Copyright 2024 gorse Project Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package worker
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zhenghaoz/gorse/storage/cache"
+	"github.com/zhenghaoz/gorse/storage/data"
+)
+
+func TestAgreement(t *testing.T) {
+	// identical rankings
+	overlap, correlation := agreement([]float64{4, 3, 2, 1}, []float64{40, 30, 20, 10}, 2)
+	assert.Equal(t, 1.0, overlap)
+	assert.Equal(t, 1.0, correlation)
+	// reversed rankings
+	overlap, correlation = agreement([]float64{4, 3, 2, 1}, []float64{1, 2, 3, 4}, 2)
+	assert.Zero(t, overlap)
+	assert.Equal(t, -1.0, correlation)
+	// partially agreed rankings
+	overlap, correlation = agreement([]float64{4, 3, 2, 1}, []float64{4, 2, 3, 1}, 2)
+	assert.Equal(t, 0.5, overlap)
+	assert.Equal(t, 0.8, correlation)
+	// single item
+	overlap, correlation = agreement([]float64{1}, []float64{2}, 10)
+	assert.Equal(t, 1.0, overlap)
+	assert.Equal(t, 1.0, correlation)
+}
+
+func TestShadowEvaluator(t *testing.T) {
+	m := newFoldInModel()
+	evaluator := NewShadowEvaluator()
+	items := []string{"0", "1", "2", "3", "4", "10"}
+
+	// no candidate model
+	evaluator.EvaluateRanking("0", items, func(itemId string) float32 { return 0 })
+	assert.Zero(t, evaluator.rankingAgreement.count)
+
+	// candidate model agrees with the served model
+	evaluator.SetRankingModel(m, 1)
+	evaluator.EvaluateRanking("0", items, func(itemId string) float32 { return m.Predict("0", itemId) })
+	evaluator.EvaluateRanking("new_user", items, func(itemId string) float32 { return 0 })
+	assert.Equal(t, 1, evaluator.rankingAgreement.count)
+	assert.Equal(t, 1.0, evaluator.rankingAgreement.overlap)
+	assert.InDelta(t, 1.0, evaluator.rankingAgreement.correlation, 1e-6)
+	evaluator.Flush(0, 0)
+	assert.Zero(t, evaluator.rankingAgreement.count)
+
+	// candidate model disagrees with the served model
+	evaluator.EvaluateRanking("0", items, func(itemId string) float32 {
+		index, _ := strconv.Atoi(itemId)
+		return float32(index)
+	})
+	assert.Less(t, evaluator.rankingAgreement.correlation, 0.0)
+
+	// stop shadow evaluation
+	evaluator.SetRankingModel(nil, 0)
+	assert.Zero(t, evaluator.RankingModelVersion())
+	assert.Zero(t, evaluator.rankingAgreement.count)
+}
+
+func TestShadowEvaluator_Click(t *testing.T) {
+	evaluator := NewShadowEvaluator()
+	itemCache := NewItemCache()
+	var scores []cache.Score
+	for i := 5; i > 0; i-- {
+		itemCache.Set(strconv.Itoa(i), data.Item{ItemId: strconv.Itoa(i)})
+		scores = append(scores, cache.Score{Id: strconv.Itoa(i), Score: float64(i)})
+	}
+
+	// no candidate model
+	evaluator.EvaluateClick(&data.User{UserId: "0"}, scores, itemCache)
+	assert.Zero(t, evaluator.clickAgreement.count)
+
+	// candidate models are evaluated concurrently by idle copies
+	evaluator.SetClickModel(&mockFactorizationMachine{}, 1)
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			evaluator.EvaluateClick(&data.User{UserId: "0"}, scores, itemCache)
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, 10, evaluator.clickAgreement.count)
+	assert.Equal(t, 10.0, evaluator.clickAgreement.overlap)
+	assert.NotEmpty(t, evaluator.idleClickModels)
+
+	// stop shadow evaluation
+	evaluator.SetClickModel(nil, 0)
+	assert.Zero(t, evaluator.ClickModelVersion())
+	assert.Zero(t, evaluator.clickAgreement.count)
+	assert.Empty(t, evaluator.idleClickModels)
+}
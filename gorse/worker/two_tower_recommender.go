@@ -0,0 +1,112 @@
+// Copyright 2024 gorse Project Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package worker
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	mapset "github.com/deckarep/golang-set/v2"
+	"github.com/samber/lo"
+	"github.com/zhenghaoz/gorse/base/search"
+	"github.com/zhenghaoz/gorse/common/nn"
+	"github.com/zhenghaoz/gorse/config"
+	"github.com/zhenghaoz/gorse/logics"
+	"github.com/zhenghaoz/gorse/model/click"
+	"github.com/zhenghaoz/gorse/storage/data"
+)
+
+// TwoTowerRecommender retrieves items by the two-tower model. Items are encoded by the item tower from labels,
+// categories and image embeddings, so fresh items without any feedback could be retrieved as well.
+type TwoTowerRecommender struct {
+	model           *nn.TwoTower
+	config          *config.Config
+	itemIds         []string
+	index           search.VectorIndex
+	userTransformer *click.LabelTransformer
+}
+
+// NewTwoTowerRecommender encodes available items in the item cache and builds a vector index on item vectors. Numeric
+// labels are transformed as they are in the training data of the two-tower model.
+func NewTwoTowerRecommender(ctx context.Context, cfg *config.Config, model *nn.TwoTower, itemCache *ItemCache, jobs int,
+	userTransformer, itemTransformer *click.LabelTransformer) *TwoTowerRecommender {
+	itemIds := make([]string, 0, itemCache.Len())
+	for itemId := range itemCache.Data {
+		if itemCache.IsAvailable(itemId) {
+			itemIds = append(itemIds, itemId)
+		}
+	}
+	sort.Strings(itemIds)
+	items := make([]nn.TowerInput, len(itemIds))
+	for i, itemId := range itemIds {
+		item, _ := itemCache.Get(itemId)
+		items[i] = logics.NewItemTowerInput(itemId, itemTransformer.Transform(click.ConvertLabelsToFeatures(item.Labels)), item.Categories,
+			logics.ImageEmbedding(item.Labels, cfg.Recommend.ImageEmbeddings.EmbeddingLabel, cfg.Recommend.ImageEmbeddings.EmbeddingDim))
+	}
+	vectors := make([]search.Vector, len(itemIds))
+	for i, vector := range model.EncodeItems(items) {
+		vectors[i] = search.NewDenseVector(vector, itemCache.GetCategory(itemIds[i]), false)
+	}
+	var index search.VectorIndex
+	if cfg.Recommend.Collaborative.EnableIndex {
+		index = search.NewHNSW(vectors, search.SetHNSWNumJobs(jobs))
+	} else {
+		index = search.NewBruteforce(vectors)
+	}
+	index.Build(ctx)
+	return &TwoTowerRecommender{
+		model:           model,
+		config:          cfg,
+		itemIds:         itemIds,
+		index:           index,
+		userTransformer: userTransformer,
+	}
+}
+
+// Recommend items to a user. The user is encoded by the user tower from labels and positive feedback.
+func (r *TwoTowerRecommender) Recommend(user *data.User, feedbacks []data.Feedback, categories []string, excludeSet mapset.Set[string], itemCache *ItemCache) (map[string][]string, time.Duration) {
+	startTime := time.Now()
+	// collect positive items from the earliest to the latest
+	positiveTypes := mapset.NewSet(r.config.Recommend.DataSource.PositiveFeedbackTypes...)
+	positiveFeedback := lo.Filter(feedbacks, func(feedback data.Feedback, _ int) bool {
+		return positiveTypes.Contains(feedback.FeedbackType)
+	})
+	sort.SliceStable(positiveFeedback, func(i, j int) bool {
+		return positiveFeedback[i].Timestamp.Before(positiveFeedback[j].Timestamp)
+	})
+	history := lo.Map(positiveFeedback, func(feedback data.Feedback, _ int) string {
+		return feedback.ItemId
+	})
+	userVector := r.model.EncodeUsers([]nn.TowerInput{
+		logics.NewUserTowerInput(user.UserId, r.userTransformer.Transform(click.ConvertLabelsToFeatures(user.Labels)), history),
+	})[0]
+
+	// search nearest items
+	values, _ := r.index.MultiSearch(search.NewDenseVector(userVector, nil, false), categories,
+		r.config.Recommend.CacheSize+excludeSet.Cardinality(), false)
+	recommend := make(map[string][]string)
+	for category, catValues := range values {
+		recommendItems := make([]string, 0, len(catValues))
+		for _, value := range catValues {
+			itemId := r.itemIds[value]
+			if !excludeSet.Contains(itemId) && itemCache.IsAvailable(itemId) {
+				recommendItems = append(recommendItems, itemId)
+			}
+		}
+		recommend[category] = recommendItems
+	}
+	return recommend, time.Since(startTime)
+}
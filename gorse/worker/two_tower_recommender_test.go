@@ -0,0 +1,83 @@
+// Copyright 2024 gorse Project Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package worker
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"testing"
+	"time"
+
+	mapset "github.com/deckarep/golang-set/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/zhenghaoz/gorse/common/nn"
+	"github.com/zhenghaoz/gorse/config"
+	"github.com/zhenghaoz/gorse/logics"
+	"github.com/zhenghaoz/gorse/model"
+	"github.com/zhenghaoz/gorse/model/click"
+	"github.com/zhenghaoz/gorse/storage/data"
+)
+
+func TestTwoTowerRecommender(t *testing.T) {
+	// items are either red or blue, and users like items in one color
+	colors := []string{"red", "blue"}
+	var items []nn.TowerInput
+	for i := 0; i < 40; i++ {
+		items = append(items, logics.NewItemTowerInput(fmt.Sprintf("%d", i),
+			[]click.Feature{{Name: "color." + colors[i%2], Value: 1}}, []string{colors[i%2]}, nil))
+	}
+	var users []nn.TowerInput
+	var feedbackUsers, feedbackItems []int32
+	rng := rand.New(rand.NewSource(0))
+	for i := 0; i < 40; i++ {
+		var history []string
+		for j := 0; j < 5; j++ {
+			itemIndex := int32(rng.Intn(20)*2 + i%2)
+			history = append(history, items[itemIndex].Id)
+			feedbackUsers = append(feedbackUsers, int32(i))
+			feedbackItems = append(feedbackItems, itemIndex)
+		}
+		users = append(users, logics.NewUserTowerInput(fmt.Sprintf("%d", i), nil, history))
+	}
+	twoTower := nn.NewTwoTower(model.Params{
+		model.NFactors:  16,
+		model.NEpochs:   30,
+		model.BatchSize: 32,
+		model.Lr:        0.01,
+	})
+	twoTower.Fit(context.Background(), users, items, feedbackUsers, feedbackItems)
+
+	// fresh items without feedback
+	itemCache := NewItemCache()
+	itemCache.Set("new_red", data.Item{ItemId: "new_red", Categories: []string{"red"}, Labels: map[string]any{"color": "red"}})
+	itemCache.Set("new_blue", data.Item{ItemId: "new_blue", Categories: []string{"blue"}, Labels: map[string]any{"color": "blue"}})
+	itemCache.Set("hidden_red", data.Item{ItemId: "hidden_red", IsHidden: true, Labels: map[string]any{"color": "red"}})
+	itemCache.Set("0", data.Item{ItemId: "0", Categories: []string{"red"}, Labels: map[string]any{"color": "red"}})
+	cfg := config.GetDefaultConfig()
+	cfg.Recommend.DataSource.PositiveFeedbackTypes = []string{"like"}
+	recommender := NewTwoTowerRecommender(context.Background(), cfg, twoTower, itemCache, 1, nil, nil)
+
+	// new user likes red items
+	timestamp := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	recommend, _ := recommender.Recommend(&data.User{UserId: "new_user"}, []data.Feedback{
+		{FeedbackKey: data.FeedbackKey{FeedbackType: "like", ItemId: "2"}, Timestamp: timestamp},
+		{FeedbackKey: data.FeedbackKey{FeedbackType: "like", ItemId: "0"}, Timestamp: timestamp.Add(time.Hour)},
+		{FeedbackKey: data.FeedbackKey{FeedbackType: "read", ItemId: "1"}, Timestamp: timestamp},
+	}, []string{"red", "blue"}, mapset.NewSet("0", "1", "2"), itemCache)
+	assert.Equal(t, []string{"new_red", "new_blue"}, recommend[""])
+	assert.Equal(t, []string{"new_red"}, recommend["red"])
+	assert.Equal(t, []string{"new_blue"}, recommend["blue"])
+}
@@ -18,49 +18,57 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"math"
 	"math/rand"
 	"net/http"
 	"os"
 	"reflect"
 	"strings"
+	"sync"
 	"time"
 
 	mapset "github.com/deckarep/golang-set/v2"
 	"github.com/google/uuid"
 	"github.com/juju/errors"
-	"github.com/lafikl/consistent"
 	cmap "github.com/orcaman/concurrent-map"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/samber/lo"
 	"github.com/thoas/go-funk"
 	"github.com/zhenghaoz/gorse/base"
 	"github.com/zhenghaoz/gorse/base/encoding"
+	"github.com/zhenghaoz/gorse/base/floats"
 	"github.com/zhenghaoz/gorse/base/heap"
 	"github.com/zhenghaoz/gorse/base/log"
 	"github.com/zhenghaoz/gorse/base/parallel"
 	"github.com/zhenghaoz/gorse/base/progress"
 	"github.com/zhenghaoz/gorse/base/search"
+	"github.com/zhenghaoz/gorse/base/sharding"
 	"github.com/zhenghaoz/gorse/base/sizeof"
 	"github.com/zhenghaoz/gorse/cmd/version"
 	encoding2 "github.com/zhenghaoz/gorse/common/encoding"
 	"github.com/zhenghaoz/gorse/common/util"
 	"github.com/zhenghaoz/gorse/config"
+	"github.com/zhenghaoz/gorse/logics"
 	"github.com/zhenghaoz/gorse/model/click"
 	"github.com/zhenghaoz/gorse/model/ranking"
 	"github.com/zhenghaoz/gorse/protocol"
 	"github.com/zhenghaoz/gorse/storage"
+	"github.com/zhenghaoz/gorse/storage/blob"
 	"github.com/zhenghaoz/gorse/storage/cache"
 	"github.com/zhenghaoz/gorse/storage/data"
 	"go.uber.org/atomic"
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/proto"
 )
 
 const (
 	batchSize                 = 10000
+	foldInCacheCapacity       = 100000
 	recommendComplexityFactor = 100
 )
 
@@ -99,25 +107,48 @@ type Worker struct {
 	// master connection
 	conn         *grpc.ClientConn
 	masterClient protocol.MasterClient
+	blobClient   *blob.MasterStoreClient
 
-	latestRankingModelVersion int64
-	latestClickModelVersion   int64
-	rankingIndex              *search.HNSW
-	randGenerator             *rand.Rand
+	latestRankingModelVersion  int64
+	latestClickModelVersion    int64
+	latestTwoTowerModelVersion int64
+	rankingIndex               *search.HNSW
+	foldInCache                *FoldInCache
+	randGenerator              *rand.Rand
+
+	// candidate models evaluated in shadow mode
+	latestShadowRankingModelVersion int64
+	latestShadowClickModelVersion   int64
+	shadow                          *ShadowEvaluator
 
 	// peers
-	peers []string
-	me    string
+	peers      []string
+	readyPeers []string
+	me         string
+	ready      bool
 
 	// scheduler state
 	scheduleState ScheduleState
 
+	// recommendation is generated by the periodic loop exclusively, since rankers and the ranking index are shared
+	recommendMutex sync.Mutex
+	// items scanned by the last periodic loop, which are reused by the real-time refresh
+	lastScan atomic.Pointer[recommendScan]
+
 	// events
-	tickDuration time.Duration
-	ticker       *time.Ticker
-	syncedChan   *parallel.ConditionChannel // meta synced events
-	pulledChan   *parallel.ConditionChannel // model pulled events
-	triggerChan  *parallel.ConditionChannel // manually triggered events
+	tickDuration        time.Duration
+	ticker              *time.Ticker
+	refreshTickDuration time.Duration
+	refreshTicker       *time.Ticker
+	syncedChan          *parallel.ConditionChannel // meta synced events
+	pulledChan          *parallel.ConditionChannel // model pulled events
+	triggerChan         *parallel.ConditionChannel // manually triggered events
+	done                chan struct{}              // closed once the worker of a tenant is stopped
+
+	// tenant served by this worker, empty for the worker of the cluster
+	tenant       string
+	tenants      map[string]*Worker
+	tenantsMutex sync.Mutex
 }
 
 // NewWorker creates a new worker node.
@@ -136,6 +167,9 @@ func NewWorker(
 		managedMode:   managedMode,
 		Settings:      config.NewSettings(),
 		randGenerator: base.NewRand(time.Now().UTC().UnixNano()),
+		foldInCache:   NewFoldInCache(foldInCacheCapacity),
+		shadow:        NewShadowEvaluator(),
+		tenants:       make(map[string]*Worker),
 		// config
 		cacheFile:  cacheFile,
 		masterHost: masterHost,
@@ -145,11 +179,13 @@ func NewWorker(
 		httpPort:   httpPort,
 		jobs:       jobs,
 		// events
-		tickDuration: time.Minute,
-		ticker:       time.NewTicker(time.Minute),
-		syncedChan:   parallel.NewConditionChannel(),
-		pulledChan:   parallel.NewConditionChannel(),
-		triggerChan:  parallel.NewConditionChannel(),
+		tickDuration:        time.Minute,
+		ticker:              time.NewTicker(time.Minute),
+		refreshTickDuration: time.Second,
+		refreshTicker:       time.NewTicker(time.Second),
+		syncedChan:          parallel.NewConditionChannel(),
+		pulledChan:          parallel.NewConditionChannel(),
+		triggerChan:         parallel.NewConditionChannel(),
 	}
 }
 
@@ -158,108 +194,205 @@ func (w *Worker) SetOneMode(settings *config.Settings) {
 	w.Settings = settings
 }
 
-// Sync this worker to the master.
+// Sync this worker to the master. Meta is pushed by the master once models are updated, and pulled every meta timeout
+// if the master doesn't support pushing meta.
 func (w *Worker) Sync() {
 	defer base.CheckPanic()
 	log.Logger().Info("start meta sync", zap.Duration("meta_timeout", w.Config.Master.MetaTimeout))
+	watch := true
 	for {
-		var meta *protocol.Meta
-		var err error
-		if meta, err = w.masterClient.GetMeta(context.Background(),
-			&protocol.NodeInfo{
-				NodeType:      protocol.NodeType_Worker,
-				Uuid:          w.workerName,
-				BinaryVersion: version.Version,
-				Hostname:      lo.Must(os.Hostname()),
-			}); err != nil {
+		if watch {
+			err := w.watchMeta()
+			if status.Code(err) == codes.Unimplemented {
+				log.Logger().Warn("master doesn't push meta, fall back to pull meta")
+				watch = false
+				continue
+			} else if err != nil {
+				log.Logger().Error("failed to watch meta", zap.Error(err))
+			}
+		} else if meta, err := w.masterClient.GetMeta(context.Background(), w.nodeInfo()); err != nil {
 			log.Logger().Error("failed to get meta", zap.Error(err))
-			goto sleep
+		} else {
+			w.syncMeta(meta)
+		}
+		if w.testMode {
+			return
+		}
+		select {
+		case <-w.done:
+			return
+		case <-time.After(w.Config.Master.MetaTimeout):
 		}
+	}
+}
 
-		// load master config
-		w.Config.Recommend.Offline.Lock()
-		err = json.Unmarshal([]byte(meta.Config), &w.Config)
+// watchMeta receives meta pushed by the master until the stream is broken.
+func (w *Worker) watchMeta() error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stream, err := w.masterClient.WatchMeta(ctx, w.nodeInfo())
+	if err != nil {
+		return err
+	}
+	for {
+		meta, err := stream.Recv()
 		if err != nil {
-			w.Config.Recommend.Offline.UnLock()
-			log.Logger().Error("failed to parse master config", zap.Error(err))
-			goto sleep
+			return err
+		}
+		w.syncMeta(meta)
+		if w.testMode {
+			return nil
 		}
+	}
+}
+
+func (w *Worker) nodeInfo() *protocol.NodeInfo {
+	return &protocol.NodeInfo{
+		NodeType:      protocol.NodeType_Worker,
+		Uuid:          w.workerName,
+		BinaryVersion: version.Version,
+		Hostname:      lo.Must(os.Hostname()),
+	}
+}
+
+// syncMeta applies meta from the master.
+func (w *Worker) syncMeta(meta *protocol.Meta) {
+	var err error
+	// load master config
+	w.Config.Recommend.Offline.Lock()
+	err = json.Unmarshal([]byte(meta.Config), &w.Config)
+	if err != nil {
 		w.Config.Recommend.Offline.UnLock()
+		log.Logger().Error("failed to parse master config", zap.Error(err))
+		return
+	}
+	w.Config.Recommend.Offline.UnLock()
+	w.Config.ResetExperimentConfigs()
 
-		// reset ticker
-		if w.tickDuration != w.Config.Recommend.Offline.CheckRecommendPeriod {
-			w.tickDuration = w.Config.Recommend.Offline.CheckRecommendPeriod
-			w.ticker.Reset(w.Config.Recommend.Offline.CheckRecommendPeriod)
-		}
+	// reset ticker
+	if w.tickDuration != w.Config.Recommend.Offline.CheckRecommendPeriod {
+		w.tickDuration = w.Config.Recommend.Offline.CheckRecommendPeriod
+		w.ticker.Reset(w.Config.Recommend.Offline.CheckRecommendPeriod)
+	}
+	if w.refreshTickDuration != w.Config.Recommend.Offline.RealtimeRefreshPeriod {
+		w.refreshTickDuration = w.Config.Recommend.Offline.RealtimeRefreshPeriod
+		w.refreshTicker.Reset(w.Config.Recommend.Offline.RealtimeRefreshPeriod)
+	}
 
-		// connect to data store
-		if w.dataPath != w.Config.Database.DataStore || w.dataPrefix != w.Config.Database.DataTablePrefix {
-			if strings.HasPrefix(w.Config.Database.DataStore, storage.SQLitePrefix) {
-				log.Logger().Info("connect data store via master")
-				w.DataClient = data.NewProxyClient(w.conn)
-			} else {
-				log.Logger().Info("connect data store",
-					zap.String("database", log.RedactDBURL(w.Config.Database.DataStore)))
-				if w.DataClient, err = data.Open(w.Config.Database.DataStore, w.Config.Database.DataTablePrefix); err != nil {
-					log.Logger().Error("failed to connect data store", zap.Error(err))
-					goto sleep
-				}
+	// connect to data store
+	if w.dataPath != w.Config.Database.DataStore || w.dataPrefix != w.Config.Database.DataTablePrefix {
+		if strings.HasPrefix(w.Config.Database.DataStore, storage.SQLitePrefix) {
+			log.Logger().Info("connect data store via master")
+			w.DataClient = data.NewProxyClient(w.conn)
+		} else {
+			log.Logger().Info("connect data store",
+				zap.String("database", log.RedactDBURL(w.Config.Database.DataStore)))
+			if w.DataClient, err = data.Open(w.Config.Database.DataStore, w.Config.Database.DataTablePrefix); err != nil {
+				log.Logger().Error("failed to connect data store", zap.Error(err))
+				return
 			}
-			w.dataPath = w.Config.Database.DataStore
-			w.dataPrefix = w.Config.Database.DataTablePrefix
 		}
+		w.dataPath = w.Config.Database.DataStore
+		w.dataPrefix = w.Config.Database.DataTablePrefix
+	}
 
-		// connect to cache store
-		if w.cachePath != w.Config.Database.CacheStore || w.cachePrefix != w.Config.Database.CacheTablePrefix {
-			if strings.HasPrefix(w.Config.Database.CacheStore, storage.SQLitePrefix) {
-				log.Logger().Info("connect cache store via master")
-				w.CacheClient = cache.NewProxyClient(w.conn)
-			} else {
-				log.Logger().Info("connect cache store",
-					zap.String("database", log.RedactDBURL(w.Config.Database.CacheStore)))
-				if w.CacheClient, err = cache.Open(w.Config.Database.CacheStore, w.Config.Database.CacheTablePrefix); err != nil {
-					log.Logger().Error("failed to connect cache store", zap.Error(err))
-					goto sleep
-				}
+	// connect to cache store
+	if w.cachePath != w.Config.Database.CacheStore || w.cachePrefix != w.Config.Database.CacheTablePrefix {
+		if strings.HasPrefix(w.Config.Database.CacheStore, storage.SQLitePrefix) {
+			log.Logger().Info("connect cache store via master")
+			w.CacheClient = cache.NewProxyClient(w.conn)
+		} else {
+			log.Logger().Info("connect cache store",
+				zap.String("database", log.RedactDBURL(w.Config.Database.CacheStore)))
+			if w.CacheClient, err = cache.Open(w.Config.Database.CacheStore, w.Config.Database.CacheTablePrefix); err != nil {
+				log.Logger().Error("failed to connect cache store", zap.Error(err))
+				return
 			}
-			w.cachePath = w.Config.Database.CacheStore
-			w.cachePrefix = w.Config.Database.CacheTablePrefix
 		}
+		w.cachePath = w.Config.Database.CacheStore
+		w.cachePrefix = w.Config.Database.CacheTablePrefix
+	}
 
-		// check ranking model version
-		w.latestRankingModelVersion = meta.RankingModelVersion
-		if w.latestRankingModelVersion != w.RankingModelVersion {
-			log.Logger().Info("new ranking model found",
-				zap.String("old_version", encoding.Hex(w.RankingModelVersion)),
-				zap.String("new_version", encoding.Hex(w.latestRankingModelVersion)))
-			w.syncedChan.Signal()
-		}
+	// check ranking model version
+	w.latestRankingModelVersion = meta.RankingModelVersion
+	if w.latestRankingModelVersion != w.RankingModelVersion {
+		log.Logger().Info("new ranking model found",
+			zap.String("old_version", encoding.Hex(w.RankingModelVersion)),
+			zap.String("new_version", encoding.Hex(w.latestRankingModelVersion)))
+		w.syncedChan.Signal()
+	}
 
-		// check click model version
-		w.latestClickModelVersion = meta.ClickModelVersion
-		if w.latestClickModelVersion != w.ClickModelVersion {
-			log.Logger().Info("new click model found",
-				zap.String("old_version", encoding.Hex(w.ClickModelVersion)),
-				zap.String("new_version", encoding.Hex(w.latestClickModelVersion)))
-			w.syncedChan.Signal()
-		}
+	// check click model version
+	w.latestClickModelVersion = meta.ClickModelVersion
+	if w.latestClickModelVersion != w.ClickModelVersion {
+		log.Logger().Info("new click model found",
+			zap.String("old_version", encoding.Hex(w.ClickModelVersion)),
+			zap.String("new_version", encoding.Hex(w.latestClickModelVersion)))
+		w.syncedChan.Signal()
+	}
+
+	// check two-tower model version
+	w.latestTwoTowerModelVersion = meta.TwoTowerModelVersion
+	if _, twoTowerModelVersion := w.LoadTwoTowerModel(); w.latestTwoTowerModelVersion != twoTowerModelVersion {
+		log.Logger().Info("new two-tower model found",
+			zap.String("old_version", encoding.Hex(twoTowerModelVersion)),
+			zap.String("new_version", encoding.Hex(w.latestTwoTowerModelVersion)))
+		w.syncedChan.Signal()
+	}
+
+	// check candidate models in shadow mode
+	w.latestShadowRankingModelVersion = meta.ShadowRankingModelVersion
+	w.latestShadowClickModelVersion = meta.ShadowClickModelVersion
+	if w.latestShadowRankingModelVersion != w.shadow.RankingModelVersion() ||
+		w.latestShadowClickModelVersion != w.shadow.ClickModelVersion() {
+		w.syncedChan.Signal()
+	}
 
+	if !w.oneMode {
 		w.peers = meta.Workers
+		w.readyPeers = meta.ReadyWorkers
 		w.me = meta.Me
-	sleep:
-		if w.testMode {
-			return
-		}
-		time.Sleep(w.Config.Master.MetaTimeout)
+	}
+	if w.tenant == "" {
+		w.syncTenants()
 	}
 }
 
 // Pull user index and ranking model from master.
 func (w *Worker) Pull() {
 	defer base.CheckPanic()
-	for range w.syncedChan.C {
+	for {
+		select {
+		case <-w.done:
+			return
+		case <-w.syncedChan.C:
+		}
 		pulled := false
 
+		// pull ranking model delta
+		if w.latestRankingModelVersion != w.RankingModelVersion && w.RankingModel != nil && !w.RankingModel.Invalid() {
+			log.Logger().Info("start pull ranking model delta")
+			if rankingModelReceiver, err := w.masterClient.GetRankingModelDelta(context.Background(),
+				&protocol.DeltaInfo{BaseVersion: w.RankingModelVersion, Version: w.latestRankingModelVersion},
+				grpc.MaxCallRecvMsgSize(math.MaxInt)); err != nil {
+				log.Logger().Warn("failed to pull ranking model delta", zap.Error(err))
+			} else {
+				var rankingModel ranking.MatrixFactorization
+				rankingModel, err = encoding2.UnmarshalRankingModelDelta(rankingModelReceiver, w.RankingModel)
+				if err != nil {
+					log.Logger().Warn("failed to apply ranking model delta", zap.Error(err))
+				} else {
+					w.RankingModel = rankingModel
+					w.rankingIndex = nil
+					w.RankingModelVersion = w.latestRankingModelVersion
+					log.Logger().Info("synced ranking model by delta",
+						zap.String("version", encoding.Hex(w.RankingModelVersion)))
+					MemoryInuseBytesVec.WithLabelValues("collaborative_filtering_model").Set(float64(w.RankingModel.Bytes()))
+					pulled = true
+				}
+			}
+		}
+
 		// pull ranking model
 		if w.latestRankingModelVersion != w.RankingModelVersion {
 			log.Logger().Info("start pull ranking model")
@@ -317,6 +450,29 @@ func (w *Worker) Pull() {
 			}
 		}
 
+		// pull two-tower model
+		if _, twoTowerModelVersion := w.LoadTwoTowerModel(); w.latestTwoTowerModelVersion != twoTowerModelVersion {
+			log.Logger().Info("start pull two-tower model")
+			if twoTowerModelReceiver, err := w.masterClient.GetTwoTowerModel(context.Background(),
+				&protocol.VersionInfo{Version: w.latestTwoTowerModelVersion},
+				grpc.MaxCallRecvMsgSize(math.MaxInt)); err != nil {
+				log.Logger().Error("failed to pull two-tower model", zap.Error(err))
+			} else {
+				twoTowerModel, userTransformer, itemTransformer, err := encoding2.UnmarshalTwoTowerModel(twoTowerModelReceiver)
+				if err != nil {
+					log.Logger().Error("failed to unmarshal two-tower model", zap.Error(err))
+				} else {
+					w.StoreTwoTowerModel(twoTowerModel, w.latestTwoTowerModelVersion, userTransformer, itemTransformer)
+					log.Logger().Info("synced two-tower model",
+						zap.String("version", encoding.Hex(w.latestTwoTowerModelVersion)))
+					pulled = true
+				}
+			}
+		}
+
+		// pull candidate models in shadow mode
+		w.pullShadowModels()
+
 		if w.testMode {
 			return
 		}
@@ -354,6 +510,11 @@ func (w *Worker) ScheduleAPIHandler(writer http.ResponseWriter, request *http.Re
 		}
 	case http.MethodPost:
 		w.triggerChan.Signal()
+		w.tenantsMutex.Lock()
+		for _, tw := range w.tenants {
+			tw.triggerChan.Signal()
+		}
+		w.tenantsMutex.Unlock()
 	default:
 		writeError(writer, "method not allowed", http.StatusMethodNotAllowed)
 	}
@@ -416,32 +577,47 @@ func (w *Worker) Serve() {
 	w.tracer = progress.NewTracer(w.workerName)
 
 	// connect to master
+	if err := w.connect(); err != nil {
+		log.Logger().Fatal("failed to connect master", zap.Error(err))
+	}
+
+	if w.oneMode {
+		w.peers = []string{w.workerName}
+		w.me = w.workerName
+		w.syncTenants()
+	} else {
+		go w.Sync()
+		go w.Pull()
+		go w.ServeHTTP()
+	}
+	w.run()
+}
+
+// connect to the master with extra dial options.
+func (w *Worker) connect(extraOpts ...grpc.DialOption) error {
 	var opts []grpc.DialOption
 	if w.tlsConfig != nil {
 		c, err := util.NewClientCreds(w.tlsConfig)
 		if err != nil {
-			log.Logger().Fatal("failed to create credentials", zap.Error(err))
+			return errors.Trace(err)
 		}
 		opts = append(opts, grpc.WithTransportCredentials(c))
 	} else {
 		opts = append(opts, grpc.WithTransportCredentials(insecure.NewCredentials()))
 	}
+	opts = append(opts, extraOpts...)
 	var err error
 	w.conn, err = grpc.Dial(fmt.Sprintf("%v:%v", w.masterHost, w.masterPort), opts...)
 	if err != nil {
-		log.Logger().Fatal("failed to connect master", zap.Error(err))
+		return errors.Trace(err)
 	}
 	w.masterClient = protocol.NewMasterClient(w.conn)
+	w.blobClient = blob.NewMasterStoreClient(w.conn)
+	return nil
+}
 
-	if w.oneMode {
-		w.peers = []string{w.workerName}
-		w.me = w.workerName
-	} else {
-		go w.Sync()
-		go w.Pull()
-		go w.ServeHTTP()
-	}
-
+// run generates recommendations periodically or once triggered, and refreshes recommendations in real time.
+func (w *Worker) run() {
 	loop := func() {
 		w.scheduleState.IsRunning = true
 		w.scheduleState.StartTime = time.Now()
@@ -451,25 +627,52 @@ func (w *Worker) Serve() {
 		}()
 
 		// pull users
-		workingUsers, err := w.pullUsers(w.peers, w.me)
+		workingUsers, numHandoffUsers, err := w.pullUsers(w.peers, w.readyPeers, w.me)
 		if err != nil {
 			log.Logger().Error("failed to split users", zap.Error(err),
 				zap.String("me", w.me),
 				zap.Strings("workers", w.peers))
 			return
 		}
+		w.pushShard(len(workingUsers)-numHandoffUsers, numHandoffUsers)
 
 		// recommendation
 		w.Recommend(workingUsers)
+		if !w.ready {
+			log.Logger().Info("complete handoff", zap.Int("n_users", len(workingUsers)-numHandoffUsers))
+			w.ready = true
+		}
+		w.pushShard(len(workingUsers)-numHandoffUsers, numHandoffUsers)
 	}
 
+	// refresh recommendation in real time apart from the periodic loop
+	go func() {
+		for {
+			select {
+			case <-w.done:
+				return
+			case <-w.refreshTicker.C:
+				if w.Config.Recommend.Offline.EnableRealtimeRefresh {
+					w.refresh()
+				}
+			}
+		}
+	}()
+
 	if w.managedMode {
-		for range w.triggerChan.C {
-			loop()
+		for {
+			select {
+			case <-w.done:
+				return
+			case <-w.triggerChan.C:
+				loop()
+			}
 		}
 	} else {
 		for {
 			select {
+			case <-w.done:
+				return
 			case tick := <-w.ticker.C:
 				if time.Since(tick) < w.Config.Recommend.Offline.CheckRecommendPeriod {
 					loop()
@@ -481,6 +684,54 @@ func (w *Worker) Serve() {
 	}
 }
 
+// syncTenants starts workers of new tenants and stops workers of deleted tenants. The worker of a tenant syncs meta,
+// pulls models and connects stores of the tenant through a connection to the master with the tenant in metadata, and
+// it generates recommendations for users of the tenant with its own models, fold-in cache and rankers.
+func (w *Worker) syncTenants() {
+	w.tenantsMutex.Lock()
+	defer w.tenantsMutex.Unlock()
+	for _, tenant := range w.Config.Tenants {
+		if _, exist := w.tenants[tenant.Name]; exist {
+			continue
+		}
+		tw := NewWorker(w.masterHost, w.masterPort, w.httpHost, w.httpPort, w.jobs, w.cacheFile, w.managedMode, w.tlsConfig)
+		tw.tenant = tenant.Name
+		tw.oneMode = w.oneMode
+		tw.workerName = w.workerName
+		tw.peers = w.peers
+		tw.me = w.me
+		tw.tracer = progress.NewTracer(w.workerName)
+		tw.done = make(chan struct{})
+		if err := tw.connect(protocol.WithTenant(tenant.Name)...); err != nil {
+			log.Logger().Error("failed to connect master", zap.String("tenant", tenant.Name), zap.Error(err))
+			continue
+		}
+		w.tenants[tenant.Name] = tw
+		go tw.Sync()
+		go tw.Pull()
+		go tw.run()
+		log.Logger().Info("start tenant", zap.String("tenant", tenant.Name))
+	}
+	// stop deleted tenants
+	for name, tw := range w.tenants {
+		if _, exist := w.Config.GetTenant(name); !exist {
+			tw.stop()
+			delete(w.tenants, name)
+			log.Logger().Info("stop tenant", zap.String("tenant", name))
+		}
+	}
+}
+
+// stop the worker of a tenant.
+func (w *Worker) stop() {
+	close(w.done)
+	w.ticker.Stop()
+	w.refreshTicker.Stop()
+	if err := w.conn.Close(); err != nil {
+		log.Logger().Error("failed to close connection", zap.String("tenant", w.tenant), zap.Error(err))
+	}
+}
+
 // Recommend items to users. The workflow of recommendation is:
 // 1. Skip inactive users.
 // 2. Load historical items.
@@ -491,6 +742,8 @@ func (w *Worker) Serve() {
 // 7. Rank items in results by click-through-rate.
 // 8. Refresh cache.
 func (w *Worker) Recommend(users []data.User) {
+	w.recommendMutex.Lock()
+	defer w.recommendMutex.Unlock()
 	ctx := context.Background()
 	startRecommendTime := time.Now()
 	log.Logger().Info("ranking recommendation",
@@ -498,14 +751,16 @@ func (w *Worker) Recommend(users []data.User) {
 		zap.Int("n_jobs", w.jobs),
 		zap.Int("cache_size", w.Config.Recommend.CacheSize))
 
-	// pull items from database
-	itemCache, itemCategories, err := w.pullItems(ctx)
+	// scan items
+	scan, err := w.scanItems(ctx)
 	if err != nil {
 		log.Logger().Error("failed to pull items", zap.Error(err))
 		return
 	}
-	MemoryInuseBytesVec.WithLabelValues("item_cache").Set(float64(itemCache.Bytes()))
-	defer MemoryInuseBytesVec.WithLabelValues("item_cache").Set(0)
+	w.lastScan.Store(scan)
+	if err = w.forgetErasedUsers(ctx); err != nil {
+		log.Logger().Error("failed to forget erased users", zap.Error(err))
+	}
 
 	// progress tracker
 	completed := make(chan struct{}, 1000)
@@ -542,6 +797,72 @@ func (w *Worker) Recommend(users []data.User) {
 		}
 	}()
 
+	// recommendation
+	startTime := time.Now()
+	var stats recommendStats
+
+	userFeedbackCache := NewFeedbackCache(w, w.Config.Recommend.DataSource.PositiveFeedbackTypes...)
+	defer MemoryInuseBytesVec.WithLabelValues("user_feedback_cache").Set(0)
+	err = parallel.Parallel(len(users), w.jobs, func(workerId, jobId int) error {
+		defer func() {
+			completed <- struct{}{}
+		}()
+		var ranker click.FactorizationMachine
+		if workerId < len(w.rankers) {
+			ranker = w.rankers[workerId]
+		}
+		return w.recommendUser(ctx, scan, users[jobId], ranker, userFeedbackCache, &stats)
+	})
+	close(completed)
+	if err != nil {
+		log.Logger().Error("failed to continue offline recommendation", zap.Error(err))
+		return
+	}
+	log.Logger().Info("complete ranking recommendation",
+		zap.String("used_time", time.Since(startTime).String()))
+	UpdateUserRecommendTotal.Set(stats.updateUserCount.Load())
+	OfflineRecommendTotalSeconds.Set(time.Since(startRecommendTime).Seconds())
+	w.shadow.Flush(w.RankingModelVersion, w.ClickModelVersion)
+	OfflineRecommendStepSecondsVec.WithLabelValues("collaborative_recommend").Set(stats.collaborativeRecommendSeconds.Load())
+	OfflineRecommendStepSecondsVec.WithLabelValues("item_based_recommend").Set(stats.itemBasedRecommendSeconds.Load())
+	OfflineRecommendStepSecondsVec.WithLabelValues("user_based_recommend").Set(stats.userBasedRecommendSeconds.Load())
+	OfflineRecommendStepSecondsVec.WithLabelValues("two_tower_recommend").Set(stats.twoTowerRecommendSeconds.Load())
+	OfflineRecommendStepSecondsVec.WithLabelValues("latest_recommend").Set(stats.latestRecommendSeconds.Load())
+	OfflineRecommendStepSecondsVec.WithLabelValues("popular_recommend").Set(stats.popularRecommendSeconds.Load())
+}
+
+// recommendScan is the state shared by recommendation of users, which is prepared by scanning items in the periodic
+// loop and reused by the real-time refresh until the next scan.
+type recommendScan struct {
+	itemCache           *ItemCache
+	itemCategories      []string
+	rankingIndex        *search.HNSW
+	foldedItems         map[string][]float32
+	twoTowerRecommender *TwoTowerRecommender
+}
+
+// recommendStats is the statistics of offline recommendation.
+type recommendStats struct {
+	updateUserCount               atomic.Float64
+	collaborativeRecommendSeconds atomic.Float64
+	userBasedRecommendSeconds     atomic.Float64
+	itemBasedRecommendSeconds     atomic.Float64
+	imageBasedRecommendSeconds    atomic.Float64
+	twoTowerRecommendSeconds      atomic.Float64
+	latestRecommendSeconds        atomic.Float64
+	popularRecommendSeconds       atomic.Float64
+}
+
+// scanItems pulls items, builds the ranking index if absent, folds in new items and encodes items by the two-tower
+// model.
+func (w *Worker) scanItems(ctx context.Context) (*recommendScan, error) {
+	// pull items from database
+	itemCache, itemCategories, err := w.pullItems(ctx)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	MemoryInuseBytesVec.WithLabelValues("item_cache").Set(float64(itemCache.Bytes()))
+
 	// build ranking index
 	if w.RankingModel != nil && !w.RankingModel.Invalid() && w.rankingIndex == nil {
 		if w.Config.Recommend.Collaborative.EnableIndex {
@@ -572,323 +893,378 @@ func (w *Worker) Recommend(users []data.User) {
 		}
 	}
 
-	// recommendation
-	startTime := time.Now()
-	var (
-		updateUserCount               atomic.Float64
-		collaborativeRecommendSeconds atomic.Float64
-		userBasedRecommendSeconds     atomic.Float64
-		itemBasedRecommendSeconds    atomic.Float64
-		imageBasedRecommendSeconds    atomic.Float64
-		latestRecommendSeconds        atomic.Float64
-		popularRecommendSeconds       atomic.Float64
-	)
-
-	userFeedbackCache := NewFeedbackCache(w, w.Config.Recommend.DataSource.PositiveFeedbackTypes...)
-	defer MemoryInuseBytesVec.WithLabelValues("user_feedback_cache").Set(0)
-	err = parallel.Parallel(len(users), w.jobs, func(workerId, jobId int) error {
-		defer func() {
-			completed <- struct{}{}
-		}()
-		user := users[jobId]
-		userId := user.UserId
-		// skip inactive users before max recommend period
-		if !w.checkUserActiveTime(ctx, userId) || !w.checkRecommendCacheTimeout(ctx, userId, itemCategories) {
-			return nil
+	// fold in items absent from the last fit
+	var foldedItems map[string][]float32
+	if w.Config.Recommend.Offline.EnableColRecommend && w.RankingModel != nil && !w.RankingModel.Invalid() {
+		if foldedItems, err = w.foldInItems(ctx, itemCache); err != nil {
+			log.Logger().Warn("failed to fold in items", zap.Error(err))
 		}
-		updateUserCount.Add(1)
+		numFoldedUsers, numFoldedItems := w.foldInCache.Len()
+		log.Logger().Info("complete folding in items",
+			zap.Int("n_folded_users", numFoldedUsers),
+			zap.Int("n_folded_items", numFoldedItems))
+	}
+
+	// encode items by two-tower model
+	var twoTowerRecommender *TwoTowerRecommender
+	if twoTowerModel, _ := w.LoadTwoTowerModel(); !twoTowerModel.Invalid() {
+		startTime := time.Now()
+		log.Logger().Info("start encoding items by two-tower model")
+		userTransformer, itemTransformer := w.LoadTwoTowerTransformers()
+		twoTowerRecommender = NewTwoTowerRecommender(ctx, w.Config, twoTowerModel, itemCache, w.jobs, userTransformer, itemTransformer)
+		log.Logger().Info("complete encoding items by two-tower model",
+			zap.Duration("build_time", time.Since(startTime)))
+	}
+
+	return &recommendScan{
+		itemCache:           itemCache,
+		itemCategories:      itemCategories,
+		rankingIndex:        w.rankingIndex,
+		foldedItems:         foldedItems,
+		twoTowerRecommender: twoTowerRecommender,
+	}, nil
+}
 
-		// load historical items
-		historyItems, feedbacks, err := w.loadUserHistoricalItems(w.DataClient, userId)
-		excludeSet := mapset.NewSet(historyItems...)
+// recommendUser generates offline recommendation for a user from items of a scan. The ranker must not be used by
+// others concurrently.
+func (w *Worker) recommendUser(ctx context.Context, scan *recommendScan, user data.User, ranker click.FactorizationMachine,
+	userFeedbackCache *FeedbackCache, stats *recommendStats) error {
+	userId := user.UserId
+	// apply overrides of experiments
+	userConfig := w.Config.ForUser(userId)
+	// skip inactive users before max recommend period
+	if !w.checkUserActiveTime(ctx, userId) || !w.checkRecommendCacheTimeout(ctx, userId, scan.itemCategories) {
+		return nil
+	}
+	stats.updateUserCount.Add(1)
+
+	// load historical items
+	historyItems, feedbacks, err := w.loadUserHistoricalItems(w.DataClient, userId)
+	excludeSet := mapset.NewSet(historyItems...)
+	if err != nil {
+		log.Logger().Error("failed to pull user feedback",
+			zap.String("user_id", userId), zap.Error(err))
+		return errors.Trace(err)
+	}
+
+	// load positive items
+	var (
+		positiveItems       []string
+		positiveConfidences []float64
+	)
+	if userConfig.Recommend.Offline.EnableItemBasedRecommend {
+		positiveItems, positiveConfidences, err = userFeedbackCache.GetUserFeedback(ctx, userId)
 		if err != nil {
 			log.Logger().Error("failed to pull user feedback",
 				zap.String("user_id", userId), zap.Error(err))
 			return errors.Trace(err)
 		}
+		MemoryInuseBytesVec.WithLabelValues("user_feedback_cache").Set(float64(userFeedbackCache.Bytes()))
+	}
+
+	// create candidates container
+	candidates := make(map[string][][]string)
+	candidates[""] = make([][]string, 0)
+	for _, category := range scan.itemCategories {
+		candidates[category] = make([][]string, 0)
+	}
 
-		// load positive items
-		var positiveItems []string
-		if w.Config.Recommend.Offline.EnableItemBasedRecommend {
-			positiveItems, err = userFeedbackCache.GetUserFeedback(ctx, userId)
+	// Recommender #1: collaborative filtering. Users absent from the last fit are folded in.
+	collaborativeUsed := false
+	var userFactor []float32
+	if w.RankingModel != nil && !w.RankingModel.Invalid() {
+		userFactor = w.foldInCache.UserFactor(w.RankingModel, userId, positiveFeedbackItems(userConfig, feedbacks))
+	}
+	if userConfig.Recommend.Offline.EnableColRecommend && w.RankingModel != nil && !w.RankingModel.Invalid() {
+		if userFactor != nil {
+			var recommend map[string][]string
+			var usedTime time.Duration
+			if userConfig.Recommend.Collaborative.EnableIndex && scan.rankingIndex != nil {
+				recommend, usedTime, err = w.collaborativeRecommendHNSW(scan.rankingIndex, userId, userFactor, scan.foldedItems, scan.itemCategories, excludeSet, scan.itemCache)
+			} else {
+				recommend, usedTime, err = w.collaborativeRecommendBruteForce(userId, userFactor, scan.foldedItems, scan.itemCategories, excludeSet, scan.itemCache)
+			}
 			if err != nil {
-				log.Logger().Error("failed to pull user feedback",
+				log.Logger().Error("failed to recommend by collaborative filtering",
 					zap.String("user_id", userId), zap.Error(err))
 				return errors.Trace(err)
 			}
-			MemoryInuseBytesVec.WithLabelValues("user_feedback_cache").Set(float64(userFeedbackCache.Bytes()))
-		}
-
-		// create candidates container
-		candidates := make(map[string][][]string)
-		candidates[""] = make([][]string, 0)
-		for _, category := range itemCategories {
-			candidates[category] = make([][]string, 0)
-		}
-
-		// Recommender #1: collaborative filtering.
-		collaborativeUsed := false
-		if w.Config.Recommend.Offline.EnableColRecommend && w.RankingModel != nil && !w.RankingModel.Invalid() {
-			if userIndex := w.RankingModel.GetUserIndex().ToNumber(userId); w.RankingModel.IsUserPredictable(userIndex) {
-				var recommend map[string][]string
-				var usedTime time.Duration
-				if w.Config.Recommend.Collaborative.EnableIndex && w.rankingIndex != nil {
-					recommend, usedTime, err = w.collaborativeRecommendHNSW(w.rankingIndex, userId, itemCategories, excludeSet, itemCache)
-				} else {
-					recommend, usedTime, err = w.collaborativeRecommendBruteForce(userId, itemCategories, excludeSet, itemCache)
-				}
-				if err != nil {
-					log.Logger().Error("failed to recommend by collaborative filtering",
-						zap.String("user_id", userId), zap.Error(err))
-					return errors.Trace(err)
-				}
-				for category, items := range recommend {
-					candidates[category] = append(candidates[category], items)
-				}
-				collaborativeUsed = true
-				collaborativeRecommendSeconds.Add(usedTime.Seconds())
-			} else if !w.RankingModel.IsUserPredictable(userIndex) {
-				log.Logger().Debug("user is unpredictable", zap.String("user_id", userId))
-			}
-		} else if w.RankingModel == nil || w.RankingModel.Invalid() {
-			log.Logger().Debug("no collaborative filtering model")
-		}
-
-		// Recommender #2: item-based.
-		itemNeighborDigests := mapset.NewSet[string]()
-		if w.Config.Recommend.Offline.EnableItemBasedRecommend {
-			localStartTime := time.Now()
-			for _, category := range append([]string{""}, itemCategories...) {
-				// collect candidates
-				scores := make(map[string]float64)
-				for _, itemId := range positiveItems {
-					// load similar items
-					similarItems, err := w.CacheClient.SearchScores(ctx, cache.ItemNeighbors, itemId, []string{category}, 0, w.Config.Recommend.CacheSize)
-					if err != nil {
-						log.Logger().Error("failed to load similar items", zap.Error(err))
-						return errors.Trace(err)
-					}
-					// add unseen items
-					for _, item := range similarItems {
-						if !excludeSet.Contains(item.Id) && itemCache.IsAvailable(item.Id) {
-							scores[item.Id] += item.Score
-						}
-					}
-					// load item neighbors digest
-					digest, err := w.CacheClient.Get(ctx, cache.Key(cache.ItemNeighborsDigest, itemId)).String()
-					if err != nil {
-						if !errors.Is(err, errors.NotFound) {
-							log.Logger().Error("failed to load item neighbors digest", zap.Error(err))
-							return errors.Trace(err)
-						}
-					}
-					itemNeighborDigests.Add(digest)
-				}
-				// collect top k
-				filter := heap.NewTopKFilter[string, float64](w.Config.Recommend.CacheSize)
-				for id, score := range scores {
-					filter.Push(id, score)
-				}
-				ids, _ := filter.PopAll()
-				candidates[category] = append(candidates[category], ids)
+			for category, items := range recommend {
+				candidates[category] = append(candidates[category], items)
 			}
-			itemBasedRecommendSeconds.Add(time.Since(localStartTime).Seconds())
+			collaborativeUsed = true
+			stats.collaborativeRecommendSeconds.Add(usedTime.Seconds())
+		} else {
+			log.Logger().Debug("user is unpredictable", zap.String("user_id", userId))
 		}
+	} else if w.RankingModel == nil || w.RankingModel.Invalid() {
+		log.Logger().Debug("no collaborative filtering model")
+	}
 
-		// Recommender #3: insert user-based items
-		userNeighborDigests := mapset.NewSet[string]()
-		if w.Config.Recommend.Offline.EnableUserBasedRecommend {
+	// Recommender #2: item-based.
+	itemNeighborDigests := mapset.NewSet[string]()
+	if userConfig.Recommend.Offline.EnableItemBasedRecommend {
+		localStartTime := time.Now()
+		for _, category := range append([]string{""}, scan.itemCategories...) {
+			// collect candidates
 			scores := make(map[string]float64)
-			// load similar users
-			similarUsers, err := w.CacheClient.SearchScores(ctx, cache.UserNeighbors, userId, []string{""}, 0, w.Config.Recommend.CacheSize)
-			if err != nil {
-				log.Logger().Error("failed to load similar users", zap.Error(err))
-				return errors.Trace(err)
-			}
-			localStartTime := time.Now()
-			for _, user := range similarUsers {
-				// load historical feedback
-				similarUserPositiveItems, err := userFeedbackCache.GetUserFeedback(ctx, user.Id)
+			for i, itemId := range positiveItems {
+				// load similar items
+				similarItems, err := w.CacheClient.SearchScores(ctx, cache.ItemNeighbors, itemId, []string{category}, 0, userConfig.Recommend.CacheSize)
 				if err != nil {
-					log.Logger().Error("failed to pull user feedback",
-						zap.String("user_id", userId), zap.Error(err))
+					log.Logger().Error("failed to load similar items", zap.Error(err))
 					return errors.Trace(err)
 				}
-				MemoryInuseBytesVec.WithLabelValues("user_feedback_cache").Set(float64(userFeedbackCache.Bytes()))
 				// add unseen items
-				for _, itemId := range similarUserPositiveItems {
-					if !excludeSet.Contains(itemId) && itemCache.IsAvailable(itemId) {
-						scores[itemId] += user.Score
+				for _, item := range similarItems {
+					if !excludeSet.Contains(item.Id) && scan.itemCache.IsAvailable(item.Id) {
+						scores[item.Id] += item.Score * positiveConfidences[i]
 					}
 				}
-				// load user neighbors digest
-				digest, err := w.CacheClient.Get(ctx, cache.Key(cache.UserNeighborsDigest, user.Id)).String()
+				// load item neighbors digest
+				digest, err := w.CacheClient.Get(ctx, cache.Key(cache.ItemNeighborsDigest, itemId)).String()
 				if err != nil {
 					if !errors.Is(err, errors.NotFound) {
-						log.Logger().Error("failed to load user neighbors digest", zap.Error(err))
+						log.Logger().Error("failed to load item neighbors digest", zap.Error(err))
 						return errors.Trace(err)
 					}
 				}
-				userNeighborDigests.Add(digest)
+				itemNeighborDigests.Add(digest)
 			}
 			// collect top k
-			filters := make(map[string]*heap.TopKFilter[string, float64])
-			filters[""] = heap.NewTopKFilter[string, float64](w.Config.Recommend.CacheSize)
-			for _, category := range itemCategories {
-				filters[category] = heap.NewTopKFilter[string, float64](w.Config.Recommend.CacheSize)
-			}
+			filter := heap.NewTopKFilter[string, float64](userConfig.Recommend.CacheSize)
 			for id, score := range scores {
-				filters[""].Push(id, score)
-				for _, category := range itemCache.GetCategory(id) {
-					filters[category].Push(id, score)
-				}
+				filter.Push(id, score)
 			}
-			for category, filter := range filters {
-				ids, _ := filter.PopAll()
-				candidates[category] = append(candidates[category], ids)
-			}
-			userBasedRecommendSeconds.Add(time.Since(localStartTime).Seconds())
+			ids, _ := filter.PopAll()
+			candidates[category] = append(candidates[category], ids)
 		}
+		stats.itemBasedRecommendSeconds.Add(time.Since(localStartTime).Seconds())
+	}
 
-		// Recommender #4: image-based recommendations
-		if w.Config.Recommend.ImageEmbeddings.EnableImageRecommend {
-			imageRecommender := NewImageBasedRecommender(w)
-			recommend, usedTime, err := imageRecommender.Recommend(ctx, userId, itemCategories, excludeSet, itemCache)
+	// Recommender #3: insert user-based items
+	userNeighborDigests := mapset.NewSet[string]()
+	if userConfig.Recommend.Offline.EnableUserBasedRecommend {
+		scores := make(map[string]float64)
+		// load similar users
+		similarUsers, err := w.CacheClient.SearchScores(ctx, cache.UserNeighbors, userId, []string{""}, 0, userConfig.Recommend.CacheSize)
+		if err != nil {
+			log.Logger().Error("failed to load similar users", zap.Error(err))
+			return errors.Trace(err)
+		}
+		localStartTime := time.Now()
+		for _, user := range similarUsers {
+			// load historical feedback
+			similarUserPositiveItems, similarUserConfidences, err := userFeedbackCache.GetUserFeedback(ctx, user.Id)
 			if err != nil {
-				log.Logger().Error("failed to recommend by image similarity",
+				log.Logger().Error("failed to pull user feedback",
 					zap.String("user_id", userId), zap.Error(err))
 				return errors.Trace(err)
 			}
-			for category, items := range recommend {
-				candidates[category] = append(candidates[category], items)
+			MemoryInuseBytesVec.WithLabelValues("user_feedback_cache").Set(float64(userFeedbackCache.Bytes()))
+			// add unseen items
+			for i, itemId := range similarUserPositiveItems {
+				if !excludeSet.Contains(itemId) && scan.itemCache.IsAvailable(itemId) {
+					scores[itemId] += user.Score * similarUserConfidences[i]
+				}
 			}
-			imageBasedRecommendSeconds.Add(usedTime.Seconds())
-		}
-
-		// Recommender #5: latest items.
-		if w.Config.Recommend.Offline.EnableLatestRecommend {
-			localStartTime := time.Now()
-			for _, category := range append([]string{""}, itemCategories...) {
-				latestItems, err := w.CacheClient.SearchScores(ctx, cache.NonPersonalized, cache.Latest, []string{category}, 0, w.Config.Recommend.CacheSize)
-				if err != nil {
-					log.Logger().Error("failed to load latest items", zap.Error(err))
+			// load user neighbors digest
+			digest, err := w.CacheClient.Get(ctx, cache.Key(cache.UserNeighborsDigest, user.Id)).String()
+			if err != nil {
+				if !errors.Is(err, errors.NotFound) {
+					log.Logger().Error("failed to load user neighbors digest", zap.Error(err))
 					return errors.Trace(err)
 				}
-				var recommend []string
-				for _, latestItem := range latestItems {
-					if !excludeSet.Contains(latestItem.Id) && itemCache.IsAvailable(latestItem.Id) {
-						recommend = append(recommend, latestItem.Id)
-					}
-				}
-				candidates[category] = append(candidates[category], recommend)
 			}
-			latestRecommendSeconds.Add(time.Since(localStartTime).Seconds())
+			userNeighborDigests.Add(digest)
+		}
+		// collect top k
+		filters := make(map[string]*heap.TopKFilter[string, float64])
+		filters[""] = heap.NewTopKFilter[string, float64](userConfig.Recommend.CacheSize)
+		for _, category := range scan.itemCategories {
+			filters[category] = heap.NewTopKFilter[string, float64](userConfig.Recommend.CacheSize)
+		}
+		for id, score := range scores {
+			filters[""].Push(id, score)
+			for _, category := range scan.itemCache.GetCategory(id) {
+				filters[category].Push(id, score)
+			}
+		}
+		for category, filter := range filters {
+			ids, _ := filter.PopAll()
+			candidates[category] = append(candidates[category], ids)
 		}
+		stats.userBasedRecommendSeconds.Add(time.Since(localStartTime).Seconds())
+	}
 
-		// Recommender #6: popular items.
-		if w.Config.Recommend.Offline.EnablePopularRecommend {
-			localStartTime := time.Now()
-			for _, category := range append([]string{""}, itemCategories...) {
-				popularItems, err := w.CacheClient.SearchScores(ctx, cache.NonPersonalized, cache.Popular, []string{category}, 0, w.Config.Recommend.CacheSize)
-				if err != nil {
-					log.Logger().Error("failed to load popular items", zap.Error(err))
-					return errors.Trace(err)
-				}
-				var recommend []string
-				for _, popularItem := range popularItems {
-					if !excludeSet.Contains(popularItem.Id) && itemCache.IsAvailable(popularItem.Id) {
-						recommend = append(recommend, popularItem.Id)
-					}
-				}
-				candidates[category] = append(candidates[category], recommend)
+	// Recommender #4: image-based recommendations
+	if userConfig.Recommend.ImageEmbeddings.EnableImageRecommend {
+		imageRecommender := NewImageBasedRecommender(w).WithConfig(userConfig)
+		recommend, usedTime, err := imageRecommender.Recommend(ctx, userId, scan.itemCategories, excludeSet, scan.itemCache)
+		if err != nil {
+			log.Logger().Error("failed to recommend by image similarity",
+				zap.String("user_id", userId), zap.Error(err))
+			return errors.Trace(err)
+		}
+		for category, items := range recommend {
+			candidates[category] = append(candidates[category], items)
+		}
+		stats.imageBasedRecommendSeconds.Add(usedTime.Seconds())
+	}
+
+	// Recommender #5: two-tower retrieval
+	if userConfig.Recommend.Offline.EnableTwoTowerRecommend && scan.twoTowerRecommender != nil {
+		recommend, usedTime := scan.twoTowerRecommender.Recommend(&user, feedbacks, scan.itemCategories, excludeSet, scan.itemCache)
+		for category, items := range recommend {
+			candidates[category] = append(candidates[category], items)
+		}
+		stats.twoTowerRecommendSeconds.Add(usedTime.Seconds())
+	}
+
+	// Recommender #6: latest items.
+	if userConfig.Recommend.Offline.EnableLatestRecommend {
+		localStartTime := time.Now()
+		for _, category := range append([]string{""}, scan.itemCategories...) {
+			latestItems, err := w.CacheClient.SearchScores(ctx, cache.NonPersonalized, cache.Latest, []string{category}, 0, userConfig.Recommend.CacheSize)
+			if err != nil {
+				log.Logger().Error("failed to load latest items", zap.Error(err))
+				return errors.Trace(err)
 			}
-			popularRecommendSeconds.Add(time.Since(localStartTime).Seconds())
-		}
-
-		// rank items from different recommenders
-		// 1. If click-through rate prediction model is available, use it to rank items.
-		// 2. If collaborative filtering model is available, use it to rank items.
-		// 3. Otherwise, merge all recommenders' results randomly.
-		ctrUsed := false
-		results := make(map[string][]cache.Score)
-		for category, catCandidates := range candidates {
-			if w.Config.Recommend.Offline.EnableClickThroughPrediction && w.rankers[workerId] != nil && !w.rankers[workerId].Invalid() {
-				results[category], err = w.rankByClickTroughRate(&user, catCandidates, itemCache, w.rankers[workerId])
-				if err != nil {
-					log.Logger().Error("failed to rank items", zap.Error(err))
-					return errors.Trace(err)
-				}
-				ctrUsed = true
-			} else if w.RankingModel != nil && !w.RankingModel.Invalid() &&
-				w.RankingModel.IsUserPredictable(w.RankingModel.GetUserIndex().ToNumber(userId)) {
-				results[category], err = w.rankByCollaborativeFiltering(userId, catCandidates)
-				if err != nil {
-					log.Logger().Error("failed to rank items", zap.Error(err))
-					return errors.Trace(err)
+			var recommend []string
+			for _, latestItem := range latestItems {
+				if !excludeSet.Contains(latestItem.Id) && scan.itemCache.IsAvailable(latestItem.Id) {
+					recommend = append(recommend, latestItem.Id)
 				}
-			} else {
-				results[category] = w.mergeAndShuffle(catCandidates)
 			}
+			candidates[category] = append(candidates[category], recommend)
 		}
+		stats.latestRecommendSeconds.Add(time.Since(localStartTime).Seconds())
+	}
 
-		// replacement
-		if w.Config.Recommend.Replacement.EnableReplacement {
-			if results, err = w.replacement(results, &user, feedbacks, itemCache); err != nil {
-				log.Logger().Error("failed to replace items", zap.Error(err))
+	// Recommender #7: popular items.
+	if userConfig.Recommend.Offline.EnablePopularRecommend {
+		localStartTime := time.Now()
+		for _, category := range append([]string{""}, scan.itemCategories...) {
+			popularItems, err := w.CacheClient.SearchScores(ctx, cache.NonPersonalized, cache.Popular, []string{category}, 0, userConfig.Recommend.CacheSize)
+			if err != nil {
+				log.Logger().Error("failed to load popular items", zap.Error(err))
 				return errors.Trace(err)
 			}
+			var recommend []string
+			for _, popularItem := range popularItems {
+				if !excludeSet.Contains(popularItem.Id) && scan.itemCache.IsAvailable(popularItem.Id) {
+					recommend = append(recommend, popularItem.Id)
+				}
+			}
+			candidates[category] = append(candidates[category], recommend)
 		}
+		stats.popularRecommendSeconds.Add(time.Since(localStartTime).Seconds())
+	}
 
-		// explore latest and popular
-		recommendTime := time.Now()
-		aggregator := cache.NewDocumentAggregator(recommendTime)
-		for category, result := range results {
-			scores, err := w.exploreRecommend(result, excludeSet, category)
+	// Recommender #8: onboarding.
+	onboardingRecommend, err := w.onboardingRecommend(ctx, userConfig, userId, feedbacks, scan.itemCategories, excludeSet, scan.itemCache)
+	if err != nil {
+		log.Logger().Error("failed to recommend by onboarding",
+			zap.String("user_id", userId), zap.Error(err))
+		return errors.Trace(err)
+	}
+	for category, items := range onboardingRecommend {
+		candidates[category] = append(candidates[category], items)
+	}
+
+	// rank items from different recommenders
+	// 1. If click-through rate prediction model is available, use it to rank items.
+	// 2. If collaborative filtering model is available, use it to rank items.
+	// 3. Otherwise, merge all recommenders' results randomly.
+	ctrUsed := false
+	results := make(map[string][]cache.Score)
+	for category, catCandidates := range candidates {
+		if userConfig.Recommend.Offline.EnableClickThroughPrediction && ranker != nil && !ranker.Invalid() {
+			results[category], err = w.rankByClickTroughRate(&user, catCandidates, scan.itemCache, ranker)
 			if err != nil {
-				log.Logger().Error("failed to explore latest and popular items", zap.Error(err))
+				log.Logger().Error("failed to rank items", zap.Error(err))
 				return errors.Trace(err)
 			}
-			aggregator.Add(category, lo.Map(scores, func(document cache.Score, _ int) string {
-				return document.Id
-			}), lo.Map(scores, func(document cache.Score, _ int) float64 {
-				return document.Score
-			}))
-		}
-		if err = w.CacheClient.AddScores(ctx, cache.OfflineRecommend, userId, aggregator.ToSlice()); err != nil {
-			log.Logger().Error("failed to cache recommendation", zap.Error(err))
+			ctrUsed = true
+		} else if w.RankingModel != nil && !w.RankingModel.Invalid() && userFactor != nil {
+			results[category], err = w.rankByCollaborativeFiltering(userId, userFactor, scan.foldedItems, catCandidates)
+			if err != nil {
+				log.Logger().Error("failed to rank items", zap.Error(err))
+				return errors.Trace(err)
+			}
+		} else {
+			results[category] = w.mergeAndShuffle(catCandidates)
+		}
+	}
+
+	// evaluate candidate models in shadow mode
+	if userFactor != nil {
+		w.shadow.EvaluateRanking(userId, lo.Map(results[""], func(score cache.Score, _ int) string {
+			return score.Id
+		}), func(itemId string) float32 {
+			return w.collaborativePredict(userId, userFactor, itemId, scan.foldedItems)
+		})
+	}
+	if ctrUsed {
+		w.shadow.EvaluateClick(&user, results[""], scan.itemCache)
+	}
+
+	// replacement
+	if userConfig.Recommend.Replacement.EnableReplacement {
+		if results, err = w.replacement(userConfig, results, &user, feedbacks, scan.itemCache); err != nil {
+			log.Logger().Error("failed to replace items", zap.Error(err))
 			return errors.Trace(err)
 		}
-		if err = w.CacheClient.Set(ctx,
-			cache.Time(cache.Key(cache.LastUpdateUserRecommendTime, userId), recommendTime),
-			cache.String(cache.Key(cache.OfflineRecommendDigest, userId), w.Config.OfflineRecommendDigest(
-				config.WithCollaborative(collaborativeUsed),
-				config.WithRanking(ctrUsed),
-				config.WithItemNeighborDigest(strings.Join(itemNeighborDigests.ToSlice(), "-")),
-				config.WithUserNeighborDigest(strings.Join(userNeighborDigests.ToSlice(), "-")),
-			))); err != nil {
-			log.Logger().Error("failed to cache recommendation time", zap.Error(err))
+	}
+
+	// explore latest and popular
+	recommendTime := time.Now()
+	aggregator := cache.NewDocumentAggregator(recommendTime)
+	propensities := make(map[string]map[string]float64, len(results))
+	for category, result := range results {
+		scores, categoryPropensities, err := w.exploreRecommend(userConfig, result, excludeSet, category)
+		if err != nil {
+			log.Logger().Error("failed to explore latest and popular items", zap.Error(err))
+			return errors.Trace(err)
 		}
-		return nil
-	})
-	close(completed)
+		propensities[category] = categoryPropensities
+		aggregator.Add(category, lo.Map(scores, func(document cache.Score, _ int) string {
+			return document.Id
+		}), lo.Map(scores, func(document cache.Score, _ int) float64 {
+			return document.Score
+		}))
+	}
+	scores := aggregator.ToSlice()
+	if err = w.CacheClient.AddScores(ctx, cache.OfflineRecommend, userId, scores); err != nil {
+		log.Logger().Error("failed to cache recommendation", zap.Error(err))
+		return errors.Trace(err)
+	}
+	if err = logics.PublishEvents(ctx, userConfig, w.DataClient, data.EventRecommendRefreshed,
+		[]logics.RecommendRefresh{logics.NewRecommendRefresh(cache.OfflineRecommend, userId, scores)},
+		func(refresh logics.RecommendRefresh) string { return refresh.UserId }); err != nil {
+		// change events are best-effort for cached recommendation, which has been refreshed already
+		log.Logger().Warn("failed to publish refreshed recommendation", zap.String("user_id", userId), zap.Error(err))
+	}
+	propensitiesJSON, err := json.Marshal(propensities)
 	if err != nil {
-		log.Logger().Error("failed to continue offline recommendation", zap.Error(err))
-		return
-	}
-	log.Logger().Info("complete ranking recommendation",
-		zap.String("used_time", time.Since(startTime).String()))
-	UpdateUserRecommendTotal.Set(updateUserCount.Load())
-	OfflineRecommendTotalSeconds.Set(time.Since(startRecommendTime).Seconds())
-	OfflineRecommendStepSecondsVec.WithLabelValues("collaborative_recommend").Set(collaborativeRecommendSeconds.Load())
-	OfflineRecommendStepSecondsVec.WithLabelValues("item_based_recommend").Set(itemBasedRecommendSeconds.Load())
-	OfflineRecommendStepSecondsVec.WithLabelValues("user_based_recommend").Set(userBasedRecommendSeconds.Load())
-	OfflineRecommendStepSecondsVec.WithLabelValues("latest_recommend").Set(latestRecommendSeconds.Load())
-	OfflineRecommendStepSecondsVec.WithLabelValues("popular_recommend").Set(popularRecommendSeconds.Load())
+		return errors.Trace(err)
+	}
+	if err = w.CacheClient.Set(ctx,
+		cache.Time(cache.Key(cache.LastUpdateUserRecommendTime, userId), recommendTime),
+		cache.String(cache.Key(cache.OfflineRecommendPropensity, userId), string(propensitiesJSON)),
+		cache.String(cache.Key(cache.OfflineRecommendDigest, userId), userConfig.OfflineRecommendDigest(
+			config.WithCollaborative(collaborativeUsed),
+			config.WithRanking(ctrUsed),
+			config.WithItemNeighborDigest(strings.Join(itemNeighborDigests.ToSlice(), "-")),
+			config.WithUserNeighborDigest(strings.Join(userNeighborDigests.ToSlice(), "-")),
+		))); err != nil {
+		log.Logger().Error("failed to cache recommendation time", zap.Error(err))
+	}
+	return nil
 }
 
-func (w *Worker) collaborativeRecommendBruteForce(userId string, itemCategories []string, excludeSet mapset.Set[string], itemCache *ItemCache) (map[string][]string, time.Duration, error) {
+func (w *Worker) collaborativeRecommendBruteForce(userId string, userFactor []float32, foldedItems map[string][]float32, itemCategories []string, excludeSet mapset.Set[string], itemCache *ItemCache) (map[string][]string, time.Duration, error) {
 	ctx := context.Background()
-	userIndex := w.RankingModel.GetUserIndex().ToNumber(userId)
 	itemIds := w.RankingModel.GetItemIndex().GetNames()
 	localStartTime := time.Now()
 	recItemsFilters := make(map[string]*heap.TopKFilter[string, float64])
@@ -898,7 +1274,16 @@ func (w *Worker) collaborativeRecommendBruteForce(userId string, itemCategories
 	}
 	for itemIndex, itemId := range itemIds {
 		if !excludeSet.Contains(itemId) && itemCache.IsAvailable(itemId) && w.RankingModel.IsItemPredictable(int32(itemIndex)) {
-			prediction := w.RankingModel.InternalPredict(userIndex, int32(itemIndex))
+			prediction := w.collaborativePredict(userId, userFactor, itemId, foldedItems)
+			recItemsFilters[""].Push(itemId, float64(prediction))
+			for _, category := range itemCache.GetCategory(itemId) {
+				recItemsFilters[category].Push(itemId, float64(prediction))
+			}
+		}
+	}
+	for itemId := range foldedItems {
+		if !excludeSet.Contains(itemId) {
+			prediction := w.collaborativePredict(userId, userFactor, itemId, foldedItems)
 			recItemsFilters[""].Push(itemId, float64(prediction))
 			for _, category := range itemCache.GetCategory(itemId) {
 				recItemsFilters[category].Push(itemId, float64(prediction))
@@ -925,11 +1310,85 @@ func (w *Worker) collaborativeRecommendBruteForce(userId string, itemCategories
 	return recommend, usedTime, nil
 }
 
-func (w *Worker) collaborativeRecommendHNSW(rankingIndex *search.HNSW, userId string, itemCategories []string, excludeSet mapset.Set[string], itemCache *ItemCache) (map[string][]string, time.Duration, error) {
+// onboardingRecommend recommends items whose image embeddings match the taste vector of seed items picked in the
+// onboarding quiz. Similar items of seed items are used if seed items have no image embeddings, and latest and popular
+// items are used if no seed item is picked. The number of recommended items fades out as the user inserts organic
+// feedback.
+func (w *Worker) onboardingRecommend(ctx context.Context, userConfig *config.Config, userId string, feedback []data.Feedback,
+	itemCategories []string, excludeSet mapset.Set[string], itemCache *ItemCache) (map[string][]string, error) {
+	onboarding, err := logics.LoadOnboarding(ctx, w.CacheClient, userId)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if onboarding == nil {
+		return nil, nil
+	}
+	weight := onboarding.Weight(userConfig.Recommend.Onboarding, userConfig.Recommend.DataSource.PositiveFeedbackTypes, feedback)
+	size := onboarding.Size(weight, userConfig.Recommend.CacheSize)
+	if size <= 0 {
+		return nil, nil
+	}
+	// collect candidates
+	scores := make(map[string]float64)
+	if onboarding.TasteVector != nil {
+		embeddingConfig := userConfig.Recommend.ImageEmbeddings
+		for itemId, item := range itemCache.Data {
+			if excludeSet.Contains(itemId) || !itemCache.IsAvailable(itemId) {
+				continue
+			}
+			if embedding := logics.ImageEmbedding(item.Labels, embeddingConfig.EmbeddingLabel, embeddingConfig.EmbeddingDim); len(embedding) == len(onboarding.TasteVector) {
+				scores[itemId] = float64(floats.Dot(onboarding.TasteVector, embedding))
+			}
+		}
+	} else if len(onboarding.Items) == 0 {
+		candidates, err := logics.FallbackCandidates(ctx, w.CacheClient, []string{""}, userConfig.Recommend.CacheSize, excludeSet)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		for itemId, score := range candidates {
+			if itemCache.IsAvailable(itemId) {
+				scores[itemId] = score
+			}
+		}
+	} else {
+		for _, itemId := range onboarding.Items {
+			similarItems, err := w.CacheClient.SearchScores(ctx, cache.ItemNeighbors, itemId, []string{""}, 0, userConfig.Recommend.CacheSize)
+			if err != nil {
+				return nil, errors.Trace(err)
+			}
+			for _, item := range similarItems {
+				if !excludeSet.Contains(item.Id) && itemCache.IsAvailable(item.Id) {
+					scores[item.Id] += item.Score
+				}
+			}
+		}
+	}
+	// collect top k matching preferences
+	filters := make(map[string]*heap.TopKFilter[string, float64])
+	filters[""] = heap.NewTopKFilter[string, float64](size)
+	for _, category := range itemCategories {
+		filters[category] = heap.NewTopKFilter[string, float64](size)
+	}
+	for id, score := range scores {
+		if item, exist := itemCache.Get(id); !exist || !onboarding.Match(item) {
+			continue
+		}
+		filters[""].Push(id, score)
+		for _, category := range itemCache.GetCategory(id) {
+			filters[category].Push(id, score)
+		}
+	}
+	recommend := make(map[string][]string)
+	for category, filter := range filters {
+		recommend[category], _ = filter.PopAll()
+	}
+	return recommend, nil
+}
+
+func (w *Worker) collaborativeRecommendHNSW(rankingIndex *search.HNSW, userId string, userFactor []float32, foldedItems map[string][]float32, itemCategories []string, excludeSet mapset.Set[string], itemCache *ItemCache) (map[string][]string, time.Duration, error) {
 	ctx := context.Background()
-	userIndex := w.RankingModel.GetUserIndex().ToNumber(userId)
 	localStartTime := time.Now()
-	values, scores := rankingIndex.MultiSearch(search.NewDenseVector(w.RankingModel.GetUserFactor(userIndex), nil, false),
+	values, scores := rankingIndex.MultiSearch(search.NewDenseVector(userFactor, nil, false),
 		itemCategories, w.Config.Recommend.CacheSize+excludeSet.Cardinality(), false)
 	// save result
 	recommend := make(map[string][]string)
@@ -944,6 +1403,16 @@ func (w *Worker) collaborativeRecommendHNSW(rankingIndex *search.HNSW, userId st
 				recommendScores = append(recommendScores, float64(scores[category][i]))
 			}
 		}
+		// merge folded items, which are scored by distances as well
+		for itemId, itemFactor := range foldedItems {
+			if !excludeSet.Contains(itemId) && (category == "" || lo.Contains(itemCache.GetCategory(itemId), category)) {
+				recommendItems = append(recommendItems, itemId)
+				recommendScores = append(recommendScores, -float64(floats.Dot(userFactor, itemFactor)))
+			}
+		}
+		if len(foldedItems) > 0 {
+			recommendItems, recommendScores = sortByDistance(recommendItems, recommendScores, w.Config.Recommend.CacheSize)
+		}
 		recommend[category] = recommendItems
 		aggregator.Add(category, recommendItems, recommendScores)
 	}
@@ -959,7 +1428,7 @@ func (w *Worker) collaborativeRecommendHNSW(rankingIndex *search.HNSW, userId st
 	return recommend, usedTime, nil
 }
 
-func (w *Worker) rankByCollaborativeFiltering(userId string, candidates [][]string) ([]cache.Score, error) {
+func (w *Worker) rankByCollaborativeFiltering(userId string, userFactor []float32, foldedItems map[string][]float32, candidates [][]string) ([]cache.Score, error) {
 	// concat candidates
 	memo := mapset.NewSet[string]()
 	var itemIds []string
@@ -976,7 +1445,7 @@ func (w *Worker) rankByCollaborativeFiltering(userId string, candidates [][]stri
 	for _, itemId := range itemIds {
 		topItems = append(topItems, cache.Score{
 			Id:    itemId,
-			Score: float64(w.RankingModel.Predict(userId, itemId)),
+			Score: float64(w.collaborativePredict(userId, userFactor, itemId, foldedItems)),
 		})
 	}
 	cache.SortDocuments(topItems)
@@ -1005,10 +1474,11 @@ func (w *Worker) rankByClickTroughRate(user *data.User, candidates [][]string, i
 			log.Logger().Warn("item doesn't exists in database", zap.String("item_id", itemId))
 		}
 	}
-	// rank by CTR
+	// rank by CTR, scores are calibrated into click-through probabilities if the model ships with a calibrator
+	calibrator := click.GetCalibrator(predictor)
 	topItems := make([]cache.Score, 0, len(items))
 	if batchPredictor, ok := predictor.(click.BatchInference); ok {
-		inputs := make([]lo.Tuple4[string, string, []click.Feature, []click.Feature], len(items))
+		inputs := make([]lo.Tuple5[string, string, []click.Feature, []click.Feature, []click.Feature], len(items))
 		for i, item := range items {
 			inputs[i].A = user.UserId
 			inputs[i].B = item.ItemId
@@ -1026,10 +1496,15 @@ func (w *Worker) rankByClickTroughRate(user *data.User, candidates [][]string, i
 		for _, item := range items {
 			topItems = append(topItems, cache.Score{
 				Id:    item.ItemId,
-				Score: float64(predictor.Predict(user.UserId, item.ItemId, click.ConvertLabelsToFeatures(user.Labels), click.ConvertLabelsToFeatures(item.Labels))),
+				Score: float64(predictor.Predict(user.UserId, item.ItemId, click.ConvertLabelsToFeatures(user.Labels), click.ConvertLabelsToFeatures(item.Labels), nil)),
 			})
 		}
 	}
+	if calibrator != nil {
+		for i := range topItems {
+			topItems[i].Score = float64(calibrator.Calibrate(float32(topItems[i].Score)))
+		}
+	}
 	cache.SortDocuments(topItems)
 	return topItems, nil
 }
@@ -1061,65 +1536,81 @@ func (w *Worker) mergeAndShuffle(candidates [][]string) []cache.Score {
 	return recommend
 }
 
-func (w *Worker) exploreRecommend(exploitRecommend []cache.Score, excludeSet mapset.Set[string], category string) ([]cache.Score, error) {
+// exploreRecommend mixes popular and latest items into exploited items by probabilities of exploration. The
+// probability that each item is picked by exploration or exploitation at its slot is returned as its propensity.
+func (w *Worker) exploreRecommend(cfg *config.Config, exploitRecommend []cache.Score, excludeSet mapset.Set[string], category string) ([]cache.Score, map[string]float64, error) {
 	var localExcludeSet mapset.Set[string]
 	ctx := context.Background()
-	if w.Config.Recommend.Replacement.EnableReplacement {
+	if cfg.Recommend.Replacement.EnableReplacement {
 		localExcludeSet = mapset.NewSet[string]()
 	} else {
 		localExcludeSet = excludeSet.Clone()
 	}
 	// create thresholds
 	explorePopularThreshold := 0.0
-	if threshold, exist := w.Config.Recommend.Offline.GetExploreRecommend("popular"); exist {
+	if threshold, exist := cfg.Recommend.Offline.GetExploreRecommend("popular"); exist {
 		explorePopularThreshold = threshold
 	}
 	exploreLatestThreshold := explorePopularThreshold
-	if threshold, exist := w.Config.Recommend.Offline.GetExploreRecommend("latest"); exist {
+	if threshold, exist := cfg.Recommend.Offline.GetExploreRecommend("latest"); exist {
 		exploreLatestThreshold += threshold
 	}
 	// load popular items
-	popularItems, err := w.CacheClient.SearchScores(ctx, cache.NonPersonalized, cache.Popular, []string{category}, 0, w.Config.Recommend.CacheSize)
+	popularItems, err := w.CacheClient.SearchScores(ctx, cache.NonPersonalized, cache.Popular, []string{category}, 0, cfg.Recommend.CacheSize)
 	if err != nil {
-		return nil, errors.Trace(err)
+		return nil, nil, errors.Trace(err)
 	}
 	// load the latest items
-	latestItems, err := w.CacheClient.SearchScores(ctx, cache.NonPersonalized, cache.Latest, []string{category}, 0, w.Config.Recommend.CacheSize)
+	latestItems, err := w.CacheClient.SearchScores(ctx, cache.NonPersonalized, cache.Latest, []string{category}, 0, cfg.Recommend.CacheSize)
 	if err != nil {
-		return nil, errors.Trace(err)
+		return nil, nil, errors.Trace(err)
 	}
 	// explore recommendation
 	var exploreRecommend []cache.Score
+	propensities := make(map[string]float64)
 	score := 1.0
 	if len(exploitRecommend) > 0 {
 		score += exploitRecommend[0].Score
 	}
 	for range exploitRecommend {
+		// probabilities of branches depend on which candidates are left
+		popularProbability, latestProbability := 0.0, 0.0
+		if len(popularItems) > 0 {
+			popularProbability = explorePopularThreshold
+		}
+		if len(latestItems) > 0 {
+			latestProbability = exploreLatestThreshold - popularProbability
+		}
 		dice := w.randGenerator.Float64()
 		var recommendItem cache.Score
+		var propensity float64
 		if dice < explorePopularThreshold && len(popularItems) > 0 {
 			score -= 1e-5
 			recommendItem.Id = popularItems[0].Id
 			recommendItem.Score = score
 			popularItems = popularItems[1:]
+			propensity = popularProbability
 		} else if dice < exploreLatestThreshold && len(latestItems) > 0 {
 			score -= 1e-5
 			recommendItem.Id = latestItems[0].Id
 			recommendItem.Score = score
 			latestItems = latestItems[1:]
+			propensity = latestProbability
 		} else if len(exploitRecommend) > 0 {
 			recommendItem = exploitRecommend[0]
 			exploitRecommend = exploitRecommend[1:]
 			score = recommendItem.Score
+			propensity = 1 - popularProbability - latestProbability
 		} else {
 			break
 		}
 		if !localExcludeSet.Contains(recommendItem.Id) {
 			localExcludeSet.Add(recommendItem.Id)
 			exploreRecommend = append(exploreRecommend, recommendItem)
+			propensities[recommendItem.Id] = propensity
 		}
 	}
-	return exploreRecommend, nil
+	return exploreRecommend, propensities, nil
 }
 
 func (w *Worker) checkUserActiveTime(ctx context.Context, userId string) bool {
@@ -1157,6 +1648,8 @@ func (w *Worker) checkRecommendCacheTimeout(ctx context.Context, userId string,
 		cacheDigest   string
 		err           error
 	)
+	// apply overrides of experiments
+	userConfig := w.Config.ForUser(userId)
 	// check cache
 	for _, category := range append([]string{""}, categories...) {
 		items, err := w.CacheClient.SearchScores(ctx, cache.OfflineRecommend, userId, []string{category}, 0, -1)
@@ -1175,7 +1668,7 @@ func (w *Worker) checkRecommendCacheTimeout(ctx context.Context, userId string,
 		}
 		return true
 	}
-	if cacheDigest != w.Config.OfflineRecommendDigest() {
+	if cacheDigest != userConfig.OfflineRecommendDigest() {
 		return true
 	}
 	// read active time
@@ -1195,12 +1688,12 @@ func (w *Worker) checkRecommendCacheTimeout(ctx context.Context, userId string,
 		return true
 	}
 	// check cache expire
-	if recommendTime.Before(time.Now().Add(-w.Config.Recommend.CacheExpire)) {
+	if recommendTime.Before(time.Now().Add(-userConfig.Recommend.CacheExpire)) {
 		return true
 	}
 	// check time
 	if activeTime.Before(recommendTime) {
-		timeoutTime := recommendTime.Add(w.Config.Recommend.Offline.RefreshRecommendPeriod)
+		timeoutTime := recommendTime.Add(userConfig.Recommend.Offline.RefreshRecommendPeriod)
 		return timeoutTime.Before(time.Now())
 	}
 	return true
@@ -1236,39 +1729,122 @@ func (w *Worker) pullItems(ctx context.Context) (*ItemCache, []string, error) {
 	return itemCache, itemCategories.ToSlice(), nil
 }
 
-func (w *Worker) pullUsers(peers []string, me string) ([]data.User, error) {
+// pullUsers pulls users owned by this worker. Users are assigned to workers by consistent hashing on the ring of all
+// workers. Users moving to a worker not ready yet are still handled by their owner on the ring of ready workers, and
+// the number of these handoff users is returned as well.
+func (w *Worker) pullUsers(peers, readyPeers []string, me string) ([]data.User, int, error) {
 	ctx := context.Background()
 	// locate me
 	if !funk.ContainsString(peers, me) {
-		return nil, errors.New("current node isn't in worker nodes")
-	}
-	// create consistent hash ring
-	c := consistent.New()
-	for _, peer := range peers {
-		c.Add(peer)
+		return nil, 0, errors.New("current node isn't in worker nodes")
 	}
+	// create consistent hash rings
+	ring := sharding.NewRing(sharding.DefaultVirtualNodes, peers...)
+	readyRing := sharding.NewRing(sharding.DefaultVirtualNodes, readyPeers...)
+	ready := mapset.NewSet(readyPeers...)
 	// pull users from database
 	var users []data.User
+	numHandoffUsers := 0
 	userChan, errChan := w.DataClient.GetUserStream(ctx, batchSize)
 	for batchUsers := range userChan {
 		for _, user := range batchUsers {
-			p, err := c.Get(user.UserId)
-			if err != nil {
-				return nil, errors.Trace(err)
-			}
-			if p == me {
+			if owner := ring.Get(user.UserId); owner == me {
+				users = append(users, user)
+			} else if !ready.Contains(owner) && readyRing.Get(user.UserId) == me {
 				users = append(users, user)
+				numHandoffUsers++
 			}
 		}
 	}
 	if err := <-errChan; err != nil {
-		return nil, errors.Trace(err)
+		return nil, 0, errors.Trace(err)
+	}
+	return users, numHandoffUsers, nil
+}
+
+// pushShard reports the share of users owned by this worker to the master.
+func (w *Worker) pushShard(numUsers, numHandoffUsers int) {
+	// users of tenants are split by the same ring, so workers of tenants follow the shard of the cluster
+	if w.masterClient == nil || w.tenant != "" {
+		return
+	}
+	if _, err := w.masterClient.PushShard(context.Background(), &protocol.Shard{
+		Uuid:            w.workerName,
+		NumUsers:        int64(numUsers),
+		NumHandoffUsers: int64(numHandoffUsers),
+		Ready:           w.ready,
+	}); err != nil {
+		log.Logger().Error("failed to push shard", zap.Error(err))
+	}
+}
+
+// refresh recommendation for users in the real-time refresh queue. Items scanned by the last periodic loop are reused
+// and the periodic loop isn't waited for. Users stay in the queue until items have been scanned.
+func (w *Worker) refresh() {
+	ctx := context.Background()
+	scan := w.lastScan.Load()
+	if scan == nil {
+		return
+	}
+	users, err := w.pullRefreshUsers(ctx)
+	if err != nil {
+		log.Logger().Error("failed to pull users to refresh", zap.Error(err))
+	}
+	if len(users) == 0 {
+		return
+	}
+	log.Logger().Info("real-time refresh recommendation", zap.Int("n_users", len(users)))
+	if err = w.forgetErasedUsers(ctx); err != nil {
+		log.Logger().Error("failed to forget erased users", zap.Error(err))
+	}
+	// rankers of the periodic loop are busy, so the click model is spawned for the refresh
+	var ranker click.FactorizationMachine
+	if w.ClickModel != nil {
+		ranker = click.Spawn(w.ClickModel)
+	}
+	var stats recommendStats
+	userFeedbackCache := NewFeedbackCache(w, w.Config.Recommend.DataSource.PositiveFeedbackTypes...)
+	for _, user := range users {
+		if err = w.recommendUser(ctx, scan, user, ranker, userFeedbackCache, &stats); err != nil {
+			log.Logger().Error("failed to refresh recommendation", zap.String("user_id", user.UserId), zap.Error(err))
+		}
+	}
+}
+
+// pullRefreshUsers pops users ready for real-time refresh from the queue. The queue is ordered by the last time users
+// inserted feedback, so only users who have stopped inserting feedback for the debounce duration are popped and the
+// rest stay in the queue. Popped users refreshed within the minimal interval are pushed back.
+func (w *Worker) pullRefreshUsers(ctx context.Context) ([]data.User, error) {
+	var users []data.User
+	debounceTime := time.Now().Add(-w.Config.Recommend.Offline.RealtimeRefreshDebounce)
+	for {
+		userId, err := w.CacheClient.PopBefore(ctx, cache.RefreshUsers, debounceTime)
+		if errors.Is(err, io.EOF) {
+			return users, nil
+		} else if err != nil {
+			return users, errors.Trace(err)
+		}
+		// limit refresh rate
+		recommendTime, err := w.CacheClient.Get(ctx, cache.Key(cache.LastUpdateUserRecommendTime, userId)).Time()
+		if err == nil && time.Since(recommendTime) < w.Config.Recommend.Offline.RealtimeRefreshInterval {
+			if err = w.CacheClient.Push(ctx, cache.RefreshUsers, userId); err != nil {
+				return users, errors.Trace(err)
+			}
+			continue
+		}
+		user, err := w.DataClient.GetUser(ctx, userId)
+		if err != nil {
+			if !errors.Is(err, errors.NotFound) {
+				log.Logger().Error("failed to load user", zap.String("user_id", userId), zap.Error(err))
+			}
+			continue
+		}
+		users = append(users, user)
 	}
-	return users, nil
 }
 
 // replacement inserts historical items back to recommendation.
-func (w *Worker) replacement(recommend map[string][]cache.Score, user *data.User, feedbacks []data.Feedback, itemCache *ItemCache) (map[string][]cache.Score, error) {
+func (w *Worker) replacement(cfg *config.Config, recommend map[string][]cache.Score, user *data.User, feedbacks []data.Feedback, itemCache *ItemCache) (map[string][]cache.Score, error) {
 	upperBounds := make(map[string]float64)
 	lowerBounds := make(map[string]float64)
 	newRecommend := make(map[string][]cache.Score)
@@ -1292,10 +1868,10 @@ func (w *Worker) replacement(recommend map[string][]cache.Score, user *data.User
 	positiveItems := mapset.NewSet[string]()
 	distinctItems := mapset.NewSet[string]()
 	for _, feedback := range feedbacks {
-		if funk.ContainsString(w.Config.Recommend.DataSource.PositiveFeedbackTypes, feedback.FeedbackType) {
+		if funk.ContainsString(cfg.Recommend.DataSource.PositiveFeedbackTypes, feedback.FeedbackType) {
 			positiveItems.Add(feedback.ItemId)
 			distinctItems.Add(feedback.ItemId)
-		} else if funk.ContainsString(w.Config.Recommend.DataSource.ReadFeedbackTypes, feedback.FeedbackType) {
+		} else if funk.ContainsString(cfg.Recommend.DataSource.ReadFeedbackTypes, feedback.FeedbackType) {
 			distinctItems.Add(feedback.ItemId)
 		}
 	}
@@ -1307,8 +1883,11 @@ func (w *Worker) replacement(recommend map[string][]cache.Score, user *data.User
 			// 2. If collaborative filtering model is available, use it.
 			// 3. Otherwise, give a random score.
 			var score float64
-			if w.Config.Recommend.Offline.EnableClickThroughPrediction && w.ClickModel != nil {
-				score = float64(w.ClickModel.Predict(user.UserId, itemId, click.ConvertLabelsToFeatures(user.Labels), click.ConvertLabelsToFeatures(item.Labels)))
+			if cfg.Recommend.Offline.EnableClickThroughPrediction && w.ClickModel != nil {
+				score = float64(w.ClickModel.Predict(user.UserId, itemId, click.ConvertLabelsToFeatures(user.Labels), click.ConvertLabelsToFeatures(item.Labels), nil))
+				if calibrator := click.GetCalibrator(w.ClickModel); calibrator != nil {
+					score = float64(calibrator.Calibrate(float32(score)))
+				}
 			} else if w.RankingModel != nil && !w.RankingModel.Invalid() && w.RankingModel.IsUserPredictable(w.RankingModel.GetUserIndex().ToNumber(user.UserId)) {
 				score = float64(w.RankingModel.Predict(user.UserId, itemId))
 			} else {
@@ -1330,9 +1909,9 @@ func (w *Worker) replacement(recommend map[string][]cache.Score, user *data.User
 					if score < 0 {
 						continue
 					} else if positiveItems.Contains(itemId) {
-						score *= w.Config.Recommend.Replacement.PositiveReplacementDecay
+						score *= cfg.Recommend.Replacement.PositiveReplacementDecay
 					} else {
-						score *= w.Config.Recommend.Replacement.ReadReplacementDecay
+						score *= cfg.Recommend.Replacement.ReadReplacementDecay
 					}
 					score += lowerBound
 				}
@@ -1409,10 +1988,10 @@ func (c *ItemCache) GetCategory(itemId string) []string {
 	}
 }
 
-// IsAvailable means the item exists in database and is not hidden.
+// IsAvailable means the item exists in database and is visible.
 func (c *ItemCache) IsAvailable(itemId string) bool {
 	if item, exist := c.Data[itemId]; exist {
-		return !item.IsHidden
+		return item.IsVisible()
 	} else {
 		return false
 	}
@@ -1441,27 +2020,30 @@ func NewFeedbackCache(worker *Worker, feedbackTypes ...string) *FeedbackCache {
 	}
 }
 
-// GetUserFeedback gets user feedback from cache or database.
-func (c *FeedbackCache) GetUserFeedback(ctx context.Context, userId string) ([]string, error) {
+// GetUserFeedback gets items and confidences of user feedback from cache or database.
+func (c *FeedbackCache) GetUserFeedback(ctx context.Context, userId string) ([]string, []float64, error) {
 	if tmp, ok := c.Cache.Get(userId); ok {
-		return tmp.([]string), nil
+		entry := tmp.(lo.Tuple2[[]string, []float64])
+		return entry.A, entry.B, nil
 	} else {
 		items := make([]string, 0)
+		confidences := make([]float64, 0)
 		feedbacks, err := c.Client.GetUserFeedback(ctx, userId, c.Config.Now(), c.Types...)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 		for _, feedback := range feedbacks {
 			items = append(items, feedback.ItemId)
+			confidences = append(confidences, c.Config.Recommend.DataSource.Confidence(feedback.FeedbackType, feedback.Value))
 			c.ByteCount += reflect.TypeOf(rune(0)).Size() * uintptr(len(feedback.FeedbackType))
 			c.ByteCount += reflect.TypeOf(rune(0)).Size() * uintptr(len(feedback.UserId))
 			c.ByteCount += reflect.TypeOf(rune(0)).Size() * uintptr(len(feedback.ItemId))
 			c.ByteCount += reflect.TypeOf(rune(0)).Size() * uintptr(len(feedback.Comment))
 		}
-		c.Cache.Set(userId, items)
+		c.Cache.Set(userId, lo.Tuple2[[]string, []float64]{A: items, B: confidences})
 		c.ByteCount += reflect.TypeOf(feedbacks).Elem().Size() * uintptr(len(feedbacks))
 		c.ByteCount += reflect.TypeOf(rune(0)).Size() * uintptr(len(userId))
-		return items, nil
+		return items, confidences, nil
 	}
 }
 
@@ -37,15 +37,19 @@ import (
 	"github.com/zhenghaoz/gorse/base"
 	"github.com/zhenghaoz/gorse/base/parallel"
 	"github.com/zhenghaoz/gorse/base/progress"
+	"github.com/zhenghaoz/gorse/common/nn"
 	"github.com/zhenghaoz/gorse/config"
+	"github.com/zhenghaoz/gorse/logics"
 	"github.com/zhenghaoz/gorse/model"
 	"github.com/zhenghaoz/gorse/model/click"
 	"github.com/zhenghaoz/gorse/model/ranking"
 	"github.com/zhenghaoz/gorse/protocol"
+	"github.com/zhenghaoz/gorse/storage/blob"
 	"github.com/zhenghaoz/gorse/storage/cache"
 	"github.com/zhenghaoz/gorse/storage/data"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/protobuf/proto"
 )
 
@@ -87,8 +91,12 @@ func (suite *WorkerTestSuite) SetupTest() {
 	suite.jobs = 1
 	// reset random generator
 	suite.randGenerator = rand.New(rand.NewSource(0))
-	// reset index
+	// reset model and index
+	suite.RankingModel = nil
 	suite.rankingIndex = nil
+	suite.lastScan.Store(nil)
+	suite.foldInCache = NewFoldInCache(foldInCacheCapacity)
+	suite.shadow = NewShadowEvaluator()
 }
 
 func (suite *WorkerTestSuite) TestPullUsers() {
@@ -108,11 +116,42 @@ func (suite *WorkerTestSuite) TestPullUsers() {
 	// create nodes
 	nodes := []string{"a", "b", "c"}
 
-	users, err := suite.pullUsers(nodes, "b")
-	suite.NoError(err)
-	suite.Equal([]data.User{{UserId: "1"}, {UserId: "3"}, {UserId: "6"}}, users)
+	// users are split between ready workers
+	owners := make(map[string]string)
+	for _, node := range nodes {
+		users, numHandoffUsers, err := suite.pullUsers(nodes, nodes, node)
+		suite.NoError(err)
+		suite.Zero(numHandoffUsers)
+		for _, user := range users {
+			suite.NotContains(owners, user.UserId)
+			owners[user.UserId] = node
+		}
+	}
+	suite.Len(owners, 8)
+
+	// users moving to a joining worker are still handled by previous owners
+	joined := append(nodes, "d")
+	users, numHandoffUsers, err := suite.pullUsers(joined, nodes, "d")
+	suite.NoError(err)
+	suite.Zero(numHandoffUsers)
+	suite.NotEmpty(users)
+	handoff := 0
+	for _, user := range users {
+		pulled, n, err := suite.pullUsers(joined, nodes, owners[user.UserId])
+		suite.NoError(err)
+		suite.Contains(pulled, user)
+		handoff += n
+	}
+	suite.Equal(len(users), handoff)
 
-	_, err = suite.pullUsers(nodes, "d")
+	// users are released once the joining worker is ready
+	for _, node := range nodes {
+		_, numHandoffUsers, err = suite.pullUsers(joined, joined, node)
+		suite.NoError(err)
+		suite.Zero(numHandoffUsers)
+	}
+
+	_, _, err = suite.pullUsers(nodes, nodes, "d")
 	suite.Error(err)
 }
 
@@ -143,6 +182,98 @@ func (suite *WorkerTestSuite) TestCheckRecommendCacheTimeout() {
 	suite.True(suite.checkRecommendCacheTimeout(ctx, "0", nil))
 }
 
+func (suite *WorkerTestSuite) TestPullRefreshUsers() {
+	ctx := context.Background()
+	suite.Config.Recommend.Offline.RealtimeRefreshDebounce = 100 * time.Millisecond
+	suite.Config.Recommend.Offline.RealtimeRefreshInterval = time.Hour
+	err := suite.DataClient.BatchInsertUsers(ctx, []data.User{{UserId: "1"}, {UserId: "2"}, {UserId: "3"}})
+	suite.NoError(err)
+	// user 3 was refreshed recently
+	err = suite.CacheClient.Set(ctx, cache.Time(cache.Key(cache.LastUpdateUserRecommendTime, "3"), time.Now().Add(-time.Minute)))
+	suite.NoError(err)
+	for _, userId := range []string{"1", "2", "3", "4"} {
+		suite.NoError(suite.CacheClient.Push(ctx, cache.RefreshUsers, userId))
+	}
+	// user 2 is inserting feedback
+	time.Sleep(200 * time.Millisecond)
+	suite.NoError(suite.CacheClient.Push(ctx, cache.RefreshUsers, "2"))
+	users, err := suite.pullRefreshUsers(ctx)
+	suite.NoError(err)
+	suite.Equal([]string{"1"}, lo.Map(users, func(user data.User, _ int) string { return user.UserId }))
+	// user 2 stays in the queue and user 3 is pushed back
+	remain, err := suite.CacheClient.Remain(ctx, cache.RefreshUsers)
+	suite.NoError(err)
+	suite.Equal(int64(2), remain)
+	value, err := suite.CacheClient.Pop(ctx, cache.RefreshUsers)
+	suite.NoError(err)
+	suite.Equal("2", value)
+}
+
+func (suite *WorkerTestSuite) TestForgetErasedUsers() {
+	ctx := context.Background()
+	m := newFoldInModel()
+	suite.foldInCache.UserFactor(m, "erased_1", []string{"0"})
+	suite.foldInCache.UserFactor(m, "erased_2", []string{"1"})
+	suite.foldInCache.UserFactor(m, "active", []string{"2"})
+	erasedTime := time.Now()
+	err := suite.CacheClient.AddScores(ctx, cache.Erasures, "", []cache.Score{
+		{Id: "erased_1", Score: float64(erasedTime.Unix()), Categories: []string{""}, Timestamp: erasedTime},
+	})
+	suite.NoError(err)
+
+	// folded factors of erased users are dropped
+	suite.NoError(suite.forgetErasedUsers(ctx))
+	numUsers, _ := suite.foldInCache.Len()
+	suite.Equal(2, numUsers)
+	suite.Nil(suite.foldInCache.users.Get("erased_1"))
+	suite.Equal(float64(erasedTime.Unix()), suite.foldInCache.ErasedSince())
+
+	// users erased since the last check are dropped
+	err = suite.CacheClient.AddScores(ctx, cache.Erasures, "", []cache.Score{
+		{Id: "erased_2", Score: float64(erasedTime.Unix() + 1), Categories: []string{""}, Timestamp: erasedTime},
+	})
+	suite.NoError(err)
+	suite.NoError(suite.forgetErasedUsers(ctx))
+	numUsers, _ = suite.foldInCache.Len()
+	suite.Equal(1, numUsers)
+	suite.NotNil(suite.foldInCache.users.Get("active"))
+}
+
+func (suite *WorkerTestSuite) TestRefresh() {
+	ctx := context.Background()
+	suite.Config.Recommend.Offline.EnableColRecommend = true
+	suite.Config.Recommend.Collaborative.EnableIndex = false
+	suite.Config.Recommend.Offline.RealtimeRefreshDebounce = 0
+	err := suite.DataClient.BatchInsertItems(ctx, lo.Map(lo.Range(6), func(i int, _ int) data.Item {
+		return data.Item{ItemId: strconv.Itoa(i)}
+	}))
+	suite.NoError(err)
+	err = suite.DataClient.BatchInsertFeedback(ctx, lo.Map([]string{"6", "7", "8", "9"}, func(itemId string, _ int) data.Feedback {
+		return data.Feedback{FeedbackKey: data.FeedbackKey{FeedbackType: "click", UserId: "0", ItemId: itemId}, Timestamp: time.Now().Add(-time.Hour)}
+	}), true, true, true)
+	suite.NoError(err)
+	suite.RankingModel = newMockMatrixFactorizationForRecommend(1, 12)
+	suite.NoError(suite.CacheClient.Push(ctx, cache.RefreshUsers, "0"))
+
+	// users stay in the queue until items are scanned
+	suite.refresh()
+	remain, err := suite.CacheClient.Remain(ctx, cache.RefreshUsers)
+	suite.NoError(err)
+	suite.Equal(int64(1), remain)
+
+	// items scanned by the periodic loop are reused and the periodic loop isn't waited for
+	suite.Recommend(nil)
+	suite.recommendMutex.Lock()
+	defer suite.recommendMutex.Unlock()
+	suite.refresh()
+	remain, err = suite.CacheClient.Remain(ctx, cache.RefreshUsers)
+	suite.NoError(err)
+	suite.Zero(remain)
+	recommends, err := suite.CacheClient.SearchScores(ctx, cache.OfflineRecommend, "0", []string{""}, 0, -1)
+	suite.NoError(err)
+	suite.Equal([]string{"5", "4", "3", "2", "1", "0"}, lo.Map(recommends, func(score cache.Score, _ int) string { return score.Id }))
+}
+
 type mockMatrixFactorizationForRecommend struct {
 	ranking.BaseMatrixFactorization
 }
@@ -202,6 +333,100 @@ func (m *mockMatrixFactorizationForRecommend) GetParamsGrid(_ bool) model.Params
 	panic("don't call me")
 }
 
+func (suite *WorkerTestSuite) TestFoldInItems() {
+	ctx := context.Background()
+	suite.Config.Recommend.DataSource.PositiveFeedbackTypes = []string{"star"}
+	suite.RankingModel = newFoldInModel()
+	now := time.Now()
+	err := suite.CacheClient.Set(ctx, cache.Time(cache.Key(cache.GlobalMeta, cache.LastFitMatchingModelTime), now))
+	suite.NoError(err)
+	itemCache := NewItemCache()
+	itemCache.Set("0", data.Item{ItemId: "0", Timestamp: now})
+	itemCache.Set("new", data.Item{ItemId: "new", Timestamp: now})
+	itemCache.Set("old", data.Item{ItemId: "old", Timestamp: now.Add(-24 * time.Hour)})
+	itemCache.Set("hidden", data.Item{ItemId: "hidden", Timestamp: now, IsHidden: true})
+	itemCache.Set("cold", data.Item{ItemId: "cold", Timestamp: now})
+	var feedback []data.Feedback
+	for _, itemId := range []string{"new", "old", "hidden"} {
+		for _, userId := range []string{"0", "1"} {
+			feedback = append(feedback, data.Feedback{FeedbackKey: data.FeedbackKey{
+				FeedbackType: "star", UserId: userId, ItemId: itemId}, Timestamp: now})
+		}
+	}
+	feedback = append(feedback, data.Feedback{FeedbackKey: data.FeedbackKey{
+		FeedbackType: "read", UserId: "2", ItemId: "new"}, Timestamp: now})
+	err = suite.DataClient.BatchInsertFeedback(ctx, feedback, true, true, true)
+	suite.NoError(err)
+
+	// only recent items absent from the last fit are folded in
+	foldedItems, err := suite.foldInItems(ctx, itemCache)
+	suite.NoError(err)
+	suite.Equal([]string{"new"}, lo.Keys(foldedItems))
+	suite.Equal(suite.foldInCache.ItemFactor(suite.RankingModel, "new", []string{"0", "1"}), foldedItems["new"])
+}
+
+func (suite *WorkerTestSuite) TestOnboardingRecommend() {
+	ctx := context.Background()
+	suite.Config.Recommend.CacheSize = 4
+	suite.Config.Recommend.Onboarding.Weight = 0.5
+	itemCache := NewItemCache()
+	for i := 0; i < 10; i++ {
+		item := data.Item{ItemId: strconv.Itoa(i)}
+		switch i {
+		case 5, 8:
+			item.Categories = []string{"a"}
+		case 9:
+			item.Categories = []string{"b"}
+		}
+		itemCache.Set(item.ItemId, item)
+	}
+	excludeSet := mapset.NewSet("1", "3")
+
+	// users without onboarding
+	recommend, err := suite.onboardingRecommend(ctx, suite.Config, "0", nil, []string{"a", "b"}, excludeSet, itemCache)
+	suite.NoError(err)
+	suite.Empty(recommend)
+	err = logics.SaveOnboarding(ctx, suite.CacheClient, "0", &logics.Onboarding{Items: []string{"1", "3"}, Categories: []string{"a"}})
+	suite.NoError(err)
+	// similar items of seed items
+	err = suite.CacheClient.AddScores(ctx, cache.ItemNeighbors, "1", []cache.Score{
+		{Id: "2", Score: 1, Categories: []string{""}},
+		{Id: "5", Score: 2, Categories: []string{""}}})
+	suite.NoError(err)
+	recommend, err = suite.onboardingRecommend(ctx, suite.Config, "0", nil, []string{"a", "b"}, excludeSet, itemCache)
+	suite.NoError(err)
+	suite.Equal(map[string][]string{"": {"5"}, "a": {"5"}, "b": {}}, recommend)
+	// items matching the taste vector
+	suite.Config.Recommend.ImageEmbeddings.EmbeddingLabel = "embedding"
+	suite.Config.Recommend.ImageEmbeddings.EmbeddingDim = 1
+	for i := 0; i < 10; i++ {
+		item, _ := itemCache.Get(strconv.Itoa(i))
+		item.Labels = map[string]any{"embedding": []any{float64(i)}}
+	}
+	err = logics.SaveOnboarding(ctx, suite.CacheClient, "0", &logics.Onboarding{Items: []string{"1", "3"}, Categories: []string{"a"},
+		TasteVector: []float32{2}})
+	suite.NoError(err)
+	recommend, err = suite.onboardingRecommend(ctx, suite.Config, "0", nil, []string{"a", "b"}, excludeSet, itemCache)
+	suite.NoError(err)
+	suite.Equal(map[string][]string{"": {"8", "5"}, "a": {"8", "5"}, "b": {}}, recommend)
+	// latest and popular items matching preferences if no seed item is picked
+	err = logics.SaveOnboarding(ctx, suite.CacheClient, "0", &logics.Onboarding{Categories: []string{"a"}})
+	suite.NoError(err)
+	err = suite.CacheClient.AddScores(ctx, cache.NonPersonalized, cache.Latest, []cache.Score{
+		{Id: "9", Score: 3, Categories: []string{""}},
+		{Id: "8", Score: 2, Categories: []string{""}},
+		{Id: "1", Score: 1, Categories: []string{""}}})
+	suite.NoError(err)
+	err = suite.CacheClient.AddScores(ctx, cache.NonPersonalized, cache.Popular, []cache.Score{
+		{Id: "5", Score: 10, Categories: []string{""}},
+		{Id: "0", Score: 8, Categories: []string{""}},
+		{Id: "8", Score: 5, Categories: []string{""}}})
+	suite.NoError(err)
+	recommend, err = suite.onboardingRecommend(ctx, suite.Config, "0", nil, []string{"a", "b"}, excludeSet, itemCache)
+	suite.NoError(err)
+	suite.Equal(map[string][]string{"": {"5", "8"}, "a": {"5", "8"}, "b": {}}, recommend)
+}
+
 func (suite *WorkerTestSuite) TestRecommendMatrixFactorizationBruteForce() {
 	ctx := context.Background()
 	suite.Config.Recommend.Offline.EnableColRecommend = true
@@ -597,7 +822,7 @@ func (suite *WorkerTestSuite) TestExploreRecommend() {
 	err = suite.CacheClient.AddScores(ctx, cache.NonPersonalized, cache.Latest, []cache.Score{{Id: "latest", Score: 0, Categories: []string{""}, Timestamp: time.Now()}})
 	suite.NoError(err)
 
-	recommend, err := suite.exploreRecommend([]cache.Score{
+	recommend, propensities, err := suite.exploreRecommend(suite.Config, []cache.Score{
 		{Id: "8", Score: 8},
 		{Id: "7", Score: 7},
 		{Id: "6", Score: 6},
@@ -618,6 +843,19 @@ func (suite *WorkerTestSuite) TestExploreRecommend() {
 	scores := lo.Map(recommend, func(d cache.Score, _ int) float64 { return d.Score })
 	suite.IsDecreasing(scores)
 	suite.Equal(8, len(recommend))
+	// propensities are probabilities of branches at slots
+	suite.Len(propensities, 8)
+	suite.InDelta(0.3, propensities["popular"], 1e-9)
+	// exploration and exploitation are more likely once explored candidates run out
+	isOneOf := func(propensity float64, candidates ...float64) bool {
+		return lo.ContainsBy(candidates, func(candidate float64) bool {
+			return candidate-1e-9 < propensity && propensity < candidate+1e-9
+		})
+	}
+	suite.True(isOneOf(propensities["latest"], 0.3, 0.6))
+	for _, item := range items {
+		suite.True(isOneOf(propensities[item], 0.4, 0.7, 1), "unexpected propensity %v", propensities[item])
+	}
 }
 
 func marshal(t *testing.T, v interface{}) string {
@@ -649,8 +887,13 @@ type mockMaster struct {
 	dataFilePath  string
 	meta          *protocol.Meta
 	rankingModel  []byte
+	rankingDelta  []byte
 	clickModel    []byte
+	twoTowerModel []byte
 	userIndex     []byte
+	blobStore     *blob.MasterStoreServer
+	watch         bool
+	tenants       chan string // tenants in metadata of meta requests
 }
 
 func newMockMaster(t *testing.T) *mockMaster {
@@ -673,31 +916,70 @@ func newMockMaster(t *testing.T) *mockMaster {
 	rankingModelBuffer := bytes.NewBuffer(nil)
 	err = ranking.MarshalModel(rankingModelBuffer, bpr)
 	assert.NoError(t, err)
+	rankingDeltaBuffer := bytes.NewBuffer(nil)
+	err = ranking.MarshalModelDelta(rankingDeltaBuffer, bpr, bpr)
+	assert.NoError(t, err)
+
+	// create two-tower model
+	twoTower := nn.NewTwoTower(model.Params{model.NEpochs: 0, model.NFactors: 16})
+	twoTower.Fit(context.Background(), []nn.TowerInput{{Id: "1"}}, []nn.TowerInput{{Id: "1"}}, []int32{0}, []int32{0})
+	twoTowerModelBuffer := bytes.NewBuffer(nil)
+	err = twoTower.Marshal(twoTowerModelBuffer)
+	assert.NoError(t, err)
 
 	// create user index
 	userIndexBuffer := bytes.NewBuffer(nil)
 	err = base.MarshalIndex(userIndexBuffer, base.NewMapIndex())
 	assert.NoError(t, err)
 
+	// store candidate ranking model
+	blobStore := blob.NewMasterStoreServer(t.TempDir())
+	err = blobStore.WriteBlob(blob.ModelBlobName(blob.RankingModelType, 4), func(w io.Writer) error {
+		return ranking.MarshalModel(w, bpr)
+	})
+	assert.NoError(t, err)
+
 	return &mockMaster{
 		addr: make(chan string),
 		meta: &protocol.Meta{
-			Config:              marshal(t, cfg),
-			ClickModelVersion:   1,
-			RankingModelVersion: 2,
+			Config:                    marshal(t, cfg),
+			ClickModelVersion:         1,
+			RankingModelVersion:       2,
+			TwoTowerModelVersion:      3,
+			ShadowRankingModelVersion: 4,
 		},
 		cacheFilePath: cfg.Database.CacheStore,
 		dataFilePath:  cfg.Database.DataStore,
 		userIndex:     userIndexBuffer.Bytes(),
 		clickModel:    clickModelBuffer.Bytes(),
 		rankingModel:  rankingModelBuffer.Bytes(),
+		rankingDelta:  rankingDeltaBuffer.Bytes(),
+		twoTowerModel: twoTowerModelBuffer.Bytes(),
+		blobStore:     blobStore,
 	}
 }
 
-func (m *mockMaster) GetMeta(_ context.Context, _ *protocol.NodeInfo) (*protocol.Meta, error) {
+func (m *mockMaster) GetMeta(ctx context.Context, _ *protocol.NodeInfo) (*protocol.Meta, error) {
+	if m.tenants != nil {
+		md, _ := metadata.FromIncomingContext(ctx)
+		for _, tenant := range md.Get(protocol.MetadataTenant) {
+			m.tenants <- tenant
+		}
+	}
 	return m.meta, nil
 }
 
+func (m *mockMaster) WatchMeta(nodeInfo *protocol.NodeInfo, sender protocol.Master_WatchMetaServer) error {
+	if !m.watch {
+		return m.UnimplementedMasterServer.WatchMeta(nodeInfo, sender)
+	}
+	return sender.Send(m.meta)
+}
+
+func (m *mockMaster) GetRankingModelDelta(_ *protocol.DeltaInfo, sender protocol.Master_GetRankingModelDeltaServer) error {
+	return sender.Send(&protocol.Fragment{Data: m.rankingDelta})
+}
+
 func (m *mockMaster) GetRankingModel(_ *protocol.VersionInfo, sender protocol.Master_GetRankingModelServer) error {
 	return sender.Send(&protocol.Fragment{Data: m.rankingModel})
 }
@@ -706,6 +988,10 @@ func (m *mockMaster) GetClickModel(_ *protocol.VersionInfo, sender protocol.Mast
 	return sender.Send(&protocol.Fragment{Data: m.clickModel})
 }
 
+func (m *mockMaster) GetTwoTowerModel(_ *protocol.VersionInfo, sender protocol.Master_GetTwoTowerModelServer) error {
+	return sender.Send(&protocol.Fragment{Data: m.twoTowerModel})
+}
+
 func (m *mockMaster) Start(t *testing.T) {
 	listen, err := net.Listen("tcp", "localhost:0")
 	assert.NoError(t, err)
@@ -713,6 +999,7 @@ func (m *mockMaster) Start(t *testing.T) {
 	var opts []grpc.ServerOption
 	m.grpcServer = grpc.NewServer(opts...)
 	protocol.RegisterMasterServer(m.grpcServer, m)
+	protocol.RegisterBlobStoreServer(m.grpcServer, m.blobStore)
 	err = m.grpcServer.Serve(listen)
 	assert.NoError(t, err)
 }
@@ -728,11 +1015,14 @@ func TestWorker_Sync(t *testing.T) {
 	conn, err := grpc.Dial(address, grpc.WithTransportCredentials(insecure.NewCredentials()))
 	assert.NoError(t, err)
 	serv := &Worker{
-		Settings:     config.NewSettings(),
-		testMode:     true,
-		masterClient: protocol.NewMasterClient(conn),
-		syncedChan:   parallel.NewConditionChannel(),
-		ticker:       time.NewTicker(time.Minute),
+		Settings:      config.NewSettings(),
+		testMode:      true,
+		masterClient:  protocol.NewMasterClient(conn),
+		blobClient:    blob.NewMasterStoreClient(conn),
+		shadow:        NewShadowEvaluator(),
+		syncedChan:    parallel.NewConditionChannel(),
+		ticker:        time.NewTicker(time.Minute),
+		refreshTicker: time.NewTicker(time.Second),
 	}
 
 	// This clause is used to test race condition.
@@ -756,15 +1046,91 @@ func TestWorker_Sync(t *testing.T) {
 	assert.NoError(t, serv.CacheClient.Close())
 	assert.Equal(t, int64(1), serv.latestClickModelVersion)
 	assert.Equal(t, int64(2), serv.latestRankingModelVersion)
+	assert.Equal(t, int64(3), serv.latestTwoTowerModelVersion)
 	assert.Zero(t, serv.ClickModelVersion)
 	assert.Zero(t, serv.RankingModelVersion)
 	serv.Pull()
 	assert.Equal(t, int64(1), serv.ClickModelVersion)
 	assert.Equal(t, int64(2), serv.RankingModelVersion)
+	twoTowerModel, twoTowerModelVersion := serv.LoadTwoTowerModel()
+	assert.False(t, twoTowerModel.Invalid())
+	assert.Equal(t, int64(3), twoTowerModelVersion)
+	assert.Equal(t, int64(4), serv.shadow.RankingModelVersion())
+	assert.Zero(t, serv.shadow.ClickModelVersion())
 	master.Stop()
 	done <- struct{}{}
 }
 
+func TestWorker_WatchMeta(t *testing.T) {
+	master := newMockMaster(t)
+	master.watch = true
+	go master.Start(t)
+	address := <-master.addr
+	conn, err := grpc.Dial(address, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	assert.NoError(t, err)
+	serv := &Worker{
+		Settings:      config.NewSettings(),
+		testMode:      true,
+		masterClient:  protocol.NewMasterClient(conn),
+		blobClient:    blob.NewMasterStoreClient(conn),
+		shadow:        NewShadowEvaluator(),
+		syncedChan:    parallel.NewConditionChannel(),
+		ticker:        time.NewTicker(time.Minute),
+		refreshTicker: time.NewTicker(time.Second),
+	}
+	serv.Sync()
+	assert.Equal(t, master.dataFilePath, serv.dataPath)
+	assert.Equal(t, master.cacheFilePath, serv.cachePath)
+	assert.NoError(t, serv.DataClient.Close())
+	assert.NoError(t, serv.CacheClient.Close())
+	assert.Equal(t, int64(1), serv.latestClickModelVersion)
+	assert.Equal(t, int64(2), serv.latestRankingModelVersion)
+
+	// pull ranking model delta
+	serv.RankingModel, err = ranking.UnmarshalModel(bytes.NewReader(master.rankingModel))
+	assert.NoError(t, err)
+	serv.RankingModelVersion = 1
+	master.rankingModel = nil
+	serv.Pull()
+	assert.Equal(t, int64(1), serv.ClickModelVersion)
+	assert.Equal(t, int64(2), serv.RankingModelVersion)
+	master.Stop()
+}
+
+func TestWorker_SyncTenants(t *testing.T) {
+	master := newMockMaster(t)
+	master.tenants = make(chan string, 10)
+	go master.Start(t)
+	defer master.Stop()
+	host, port, err := net.SplitHostPort(<-master.addr)
+	assert.NoError(t, err)
+	masterPort, err := strconv.Atoi(port)
+	assert.NoError(t, err)
+	w := NewWorker(host, masterPort, "localhost", 0, 1, "", false, nil)
+	w.Config.Master.MetaTimeout = time.Second
+
+	// start workers of tenants
+	w.Config.Tenants = []config.TenantConfig{{Name: "eu", TablePrefix: "eu_"}}
+	w.syncTenants()
+	tw, exist := w.tenants["eu"]
+	if assert.True(t, exist) {
+		assert.Equal(t, "eu", tw.tenant)
+		select {
+		case tenant := <-master.tenants:
+			assert.Equal(t, "eu", tenant)
+		case <-time.After(10 * time.Second):
+			assert.Fail(t, "meta of tenant not requested")
+		}
+	}
+
+	// stop workers of deleted tenants
+	w.Config.Tenants = nil
+	w.syncTenants()
+	assert.Empty(t, w.tenants)
+	_, open := <-tw.done
+	assert.False(t, open)
+}
+
 func TestWorker_SyncRecommend(t *testing.T) {
 	cfg := config.GetDefaultConfig()
 	cfg.Recommend.Offline.ExploreRecommend = map[string]float64{"popular": 0.5}
@@ -775,12 +1141,15 @@ func TestWorker_SyncRecommend(t *testing.T) {
 	conn, err := grpc.Dial(address, grpc.WithTransportCredentials(insecure.NewCredentials()))
 	assert.NoError(t, err)
 	worker := &Worker{
-		Settings:     config.NewSettings(),
-		jobs:         1,
-		testMode:     true,
-		masterClient: protocol.NewMasterClient(conn),
-		syncedChan:   parallel.NewConditionChannel(),
-		ticker:       time.NewTicker(time.Minute),
+		Settings:      config.NewSettings(),
+		jobs:          1,
+		testMode:      true,
+		masterClient:  protocol.NewMasterClient(conn),
+		blobClient:    blob.NewMasterStoreClient(conn),
+		shadow:        NewShadowEvaluator(),
+		syncedChan:    parallel.NewConditionChannel(),
+		ticker:        time.NewTicker(time.Minute),
+		refreshTicker: time.NewTicker(time.Second),
 	}
 	worker.Sync()
 
@@ -838,7 +1207,7 @@ func (m mockFactorizationMachine) Invalid() bool {
 	return false
 }
 
-func (m mockFactorizationMachine) Predict(_, itemId string, _, _ []click.Feature) float32 {
+func (m mockFactorizationMachine) Predict(_, itemId string, _, _, _ []click.Feature) float32 {
 	score, err := strconv.Atoi(itemId)
 	if err != nil {
 		panic(err)
@@ -870,7 +1239,7 @@ func (suite *WorkerTestSuite) TestRankByCollaborativeFiltering() {
 	}
 	// rank items
 	suite.RankingModel = newMockMatrixFactorizationForRecommend(10, 10)
-	result, err := suite.rankByCollaborativeFiltering("1", [][]string{{"1", "2", "3", "4", "5"}})
+	result, err := suite.rankByCollaborativeFiltering("1", []float32{1}, nil, [][]string{{"1", "2", "3", "4", "5"}})
 	suite.NoError(err)
 	suite.Equal([]string{"5", "4", "3", "2", "1"}, lo.Map(result, func(d cache.Score, _ int) string {
 		return d.Id
@@ -97,6 +97,7 @@ go build -o ./bin/gorse-server ./cmd/gorse-server
 go build -o ./bin/gorse-worker ./cmd/gorse-worker
 go build -o ./bin/gorse-master ./cmd/gorse-master
 go build -o ./bin/gorse-in-one ./cmd/gorse-in-one
+go build -o ./bin/gorse-migrate ./cmd/gorse-migrate
 
 # Pull required Docker images
 echo "🐳 Pulling Docker images..."